@@ -127,3 +127,9 @@ func CanViewPlayerRecords(permissions *bitperms.Permissions) (bool, error) {
 
 	return false, nil
 }
+
+// CanViewInfraction checks if a user can view infraction records. Users who can view player records are also allowed to
+// view infractions since infractions are so closely tied to the players they were issued to.
+func CanViewInfraction(permissions *bitperms.Permissions) (bool, error) {
+	return HasOneOfPermissions(true, perms.FlagViewPlayerRecords, perms.FlagViewInfractionRecords)(permissions)
+}
@@ -0,0 +1,97 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package domain
+
+import (
+	"context"
+	"github.com/guregu/null"
+	"time"
+)
+
+// Alt account detection signals
+const (
+	AltSignalSharedName     = "SHARED_NAME"
+	AltSignalSessionHandoff = "SESSION_HANDOFF"
+	AltSignalBanEvasion     = "BAN_EVASION"
+)
+
+// AltCandidate is a player who matched an alt account detection signal. Count is the number of times they matched.
+type AltCandidate struct {
+	PlayerID string
+	Platform string
+	Count    int
+}
+
+// PossibleLink is a player who may be an alt account of another player. Score ranges from 0 to 100, and Signals holds
+// the number of matches for each signal which contributed to it. Confirmed links always have a score of 100.
+type PossibleLink struct {
+	PlayerID    string         `json:"player_id"`
+	Platform    string         `json:"platform"`
+	CurrentName string         `json:"current_name"`
+	Score       int            `json:"score"`
+	Signals     map[string]int `json:"signals"`
+	Confirmed   bool           `json:"confirmed"`
+	LinkID      int64          `json:"link_id,omitempty"`
+}
+
+// PlayerLink is a staff confirmed link between two player accounts which belong to the same person.
+type PlayerLink struct {
+	LinkID         int64       `json:"id"`
+	PlayerID       string      `json:"player_id"`
+	Platform       string      `json:"platform"`
+	LinkedPlayerID string      `json:"linked_player_id"`
+	LinkedPlatform string      `json:"linked_platform"`
+	UserID         string      `json:"user_id"` // UserID is the ID of the user who confirmed the link
+	Note           null.String `json:"note"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+// Other returns the platform and player ID on the other side of the link from the provided player.
+func (l *PlayerLink) Other(platform, playerID string) (string, string) {
+	if l.Platform == platform && l.PlayerID == playerID {
+		return l.LinkedPlatform, l.LinkedPlayerID
+	}
+
+	return l.Platform, l.PlayerID
+}
+
+type AltRepo interface {
+	// GetSharedNameCandidates returns players who have used any of the names the provided player has used.
+	GetSharedNameCandidates(ctx context.Context, platform, playerID string, limit int) ([]*AltCandidate, error)
+
+	// GetSessionHandoffCandidates returns players who joined a server within window of the provided player quitting
+	// it, or the other way around.
+	GetSessionHandoffCandidates(ctx context.Context, platform, playerID string, window time.Duration, limit int) ([]*AltCandidate, error)
+
+	// GetBanEvasionCandidates returns players who were first seen on a server within window of the provided player
+	// being banned there, or players who were banned on a server within window before the provided player was first
+	// seen there.
+	GetBanEvasionCandidates(ctx context.Context, platform, playerID string, window time.Duration, limit int) ([]*AltCandidate, error)
+
+	StoreLink(ctx context.Context, link *PlayerLink) error
+	GetLinkByID(ctx context.Context, id int64) (*PlayerLink, error)
+	GetLinks(ctx context.Context, platform, playerID string) ([]*PlayerLink, error)
+	DeleteLink(ctx context.Context, id int64) error
+}
+
+type AltService interface {
+	GetPossibleLinks(c context.Context, platform, playerID string) ([]*PossibleLink, error)
+	GetLinks(c context.Context, platform, playerID string) ([]*PlayerLink, error)
+	ConfirmLink(c context.Context, platform, playerID, linkedPlatform, linkedPlayerID, note string) (*PlayerLink, error)
+	RemoveLink(c context.Context, platform, playerID string, linkID int64) error
+}
@@ -0,0 +1,80 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package domain
+
+import (
+	"context"
+	"github.com/guregu/null"
+)
+
+const (
+	AppealStatusOpen     = "open"
+	AppealStatusAccepted = "accepted"
+	AppealStatusRejected = "rejected"
+)
+
+type Appeal struct {
+	AppealID     int64       `json:"id"`
+	InfractionID int64       `json:"infraction_id"`
+	UserID       null.String `json:"user_id"` // UserID is the ID of the user who recorded this appeal
+	Status       string      `json:"status"`
+	Statement    string      `json:"statement"` // Statement is the player's statement
+	DecidedBy    null.String `json:"decided_by"`
+	DecidedAt    null.Time   `json:"decided_at"`
+	CreatedAt    null.Time   `json:"created_at"`
+	ModifiedAt   null.Time   `json:"modified_at"`
+
+	// The following fields are not DB fields. They do not get scanned. They are populated manually.
+	ServerID int64            `json:"server_id,omitempty"`
+	PlayerID string           `json:"player_id,omitempty"`
+	Platform string           `json:"platform,omitempty"`
+	Comments []*AppealComment `json:"comments,omitempty"`
+}
+
+// AppealComment is a staff comment on an appeal. If the comment was made as part of a decision, NewStatus holds the
+// status the appeal was changed to.
+type AppealComment struct {
+	CommentID int64       `json:"id"`
+	AppealID  int64       `json:"appeal_id"`
+	UserID    null.String `json:"user_id"`
+	Comment   string      `json:"comment"`
+	NewStatus null.String `json:"new_status"`
+	CreatedAt null.Time   `json:"created_at"`
+	Username  string      `json:"username,omitempty"` // Username is not a DB field. It is populated manually.
+}
+
+type AppealRepo interface {
+	Store(ctx context.Context, appeal *Appeal) (*Appeal, error)
+	GetByID(ctx context.Context, id int64) (*Appeal, error)
+	GetByInfraction(ctx context.Context, infractionID int64) ([]*Appeal, error)
+	Update(ctx context.Context, id int64, args UpdateArgs) (*Appeal, error)
+	StoreComment(ctx context.Context, comment *AppealComment) (*AppealComment, error)
+	GetComments(ctx context.Context, appealID int64) ([]*AppealComment, error)
+	Search(ctx context.Context, args FindArgs, serverIDs []int64, limit, offset int) (int, []*Appeal, error)
+}
+
+type AppealService interface {
+	Store(c context.Context, infractionID int64, statement string) (*Appeal, error)
+	GetByID(c context.Context, id int64) (*Appeal, error)
+	GetByInfraction(c context.Context, infractionID int64) ([]*Appeal, error)
+	AddComment(c context.Context, appealID int64, comment string) (*AppealComment, error)
+	SetStatus(c context.Context, appealID int64, status, comment string) (*Appeal, error)
+	SubscribeAppealUpdate(sub AppealSubscriber)
+}
+
+type AppealSubscriber func(appeal *Appeal)
@@ -17,24 +17,59 @@
 
 package domain
 
-import "context"
+import (
+	"context"
+	"github.com/guregu/null"
+	"io"
+)
+
+const (
+	// MaxAttachmentFileSize is the maximum size in bytes of an uploaded attachment file.
+	MaxAttachmentFileSize = 8 << 20
+)
+
+// AllowedAttachmentContentTypes holds the content types of files which may be uploaded as attachments. Content types
+// are detected from the file's content rather than trusted from the client.
+var AllowedAttachmentContentTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
 
 type Attachment struct {
-	AttachmentID int64  `json:"id"`
-	InfractionID int64  `json:"infraction_id"`
-	URL          string `json:"url"`
-	Note         string `json:"note"`
+	AttachmentID int64       `json:"id"`
+	InfractionID int64       `json:"infraction_id"`
+	URL          string      `json:"url"`
+	Note         string      `json:"note"`
+	ContentType  null.String `json:"content_type"`
+	FileSize     null.Int    `json:"file_size"`
+	SHA256       null.String `json:"sha256"` // SHA256 is the hash of an uploaded file. It is null for linked attachments.
+}
+
+// IsUpload returns true if the attachment's file is kept in an AttachmentStore.
+func (a *Attachment) IsUpload() bool {
+	return a.SHA256.Valid
 }
 
 type AttachmentRepo interface {
 	Store(ctx context.Context, attachment *Attachment) error
 	GetByInfraction(ctx context.Context, infractionID int64) ([]*Attachment, error)
 	GetByID(ctx context.Context, id int64) (*Attachment, error)
+	GetBySHA256(ctx context.Context, sha256 string) ([]*Attachment, error)
 	Delete(ctx context.Context, id int64) error
 }
 
+// AttachmentStore stores the files of uploaded attachments. Files are addressed by a key which is unique to the file's
+// content, so storing the same key twice should be a no-op.
+//
+// Get should return ErrNotFound if no file is stored under the provided key.
+type AttachmentStore interface {
+	Put(ctx context.Context, key string, content io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Exists(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, key string) error
+}
+
 type AttachmentService interface {
 	Store(c context.Context, attachment *Attachment) error
+	Upload(c context.Context, infractionID int64, note string, content io.Reader) (*Attachment, error)
+	Download(c context.Context, id int64) (*Attachment, io.ReadCloser, error)
 	GetByInfraction(c context.Context, infractionID int64) ([]*Attachment, error)
 	Delete(c context.Context, id int64) error
 }
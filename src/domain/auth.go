@@ -33,6 +33,19 @@ type AuthUser struct {
 	*kratos.Session
 }
 
+// WithAuthBypass returns a copy of ctx which tells services to skip their own permission checks. The user in ctx, if
+// any, is still credited with the changes made. It should only be used once the user was authorized for the action in
+// some other way, such as when the decider of an appeal repeals the appealed infraction.
+func WithAuthBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, "authBypass", true)
+}
+
+// HasAuthBypass returns true if permission checks should be skipped for ctx.
+func HasAuthBypass(ctx context.Context) bool {
+	bypass, _ := ctx.Value("authBypass").(bool)
+	return bypass
+}
+
 type AuthRepo interface {
 	CreateUser(ctx context.Context, userTraits *Traits) (*AuthUser, error)
 	GetUserByID(ctx context.Context, id string) (*AuthUser, error)
@@ -20,15 +20,39 @@ package domain
 import (
 	"context"
 	"github.com/guregu/null"
+	"strings"
 	"time"
 )
 
+const (
+	ChatChannelAll   = "all"
+	ChatChannelTeam  = "team"
+	ChatChannelSquad = "squad"
+)
+
+var AllChatChannels = []string{ChatChannelAll, ChatChannelTeam, ChatChannelSquad}
+
+// NormalizeChatChannel converts a channel name captured from a game's chat broadcast into the form it is stored in.
+// Games which don't report a channel only have all-chat, so an empty or unknown channel is treated as ChatChannelAll.
+func NormalizeChatChannel(channel string) string {
+	channel = strings.ToLower(strings.TrimSpace(channel))
+
+	for _, known := range AllChatChannels {
+		if channel == known {
+			return channel
+		}
+	}
+
+	return ChatChannelAll
+}
+
 type ChatReceiveBody struct {
 	ServerID   int64  `json:"server_id"`
 	PlayerID   string `json:"player_id"`
 	Platform   string `json:"platform"`
 	Name       string `json:"name"`
 	Message    string `json:"message"`
+	Channel    string `json:"channel"`
 	SentByUser bool   `json:"sent_by_user"`
 }
 
@@ -38,6 +62,7 @@ type ChatMessage struct {
 	Platform   string    `json:"platform"`
 	ServerID   int64     `json:"server_id"`
 	Message    string    `json:"message"`
+	Channel    string    `json:"channel"`
 	Flagged    bool      `json:"flagged"`
 	CreatedAt  time.Time `json:"created_at"`
 	ModifiedAt null.Time `json:"modified_at"`
@@ -49,6 +74,12 @@ type ChatRepo interface {
 	GetByID(ctx context.Context, id int64) (*ChatMessage, error)
 	GetRecentByServer(ctx context.Context, serverID int64, count int) ([]*ChatMessage, error)
 
+	// GetContext returns the message with the provided ID along with up to before messages sent before it and up to
+	// after messages sent after it on the same server, ordered by when they were sent. If the message does not exist,
+	// ErrNotFound is returned.
+	GetContext(ctx context.Context, id int64, before, after int) ([]*ChatMessage, error)
+	IsLinkedToInfraction(ctx context.Context, id int64) (bool, error)
+
 	// Search takes in a criteria (FindArgs) and finds matching results. serverIDs are the server IDs which can be
 	// searched. If serverIDs is null, all servers get fetched.
 	Search(ctx context.Context, args FindArgs, serverIDs []int64, limit, offset int) (int, []*ChatMessage, error)
@@ -60,6 +91,7 @@ type ChatRepo interface {
 type ChatService interface {
 	Store(c context.Context, message *ChatMessage) error
 	GetRecentByServer(c context.Context, serverID int64, count int) ([]*ChatMessage, error)
+	GetContext(c context.Context, id int64, before, after int) ([]*ChatMessage, error)
 	GetFlaggedMessages(c context.Context, count int, random bool) ([]*ChatMessage, error)
 	HandleChatReceive(body *ChatReceiveBody, serverID int64, game Game)
 	HandleUserSendChat(body *ChatSendBody)
@@ -67,22 +99,136 @@ type ChatService interface {
 	UnflagMessage(c context.Context, id int64) error
 }
 
+const (
+	// FlaggedWordModeExact matches whole words, ignoring case and punctuation around them.
+	FlaggedWordModeExact = "exact"
+
+	// FlaggedWordModePhrase matches a sequence of words, ignoring case and whatever separates the words.
+	FlaggedWordModePhrase = "phrase"
+
+	// FlaggedWordModeRegex matches a case-insensitive regular expression.
+	FlaggedWordModeRegex = "regex"
+
+	// FlaggedWordModeNormalized matches anywhere in the message after leetspeak, diacritics, case and separators have
+	// been normalized away, so "B4d-w0rd" matches "badword".
+	FlaggedWordModeNormalized = "normalized"
+
+	// FlaggedWordModeFuzzy matches words which are within the flagged word's MaxDistance edits of it.
+	FlaggedWordModeFuzzy = "fuzzy"
+
+	// MaxFlaggedWordDistance is the highest edit distance a fuzzy flagged word can allow.
+	MaxFlaggedWordDistance = 3
+)
+
+var AllFlaggedWordModes = []string{FlaggedWordModeExact, FlaggedWordModePhrase, FlaggedWordModeRegex,
+	FlaggedWordModeNormalized, FlaggedWordModeFuzzy}
+
+const (
+	FlaggedWordSeverityLow    = "low"
+	FlaggedWordSeverityMedium = "medium"
+	FlaggedWordSeverityHigh   = "high"
+)
+
+// AllFlaggedWordSeverities is ordered from the least to the most severe.
+var AllFlaggedWordSeverities = []string{FlaggedWordSeverityLow, FlaggedWordSeverityMedium, FlaggedWordSeverityHigh}
+
+const (
+	// FlaggedWordActionFlag only flags the message for review.
+	FlaggedWordActionFlag = "flag"
+
+	// FlaggedWordActionAlert flags the message and alerts staff who moderate flagged messages on the server.
+	FlaggedWordActionAlert = "alert"
+
+	// FlaggedWordActionWarn flags the message and warns the player through a system action infraction.
+	FlaggedWordActionWarn = "warn"
+
+	// FlaggedWordActionMute flags the message and mutes the player for the flagged word's ActionDuration minutes
+	// through a system action infraction.
+	FlaggedWordActionMute = "mute"
+)
+
+// AllFlaggedWordActions is ordered from the least to the most severe.
+var AllFlaggedWordActions = []string{FlaggedWordActionFlag, FlaggedWordActionAlert, FlaggedWordActionWarn,
+	FlaggedWordActionMute}
+
 type FlaggedWord struct {
-	ID   int64  `json:"id"`
-	Word string `json:"word"`
+	ID             int64  `json:"id"`
+	Word           string `json:"word"`
+	Mode           string `json:"mode"`
+	MaxDistance    int    `json:"max_distance"` // MaxDistance is the number of edits allowed by fuzzy matching
+	Severity       string `json:"severity"`
+	Action         string `json:"action"`
+	ActionDuration int    `json:"action_duration"` // ActionDuration is the length of an automatic mute in minutes
+
+	// Channels are the chat channels the flagged word is matched in. If empty, it is matched in every channel.
+	Channels []string `json:"channels"`
+
+	// ExemptServers are the IDs of servers the flagged word is not matched on. Not a db field, it is populated from
+	// the FlaggedWordExemptions table.
+	ExemptServers []int64 `json:"exempt_servers"`
+}
+
+// IsExempt returns true if the flagged word should not be matched on the provided server.
+func (fw *FlaggedWord) IsExempt(serverID int64) bool {
+	for _, id := range fw.ExemptServers {
+		if id == serverID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AppliesToChannel returns true if the flagged word should be matched in messages sent in the provided channel.
+func (fw *FlaggedWord) AppliesToChannel(channel string) bool {
+	if len(fw.Channels) < 1 {
+		return true
+	}
+
+	for _, c := range fw.Channels {
+		if c == channel {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FlaggedWordMatch is an occurrence of a flagged word inside of a message. Start and End are the byte offsets of the
+// matched text in the original message.
+type FlaggedWordMatch struct {
+	WordID         int64  `json:"word_id"`
+	Word           string `json:"word"`
+	Mode           string `json:"mode"`
+	Severity       string `json:"severity"`
+	Action         string `json:"action"`
+	ActionDuration int    `json:"action_duration"`
+	Start          int    `json:"start"`
+	End            int    `json:"end"`
 }
 
 type FlaggedWordRepo interface {
 	Store(ctx context.Context, word *FlaggedWord) error
 	GetAll(ctx context.Context) ([]*FlaggedWord, error)
-	Update(ctx context.Context, id int64, newWord string) (*FlaggedWord, error)
+	Update(ctx context.Context, id int64, args UpdateArgs) (*FlaggedWord, error)
 	Delete(ctx context.Context, id int64) error
+
+	// AddExemption exempts a server from a flagged word. If the server is already exempt, ErrConflict is returned.
+	AddExemption(ctx context.Context, id, serverID int64) error
+	RemoveExemption(ctx context.Context, id, serverID int64) error
 }
 
 type FlaggedWordService interface {
 	Store(c context.Context, word *FlaggedWord) error
 	GetAll(c context.Context) ([]*FlaggedWord, error)
-	Update(c context.Context, id int64, newWord string) (*FlaggedWord, error)
+	Update(c context.Context, id int64, args UpdateArgs) (*FlaggedWord, error)
 	Delete(c context.Context, id int64) error
-	MessageContainsFlaggedWord(c context.Context, message string) (bool, error)
+
+	AddExemption(c context.Context, id, serverID int64) error
+	RemoveExemption(c context.Context, id, serverID int64) error
+
+	// FindFlaggedWords returns every flagged word match inside of a message sent on the provided server and channel,
+	// ordered by where they start. Words the server is exempt from or which don't apply to the channel are skipped. An
+	// empty slice is returned if the message contains no flagged words.
+	FindFlaggedWords(c context.Context, serverID int64, channel, message string) ([]*FlaggedWordMatch, error)
 }
@@ -19,6 +19,8 @@ package domain
 
 import (
 	"context"
+	"github.com/guregu/null"
+	"time"
 )
 
 type CommandPayload interface {
@@ -30,15 +32,105 @@ type Command interface {
 	GetCommand() string
 	ShouldRunOnAll() bool
 	GetServerID() int64
+	GetInfractionID() int64
+	GetUserID() string
+
+	// GetPlayerID, GetPlatform, GetInfractionType and GetAction describe the infraction action the command was prepared
+	// for. They are empty for commands which were not prepared for an infraction.
+	GetPlayerID() string
+	GetPlatform() string
+	GetInfractionType() string
+	GetAction() string
+}
+
+const (
+	CommandStatusPending   = "pending"
+	CommandStatusRunning   = "running"
+	CommandStatusSucceeded = "succeeded"
+	CommandStatusFailed    = "failed"
+	CommandStatusExpired   = "expired"
+)
+
+// QueuedCommand is a command which has been persisted to the command queue. Queued commands stay pending until the
+// server they belong to is online, at which point they are run by the command runner. Once run, the queued command
+// serves as the record of the execution.
+//
+// Platform, PlayerID, InfractionType and Action are only set on commands prepared for an infraction action.
+type QueuedCommand struct {
+	CommandID      int64       `json:"id"`
+	ServerID       int64       `json:"server_id"`
+	InfractionID   null.Int    `json:"infraction_id"`
+	UserID         null.String `json:"user_id"`
+	Platform       null.String `json:"platform"`
+	PlayerID       null.String `json:"player_id"`
+	InfractionType null.String `json:"infraction_type"`
+	Action         null.String `json:"action"`
+	Command        string      `json:"command"`
+	Status         string      `json:"status"`
+	Attempts       int         `json:"attempts"`
+	MaxAttempts    int         `json:"max_attempts"`
+	Response       null.String `json:"response"`
+	LastError      null.String `json:"last_error"`
+	NextAttemptAt  time.Time   `json:"next_attempt_at"`
+	ExpiresAt      null.Time   `json:"expires_at"`
+	StartedAt      null.Time   `json:"started_at"`
+	FinishedAt     null.Time   `json:"finished_at"`
+	CreatedAt      time.Time   `json:"created_at"`
+	ModifiedAt     null.Time   `json:"modified_at"`
+}
+
+type CommandQueueRepo interface {
+	Store(ctx context.Context, cmd *QueuedCommand) error
+	Update(ctx context.Context, id int64, args UpdateArgs) (*QueuedCommand, error)
+
+	// GetPendingServers returns the IDs of all servers which have pending commands ready to be run.
+	GetPendingServers(ctx context.Context) ([]int64, error)
+
+	// ClaimPending marks up to limit pending commands for the provided server as running and returns them ordered by
+	// the order in which they were queued.
+	ClaimPending(ctx context.Context, serverID int64, limit int) ([]*QueuedCommand, error)
+
+	// ExpireStale marks all pending commands which have passed their expiry time as expired. The number of expired
+	// commands is returned.
+	ExpireStale(ctx context.Context) (int64, error)
+
+	// ResetRunning returns commands which were claimed before claimedBefore and are still in the running state back to
+	// pending. This recovers commands whose runner was stopped by a shutdown or crash. The number of reset commands is
+	// returned.
+	ResetRunning(ctx context.Context, claimedBefore time.Time) (int64, error)
+
+	// GetByServer returns a page of the commands queued for the provided server, newest first, along with the total
+	// number of commands queued for the server.
+	GetByServer(ctx context.Context, serverID int64, limit, offset int) (int, []*QueuedCommand, error)
+
+	// HasRecentPlayerCommand returns true if a command prepared for one of the provided actions on an infraction of the
+	// provided type against the player is running on the server, or finished running on it at or after since.
+	HasRecentPlayerCommand(ctx context.Context, serverID int64, platform, playerID, infractionType string,
+		actions []string, since time.Time) (bool, error)
 }
 
 type CommandExecutor interface {
 	PrepareInfractionCommands(ctx context.Context, infraction InfractionPayload, action string, serverID int64) (CommandPayload, error)
 	QueueCommands(payload CommandPayload) error
+	PreviewCommands(ctx context.Context, payload CommandPayload) ([]*CommandPreview, error)
 	StartRunner(terminate chan uint8)
+	HandleServerStatusChange(serverID int64, status string)
+
+	// HasRecentPunishment returns true if Refractor ran a command on the server which applies an infraction of the
+	// provided type to the player (a create, update or sync command) at or after since, or is running one right now.
+	HasRecentPunishment(ctx context.Context, serverID int64, platform, playerID, infractionType string,
+		since time.Time) (bool, error)
+}
+
+// CommandPreview is a prepared command along with the IDs of the servers it would be queued on.
+type CommandPreview struct {
+	Command   string  `json:"command"`
+	RunOnAll  bool    `json:"run_on_all"`
+	ServerIDs []int64 `json:"server_ids"`
 }
 
 type CustomInfractionPayload struct {
+	InfractionID      int64
 	PlayerID          string
 	Platform          string
 	PlayerName        string
@@ -47,6 +139,11 @@ type CustomInfractionPayload struct {
 	Duration          int64
 	DurationRemaining int64
 	Reason            string
+	RuleID            int64
+}
+
+func (p *CustomInfractionPayload) GetInfractionID() int64 {
+	return p.InfractionID
 }
 
 func (p *CustomInfractionPayload) GetPlayerID() string {
@@ -80,3 +177,7 @@ func (p *CustomInfractionPayload) GetReason() string {
 func (p *CustomInfractionPayload) GetUserID() string {
 	return p.UserID
 }
+
+func (p *CustomInfractionPayload) GetRuleID() int64 {
+	return p.RuleID
+}
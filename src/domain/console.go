@@ -0,0 +1,88 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package domain
+
+import (
+	"context"
+	"strings"
+)
+
+// ConsoleRule restricts which commands members of a group may run through the RCON console. If AllowPrefixes is not
+// empty, commands must start with one of the allowed prefixes. Commands starting with any of the DenyPrefixes are
+// always rejected.
+type ConsoleRule struct {
+	GroupID       int64    `json:"group_id"`
+	AllowPrefixes []string `json:"allow_prefixes"`
+	DenyPrefixes  []string `json:"deny_prefixes"`
+}
+
+// ConsoleRuleSlice is an alias for []*ConsoleRule. It exists so that we can attach helper functions to it.
+type ConsoleRuleSlice []*ConsoleRule
+
+// Allows checks if a command is allowed by the combination of all rules in the slice. A command is allowed if it does
+// not match any deny prefix, and if either no rule restricts the allowed prefixes or it matches an allowed prefix of
+// any rule. Prefixes are matched case-insensitively.
+func (rs ConsoleRuleSlice) Allows(command string) bool {
+	command = strings.ToLower(strings.TrimSpace(command))
+
+	restricted := false
+	allowed := false
+
+	for _, rule := range rs {
+		for _, prefix := range rule.DenyPrefixes {
+			if strings.HasPrefix(command, strings.ToLower(prefix)) {
+				return false
+			}
+		}
+
+		if len(rule.AllowPrefixes) > 0 {
+			restricted = true
+		}
+
+		for _, prefix := range rule.AllowPrefixes {
+			if strings.HasPrefix(command, strings.ToLower(prefix)) {
+				allowed = true
+			}
+		}
+	}
+
+	return !restricted || allowed
+}
+
+type ConsoleCommandBody struct {
+	ServerID int64  `json:"server_id"`
+	Command  string `json:"command"`
+	UserID   string `json:"-"`
+}
+
+type ConsoleCommandSubscriber func(body *ConsoleCommandBody)
+
+type ConsoleRuleRepo interface {
+	GetByGroups(ctx context.Context, groupIDs []int64) ([]*ConsoleRule, error)
+	Set(ctx context.Context, rule *ConsoleRule) error
+}
+
+type ConsoleService interface {
+	// RunCommand runs a command on a server on behalf of the user in context. The executed command is recorded in the
+	// server's command history and the record is returned. A failing command is not treated as an error; the failure
+	// is reported on the returned record.
+	RunCommand(c context.Context, serverID int64, command string) (*QueuedCommand, error)
+	GetGroupRule(c context.Context, groupID int64) (*ConsoleRule, error)
+	SetGroupRule(c context.Context, rule *ConsoleRule) error
+	HandleConsoleCommand(body *ConsoleCommandBody)
+}
@@ -0,0 +1,64 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package domain
+
+// EscalationPolicy describes a progressive punishment rule for a game. When a player receives their TriggerCount-th
+// infraction of TriggerType within the last Timespan minutes, an infraction of ActionType is either suggested to the
+// moderator or created automatically. Repealed and deleted infractions are not counted, and further infractions
+// inside the timespan do not trigger the policy again.
+//
+// e.g "3 warnings in 7 days, then a 60-minute mute" would have a TriggerType of WARNING, a TriggerCount of 3, a Timespan
+// of 10080, an ActionType of MUTE and an ActionDuration of 60.
+type EscalationPolicy struct {
+	TriggerType    string `json:"trigger_type"`
+	TriggerCount   int    `json:"trigger_count"`
+	Timespan       int    `json:"timespan"` // minutes
+	ActionType     string `json:"action_type"`
+	ActionDuration int64  `json:"action_duration"`
+	ActionReason   string `json:"action_reason"`
+	Automatic      bool   `json:"automatic"`
+}
+
+type EscalationSettings struct {
+	Policies []*EscalationPolicy `json:"policies"`
+}
+
+// PoliciesFor returns the policies which are triggered by infractions of the provided type.
+func (es *EscalationSettings) PoliciesFor(infractionType string) []*EscalationPolicy {
+	policies := make([]*EscalationPolicy, 0)
+
+	if es == nil {
+		return policies
+	}
+
+	for _, policy := range es.Policies {
+		if policy.TriggerType == infractionType {
+			policies = append(policies, policy)
+		}
+	}
+
+	return policies
+}
+
+// EscalationResult is the outcome of a triggered escalation policy. If the policy was applied automatically, Infraction
+// holds the infraction which was created. Otherwise, the policy's action is a suggestion for the moderator.
+type EscalationResult struct {
+	Policy     *EscalationPolicy `json:"policy"`
+	Applied    bool              `json:"applied"`
+	Infraction *Infraction       `json:"infraction,omitempty"`
+}
@@ -0,0 +1,158 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package domain
+
+import (
+	"Refractor/pkg/broadcast"
+	"context"
+	"github.com/guregu/null"
+	"time"
+)
+
+// Federation source trust levels
+const (
+	// FederationTrustAdvisory bans are only shown on player records.
+	FederationTrustAdvisory = "ADVISORY"
+
+	// FederationTrustAlert bans also alert staff when the banned player joins a server.
+	FederationTrustAlert = "ALERT"
+
+	// FederationTrustEnforce bans are also enforced on join using the ban sync commands.
+	FederationTrustEnforce = "ENFORCE"
+)
+
+var AllFederationTrustLevels = []interface{}{
+	FederationTrustAdvisory,
+	FederationTrustAlert,
+	FederationTrustEnforce,
+}
+
+// FederationSignatureHeader is the HTTP header which holds the base64 encoded ed25519 signature of a ban feed.
+const FederationSignatureHeader = "X-Refractor-Signature"
+
+// FederationFeedKeyHeader is the HTTP header partner instances use to send the shared key required to fetch a ban feed.
+const FederationFeedKeyHeader = "X-Refractor-Feed-Key"
+
+// FederationSource is the ban feed of a partner Refractor instance. Instance is the name the partner's feeds must carry
+// and FeedKey is the shared key sent to the partner to fetch its feed. FeedKey is never sent to clients.
+type FederationSource struct {
+	SourceID      int64       `json:"id"`
+	Name          string      `json:"name"`
+	FeedURL       string      `json:"feed_url"`
+	PublicKey     string      `json:"public_key"`
+	Instance      string      `json:"instance"`
+	FeedKey       string      `json:"-"`
+	TrustLevel    string      `json:"trust_level"`
+	Enabled       bool        `json:"enabled"`
+	LastSyncedAt  null.Time   `json:"last_synced_at"`
+	LastSyncError null.String `json:"last_sync_error"`
+	LastFeedAt    null.Time   `json:"last_feed_at"`
+	CreatedAt     time.Time   `json:"created_at"`
+	ModifiedAt    null.Time   `json:"modified_at"`
+}
+
+// FederatedBanEntry is a single ban in a ban feed. Duration is in minutes and is PermanentInfractionValue for
+// permanent bans.
+type FederatedBanEntry struct {
+	ID        int64     `json:"id"`
+	Platform  string    `json:"platform"`
+	PlayerID  string    `json:"player_id"`
+	Reason    string    `json:"reason"`
+	Duration  int64     `json:"duration"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FederatedBanFeed is the body of the ban feed shared with partner instances. It only contains active bans.
+type FederatedBanFeed struct {
+	Instance    string               `json:"instance"`
+	GeneratedAt time.Time            `json:"generated_at"`
+	Bans        []*FederatedBanEntry `json:"bans"`
+}
+
+// FederatedBan is a ban imported from a federation source.
+type FederatedBan struct {
+	SourceID        int64       `json:"source_id"`
+	RemoteID        int64       `json:"remote_id"`
+	Platform        string      `json:"platform"`
+	PlayerID        string      `json:"player_id"`
+	Reason          null.String `json:"reason"`
+	Duration        int64       `json:"duration"`
+	RemoteCreatedAt time.Time   `json:"remote_created_at"`
+	ImportedAt      time.Time   `json:"imported_at"`
+
+	// SourceName and TrustLevel are not FederatedBans fields. They are joined from the ban's source.
+	SourceName string `json:"source_name"`
+	TrustLevel string `json:"trust_level"`
+}
+
+// MinutesRemaining returns the number of minutes left on the ban. Permanent bans return PermanentInfractionValue.
+func (b *FederatedBan) MinutesRemaining() int64 {
+	if b.Duration == PermanentInfractionValue {
+		return PermanentInfractionValue
+	}
+
+	expiresAt := b.RemoteCreatedAt.Add(time.Duration(b.Duration) * time.Minute)
+	return int64(time.Until(expiresAt).Minutes())
+}
+
+// FederatedBanAlert is sent when a player with federated bans from an alert or enforce level source joins a server.
+type FederatedBanAlert struct {
+	ServerID   int64           `json:"server_id"`
+	Platform   string          `json:"platform"`
+	PlayerID   string          `json:"player_id"`
+	PlayerName string          `json:"player_name"`
+	Enforced   bool            `json:"enforced"`
+	Bans       []*FederatedBan `json:"bans"`
+}
+
+type FederatedBanSubscriber func(alert *FederatedBanAlert)
+
+type FederationRepo interface {
+	StoreSource(ctx context.Context, source *FederationSource) error
+	GetSources(ctx context.Context) ([]*FederationSource, error)
+	GetSourceByID(ctx context.Context, id int64) (*FederationSource, error)
+	UpdateSource(ctx context.Context, id int64, args UpdateArgs) (*FederationSource, error)
+	DeleteSource(ctx context.Context, id int64) error
+	SetSyncResult(ctx context.Context, id int64, syncedAt time.Time, syncError null.String) error
+
+	// ReplaceBans replaces all bans imported from a source with the bans of a feed generated at generatedAt. It returns
+	// ErrConflict if generatedAt is not after the generation time of the last feed imported from the source.
+	ReplaceBans(ctx context.Context, sourceID int64, generatedAt time.Time, bans []*FederatedBan) error
+
+	// GetActiveBansByPlayer returns the active bans of a player imported from enabled sources.
+	GetActiveBansByPlayer(ctx context.Context, platform, playerID string) ([]*FederatedBan, error)
+
+	// GetExportBans returns all active bans issued on this instance.
+	GetExportBans(ctx context.Context) ([]*FederatedBanEntry, error)
+}
+
+type FederationService interface {
+	// GetFeed returns the signed ban feed body and its base64 encoded signature. feedKey must match the shared feed key
+	// of this instance.
+	GetFeed(c context.Context, feedKey string) ([]byte, string, error)
+	GetPublicKey() (string, error)
+	CreateSource(c context.Context, source *FederationSource) (*FederationSource, error)
+	GetSources(c context.Context) ([]*FederationSource, error)
+	UpdateSource(c context.Context, id int64, args UpdateArgs) (*FederationSource, error)
+	DeleteSource(c context.Context, id int64) error
+	SyncSource(c context.Context, id int64) (*FederationSource, error)
+	GetPlayerBans(c context.Context, platform, playerID string) ([]*FederatedBan, error)
+	StartSyncWatcher(terminate chan uint8)
+	HandlePlayerJoin(fields broadcast.Fields, serverID int64, game Game)
+	SubscribeFederatedBanAlert(sub FederatedBanSubscriber)
+}
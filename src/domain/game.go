@@ -141,6 +141,7 @@ type GameCommandSettings struct {
 	DeleteInfractionCommands *InfractionCommands `json:"delete"`
 	RepealInfractionCommands *InfractionCommands `json:"repeal"`
 	SyncInfractionCommands   *InfractionCommands `json:"sync"`
+	ExpireInfractionCommands *InfractionCommands `json:"expire"`
 }
 
 type GeneralSettings struct {
@@ -148,11 +149,16 @@ type GeneralSettings struct {
 	EnableMuteSync            bool `json:"enable_mute_sync"`
 	PlayerInfractionThreshold int  `json:"player_infraction_threshold"`
 	PlayerInfractionTimespan  int  `json:"player_infraction_timespan"`
+
+	// ReportCommand is the chat command players use to report other players in-game, e.g. "!report". Reports are
+	// disabled if it is empty.
+	ReportCommand string `json:"report_command"`
 }
 
 type GameSettings struct {
-	Commands *GameCommandSettings `json:"commands"`
-	General  *GeneralSettings     `json:"general"`
+	Commands   *GameCommandSettings `json:"commands"`
+	General    *GeneralSettings     `json:"general"`
+	Escalation *EscalationSettings  `json:"escalation"`
 }
 
 // Prepare prepares the data to be sent to the frontend
@@ -162,6 +168,15 @@ func (gs *GameSettings) Prepare() *GameSettings {
 	gs.Commands.DeleteInfractionCommands = gs.Commands.DeleteInfractionCommands.Prepare()
 	gs.Commands.RepealInfractionCommands = gs.Commands.RepealInfractionCommands.Prepare()
 	gs.Commands.SyncInfractionCommands = gs.Commands.SyncInfractionCommands.Prepare()
+	gs.Commands.ExpireInfractionCommands = gs.Commands.ExpireInfractionCommands.Prepare()
+
+	if gs.Escalation == nil {
+		gs.Escalation = &EscalationSettings{}
+	}
+
+	if gs.Escalation.Policies == nil {
+		gs.Escalation.Policies = make([]*EscalationPolicy, 0)
+	}
 
 	return gs
 }
@@ -173,6 +188,7 @@ func (gcs *GameCommandSettings) InfractionActionMap() map[string]*InfractionComm
 		InfractionCommandDelete: gcs.DeleteInfractionCommands,
 		InfractionCommandRepeal: gcs.RepealInfractionCommands,
 		InfractionCommandSync:   gcs.SyncInfractionCommands,
+		InfractionCommandExpire: gcs.ExpireInfractionCommands,
 	}
 }
 
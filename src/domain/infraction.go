@@ -21,6 +21,7 @@ import (
 	"Refractor/pkg/broadcast"
 	"context"
 	"github.com/guregu/null"
+	"io"
 	"math"
 	"time"
 )
@@ -53,8 +54,23 @@ type Infraction struct {
 	CreatedAt    null.Time   `json:"created_at"`
 	ModifiedAt   null.Time   `json:"modified_at"`
 	Repealed     bool        `json:"repealed"`
+	ExpiredAt    null.Time   `json:"expired_at"`            // ExpiredAt is set once the infraction's expiry has been processed
+	RuleID       null.Int    `json:"rule_id"`               // RuleID is the ID of the rule which was broken
+	DeletedAt    null.Time   `json:"deleted_at"`            // DeletedAt is set once the infraction has been moved to the trash
+	DeletedBy    null.String `json:"deleted_by"`            // DeletedBy is the ID of the user who deleted this infraction
+	DeleteReason null.String `json:"delete_reason"`         // DeleteReason is the optional reason given when deleting
 	IssuerName   string      `json:"issuer_name,omitempty"` // IssuerName is not a DB field. It does not get scanned. It is populated manually.
 	PlayerName   string      `json:"player_name,omitempty"` // PlayerName is not a DB field. It does not get scanned. It is populated manually.
+
+	// DeleterName is not a DB field. It is populated with the username of the user who deleted the infraction when
+	// viewing the trash.
+	DeleterName string `json:"deleter_name,omitempty"`
+
+	// Escalation is not a DB field. It is set on newly created infractions which triggered an escalation policy.
+	Escalation *EscalationResult `json:"escalation,omitempty"`
+
+	// Revisions is not a DB field. It is populated when an infraction is fetched by ID.
+	Revisions []*InfractionRevision `json:"revisions,omitempty"`
 }
 
 func (i *Infraction) IsPermanent() bool {
@@ -78,7 +94,6 @@ type InfractionRepo interface {
 	Store(ctx context.Context, infraction *Infraction) (*Infraction, error)
 	GetByID(ctx context.Context, id int64) (*Infraction, error)
 	Update(ctx context.Context, id int64, args UpdateArgs) (*Infraction, error)
-	Delete(ctx context.Context, id int64) error
 	GetByPlayer(ctx context.Context, playerID, platform string) ([]*Infraction, error)
 	Search(ctx context.Context, args FindArgs, serverIDs []int64, limit, offset int) (int, []*Infraction, error)
 	GetLinkedChatMessages(ctx context.Context, id int64) ([]*ChatMessage, error)
@@ -89,7 +104,81 @@ type InfractionRepo interface {
 	// specified type with the highest duration.
 	GetMostSignificantInfraction(ctx context.Context, infrType, platform, playerID string) (*Infraction, error)
 	GetPlayerTotalInfractions(ctx context.Context, platform, playerID string) (int, error)
-	GetPlayerInfractionCountSince(ctx context.Context, platform, playerID string, since time.Time) (int, error)
+	GetExpired(ctx context.Context, limit int) ([]*Infraction, error)
+	MarkExpired(ctx context.Context, id int64, expiredAt time.Time) error
+	GetPlayerInfractionCountSince(ctx context.Context, platform, playerID string, since time.Time, types ...string) (int, error)
+	StoreRevision(ctx context.Context, revision *InfractionRevision) (*InfractionRevision, error)
+	GetRevisions(ctx context.Context, id int64) ([]*InfractionRevision, error)
+
+	// SoftDelete moves an infraction to the trash and returns it. Deleted infractions are left out of every other query
+	// until they are restored or purged. If the infraction does not exist or is already deleted, domain.ErrNotFound is
+	// returned.
+	SoftDelete(ctx context.Context, id int64, deletedBy, reason null.String) (*Infraction, error)
+
+	// Restore takes an infraction out of the trash and returns it. If the infraction does not exist or is not deleted,
+	// domain.ErrNotFound is returned.
+	Restore(ctx context.Context, id int64) (*Infraction, error)
+
+	// GetDeleted returns a page of deleted infractions, most recently deleted first, along with the total number of
+	// deleted infractions.
+	GetDeleted(ctx context.Context, limit, offset int) (int, []*Infraction, error)
+
+	// PurgeDeleted permanently deletes infractions which were deleted before the provided time and returns how many
+	// were purged. Linked chat messages and attachments are removed along with them. The storage keys of uploaded
+	// attachment files which are no longer referenced by any remaining attachment are returned so that the files can
+	// be removed from the attachment store.
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, []string, error)
+
+	// Import stores historical infractions in a single transaction. CreatedAt and ExpiredAt are taken from the provided
+	// infractions, and players which do not exist yet are created. If PlayerName is set on an infraction, it is recorded
+	// as one of the player's names.
+	Import(ctx context.Context, infractions []*Infraction) error
+}
+
+// InfractionRevision is an immutable record of a change made to an infraction.
+type InfractionRevision struct {
+	RevisionID   int64             `json:"id"`
+	InfractionID int64             `json:"infraction_id"`
+	UserID       null.String       `json:"user_id"` // UserID is the ID of the user who made the change
+	Changes      InfractionChanges `json:"changes"`
+	Note         null.String       `json:"note"`
+	CreatedAt    null.Time         `json:"created_at"`
+	Username     string            `json:"username,omitempty"` // Username is not a DB field. It is populated manually.
+}
+
+// InfractionChanges maps the JSON name of each changed infraction field to its old and new value.
+type InfractionChanges map[string]*InfractionFieldChange
+
+type InfractionFieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// DiffInfractions returns the changes to the user editable fields between two versions of an infraction.
+func DiffInfractions(old, new *Infraction) InfractionChanges {
+	changes := InfractionChanges{}
+
+	if old.Reason != new.Reason {
+		changes["reason"] = &InfractionFieldChange{Old: old.Reason, New: new.Reason}
+	}
+
+	if old.Duration != new.Duration {
+		changes["duration"] = &InfractionFieldChange{Old: old.Duration, New: new.Duration}
+	}
+
+	if old.Repealed != new.Repealed {
+		changes["repealed"] = &InfractionFieldChange{Old: old.Repealed, New: new.Repealed}
+	}
+
+	if old.RuleID != new.RuleID {
+		changes["rule_id"] = &InfractionFieldChange{Old: old.RuleID, New: new.RuleID}
+	}
+
+	if old.DeletedAt.Valid != new.DeletedAt.Valid {
+		changes["deleted"] = &InfractionFieldChange{Old: old.DeletedAt.Valid, New: new.DeletedAt.Valid}
+	}
+
+	return changes
 }
 
 type InfractionSubscriber func(infraction *Infraction)
@@ -97,9 +186,12 @@ type InfractionSubscriber func(infraction *Infraction)
 type InfractionService interface {
 	Store(c context.Context, infraction *Infraction, attachments []*Attachment, linkedMessages []int64) (*Infraction, error)
 	GetByID(c context.Context, id int64) (*Infraction, error)
-	Update(c context.Context, id int64, args UpdateArgs) (*Infraction, error)
-	SetRepealed(c context.Context, id int64, repealed bool) (*Infraction, error)
-	Delete(c context.Context, id int64) error
+	Update(c context.Context, id int64, args UpdateArgs, note string) (*Infraction, error)
+	SetRepealed(c context.Context, id int64, repealed bool, note string) (*Infraction, error)
+	GetHistory(c context.Context, id int64) ([]*InfractionRevision, error)
+	Delete(c context.Context, id int64, reason string) error
+	Restore(c context.Context, id int64) (*Infraction, error)
+	GetDeleted(c context.Context, limit, offset int) (int, []*Infraction, error)
 	GetByPlayer(c context.Context, playerID, platform string) ([]*Infraction, error)
 	GetLinkedChatMessages(c context.Context, id int64) ([]*ChatMessage, error)
 	LinkChatMessages(c context.Context, id int64, messageIDs ...int64) error
@@ -109,6 +201,48 @@ type InfractionService interface {
 	HandlePlayerJoin(fields broadcast.Fields, serverID int64, game Game)
 	HandleModerationAction(fields broadcast.Fields, serverID int64, game Game)
 	SubscribeInfractionCreate(sub InfractionSubscriber)
+	SubscribeInfractionExpire(sub InfractionSubscriber)
+	StartExpiryWatcher(terminate chan uint8)
+	StartDeletedPurger(retention time.Duration, terminate chan uint8)
+	PreviewCommands(c context.Context, serverID int64, action string, draft *CustomInfractionPayload) ([]*CommandPreview, error)
+	ExportInfractions(c context.Context, args FindArgs, format string, w io.Writer) error
+	ImportInfractions(c context.Context, serverID int64, format string, mapIssuers bool, file io.Reader) (*InfractionImportResult, error)
+}
+
+const (
+	InfractionTransferFormatCSV  = "csv"
+	InfractionTransferFormatJSON = "json"
+)
+
+var AllInfractionTransferFormats = []string{InfractionTransferFormatCSV, InfractionTransferFormatJSON}
+
+// MaxInfractionImportFileSize is the largest import file accepted, in bytes.
+const MaxInfractionImportFileSize = 32 << 20
+
+// InfractionImportRow is a single historical infraction read from an import file. Every field is kept as text so that
+// each row can be validated and reported on individually instead of failing the whole file.
+type InfractionImportRow struct {
+	PlayerID   string `json:"player_id"`
+	Platform   string `json:"platform"`
+	PlayerName string `json:"player_name"`
+	Type       string `json:"type"`
+	Duration   string `json:"duration"`
+	Reason     string `json:"reason"`
+	Date       string `json:"date"`
+	IssuerName string `json:"issuer_name"`
+}
+
+// InfractionImportRowError holds the validation errors for a single import row. Row numbers start at 1 and do not
+// count the CSV header.
+type InfractionImportRowError struct {
+	Row    int               `json:"row"`
+	Errors map[string]string `json:"errors"`
+}
+
+type InfractionImportResult struct {
+	Imported        int                         `json:"imported"`
+	UnmappedIssuers []string                    `json:"unmapped_issuers"`
+	Errors          []*InfractionImportRowError `json:"errors"`
 }
 
 const (
@@ -117,6 +251,7 @@ const (
 	InfractionCommandDelete = "DELETE"
 	InfractionCommandRepeal = "REPEAL"
 	InfractionCommandSync   = "SYNC"
+	InfractionCommandExpire = "EXPIRE"
 )
 
 type InfractionCommands struct {
@@ -124,6 +259,9 @@ type InfractionCommands struct {
 	Mute []*InfractionCommand `json:"mute"`
 	Kick []*InfractionCommand `json:"kick"`
 	Ban  []*InfractionCommand `json:"ban"`
+
+	// Custom holds the commands of the game's custom infraction types keyed by type name.
+	Custom map[string][]*InfractionCommand `json:"custom"`
 }
 
 type InfractionCommand struct {
@@ -134,16 +272,26 @@ type InfractionCommand struct {
 }
 
 func (ic *InfractionCommands) Map() map[string][]*InfractionCommand {
-	return map[string][]*InfractionCommand{
-		InfractionTypeWarning: ic.Warn,
-		InfractionTypeMute:    ic.Mute,
-		InfractionTypeKick:    ic.Kick,
-		InfractionTypeBan:     ic.Ban,
+	cmdMap := map[string][]*InfractionCommand{}
+
+	for name, cmds := range ic.Custom {
+		cmdMap[name] = cmds
 	}
+
+	cmdMap[InfractionTypeWarning] = ic.Warn
+	cmdMap[InfractionTypeMute] = ic.Mute
+	cmdMap[InfractionTypeKick] = ic.Kick
+	cmdMap[InfractionTypeBan] = ic.Ban
+
+	return cmdMap
 }
 
 // Prepare will replace all nil fields with empty arrays for a consistent experience on the frontend
 func (ic *InfractionCommands) Prepare() *InfractionCommands {
+	if ic == nil {
+		ic = &InfractionCommands{}
+	}
+
 	if ic.Warn == nil {
 		ic.Warn = make([]*InfractionCommand, 0)
 	}
@@ -156,11 +304,15 @@ func (ic *InfractionCommands) Prepare() *InfractionCommands {
 	if ic.Ban == nil {
 		ic.Ban = make([]*InfractionCommand, 0)
 	}
+	if ic.Custom == nil {
+		ic.Custom = make(map[string][]*InfractionCommand)
+	}
 
 	return ic
 }
 
 type InfractionPayload interface {
+	GetInfractionID() int64
 	GetPlayerID() string
 	GetPlatform() string
 	GetPlayerName() string
@@ -169,6 +321,11 @@ type InfractionPayload interface {
 	GetDurationRemaining() int64
 	GetReason() string
 	GetUserID() string
+	GetRuleID() int64
+}
+
+func (i *Infraction) GetInfractionID() int64 {
+	return i.InfractionID
 }
 
 func (i *Infraction) GetPlayerID() string {
@@ -202,3 +359,7 @@ func (i *Infraction) GetReason() string {
 func (i *Infraction) GetUserID() string {
 	return i.UserID.ValueOrZero()
 }
+
+func (i *Infraction) GetRuleID() int64 {
+	return i.RuleID.ValueOrZero()
+}
@@ -0,0 +1,93 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package domain
+
+import (
+	"context"
+	"fmt"
+	"github.com/guregu/null"
+	"strings"
+)
+
+// BuiltInInfractionTypes are the infraction types available in every game.
+var BuiltInInfractionTypes = []string{InfractionTypeWarning, InfractionTypeMute, InfractionTypeKick, InfractionTypeBan}
+
+// IsBuiltInInfractionType returns true if the provided type name is one of the built-in infraction types.
+func IsBuiltInInfractionType(name string) bool {
+	for _, t := range BuiltInInfractionTypes {
+		if t == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CustomInfractionTypeUpdateFields are the infraction fields which a custom infraction type can allow to be updated.
+var CustomInfractionTypeUpdateFields = []string{"Reason", "Duration", "Repealed"}
+
+// CustomInfractionType is an infraction type defined by an admin for a single game. Custom types are used for
+// punishments which don't map onto the built-in types, such as voice mutes or slays.
+type CustomInfractionType struct {
+	TypeID      int64  `json:"id"`
+	Game        string `json:"game"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+
+	// HasDuration is true if infractions of this type last for a duration like mutes and bans. Infractions of types
+	// without a duration are one-off actions like warnings and kicks.
+	HasDuration bool `json:"has_duration"`
+
+	// AllowPermanent is true if infractions of this type can be permanent. It is only used if HasDuration is true.
+	AllowPermanent bool `json:"allow_permanent"`
+
+	AllowedUpdateFields []string  `json:"allowed_update_fields"`
+	CreatedAt           null.Time `json:"created_at"`
+	ModifiedAt          null.Time `json:"modified_at"`
+}
+
+// PermissionName returns the name of the generated permission flag required to create infractions of this type.
+func (t *CustomInfractionType) PermissionName() string {
+	game := strings.ToUpper(strings.ReplaceAll(t.Game, " ", "_"))
+	return fmt.Sprintf("FLAG_CREATE_%s_%s", game, t.Name)
+}
+
+type InfractionTypeRepo interface {
+	Store(ctx context.Context, infractionType *CustomInfractionType) error
+	GetAll(ctx context.Context) ([]*CustomInfractionType, error)
+	GetByID(ctx context.Context, id int64) (*CustomInfractionType, error)
+	GetByGame(ctx context.Context, game string) ([]*CustomInfractionType, error)
+	GetByName(ctx context.Context, game, name string) (*CustomInfractionType, error)
+	Update(ctx context.Context, id int64, args UpdateArgs) (*CustomInfractionType, error)
+	Delete(ctx context.Context, id int64) error
+
+	// IsInUse returns true if any infractions have been created with the infraction type.
+	IsInUse(ctx context.Context, id int64) (bool, error)
+}
+
+type InfractionTypeService interface {
+	Store(c context.Context, infractionType *CustomInfractionType) error
+	GetAll(c context.Context) ([]*CustomInfractionType, error)
+	GetByGame(c context.Context, game string) ([]*CustomInfractionType, error)
+	Update(c context.Context, id int64, args UpdateArgs) (*CustomInfractionType, error)
+	Delete(c context.Context, id int64) error
+
+	// RegisterPermissions registers the generated permission flag of every custom infraction type. It should be called
+	// once on startup.
+	RegisterPermissions(c context.Context) error
+}
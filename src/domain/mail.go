@@ -20,4 +20,5 @@ package domain
 type MailService interface {
 	SendMail(to []string, sub string, body string) error
 	SendWelcomeEmail(to, inviterName, link string) error
+	SendWatchedPlayerEmail(to []string, alert *WatchedPlayerAlert, serverName string) error
 }
@@ -74,6 +74,29 @@ func (_m *AttachmentRepo) GetByInfraction(ctx context.Context, infractionID int6
 	return r0, r1
 }
 
+// GetBySHA256 provides a mock function with given fields: ctx, sha256
+func (_m *AttachmentRepo) GetBySHA256(ctx context.Context, sha256 string) ([]*domain.Attachment, error) {
+	ret := _m.Called(ctx, sha256)
+
+	var r0 []*domain.Attachment
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*domain.Attachment); ok {
+		r0 = rf(ctx, sha256)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Attachment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, sha256)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Store provides a mock function with given fields: ctx, attachment
 func (_m *AttachmentRepo) Store(ctx context.Context, attachment *domain.Attachment) error {
 	ret := _m.Called(ctx, attachment)
@@ -7,6 +7,8 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+
+	io "io"
 )
 
 // AttachmentService is an autogenerated mock type for the AttachmentService type
@@ -28,6 +30,38 @@ func (_m *AttachmentService) Delete(c context.Context, id int64) error {
 	return r0
 }
 
+// Download provides a mock function with given fields: c, id
+func (_m *AttachmentService) Download(c context.Context, id int64) (*domain.Attachment, io.ReadCloser, error) {
+	ret := _m.Called(c, id)
+
+	var r0 *domain.Attachment
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.Attachment); ok {
+		r0 = rf(c, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Attachment)
+		}
+	}
+
+	var r1 io.ReadCloser
+	if rf, ok := ret.Get(1).(func(context.Context, int64) io.ReadCloser); ok {
+		r1 = rf(c, id)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(io.ReadCloser)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, int64) error); ok {
+		r2 = rf(c, id)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetByInfraction provides a mock function with given fields: c, infractionID
 func (_m *AttachmentService) GetByInfraction(c context.Context, infractionID int64) ([]*domain.Attachment, error) {
 	ret := _m.Called(c, infractionID)
@@ -64,3 +98,26 @@ func (_m *AttachmentService) Store(c context.Context, attachment *domain.Attachm
 
 	return r0
 }
+
+// Upload provides a mock function with given fields: c, infractionID, note, content
+func (_m *AttachmentService) Upload(c context.Context, infractionID int64, note string, content io.Reader) (*domain.Attachment, error) {
+	ret := _m.Called(c, infractionID, note, content)
+
+	var r0 *domain.Attachment
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, io.Reader) *domain.Attachment); ok {
+		r0 = rf(c, infractionID, note, content)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Attachment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, io.Reader) error); ok {
+		r1 = rf(c, infractionID, note, content)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -37,6 +37,29 @@ func (_m *ChatRepo) GetByID(ctx context.Context, id int64) (*domain.ChatMessage,
 	return r0, r1
 }
 
+// GetContext provides a mock function with given fields: ctx, id, before, after
+func (_m *ChatRepo) GetContext(ctx context.Context, id int64, before int, after int) ([]*domain.ChatMessage, error) {
+	ret := _m.Called(ctx, id, before, after)
+
+	var r0 []*domain.ChatMessage
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int, int) []*domain.ChatMessage); ok {
+		r0 = rf(ctx, id, before, after)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.ChatMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int, int) error); ok {
+		r1 = rf(ctx, id, before, after)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetFlaggedMessageCount provides a mock function with given fields: ctx
 func (_m *ChatRepo) GetFlaggedMessageCount(ctx context.Context) (int, error) {
 	ret := _m.Called(ctx)
@@ -104,6 +127,27 @@ func (_m *ChatRepo) GetRecentByServer(ctx context.Context, serverID int64, count
 	return r0, r1
 }
 
+// IsLinkedToInfraction provides a mock function with given fields: ctx, id
+func (_m *ChatRepo) IsLinkedToInfraction(ctx context.Context, id int64) (bool, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, int64) bool); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Search provides a mock function with given fields: ctx, args, serverIDs, limit, offset
 func (_m *ChatRepo) Search(ctx context.Context, args domain.FindArgs, serverIDs []int64, limit int, offset int) (int, []*domain.ChatMessage, error) {
 	ret := _m.Called(ctx, args, serverIDs, limit, offset)
@@ -14,6 +14,29 @@ type ChatService struct {
 	mock.Mock
 }
 
+// GetContext provides a mock function with given fields: c, id, before, after
+func (_m *ChatService) GetContext(c context.Context, id int64, before int, after int) ([]*domain.ChatMessage, error) {
+	ret := _m.Called(c, id, before, after)
+
+	var r0 []*domain.ChatMessage
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int, int) []*domain.ChatMessage); ok {
+		r0 = rf(c, id, before, after)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.ChatMessage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int, int) error); ok {
+		r1 = rf(c, id, before, after)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetFlaggedMessageCount provides a mock function with given fields: c
 func (_m *ChatService) GetFlaggedMessageCount(c context.Context) (int, error) {
 	ret := _m.Called(c)
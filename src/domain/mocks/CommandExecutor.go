@@ -7,6 +7,8 @@ import (
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // CommandExecutor is an autogenerated mock type for the CommandExecutor type
@@ -14,12 +16,38 @@ type CommandExecutor struct {
 	mock.Mock
 }
 
+// HandleServerStatusChange provides a mock function with given fields: serverID, status
+func (_m *CommandExecutor) HandleServerStatusChange(serverID int64, status string) {
+	_m.Called(serverID, status)
+}
+
+// HasRecentPunishment provides a mock function with given fields: ctx, serverID, platform, playerID, infractionType, since
+func (_m *CommandExecutor) HasRecentPunishment(ctx context.Context, serverID int64, platform string, playerID string, infractionType string, since time.Time) (bool, error) {
+	ret := _m.Called(ctx, serverID, platform, playerID, infractionType, since)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string, string, time.Time) bool); ok {
+		r0 = rf(ctx, serverID, platform, playerID, infractionType, since)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, string, string, time.Time) error); ok {
+		r1 = rf(ctx, serverID, platform, playerID, infractionType, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PrepareInfractionCommands provides a mock function with given fields: ctx, infraction, action, serverID
-func (_m *CommandExecutor) PrepareInfractionCommands(ctx context.Context, infraction *domain.Infraction, action string, serverID int64) (domain.CommandPayload, error) {
+func (_m *CommandExecutor) PrepareInfractionCommands(ctx context.Context, infraction domain.InfractionPayload, action string, serverID int64) (domain.CommandPayload, error) {
 	ret := _m.Called(ctx, infraction, action, serverID)
 
 	var r0 domain.CommandPayload
-	if rf, ok := ret.Get(0).(func(context.Context, *domain.Infraction, string, int64) domain.CommandPayload); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, domain.InfractionPayload, string, int64) domain.CommandPayload); ok {
 		r0 = rf(ctx, infraction, action, serverID)
 	} else {
 		if ret.Get(0) != nil {
@@ -28,7 +56,7 @@ func (_m *CommandExecutor) PrepareInfractionCommands(ctx context.Context, infrac
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *domain.Infraction, string, int64) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, domain.InfractionPayload, string, int64) error); ok {
 		r1 = rf(ctx, infraction, action, serverID)
 	} else {
 		r1 = ret.Error(1)
@@ -37,8 +65,31 @@ func (_m *CommandExecutor) PrepareInfractionCommands(ctx context.Context, infrac
 	return r0, r1
 }
 
-// RunCommands provides a mock function with given fields: payload
-func (_m *CommandExecutor) RunCommands(payload domain.CommandPayload) error {
+// PreviewCommands provides a mock function with given fields: ctx, payload
+func (_m *CommandExecutor) PreviewCommands(ctx context.Context, payload domain.CommandPayload) ([]*domain.CommandPreview, error) {
+	ret := _m.Called(ctx, payload)
+
+	var r0 []*domain.CommandPreview
+	if rf, ok := ret.Get(0).(func(context.Context, domain.CommandPayload) []*domain.CommandPreview); ok {
+		r0 = rf(ctx, payload)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.CommandPreview)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.CommandPayload) error); ok {
+		r1 = rf(ctx, payload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QueueCommands provides a mock function with given fields: payload
+func (_m *CommandExecutor) QueueCommands(payload domain.CommandPayload) error {
 	ret := _m.Called(payload)
 
 	var r0 error
@@ -50,3 +101,8 @@ func (_m *CommandExecutor) RunCommands(payload domain.CommandPayload) error {
 
 	return r0
 }
+
+// StartRunner provides a mock function with given fields: terminate
+func (_m *CommandExecutor) StartRunner(terminate chan uint8) {
+	_m.Called(terminate)
+}
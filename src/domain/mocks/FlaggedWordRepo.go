@@ -14,6 +14,20 @@ type FlaggedWordRepo struct {
 	mock.Mock
 }
 
+// AddExemption provides a mock function with given fields: ctx, id, serverID
+func (_m *FlaggedWordRepo) AddExemption(ctx context.Context, id int64, serverID int64) error {
+	ret := _m.Called(ctx, id, serverID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, id, serverID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Delete provides a mock function with given fields: ctx, id
 func (_m *FlaggedWordRepo) Delete(ctx context.Context, id int64) error {
 	ret := _m.Called(ctx, id)
@@ -51,6 +65,20 @@ func (_m *FlaggedWordRepo) GetAll(ctx context.Context) ([]*domain.FlaggedWord, e
 	return r0, r1
 }
 
+// RemoveExemption provides a mock function with given fields: ctx, id, serverID
+func (_m *FlaggedWordRepo) RemoveExemption(ctx context.Context, id int64, serverID int64) error {
+	ret := _m.Called(ctx, id, serverID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, id, serverID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Store provides a mock function with given fields: ctx, word
 func (_m *FlaggedWordRepo) Store(ctx context.Context, word *domain.FlaggedWord) error {
 	ret := _m.Called(ctx, word)
@@ -65,13 +93,13 @@ func (_m *FlaggedWordRepo) Store(ctx context.Context, word *domain.FlaggedWord)
 	return r0
 }
 
-// Update provides a mock function with given fields: ctx, id, newWord
-func (_m *FlaggedWordRepo) Update(ctx context.Context, id int64, newWord string) (*domain.FlaggedWord, error) {
-	ret := _m.Called(ctx, id, newWord)
+// Update provides a mock function with given fields: ctx, id, args
+func (_m *FlaggedWordRepo) Update(ctx context.Context, id int64, args domain.UpdateArgs) (*domain.FlaggedWord, error) {
+	ret := _m.Called(ctx, id, args)
 
 	var r0 *domain.FlaggedWord
-	if rf, ok := ret.Get(0).(func(context.Context, int64, string) *domain.FlaggedWord); ok {
-		r0 = rf(ctx, id, newWord)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.UpdateArgs) *domain.FlaggedWord); ok {
+		r0 = rf(ctx, id, args)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.FlaggedWord)
@@ -79,8 +107,8 @@ func (_m *FlaggedWordRepo) Update(ctx context.Context, id int64, newWord string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
-		r1 = rf(ctx, id, newWord)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, domain.UpdateArgs) error); ok {
+		r1 = rf(ctx, id, args)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -14,6 +14,20 @@ type FlaggedWordService struct {
 	mock.Mock
 }
 
+// AddExemption provides a mock function with given fields: c, id, serverID
+func (_m *FlaggedWordService) AddExemption(c context.Context, id int64, serverID int64) error {
+	ret := _m.Called(c, id, serverID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(c, id, serverID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Delete provides a mock function with given fields: c, id
 func (_m *FlaggedWordService) Delete(c context.Context, id int64) error {
 	ret := _m.Called(c, id)
@@ -28,6 +42,29 @@ func (_m *FlaggedWordService) Delete(c context.Context, id int64) error {
 	return r0
 }
 
+// FindFlaggedWords provides a mock function with given fields: c, serverID, channel, message
+func (_m *FlaggedWordService) FindFlaggedWords(c context.Context, serverID int64, channel string, message string) ([]*domain.FlaggedWordMatch, error) {
+	ret := _m.Called(c, serverID, channel, message)
+
+	var r0 []*domain.FlaggedWordMatch
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string) []*domain.FlaggedWordMatch); ok {
+		r0 = rf(c, serverID, channel, message)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.FlaggedWordMatch)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, string) error); ok {
+		r1 = rf(c, serverID, channel, message)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAll provides a mock function with given fields: c
 func (_m *FlaggedWordService) GetAll(c context.Context) ([]*domain.FlaggedWord, error) {
 	ret := _m.Called(c)
@@ -51,25 +88,18 @@ func (_m *FlaggedWordService) GetAll(c context.Context) ([]*domain.FlaggedWord,
 	return r0, r1
 }
 
-// MessageContainsFlaggedWord provides a mock function with given fields: c, message
-func (_m *FlaggedWordService) MessageContainsFlaggedWord(c context.Context, message string) (bool, error) {
-	ret := _m.Called(c, message)
-
-	var r0 bool
-	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
-		r0 = rf(c, message)
-	} else {
-		r0 = ret.Get(0).(bool)
-	}
+// RemoveExemption provides a mock function with given fields: c, id, serverID
+func (_m *FlaggedWordService) RemoveExemption(c context.Context, id int64, serverID int64) error {
+	ret := _m.Called(c, id, serverID)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(c, message)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(c, id, serverID)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
 // Store provides a mock function with given fields: c, word
@@ -86,13 +116,13 @@ func (_m *FlaggedWordService) Store(c context.Context, word *domain.FlaggedWord)
 	return r0
 }
 
-// Update provides a mock function with given fields: c, id, newWord
-func (_m *FlaggedWordService) Update(c context.Context, id int64, newWord string) (*domain.FlaggedWord, error) {
-	ret := _m.Called(c, id, newWord)
+// Update provides a mock function with given fields: c, id, args
+func (_m *FlaggedWordService) Update(c context.Context, id int64, args domain.UpdateArgs) (*domain.FlaggedWord, error) {
+	ret := _m.Called(c, id, args)
 
 	var r0 *domain.FlaggedWord
-	if rf, ok := ret.Get(0).(func(context.Context, int64, string) *domain.FlaggedWord); ok {
-		r0 = rf(c, id, newWord)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.UpdateArgs) *domain.FlaggedWord); ok {
+		r0 = rf(c, id, args)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.FlaggedWord)
@@ -100,8 +130,8 @@ func (_m *FlaggedWordService) Update(c context.Context, id int64, newWord string
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
-		r1 = rf(c, id, newWord)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, domain.UpdateArgs) error); ok {
+		r1 = rf(c, id, args)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -8,6 +8,8 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 
+	null "github.com/guregu/null"
+
 	time "time"
 )
 
@@ -16,20 +18,6 @@ type InfractionRepo struct {
 	mock.Mock
 }
 
-// Delete provides a mock function with given fields: ctx, id
-func (_m *InfractionRepo) Delete(ctx context.Context, id int64) error {
-	ret := _m.Called(ctx, id)
-
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
-		r0 = rf(ctx, id)
-	} else {
-		r0 = ret.Error(0)
-	}
-
-	return r0
-}
-
 // GetByID provides a mock function with given fields: ctx, id
 func (_m *InfractionRepo) GetByID(ctx context.Context, id int64) (*domain.Infraction, error) {
 	ret := _m.Called(ctx, id)
@@ -76,6 +64,59 @@ func (_m *InfractionRepo) GetByPlayer(ctx context.Context, playerID string, plat
 	return r0, r1
 }
 
+// GetDeleted provides a mock function with given fields: ctx, limit, offset
+func (_m *InfractionRepo) GetDeleted(ctx context.Context, limit int, offset int) (int, []*domain.Infraction, error) {
+	ret := _m.Called(ctx, limit, offset)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) int); ok {
+		r0 = rf(ctx, limit, offset)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 []*domain.Infraction
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) []*domain.Infraction); ok {
+		r1 = rf(ctx, limit, offset)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]*domain.Infraction)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, int, int) error); ok {
+		r2 = rf(ctx, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetExpired provides a mock function with given fields: ctx, limit
+func (_m *InfractionRepo) GetExpired(ctx context.Context, limit int) ([]*domain.Infraction, error) {
+	ret := _m.Called(ctx, limit)
+
+	var r0 []*domain.Infraction
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*domain.Infraction); ok {
+		r0 = rf(ctx, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Infraction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetLinkedChatMessages provides a mock function with given fields: ctx, id
 func (_m *InfractionRepo) GetLinkedChatMessages(ctx context.Context, id int64) ([]*domain.ChatMessage, error) {
 	ret := _m.Called(ctx, id)
@@ -122,20 +163,27 @@ func (_m *InfractionRepo) GetMostSignificantInfraction(ctx context.Context, infr
 	return r0, r1
 }
 
-// GetPlayerInfractionCountSince provides a mock function with given fields: ctx, platform, playerID, since
-func (_m *InfractionRepo) GetPlayerInfractionCountSince(ctx context.Context, platform string, playerID string, since time.Time) (int, error) {
-	ret := _m.Called(ctx, platform, playerID, since)
+// GetPlayerInfractionCountSince provides a mock function with given fields: ctx, platform, playerID, since, types
+func (_m *InfractionRepo) GetPlayerInfractionCountSince(ctx context.Context, platform string, playerID string, since time.Time, types ...string) (int, error) {
+	_va := make([]interface{}, len(types))
+	for _i := range types {
+		_va[_i] = types[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, platform, playerID, since)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	var r0 int
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) int); ok {
-		r0 = rf(ctx, platform, playerID, since)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time, ...string) int); ok {
+		r0 = rf(ctx, platform, playerID, since, types...)
 	} else {
 		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time) error); ok {
-		r1 = rf(ctx, platform, playerID, since)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time, ...string) error); ok {
+		r1 = rf(ctx, platform, playerID, since, types...)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -164,6 +212,43 @@ func (_m *InfractionRepo) GetPlayerTotalInfractions(ctx context.Context, platfor
 	return r0, r1
 }
 
+// GetRevisions provides a mock function with given fields: ctx, id
+func (_m *InfractionRepo) GetRevisions(ctx context.Context, id int64) ([]*domain.InfractionRevision, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 []*domain.InfractionRevision
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*domain.InfractionRevision); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.InfractionRevision)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Import provides a mock function with given fields: ctx, infractions
+func (_m *InfractionRepo) Import(ctx context.Context, infractions []*domain.Infraction) error {
+	ret := _m.Called(ctx, infractions)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*domain.Infraction) error); ok {
+		r0 = rf(ctx, infractions)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // LinkChatMessages provides a mock function with given fields: ctx, id, messageIDs
 func (_m *InfractionRepo) LinkChatMessages(ctx context.Context, id int64, messageIDs ...int64) error {
 	_va := make([]interface{}, len(messageIDs))
@@ -185,6 +270,73 @@ func (_m *InfractionRepo) LinkChatMessages(ctx context.Context, id int64, messag
 	return r0
 }
 
+// MarkExpired provides a mock function with given fields: ctx, id, expiredAt
+func (_m *InfractionRepo) MarkExpired(ctx context.Context, id int64, expiredAt time.Time) error {
+	ret := _m.Called(ctx, id, expiredAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Time) error); ok {
+		r0 = rf(ctx, id, expiredAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PurgeDeleted provides a mock function with given fields: ctx, before
+func (_m *InfractionRepo) PurgeDeleted(ctx context.Context, before time.Time) (int64, []string, error) {
+	ret := _m.Called(ctx, before)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 []string
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) []string); ok {
+		r1 = rf(ctx, before)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]string)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, time.Time) error); ok {
+		r2 = rf(ctx, before)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Restore provides a mock function with given fields: ctx, id
+func (_m *InfractionRepo) Restore(ctx context.Context, id int64) (*domain.Infraction, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *domain.Infraction
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.Infraction); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Infraction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Search provides a mock function with given fields: ctx, args, serverIDs, limit, offset
 func (_m *InfractionRepo) Search(ctx context.Context, args domain.FindArgs, serverIDs []int64, limit int, offset int) (int, []*domain.Infraction, error) {
 	ret := _m.Called(ctx, args, serverIDs, limit, offset)
@@ -215,6 +367,29 @@ func (_m *InfractionRepo) Search(ctx context.Context, args domain.FindArgs, serv
 	return r0, r1, r2
 }
 
+// SoftDelete provides a mock function with given fields: ctx, id, deletedBy, reason
+func (_m *InfractionRepo) SoftDelete(ctx context.Context, id int64, deletedBy null.String, reason null.String) (*domain.Infraction, error) {
+	ret := _m.Called(ctx, id, deletedBy, reason)
+
+	var r0 *domain.Infraction
+	if rf, ok := ret.Get(0).(func(context.Context, int64, null.String, null.String) *domain.Infraction); ok {
+		r0 = rf(ctx, id, deletedBy, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Infraction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, null.String, null.String) error); ok {
+		r1 = rf(ctx, id, deletedBy, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Store provides a mock function with given fields: ctx, infraction
 func (_m *InfractionRepo) Store(ctx context.Context, infraction *domain.Infraction) (*domain.Infraction, error) {
 	ret := _m.Called(ctx, infraction)
@@ -238,6 +413,29 @@ func (_m *InfractionRepo) Store(ctx context.Context, infraction *domain.Infracti
 	return r0, r1
 }
 
+// StoreRevision provides a mock function with given fields: ctx, revision
+func (_m *InfractionRepo) StoreRevision(ctx context.Context, revision *domain.InfractionRevision) (*domain.InfractionRevision, error) {
+	ret := _m.Called(ctx, revision)
+
+	var r0 *domain.InfractionRevision
+	if rf, ok := ret.Get(0).(func(context.Context, *domain.InfractionRevision) *domain.InfractionRevision); ok {
+		r0 = rf(ctx, revision)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.InfractionRevision)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *domain.InfractionRevision) error); ok {
+		r1 = rf(ctx, revision)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UnlinkChatMessages provides a mock function with given fields: ctx, id, messageIDs
 func (_m *InfractionRepo) UnlinkChatMessages(ctx context.Context, id int64, messageIDs ...int64) error {
 	_va := make([]interface{}, len(messageIDs))
@@ -3,12 +3,16 @@
 package mocks
 
 import (
-	broadcast "Refractor/pkg/broadcast"
-	context "context"
-
 	domain "Refractor/domain"
+	context "context"
 
 	mock "github.com/stretchr/testify/mock"
+
+	broadcast "Refractor/pkg/broadcast"
+
+	io "io"
+
+	time "time"
 )
 
 // InfractionService is an autogenerated mock type for the InfractionService type
@@ -16,13 +20,27 @@ type InfractionService struct {
 	mock.Mock
 }
 
-// Delete provides a mock function with given fields: c, id
-func (_m *InfractionService) Delete(c context.Context, id int64) error {
-	ret := _m.Called(c, id)
+// Delete provides a mock function with given fields: c, id, reason
+func (_m *InfractionService) Delete(c context.Context, id int64, reason string) error {
+	ret := _m.Called(c, id, reason)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
-		r0 = rf(c, id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(c, id, reason)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExportInfractions provides a mock function with given fields: c, args, format, w
+func (_m *InfractionService) ExportInfractions(c context.Context, args domain.FindArgs, format string, w io.Writer) error {
+	ret := _m.Called(c, args, format, w)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, domain.FindArgs, string, io.Writer) error); ok {
+		r0 = rf(c, args, format, w)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -76,6 +94,105 @@ func (_m *InfractionService) GetByPlayer(c context.Context, playerID string, pla
 	return r0, r1
 }
 
+// GetCurrentBan provides a mock function with given fields: c, platform, playerID
+func (_m *InfractionService) GetCurrentBan(c context.Context, platform string, playerID string) (*domain.Infraction, error) {
+	ret := _m.Called(c, platform, playerID)
+
+	var r0 *domain.Infraction
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.Infraction); ok {
+		r0 = rf(c, platform, playerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Infraction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(c, platform, playerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCurrentMute provides a mock function with given fields: c, platform, playerID
+func (_m *InfractionService) GetCurrentMute(c context.Context, platform string, playerID string) (*domain.Infraction, error) {
+	ret := _m.Called(c, platform, playerID)
+
+	var r0 *domain.Infraction
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *domain.Infraction); ok {
+		r0 = rf(c, platform, playerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Infraction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(c, platform, playerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeleted provides a mock function with given fields: c, limit, offset
+func (_m *InfractionService) GetDeleted(c context.Context, limit int, offset int) (int, []*domain.Infraction, error) {
+	ret := _m.Called(c, limit, offset)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) int); ok {
+		r0 = rf(c, limit, offset)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 []*domain.Infraction
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) []*domain.Infraction); ok {
+		r1 = rf(c, limit, offset)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]*domain.Infraction)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, int, int) error); ok {
+		r2 = rf(c, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetHistory provides a mock function with given fields: c, id
+func (_m *InfractionService) GetHistory(c context.Context, id int64) ([]*domain.InfractionRevision, error) {
+	ret := _m.Called(c, id)
+
+	var r0 []*domain.InfractionRevision
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*domain.InfractionRevision); ok {
+		r0 = rf(c, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.InfractionRevision)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(c, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetLinkedChatMessages provides a mock function with given fields: c, id
 func (_m *InfractionService) GetLinkedChatMessages(c context.Context, id int64) ([]*domain.ChatMessage, error) {
 	ret := _m.Called(c, id)
@@ -109,6 +226,29 @@ func (_m *InfractionService) HandlePlayerJoin(fields broadcast.Fields, serverID
 	_m.Called(fields, serverID, game)
 }
 
+// ImportInfractions provides a mock function with given fields: c, serverID, format, mapIssuers, file
+func (_m *InfractionService) ImportInfractions(c context.Context, serverID int64, format string, mapIssuers bool, file io.Reader) (*domain.InfractionImportResult, error) {
+	ret := _m.Called(c, serverID, format, mapIssuers, file)
+
+	var r0 *domain.InfractionImportResult
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, bool, io.Reader) *domain.InfractionImportResult); ok {
+		r0 = rf(c, serverID, format, mapIssuers, file)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.InfractionImportResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, bool, io.Reader) error); ok {
+		r1 = rf(c, serverID, format, mapIssuers, file)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // LinkChatMessages provides a mock function with given fields: c, id, messageIDs
 func (_m *InfractionService) LinkChatMessages(c context.Context, id int64, messageIDs ...int64) error {
 	_va := make([]interface{}, len(messageIDs))
@@ -130,69 +270,59 @@ func (_m *InfractionService) LinkChatMessages(c context.Context, id int64, messa
 	return r0
 }
 
-// PlayerIsBanned provides a mock function with given fields: c, platform, playerID
-func (_m *InfractionService) PlayerIsBanned(c context.Context, platform string, playerID string) (bool, int64, error) {
-	ret := _m.Called(c, platform, playerID)
-
-	var r0 bool
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
-		r0 = rf(c, platform, playerID)
-	} else {
-		r0 = ret.Get(0).(bool)
-	}
+// PreviewCommands provides a mock function with given fields: c, serverID, action, draft
+func (_m *InfractionService) PreviewCommands(c context.Context, serverID int64, action string, draft *domain.CustomInfractionPayload) ([]*domain.CommandPreview, error) {
+	ret := _m.Called(c, serverID, action, draft)
 
-	var r1 int64
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) int64); ok {
-		r1 = rf(c, platform, playerID)
+	var r0 []*domain.CommandPreview
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, *domain.CustomInfractionPayload) []*domain.CommandPreview); ok {
+		r0 = rf(c, serverID, action, draft)
 	} else {
-		r1 = ret.Get(1).(int64)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.CommandPreview)
+		}
 	}
 
-	var r2 error
-	if rf, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
-		r2 = rf(c, platform, playerID)
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, *domain.CustomInfractionPayload) error); ok {
+		r1 = rf(c, serverID, action, draft)
 	} else {
-		r2 = ret.Error(2)
+		r1 = ret.Error(1)
 	}
 
-	return r0, r1, r2
+	return r0, r1
 }
 
-// PlayerIsMuted provides a mock function with given fields: c, platform, playerID
-func (_m *InfractionService) PlayerIsMuted(c context.Context, platform string, playerID string) (bool, int64, error) {
-	ret := _m.Called(c, platform, playerID)
-
-	var r0 bool
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
-		r0 = rf(c, platform, playerID)
-	} else {
-		r0 = ret.Get(0).(bool)
-	}
+// Restore provides a mock function with given fields: c, id
+func (_m *InfractionService) Restore(c context.Context, id int64) (*domain.Infraction, error) {
+	ret := _m.Called(c, id)
 
-	var r1 int64
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) int64); ok {
-		r1 = rf(c, platform, playerID)
+	var r0 *domain.Infraction
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.Infraction); ok {
+		r0 = rf(c, id)
 	} else {
-		r1 = ret.Get(1).(int64)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Infraction)
+		}
 	}
 
-	var r2 error
-	if rf, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
-		r2 = rf(c, platform, playerID)
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(c, id)
 	} else {
-		r2 = ret.Error(2)
+		r1 = ret.Error(1)
 	}
 
-	return r0, r1, r2
+	return r0, r1
 }
 
-// SetRepealed provides a mock function with given fields: c, id, repealed
-func (_m *InfractionService) SetRepealed(c context.Context, id int64, repealed bool) (*domain.Infraction, error) {
-	ret := _m.Called(c, id, repealed)
+// SetRepealed provides a mock function with given fields: c, id, repealed, note
+func (_m *InfractionService) SetRepealed(c context.Context, id int64, repealed bool, note string) (*domain.Infraction, error) {
+	ret := _m.Called(c, id, repealed, note)
 
 	var r0 *domain.Infraction
-	if rf, ok := ret.Get(0).(func(context.Context, int64, bool) *domain.Infraction); ok {
-		r0 = rf(c, id, repealed)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool, string) *domain.Infraction); ok {
+		r0 = rf(c, id, repealed, note)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Infraction)
@@ -200,8 +330,8 @@ func (_m *InfractionService) SetRepealed(c context.Context, id int64, repealed b
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, int64, bool) error); ok {
-		r1 = rf(c, id, repealed)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, bool, string) error); ok {
+		r1 = rf(c, id, repealed, note)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -209,6 +339,16 @@ func (_m *InfractionService) SetRepealed(c context.Context, id int64, repealed b
 	return r0, r1
 }
 
+// StartDeletedPurger provides a mock function with given fields: retention, terminate
+func (_m *InfractionService) StartDeletedPurger(retention time.Duration, terminate chan uint8) {
+	_m.Called(retention, terminate)
+}
+
+// StartExpiryWatcher provides a mock function with given fields: terminate
+func (_m *InfractionService) StartExpiryWatcher(terminate chan uint8) {
+	_m.Called(terminate)
+}
+
 // Store provides a mock function with given fields: c, infraction, attachments, linkedMessages
 func (_m *InfractionService) Store(c context.Context, infraction *domain.Infraction, attachments []*domain.Attachment, linkedMessages []int64) (*domain.Infraction, error) {
 	ret := _m.Called(c, infraction, attachments, linkedMessages)
@@ -237,6 +377,11 @@ func (_m *InfractionService) SubscribeInfractionCreate(sub domain.InfractionSubs
 	_m.Called(sub)
 }
 
+// SubscribeInfractionExpire provides a mock function with given fields: sub
+func (_m *InfractionService) SubscribeInfractionExpire(sub domain.InfractionSubscriber) {
+	_m.Called(sub)
+}
+
 // UnlinkChatMessages provides a mock function with given fields: c, id, messageIDs
 func (_m *InfractionService) UnlinkChatMessages(c context.Context, id int64, messageIDs ...int64) error {
 	_va := make([]interface{}, len(messageIDs))
@@ -258,13 +403,13 @@ func (_m *InfractionService) UnlinkChatMessages(c context.Context, id int64, mes
 	return r0
 }
 
-// Update provides a mock function with given fields: c, id, args
-func (_m *InfractionService) Update(c context.Context, id int64, args domain.UpdateArgs) (*domain.Infraction, error) {
-	ret := _m.Called(c, id, args)
+// Update provides a mock function with given fields: c, id, args, note
+func (_m *InfractionService) Update(c context.Context, id int64, args domain.UpdateArgs, note string) (*domain.Infraction, error) {
+	ret := _m.Called(c, id, args, note)
 
 	var r0 *domain.Infraction
-	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.UpdateArgs) *domain.Infraction); ok {
-		r0 = rf(c, id, args)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, domain.UpdateArgs, string) *domain.Infraction); ok {
+		r0 = rf(c, id, args, note)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*domain.Infraction)
@@ -272,8 +417,8 @@ func (_m *InfractionService) Update(c context.Context, id int64, args domain.Upd
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, int64, domain.UpdateArgs) error); ok {
-		r1 = rf(c, id, args)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, domain.UpdateArgs, string) error); ok {
+		r1 = rf(c, id, args, note)
 	} else {
 		r1 = ret.Error(1)
 	}
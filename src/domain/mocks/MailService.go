@@ -2,7 +2,11 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	domain "Refractor/domain"
+
+	mock "github.com/stretchr/testify/mock"
+)
 
 // MailService is an autogenerated mock type for the MailService type
 type MailService struct {
@@ -23,6 +27,20 @@ func (_m *MailService) SendMail(to []string, sub string, body string) error {
 	return r0
 }
 
+// SendWatchedPlayerEmail provides a mock function with given fields: to, alert, serverName
+func (_m *MailService) SendWatchedPlayerEmail(to []string, alert *domain.WatchedPlayerAlert, serverName string) error {
+	ret := _m.Called(to, alert, serverName)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]string, *domain.WatchedPlayerAlert, string) error); ok {
+		r0 = rf(to, alert, serverName)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SendWelcomeEmail provides a mock function with given fields: to, inviterName, link
 func (_m *MailService) SendWelcomeEmail(to string, inviterName string, link string) error {
 	ret := _m.Called(to, inviterName, link)
@@ -136,6 +136,36 @@ func (_m *ServerService) GetByID(c context.Context, id int64) (*domain.Server, e
 	return r0, r1
 }
 
+// GetCommandHistory provides a mock function with given fields: c, serverID, limit, offset
+func (_m *ServerService) GetCommandHistory(c context.Context, serverID int64, limit int, offset int) (int, []*domain.QueuedCommand, error) {
+	ret := _m.Called(c, serverID, limit, offset)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int, int) int); ok {
+		r0 = rf(c, serverID, limit, offset)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 []*domain.QueuedCommand
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int, int) []*domain.QueuedCommand); ok {
+		r1 = rf(c, serverID, limit, offset)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]*domain.QueuedCommand)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, int64, int, int) error); ok {
+		r2 = rf(c, serverID, limit, offset)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetServerData provides a mock function with given fields: id
 func (_m *ServerService) GetServerData(id int64) (*domain.ServerData, error) {
 	ret := _m.Called(id)
@@ -3,6 +3,7 @@
 package mocks
 
 import (
+	domain "Refractor/domain"
 	context "context"
 
 	mock "github.com/stretchr/testify/mock"
@@ -15,6 +16,29 @@ type StatsRepo struct {
 	mock.Mock
 }
 
+// GetRuleStatsInRange provides a mock function with given fields: ctx, start, end
+func (_m *StatsRepo) GetRuleStatsInRange(ctx context.Context, start time.Time, end time.Time) ([]*domain.RuleStats, error) {
+	ret := _m.Called(ctx, start, end)
+
+	var r0 []*domain.RuleStats
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, time.Time) []*domain.RuleStats); ok {
+		r0 = rf(ctx, start, end)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.RuleStats)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, time.Time) error); ok {
+		r1 = rf(ctx, start, end)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTotalChatMessages provides a mock function with given fields: ctx
 func (_m *StatsRepo) GetTotalChatMessages(ctx context.Context) (int, error) {
 	ret := _m.Called(ctx)
@@ -37,6 +37,27 @@ func (_m *UserMetaRepo) GetByID(ctx context.Context, userID string) (*domain.Use
 	return r0, r1
 }
 
+// GetIDByUsername provides a mock function with given fields: ctx, username
+func (_m *UserMetaRepo) GetIDByUsername(ctx context.Context, username string) (string, error) {
+	ret := _m.Called(ctx, username)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, username)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetLinkedPlayers provides a mock function with given fields: ctx, userID
 func (_m *UserMetaRepo) GetLinkedPlayers(ctx context.Context, userID string) ([]*domain.Player, error) {
 	ret := _m.Called(ctx, userID)
@@ -60,6 +81,27 @@ func (_m *UserMetaRepo) GetLinkedPlayers(ctx context.Context, userID string) ([]
 	return r0, r1
 }
 
+// GetLinkedUser provides a mock function with given fields: ctx, platform, playerID
+func (_m *UserMetaRepo) GetLinkedUser(ctx context.Context, platform string, playerID string) (string, error) {
+	ret := _m.Called(ctx, platform, playerID)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, platform, playerID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, platform, playerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUsername provides a mock function with given fields: ctx, userID
 func (_m *UserMetaRepo) GetUsername(ctx context.Context, userID string) (string, error) {
 	ret := _m.Called(ctx, userID)
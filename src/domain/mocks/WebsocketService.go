@@ -40,11 +40,26 @@ func (_m *WebsocketService) CreateClient(userID string, conn net.Conn) {
 	_m.Called(userID, conn)
 }
 
+// HandleAppealUpdate provides a mock function with given fields: appeal
+func (_m *WebsocketService) HandleAppealUpdate(appeal *domain.Appeal) {
+	_m.Called(appeal)
+}
+
+// HandleFederatedBanAlert provides a mock function with given fields: alert
+func (_m *WebsocketService) HandleFederatedBanAlert(alert *domain.FederatedBanAlert) {
+	_m.Called(alert)
+}
+
 // HandleInfractionCreate provides a mock function with given fields: infraction
 func (_m *WebsocketService) HandleInfractionCreate(infraction *domain.Infraction) {
 	_m.Called(infraction)
 }
 
+// HandleInfractionExpire provides a mock function with given fields: infraction
+func (_m *WebsocketService) HandleInfractionExpire(infraction *domain.Infraction) {
+	_m.Called(infraction)
+}
+
 // HandlePlayerJoin provides a mock function with given fields: fields, serverID, game
 func (_m *WebsocketService) HandlePlayerJoin(fields broadcast.Fields, serverID int64, game domain.Game) {
 	_m.Called(fields, serverID, game)
@@ -60,11 +75,21 @@ func (_m *WebsocketService) HandlePlayerQuit(fields broadcast.Fields, serverID i
 	_m.Called(fields, serverID, game)
 }
 
+// HandlePlayerReport provides a mock function with given fields: report
+func (_m *WebsocketService) HandlePlayerReport(report *domain.Report) {
+	_m.Called(report)
+}
+
 // HandleServerStatusChange provides a mock function with given fields: serverID, status
 func (_m *WebsocketService) HandleServerStatusChange(serverID int64, status string) {
 	_m.Called(serverID, status)
 }
 
+// HandleWatchedPlayerAlert provides a mock function with given fields: alert
+func (_m *WebsocketService) HandleWatchedPlayerAlert(alert *domain.WatchedPlayerAlert) {
+	_m.Called(alert)
+}
+
 // SendDirectMessage provides a mock function with given fields: message, userID
 func (_m *WebsocketService) SendDirectMessage(message *domain.WebsocketMessage, userID string) {
 	_m.Called(message, userID)
@@ -79,3 +104,8 @@ func (_m *WebsocketService) StartPool() {
 func (_m *WebsocketService) SubscribeChatSend(sub domain.ChatSendSubscriber) {
 	_m.Called(sub)
 }
+
+// SubscribeConsoleCommand provides a mock function with given fields: sub
+func (_m *WebsocketService) SubscribeConsoleCommand(sub domain.ConsoleCommandSubscriber) {
+	_m.Called(sub)
+}
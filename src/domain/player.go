@@ -44,6 +44,14 @@ type Player struct {
 	LastSeen      time.Time `json:"last_seen"`
 	CreatedAt     time.Time `json:"created_at"`
 	ModifiedAt    time.Time `json:"modified_at"`
+
+	// Playtime is not a DB field. It is populated when a single player is fetched.
+	Playtime *PlayerPlaytime `json:"playtime,omitempty"`
+
+	// Notes and Tags are not DB fields. They are populated when a single player is fetched by a user who is allowed
+	// to view player notes.
+	Notes []*PlayerNote `json:"notes,omitempty"`
+	Tags  []string      `json:"tags,omitempty"`
 }
 
 func (pp *PlayerPayload) GetPlayer() *Player {
@@ -91,6 +99,7 @@ type PlayerRepo interface {
 	Exists(ctx context.Context, args FindArgs) (bool, error)
 	Update(ctx context.Context, platform, id string, args UpdateArgs) (*Player, error)
 	SearchByName(ctx context.Context, name string, limit, offset int) (int, []*Player, error)
+	SearchByTag(ctx context.Context, tag, platform string, limit, offset int) (int, []*Player, error)
 }
 
 type PlayerNameRepo interface {
@@ -102,14 +111,25 @@ type PlayerNameRepo interface {
 type PlayerService interface {
 	HandlePlayerJoin(fields broadcast.Fields, serverID int64, game Game)
 	HandlePlayerQuit(fields broadcast.Fields, serverID int64, game Game)
+	HandlePlayerListUpdate(serverID int64, players []*OnlinePlayer, game Game)
+	HandleServerStatusChange(serverID int64, status string)
 	GetPlayer(c context.Context, id, platform string) (*Player, error)
+	GetNotes(c context.Context, platform, id string) ([]*PlayerNote, error)
+	CreateNote(c context.Context, platform, id, note string, pinned bool) (*PlayerNote, error)
+	UpdateNote(c context.Context, platform, id string, noteID int64, args UpdateArgs) (*PlayerNote, error)
+	DeleteNote(c context.Context, platform, id string, noteID int64) error
+	GetTags(c context.Context, platform, id string) ([]*PlayerTag, error)
+	AddTag(c context.Context, platform, id, tag string) (*PlayerTag, error)
+	RemoveTag(c context.Context, platform, id, tag string) error
 }
 
 // PlayerPayload embeds the Player struct and provides additional fields for stats relevant to frontend applications.
 type PlayerPayload struct {
 	*Player
-	InfractionCount              int `json:"infraction_count"`
-	InfractionCountSinceTimespan int `json:"infraction_count_since_timespan"`
+	InfractionCount              int             `json:"infraction_count"`
+	InfractionCountSinceTimespan int             `json:"infraction_count_since_timespan"`
+	PossibleLinks                []*PossibleLink `json:"possible_links"`
+	FederatedBans                []*FederatedBan `json:"federated_bans"`
 }
 
 // Implement player interface on player types
@@ -0,0 +1,63 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package domain
+
+import (
+	"context"
+	"github.com/guregu/null"
+	"time"
+)
+
+type PlayerNote struct {
+	NoteID     int64     `json:"id"`
+	PlayerID   string    `json:"player_id"`
+	Platform   string    `json:"platform"`
+	UserID     string    `json:"user_id"`
+	Note       string    `json:"note"`
+	Pinned     bool      `json:"pinned"`
+	CreatedAt  time.Time `json:"created_at"`
+	ModifiedAt null.Time `json:"modified_at"`
+	AuthorName string    `json:"author_name,omitempty"` // AuthorName is not a DB field. It is populated manually.
+}
+
+type PlayerTag struct {
+	PlayerID  string    `json:"player_id"`
+	Platform  string    `json:"platform"`
+	Tag       string    `json:"tag"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PlayerNoteRepo interface {
+	Store(ctx context.Context, note *PlayerNote) (*PlayerNote, error)
+	GetByID(ctx context.Context, id int64) (*PlayerNote, error)
+
+	// GetByPlayer returns a player's notes with pinned notes first, then newest first.
+	GetByPlayer(ctx context.Context, platform, playerID string) ([]*PlayerNote, error)
+	Update(ctx context.Context, id int64, args UpdateArgs) (*PlayerNote, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+type PlayerTagRepo interface {
+	// Store adds a tag to a player. If the player already has the tag, ErrConflict is returned.
+	Store(ctx context.Context, tag *PlayerTag) error
+	GetByPlayer(ctx context.Context, platform, playerID string) ([]*PlayerTag, error)
+
+	// Delete removes a tag from a player. Tags are matched case-insensitively.
+	Delete(ctx context.Context, platform, playerID, tag string) error
+}
@@ -0,0 +1,85 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package domain
+
+import (
+	"context"
+	"github.com/guregu/null"
+	"time"
+)
+
+// Player session end reasons
+const (
+	SessionEndQuit       = "QUIT"       // the player left the server
+	SessionEndDisconnect = "DISCONNECT" // Refractor lost its connection to the server
+	SessionEndDesync     = "DESYNC"     // the player was no longer online when the player list was refreshed
+)
+
+type PlayerSession struct {
+	SessionID int64       `json:"id"`
+	PlayerID  string      `json:"player_id"`
+	Platform  string      `json:"platform"`
+	ServerID  int64       `json:"server_id"`
+	StartedAt time.Time   `json:"started_at"`
+	EndedAt   null.Time   `json:"ended_at"`
+	EndReason null.String `json:"end_reason"`
+}
+
+// IsOpen returns true if the session has not ended yet.
+func (s *PlayerSession) IsOpen() bool {
+	return !s.EndedAt.Valid
+}
+
+// ServerPlaytime is the total number of seconds a player has spent on a server.
+type ServerPlaytime struct {
+	ServerID int64 `json:"server_id"`
+	Playtime int64 `json:"playtime"`
+}
+
+// PlayerPlaytime summarises the time a player has spent across all servers. Playtime values are in seconds and
+// include any sessions which are still open.
+type PlayerPlaytime struct {
+	Total     int64             `json:"total"`
+	Servers   []*ServerPlaytime `json:"servers"`
+	FirstSeen time.Time         `json:"first_seen"`
+	Sessions  []*PlayerSession  `json:"sessions"`
+}
+
+type PlayerSessionRepo interface {
+	// Start opens a new session for the player on the given server. If the player already has an open session on the
+	// server, no session is opened and ErrConflict is returned.
+	Start(ctx context.Context, platform, playerID string, serverID int64) (*PlayerSession, error)
+
+	// End closes any open sessions the player has on the given server.
+	End(ctx context.Context, platform, playerID string, serverID int64, reason string) error
+
+	// EndByIDs closes the open sessions with the provided IDs.
+	EndByIDs(ctx context.Context, reason string, sessionIDs ...int64) error
+
+	// EndAllOnServer closes every open session on the given server.
+	EndAllOnServer(ctx context.Context, serverID int64, reason string) error
+
+	// GetOpenOnServer returns every open session on the given server.
+	GetOpenOnServer(ctx context.Context, serverID int64) ([]*PlayerSession, error)
+
+	// GetByPlayer returns the player's most recent sessions, newest first.
+	GetByPlayer(ctx context.Context, platform, playerID string, limit int) ([]*PlayerSession, error)
+
+	// GetPlaytime returns the player's total playtime on each server they have played on.
+	GetPlaytime(ctx context.Context, platform, playerID string) ([]*ServerPlaytime, error)
+}
@@ -0,0 +1,98 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package domain
+
+import (
+	"context"
+	"github.com/guregu/null"
+)
+
+const (
+	ReportStatusOpen     = "open"
+	ReportStatusClaimed  = "claimed"
+	ReportStatusResolved = "resolved"
+)
+
+var AllReportStatuses = []string{ReportStatusOpen, ReportStatusClaimed, ReportStatusResolved}
+
+// ReportChatContextSize is the number of recent chat messages on a server which are linked to a report made on it.
+const ReportChatContextSize = 20
+
+// Report is a report made by a player against another player in-game using their game's report command.
+type Report struct {
+	ReportID     int64       `json:"id"`
+	ServerID     int64       `json:"server_id"`
+	Platform     string      `json:"platform"`
+	ReporterID   string      `json:"reporter_id"` // ReporterID is the player ID of the player who made the report
+	TargetID     string      `json:"target_id"`   // TargetID is the player ID of the reported player
+	Reason       string      `json:"reason"`
+	Status       string      `json:"status"`
+	ClaimedBy    null.String `json:"claimed_by"`
+	ClaimedAt    null.Time   `json:"claimed_at"`
+	ResolvedBy   null.String `json:"resolved_by"`
+	ResolvedAt   null.Time   `json:"resolved_at"`
+	Resolution   null.String `json:"resolution"`
+	InfractionID null.Int    `json:"infraction_id"` // InfractionID is set if the report was converted into an infraction
+	CreatedAt    null.Time   `json:"created_at"`
+	ModifiedAt   null.Time   `json:"modified_at"`
+
+	// The following fields are not DB fields. They do not get scanned. They are populated manually.
+	ReporterName string         `json:"reporter_name,omitempty"`
+	TargetName   string         `json:"target_name,omitempty"`
+	ChatMessages []*ChatMessage `json:"chat_messages,omitempty"`
+}
+
+// ReportCommand is a parsed use of the report command by a player in-game.
+type ReportCommand struct {
+	Command      string // Command is the report command which was used, e.g. "!report"
+	ServerID     int64
+	Platform     string
+	ReporterID   string
+	ReporterName string
+	Target       string // Target is the name of the reported player as it was typed by the reporter
+	Reason       string
+}
+
+type ReportRepo interface {
+	Store(ctx context.Context, report *Report) (*Report, error)
+	GetByID(ctx context.Context, id int64) (*Report, error)
+	Update(ctx context.Context, id int64, args UpdateArgs) (*Report, error)
+
+	// Search returns reports matching the provided args. If serverIDs is not empty, only reports made on these servers
+	// are returned.
+	Search(ctx context.Context, args FindArgs, serverIDs []int64, limit, offset int) (int, []*Report, error)
+	LinkChatMessages(ctx context.Context, id int64, messageIDs ...int64) error
+	GetLinkedChatMessages(ctx context.Context, id int64) ([]*ChatMessage, error)
+}
+
+type ReportService interface {
+	// HandleReportCommand creates a report from a use of the report command. The reported player is resolved from the
+	// players online on the server and the reporter is told the outcome in-game.
+	HandleReportCommand(command *ReportCommand, game Game)
+	GetByID(c context.Context, id int64) (*Report, error)
+	Search(c context.Context, args FindArgs, limit, offset int) (int, []*Report, error)
+	Claim(c context.Context, id int64) (*Report, error)
+	Resolve(c context.Context, id int64, resolution string) (*Report, error)
+
+	// Convert creates an infraction against the reported player with the report's chat linked to it, and resolves the
+	// report.
+	Convert(c context.Context, id int64, infraction *Infraction) (*Report, error)
+	SubscribeReportCreate(sub ReportSubscriber)
+}
+
+type ReportSubscriber func(report *Report)
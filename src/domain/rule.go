@@ -0,0 +1,91 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package domain
+
+import (
+	"context"
+	"github.com/guregu/null"
+)
+
+// Rule is a server rule in the rule catalogue. Infractions can reference the rule which was broken.
+type Rule struct {
+	RuleID      int64             `json:"id"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Suggestions []*RuleSuggestion `json:"suggestions"`
+	CreatedAt   null.Time         `json:"created_at"`
+	ModifiedAt  null.Time         `json:"modified_at"`
+}
+
+// RuleSuggestion is the default punishment for breaking a rule for the nth time. The suggestion with the highest
+// offense number which is not above the player's offense number applies.
+type RuleSuggestion struct {
+	Offense  int      `json:"offense"`
+	Type     string   `json:"type"`
+	Duration null.Int `json:"duration"`
+}
+
+// GetSuggestion returns the suggestion which applies to the provided offense number. If no suggestion applies, nil is
+// returned.
+func (r *Rule) GetSuggestion(offense int) *RuleSuggestion {
+	var suggestion *RuleSuggestion
+
+	for _, s := range r.Suggestions {
+		if s.Offense > offense {
+			continue
+		}
+
+		if suggestion == nil || s.Offense > suggestion.Offense {
+			suggestion = s
+		}
+	}
+
+	return suggestion
+}
+
+// RuleSuggestionResult is the punishment suggested for a specific player breaking a rule.
+type RuleSuggestionResult struct {
+	RuleID int64 `json:"rule_id"`
+
+	// Offense is the offense number the player would be on if they were punished for breaking the rule again.
+	Offense    int             `json:"offense"`
+	Suggestion *RuleSuggestion `json:"suggestion"`
+}
+
+type RuleRepo interface {
+	Store(ctx context.Context, rule *Rule) (*Rule, error)
+	GetAll(ctx context.Context) ([]*Rule, error)
+	GetByID(ctx context.Context, id int64) (*Rule, error)
+	Update(ctx context.Context, id int64, args UpdateArgs) (*Rule, error)
+	Delete(ctx context.Context, id int64) error
+
+	// GetPlayerOffenseCount returns the number of non-repealed infractions a player has received for breaking a rule.
+	GetPlayerOffenseCount(ctx context.Context, id int64, platform, playerID string) (int, error)
+}
+
+type RuleService interface {
+	Store(c context.Context, rule *Rule) (*Rule, error)
+	GetAll(c context.Context) ([]*Rule, error)
+	GetByID(c context.Context, id int64) (*Rule, error)
+	Update(c context.Context, id int64, args UpdateArgs) (*Rule, error)
+	Delete(c context.Context, id int64) error
+
+	// GetSuggestion returns the punishment suggested for a player breaking a rule based on how many times they have
+	// been punished for breaking it before.
+	GetSuggestion(c context.Context, id int64, platform, playerID string) (*RuleSuggestionResult, error)
+}
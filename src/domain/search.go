@@ -23,4 +23,5 @@ type SearchService interface {
 	SearchPlayers(c context.Context, term, searchType, platform string, limit, offset int) (int, []*Player, error)
 	SearchInfractions(c context.Context, args FindArgs, limit, offset int) (int, []*Infraction, error)
 	SearchChatMessages(c context.Context, args FindArgs, limit, offset int) (int, []*ChatMessage, error)
+	SearchAppeals(c context.Context, args FindArgs, limit, offset int) (int, []*Appeal, error)
 }
@@ -114,4 +114,8 @@ type ServerService interface {
 	HandleServerStatusChange(serverID int64, status string)
 	HandlePlayerListUpdate(serverID int64, players []*OnlinePlayer, game Game)
 	SubscribeServerUpdate(sub ServerUpdateSubscriber)
+
+	// GetCommandHistory returns a page of the commands which were queued for the provided server along with the
+	// total number of commands queued for the server.
+	GetCommandHistory(c context.Context, serverID int64, limit, offset int) (int, []*QueuedCommand, error)
 }
@@ -33,6 +33,13 @@ type Stats struct {
 	NewChatMessagesLastDay   int `json:"new_chat_messages_last_day"`
 }
 
+// RuleStats is the number of infractions issued for breaking a rule. Repealed infractions are not counted.
+type RuleStats struct {
+	RuleID      int64  `json:"rule_id"`
+	Title       string `json:"title"`
+	Infractions int    `json:"infractions"`
+}
+
 type StatsRepo interface {
 	GetTotalPlayers(ctx context.Context) (int, error)
 	GetTotalInfractions(ctx context.Context) (int, error)
@@ -41,8 +48,16 @@ type StatsRepo interface {
 	GetUniquePlayersInRange(ctx context.Context, start, end time.Time) (int, error)
 	GetTotalChatMessages(ctx context.Context) (int, error)
 	GetTotalChatMessagesInRange(ctx context.Context, start, end time.Time) (int, error)
+
+	// GetRuleStatsInRange returns the infraction count of every rule for infractions created within the provided range,
+	// most broken rules first.
+	GetRuleStatsInRange(ctx context.Context, start, end time.Time) ([]*RuleStats, error)
 }
 
 type StatsService interface {
 	GetStats(c context.Context) (*Stats, error)
+
+	// GetRuleStats returns the infraction count of every rule over the last number of days. If days is 0, all
+	// infractions are counted.
+	GetRuleStats(c context.Context, days int) ([]*RuleStats, error)
 }
@@ -47,6 +47,12 @@ type UserMetaRepo interface {
 	LinkPlayer(ctx context.Context, userID, platform, playerID string) error
 	UnlinkPlayer(ctx context.Context, userID, platform, playerID string) error
 	GetLinkedPlayers(ctx context.Context, userID string) ([]*Player, error)
+
+	// GetLinkedUser returns the ID of the user a player is linked to.
+	GetLinkedUser(ctx context.Context, platform, playerID string) (string, error)
+
+	// GetIDByUsername returns the ID of the user with the provided username. The match is case-insensitive.
+	GetIDByUsername(ctx context.Context, username string) (string, error)
 }
 
 type UserService interface {
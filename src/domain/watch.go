@@ -0,0 +1,85 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package domain
+
+import (
+	"Refractor/pkg/broadcast"
+	"context"
+	"github.com/guregu/null"
+	"time"
+)
+
+// Watched player alert events
+const (
+	WatchEventJoin = "JOIN"
+	WatchEventChat = "CHAT"
+)
+
+type PlayerWatch struct {
+	PlayerID  string      `json:"player_id"`
+	Platform  string      `json:"platform"`
+	UserID    string      `json:"user_id"` // UserID is the ID of the user who started watching the player
+	Reason    null.String `json:"reason"`
+	ExpiresAt null.Time   `json:"expires_at"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// IsExpired returns true if the watch has an expiry time which has passed.
+func (w *PlayerWatch) IsExpired() bool {
+	return w.ExpiresAt.Valid && w.ExpiresAt.Time.Before(time.Now())
+}
+
+// WatchedPlayerAlert is sent when a watched player joins a server or sends a chat message.
+type WatchedPlayerAlert struct {
+	Event      string       `json:"event"`
+	ServerID   int64        `json:"server_id"`
+	PlayerID   string       `json:"player_id"`
+	Platform   string       `json:"platform"`
+	PlayerName string       `json:"player_name"`
+	Message    string       `json:"message,omitempty"` // Message is only set for chat alerts
+	Watch      *PlayerWatch `json:"watch"`
+}
+
+type WatchedPlayerSubscriber func(alert *WatchedPlayerAlert)
+
+type WatchRepo interface {
+	// Store creates or replaces the watch of a player.
+	Store(ctx context.Context, watch *PlayerWatch) error
+	GetByPlayer(ctx context.Context, platform, playerID string) (*PlayerWatch, error)
+	Delete(ctx context.Context, platform, playerID string) error
+
+	// DeleteExpired removes every watch which expired before the provided time and clears the Watched flag of each of
+	// their players. The number of removed watches is returned.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+	Subscribe(ctx context.Context, userID string) error
+	Unsubscribe(ctx context.Context, userID string) error
+	IsSubscribed(ctx context.Context, userID string) (bool, error)
+	GetSubscribers(ctx context.Context) ([]string, error)
+}
+
+type WatchService interface {
+	Watch(c context.Context, platform, playerID, reason string, duration int64) (*PlayerWatch, error)
+	Unwatch(c context.Context, platform, playerID string) error
+	GetWatch(c context.Context, platform, playerID string) (*PlayerWatch, error)
+	SetAlertSubscription(c context.Context, subscribed bool) error
+	IsSubscribed(c context.Context) (bool, error)
+	HandlePlayerJoin(fields broadcast.Fields, serverID int64, game Game)
+	HandleChatReceive(body *ChatReceiveBody, serverID int64, game Game)
+	SubscribeWatchedPlayerAlert(sub WatchedPlayerSubscriber)
+	StartExpiryWatcher(terminate chan uint8)
+}
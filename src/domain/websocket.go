@@ -54,5 +54,11 @@ type WebsocketService interface {
 	HandleServerStatusChange(serverID int64, status string)
 	HandlePlayerListUpdate(serverID int64, players []*OnlinePlayer, game Game)
 	HandleInfractionCreate(infraction *Infraction)
+	HandleInfractionExpire(infraction *Infraction)
+	HandleAppealUpdate(appeal *Appeal)
+	HandleWatchedPlayerAlert(alert *WatchedPlayerAlert)
+	HandleFederatedBanAlert(alert *FederatedBanAlert)
+	HandlePlayerReport(report *Report)
 	SubscribeChatSend(sub ChatSendSubscriber)
+	SubscribeConsoleCommand(sub ConsoleCommandSubscriber)
 }
@@ -142,6 +142,7 @@ func (g *minecraft) GetDefaultSettings() *domain.GameSettings {
 			EnableMuteSync:            true,
 			PlayerInfractionThreshold: 10,
 			PlayerInfractionTimespan:  4320, // 3 days
+			ReportCommand:             "!report",
 		},
 	}
 }
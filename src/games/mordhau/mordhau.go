@@ -39,14 +39,16 @@ func NewMordhauGame(platform domain.Platform) domain.Game {
 			UseRCON:                   true,
 			AlivePingInterval:         time.Second * 10,
 			EnableBroadcasts:          true,
-			RCONInitCommands:          []string{"listen login", "listen chat"},
+			RCONInitCommands:          []string{"listen login", "listen chat", "listen punishment"},
 			PlayerListRefreshInterval: time.Minute * 2,
 			EnableChat:                true,
 			BroadcastPatterns: map[string]*regexp.Regexp{
 				broadcast.TypeJoin: regexp.MustCompile("^Login: (?P<Date>[0-9\\.-]+): (?P<Name>.+) \\((?P<PlayerID>[0-9a-fA-F]+)\\) logged in$"),
 				broadcast.TypeQuit: regexp.MustCompile("^Login: (?P<Date>[0-9\\.-]+): (?P<Name>.+) \\((?P<PlayerID>[0-9a-fA-F]+)\\) logged out$"),
-				broadcast.TypeChat: regexp.MustCompile("^Chat: (?P<PlayerID>[0-9a-fA-F]+), (?P<Name>.+), \\((?P<Channel>.+)\\) (?P<Message>.+)$"),
-				//broadcast.TypeBan: regexp.MustCompile("^Punishment: Admin (?P<AdminName>.+) \\((?P<AdminPlayerID>[0-9a-fA-F]+)\\) banned player (?P<PlayerID>[0-9a-fA-F]+) \\(Duration: (?P<Duration>\\d+), Reason: (?P<Reason>.+)\\)$"),
+				broadcast.TypeChat: regexp.MustCompile("^Chat: (?P<PlayerID>[0-9a-fA-F]+), (?P<Name>.+?), \\((?P<Channel>All|Team|Squad)\\) (?P<Message>.+)$"),
+				broadcast.TypeBan:  regexp.MustCompile("^Punishment: Admin (?P<AdminName>.+) \\((?P<AdminPlayerID>[0-9a-fA-F]+)\\) banned player (?P<PlayerID>[0-9a-fA-F]+) \\(Duration: (?P<Duration>\\d+), Reason: (?P<Reason>.*)\\)$"),
+				broadcast.TypeKick: regexp.MustCompile("^Punishment: Admin (?P<AdminName>.+) \\((?P<AdminPlayerID>[0-9a-fA-F]+)\\) kicked player (?P<PlayerID>[0-9a-fA-F]+) \\(Reason: (?P<Reason>.*)\\)$"),
+				broadcast.TypeMute: regexp.MustCompile("^Punishment: Admin (?P<AdminName>.+) \\((?P<AdminPlayerID>[0-9a-fA-F]+)\\) muted player (?P<PlayerID>[0-9a-fA-F]+) \\(Duration: (?P<Duration>\\d+)\\)$"),
 			},
 			IgnoredBroadcastPatterns: []*regexp.Regexp{
 				regexp.MustCompile("Keeping client alive for another [0-9]+ seconds"),
@@ -176,6 +178,7 @@ func (g *mordhau) GetDefaultSettings() *domain.GameSettings {
 			EnableMuteSync:            true,
 			PlayerInfractionThreshold: 10,
 			PlayerInfractionTimespan:  4320, // 3 days
+			ReportCommand:             "!report",
 		},
 	}
 }
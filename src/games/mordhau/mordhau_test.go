@@ -0,0 +1,124 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package mordhau
+
+import (
+	"Refractor/domain"
+	"Refractor/pkg/broadcast"
+	"reflect"
+	"testing"
+)
+
+func TestChatBroadcastPattern(t *testing.T) {
+	patterns := NewMordhauGame(nil).GetConfig().BroadcastPatterns
+
+	tests := []struct {
+		name      string
+		broadcast string
+		want      broadcast.Fields
+	}{
+		{
+			name:      "mordhau.chat.1",
+			broadcast: "Chat: 1ab, Bob, (All) hello there",
+			want: broadcast.Fields{
+				"PlayerID": "1ab",
+				"Name":     "Bob",
+				"Channel":  "All",
+				"Message":  "hello there",
+			},
+		},
+		{
+			name:      "mordhau.chat.2",
+			broadcast: "Chat: 1ab, Bob, (All) hi (lol) ok",
+			want: broadcast.Fields{
+				"PlayerID": "1ab",
+				"Name":     "Bob",
+				"Channel":  "All",
+				"Message":  "hi (lol) ok",
+			},
+		},
+		{
+			name:      "mordhau.chat.3",
+			broadcast: "Chat: 1ab, Bob, (Team) wait, (All) ok then",
+			want: broadcast.Fields{
+				"PlayerID": "1ab",
+				"Name":     "Bob",
+				"Channel":  "Team",
+				"Message":  "wait, (All) ok then",
+			},
+		},
+		{
+			name:      "mordhau.chat.4",
+			broadcast: "Chat: 1ab, Bob, (Team 2) hi",
+			want:      nil,
+		},
+		{
+			name:      "mordhau.chat.5",
+			broadcast: "Chat: 1ab, Bob, (Whisper) hi",
+			want:      nil,
+		},
+		{
+			// A name containing a channel marker can't be told apart from a message containing one, so the shortest
+			// name is matched. The RCON service splits these again using the name of the online player.
+			name:      "mordhau.chat.6",
+			broadcast: "Chat: 1ab, Evil, (All) Bob, (Team) hi",
+			want: broadcast.Fields{
+				"PlayerID": "1ab",
+				"Name":     "Evil",
+				"Channel":  "All",
+				"Message":  "Bob, (Team) hi",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := broadcast.GetBroadcastType(tt.broadcast, patterns)
+
+			if tt.want == nil {
+				if got != nil {
+					t.Errorf("GetBroadcastType() = %v, want nil", got)
+				}
+				return
+			}
+
+			if got == nil || got.Type != broadcast.TypeChat || !reflect.DeepEqual(got.Fields, tt.want) {
+				t.Errorf("GetBroadcastType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeChatChannel(t *testing.T) {
+	tests := []struct {
+		channel string
+		want    string
+	}{
+		{channel: "All", want: domain.ChatChannelAll},
+		{channel: " Team ", want: domain.ChatChannelTeam},
+		{channel: "", want: domain.ChatChannelAll},
+		{channel: "All) hi (lol", want: domain.ChatChannelAll},
+		{channel: "Unknown", want: domain.ChatChannelAll},
+	}
+	for _, tt := range tests {
+		t.Run(tt.channel, func(t *testing.T) {
+			if got := domain.NormalizeChatChannel(tt.channel); got != tt.want {
+				t.Errorf("NormalizeChatChannel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package http
+
+import (
+	"Refractor/authcheckers"
+	"Refractor/domain"
+	"Refractor/params"
+	"Refractor/pkg/api"
+	"Refractor/pkg/api/middleware"
+	"Refractor/pkg/perms"
+	"context"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type altHandler struct {
+	service domain.AltService
+	logger  *zap.Logger
+}
+
+func ApplyAltHandler(apiGroup *echo.Group, s domain.AltService, a domain.Authorizer, mware domain.Middleware, log *zap.Logger) {
+	handler := &altHandler{
+		service: s,
+		logger:  log,
+	}
+
+	// Player links are managed on the player routing group
+	playerGroup := apiGroup.Group("/players", mware.ProtectMiddleware, mware.ActivationMiddleware)
+
+	// Create an enforcer to authorize the user on the various endpoints
+	enforcer := middleware.NewEnforcer(a, domain.AuthScope{
+		Type: domain.AuthObjRefractor,
+	}, log)
+
+	canView := enforcer.CheckAuth(authcheckers.CanViewPlayerRecords)
+	canLink := enforcer.CheckAuth(authcheckers.HasPermission(perms.FlagLinkPlayers, true))
+
+	playerGroup.GET("/:platform/:id/links", handler.GetLinks, canView)
+	playerGroup.GET("/:platform/:id/links/possible", handler.GetPossibleLinks, canView)
+	playerGroup.POST("/:platform/:id/links", handler.ConfirmLink, canLink)
+	playerGroup.DELETE("/:platform/:id/links/:linkId", handler.RemoveLink, canLink)
+}
+
+func (h *altHandler) GetLinks(c echo.Context) error {
+	platform, id, err := api.GetPlayerParams(c)
+	if err != nil {
+		return err
+	}
+
+	links, err := h.service.GetLinks(c.Request().Context(), platform, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: links,
+	})
+}
+
+func (h *altHandler) GetPossibleLinks(c echo.Context) error {
+	platform, id, err := api.GetPlayerParams(c)
+	if err != nil {
+		return err
+	}
+
+	possibleLinks, err := h.service.GetPossibleLinks(c.Request().Context(), platform, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: possibleLinks,
+	})
+}
+
+func (h *altHandler) ConfirmLink(c echo.Context) error {
+	platform, id, err := api.GetPlayerParams(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate request body
+	var body params.ConfirmPlayerLinkParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	link, err := h.service.ConfirmLink(ctx, platform, id, strings.ToLower(strings.TrimSpace(body.Platform)),
+		strings.TrimSpace(body.PlayerID), strings.TrimSpace(body.Note))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Players linked",
+		Payload: link,
+	})
+}
+
+func (h *altHandler) RemoveLink(c echo.Context) error {
+	platform, id, err := api.GetPlayerParams(c)
+	if err != nil {
+		return err
+	}
+
+	linkID, err := strconv.ParseInt(c.Param("linkId"), 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid link id"), http.StatusBadRequest, "")
+	}
+
+	if err := h.service.RemoveLink(c.Request().Context(), platform, id, linkID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Link removed",
+	})
+}
@@ -0,0 +1,256 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+const opTag = "AltRepo.Postgres."
+
+const pgUniqueViolationCode = "23505"
+
+type altRepo struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewAltRepo(db *sql.DB, logger *zap.Logger) domain.AltRepo {
+	return &altRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *altRepo) GetSharedNameCandidates(ctx context.Context, platform, playerID string, limit int) ([]*domain.AltCandidate, error) {
+	const op = opTag + "GetSharedNameCandidates"
+
+	query := `SELECT o.Platform, o.PlayerID, COUNT(DISTINCT LOWER(o.Name)) AS Matches FROM PlayerNames pn
+			JOIN PlayerNames o ON LOWER(o.Name) = LOWER(pn.Name) AND NOT (o.Platform = pn.Platform AND o.PlayerID = pn.PlayerID)
+			WHERE pn.Platform = $1 AND pn.PlayerID = $2
+			GROUP BY o.Platform, o.PlayerID
+			ORDER BY Matches DESC LIMIT $3;`
+
+	results, err := r.fetchCandidates(ctx, query, platform, playerID, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return results, nil
+}
+
+// GetSessionHandoffCandidates only considers sessions which ended because the player quit. Sessions which were closed
+// by a server disconnect end at the same time for every player, so they would match everyone who rejoined afterwards.
+func (r *altRepo) GetSessionHandoffCandidates(ctx context.Context, platform, playerID string, window time.Duration,
+	limit int) ([]*domain.AltCandidate, error) {
+	const op = opTag + "GetSessionHandoffCandidates"
+
+	query := `SELECT o.Platform, o.PlayerID, COUNT(*) AS Matches FROM PlayerSessions s
+			JOIN PlayerSessions o ON o.ServerID = s.ServerID AND NOT (o.Platform = s.Platform AND o.PlayerID = s.PlayerID)
+				AND (
+					(s.EndReason = $3 AND o.StartedAt BETWEEN s.EndedAt AND s.EndedAt + make_interval(secs => $4))
+					OR (o.EndReason = $3 AND s.StartedAt BETWEEN o.EndedAt AND o.EndedAt + make_interval(secs => $4))
+				)
+			WHERE s.Platform = $1 AND s.PlayerID = $2
+			GROUP BY o.Platform, o.PlayerID
+			ORDER BY Matches DESC LIMIT $5;`
+
+	results, err := r.fetchCandidates(ctx, query, platform, playerID, domain.SessionEndQuit, window.Seconds(), limit)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return results, nil
+}
+
+// GetBanEvasionCandidates only considers repealed bans as not having been evaded. A player is considered to have been
+// seen on a server if they have at least one session on it.
+func (r *altRepo) GetBanEvasionCandidates(ctx context.Context, platform, playerID string, window time.Duration,
+	limit int) ([]*domain.AltCandidate, error) {
+	const op = opTag + "GetBanEvasionCandidates"
+
+	query := `SELECT Platform, PlayerID, COUNT(*) AS Matches FROM (
+				SELECT p.Platform, p.PlayerID FROM Infractions i
+				JOIN Players p ON p.CreatedAt BETWEEN i.CreatedAt AND i.CreatedAt + make_interval(secs => $4)
+					AND NOT (p.Platform = i.Platform AND p.PlayerID = i.PlayerID)
+				WHERE i.Platform = $1 AND i.PlayerID = $2 AND i.Type = $3 AND i.Repealed = FALSE AND i.DeletedAt IS NULL
+					AND EXISTS (SELECT 1 FROM PlayerSessions ps WHERE ps.Platform = p.Platform
+						AND ps.PlayerID = p.PlayerID AND ps.ServerID = i.ServerID)
+				UNION ALL
+				SELECT i.Platform, i.PlayerID FROM Players p
+				JOIN Infractions i ON p.CreatedAt BETWEEN i.CreatedAt AND i.CreatedAt + make_interval(secs => $4)
+					AND NOT (i.Platform = p.Platform AND i.PlayerID = p.PlayerID)
+				WHERE p.Platform = $1 AND p.PlayerID = $2 AND i.Type = $3 AND i.Repealed = FALSE AND i.DeletedAt IS NULL
+					AND EXISTS (SELECT 1 FROM PlayerSessions ps WHERE ps.Platform = p.Platform
+						AND ps.PlayerID = p.PlayerID AND ps.ServerID = i.ServerID)
+			) AS Evasions
+			GROUP BY Platform, PlayerID
+			ORDER BY Matches DESC LIMIT $5;`
+
+	results, err := r.fetchCandidates(ctx, query, platform, playerID, domain.InfractionTypeBan, window.Seconds(), limit)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return results, nil
+}
+
+func (r *altRepo) fetchCandidates(ctx context.Context, query string, args ...interface{}) ([]*domain.AltCandidate, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.AltCandidate, 0)
+	for rows.Next() {
+		candidate := &domain.AltCandidate{}
+
+		if err := rows.Scan(&candidate.Platform, &candidate.PlayerID, &candidate.Count); err != nil {
+			r.logger.Error("Could not scan alt candidate", zap.Error(err))
+			return nil, err
+		}
+
+		results = append(results, candidate)
+	}
+
+	return results, nil
+}
+
+func (r *altRepo) StoreLink(ctx context.Context, link *domain.PlayerLink) error {
+	const op = opTag + "StoreLink"
+
+	query := `INSERT INTO PlayerLinks (PlayerID, Platform, LinkedPlayerID, LinkedPlatform, UserID, Note)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING LinkID, CreatedAt;`
+
+	row := r.db.QueryRowContext(ctx, query, link.PlayerID, link.Platform, link.LinkedPlayerID, link.LinkedPlatform,
+		link.UserID, link.Note)
+	if err := row.Scan(&link.LinkID, &link.CreatedAt); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == pgUniqueViolationCode {
+			return errors.Wrap(domain.ErrConflict, op)
+		}
+
+		r.logger.Error("Could not store player link", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *altRepo) GetLinkByID(ctx context.Context, id int64) (*domain.PlayerLink, error) {
+	const op = opTag + "GetLinkByID"
+
+	query := "SELECT * FROM PlayerLinks WHERE LinkID = $1;"
+
+	results, err := r.fetchLinks(ctx, query, id)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if len(results) == 0 {
+		return nil, errors.Wrap(domain.ErrNotFound, op)
+	}
+
+	return results[0], nil
+}
+
+// GetLinks returns the confirmed links of a player regardless of which side of the link the player is stored on.
+func (r *altRepo) GetLinks(ctx context.Context, platform, playerID string) ([]*domain.PlayerLink, error) {
+	const op = opTag + "GetLinks"
+
+	query := `SELECT * FROM PlayerLinks
+			WHERE (Platform = $1 AND PlayerID = $2) OR (LinkedPlatform = $1 AND LinkedPlayerID = $2)
+			ORDER BY CreatedAt DESC;`
+
+	results, err := r.fetchLinks(ctx, query, platform, playerID)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return results, nil
+}
+
+func (r *altRepo) DeleteLink(ctx context.Context, id int64) error {
+	const op = opTag + "DeleteLink"
+
+	query := "DELETE FROM PlayerLinks WHERE LinkID = $1;"
+
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Could not get affected rows", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	if rowsAffected < 1 {
+		return errors.Wrap(domain.ErrNotFound, op)
+	}
+
+	return nil
+}
+
+func (r *altRepo) fetchLinks(ctx context.Context, query string, args ...interface{}) ([]*domain.PlayerLink, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.PlayerLink, 0)
+	for rows.Next() {
+		link := &domain.PlayerLink{}
+
+		if err := r.scanRowsLink(rows, link); err != nil {
+			r.logger.Error("Could not scan player link", zap.Error(err))
+			return nil, err
+		}
+
+		results = append(results, link)
+	}
+
+	return results, nil
+}
+
+// Scan helpers
+func (r *altRepo) scanRowsLink(rows *sql.Rows, link *domain.PlayerLink) error {
+	return rows.Scan(&link.LinkID, &link.PlayerID, &link.Platform, &link.LinkedPlayerID, &link.LinkedPlatform,
+		&link.UserID, &link.Note, &link.CreatedAt)
+}
@@ -0,0 +1,208 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/franela/goblin"
+	"github.com/guregu/null"
+	"github.com/lib/pq"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var candidateCols = []string{"Platform", "PlayerID", "Matches"}
+	var linkCols = []string{"LinkID", "PlayerID", "Platform", "LinkedPlayerID", "LinkedPlatform", "UserID", "Note",
+		"CreatedAt"}
+	var ctx = context.TODO()
+
+	g.Describe("Alt Postgres Repo", func() {
+		var repo domain.AltRepo
+		var mock sqlmock.Sqlmock
+		var db *sql.DB
+
+		g.BeforeEach(func() {
+			var err error
+
+			db, mock, err = sqlmock.New()
+			if err != nil {
+				t.Fatalf("Could not create new sqlmock instance. Error: %v", err)
+			}
+
+			repo = NewAltRepo(db, zap.NewNop())
+		})
+
+		g.After(func() {
+			_ = db.Close()
+		})
+
+		g.Describe("GetSharedNameCandidates()", func() {
+			g.It("Should return the matched players", func() {
+				mock.ExpectQuery("SELECT (.+) FROM PlayerNames").WithArgs("platform", "playerid", 10).
+					WillReturnRows(sqlmock.NewRows(candidateCols).
+						AddRow("platform", "alt1", 3).
+						AddRow("platform", "alt2", 1))
+
+				candidates, err := repo.GetSharedNameCandidates(ctx, "platform", "playerid", 10)
+
+				Expect(err).To(BeNil())
+				Expect(candidates).To(Equal([]*domain.AltCandidate{
+					{PlayerID: "alt1", Platform: "platform", Count: 3},
+					{PlayerID: "alt2", Platform: "platform", Count: 1},
+				}))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("GetSessionHandoffCandidates()", func() {
+			g.It("Should only match sessions which ended with a quit", func() {
+				mock.ExpectQuery("SELECT (.+) FROM PlayerSessions").
+					WithArgs("platform", "playerid", domain.SessionEndQuit, float64(120), 10).
+					WillReturnRows(sqlmock.NewRows(candidateCols).AddRow("platform", "alt1", 4))
+
+				candidates, err := repo.GetSessionHandoffCandidates(ctx, "platform", "playerid", time.Minute*2, 10)
+
+				Expect(err).To(BeNil())
+				Expect(candidates).To(HaveLen(1))
+				Expect(candidates[0].Count).To(Equal(4))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("GetBanEvasionCandidates()", func() {
+			g.It("Should match against bans", func() {
+				mock.ExpectQuery("SELECT (.+) FROM Infractions").
+					WithArgs("platform", "playerid", domain.InfractionTypeBan, float64(86400), 10).
+					WillReturnRows(sqlmock.NewRows(candidateCols).AddRow("platform", "alt1", 1))
+
+				candidates, err := repo.GetBanEvasionCandidates(ctx, "platform", "playerid", time.Hour*24, 10)
+
+				Expect(err).To(BeNil())
+				Expect(candidates).To(HaveLen(1))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("StoreLink()", func() {
+			var link *domain.PlayerLink
+
+			g.BeforeEach(func() {
+				link = &domain.PlayerLink{
+					PlayerID:       "playerid",
+					Platform:       "platform",
+					LinkedPlayerID: "alt1",
+					LinkedPlatform: "platform",
+					UserID:         "userid",
+					Note:           null.StringFrom("same IP"),
+				}
+			})
+
+			g.It("Should set the link ID and created at time", func() {
+				createdAt := time.Now()
+
+				mock.ExpectQuery("INSERT INTO PlayerLinks").
+					WithArgs("playerid", "platform", "alt1", "platform", "userid", link.Note).
+					WillReturnRows(sqlmock.NewRows([]string{"LinkID", "CreatedAt"}).AddRow(5, createdAt))
+
+				err := repo.StoreLink(ctx, link)
+
+				Expect(err).To(BeNil())
+				Expect(link.LinkID).To(Equal(int64(5)))
+				Expect(link.CreatedAt).To(Equal(createdAt))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return domain.ErrConflict if the link already exists", func() {
+				mock.ExpectQuery("INSERT INTO PlayerLinks").WillReturnError(&pq.Error{Code: pgUniqueViolationCode})
+
+				err := repo.StoreLink(ctx, link)
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrConflict))
+			})
+		})
+
+		g.Describe("GetLinkByID()", func() {
+			g.It("Should return the link", func() {
+				mock.ExpectQuery("SELECT (.+) FROM PlayerLinks").WithArgs(5).
+					WillReturnRows(sqlmock.NewRows(linkCols).
+						AddRow(5, "playerid", "platform", "alt1", "platform", "userid", nil, time.Now()))
+
+				link, err := repo.GetLinkByID(ctx, 5)
+
+				Expect(err).To(BeNil())
+				Expect(link.LinkedPlayerID).To(Equal("alt1"))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return domain.ErrNotFound if no link was found", func() {
+				mock.ExpectQuery("SELECT (.+) FROM PlayerLinks").WillReturnRows(sqlmock.NewRows(linkCols))
+
+				_, err := repo.GetLinkByID(ctx, 5)
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+			})
+		})
+
+		g.Describe("GetLinks()", func() {
+			g.It("Should return links on both sides of the player", func() {
+				mock.ExpectQuery("SELECT (.+) FROM PlayerLinks").WithArgs("platform", "playerid").
+					WillReturnRows(sqlmock.NewRows(linkCols).
+						AddRow(5, "playerid", "platform", "alt1", "platform", "userid", nil, time.Now()).
+						AddRow(6, "alt0", "platform", "playerid", "platform", "userid", "note", time.Now()))
+
+				links, err := repo.GetLinks(ctx, "platform", "playerid")
+
+				Expect(err).To(BeNil())
+				Expect(links).To(HaveLen(2))
+				Expect(links[1].Note).To(Equal(null.StringFrom("note")))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("DeleteLink()", func() {
+			g.It("Should not return an error if the link was deleted", func() {
+				mock.ExpectExec("DELETE FROM PlayerLinks").WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 1))
+
+				err := repo.DeleteLink(ctx, 5)
+
+				Expect(err).To(BeNil())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return domain.ErrNotFound if the link does not exist", func() {
+				mock.ExpectExec("DELETE FROM PlayerLinks").WillReturnResult(sqlmock.NewResult(0, 0))
+
+				err := repo.DeleteLink(ctx, 5)
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+			})
+		})
+	})
+}
@@ -0,0 +1,336 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/domain"
+	"context"
+	"fmt"
+	"github.com/guregu/null"
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"net/http"
+	"sort"
+	"time"
+)
+
+type altService struct {
+	repo           domain.AltRepo
+	playerRepo     domain.PlayerRepo
+	playerNameRepo domain.PlayerNameRepo
+	timeout        time.Duration
+	logger         *zap.Logger
+	cache          *gocache.Cache
+}
+
+const (
+	// candidateLimit is the maximum number of candidates fetched for each signal.
+	candidateLimit = 25
+
+	// maxPossibleLinks is the maximum number of unconfirmed possible links returned for a player.
+	maxPossibleLinks = 10
+
+	// minPossibleLinkScore is the lowest score an unconfirmed possible link can have to be returned.
+	minPossibleLinkScore = 20
+
+	// maxUnconfirmedScore is the highest score an unconfirmed possible link can have. A score of 100 is reserved for
+	// confirmed links.
+	maxUnconfirmedScore = 99
+
+	// sessionHandoffWindow is how soon after one player quits a server another player must join it for the two
+	// sessions to be considered a handoff.
+	sessionHandoffWindow = time.Minute * 2
+
+	// banEvasionWindow is how soon after a player is banned from a server a new player must show up on it to be
+	// considered a possible ban evasion.
+	banEvasionWindow = time.Hour * 24
+)
+
+// signalWeight holds how many points a single match of a signal adds to a possible link's score, and the maximum
+// number of points the signal can contribute in total.
+type signalWeight struct {
+	points int
+	max    int
+}
+
+var signalWeights = map[string]signalWeight{
+	domain.AltSignalSharedName:     {points: 25, max: 50},
+	domain.AltSignalSessionHandoff: {points: 15, max: 45},
+	domain.AltSignalBanEvasion:     {points: 40, max: 60},
+}
+
+func NewAltService(repo domain.AltRepo, pr domain.PlayerRepo, pnr domain.PlayerNameRepo, to time.Duration,
+	log *zap.Logger) domain.AltService {
+	return &altService{
+		repo:           repo,
+		playerRepo:     pr,
+		playerNameRepo: pnr,
+		timeout:        to,
+		logger:         log,
+		cache:          gocache.New(time.Minute*5, time.Minute*10),
+	}
+}
+
+// GetPossibleLinks returns the players who are likely to be alt accounts of the provided player. Confirmed links are
+// returned first with a score of 100, followed by the highest scoring unconfirmed links. Results are cached for a short
+// time since they are included in every player payload.
+func (s *altService) GetPossibleLinks(c context.Context, platform, playerID string) ([]*domain.PossibleLink, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	cacheKey := playerKey(platform, playerID)
+	if cached, found := s.cache.Get(cacheKey); found {
+		return cached.([]*domain.PossibleLink), nil
+	}
+
+	links, err := s.repo.GetLinks(ctx, platform, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	confirmed := make([]*domain.PossibleLink, 0)
+	confirmedKeys := map[string]bool{}
+
+	for _, link := range links {
+		linkedPlatform, linkedPlayerID := link.Other(platform, playerID)
+
+		confirmed = append(confirmed, &domain.PossibleLink{
+			PlayerID:  linkedPlayerID,
+			Platform:  linkedPlatform,
+			Score:     100,
+			Signals:   map[string]int{},
+			Confirmed: true,
+			LinkID:    link.LinkID,
+		})
+
+		confirmedKeys[playerKey(linkedPlatform, linkedPlayerID)] = true
+	}
+
+	candidateSets := map[string][]*domain.AltCandidate{}
+
+	if candidateSets[domain.AltSignalSharedName], err = s.repo.GetSharedNameCandidates(ctx, platform, playerID,
+		candidateLimit); err != nil {
+		return nil, err
+	}
+
+	if candidateSets[domain.AltSignalSessionHandoff], err = s.repo.GetSessionHandoffCandidates(ctx, platform, playerID,
+		sessionHandoffWindow, candidateLimit); err != nil {
+		return nil, err
+	}
+
+	if candidateSets[domain.AltSignalBanEvasion], err = s.repo.GetBanEvasionCandidates(ctx, platform, playerID,
+		banEvasionWindow, candidateLimit); err != nil {
+		return nil, err
+	}
+
+	possible := scoreCandidates(candidateSets, confirmedKeys)
+	if len(possible) > maxPossibleLinks {
+		possible = possible[:maxPossibleLinks]
+	}
+
+	results := append(confirmed, possible...)
+
+	for _, link := range results {
+		currentName, _, err := s.playerNameRepo.GetNames(ctx, link.PlayerID, link.Platform)
+		if err != nil {
+			s.logger.Warn("Could not get possible link current name",
+				zap.String("Platform", link.Platform),
+				zap.String("Player ID", link.PlayerID),
+				zap.Error(err))
+			continue
+		}
+
+		link.CurrentName = currentName
+	}
+
+	s.cache.SetDefault(cacheKey, results)
+
+	return results, nil
+}
+
+// scoreCandidates combines the candidates of each signal into possible links and scores them. Candidates who are
+// already confirmed links are skipped. The returned links are sorted by score in descending order.
+func scoreCandidates(candidateSets map[string][]*domain.AltCandidate, skip map[string]bool) []*domain.PossibleLink {
+	linkMap := map[string]*domain.PossibleLink{}
+
+	for signal, candidates := range candidateSets {
+		for _, candidate := range candidates {
+			key := playerKey(candidate.Platform, candidate.PlayerID)
+			if skip[key] {
+				continue
+			}
+
+			link, ok := linkMap[key]
+			if !ok {
+				link = &domain.PossibleLink{
+					PlayerID: candidate.PlayerID,
+					Platform: candidate.Platform,
+					Signals:  map[string]int{},
+				}
+				linkMap[key] = link
+			}
+
+			link.Signals[signal] += candidate.Count
+		}
+	}
+
+	results := make([]*domain.PossibleLink, 0)
+	for _, link := range linkMap {
+		score := 0
+		for signal, count := range link.Signals {
+			weight := signalWeights[signal]
+
+			points := count * weight.points
+			if points > weight.max {
+				points = weight.max
+			}
+
+			score += points
+		}
+
+		if score > maxUnconfirmedScore {
+			score = maxUnconfirmedScore
+		}
+
+		if score < minPossibleLinkScore {
+			continue
+		}
+
+		link.Score = score
+		results = append(results, link)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+
+		// Sort ties by player ID so results are consistent
+		return results[i].PlayerID < results[j].PlayerID
+	})
+
+	return results
+}
+
+func (s *altService) GetLinks(c context.Context, platform, playerID string) ([]*domain.PlayerLink, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.repo.GetLinks(ctx, platform, playerID)
+}
+
+// ConfirmLink records that two players belong to the same person. Each pair of players is stored once regardless of
+// which of the two the link was confirmed from.
+func (s *altService) ConfirmLink(c context.Context, platform, playerID, linkedPlatform, linkedPlayerID,
+	note string) (*domain.PlayerLink, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	user, ok := ctx.Value("user").(*domain.AuthUser)
+	if !ok {
+		return nil, errors.New("user not set in context")
+	}
+
+	if platform == linkedPlatform && playerID == linkedPlayerID {
+		return nil, domain.NewHTTPError(nil, http.StatusBadRequest, "A player cannot be linked to themselves")
+	}
+
+	for _, p := range [][2]string{{platform, playerID}, {linkedPlatform, linkedPlayerID}} {
+		exists, err := s.playerRepo.Exists(ctx, domain.FindArgs{
+			"Platform": p[0],
+			"PlayerID": p[1],
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			return nil, domain.NewHTTPError(domain.ErrNotFound, http.StatusNotFound, "Player not found")
+		}
+	}
+
+	link := &domain.PlayerLink{
+		PlayerID:       playerID,
+		Platform:       platform,
+		LinkedPlayerID: linkedPlayerID,
+		LinkedPlatform: linkedPlatform,
+		UserID:         user.Identity.Id,
+		Note:           null.NewString(note, note != ""),
+	}
+
+	// Store the lower player key first so each pair can only be stored once
+	if playerKey(linkedPlatform, linkedPlayerID) < playerKey(platform, playerID) {
+		link.PlayerID, link.LinkedPlayerID = linkedPlayerID, playerID
+		link.Platform, link.LinkedPlatform = linkedPlatform, platform
+	}
+
+	if err := s.repo.StoreLink(ctx, link); err != nil {
+		if errors.Cause(err) == domain.ErrConflict {
+			return nil, domain.NewHTTPError(err, http.StatusConflict, "These players are already linked")
+		}
+
+		return nil, err
+	}
+
+	s.clearCache(link)
+
+	return link, nil
+}
+
+// RemoveLink deletes a confirmed link. The link must belong to the provided player.
+func (s *altService) RemoveLink(c context.Context, platform, playerID string, linkID int64) error {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	link, err := s.repo.GetLinkByID(ctx, linkID)
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return domain.NewHTTPError(err, http.StatusNotFound, "Link not found")
+		}
+
+		return err
+	}
+
+	if !(link.Platform == platform && link.PlayerID == playerID) &&
+		!(link.LinkedPlatform == platform && link.LinkedPlayerID == playerID) {
+		return domain.NewHTTPError(domain.ErrNotFound, http.StatusNotFound, "Link not found")
+	}
+
+	if err := s.repo.DeleteLink(ctx, linkID); err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return domain.NewHTTPError(err, http.StatusNotFound, "Link not found")
+		}
+
+		return err
+	}
+
+	s.clearCache(link)
+
+	return nil
+}
+
+func (s *altService) clearCache(link *domain.PlayerLink) {
+	s.cache.Delete(playerKey(link.Platform, link.PlayerID))
+	s.cache.Delete(playerKey(link.LinkedPlatform, link.LinkedPlayerID))
+}
+
+// playerKey returns a key which uniquely identifies a player across platforms.
+func playerKey(platform, playerID string) string {
+	return fmt.Sprintf("%s:%s", platform, playerID)
+}
@@ -0,0 +1,258 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/domain"
+	"Refractor/domain/mocks"
+	"context"
+	"fmt"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	kratos "github.com/ory/kratos-client-go"
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Alt Service", func() {
+		var mockRepo *mocks.AltRepo
+		var playerRepo *mocks.PlayerRepo
+		var playerNameRepo *mocks.PlayerNameRepo
+		var service *altService
+		var ctx context.Context
+
+		g.BeforeEach(func() {
+			mockRepo = new(mocks.AltRepo)
+			playerRepo = new(mocks.PlayerRepo)
+			playerNameRepo = new(mocks.PlayerNameRepo)
+			service = &altService{
+				repo:           mockRepo,
+				playerRepo:     playerRepo,
+				playerNameRepo: playerNameRepo,
+				timeout:        time.Second * 2,
+				logger:         zap.NewNop(),
+				cache:          gocache.New(time.Minute, time.Minute),
+			}
+			ctx = context.WithValue(context.TODO(), "user", &domain.AuthUser{
+				Session: &kratos.Session{Identity: kratos.Identity{Id: "userid"}},
+			})
+		})
+
+		g.Describe("GetPossibleLinks()", func() {
+			g.Describe("Candidates found", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("GetLinks", mock.Anything, "platform", "playerid").Return([]*domain.PlayerLink{
+						{LinkID: 4, PlayerID: "confirmed", Platform: "platform", LinkedPlayerID: "playerid",
+							LinkedPlatform: "platform"},
+					}, nil)
+					mockRepo.On("GetSharedNameCandidates", mock.Anything, "platform", "playerid", candidateLimit).
+						Return([]*domain.AltCandidate{
+							{PlayerID: "alt1", Platform: "platform", Count: 1},
+							{PlayerID: "confirmed", Platform: "platform", Count: 3},
+						}, nil)
+					mockRepo.On("GetSessionHandoffCandidates", mock.Anything, "platform", "playerid",
+						sessionHandoffWindow, candidateLimit).
+						Return([]*domain.AltCandidate{
+							{PlayerID: "alt1", Platform: "platform", Count: 10},
+							{PlayerID: "alt2", Platform: "platform", Count: 1},
+						}, nil)
+					mockRepo.On("GetBanEvasionCandidates", mock.Anything, "platform", "playerid",
+						banEvasionWindow, candidateLimit).
+						Return([]*domain.AltCandidate{
+							{PlayerID: "alt3", Platform: "platform", Count: 1},
+						}, nil)
+					playerNameRepo.On("GetNames", mock.Anything, mock.Anything, "platform").Return("name", nil, nil)
+				})
+
+				g.It("Should return confirmed links first followed by scored candidates", func() {
+					links, err := service.GetPossibleLinks(ctx, "platform", "playerid")
+
+					Expect(err).To(BeNil())
+					Expect(links).To(HaveLen(3))
+
+					Expect(links[0].PlayerID).To(Equal("confirmed"))
+					Expect(links[0].Confirmed).To(BeTrue())
+					Expect(links[0].Score).To(Equal(100))
+					Expect(links[0].LinkID).To(Equal(int64(4)))
+
+					// 25 for one shared name plus 45 (capped) for session handoffs
+					Expect(links[1].PlayerID).To(Equal("alt1"))
+					Expect(links[1].Score).To(Equal(70))
+					Expect(links[1].Signals).To(Equal(map[string]int{
+						domain.AltSignalSharedName:     1,
+						domain.AltSignalSessionHandoff: 10,
+					}))
+					Expect(links[1].CurrentName).To(Equal("name"))
+
+					Expect(links[2].PlayerID).To(Equal("alt3"))
+					Expect(links[2].Score).To(Equal(40))
+				})
+
+				g.It("Should not include candidates below the minimum score", func() {
+					links, err := service.GetPossibleLinks(ctx, "platform", "playerid")
+
+					Expect(err).To(BeNil())
+					for _, link := range links {
+						Expect(link.PlayerID).ToNot(Equal("alt2"))
+					}
+				})
+
+				g.It("Should cache the results", func() {
+					_, err := service.GetPossibleLinks(ctx, "platform", "playerid")
+					Expect(err).To(BeNil())
+
+					_, err = service.GetPossibleLinks(ctx, "platform", "playerid")
+					Expect(err).To(BeNil())
+
+					mockRepo.AssertNumberOfCalls(t, "GetLinks", 1)
+				})
+			})
+
+			g.Describe("Repo error", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("GetLinks", mock.Anything, "platform", "playerid").Return([]*domain.PlayerLink{}, nil)
+					mockRepo.On("GetSharedNameCandidates", mock.Anything, "platform", "playerid", candidateLimit).
+						Return(nil, fmt.Errorf("err"))
+				})
+
+				g.It("Should return an error", func() {
+					_, err := service.GetPossibleLinks(ctx, "platform", "playerid")
+
+					Expect(err).ToNot(BeNil())
+				})
+			})
+		})
+
+		g.Describe("ConfirmLink()", func() {
+			g.Describe("Both players exist", func() {
+				g.BeforeEach(func() {
+					playerRepo.On("Exists", mock.Anything, mock.Anything).Return(true, nil)
+					mockRepo.On("StoreLink", mock.Anything, mock.Anything).Return(nil)
+				})
+
+				g.It("Should store the lower player key first", func() {
+					link, err := service.ConfirmLink(ctx, "platform", "playerb", "platform", "playera", "note")
+
+					Expect(err).To(BeNil())
+					Expect(link.PlayerID).To(Equal("playera"))
+					Expect(link.LinkedPlayerID).To(Equal("playerb"))
+					Expect(link.UserID).To(Equal("userid"))
+					Expect(link.Note.ValueOrZero()).To(Equal("note"))
+					mockRepo.AssertExpectations(t)
+				})
+
+				g.It("Should clear the cached possible links of both players", func() {
+					service.cache.SetDefault(playerKey("platform", "playera"), []*domain.PossibleLink{})
+					service.cache.SetDefault(playerKey("platform", "playerb"), []*domain.PossibleLink{})
+
+					_, err := service.ConfirmLink(ctx, "platform", "playera", "platform", "playerb", "")
+
+					Expect(err).To(BeNil())
+					Expect(service.cache.ItemCount()).To(Equal(0))
+				})
+			})
+
+			g.Describe("Players are already linked", func() {
+				g.BeforeEach(func() {
+					playerRepo.On("Exists", mock.Anything, mock.Anything).Return(true, nil)
+					mockRepo.On("StoreLink", mock.Anything, mock.Anything).Return(errors.Wrap(domain.ErrConflict, ""))
+				})
+
+				g.It("Should return a conflict error", func() {
+					_, err := service.ConfirmLink(ctx, "platform", "playera", "platform", "playerb", "")
+
+					httpErr, ok := errors.Cause(err).(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.Status).To(Equal(http.StatusConflict))
+				})
+			})
+
+			g.Describe("Linked player does not exist", func() {
+				g.BeforeEach(func() {
+					playerRepo.On("Exists", mock.Anything, domain.FindArgs{
+						"Platform": "platform",
+						"PlayerID": "playera",
+					}).Return(true, nil)
+					playerRepo.On("Exists", mock.Anything, domain.FindArgs{
+						"Platform": "platform",
+						"PlayerID": "playerb",
+					}).Return(false, nil)
+				})
+
+				g.It("Should return a not found error", func() {
+					_, err := service.ConfirmLink(ctx, "platform", "playera", "platform", "playerb", "")
+
+					httpErr, ok := errors.Cause(err).(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.Status).To(Equal(http.StatusNotFound))
+					mockRepo.AssertNotCalled(t, "StoreLink", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Player linked to themselves", func() {
+				g.It("Should return a bad request error", func() {
+					_, err := service.ConfirmLink(ctx, "platform", "playera", "platform", "playera", "")
+
+					httpErr, ok := errors.Cause(err).(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.Status).To(Equal(http.StatusBadRequest))
+				})
+			})
+		})
+
+		g.Describe("RemoveLink()", func() {
+			g.BeforeEach(func() {
+				mockRepo.On("GetLinkByID", mock.Anything, int64(4)).Return(&domain.PlayerLink{
+					LinkID:         4,
+					PlayerID:       "playera",
+					Platform:       "platform",
+					LinkedPlayerID: "playerb",
+					LinkedPlatform: "platform",
+				}, nil)
+				mockRepo.On("DeleteLink", mock.Anything, int64(4)).Return(nil)
+			})
+
+			g.It("Should delete the link if it belongs to the player", func() {
+				err := service.RemoveLink(ctx, "platform", "playerb", 4)
+
+				Expect(err).To(BeNil())
+				mockRepo.AssertCalled(t, "DeleteLink", mock.Anything, int64(4))
+			})
+
+			g.It("Should return a not found error if the link belongs to another player", func() {
+				err := service.RemoveLink(ctx, "platform", "playerc", 4)
+
+				httpErr, ok := errors.Cause(err).(*domain.HTTPError)
+				Expect(ok).To(BeTrue())
+				Expect(httpErr.Status).To(Equal(http.StatusNotFound))
+				mockRepo.AssertNotCalled(t, "DeleteLink", mock.Anything, mock.Anything)
+			})
+		})
+	})
+}
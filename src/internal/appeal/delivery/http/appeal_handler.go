@@ -0,0 +1,211 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package http
+
+import (
+	"Refractor/domain"
+	"Refractor/params"
+	"Refractor/pkg/api"
+	"context"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type appealHandler struct {
+	service domain.AppealService
+	logger  *zap.Logger
+}
+
+// ApplyAppealHandler registers the appeal endpoints. Permissions are checked by the appeal service since the server an
+// appeal belongs to is only known once the related infraction has been fetched.
+func ApplyAppealHandler(apiGroup *echo.Group, s domain.AppealService, a domain.Authorizer, mware domain.Middleware, log *zap.Logger) {
+	handler := &appealHandler{
+		service: s,
+		logger:  log,
+	}
+
+	// Create the appeal routing group
+	appealGroup := apiGroup.Group("/appeals", mware.ProtectMiddleware, mware.ActivationMiddleware)
+
+	appealGroup.POST("/infraction/:id", handler.CreateAppeal)
+	appealGroup.GET("/infraction/:id", handler.GetInfractionAppeals)
+	appealGroup.GET("/:id", handler.GetAppeal)
+	appealGroup.POST("/:id/comments", handler.AddComment)
+	appealGroup.POST("/:id/status", handler.SetStatus)
+}
+
+func (h *appealHandler) CreateAppeal(c echo.Context) error {
+	infractionIDString := c.Param("id")
+
+	infractionID, err := strconv.ParseInt(infractionIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid infraction id"), http.StatusBadRequest, "")
+	}
+
+	// Validate request body
+	var body params.CreateAppealParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	appeal, err := h.service.Store(ctx, infractionID, body.Statement)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Appeal created",
+		Payload: appeal,
+	})
+}
+
+func (h *appealHandler) GetInfractionAppeals(c echo.Context) error {
+	infractionIDString := c.Param("id")
+
+	infractionID, err := strconv.ParseInt(infractionIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid infraction id"), http.StatusBadRequest, "")
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	appeals, err := h.service.GetByInfraction(ctx, infractionID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: appeals,
+	})
+}
+
+func (h *appealHandler) GetAppeal(c echo.Context) error {
+	appealIDString := c.Param("id")
+
+	appealID, err := strconv.ParseInt(appealIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid appeal id"), http.StatusBadRequest, "")
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	appeal, err := h.service.GetByID(ctx, appealID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: appeal,
+	})
+}
+
+func (h *appealHandler) AddComment(c echo.Context) error {
+	appealIDString := c.Param("id")
+
+	appealID, err := strconv.ParseInt(appealIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid appeal id"), http.StatusBadRequest, "")
+	}
+
+	// Validate request body
+	var body params.AppealCommentParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	comment, err := h.service.AddComment(ctx, appealID, body.Comment)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Comment added",
+		Payload: comment,
+	})
+}
+
+func (h *appealHandler) SetStatus(c echo.Context) error {
+	appealIDString := c.Param("id")
+
+	appealID, err := strconv.ParseInt(appealIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid appeal id"), http.StatusBadRequest, "")
+	}
+
+	// Validate request body
+	var body params.SetAppealStatusParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	appeal, err := h.service.SetStatus(ctx, appealID, body.Status, body.Comment)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Appeal status updated",
+		Payload: appeal,
+	})
+}
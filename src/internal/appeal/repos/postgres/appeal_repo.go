@@ -0,0 +1,309 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"Refractor/pkg/querybuilders/psqlqb"
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const opTag = "AppealRepo.Postgres."
+
+const pgUniqueViolationCode = "23505"
+
+type appealRepo struct {
+	db     *sql.DB
+	logger *zap.Logger
+	qb     domain.QueryBuilder
+}
+
+func NewAppealRepo(db *sql.DB, logger *zap.Logger) domain.AppealRepo {
+	return &appealRepo{
+		db:     db,
+		logger: logger,
+		qb:     psqlqb.NewPostgresQueryBuilder(),
+	}
+}
+
+func (r *appealRepo) fetch(ctx context.Context, query string, args ...interface{}) ([]*domain.Appeal, error) {
+	const op = opTag + "Fetch"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	// Clean up on function exit
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.Appeal, 0)
+	for rows.Next() {
+		appeal := &domain.Appeal{}
+
+		if err := r.scanRows(rows, appeal); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, errors.Wrap(domain.ErrNotFound, op)
+			}
+
+			return nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, appeal)
+	}
+
+	return results, nil
+}
+
+func (r *appealRepo) Store(ctx context.Context, appeal *domain.Appeal) (*domain.Appeal, error) {
+	const op = opTag + "Store"
+
+	query := `INSERT INTO Appeals (InfractionID, UserID, Status, Statement) VALUES ($1, $2, $3, $4) RETURNING *;`
+
+	stmt, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Could not prepare statement", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	row := stmt.QueryRowContext(ctx, appeal.InfractionID, appeal.UserID, appeal.Status, appeal.Statement)
+
+	newAppeal := &domain.Appeal{}
+	if err := r.scanRow(row, newAppeal); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == pgUniqueViolationCode {
+			// the infraction already has an open appeal
+			return nil, errors.Wrap(domain.ErrConflict, op)
+		}
+
+		r.logger.Error("Could not scan newly created appeal", zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return newAppeal, nil
+}
+
+func (r *appealRepo) GetByID(ctx context.Context, id int64) (*domain.Appeal, error) {
+	const op = opTag + "GetByID"
+
+	query := "SELECT * FROM Appeals WHERE AppealID = $1;"
+
+	results, err := r.fetch(ctx, query, id)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if len(results) > 0 {
+		return results[0], nil
+	}
+
+	return nil, errors.Wrap(domain.ErrNotFound, op)
+}
+
+func (r *appealRepo) GetByInfraction(ctx context.Context, infractionID int64) ([]*domain.Appeal, error) {
+	const op = opTag + "GetByInfraction"
+
+	query := "SELECT * FROM Appeals WHERE InfractionID = $1 ORDER BY CreatedAt DESC;"
+
+	results, err := r.fetch(ctx, query, infractionID)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if len(results) > 0 {
+		return results, nil
+	}
+
+	return nil, errors.Wrap(domain.ErrNotFound, op)
+}
+
+func (r *appealRepo) Update(ctx context.Context, id int64, args domain.UpdateArgs) (*domain.Appeal, error) {
+	const op = opTag + "Update"
+
+	query, values := r.qb.BuildUpdateQuery("Appeals", id, "AppealID", args, nil)
+
+	stmt, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Could not prepare statement", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	row := stmt.QueryRowContext(ctx, values...)
+
+	updated := &domain.Appeal{}
+	if err := r.scanRow(row, updated); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.Wrap(domain.ErrNotFound, op)
+		}
+
+		r.logger.Error("Could not scan updated appeal", zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return updated, nil
+}
+
+func (r *appealRepo) StoreComment(ctx context.Context, comment *domain.AppealComment) (*domain.AppealComment, error) {
+	const op = opTag + "StoreComment"
+
+	query := `INSERT INTO AppealComments (AppealID, UserID, Comment, NewStatus) VALUES ($1, $2, $3, $4)
+			RETURNING CommentID, AppealID, UserID, Comment, NewStatus, CreatedAt;`
+
+	row := r.db.QueryRowContext(ctx, query, comment.AppealID, comment.UserID, comment.Comment, comment.NewStatus)
+
+	newComment := &domain.AppealComment{}
+	if err := row.Scan(&newComment.CommentID, &newComment.AppealID, &newComment.UserID, &newComment.Comment,
+		&newComment.NewStatus, &newComment.CreatedAt); err != nil {
+		r.logger.Error("Could not scan newly created appeal comment", zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return newComment, nil
+}
+
+func (r *appealRepo) GetComments(ctx context.Context, appealID int64) ([]*domain.AppealComment, error) {
+	const op = opTag + "GetComments"
+
+	query := `SELECT CommentID, AppealID, UserID, Comment, NewStatus, CreatedAt FROM AppealComments
+			WHERE AppealID = $1 ORDER BY CreatedAt, CommentID;`
+
+	rows, err := r.db.QueryContext(ctx, query, appealID)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.AppealComment, 0)
+	for rows.Next() {
+		c := &domain.AppealComment{}
+
+		if err := rows.Scan(&c.CommentID, &c.AppealID, &c.UserID, &c.Comment, &c.NewStatus, &c.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, c)
+	}
+
+	return results, nil
+}
+
+// Search returns appeals matching the provided args. If serverIDs is not empty, only appeals against infractions
+// recorded on these servers are returned.
+func (r *appealRepo) Search(ctx context.Context, args domain.FindArgs, serverIDs []int64, limit, offset int) (int, []*domain.Appeal, error) {
+	const op = opTag + "Search"
+
+	where := `
+		i.DeletedAt IS NULL AND
+		($1::INT[] IS NULL OR $1::INT[] = '{}' OR i.ServerID = ANY ($1::INT[])) AND
+		($2::AppealStatus IS NULL OR a.Status = $2) AND
+		($3::INT IS NULL OR a.InfractionID = $3) AND
+		($4::VARCHAR IS NULL OR i.PlayerID = $4) AND
+		($5::VARCHAR IS NULL OR i.Platform = $5) AND
+		($6::INT IS NULL OR i.ServerID = $6)
+	`
+
+	query := `
+		SELECT a.*, i.ServerID, i.PlayerID, i.Platform
+		FROM Appeals a
+		INNER JOIN Infractions i ON a.InfractionID = i.InfractionID
+		WHERE ` + where + `
+		ORDER BY a.CreatedAt DESC
+		LIMIT $7 OFFSET $8;
+	`
+
+	var (
+		status       = args["Status"]
+		infractionID = args["InfractionID"]
+		playerID     = args["PlayerID"]
+		platform     = args["Platform"]
+		serverID     = args["ServerID"]
+	)
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(serverIDs), status, infractionID, playerID, platform, serverID,
+		limit, offset)
+	if err != nil {
+		r.logger.Error("Could not execute appeal search query", zap.Any("Filters", args), zap.Error(err))
+		return 0, nil, errors.Wrap(err, op)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.Appeal, 0)
+	for rows.Next() {
+		a := &domain.Appeal{}
+
+		if err := rows.Scan(&a.AppealID, &a.InfractionID, &a.UserID, &a.Status, &a.Statement, &a.DecidedBy,
+			&a.DecidedAt, &a.CreatedAt, &a.ModifiedAt, &a.ServerID, &a.PlayerID, &a.Platform); err != nil {
+			r.logger.Error("Could not scan appeal search result", zap.Error(err))
+			return 0, nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, a)
+	}
+
+	if len(results) < 1 {
+		return 0, results, nil
+	}
+
+	// Get total number of matches
+	query = `
+		SELECT COUNT(1) AS Count
+		FROM Appeals a
+		INNER JOIN Infractions i ON a.InfractionID = i.InfractionID
+		WHERE ` + where + `;`
+
+	row := r.db.QueryRowContext(ctx, query, pq.Array(serverIDs), status, infractionID, playerID, platform, serverID)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		r.logger.Error("Could not scan total search results for appeal search", zap.Error(err))
+		return 0, nil, errors.Wrap(err, op)
+	}
+
+	return count, results, nil
+}
+
+// Scan helpers
+func (r *appealRepo) scanRow(row *sql.Row, a *domain.Appeal) error {
+	return row.Scan(&a.AppealID, &a.InfractionID, &a.UserID, &a.Status, &a.Statement, &a.DecidedBy, &a.DecidedAt,
+		&a.CreatedAt, &a.ModifiedAt)
+}
+
+func (r *appealRepo) scanRows(rows *sql.Rows, a *domain.Appeal) error {
+	return rows.Scan(&a.AppealID, &a.InfractionID, &a.UserID, &a.Status, &a.Statement, &a.DecidedBy, &a.DecidedAt,
+		&a.CreatedAt, &a.ModifiedAt)
+}
@@ -0,0 +1,189 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/franela/goblin"
+	"github.com/guregu/null"
+	"github.com/lib/pq"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var cols = []string{"AppealID", "InfractionID", "UserID", "Status", "Statement", "DecidedBy", "DecidedAt",
+		"CreatedAt", "ModifiedAt"}
+	var commentCols = []string{"CommentID", "AppealID", "UserID", "Comment", "NewStatus", "CreatedAt"}
+
+	g.Describe("Postgres Appeal Repo", func() {
+		var repo domain.AppealRepo
+		var mock sqlmock.Sqlmock
+		var db *sql.DB
+		var ctx context.Context
+
+		g.BeforeEach(func() {
+			var err error
+
+			db, mock, err = sqlmock.New()
+			if err != nil {
+				t.Fatalf("Could not create new sqlmock instance. Error: %v", err)
+			}
+
+			repo = NewAppealRepo(db, zap.NewNop())
+			ctx = context.TODO()
+		})
+
+		g.AfterEach(func() {
+			_ = db.Close()
+		})
+
+		g.Describe("Store()", func() {
+			var appeal *domain.Appeal
+
+			g.BeforeEach(func() {
+				appeal = &domain.Appeal{
+					InfractionID: 4,
+					UserID:       null.StringFrom("user"),
+					Status:       domain.AppealStatusOpen,
+					Statement:    "I did not do it",
+				}
+			})
+
+			g.Describe("Appeal stored successfully", func() {
+				g.BeforeEach(func() {
+					mock.ExpectPrepare("INSERT INTO Appeals").ExpectQuery().
+						WithArgs(appeal.InfractionID, appeal.UserID, appeal.Status, appeal.Statement).
+						WillReturnRows(sqlmock.NewRows(cols).AddRow(1, 4, "user", "open", "I did not do it", nil,
+							nil, time.Time{}, time.Time{}))
+				})
+
+				g.It("Should not return an error", func() {
+					_, err := repo.Store(ctx, appeal)
+
+					Expect(err).To(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+
+				g.It("Should return the new appeal", func() {
+					stored, err := repo.Store(ctx, appeal)
+
+					Expect(err).To(BeNil())
+					Expect(stored.AppealID).To(Equal(int64(1)))
+					Expect(stored.Status).To(Equal(domain.AppealStatusOpen))
+					Expect(stored.DecidedBy.Valid).To(BeFalse())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Infraction already has an open appeal", func() {
+				g.BeforeEach(func() {
+					mock.ExpectPrepare("INSERT INTO Appeals").ExpectQuery().
+						WillReturnError(&pq.Error{Code: "23505"}) // pg unique violation error code
+				})
+
+				g.It("Should return ErrConflict", func() {
+					_, err := repo.Store(ctx, appeal)
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrConflict))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("GetByID()", func() {
+			g.Describe("Appeal found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Appeals WHERE AppealID = $1")).WithArgs(1).
+						WillReturnRows(sqlmock.NewRows(cols).AddRow(1, 4, "user", "accepted", "statement", "admin",
+							time.Time{}, time.Time{}, time.Time{}))
+				})
+
+				g.It("Should return the appeal", func() {
+					appeal, err := repo.GetByID(ctx, 1)
+
+					Expect(err).To(BeNil())
+					Expect(appeal.AppealID).To(Equal(int64(1)))
+					Expect(appeal.Status).To(Equal(domain.AppealStatusAccepted))
+					Expect(appeal.DecidedBy).To(Equal(null.StringFrom("admin")))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Appeal not found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Appeals WHERE AppealID = $1")).WithArgs(1).
+						WillReturnRows(sqlmock.NewRows(cols))
+				})
+
+				g.It("Should return domain.ErrNotFound", func() {
+					_, err := repo.GetByID(ctx, 1)
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("StoreComment()", func() {
+			g.It("Should return the new comment", func() {
+				mock.ExpectQuery("INSERT INTO AppealComments").
+					WithArgs(int64(1), null.StringFrom("admin"), "Looks valid", null.StringFrom("accepted")).
+					WillReturnRows(sqlmock.NewRows(commentCols).AddRow(3, 1, "admin", "Looks valid", "accepted",
+						time.Time{}))
+
+				comment, err := repo.StoreComment(ctx, &domain.AppealComment{
+					AppealID:  1,
+					UserID:    null.StringFrom("admin"),
+					Comment:   "Looks valid",
+					NewStatus: null.StringFrom("accepted"),
+				})
+
+				Expect(err).To(BeNil())
+				Expect(comment.CommentID).To(Equal(int64(3)))
+				Expect(comment.NewStatus).To(Equal(null.StringFrom("accepted")))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("GetComments()", func() {
+			g.It("Should return an empty slice if there are no comments", func() {
+				mock.ExpectQuery("SELECT (.+) FROM AppealComments").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(commentCols))
+
+				comments, err := repo.GetComments(ctx, 1)
+
+				Expect(err).To(BeNil())
+				Expect(comments).To(Equal([]*domain.AppealComment{}))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+	})
+}
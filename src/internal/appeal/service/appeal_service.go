@@ -0,0 +1,379 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/authcheckers"
+	"Refractor/domain"
+	"Refractor/pkg/perms"
+	"context"
+	"fmt"
+	"github.com/guregu/null"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+type appealService struct {
+	repo              domain.AppealRepo
+	infractionRepo    domain.InfractionRepo
+	infractionService domain.InfractionService
+	userMetaRepo      domain.UserMetaRepo
+	authorizer        domain.Authorizer
+	timeout           time.Duration
+	logger            *zap.Logger
+	updateSubs        []domain.AppealSubscriber
+}
+
+func NewAppealService(repo domain.AppealRepo, ir domain.InfractionRepo, is domain.InfractionService,
+	umr domain.UserMetaRepo, a domain.Authorizer, to time.Duration, log *zap.Logger) domain.AppealService {
+	return &appealService{
+		repo:              repo,
+		infractionRepo:    ir,
+		infractionService: is,
+		userMetaRepo:      umr,
+		authorizer:        a,
+		timeout:           to,
+		logger:            log,
+		updateSubs:        []domain.AppealSubscriber{},
+	}
+}
+
+// Store records a new appeal against an infraction. Only one appeal per infraction can be open at a time, and repealed
+// infractions cannot be appealed.
+//
+// If a user is set in the provided context, they must have permission to create appeals on the infraction's server.
+func (s *appealService) Store(c context.Context, infractionID int64, statement string) (*domain.Appeal, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	infraction, err := s.getInfraction(ctx, infractionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkPermission(ctx, infraction.ServerID, perms.FlagCreateAppeals,
+		"You do not have permission to create appeals for this infraction."); err != nil {
+		return nil, err
+	}
+
+	if infraction.Repealed {
+		return nil, domain.NewHTTPError(nil, http.StatusBadRequest, "This infraction has already been repealed")
+	}
+
+	// Make sure there isn't already an open appeal for this infraction
+	existing, err := s.repo.GetByInfraction(ctx, infractionID)
+	if err != nil && errors.Cause(err) != domain.ErrNotFound {
+		return nil, err
+	}
+
+	for _, appeal := range existing {
+		if appeal.Status == domain.AppealStatusOpen {
+			return nil, domain.NewHTTPError(nil, http.StatusConflict, "This infraction already has an open appeal")
+		}
+	}
+
+	appeal := &domain.Appeal{
+		InfractionID: infractionID,
+		Status:       domain.AppealStatusOpen,
+		Statement:    statement,
+	}
+
+	if user, ok := ctx.Value("user").(*domain.AuthUser); ok {
+		appeal.UserID = null.StringFrom(user.Identity.Id)
+	}
+
+	appeal, err = s.repo.Store(ctx, appeal)
+	if err != nil {
+		// An open appeal was created for the infraction after the check above
+		if errors.Cause(err) == domain.ErrConflict {
+			return nil, domain.NewHTTPError(err, http.StatusConflict, "This infraction already has an open appeal")
+		}
+
+		return nil, err
+	}
+
+	s.populateInfractionFields(appeal, infraction)
+	s.notifyUpdate(appeal)
+
+	return appeal, nil
+}
+
+// GetByID returns an appeal along with its comments.
+//
+// If a user is set in the provided context, they must have permission to view appeals on the infraction's server.
+func (s *appealService) GetByID(c context.Context, id int64) (*domain.Appeal, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	appeal, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	infraction, err := s.getInfraction(ctx, appeal.InfractionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkPermission(ctx, infraction.ServerID, perms.FlagViewAppeals,
+		"You do not have permission to view this appeal."); err != nil {
+		return nil, err
+	}
+
+	s.populateInfractionFields(appeal, infraction)
+
+	appeal.Comments, err = s.getComments(ctx, appeal.AppealID)
+	if err != nil {
+		return nil, err
+	}
+
+	return appeal, nil
+}
+
+// GetByInfraction returns all appeals recorded against an infraction.
+//
+// If a user is set in the provided context, they must have permission to view appeals on the infraction's server.
+func (s *appealService) GetByInfraction(c context.Context, infractionID int64) ([]*domain.Appeal, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	infraction, err := s.getInfraction(ctx, infractionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkPermission(ctx, infraction.ServerID, perms.FlagViewAppeals,
+		"You do not have permission to view appeals for this infraction."); err != nil {
+		return nil, err
+	}
+
+	appeals, err := s.repo.GetByInfraction(ctx, infractionID)
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return []*domain.Appeal{}, nil
+		}
+
+		return nil, err
+	}
+
+	for _, appeal := range appeals {
+		s.populateInfractionFields(appeal, infraction)
+	}
+
+	return appeals, nil
+}
+
+// AddComment adds a staff comment to an appeal.
+//
+// If a user is set in the provided context, they must have permission to view appeals on the infraction's server.
+func (s *appealService) AddComment(c context.Context, appealID int64, comment string) (*domain.AppealComment, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	appeal, err := s.repo.GetByID(ctx, appealID)
+	if err != nil {
+		return nil, err
+	}
+
+	infraction, err := s.getInfraction(ctx, appeal.InfractionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkPermission(ctx, infraction.ServerID, perms.FlagViewAppeals,
+		"You do not have permission to comment on this appeal."); err != nil {
+		return nil, err
+	}
+
+	newComment := &domain.AppealComment{
+		AppealID: appealID,
+		Comment:  comment,
+	}
+
+	if user, ok := ctx.Value("user").(*domain.AuthUser); ok {
+		newComment.UserID = null.StringFrom(user.Identity.Id)
+	}
+
+	return s.repo.StoreComment(ctx, newComment)
+}
+
+// SetStatus decides an open appeal and records the decision in the appeal's comment trail. Accepting an appeal repeals
+// the appealed infraction, which runs the infraction's repeal commands. Decisions are final, so accepted and rejected
+// appeals cannot be changed.
+//
+// If a user is set in the provided context, they must have permission to decide appeals on the infraction's server.
+func (s *appealService) SetStatus(c context.Context, appealID int64, status, comment string) (*domain.Appeal, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	appeal, err := s.repo.GetByID(ctx, appealID)
+	if err != nil {
+		return nil, err
+	}
+
+	infraction, err := s.getInfraction(ctx, appeal.InfractionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkPermission(ctx, infraction.ServerID, perms.FlagDecideAppeals,
+		"You do not have permission to decide this appeal."); err != nil {
+		return nil, err
+	}
+
+	if appeal.Status != domain.AppealStatusOpen {
+		return nil, domain.NewHTTPError(nil, http.StatusBadRequest, "This appeal has already been decided")
+	}
+
+	if status != domain.AppealStatusAccepted && status != domain.AppealStatusRejected {
+		return nil, domain.NewHTTPError(nil, http.StatusBadRequest, "Appeals can only be accepted or rejected")
+	}
+
+	var userID null.String
+	if user, ok := ctx.Value("user").(*domain.AuthUser); ok {
+		userID = null.StringFrom(user.Identity.Id)
+	}
+
+	args := domain.UpdateArgs{
+		"Status":    status,
+		"DecidedBy": userID,
+		"DecidedAt": null.TimeFrom(time.Now()),
+	}
+
+	// Repeal the infraction before the appeal is marked as accepted so that a failed repeal never leaves an accepted
+	// appeal with an active infraction. The user was authorized to decide this appeal, so the repeal skips the user's
+	// infraction edit permission checks but is still credited to them.
+	if status == domain.AppealStatusAccepted && !infraction.Repealed {
+		if _, err := s.infractionService.SetRepealed(domain.WithAuthBypass(ctx), infraction.InfractionID, true,
+			fmt.Sprintf("Appeal #%d accepted", appealID)); err != nil {
+			s.logger.Error("Could not repeal infraction of accepted appeal",
+				zap.Int64("Appeal ID", appealID),
+				zap.Int64("Infraction ID", infraction.InfractionID),
+				zap.Error(err))
+			return nil, err
+		}
+	}
+
+	updated, err := s.repo.Update(ctx, appealID, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record the decision
+	if _, err := s.repo.StoreComment(ctx, &domain.AppealComment{
+		AppealID:  appealID,
+		UserID:    userID,
+		Comment:   comment,
+		NewStatus: null.StringFrom(status),
+	}); err != nil {
+		s.logger.Error("Could not record appeal decision", zap.Int64("Appeal ID", appealID), zap.Error(err))
+	}
+
+	s.populateInfractionFields(updated, infraction)
+
+	updated.Comments, err = s.getComments(ctx, appealID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyUpdate(updated)
+
+	return updated, nil
+}
+
+func (s *appealService) SubscribeAppealUpdate(sub domain.AppealSubscriber) {
+	s.updateSubs = append(s.updateSubs, sub)
+}
+
+func (s *appealService) notifyUpdate(appeal *domain.Appeal) {
+	for _, sub := range s.updateSubs {
+		sub(appeal)
+	}
+}
+
+func (s *appealService) getInfraction(ctx context.Context, infractionID int64) (*domain.Infraction, error) {
+	infraction, err := s.infractionRepo.GetByID(ctx, infractionID)
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return nil, domain.NewHTTPError(err, http.StatusBadRequest, "Infraction not found")
+		}
+
+		return nil, err
+	}
+
+	return infraction, nil
+}
+
+// getComments returns the comments of an appeal with the username of each comment's author populated.
+func (s *appealService) getComments(ctx context.Context, appealID int64) ([]*domain.AppealComment, error) {
+	comments, err := s.repo.GetComments(ctx, appealID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, comment := range comments {
+		if !comment.UserID.Valid {
+			comment.Username = "[SYSTEM]"
+			continue
+		}
+
+		username, err := s.userMetaRepo.GetUsername(ctx, comment.UserID.ValueOrZero())
+		if err != nil {
+			if errors.Cause(err) != domain.ErrNotFound {
+				s.logger.Error("Could not get username of appeal comment author", zap.Error(err))
+			}
+
+			username = "[UNKNOWN]"
+		}
+
+		comment.Username = username
+	}
+
+	return comments, nil
+}
+
+// checkPermission checks that the user in context has the provided permission on a server. If no user is set in
+// context, the call is seen as a system call and is not checked.
+func (s *appealService) checkPermission(ctx context.Context, serverID int64, flag perms.FlagName, message string) error {
+	user, ok := ctx.Value("user").(*domain.AuthUser)
+	if !ok {
+		return nil
+	}
+
+	hasPermission, err := s.authorizer.HasPermission(ctx, domain.AuthScope{
+		Type: domain.AuthObjServer,
+		ID:   serverID,
+	}, user.Identity.Id, authcheckers.HasPermission(flag, true))
+	if err != nil {
+		return err
+	}
+
+	if !hasPermission {
+		return domain.NewHTTPError(nil, http.StatusUnauthorized, message)
+	}
+
+	return nil
+}
+
+func (s *appealService) populateInfractionFields(appeal *domain.Appeal, infraction *domain.Infraction) {
+	appeal.ServerID = infraction.ServerID
+	appeal.PlayerID = infraction.PlayerID
+	appeal.Platform = infraction.Platform
+}
@@ -0,0 +1,313 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/domain"
+	"Refractor/domain/mocks"
+	"context"
+	"fmt"
+	"github.com/franela/goblin"
+	"github.com/guregu/null"
+	. "github.com/onsi/gomega"
+	kratos "github.com/ory/kratos-client-go"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Appeal Service", func() {
+		var repo *mocks.AppealRepo
+		var infractionRepo *mocks.InfractionRepo
+		var infractionService *mocks.InfractionService
+		var userMetaRepo *mocks.UserMetaRepo
+		var authorizer *mocks.Authorizer
+		var service *appealService
+		var ctx context.Context
+		var infraction *domain.Infraction
+
+		g.BeforeEach(func() {
+			repo = new(mocks.AppealRepo)
+			infractionRepo = new(mocks.InfractionRepo)
+			infractionService = new(mocks.InfractionService)
+			userMetaRepo = new(mocks.UserMetaRepo)
+			authorizer = new(mocks.Authorizer)
+
+			service = &appealService{
+				repo:              repo,
+				infractionRepo:    infractionRepo,
+				infractionService: infractionService,
+				userMetaRepo:      userMetaRepo,
+				authorizer:        authorizer,
+				timeout:           time.Second * 2,
+				logger:            zap.NewNop(),
+				updateSubs:        []domain.AppealSubscriber{},
+			}
+
+			ctx = context.TODO()
+
+			infraction = &domain.Infraction{
+				InfractionID: 4,
+				PlayerID:     "player",
+				Platform:     "platform",
+				ServerID:     2,
+				Type:         domain.InfractionTypeBan,
+			}
+		})
+
+		g.Describe("Store()", func() {
+			g.Describe("Appeal stored successfully", func() {
+				g.BeforeEach(func() {
+					infractionRepo.On("GetByID", mock.Anything, int64(4)).Return(infraction, nil)
+					repo.On("GetByInfraction", mock.Anything, int64(4)).Return(nil, domain.ErrNotFound)
+					repo.On("Store", mock.Anything, mock.AnythingOfType("*domain.Appeal")).
+						Return(func(_ context.Context, a *domain.Appeal) *domain.Appeal {
+							a.AppealID = 1
+							return a
+						}, nil)
+				})
+
+				g.It("Should return an open appeal with the infraction fields populated", func() {
+					appeal, err := service.Store(ctx, 4, "statement")
+
+					Expect(err).To(BeNil())
+					Expect(appeal.AppealID).To(Equal(int64(1)))
+					Expect(appeal.Status).To(Equal(domain.AppealStatusOpen))
+					Expect(appeal.ServerID).To(Equal(int64(2)))
+					Expect(appeal.PlayerID).To(Equal("player"))
+					Expect(appeal.Platform).To(Equal("platform"))
+				})
+
+				g.It("Should notify update subscribers", func() {
+					var notified *domain.Appeal
+					service.SubscribeAppealUpdate(func(a *domain.Appeal) {
+						notified = a
+					})
+
+					_, err := service.Store(ctx, 4, "statement")
+
+					Expect(err).To(BeNil())
+					Expect(notified).ToNot(BeNil())
+					Expect(notified.AppealID).To(Equal(int64(1)))
+				})
+			})
+
+			g.Describe("Infraction already has an open appeal", func() {
+				g.BeforeEach(func() {
+					infractionRepo.On("GetByID", mock.Anything, int64(4)).Return(infraction, nil)
+					repo.On("GetByInfraction", mock.Anything, int64(4)).Return([]*domain.Appeal{
+						{AppealID: 1, Status: domain.AppealStatusRejected},
+						{AppealID: 2, Status: domain.AppealStatusOpen},
+					}, nil)
+				})
+
+				g.It("Should return a conflict error", func() {
+					_, err := service.Store(ctx, 4, "statement")
+
+					Expect(err).ToNot(BeNil())
+					Expect(err.(*domain.HTTPError).Status).To(Equal(http.StatusConflict))
+					repo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Open appeal was created concurrently", func() {
+				g.BeforeEach(func() {
+					infractionRepo.On("GetByID", mock.Anything, int64(4)).Return(infraction, nil)
+					repo.On("GetByInfraction", mock.Anything, int64(4)).Return(nil, errors.Wrap(domain.ErrNotFound, ""))
+					repo.On("Store", mock.Anything, mock.Anything).Return(nil, errors.Wrap(domain.ErrConflict, ""))
+				})
+
+				g.It("Should return a conflict error", func() {
+					_, err := service.Store(ctx, 4, "statement")
+
+					Expect(err).ToNot(BeNil())
+					Expect(err.(*domain.HTTPError).Status).To(Equal(http.StatusConflict))
+				})
+			})
+
+			g.Describe("Infraction is repealed", func() {
+				g.BeforeEach(func() {
+					infraction.Repealed = true
+					infractionRepo.On("GetByID", mock.Anything, int64(4)).Return(infraction, nil)
+				})
+
+				g.It("Should return a bad request error", func() {
+					_, err := service.Store(ctx, 4, "statement")
+
+					Expect(err).ToNot(BeNil())
+					Expect(err.(*domain.HTTPError).Status).To(Equal(http.StatusBadRequest))
+					repo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("User does not have permission", func() {
+				g.BeforeEach(func() {
+					ctx = context.WithValue(ctx, "user", &domain.AuthUser{
+						Session: &kratos.Session{Identity: kratos.Identity{Id: "user"}},
+					})
+
+					infractionRepo.On("GetByID", mock.Anything, int64(4)).Return(infraction, nil)
+					authorizer.On("HasPermission", mock.Anything, mock.MatchedBy(func(scope domain.AuthScope) bool {
+						return scope.Type == domain.AuthObjServer && scope.ID == int64(2)
+					}), "user", mock.Anything).Return(false, nil)
+				})
+
+				g.It("Should return an unauthorized error", func() {
+					_, err := service.Store(ctx, 4, "statement")
+
+					Expect(err).ToNot(BeNil())
+					Expect(err.(*domain.HTTPError).Status).To(Equal(http.StatusUnauthorized))
+					repo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+		})
+
+		g.Describe("SetStatus()", func() {
+			var appeal *domain.Appeal
+
+			g.BeforeEach(func() {
+				ctx = context.WithValue(ctx, "user", &domain.AuthUser{
+					Session: &kratos.Session{Identity: kratos.Identity{Id: "admin"}},
+				})
+
+				appeal = &domain.Appeal{
+					AppealID:     1,
+					InfractionID: 4,
+					Status:       domain.AppealStatusOpen,
+				}
+
+				repo.On("GetByID", mock.Anything, int64(1)).Return(appeal, nil)
+				infractionRepo.On("GetByID", mock.Anything, int64(4)).Return(infraction, nil)
+				authorizer.On("HasPermission", mock.Anything, mock.Anything, "admin", mock.Anything).Return(true, nil)
+				repo.On("StoreComment", mock.Anything, mock.AnythingOfType("*domain.AppealComment")).
+					Return(&domain.AppealComment{}, nil)
+				repo.On("GetComments", mock.Anything, int64(1)).Return([]*domain.AppealComment{}, nil)
+			})
+
+			g.Describe("Appeal accepted", func() {
+				g.BeforeEach(func() {
+					repo.On("Update", mock.Anything, int64(1), mock.AnythingOfType("domain.UpdateArgs")).
+						Return(&domain.Appeal{AppealID: 1, InfractionID: 4, Status: domain.AppealStatusAccepted}, nil)
+					infractionService.On("SetRepealed", mock.Anything, int64(4), true, "Appeal #1 accepted").Return(infraction, nil)
+				})
+
+				g.It("Should repeal the infraction", func() {
+					updated, err := service.SetStatus(ctx, 1, domain.AppealStatusAccepted, "Valid appeal")
+
+					Expect(err).To(BeNil())
+					Expect(updated.Status).To(Equal(domain.AppealStatusAccepted))
+					infractionService.AssertCalled(t, "SetRepealed", mock.Anything, int64(4), true, "Appeal #1 accepted")
+				})
+
+				g.It("Should record the decision", func() {
+					_, err := service.SetStatus(ctx, 1, domain.AppealStatusAccepted, "Valid appeal")
+
+					Expect(err).To(BeNil())
+					repo.AssertCalled(t, "Update", mock.Anything, int64(1), mock.MatchedBy(func(args domain.UpdateArgs) bool {
+						return args["Status"] == domain.AppealStatusAccepted && args["DecidedBy"] == null.StringFrom("admin")
+					}))
+					repo.AssertCalled(t, "StoreComment", mock.Anything, mock.MatchedBy(func(c *domain.AppealComment) bool {
+						return c.AppealID == 1 && c.Comment == "Valid appeal" &&
+							c.NewStatus == null.StringFrom(domain.AppealStatusAccepted) && c.UserID == null.StringFrom("admin")
+					}))
+				})
+
+				g.It("Should credit the repeal to the deciding user without checking their infraction permissions", func() {
+					_, err := service.SetStatus(ctx, 1, domain.AppealStatusAccepted, "Valid appeal")
+
+					Expect(err).To(BeNil())
+					infractionService.AssertCalled(t, "SetRepealed", mock.MatchedBy(func(c context.Context) bool {
+						user, ok := c.Value("user").(*domain.AuthUser)
+						return ok && user.Identity.Id == "admin" && domain.HasAuthBypass(c)
+					}), int64(4), true, "Appeal #1 accepted")
+				})
+			})
+
+			g.Describe("Infraction repeal fails", func() {
+				g.BeforeEach(func() {
+					infractionService.On("SetRepealed", mock.Anything, int64(4), true, "Appeal #1 accepted").
+						Return(nil, fmt.Errorf("err"))
+				})
+
+				g.It("Should return an error and not accept the appeal", func() {
+					_, err := service.SetStatus(ctx, 1, domain.AppealStatusAccepted, "Valid appeal")
+
+					Expect(err).ToNot(BeNil())
+					repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+					repo.AssertNotCalled(t, "StoreComment", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Appeal rejected", func() {
+				g.BeforeEach(func() {
+					repo.On("Update", mock.Anything, int64(1), mock.AnythingOfType("domain.UpdateArgs")).
+						Return(&domain.Appeal{AppealID: 1, InfractionID: 4, Status: domain.AppealStatusRejected}, nil)
+				})
+
+				g.It("Should not repeal the infraction", func() {
+					_, err := service.SetStatus(ctx, 1, domain.AppealStatusRejected, "")
+
+					Expect(err).To(BeNil())
+					infractionService.AssertNotCalled(t, "SetRepealed", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Appeal was already decided", func() {
+				g.It("Should not reopen an accepted appeal", func() {
+					appeal.Status = domain.AppealStatusAccepted
+
+					_, err := service.SetStatus(ctx, 1, domain.AppealStatusOpen, "")
+
+					Expect(err).ToNot(BeNil())
+					Expect(err.(*domain.HTTPError).Status).To(Equal(http.StatusBadRequest))
+					repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+				})
+
+				g.It("Should not change a rejected appeal to accepted", func() {
+					appeal.Status = domain.AppealStatusRejected
+
+					_, err := service.SetStatus(ctx, 1, domain.AppealStatusAccepted, "")
+
+					Expect(err).ToNot(BeNil())
+					Expect(err.(*domain.HTTPError).Status).To(Equal(http.StatusBadRequest))
+					infractionService.AssertNotCalled(t, "SetRepealed", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+					repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Appeal is set to open", func() {
+				g.It("Should return a bad request error", func() {
+					_, err := service.SetStatus(ctx, 1, domain.AppealStatusOpen, "")
+
+					Expect(err).ToNot(BeNil())
+					Expect(err.(*domain.HTTPError).Status).To(Equal(http.StatusBadRequest))
+					repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+				})
+			})
+		})
+	})
+}
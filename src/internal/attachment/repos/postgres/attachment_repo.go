@@ -77,7 +77,8 @@ func (r *attachmentRepo) fetch(ctx context.Context, query string, args ...interf
 func (r *attachmentRepo) Store(ctx context.Context, attachment *domain.Attachment) error {
 	const op = opTag + "Store"
 
-	query := "INSERT INTO Attachments (InfractionID, URL, Note) VALUES ($1, $2, $3) RETURNING AttachmentID;"
+	query := `INSERT INTO Attachments (InfractionID, URL, Note, ContentType, FileSize, SHA256)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING AttachmentID;`
 
 	stmt, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
@@ -85,7 +86,8 @@ func (r *attachmentRepo) Store(ctx context.Context, attachment *domain.Attachmen
 		return errors.Wrap(err, op)
 	}
 
-	row := stmt.QueryRowContext(ctx, attachment.InfractionID, attachment.URL, attachment.Note)
+	row := stmt.QueryRowContext(ctx, attachment.InfractionID, attachment.URL, attachment.Note, attachment.ContentType,
+		attachment.FileSize, attachment.SHA256)
 
 	var id int64
 	if err := row.Scan(&id); err != nil {
@@ -132,6 +134,24 @@ func (r *attachmentRepo) GetByID(ctx context.Context, id int64) (*domain.Attachm
 	return nil, errors.Wrap(domain.ErrNotFound, op)
 }
 
+// GetBySHA256 returns all attachments whose uploaded file has the provided hash.
+func (r *attachmentRepo) GetBySHA256(ctx context.Context, sha256 string) ([]*domain.Attachment, error) {
+	const op = opTag + "GetBySHA256"
+
+	query := "SELECT * FROM Attachments WHERE SHA256 = $1;"
+
+	results, err := r.fetch(ctx, query, sha256)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if len(results) > 0 {
+		return results, nil
+	}
+
+	return nil, errors.Wrap(domain.ErrNotFound, op)
+}
+
 func (r *attachmentRepo) Delete(ctx context.Context, id int64) error {
 	const op = opTag + "Delete"
 
@@ -158,9 +178,11 @@ func (r *attachmentRepo) Delete(ctx context.Context, id int64) error {
 
 // Scan helpers
 func (r *attachmentRepo) scanRow(row *sql.Row, attachment *domain.Attachment) error {
-	return row.Scan(&attachment.AttachmentID, &attachment.InfractionID, &attachment.URL, &attachment.Note)
+	return row.Scan(&attachment.AttachmentID, &attachment.InfractionID, &attachment.URL, &attachment.Note,
+		&attachment.ContentType, &attachment.FileSize, &attachment.SHA256)
 }
 
 func (r *attachmentRepo) scanRows(rows *sql.Rows, attachment *domain.Attachment) error {
-	return rows.Scan(&attachment.AttachmentID, &attachment.InfractionID, &attachment.URL, &attachment.Note)
+	return rows.Scan(&attachment.AttachmentID, &attachment.InfractionID, &attachment.URL, &attachment.Note,
+		&attachment.ContentType, &attachment.FileSize, &attachment.SHA256)
 }
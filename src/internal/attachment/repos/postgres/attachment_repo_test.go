@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/franela/goblin"
+	"github.com/guregu/null"
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
@@ -37,7 +38,7 @@ func Test(t *testing.T) {
 	// Special hook for gomega
 	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
 
-	var cols = []string{"AttachmentID", "InfractionID", "URL", "Note"}
+	var cols = []string{"AttachmentID", "InfractionID", "URL", "Note", "ContentType", "FileSize", "SHA256"}
 
 	g.Describe("Postgres Attachment Repo", func() {
 		var repo domain.AttachmentRepo
@@ -139,7 +140,8 @@ func Test(t *testing.T) {
 					mockRows = sqlmock.NewRows(cols)
 
 					for _, attachment := range mockAttachments {
-						mockRows.AddRow(attachment.AttachmentID, attachment.InfractionID, attachment.URL, attachment.Note)
+						mockRows.AddRow(attachment.AttachmentID, attachment.InfractionID, attachment.URL, attachment.Note,
+							attachment.ContentType, attachment.FileSize, attachment.SHA256)
 					}
 
 					mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Attachments")).WillReturnRows(mockRows)
@@ -200,5 +202,43 @@ func Test(t *testing.T) {
 				})
 			})
 		})
+
+		g.Describe("GetBySHA256()", func() {
+			g.Describe("Attachments found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Attachments WHERE SHA256 = $1")).WithArgs("hash").
+						WillReturnRows(sqlmock.NewRows(cols).
+							AddRow(1, 2, "", "", "image/png", 512, "hash"))
+				})
+
+				g.It("Should return the attachments", func() {
+					attachments, err := repo.GetBySHA256(ctx, "hash")
+
+					Expect(err).To(BeNil())
+					Expect(attachments).To(Equal([]*domain.Attachment{{
+						AttachmentID: 1,
+						InfractionID: 2,
+						ContentType:  null.StringFrom("image/png"),
+						FileSize:     null.IntFrom(512),
+						SHA256:       null.StringFrom("hash"),
+					}}))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("No attachments found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Attachments WHERE SHA256 = $1")).WithArgs("hash").
+						WillReturnRows(sqlmock.NewRows(cols))
+				})
+
+				g.It("Should return domain.ErrNotFound", func() {
+					_, err := repo.GetBySHA256(ctx, "hash")
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
 	})
 }
@@ -18,27 +18,41 @@
 package service
 
 import (
+	"Refractor/authcheckers"
 	"Refractor/domain"
 	"Refractor/pkg/perms"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/guregu/null"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"time"
 )
 
+// uploadURLFormat is the format of the download route of uploaded attachments.
+const uploadURLFormat = "/api/v1/infractions/attachment/%d/file"
+
 type attachmentService struct {
 	repo           domain.AttachmentRepo
 	infractionRepo domain.InfractionRepo
+	store          domain.AttachmentStore
 	authorizer     domain.Authorizer
 	timeout        time.Duration
 	logger         *zap.Logger
 }
 
-func NewAttachmentService(repo domain.AttachmentRepo, ir domain.InfractionRepo, a domain.Authorizer, to time.Duration,
-	log *zap.Logger) domain.AttachmentService {
+func NewAttachmentService(repo domain.AttachmentRepo, ir domain.InfractionRepo, store domain.AttachmentStore,
+	a domain.Authorizer, to time.Duration, log *zap.Logger) domain.AttachmentService {
 	return &attachmentService{
 		repo:           repo,
 		infractionRepo: ir,
+		store:          store,
 		authorizer:     a,
 		timeout:        to,
 		logger:         log,
@@ -69,11 +83,168 @@ func (s *attachmentService) Store(c context.Context, attachment *domain.Attachme
 	return s.repo.Store(ctx, attachment)
 }
 
+// Upload stores an uploaded file as a new attachment of an infraction. The file's content type and size are validated
+// before it is stored. Files are deduplicated by their SHA-256 hash: identical files are only stored once, and uploading
+// a file which is already attached to the infraction returns the existing attachment.
+//
+// If a user is set in the passed in context with the key of "user" then the user's authorization will be checked to
+// see if they have permission to create attachments on the target infraction. Otherwise, this is assumed to be a
+// system call and authorization is skipped.
+func (s *attachmentService) Upload(c context.Context, infractionID int64, note string, content io.Reader) (*domain.Attachment, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	// If user is set in context, check authorization to modify attachments for the target infraction.
+	user, ok := ctx.Value("user").(*domain.AuthUser)
+	if ok {
+		hasPermission, err := s.canAttachOnInfraction(ctx, infractionID, user)
+		if err != nil {
+			return nil, err
+		}
+
+		if !hasPermission {
+			return nil, domain.NewHTTPError(nil, http.StatusUnauthorized,
+				"You do not have permission to create attachments for this infraction.")
+		}
+	}
+
+	// Read the file, reading at most one byte past the size limit so oversized files can be detected.
+	data, err := ioutil.ReadAll(io.LimitReader(content, domain.MaxAttachmentFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 1 {
+		return nil, domain.NewHTTPError(nil, http.StatusBadRequest, "The uploaded file is empty")
+	}
+
+	if len(data) > domain.MaxAttachmentFileSize {
+		return nil, domain.NewHTTPError(nil, http.StatusBadRequest,
+			fmt.Sprintf("Attachments cannot be larger than %d MB", domain.MaxAttachmentFileSize>>20))
+	}
+
+	contentType := http.DetectContentType(data)
+	if !isAllowedContentType(contentType) {
+		return nil, domain.NewHTTPError(nil, http.StatusBadRequest,
+			fmt.Sprintf("Files of type %s cannot be uploaded as attachments", contentType))
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	// If this file is already attached to the infraction, return the existing attachment.
+	existing, err := s.repo.GetBySHA256(ctx, hash)
+	if err != nil && errors.Cause(err) != domain.ErrNotFound {
+		return nil, err
+	}
+
+	for _, attachment := range existing {
+		if attachment.InfractionID == infractionID {
+			setUploadURL(attachment)
+			return attachment, nil
+		}
+	}
+
+	// Only write the file if an identical file isn't already stored.
+	exists, err := s.store.Exists(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		if err := s.store.Put(ctx, hash, bytes.NewReader(data)); err != nil {
+			s.logger.Error("Could not store attachment file", zap.String("SHA256", hash), zap.Error(err))
+			return nil, err
+		}
+	}
+
+	attachment := &domain.Attachment{
+		InfractionID: infractionID,
+		Note:         note,
+		ContentType:  null.StringFrom(contentType),
+		FileSize:     null.IntFrom(int64(len(data))),
+		SHA256:       null.StringFrom(hash),
+	}
+
+	if err := s.repo.Store(ctx, attachment); err != nil {
+		return nil, err
+	}
+
+	setUploadURL(attachment)
+
+	return attachment, nil
+}
+
+// Download returns an uploaded attachment along with a reader of its file. The caller is responsible for closing the
+// returned reader.
+//
+// If a user is set in the passed in context with the key of "user" then the user must have permission to view the
+// infraction the attachment belongs to. Otherwise, this is assumed to be a system call and authorization is skipped.
+func (s *attachmentService) Download(c context.Context, id int64) (*domain.Attachment, io.ReadCloser, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	attachment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// If user is set in context, check authorization to view the target infraction.
+	user, ok := ctx.Value("user").(*domain.AuthUser)
+	if ok {
+		infraction, err := s.infractionRepo.GetByID(ctx, attachment.InfractionID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		hasPermission, err := s.authorizer.HasPermission(ctx, domain.AuthScope{
+			Type: domain.AuthObjServer,
+			ID:   infraction.ServerID,
+		}, user.Identity.Id, authcheckers.CanViewInfraction)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !hasPermission {
+			return nil, nil, domain.NewHTTPError(nil, http.StatusUnauthorized,
+				"You do not have permission to view this attachment.")
+		}
+	}
+
+	if !attachment.IsUpload() {
+		return nil, nil, domain.NewHTTPError(nil, http.StatusBadRequest, "This attachment is not an uploaded file")
+	}
+
+	// The file is read after this function returns, so the store is accessed using the caller's context rather than
+	// the timeout context.
+	file, err := s.store.Get(c, attachment.SHA256.ValueOrZero())
+	if err != nil {
+		s.logger.Error("Could not get attachment file",
+			zap.Int64("Attachment ID", attachment.AttachmentID),
+			zap.String("SHA256", attachment.SHA256.ValueOrZero()),
+			zap.Error(err))
+		return nil, nil, err
+	}
+
+	setUploadURL(attachment)
+
+	return attachment, file, nil
+}
+
 func (s *attachmentService) GetByInfraction(c context.Context, infractionID int64) ([]*domain.Attachment, error) {
 	ctx, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
-	return s.repo.GetByInfraction(ctx, infractionID)
+	attachments, err := s.repo.GetByInfraction(ctx, infractionID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, attachment := range attachments {
+		setUploadURL(attachment)
+	}
+
+	return attachments, nil
 }
 
 // Delete deletes an attachment. If a user is set in the passed in context with the key of "user" then the user's
@@ -83,14 +254,14 @@ func (s *attachmentService) Delete(c context.Context, id int64) error {
 	ctx, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
+	attachment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
 	// If user is set in context, check authorization to modify attachments for the target infraction.
 	user, ok := ctx.Value("user").(*domain.AuthUser)
 	if ok {
-		attachment, err := s.repo.GetByID(ctx, id)
-		if err != nil {
-			return err
-		}
-
 		hasPermission, err := s.canAttachOnInfraction(ctx, attachment.InfractionID, user)
 		if err != nil {
 			return err
@@ -102,7 +273,28 @@ func (s *attachmentService) Delete(c context.Context, id int64) error {
 		}
 	}
 
-	return s.repo.Delete(ctx, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	// Remove the uploaded file if no other attachments share it.
+	if attachment.IsUpload() {
+		hash := attachment.SHA256.ValueOrZero()
+
+		if _, err := s.repo.GetBySHA256(ctx, hash); err != nil {
+			if errors.Cause(err) != domain.ErrNotFound {
+				s.logger.Error("Could not check for other attachments sharing a file", zap.String("SHA256", hash),
+					zap.Error(err))
+				return nil
+			}
+
+			if err := s.store.Delete(ctx, hash); err != nil && errors.Cause(err) != domain.ErrNotFound {
+				s.logger.Error("Could not delete attachment file", zap.String("SHA256", hash), zap.Error(err))
+			}
+		}
+	}
+
+	return nil
 }
 
 func (s *attachmentService) canAttachOnInfraction(ctx context.Context, infractionID int64, user *domain.AuthUser) (bool, error) {
@@ -147,3 +339,20 @@ func (s *attachmentService) canAttachOnInfraction(ctx context.Context, infractio
 	// Otherwise, deny access
 	return false, nil
 }
+
+func isAllowedContentType(contentType string) bool {
+	for _, allowed := range domain.AllowedAttachmentContentTypes {
+		if contentType == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setUploadURL points the URL of an uploaded attachment to its download route.
+func setUploadURL(attachment *domain.Attachment) {
+	if attachment.IsUpload() {
+		attachment.URL = fmt.Sprintf(uploadURLFormat, attachment.AttachmentID)
+	}
+}
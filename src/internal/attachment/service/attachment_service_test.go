@@ -0,0 +1,195 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/domain"
+	"Refractor/domain/mocks"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/franela/goblin"
+	"github.com/guregu/null"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	// A minimal PNG signature is enough for content type detection
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+	sum := sha256.Sum256(png)
+	pngHash := hex.EncodeToString(sum[:])
+
+	g.Describe("Attachment Service", func() {
+		var repo *mocks.AttachmentRepo
+		var infractionRepo *mocks.InfractionRepo
+		var store *mocks.AttachmentStore
+		var authorizer *mocks.Authorizer
+		var service *attachmentService
+		var ctx context.Context
+
+		g.BeforeEach(func() {
+			repo = new(mocks.AttachmentRepo)
+			infractionRepo = new(mocks.InfractionRepo)
+			store = new(mocks.AttachmentStore)
+			authorizer = new(mocks.Authorizer)
+
+			service = &attachmentService{
+				repo:           repo,
+				infractionRepo: infractionRepo,
+				store:          store,
+				authorizer:     authorizer,
+				timeout:        time.Second * 2,
+				logger:         zap.NewNop(),
+			}
+
+			ctx = context.TODO()
+		})
+
+		g.Describe("Upload()", func() {
+			g.Describe("New file", func() {
+				g.BeforeEach(func() {
+					repo.On("GetBySHA256", mock.Anything, pngHash).Return(nil, domain.ErrNotFound)
+					store.On("Exists", mock.Anything, pngHash).Return(false, nil)
+					store.On("Put", mock.Anything, pngHash, mock.Anything).Return(nil)
+					repo.On("Store", mock.Anything, mock.AnythingOfType("*domain.Attachment")).
+						Run(func(args mock.Arguments) {
+							args.Get(1).(*domain.Attachment).AttachmentID = 5
+						}).Return(nil)
+				})
+
+				g.It("Should store the file and return the new attachment", func() {
+					attachment, err := service.Upload(ctx, 1, "note", bytes.NewReader(png))
+
+					Expect(err).To(BeNil())
+					Expect(attachment.InfractionID).To(Equal(int64(1)))
+					Expect(attachment.ContentType).To(Equal(null.StringFrom("image/png")))
+					Expect(attachment.FileSize).To(Equal(null.IntFrom(int64(len(png)))))
+					Expect(attachment.SHA256).To(Equal(null.StringFrom(pngHash)))
+					Expect(attachment.URL).To(Equal("/api/v1/infractions/attachment/5/file"))
+					store.AssertCalled(t, "Put", mock.Anything, pngHash, mock.Anything)
+				})
+			})
+
+			g.Describe("File already stored for another infraction", func() {
+				g.BeforeEach(func() {
+					repo.On("GetBySHA256", mock.Anything, pngHash).Return([]*domain.Attachment{
+						{AttachmentID: 2, InfractionID: 7, SHA256: null.StringFrom(pngHash)},
+					}, nil)
+					store.On("Exists", mock.Anything, pngHash).Return(true, nil)
+					repo.On("Store", mock.Anything, mock.AnythingOfType("*domain.Attachment")).Return(nil)
+				})
+
+				g.It("Should not store the file again", func() {
+					_, err := service.Upload(ctx, 1, "", bytes.NewReader(png))
+
+					Expect(err).To(BeNil())
+					store.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything)
+					repo.AssertCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("File already attached to the infraction", func() {
+				g.BeforeEach(func() {
+					repo.On("GetBySHA256", mock.Anything, pngHash).Return([]*domain.Attachment{
+						{AttachmentID: 2, InfractionID: 1, SHA256: null.StringFrom(pngHash)},
+					}, nil)
+				})
+
+				g.It("Should return the existing attachment", func() {
+					attachment, err := service.Upload(ctx, 1, "", bytes.NewReader(png))
+
+					Expect(err).To(BeNil())
+					Expect(attachment.AttachmentID).To(Equal(int64(2)))
+					repo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("File type is not allowed", func() {
+				g.It("Should return a bad request error", func() {
+					_, err := service.Upload(ctx, 1, "", strings.NewReader("<html><script></script></html>"))
+
+					Expect(err).ToNot(BeNil())
+					Expect(err.(*domain.HTTPError).Status).To(Equal(http.StatusBadRequest))
+					repo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("File is too large", func() {
+				g.It("Should return a bad request error", func() {
+					large := append(png, make([]byte, domain.MaxAttachmentFileSize)...)
+
+					_, err := service.Upload(ctx, 1, "", bytes.NewReader(large))
+
+					Expect(err).ToNot(BeNil())
+					Expect(err.(*domain.HTTPError).Status).To(Equal(http.StatusBadRequest))
+					repo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+		})
+
+		g.Describe("Delete()", func() {
+			g.BeforeEach(func() {
+				repo.On("GetByID", mock.Anything, int64(2)).Return(&domain.Attachment{
+					AttachmentID: 2,
+					InfractionID: 1,
+					SHA256:       null.StringFrom(pngHash),
+				}, nil)
+				repo.On("Delete", mock.Anything, int64(2)).Return(nil)
+			})
+
+			g.Describe("File is not shared", func() {
+				g.BeforeEach(func() {
+					repo.On("GetBySHA256", mock.Anything, pngHash).Return(nil, domain.ErrNotFound)
+					store.On("Delete", mock.Anything, pngHash).Return(nil)
+				})
+
+				g.It("Should delete the file", func() {
+					err := service.Delete(ctx, 2)
+
+					Expect(err).To(BeNil())
+					store.AssertCalled(t, "Delete", mock.Anything, pngHash)
+				})
+			})
+
+			g.Describe("File is shared with another attachment", func() {
+				g.BeforeEach(func() {
+					repo.On("GetBySHA256", mock.Anything, pngHash).Return([]*domain.Attachment{{AttachmentID: 3}}, nil)
+				})
+
+				g.It("Should not delete the file", func() {
+					err := service.Delete(ctx, 2)
+
+					Expect(err).To(BeNil())
+					store.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+				})
+			})
+		})
+	})
+}
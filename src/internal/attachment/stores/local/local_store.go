@@ -0,0 +1,167 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package local
+
+import (
+	"Refractor/domain"
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+const opTag = "AttachmentStore.Local."
+
+// keyPattern restricts keys to characters which are safe to use in a file name so that a key can never escape the
+// store's root directory.
+var keyPattern = regexp.MustCompile("^[a-zA-Z0-9_-]{3,128}$")
+
+type localStore struct {
+	root   string
+	logger *zap.Logger
+}
+
+// NewLocalAttachmentStore returns an AttachmentStore which keeps files on the local filesystem inside of root. The root
+// directory is created if it does not exist.
+func NewLocalAttachmentStore(root string, logger *zap.Logger) (domain.AttachmentStore, error) {
+	const op = opTag + "New"
+
+	if err := os.MkdirAll(root, 0750); err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return &localStore{
+		root:   root,
+		logger: logger,
+	}, nil
+}
+
+// path returns the path of the file stored under key. Files are sharded into subdirectories by the first two characters
+// of their key to avoid keeping too many files in a single directory.
+func (s *localStore) path(key string) (string, error) {
+	if !keyPattern.MatchString(key) {
+		return "", fmt.Errorf("invalid attachment key")
+	}
+
+	return filepath.Join(s.root, key[:2], key), nil
+}
+
+// Put writes content to a temporary file which is then moved into place, so a partially written file is never visible
+// under its key.
+func (s *localStore) Put(ctx context.Context, key string, content io.Reader) error {
+	const op = opTag + "Put"
+
+	path, err := s.path(key)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	// Clean up the temp file if it was not moved into place
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		_ = tmp.Close()
+		s.logger.Error("Could not write attachment file", zap.String("Key", key), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		s.logger.Error("Could not move attachment file into place", zap.String("Key", key), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (s *localStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	const op = opTag + "Get"
+
+	path, err := s.path(key)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.Wrap(domain.ErrNotFound, op)
+		}
+
+		return nil, errors.Wrap(err, op)
+	}
+
+	return file, nil
+}
+
+func (s *localStore) Exists(ctx context.Context, key string) (bool, error) {
+	const op = opTag + "Exists"
+
+	path, err := s.path(key)
+	if err != nil {
+		return false, errors.Wrap(err, op)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+
+		return false, errors.Wrap(err, op)
+	}
+
+	return true, nil
+}
+
+func (s *localStore) Delete(ctx context.Context, key string) error {
+	const op = opTag + "Delete"
+
+	path, err := s.path(key)
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return errors.Wrap(domain.ErrNotFound, op)
+		}
+
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
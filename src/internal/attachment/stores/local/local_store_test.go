@@ -0,0 +1,145 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package local
+
+import (
+	"Refractor/domain"
+	"context"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Local Attachment Store", func() {
+		var store domain.AttachmentStore
+		var root string
+		var ctx = context.TODO()
+
+		g.BeforeEach(func() {
+			var err error
+
+			root, err = ioutil.TempDir("", "attachments")
+			if err != nil {
+				t.Fatalf("Could not create temp dir. Error: %v", err)
+			}
+
+			store, err = NewLocalAttachmentStore(root, zap.NewNop())
+			if err != nil {
+				t.Fatalf("Could not create local attachment store. Error: %v", err)
+			}
+		})
+
+		g.AfterEach(func() {
+			_ = os.RemoveAll(root)
+		})
+
+		g.Describe("Put()", func() {
+			g.It("Should store the file under its key", func() {
+				err := store.Put(ctx, "abcdef", strings.NewReader("content"))
+				Expect(err).To(BeNil())
+
+				data, err := ioutil.ReadFile(filepath.Join(root, "ab", "abcdef"))
+				Expect(err).To(BeNil())
+				Expect(string(data)).To(Equal("content"))
+			})
+
+			g.It("Should not leave temporary files behind", func() {
+				err := store.Put(ctx, "abcdef", strings.NewReader("content"))
+				Expect(err).To(BeNil())
+
+				files, err := ioutil.ReadDir(filepath.Join(root, "ab"))
+				Expect(err).To(BeNil())
+				Expect(files).To(HaveLen(1))
+			})
+
+			g.It("Should reject keys which could escape the root directory", func() {
+				err := store.Put(ctx, "../../etc/passwd", strings.NewReader("content"))
+
+				Expect(err).ToNot(BeNil())
+			})
+		})
+
+		g.Describe("Get()", func() {
+			g.It("Should return the stored file", func() {
+				Expect(store.Put(ctx, "abcdef", strings.NewReader("content"))).To(BeNil())
+
+				file, err := store.Get(ctx, "abcdef")
+				Expect(err).To(BeNil())
+				defer file.Close()
+
+				data, err := ioutil.ReadAll(file)
+				Expect(err).To(BeNil())
+				Expect(string(data)).To(Equal("content"))
+			})
+
+			g.It("Should return domain.ErrNotFound if the file does not exist", func() {
+				_, err := store.Get(ctx, "abcdef")
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+			})
+		})
+
+		g.Describe("Exists()", func() {
+			g.It("Should return true if the file exists", func() {
+				Expect(store.Put(ctx, "abcdef", strings.NewReader("content"))).To(BeNil())
+
+				exists, err := store.Exists(ctx, "abcdef")
+
+				Expect(err).To(BeNil())
+				Expect(exists).To(BeTrue())
+			})
+
+			g.It("Should return false if the file does not exist", func() {
+				exists, err := store.Exists(ctx, "abcdef")
+
+				Expect(err).To(BeNil())
+				Expect(exists).To(BeFalse())
+			})
+		})
+
+		g.Describe("Delete()", func() {
+			g.It("Should remove the file", func() {
+				Expect(store.Put(ctx, "abcdef", strings.NewReader("content"))).To(BeNil())
+
+				Expect(store.Delete(ctx, "abcdef")).To(BeNil())
+
+				exists, err := store.Exists(ctx, "abcdef")
+				Expect(err).To(BeNil())
+				Expect(exists).To(BeFalse())
+			})
+
+			g.It("Should return domain.ErrNotFound if the file does not exist", func() {
+				err := store.Delete(ctx, "abcdef")
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+			})
+		})
+	})
+}
@@ -24,6 +24,7 @@ import (
 	"Refractor/pkg/api"
 	"Refractor/pkg/api/middleware"
 	"Refractor/pkg/perms"
+	"Refractor/pkg/structutils"
 	"context"
 	"fmt"
 	"github.com/labstack/echo/v4"
@@ -62,10 +63,15 @@ func ApplyChatHandler(apiGroup *echo.Group, service domain.ChatService,
 
 	chatGroup.GET("/recent/:serverId", handler.GetRecentServerMessages,
 		sEnforcer.CheckAuth(authcheckers.HasOneOfPermissions(true, perms.FlagReadLiveChat, perms.FlagViewChatRecords)))
+	chatGroup.GET("/:id/context", handler.GetMessageContext) // perms checked in service
 	chatGroup.GET("/flagged", handler.GetAllFlaggedWords, rEnforcer.CheckAuth(authcheckers.RequireAdmin))
 	chatGroup.POST("/flagged", handler.CreateFlaggedWord, rEnforcer.CheckAuth(authcheckers.RequireAdmin))
 	chatGroup.PATCH("/flagged/:id", handler.UpdateFlaggedWord, rEnforcer.CheckAuth(authcheckers.RequireAdmin))
 	chatGroup.DELETE("/flagged/:id", handler.DeleteFlaggedWord, rEnforcer.CheckAuth(authcheckers.RequireAdmin))
+	chatGroup.POST("/flagged/:id/exemptions/:serverId", handler.AddFlaggedWordExemption,
+		sEnforcer.CheckAuth(authcheckers.HasPermission(perms.FlagModerateFlaggedMessages, true)))
+	chatGroup.DELETE("/flagged/:id/exemptions/:serverId", handler.RemoveFlaggedWordExemption,
+		sEnforcer.CheckAuth(authcheckers.HasPermission(perms.FlagModerateFlaggedMessages, true)))
 	chatGroup.GET("/recent/flagged", handler.GetRecentFlaggedMessages,
 		rEnforcer.CheckAuth(authcheckers.HasPermission(perms.FlagModerateFlaggedMessages, true)))
 	chatGroup.PATCH("/unflag/:id", handler.UnflagMessage,
@@ -118,6 +124,74 @@ func (h *chatHandler) GetRecentServerMessages(c echo.Context) error {
 	})
 }
 
+const defaultContextMessagesCount = 10
+const maxContextMessagesCount = 50
+
+func (h *chatHandler) GetMessageContext(c echo.Context) error {
+	messageIDString := c.Param("id")
+
+	messageID, err := strconv.ParseInt(messageIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid message id"), http.StatusBadRequest, "")
+	}
+
+	before, err := parseContextCount(c, "before")
+	if err != nil {
+		return err
+	}
+
+	after, err := parseContextCount(c, "after")
+	if err != nil {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	messages, err := h.service.GetContext(ctx, messageID, before, after)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: messages,
+	})
+}
+
+// parseContextCount parses the number of context messages requested in a query param. If the query param is not set,
+// defaultContextMessagesCount is returned.
+func parseContextCount(c echo.Context, name string) (int, error) {
+	countString := c.QueryParam(name)
+	if countString == "" {
+		return defaultContextMessagesCount, nil
+	}
+
+	count, err := strconv.ParseInt(countString, 10, 32)
+	if err != nil {
+		return 0, &domain.HTTPError{
+			Success:          false,
+			Message:          fmt.Sprintf("%s input error", name),
+			ValidationErrors: map[string]string{name: "invalid int"},
+			Status:           http.StatusBadRequest,
+		}
+	}
+
+	if count < 0 || count > maxContextMessagesCount {
+		return 0, &domain.HTTPError{
+			Success:          false,
+			Message:          fmt.Sprintf("%s input error", name),
+			ValidationErrors: map[string]string{name: fmt.Sprintf("should be between 0 and %d", maxContextMessagesCount)},
+			Status:           http.StatusBadRequest,
+		}
+	}
+
+	return int(count), nil
+}
+
 func (h *chatHandler) GetAllFlaggedWords(c echo.Context) error {
 	allFlaggedWords, err := h.flaggedWordService.GetAll(c.Request().Context())
 	if err != nil {
@@ -142,7 +216,13 @@ func (h *chatHandler) CreateFlaggedWord(c echo.Context) error {
 	}
 
 	newWord := &domain.FlaggedWord{
-		Word: body.Word,
+		Word:           body.Word,
+		Mode:           body.Mode,
+		MaxDistance:    body.MaxDistance,
+		Severity:       body.Severity,
+		Action:         body.Action,
+		ActionDuration: body.ActionDuration,
+		Channels:       body.Channels,
 	}
 
 	if err := h.flaggedWordService.Store(c.Request().Context(), newWord); err != nil {
@@ -174,7 +254,12 @@ func (h *chatHandler) UpdateFlaggedWord(c echo.Context) error {
 		return err
 	}
 
-	updated, err := h.flaggedWordService.Update(c.Request().Context(), flaggedWordID, *body.Word)
+	updateArgs, err := structutils.GetNonNilFieldMap(body)
+	if err != nil {
+		return err
+	}
+
+	updated, err := h.flaggedWordService.Update(c.Request().Context(), flaggedWordID, updateArgs)
 	if err != nil {
 		return err
 	}
@@ -204,6 +289,52 @@ func (h *chatHandler) DeleteFlaggedWord(c echo.Context) error {
 	})
 }
 
+func (h *chatHandler) parseExemptionIDs(c echo.Context) (int64, int64, error) {
+	flaggedWordID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, 0, domain.NewHTTPError(fmt.Errorf("invalid flagged word id"), http.StatusBadRequest, "")
+	}
+
+	serverID, err := strconv.ParseInt(c.Param("serverId"), 10, 64)
+	if err != nil {
+		return 0, 0, domain.NewHTTPError(fmt.Errorf("invalid server id"), http.StatusBadRequest, "")
+	}
+
+	return flaggedWordID, serverID, nil
+}
+
+func (h *chatHandler) AddFlaggedWordExemption(c echo.Context) error {
+	flaggedWordID, serverID, err := h.parseExemptionIDs(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.flaggedWordService.AddExemption(c.Request().Context(), flaggedWordID, serverID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Server exempted",
+	})
+}
+
+func (h *chatHandler) RemoveFlaggedWordExemption(c echo.Context) error {
+	flaggedWordID, serverID, err := h.parseExemptionIDs(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.flaggedWordService.RemoveExemption(c.Request().Context(), flaggedWordID, serverID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Exemption removed",
+	})
+}
+
 func (h *chatHandler) GetRecentFlaggedMessages(c echo.Context) error {
 	var count int64 = 10
 	countString := c.QueryParam("count")
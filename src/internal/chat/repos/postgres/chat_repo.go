@@ -83,14 +83,14 @@ func (r *chatRepo) fetch(ctx context.Context, query string, args ...interface{})
 // Store stores a new chat message in the postgres database. The following fields must be present on the passed in
 // chat message struct:
 //
-// PlayerID, Platform, ServerID, Message
+// PlayerID, Platform, ServerID, Message, Channel
 //
 // Flagged is optional.
 func (r *chatRepo) Store(ctx context.Context, msg *domain.ChatMessage) error {
 	const op = opTag + "Store"
 
-	query := `INSERT INTO ChatMessages (PlayerID, Platform, ServerID, Message, Flagged, MessageVectors)
-			VALUES ($1, $2, $3, $4, $5, to_tsvector($6)) RETURNING MessageID;`
+	query := `INSERT INTO ChatMessages (PlayerID, Platform, ServerID, Message, Channel, Flagged, MessageVectors)
+			VALUES ($1, $2, $3, $4, $5, $6, to_tsvector($7)) RETURNING MessageID;`
 
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -105,7 +105,8 @@ func (r *chatRepo) Store(ctx context.Context, msg *domain.ChatMessage) error {
 		return errors.Wrap(err, op)
 	}
 
-	row := stmt.QueryRowContext(ctx, msg.PlayerID, msg.Platform, msg.ServerID, msg.Message, msg.Flagged, msg.Message)
+	row := stmt.QueryRowContext(ctx, msg.PlayerID, msg.Platform, msg.ServerID, msg.Message, msg.Channel, msg.Flagged,
+		msg.Message)
 	if err != nil {
 		_ = tx.Rollback()
 		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
@@ -130,7 +131,7 @@ func (r *chatRepo) Store(ctx context.Context, msg *domain.ChatMessage) error {
 func (r *chatRepo) GetByID(ctx context.Context, id int64) (*domain.ChatMessage, error) {
 	const op = opTag + "GetByID"
 
-	query := `SELECT MessageID, PlayerID, Platform, ServerID, Message, Flagged, CreatedAt, ModifiedAt
+	query := `SELECT MessageID, PlayerID, Platform, ServerID, Message, Channel, Flagged, CreatedAt, ModifiedAt
 				FROM ChatMessages WHERE MessageID = $1;`
 
 	results, err := r.fetch(ctx, query, id)
@@ -148,7 +149,7 @@ func (r *chatRepo) GetByID(ctx context.Context, id int64) (*domain.ChatMessage,
 func (r *chatRepo) GetRecentByServer(ctx context.Context, serverID int64, count int) ([]*domain.ChatMessage, error) {
 	const op = opTag + "GetRecentByServer"
 
-	query := `SELECT MessageID, PlayerID, Platform, ServerID, Message, Flagged, CreatedAt, ModifiedAt
+	query := `SELECT MessageID, PlayerID, Platform, ServerID, Message, Channel, Flagged, CreatedAt, ModifiedAt
 			FROM ChatMessages WHERE ServerID = $1 ORDER BY CreatedAt DESC LIMIT $2;`
 
 	results, err := r.fetch(ctx, query, serverID, count)
@@ -163,6 +164,55 @@ func (r *chatRepo) GetRecentByServer(ctx context.Context, serverID int64, count
 	return nil, errors.Wrap(domain.ErrNotFound, op)
 }
 
+// GetContext returns the message with the provided ID along with up to before messages sent before it and up to after
+// messages sent after it on the same server, ordered by when they were sent.
+func (r *chatRepo) GetContext(ctx context.Context, id int64, before, after int) ([]*domain.ChatMessage, error) {
+	const op = opTag + "GetContext"
+
+	query := `
+		WITH target AS (SELECT MessageID, ServerID, CreatedAt FROM ChatMessages WHERE MessageID = $1)
+		SELECT MessageID, PlayerID, Platform, ServerID, Message, Channel, Flagged, CreatedAt, ModifiedAt FROM (
+			(SELECT cm.* FROM ChatMessages cm, target t
+				WHERE cm.ServerID = t.ServerID AND (cm.CreatedAt, cm.MessageID) < (t.CreatedAt, t.MessageID)
+				ORDER BY cm.CreatedAt DESC, cm.MessageID DESC LIMIT $2)
+			UNION ALL
+			(SELECT cm.* FROM ChatMessages cm, target t WHERE cm.MessageID = t.MessageID)
+			UNION ALL
+			(SELECT cm.* FROM ChatMessages cm, target t
+				WHERE cm.ServerID = t.ServerID AND (cm.CreatedAt, cm.MessageID) > (t.CreatedAt, t.MessageID)
+				ORDER BY cm.CreatedAt, cm.MessageID LIMIT $3)
+		) AS context
+		ORDER BY CreatedAt, MessageID;
+	`
+
+	results, err := r.fetch(ctx, query, id, before, after)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if len(results) > 0 {
+		return results, nil
+	}
+
+	return nil, errors.Wrap(domain.ErrNotFound, op)
+}
+
+// IsLinkedToInfraction returns true if the message is linked to at least one infraction.
+func (r *chatRepo) IsLinkedToInfraction(ctx context.Context, id int64) (bool, error) {
+	const op = opTag + "IsLinkedToInfraction"
+
+	query := "SELECT EXISTS(SELECT 1 FROM InfractionChatMessages WHERE MessageID = $1);"
+
+	var linked bool
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&linked); err != nil {
+		r.logger.Error("Could not check if chat message is linked to an infraction", zap.Int64("Message ID", id),
+			zap.Error(err))
+		return false, errors.Wrap(err, op)
+	}
+
+	return linked, nil
+}
+
 func (r *chatRepo) Search(ctx context.Context, args domain.FindArgs, serverIDs []int64, limit, offset int) (int, []*domain.ChatMessage, error) {
 	const op = opTag + "Search"
 
@@ -173,6 +223,7 @@ func (r *chatRepo) Search(ctx context.Context, args domain.FindArgs, serverIDs [
 		    cm.Platform,
 		    cm.ServerID,
 		    cm.Message,
+		    cm.Channel,
 		    cm.Flagged,
 		    cm.CreatedAt,
 		    cm.ModifiedAt
@@ -185,8 +236,9 @@ func (r *chatRepo) Search(ctx context.Context, args domain.FindArgs, serverIDs [
 			($4::INT IS NULL OR cm.ServerID = $4) AND
 			($5::VARCHAR IS NULL OR s.Game = $5) AND
 			(($6::BIGINT IS NULL OR $7::BIGINT IS NULL) OR cm.CreatedAt BETWEEN TO_TIMESTAMP($6) AND TO_TIMESTAMP($7)) AND
-			($8::VARCHAR IS NULL OR cm.MessageVectors @@ PLAINTO_TSQUERY($8))
-		ORDER BY CreatedAt DESC LIMIT $9 OFFSET $10;
+			($8::VARCHAR IS NULL OR cm.MessageVectors @@ PLAINTO_TSQUERY($8)) AND
+			($9::VARCHAR IS NULL OR cm.Channel = $9)
+		ORDER BY CreatedAt DESC LIMIT $10 OFFSET $11;
 	`
 
 	var (
@@ -197,6 +249,7 @@ func (r *chatRepo) Search(ctx context.Context, args domain.FindArgs, serverIDs [
 		startDate   = args["StartDate"]
 		endDate     = args["EndDate"]
 		searchQuery = args["Query"]
+		channel     = args["Channel"]
 	)
 
 	var toQueryMethod = "PLAINTO_TSQUERY"
@@ -214,7 +267,7 @@ func (r *chatRepo) Search(ctx context.Context, args domain.FindArgs, serverIDs [
 
 	//results, err := r.fetch(ctx, query, playerID, playerID, platform, platform, serverID, serverID, game, game,
 	//	startDate, endDate, startDate, endDate, searchQuery, searchQuery, limit, offset)
-	results, err := r.fetch(ctx, query, pq.Array(serverIDs), playerID, platform, serverID, game, startDate, endDate, searchQuery, limit, offset)
+	results, err := r.fetch(ctx, query, pq.Array(serverIDs), playerID, platform, serverID, game, startDate, endDate, searchQuery, channel, limit, offset)
 	if err != nil {
 		if strings.Contains(errors.Cause(err).Error(), "syntax error in tsquery") {
 			return 0, nil, errors.Wrap(domain.ErrInvalidQuery, op)
@@ -240,10 +293,11 @@ func (r *chatRepo) Search(ctx context.Context, args domain.FindArgs, serverIDs [
 			($4::INT IS NULL OR cm.ServerID = $4) AND
 		    ($5::VARCHAR IS NULL OR s.Game = $5) AND
 			(($6::BIGINT IS NULL OR $7::BIGINT IS NULL) OR cm.CreatedAt BETWEEN TO_TIMESTAMP($6) AND TO_TIMESTAMP($7)) AND
-			($8::VARCHAR IS NULL OR cm.MessageVectors @@ %s($8));
+			($8::VARCHAR IS NULL OR cm.MessageVectors @@ %s($8)) AND
+			($9::VARCHAR IS NULL OR cm.Channel = $9);
 	`, toQueryMethod)
 
-	row := r.db.QueryRowContext(ctx, query, pq.Array(serverIDs), playerID, platform, serverID, game, startDate, endDate, searchQuery)
+	row := r.db.QueryRowContext(ctx, query, pq.Array(serverIDs), playerID, platform, serverID, game, startDate, endDate, searchQuery, channel)
 
 	var resultCount int
 	if err := row.Scan(&resultCount); err != nil {
@@ -267,6 +321,7 @@ func (r *chatRepo) GetFlaggedMessages(ctx context.Context, count int, serverIDs
 				Platform,
 				ServerID,
 				Message,
+				Channel,
 				Flagged,
 				CreatedAt,
 				ModifiedAt
@@ -281,6 +336,7 @@ func (r *chatRepo) GetFlaggedMessages(ctx context.Context, count int, serverIDs
 				Platform,
 				ServerID,
 				Message,
+				Channel,
 				Flagged,
 				CreatedAt,
 				ModifiedAt
@@ -317,7 +373,7 @@ func (r *chatRepo) Update(ctx context.Context, id int64, args domain.UpdateArgs)
 	const op = opTag + "Update"
 
 	query, values := r.qb.BuildUpdateQuery("ChatMessages", id, "MessageID", args, []string{
-		"MessageID", "PlayerID", "Platform", "ServerID", "Message", "Flagged", "CreatedAt", "ModifiedAt",
+		"MessageID", "PlayerID", "Platform", "ServerID", "Message", "Channel", "Flagged", "CreatedAt", "ModifiedAt",
 	})
 
 	stmt, err := r.db.PrepareContext(ctx, query)
@@ -343,9 +399,9 @@ func (r *chatRepo) Update(ctx context.Context, id int64, args domain.UpdateArgs)
 
 // Scan helpers
 func (r *chatRepo) scanRow(row *sql.Row, msg *domain.ChatMessage) error {
-	return row.Scan(&msg.MessageID, &msg.PlayerID, &msg.Platform, &msg.ServerID, &msg.Message, &msg.Flagged, &msg.CreatedAt, &msg.ModifiedAt)
+	return row.Scan(&msg.MessageID, &msg.PlayerID, &msg.Platform, &msg.ServerID, &msg.Message, &msg.Channel, &msg.Flagged, &msg.CreatedAt, &msg.ModifiedAt)
 }
 
 func (r *chatRepo) scanRows(rows *sql.Rows, msg *domain.ChatMessage) error {
-	return rows.Scan(&msg.MessageID, &msg.PlayerID, &msg.Platform, &msg.ServerID, &msg.Message, &msg.Flagged, &msg.CreatedAt, &msg.ModifiedAt)
+	return rows.Scan(&msg.MessageID, &msg.PlayerID, &msg.Platform, &msg.ServerID, &msg.Message, &msg.Channel, &msg.Flagged, &msg.CreatedAt, &msg.ModifiedAt)
 }
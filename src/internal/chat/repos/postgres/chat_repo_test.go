@@ -30,6 +30,7 @@ import (
 	"go.uber.org/zap"
 	"regexp"
 	"testing"
+	"time"
 )
 
 func Test(t *testing.T) {
@@ -38,7 +39,7 @@ func Test(t *testing.T) {
 	// Special hook for gomega
 	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
 
-	var cols = []string{"MessageID", "PlayerID", "Platform", "ServerID", "Message", "Flagged", "CreatedAt", "ModifiedAt"}
+	var cols = []string{"MessageID", "PlayerID", "Platform", "ServerID", "Message", "Channel", "Flagged", "CreatedAt", "ModifiedAt"}
 
 	g.Describe("ChatMessage Postgres Repo", func() {
 		var repo *chatRepo
@@ -139,7 +140,7 @@ func Test(t *testing.T) {
 				g.BeforeEach(func() {
 					mockRepo.ExpectQuery(regexp.QuoteMeta("SELECT MessageID, PlayerID, Platform")).WillReturnRows(sqlmock.NewRows(cols).
 						AddRow(chatMessage.MessageID, chatMessage.PlayerID, chatMessage.Platform, chatMessage.ServerID,
-							chatMessage.Message, chatMessage.Flagged, chatMessage.CreatedAt, chatMessage.ModifiedAt))
+							chatMessage.Message, chatMessage.Channel, chatMessage.Flagged, chatMessage.CreatedAt, chatMessage.ModifiedAt))
 				})
 
 				g.It("Should not return an error", func() {
@@ -256,7 +257,7 @@ func Test(t *testing.T) {
 					rows = sqlmock.NewRows(cols)
 
 					for _, msg := range messages {
-						rows.AddRow(msg.MessageID, msg.PlayerID, msg.Platform, msg.ServerID, msg.Message, msg.Flagged, msg.CreatedAt, msg.ModifiedAt)
+						rows.AddRow(msg.MessageID, msg.PlayerID, msg.Platform, msg.ServerID, msg.Message, msg.Channel, msg.Flagged, msg.CreatedAt, msg.ModifiedAt)
 					}
 
 					mockRepo.ExpectQuery(regexp.QuoteMeta("SELECT MessageID, PlayerID, Platform")).WillReturnRows(rows)
@@ -294,6 +295,65 @@ func Test(t *testing.T) {
 			})
 		})
 
+		g.Describe("GetContext()", func() {
+			g.Describe("Results found", func() {
+				g.BeforeEach(func() {
+					rows := sqlmock.NewRows(cols)
+					for i := 1; i <= 3; i++ {
+						rows.AddRow(i, "playerid", "platform", 1, fmt.Sprintf("message %d", i), "all", false,
+							time.Time{}, nil)
+					}
+
+					mockRepo.ExpectQuery("WITH target AS").WithArgs(2, 1, 1).WillReturnRows(rows)
+				})
+
+				g.It("Should return the messages in order", func() {
+					results, err := repo.GetContext(ctx, 2, 1, 1)
+
+					Expect(err).To(BeNil())
+					Expect(len(results)).To(Equal(3))
+					Expect(results[0].MessageID).To(Equal(int64(1)))
+					Expect(results[2].MessageID).To(Equal(int64(3)))
+					Expect(mockRepo.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Message not found", func() {
+				g.BeforeEach(func() {
+					mockRepo.ExpectQuery("WITH target AS").WillReturnRows(sqlmock.NewRows(cols))
+				})
+
+				g.It("Should return domain.ErrNotFound", func() {
+					_, err := repo.GetContext(ctx, 2, 1, 1)
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+					Expect(mockRepo.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("IsLinkedToInfraction()", func() {
+			g.It("Should return true if the message is linked", func() {
+				mockRepo.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS")).WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+				linked, err := repo.IsLinkedToInfraction(ctx, 2)
+
+				Expect(err).To(BeNil())
+				Expect(linked).To(BeTrue())
+				Expect(mockRepo.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return an error if the query fails", func() {
+				mockRepo.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS")).WillReturnError(fmt.Errorf("err"))
+
+				_, err := repo.IsLinkedToInfraction(ctx, 2)
+
+				Expect(err).ToNot(BeNil())
+				Expect(mockRepo.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
 		g.Describe("Search()", func() {
 			g.Describe("Results found", func() {
 				var results []*domain.ChatMessage
@@ -345,7 +405,7 @@ func Test(t *testing.T) {
 					rows := sqlmock.NewRows(cols)
 
 					for _, msg := range results {
-						rows.AddRow(msg.MessageID, msg.PlayerID, msg.Platform, msg.ServerID, msg.Message, msg.Flagged,
+						rows.AddRow(msg.MessageID, msg.PlayerID, msg.Platform, msg.ServerID, msg.Message, msg.Channel, msg.Flagged,
 							msg.CreatedAt, msg.ModifiedAt)
 					}
 
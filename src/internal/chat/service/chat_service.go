@@ -22,11 +22,20 @@ import (
 	"Refractor/domain"
 	"Refractor/pkg/perms"
 	"context"
+	"fmt"
+	"github.com/guregu/null"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
+// autoActionCooldown is how long a player is not given another automatic infraction of the same type after a flagged
+// chat message, so that a burst of flagged messages does not stack warnings or mutes.
+const autoActionCooldown = time.Minute * 5
+
 type chatService struct {
 	repo               domain.ChatRepo
 	playerRepo         domain.PlayerRepo
@@ -34,13 +43,21 @@ type chatService struct {
 	serverService      domain.ServerService
 	websocketService   domain.WebsocketService
 	flaggedWordService domain.FlaggedWordService
+	infractionService  domain.InfractionService
+	gameService        domain.GameService
+	reportService      domain.ReportService
 	authorizer         domain.Authorizer
 	timeout            time.Duration
 	logger             *zap.Logger
+
+	// autoActions holds when each player was last given an automatic infraction of each type
+	autoActions     map[string]time.Time
+	autoActionsLock sync.Mutex
 }
 
 func NewChatService(repo domain.ChatRepo, pr domain.PlayerRepo, pnr domain.PlayerNameRepo, ss domain.ServerService,
-	wss domain.WebsocketService, fws domain.FlaggedWordService, a domain.Authorizer, to time.Duration, log *zap.Logger) domain.ChatService {
+	wss domain.WebsocketService, fws domain.FlaggedWordService, is domain.InfractionService, gs domain.GameService,
+	rs domain.ReportService, a domain.Authorizer, to time.Duration, log *zap.Logger) domain.ChatService {
 	return &chatService{
 		repo:               repo,
 		playerRepo:         pr,
@@ -48,9 +65,13 @@ func NewChatService(repo domain.ChatRepo, pr domain.PlayerRepo, pnr domain.Playe
 		serverService:      ss,
 		websocketService:   wss,
 		flaggedWordService: fws,
+		infractionService:  is,
+		gameService:        gs,
+		reportService:      rs,
 		authorizer:         a,
 		timeout:            to,
 		logger:             log,
+		autoActions:        map[string]time.Time{},
 	}
 }
 
@@ -58,16 +79,25 @@ func (s *chatService) Store(c context.Context, message *domain.ChatMessage) erro
 	ctx, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
+	_, err := s.store(ctx, message)
+	return err
+}
+
+// store flags the message if it contains any flagged words and stores it. The flagged word matches are returned even
+// if storing the message failed.
+func (s *chatService) store(ctx context.Context, message *domain.ChatMessage) ([]*domain.FlaggedWordMatch, error) {
+	message.Channel = domain.NormalizeChatChannel(message.Channel)
+
 	// Check if this message contains any flagged words
-	shouldBeFlagged, err := s.flaggedWordService.MessageContainsFlaggedWord(ctx, message.Message)
+	matches, err := s.flaggedWordService.FindFlaggedWords(ctx, message.ServerID, message.Channel, message.Message)
 	if err != nil {
 		s.logger.Error("Could not check if message contains flagged word", zap.Error(err))
 		// do not return as this is not a critical error and storing the chat message is more important than flagging it
 	}
 
-	message.Flagged = shouldBeFlagged
+	message.Flagged = len(matches) > 0
 
-	return s.repo.Store(ctx, message)
+	return matches, s.repo.Store(ctx, message)
 }
 
 func (s *chatService) HandleUserSendChat(body *domain.ChatSendBody) {
@@ -112,11 +142,13 @@ func (s *chatService) HandleChatReceive(body *domain.ChatReceiveBody, serverID i
 		Platform: body.Platform,
 		ServerID: serverID,
 		Message:  body.Message,
+		Channel:  body.Channel,
 		Flagged:  false,
 	}
 
 	// Log chat message
-	if err := s.Store(ctx, message); err != nil {
+	matches, err := s.store(ctx, message)
+	if err != nil {
 		s.logger.Error("Could not store chat message in repo",
 			zap.Int64("Server ID", body.ServerID),
 			zap.String("Player ID", body.PlayerID),
@@ -148,6 +180,212 @@ func (s *chatService) HandleChatReceive(body *domain.ChatReceiveBody, serverID i
 
 		// do not return as this is not a critical error
 	}
+
+	if len(matches) > 0 {
+		s.handleFlaggedWordMatches(ctx, message, body.Name, matches)
+	}
+
+	s.handleReportCommand(body, serverID, game)
+}
+
+// handleReportCommand passes the message on to the report service if it uses the game's report command.
+func (s *chatService) handleReportCommand(body *domain.ChatReceiveBody, serverID int64, game domain.Game) {
+	settings, err := s.gameService.GetGameSettings(game)
+	if err != nil {
+		s.logger.Error("Could not get game settings to check for report command", zap.Error(err))
+		return
+	}
+
+	command := settings.General.ReportCommand
+	if command == "" {
+		return
+	}
+
+	target, reason, ok := parseReportCommand(command, body.Message)
+	if !ok {
+		return
+	}
+
+	s.reportService.HandleReportCommand(&domain.ReportCommand{
+		Command:      command,
+		ServerID:     serverID,
+		Platform:     body.Platform,
+		ReporterID:   body.PlayerID,
+		ReporterName: body.Name,
+		Target:       target,
+		Reason:       reason,
+	}, game)
+}
+
+// parseReportCommand checks if a message starts with the report command and splits the rest of it into the reported
+// player's name and the reason. The command is matched case-insensitively. Target and reason are empty if they were
+// not provided.
+func parseReportCommand(command, message string) (target, reason string, ok bool) {
+	fields := strings.Fields(message)
+	if len(fields) < 1 || !strings.EqualFold(fields[0], command) {
+		return "", "", false
+	}
+
+	if len(fields) > 1 {
+		target = fields[1]
+	}
+
+	if len(fields) > 2 {
+		reason = strings.Join(fields[2:], " ")
+	}
+
+	return target, reason, true
+}
+
+// flaggedWordSeverityRanks and flaggedWordActionRanks are used to pick the strongest of multiple flagged word matches.
+var flaggedWordSeverityRanks = rankStrings(domain.AllFlaggedWordSeverities)
+var flaggedWordActionRanks = rankStrings(domain.AllFlaggedWordActions)
+
+func rankStrings(values []string) map[string]int {
+	ranks := map[string]int{}
+	for i, value := range values {
+		ranks[value] = i
+	}
+
+	return ranks
+}
+
+// strongestMatch returns the match with the highest severity. If multiple matches share it, the one with the most
+// severe action wins.
+func strongestMatch(matches []*domain.FlaggedWordMatch) *domain.FlaggedWordMatch {
+	var strongest *domain.FlaggedWordMatch
+
+	for _, match := range matches {
+		if strongest == nil {
+			strongest = match
+			continue
+		}
+
+		severity := flaggedWordSeverityRanks[match.Severity]
+		strongestSeverity := flaggedWordSeverityRanks[strongest.Severity]
+
+		if severity > strongestSeverity || (severity == strongestSeverity &&
+			flaggedWordActionRanks[match.Action] > flaggedWordActionRanks[strongest.Action]) {
+			strongest = match
+		}
+	}
+
+	return strongest
+}
+
+type flaggedWordAlertBody struct {
+	MessageID int64                      `json:"message_id"`
+	ServerID  int64                      `json:"server_id"`
+	PlayerID  string                     `json:"player_id"`
+	Platform  string                     `json:"platform"`
+	Name      string                     `json:"name"`
+	Message   string                     `json:"message"`
+	Channel   string                     `json:"channel"`
+	Matches   []*domain.FlaggedWordMatch `json:"matches"`
+}
+
+// handleFlaggedWordMatches runs the action of the strongest flagged word found in a message. Staff are alerted for the
+// alert action. The warn and mute actions create a system action infraction which the message is linked to, which
+// also runs the infraction's commands on the server.
+func (s *chatService) handleFlaggedWordMatches(ctx context.Context, message *domain.ChatMessage, name string,
+	matches []*domain.FlaggedWordMatch) {
+	match := strongestMatch(matches)
+
+	switch match.Action {
+	case domain.FlaggedWordActionAlert:
+		if err := s.websocketService.BroadcastServerMessage(&domain.WebsocketMessage{
+			Type: "flagged-word-alert",
+			Body: &flaggedWordAlertBody{
+				MessageID: message.MessageID,
+				ServerID:  message.ServerID,
+				PlayerID:  message.PlayerID,
+				Platform:  message.Platform,
+				Name:      name,
+				Message:   message.Message,
+				Channel:   message.Channel,
+				Matches:   matches,
+			},
+		}, message.ServerID, authcheckers.HasPermission(perms.FlagModerateFlaggedMessages, true)); err != nil {
+			s.logger.Warn("Could not broadcast flagged word alert", zap.Int64("Message ID", message.MessageID),
+				zap.Error(err))
+		}
+	case domain.FlaggedWordActionWarn, domain.FlaggedWordActionMute:
+		infraction := &domain.Infraction{
+			PlayerID:     message.PlayerID,
+			Platform:     message.Platform,
+			ServerID:     message.ServerID,
+			Type:         domain.InfractionTypeWarning,
+			Reason:       null.StringFrom(fmt.Sprintf("Flagged chat message: %s", message.Message[match.Start:match.End])),
+			SystemAction: true,
+		}
+
+		if match.Action == domain.FlaggedWordActionMute {
+			infraction.Type = domain.InfractionTypeMute
+			infraction.Duration = null.IntFrom(int64(match.ActionDuration))
+
+			// Players who are already muted don't need another one
+			current, err := s.infractionService.GetCurrentMute(ctx, message.Platform, message.PlayerID)
+			if err != nil && errors.Cause(err) != domain.ErrNotFound {
+				s.logger.Warn("Could not check if player is already muted",
+					zap.String("Platform", message.Platform),
+					zap.String("Player ID", message.PlayerID),
+					zap.Error(err))
+			} else if err == nil && current != nil {
+				return
+			}
+		}
+
+		if !s.claimAutoAction(message.Platform, message.PlayerID, infraction.Type) {
+			return
+		}
+
+		// The message can only be linked if it was stored
+		var linkedMessages []int64
+		if message.MessageID != 0 {
+			linkedMessages = []int64{message.MessageID}
+		}
+
+		if _, err := s.infractionService.Store(ctx, infraction, nil, linkedMessages); err != nil {
+			s.logger.Error("Could not create infraction for flagged chat message",
+				zap.Int64("Message ID", message.MessageID),
+				zap.Int64("Word ID", match.WordID),
+				zap.String("Action", match.Action),
+				zap.Error(err))
+
+			// Let the next flagged message try again
+			s.releaseAutoAction(message.Platform, message.PlayerID, infraction.Type)
+		}
+	}
+}
+
+// claimAutoAction returns true and starts the cooldown if the player can be given an automatic infraction of the
+// provided type. Expired cooldowns are cleaned up along the way.
+func (s *chatService) claimAutoAction(platform, playerID, infractionType string) bool {
+	s.autoActionsLock.Lock()
+	defer s.autoActionsLock.Unlock()
+
+	now := time.Now()
+
+	for key, last := range s.autoActions {
+		if now.Sub(last) >= autoActionCooldown {
+			delete(s.autoActions, key)
+		}
+	}
+
+	key := fmt.Sprintf("%s:%s:%s", platform, playerID, infractionType)
+	if _, ok := s.autoActions[key]; ok {
+		return false
+	}
+
+	s.autoActions[key] = now
+	return true
+}
+
+func (s *chatService) releaseAutoAction(platform, playerID, infractionType string) {
+	s.autoActionsLock.Lock()
+	defer s.autoActionsLock.Unlock()
+
+	delete(s.autoActions, fmt.Sprintf("%s:%s:%s", platform, playerID, infractionType))
 }
 
 func (s *chatService) GetRecentByServer(c context.Context, serverID int64, count int) ([]*domain.ChatMessage, error) {
@@ -181,6 +419,87 @@ func (s *chatService) GetRecentByServer(c context.Context, serverID int64, count
 	return results, nil
 }
 
+// GetContext returns the messages sent around a chat message on the same server, including the message itself.
+//
+// If a user is provided in context under the key "user", they must be able to view chat records on the message's
+// server. Messages linked to an infraction can also be viewed by users who can view infraction records on the server,
+// so that the conversation around an infraction's evidence can be shown.
+//
+// If no user is provided, we assume this is a system call and skip authorization.
+func (s *chatService) GetContext(c context.Context, id int64, before, after int) ([]*domain.ChatMessage, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	message, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user, ok := ctx.Value("user").(*domain.AuthUser); ok {
+		if err := s.checkContextPermission(ctx, user, message); err != nil {
+			return nil, err
+		}
+	}
+
+	results, err := s.repo.GetContext(ctx, id, before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, msg := range results {
+		// Get player name for message
+		currentName, _, err := s.playerNameRepo.GetNames(ctx, msg.PlayerID, msg.Platform)
+		if err != nil {
+			s.logger.Error("Could not get current name for chat context message",
+				zap.String("Platform", msg.Platform),
+				zap.String("Player ID", msg.PlayerID),
+				zap.Int64("Message ID", msg.MessageID),
+				zap.Error(err))
+			continue
+		}
+
+		msg.Name = currentName
+	}
+
+	return results, nil
+}
+
+func (s *chatService) checkContextPermission(ctx context.Context, user *domain.AuthUser, message *domain.ChatMessage) error {
+	scope := domain.AuthScope{
+		Type: domain.AuthObjServer,
+		ID:   message.ServerID,
+	}
+
+	hasPermission, err := s.authorizer.HasPermission(ctx, scope, user.Identity.Id,
+		authcheckers.HasPermission(perms.FlagViewChatRecords, true))
+	if err != nil {
+		return err
+	}
+
+	if hasPermission {
+		return nil
+	}
+
+	linked, err := s.repo.IsLinkedToInfraction(ctx, message.MessageID)
+	if err != nil {
+		return err
+	}
+
+	if linked {
+		hasPermission, err = s.authorizer.HasPermission(ctx, scope, user.Identity.Id,
+			authcheckers.HasPermission(perms.FlagViewInfractionRecords, true))
+		if err != nil {
+			return err
+		}
+	}
+
+	if !hasPermission {
+		return domain.NewHTTPError(nil, http.StatusUnauthorized, "You do not have permission to view this chat message.")
+	}
+
+	return nil
+}
+
 // GetFlaggedMessages returns n amount of recent flagged messages.
 //
 // If a user is provided in context under the key "user", the user will be authorized against servers by their ability
@@ -29,6 +29,8 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 )
@@ -46,6 +48,9 @@ func Test(t *testing.T) {
 		var serverService *mocks.ServerService
 		var websocketService *mocks.WebsocketService
 		var flaggedWordService *mocks.FlaggedWordService
+		var infractionService *mocks.InfractionService
+		var gameService *mocks.GameService
+		var reportService *mocks.ReportService
 		var authorizer *mocks.Authorizer
 		var service *chatService
 		var ctx context.Context
@@ -57,6 +62,9 @@ func Test(t *testing.T) {
 			serverService = new(mocks.ServerService)
 			websocketService = new(mocks.WebsocketService)
 			flaggedWordService = new(mocks.FlaggedWordService)
+			infractionService = new(mocks.InfractionService)
+			gameService = new(mocks.GameService)
+			reportService = new(mocks.ReportService)
 			authorizer = new(mocks.Authorizer)
 
 			service = &chatService{
@@ -66,9 +74,13 @@ func Test(t *testing.T) {
 				serverService:      serverService,
 				websocketService:   websocketService,
 				flaggedWordService: flaggedWordService,
+				infractionService:  infractionService,
+				gameService:        gameService,
+				reportService:      reportService,
 				authorizer:         authorizer,
 				timeout:            time.Second * 2,
 				logger:             zap.NewNop(),
+				autoActions:        map[string]time.Time{},
 			}
 
 			ctx = context.TODO()
@@ -89,7 +101,8 @@ func Test(t *testing.T) {
 
 			g.Describe("Successful store", func() {
 				g.BeforeEach(func() {
-					flaggedWordService.On("MessageContainsFlaggedWord", mock.Anything, mock.Anything).Return(false, nil)
+					flaggedWordService.On("FindFlaggedWords", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+						Return([]*domain.FlaggedWordMatch{}, nil)
 					repo.On("Store", mock.Anything, mock.Anything).Return(nil)
 				})
 
@@ -103,7 +116,8 @@ func Test(t *testing.T) {
 
 			g.Describe("Repo error", func() {
 				g.BeforeEach(func() {
-					flaggedWordService.On("MessageContainsFlaggedWord", mock.Anything, mock.Anything).Return(false, nil)
+					flaggedWordService.On("FindFlaggedWords", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+						Return([]*domain.FlaggedWordMatch{}, nil)
 					repo.On("Store", mock.Anything, mock.Anything).Return(fmt.Errorf("err"))
 				})
 
@@ -120,6 +134,7 @@ func Test(t *testing.T) {
 			var zapCore zapcore.Core
 			var recordedLogs *observer.ObservedLogs
 			var body *domain.ChatReceiveBody
+			var settings *domain.GameSettings
 
 			g.BeforeEach(func() {
 				// Since HandleChatReceive does not return error, we can check if any error occurred by the logger output.
@@ -134,6 +149,8 @@ func Test(t *testing.T) {
 					Message:    "test chat message",
 					SentByUser: false,
 				}
+				settings = &domain.GameSettings{General: &domain.GeneralSettings{}}
+				gameService.On("GetGameSettings", mock.Anything).Return(settings, nil)
 			})
 
 			g.Describe("Successful message broadcast and storage", func() {
@@ -145,7 +162,8 @@ func Test(t *testing.T) {
 						Platform:    body.Platform,
 						CurrentName: body.Name,
 					}, nil)
-					flaggedWordService.On("MessageContainsFlaggedWord", mock.Anything, mock.Anything).Return(false, nil)
+					flaggedWordService.On("FindFlaggedWords", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+						Return([]*domain.FlaggedWordMatch{}, nil)
 					repo.On("Store", mock.Anything, mock.Anything).Return(nil)
 				})
 
@@ -157,6 +175,26 @@ func Test(t *testing.T) {
 					playerRepo.AssertExpectations(t)
 					repo.AssertExpectations(t)
 				})
+
+				g.It("Should store the message in its channel", func() {
+					body.Channel = domain.ChatChannelTeam
+
+					service.HandleChatReceive(body, body.ServerID, nil)
+
+					flaggedWordService.AssertCalled(t, "FindFlaggedWords", mock.Anything, body.ServerID,
+						domain.ChatChannelTeam, body.Message)
+					repo.AssertCalled(t, "Store", mock.Anything, mock.MatchedBy(func(m *domain.ChatMessage) bool {
+						return m.Channel == domain.ChatChannelTeam
+					}))
+				})
+
+				g.It("Should store messages without a channel in all-chat", func() {
+					service.HandleChatReceive(body, body.ServerID, nil)
+
+					repo.AssertCalled(t, "Store", mock.Anything, mock.MatchedBy(func(m *domain.ChatMessage) bool {
+						return m.Channel == domain.ChatChannelAll
+					}))
+				})
 			})
 
 			g.Describe("Websocket broadcast error", func() {
@@ -172,7 +210,8 @@ func Test(t *testing.T) {
 						CurrentName: body.Name,
 					}, nil)
 					repo.On("Store", mock.Anything, mock.Anything).Return(nil)
-					flaggedWordService.On("MessageContainsFlaggedWord", mock.Anything, mock.Anything).Return(false, nil)
+					flaggedWordService.On("FindFlaggedWords", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+						Return([]*domain.FlaggedWordMatch{}, nil)
 				})
 
 				g.It("Should only log one error of level Warning", func() {
@@ -219,7 +258,8 @@ func Test(t *testing.T) {
 						CurrentName: body.Name,
 					}, nil)
 					repo.On("Store", mock.Anything, mock.Anything).Return(fmt.Errorf("repo err"))
-					flaggedWordService.On("MessageContainsFlaggedWord", mock.Anything, mock.Anything).Return(false, nil)
+					flaggedWordService.On("FindFlaggedWords", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+						Return([]*domain.FlaggedWordMatch{}, nil)
 				})
 
 				g.It("Should only log one error of level Error", func() {
@@ -231,6 +271,271 @@ func Test(t *testing.T) {
 					repo.AssertExpectations(t)
 				})
 			})
+
+			g.Describe("Flagged word matched", func() {
+				var matches []*domain.FlaggedWordMatch
+
+				g.BeforeEach(func() {
+					matches = []*domain.FlaggedWordMatch{{
+						WordID:   1,
+						Word:     "chat",
+						Severity: domain.FlaggedWordSeverityLow,
+						Action:   domain.FlaggedWordActionFlag,
+						Start:    5,
+						End:      9,
+					}}
+
+					playerRepo.On("GetByID", mock.Anything, mock.Anything, mock.Anything).Return(&domain.Player{
+						PlayerID:    body.PlayerID,
+						Platform:    body.Platform,
+						CurrentName: body.Name,
+					}, nil)
+					flaggedWordService.On("FindFlaggedWords", mock.Anything, body.ServerID, domain.ChatChannelAll,
+						body.Message).Return(matches, nil)
+					repo.On("Store", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+						args.Get(1).(*domain.ChatMessage).MessageID = 10
+					}).Return(nil)
+				})
+
+				g.Describe("Flag action", func() {
+					g.BeforeEach(func() {
+						websocketService.On("BroadcastServerMessage", mock.Anything, mock.Anything, mock.Anything).
+							Return(nil).Once()
+					})
+
+					g.It("Should store the message as flagged without any other action", func() {
+						service.HandleChatReceive(body, body.ServerID, nil)
+
+						Expect(recordedLogs.All()).To(Equal([]observer.LoggedEntry{}))
+						repo.AssertCalled(t, "Store", mock.Anything, mock.MatchedBy(func(m *domain.ChatMessage) bool {
+							return m.Flagged
+						}))
+						websocketService.AssertExpectations(t)
+						infractionService.AssertNotCalled(t, "Store", mock.Anything, mock.Anything, mock.Anything,
+							mock.Anything)
+					})
+				})
+
+				g.Describe("Alert action", func() {
+					g.BeforeEach(func() {
+						matches[0].Action = domain.FlaggedWordActionAlert
+
+						websocketService.On("BroadcastServerMessage", mock.Anything, mock.Anything, mock.Anything).
+							Return(nil)
+					})
+
+					g.It("Should send a flagged word alert", func() {
+						service.HandleChatReceive(body, body.ServerID, nil)
+
+						Expect(recordedLogs.All()).To(Equal([]observer.LoggedEntry{}))
+						websocketService.AssertCalled(t, "BroadcastServerMessage",
+							mock.MatchedBy(func(m *domain.WebsocketMessage) bool {
+								return m.Type == "flagged-word-alert"
+							}), body.ServerID, mock.Anything)
+					})
+				})
+
+				g.Describe("Mute action", func() {
+					g.BeforeEach(func() {
+						matches[0].Action = domain.FlaggedWordActionMute
+						matches[0].ActionDuration = 15
+
+						websocketService.On("BroadcastServerMessage", mock.Anything, mock.Anything, mock.Anything).
+							Return(nil)
+						infractionService.On("Store", mock.Anything, mock.AnythingOfType("*domain.Infraction"),
+							mock.Anything, []int64{10}).Return(&domain.Infraction{}, nil)
+					})
+
+					g.Describe("Player is not muted", func() {
+						g.BeforeEach(func() {
+							infractionService.On("GetCurrentMute", mock.Anything, body.Platform, body.PlayerID).
+								Return(nil, domain.ErrNotFound)
+						})
+
+						g.It("Should store a system action mute linked to the message", func() {
+							service.HandleChatReceive(body, body.ServerID, nil)
+
+							Expect(recordedLogs.All()).To(Equal([]observer.LoggedEntry{}))
+							infractionService.AssertExpectations(t)
+							infractionService.AssertCalled(t, "Store", mock.Anything, mock.MatchedBy(func(i *domain.Infraction) bool {
+								return i.Type == domain.InfractionTypeMute && i.Duration.ValueOrZero() == 15 && i.SystemAction &&
+									strings.Contains(i.Reason.ValueOrZero(), "chat")
+							}), mock.Anything, []int64{10})
+						})
+					})
+
+					g.Describe("Player is already muted", func() {
+						g.BeforeEach(func() {
+							infractionService.On("GetCurrentMute", mock.Anything, body.Platform, body.PlayerID).
+								Return(&domain.Infraction{InfractionID: 3, Type: domain.InfractionTypeMute}, nil)
+						})
+
+						g.It("Should not store another mute", func() {
+							service.HandleChatReceive(body, body.ServerID, nil)
+
+							infractionService.AssertNotCalled(t, "Store", mock.Anything, mock.Anything, mock.Anything,
+								mock.Anything)
+						})
+					})
+				})
+
+				g.Describe("Repeated flagged messages", func() {
+					g.BeforeEach(func() {
+						matches[0].Action = domain.FlaggedWordActionWarn
+
+						websocketService.On("BroadcastServerMessage", mock.Anything, mock.Anything, mock.Anything).
+							Return(nil)
+						infractionService.On("Store", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+							Return(&domain.Infraction{}, nil)
+					})
+
+					g.It("Should only give the player one warning during the cooldown", func() {
+						service.HandleChatReceive(body, body.ServerID, nil)
+						service.HandleChatReceive(body, body.ServerID, nil)
+						service.HandleChatReceive(body, body.ServerID, nil)
+
+						infractionService.AssertNumberOfCalls(t, "Store", 1)
+					})
+
+					g.It("Should warn the player again once the cooldown is over", func() {
+						service.HandleChatReceive(body, body.ServerID, nil)
+
+						for key := range service.autoActions {
+							service.autoActions[key] = time.Now().Add(-autoActionCooldown)
+						}
+
+						service.HandleChatReceive(body, body.ServerID, nil)
+
+						infractionService.AssertNumberOfCalls(t, "Store", 2)
+					})
+				})
+
+				g.Describe("Infraction service error", func() {
+					g.BeforeEach(func() {
+						matches[0].Action = domain.FlaggedWordActionWarn
+
+						websocketService.On("BroadcastServerMessage", mock.Anything, mock.Anything, mock.Anything).
+							Return(nil)
+						infractionService.On("Store", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+							Return(nil, fmt.Errorf("err"))
+					})
+
+					g.It("Should only log one error of level Error", func() {
+						service.HandleChatReceive(body, body.ServerID, nil)
+
+						Expect(len(recordedLogs.All())).To(Equal(1))
+						infractionService.AssertExpectations(t)
+					})
+				})
+			})
+
+			g.Describe("Report command used", func() {
+				g.BeforeEach(func() {
+					settings.General.ReportCommand = "!report"
+					body.Message = "!REPORT bob spamming the chat"
+
+					websocketService.On("BroadcastServerMessage", mock.Anything, mock.Anything, mock.Anything).
+						Return(nil)
+					playerRepo.On("GetByID", mock.Anything, mock.Anything, mock.Anything).Return(&domain.Player{
+						PlayerID:    body.PlayerID,
+						Platform:    body.Platform,
+						CurrentName: body.Name,
+					}, nil)
+					flaggedWordService.On("FindFlaggedWords", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+						Return([]*domain.FlaggedWordMatch{}, nil)
+					repo.On("Store", mock.Anything, mock.Anything).Return(nil)
+					reportService.On("HandleReportCommand", mock.Anything, mock.Anything).Return()
+				})
+
+				g.It("Should pass the report command to the report service", func() {
+					service.HandleChatReceive(body, body.ServerID, nil)
+
+					Expect(recordedLogs.All()).To(Equal([]observer.LoggedEntry{}))
+					reportService.AssertCalled(t, "HandleReportCommand", &domain.ReportCommand{
+						Command:      "!report",
+						ServerID:     body.ServerID,
+						Platform:     body.Platform,
+						ReporterID:   body.PlayerID,
+						ReporterName: body.Name,
+						Target:       "bob",
+						Reason:       "spamming the chat",
+					}, nil)
+				})
+			})
+
+			g.Describe("Report command disabled", func() {
+				g.BeforeEach(func() {
+					body.Message = "!report bob spamming the chat"
+
+					websocketService.On("BroadcastServerMessage", mock.Anything, mock.Anything, mock.Anything).
+						Return(nil)
+					playerRepo.On("GetByID", mock.Anything, mock.Anything, mock.Anything).Return(&domain.Player{
+						PlayerID:    body.PlayerID,
+						Platform:    body.Platform,
+						CurrentName: body.Name,
+					}, nil)
+					flaggedWordService.On("FindFlaggedWords", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+						Return([]*domain.FlaggedWordMatch{}, nil)
+					repo.On("Store", mock.Anything, mock.Anything).Return(nil)
+				})
+
+				g.It("Should not call the report service", func() {
+					service.HandleChatReceive(body, body.ServerID, nil)
+
+					reportService.AssertNotCalled(t, "HandleReportCommand", mock.Anything, mock.Anything)
+				})
+			})
+		})
+
+		g.Describe("parseReportCommand()", func() {
+			g.It("Should split the target and reason", func() {
+				target, reason, ok := parseReportCommand("!report", "!report  bob   spam and  abuse ")
+
+				Expect(ok).To(BeTrue())
+				Expect(target).To(Equal("bob"))
+				Expect(reason).To(Equal("spam and abuse"))
+			})
+
+			g.It("Should return empty values if the target and reason are missing", func() {
+				target, reason, ok := parseReportCommand("!report", "!report")
+
+				Expect(ok).To(BeTrue())
+				Expect(target).To(Equal(""))
+				Expect(reason).To(Equal(""))
+			})
+
+			g.It("Should not match messages which don't start with the command", func() {
+				_, _, ok := parseReportCommand("!report", "please !report bob")
+
+				Expect(ok).To(BeFalse())
+			})
+
+			g.It("Should not match a longer command", func() {
+				_, _, ok := parseReportCommand("!report", "!reports bob spam")
+
+				Expect(ok).To(BeFalse())
+			})
+		})
+
+		g.Describe("strongestMatch()", func() {
+			g.It("Should prefer the highest severity", func() {
+				match := strongestMatch([]*domain.FlaggedWordMatch{
+					{WordID: 1, Severity: domain.FlaggedWordSeverityLow, Action: domain.FlaggedWordActionMute},
+					{WordID: 2, Severity: domain.FlaggedWordSeverityHigh, Action: domain.FlaggedWordActionFlag},
+				})
+
+				Expect(match.WordID).To(Equal(int64(2)))
+			})
+
+			g.It("Should prefer the most severe action between equal severities", func() {
+				match := strongestMatch([]*domain.FlaggedWordMatch{
+					{WordID: 1, Severity: domain.FlaggedWordSeverityMedium, Action: domain.FlaggedWordActionAlert},
+					{WordID: 2, Severity: domain.FlaggedWordSeverityMedium, Action: domain.FlaggedWordActionWarn},
+					{WordID: 3, Severity: domain.FlaggedWordSeverityMedium, Action: domain.FlaggedWordActionFlag},
+				})
+
+				Expect(match.WordID).To(Equal(int64(2)))
+			})
 		})
 
 		g.Describe("GetRecentByServer()", func() {
@@ -490,6 +795,108 @@ func Test(t *testing.T) {
 			})
 		})
 
+		g.Describe("GetContext()", func() {
+			var messages []*domain.ChatMessage
+
+			g.BeforeEach(func() {
+				messages = []*domain.ChatMessage{
+					{MessageID: 1, PlayerID: "playerid1", Platform: "platform", ServerID: 1, Message: "before"},
+					{MessageID: 2, PlayerID: "playerid2", Platform: "platform", ServerID: 1, Message: "target"},
+					{MessageID: 3, PlayerID: "playerid3", Platform: "platform", ServerID: 1, Message: "after"},
+				}
+
+				repo.On("GetByID", mock.Anything, int64(2)).Return(messages[1], nil)
+				repo.On("GetContext", mock.Anything, int64(2), 1, 1).Return(messages, nil)
+				playerNameRepo.On("GetNames", mock.Anything, mock.Anything, mock.Anything).
+					Return("currentName", []string{}, nil)
+			})
+
+			g.Describe("No user in context", func() {
+				g.It("Should return the messages with their names", func() {
+					got, err := service.GetContext(ctx, 2, 1, 1)
+
+					Expect(err).To(BeNil())
+					Expect(len(got)).To(Equal(3))
+					Expect(got[0].Name).To(Equal("currentName"))
+					authorizer.AssertNotCalled(t, "HasPermission", mock.Anything, mock.Anything, mock.Anything,
+						mock.Anything)
+				})
+			})
+
+			g.Describe("User was provided in context", func() {
+				g.BeforeEach(func() {
+					ctx = context.WithValue(ctx, "user", &domain.AuthUser{
+						Session: &kratos.Session{Identity: kratos.Identity{Id: "testuserid"}},
+					})
+				})
+
+				g.Describe("User can view chat records", func() {
+					g.BeforeEach(func() {
+						authorizer.On("HasPermission", mock.Anything, domain.AuthScope{
+							Type: domain.AuthObjServer,
+							ID:   int64(1),
+						}, "testuserid", mock.Anything).Return(true, nil)
+					})
+
+					g.It("Should return the messages", func() {
+						got, err := service.GetContext(ctx, 2, 1, 1)
+
+						Expect(err).To(BeNil())
+						Expect(got).To(Equal(messages))
+						repo.AssertNotCalled(t, "IsLinkedToInfraction", mock.Anything, mock.Anything)
+					})
+				})
+
+				g.Describe("User can only view infraction records and the message is linked", func() {
+					g.BeforeEach(func() {
+						authorizer.On("HasPermission", mock.Anything, mock.Anything, "testuserid", mock.Anything).
+							Return(false, nil).Once()
+						authorizer.On("HasPermission", mock.Anything, mock.Anything, "testuserid", mock.Anything).
+							Return(true, nil).Once()
+						repo.On("IsLinkedToInfraction", mock.Anything, int64(2)).Return(true, nil)
+					})
+
+					g.It("Should return the messages", func() {
+						got, err := service.GetContext(ctx, 2, 1, 1)
+
+						Expect(err).To(BeNil())
+						Expect(got).To(Equal(messages))
+						authorizer.AssertExpectations(t)
+					})
+				})
+
+				g.Describe("User cannot view chat records and the message is not linked", func() {
+					g.BeforeEach(func() {
+						authorizer.On("HasPermission", mock.Anything, mock.Anything, "testuserid", mock.Anything).
+							Return(false, nil)
+						repo.On("IsLinkedToInfraction", mock.Anything, int64(2)).Return(false, nil)
+					})
+
+					g.It("Should return an unauthorized error", func() {
+						_, err := service.GetContext(ctx, 2, 1, 1)
+
+						httpErr, ok := err.(*domain.HTTPError)
+						Expect(ok).To(BeTrue())
+						Expect(httpErr.Status).To(Equal(http.StatusUnauthorized))
+						repo.AssertNotCalled(t, "GetContext", mock.Anything, mock.Anything, mock.Anything,
+							mock.Anything)
+					})
+				})
+			})
+
+			g.Describe("Message not found", func() {
+				g.It("Should return domain.ErrNotFound", func() {
+					repo = new(mocks.ChatRepo)
+					service.repo = repo
+					repo.On("GetByID", mock.Anything, int64(2)).Return(nil, domain.ErrNotFound)
+
+					_, err := service.GetContext(ctx, 2, 1, 1)
+
+					Expect(err).To(Equal(domain.ErrNotFound))
+				})
+			})
+		})
+
 		g.Describe("GetFlaggedMessageCount()", func() {
 			g.Describe("Count fetched", func() {
 				g.BeforeEach(func() {
@@ -19,38 +19,66 @@ package command_executor
 
 import (
 	"Refractor/domain"
+	"Refractor/pkg/cmdtemplate"
 	"context"
+	"github.com/guregu/null"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
-	"strconv"
-	"strings"
+	"time"
 )
 
+const (
+	// maxCommandAttempts is the number of times a command will be attempted before it is marked as failed.
+	maxCommandAttempts = 5
+
+	// commandTTL is how long a command will wait in the queue for its server to come online before it expires.
+	commandTTL = time.Hour * 24
+
+	// runnerPollInterval is how often the runner checks the queue for commands which are ready to be run.
+	runnerPollInterval = time.Second * 5
+
+	// runnerBatchSize is the maximum number of commands claimed for a single server at once.
+	runnerBatchSize = 25
+
+	// commandClaimLease is how long a runner may hold a claimed command before it is considered interrupted and the
+	// command is returned to the queue. It must be longer than it takes to run a full batch of commands.
+	commandClaimLease = time.Minute * 10
+
+	retryBaseDelay = time.Second * 5
+	retryMaxDelay  = time.Minute * 5
+)
+
+// punishmentActions are the infraction actions whose commands apply an infraction to a player in-game.
+var punishmentActions = []string{
+	domain.InfractionCommandCreate,
+	domain.InfractionCommandUpdate,
+	domain.InfractionCommandSync,
+}
+
 type executor struct {
 	rconService    domain.RCONService
 	gameService    domain.GameService
 	serverRepo     domain.ServerRepo
 	userRepo       domain.UserMetaRepo
 	playerNameRepo domain.PlayerNameRepo
+	queueRepo      domain.CommandQueueRepo
+	ruleRepo       domain.RuleRepo
 	logger         *zap.Logger
-	queue          chan *queuedCommand
-}
-
-type queuedCommand struct {
-	cmd      string
-	serverID int64
+	wake           chan struct{}
 }
 
 func NewCommandExecutor(rs domain.RCONService, gs domain.GameService, sr domain.ServerRepo, umr domain.UserMetaRepo, pnr domain.PlayerNameRepo,
-	log *zap.Logger) domain.CommandExecutor {
+	cqr domain.CommandQueueRepo, rr domain.RuleRepo, log *zap.Logger) domain.CommandExecutor {
 	return &executor{
 		rconService:    rs,
 		gameService:    gs,
 		serverRepo:     sr,
 		userRepo:       umr,
 		playerNameRepo: pnr,
+		queueRepo:      cqr,
+		ruleRepo:       rr,
 		logger:         log,
-		queue:          make(chan *queuedCommand, 100),
+		wake:           make(chan struct{}, 1),
 	}
 }
 
@@ -110,6 +138,20 @@ func (e *executor) PrepareInfractionCommands(ctx context.Context, infraction dom
 		return nil, domain.ErrNotFound
 	}
 
+	// Get the title of the rule which was broken, if any
+	var ruleTitle string
+	if infraction.GetRuleID() > 0 {
+		rule, err := e.ruleRepo.GetByID(ctx, infraction.GetRuleID())
+		if err != nil {
+			e.logger.Error("Could not get infraction rule",
+				zap.Int64("Rule ID", infraction.GetRuleID()),
+				zap.Error(err))
+			return nil, err
+		}
+
+		ruleTitle = rule.Title
+	}
+
 	// Determine commands to prepare based on action and infraction type
 	infrActionMap := gameSettings.Commands.InfractionActionMap()
 	actMap := infrActionMap[action]
@@ -123,6 +165,13 @@ func (e *executor) PrepareInfractionCommands(ctx context.Context, infraction dom
 		return nil, errors.New("no commands found for infraction type: " + infraction.GetType())
 	}
 
+	// Determine the user who triggered these commands. If a user is set in context, they're the one taking action on
+	// the infraction. Otherwise, the commands are attributed to the infraction's creator.
+	originUserID := infraction.GetUserID()
+	if user, ok := ctx.Value("user").(*domain.AuthUser); ok && user != nil {
+		originUserID = user.Identity.Id
+	}
+
 	// Prepare the commands
 	commands := make([]domain.Command, 0)
 
@@ -131,96 +180,321 @@ func (e *executor) PrepareInfractionCommands(ctx context.Context, infraction dom
 		durationRemaining = 0
 	}
 
-	// Parse and run the commands
+	tmplData := &cmdtemplate.Data{
+		PlayerID:          infraction.GetPlayerID(),
+		Platform:          infraction.GetPlatform(),
+		PlayerName:        playerName,
+		Issuer:            creatorName,
+		Reason:            infraction.GetReason(),
+		Rule:              ruleTitle,
+		Type:              infraction.GetType(),
+		Duration:          infraction.GetDuration(),
+		DurationRemaining: durationRemaining,
+	}
+
+	// Render the commands
 	for _, cmd := range cmds {
-		// Replace placeholders inside command with payload data
-		runCmd := strings.ReplaceAll(cmd.Command, "{{PLAYER_ID}}", infraction.GetPlayerID())
-		runCmd = strings.ReplaceAll(runCmd, "{{PLATFORM}}", infraction.GetPlatform())
-		runCmd = strings.ReplaceAll(runCmd, "{{PLAYER_NAME}}", playerName)
-		runCmd = strings.ReplaceAll(runCmd, "{{ISSUER}}", creatorName)
-		runCmd = strings.ReplaceAll(runCmd, "{{DURATION}}", strconv.FormatInt(infraction.GetDuration(), 10))
-		runCmd = strings.ReplaceAll(runCmd, "{{DURATION_REMAINING}}", strconv.FormatInt(durationRemaining, 10))
-		runCmd = strings.ReplaceAll(runCmd, "{{REASON}}", infraction.GetReason())
+		runCmd, err := cmdtemplate.Render(cmd.Command, tmplData)
+		if err != nil {
+			e.logger.Error("Could not render infraction command",
+				zap.String("Command", cmd.Command),
+				zap.Int64("Infraction ID", infraction.GetInfractionID()),
+				zap.Error(err))
+			return nil, errors.Wrap(err, "could not render command")
+		}
 
 		commands = append(commands, &infractionCommand{
-			Command:  runCmd,
-			RunOnAll: cmd.RunOnAll,
-			ServerID: serverID,
+			Command:        runCmd,
+			RunOnAll:       cmd.RunOnAll,
+			ServerID:       serverID,
+			InfractionID:   infraction.GetInfractionID(),
+			UserID:         originUserID,
+			PlayerID:       infraction.GetPlayerID(),
+			Platform:       infraction.GetPlatform(),
+			InfractionType: infraction.GetType(),
+			Action:         action,
 		})
 	}
 
 	return newInfractionCommandPayload(commands, game), nil
 }
 
+// QueueCommands persists the commands inside of the provided payload to the command queue. Commands which should run
+// on all servers are queued once for every active server of the payload's game. Commands for servers which are offline
+// remain in the queue until the server comes back online or the command expires.
 func (e *executor) QueueCommands(payload domain.CommandPayload) error {
+	ctx := context.TODO()
+
+	targets, err := e.getCommandTargets(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	for i, cmd := range payload.GetCommands() {
+		for _, serverID := range targets[i] {
+			// Add command to queue
+			if err := e.enqueue(ctx, cmd, serverID); err != nil {
+				return err
+			}
+		}
+	}
+
+	e.notifyRunner()
+
+	return nil
+}
+
+// PreviewCommands returns the commands inside of the provided payload along with the servers they would be queued on.
+// Nothing is queued or executed.
+func (e *executor) PreviewCommands(ctx context.Context, payload domain.CommandPayload) ([]*domain.CommandPreview, error) {
+	targets, err := e.getCommandTargets(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	previews := make([]*domain.CommandPreview, 0, len(targets))
+
+	for i, cmd := range payload.GetCommands() {
+		previews = append(previews, &domain.CommandPreview{
+			Command:   cmd.GetCommand(),
+			RunOnAll:  cmd.ShouldRunOnAll(),
+			ServerIDs: targets[i],
+		})
+	}
+
+	return previews, nil
+}
+
+// getCommandTargets returns the IDs of the servers each command inside of the payload should run on, in the same order
+// as the payload's commands. Commands which should run on all servers target every active server of the payload's game.
+func (e *executor) getCommandTargets(ctx context.Context, payload domain.CommandPayload) ([][]int64, error) {
 	game := payload.GetGame()
 	cmds := payload.GetCommands()
 
 	// Get servers of this game
-	serversOfGame, err := e.serverRepo.GetByGame(context.TODO(), game.GetName())
+	serversOfGame, err := e.serverRepo.GetByGame(ctx, game.GetName())
 	if err != nil {
 		e.logger.Error("Command executor could not get servers by game", zap.String("Game",
 			game.GetName()),
 			zap.Error(err))
-		return err
+		return nil, err
 	}
 
-	for _, cmd := range cmds {
+	targets := make([][]int64, len(cmds))
+
+	for i, cmd := range cmds {
 		if !cmd.ShouldRunOnAll() {
 			// Only run on the specified server
-			e.queue <- &queuedCommand{
-				cmd:      cmd.GetCommand(),
-				serverID: cmd.GetServerID(),
-			}
+			targets[i] = []int64{cmd.GetServerID()}
 			continue
 		}
 
-		// Queue on all servers running this game
+		// Target all servers running this game
+		targets[i] = []int64{}
 		for _, server := range serversOfGame {
 			if server.Deactivated {
 				// do not run on deactivated servers
 				continue
 			}
 
-			// Add command to queue
-			e.queue <- &queuedCommand{
-				cmd:      cmd.GetCommand(),
-				serverID: server.ID,
-			}
+			targets[i] = append(targets[i], server.ID)
 		}
 	}
 
+	return targets, nil
+}
+
+func (e *executor) enqueue(ctx context.Context, cmd domain.Command, serverID int64) error {
+	now := time.Now()
+
+	queued := &domain.QueuedCommand{
+		ServerID:       serverID,
+		InfractionID:   null.NewInt(cmd.GetInfractionID(), cmd.GetInfractionID() != 0),
+		UserID:         null.NewString(cmd.GetUserID(), cmd.GetUserID() != ""),
+		Platform:       null.NewString(cmd.GetPlatform(), cmd.GetPlatform() != ""),
+		PlayerID:       null.NewString(cmd.GetPlayerID(), cmd.GetPlayerID() != ""),
+		InfractionType: null.NewString(cmd.GetInfractionType(), cmd.GetInfractionType() != ""),
+		Action:         null.NewString(cmd.GetAction(), cmd.GetAction() != ""),
+		Command:        cmd.GetCommand(),
+		Status:         domain.CommandStatusPending,
+		MaxAttempts:    maxCommandAttempts,
+		NextAttemptAt:  now,
+		ExpiresAt:      null.TimeFrom(now.Add(commandTTL)),
+	}
+
+	if err := e.queueRepo.Store(ctx, queued); err != nil {
+		e.logger.Error("Could not store queued command",
+			zap.String("Command", cmd.GetCommand()),
+			zap.Int64("Server ID", serverID),
+			zap.Error(err))
+		return err
+	}
+
 	return nil
 }
 
-// StartRunner is a runner routine which reads from the command queue and executes the commands within
-// it on the correct server. This should only be used when the command response doesn't matter because
-// the response is not returned out of the runner.
+// HasRecentPunishment checks the command queue for create, update or sync commands of an infraction type which were run
+// against a player on a server. These commands are what games report back as in-game moderation actions.
+func (e *executor) HasRecentPunishment(ctx context.Context, serverID int64, platform, playerID, infractionType string,
+	since time.Time) (bool, error) {
+	return e.queueRepo.HasRecentPlayerCommand(ctx, serverID, platform, playerID, infractionType, punishmentActions, since)
+}
+
+// notifyRunner wakes up the runner routine so that newly runnable commands are executed right away rather than on the
+// next poll. If a wake up is already pending, this is a no-op.
+func (e *executor) notifyRunner() {
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// HandleServerStatusChange wakes up the runner when a server comes online so that any commands which were queued while
+// it was offline are delivered.
+func (e *executor) HandleServerStatusChange(serverID int64, status string) {
+	if status != "Online" {
+		return
+	}
+
+	e.logger.Info("Server came online. Delivering queued commands.", zap.Int64("Server ID", serverID))
+	e.notifyRunner()
+}
+
+// StartRunner is a runner routine which reads from the command queue and executes the commands within it on the
+// correct server. The runner polls the queue periodically and is also woken up whenever new commands are queued or a
+// server comes back online. The outcome of each command, including the response, is recorded on the queued command
+// rather than returned out of the runner.
 func (e *executor) StartRunner(terminate chan uint8) {
+	ticker := time.NewTicker(runnerPollInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-terminate:
 			e.logger.Info("Terminating command runner routine")
-			break
-		case queuedCmd := <-e.queue:
-			client := e.rconService.GetServerClient(queuedCmd.serverID)
-			if client == nil {
-				e.logger.Warn("Could not run commands on server. RCON client was nil.", zap.Int64("Server ID", queuedCmd.serverID))
-				break
-			}
+			return
+		case <-e.wake:
+		case <-ticker.C:
+		}
 
-			e.logger.Info("Running command", zap.String("cmd", queuedCmd.cmd))
-			if _, err := client.RunCommand(queuedCmd.cmd); err != nil {
-				e.logger.Error("Could not execute command on server",
-					zap.String("Command", queuedCmd.cmd),
-					zap.Int64("Server ID", queuedCmd.serverID),
-					zap.Error(err))
-				break
-			}
+		e.processQueue()
+	}
+}
+
+func (e *executor) processQueue() {
+	ctx := context.TODO()
+
+	// Commands which have been running for longer than the claim lease were interrupted by a shutdown or crash of
+	// their runner, so we put them back into the queue. Commands claimed by live runners are left alone.
+	if count, err := e.queueRepo.ResetRunning(ctx, time.Now().Add(-commandClaimLease)); err != nil {
+		e.logger.Error("Could not reset interrupted queued commands", zap.Error(err))
+	} else if count > 0 {
+		e.logger.Info("Reset interrupted queued commands", zap.Int64("Count", count))
+	}
+
+	if count, err := e.queueRepo.ExpireStale(ctx); err != nil {
+		e.logger.Error("Could not expire stale queued commands", zap.Error(err))
+	} else if count > 0 {
+		e.logger.Warn("Queued commands expired before they could be run", zap.Int64("Count", count))
+	}
+
+	serverIDs, err := e.queueRepo.GetPendingServers(ctx)
+	if err != nil {
+		e.logger.Error("Could not get servers with pending commands", zap.Error(err))
+		return
+	}
+
+	for _, serverID := range serverIDs {
+		// Commands for offline servers are left in the queue until the server comes back online
+		if e.rconService.GetServerClient(serverID) == nil {
+			continue
+		}
+
+		cmds, err := e.queueRepo.ClaimPending(ctx, serverID, runnerBatchSize)
+		if err != nil {
+			e.logger.Error("Could not claim pending commands", zap.Int64("Server ID", serverID), zap.Error(err))
+			continue
+		}
+
+		for _, cmd := range cmds {
+			e.runQueuedCommand(ctx, cmd)
+		}
+	}
+}
+
+func (e *executor) runQueuedCommand(ctx context.Context, cmd *domain.QueuedCommand) {
+	client := e.rconService.GetServerClient(cmd.ServerID)
+	if client == nil {
+		// The server went offline after the command was claimed. Put it back into the queue without counting this
+		// as an attempt.
+		e.logger.Warn("Could not run command on server. RCON client was nil. Command will be retried once the server is online.",
+			zap.Int64("Server ID", cmd.ServerID))
+		e.updateQueuedCommand(ctx, cmd.CommandID, domain.UpdateArgs{"Status": domain.CommandStatusPending})
+		return
+	}
+
+	e.logger.Info("Running command", zap.String("cmd", cmd.Command))
+
+	attempts := cmd.Attempts + 1
+
+	startedAt := time.Now()
+	res, err := client.RunCommand(cmd.Command)
+	finishedAt := time.Now()
+
+	if err != nil {
+		e.logger.Error("Could not execute command on server",
+			zap.String("Command", cmd.Command),
+			zap.Int64("Server ID", cmd.ServerID),
+			zap.Int("Attempt", attempts),
+			zap.Error(err))
+
+		args := domain.UpdateArgs{
+			"Attempts":   attempts,
+			"LastError":  err.Error(),
+			"StartedAt":  startedAt,
+			"FinishedAt": finishedAt,
+		}
 
-			e.logger.Info("Executed command on server",
-				zap.String("Command", queuedCmd.cmd),
-				zap.Int64("Server ID", queuedCmd.serverID))
+		if attempts >= cmd.MaxAttempts {
+			args["Status"] = domain.CommandStatusFailed
+		} else {
+			args["Status"] = domain.CommandStatusPending
+			args["NextAttemptAt"] = time.Now().Add(retryBackoff(attempts))
 		}
+
+		e.updateQueuedCommand(ctx, cmd.CommandID, args)
+		return
 	}
+
+	e.updateQueuedCommand(ctx, cmd.CommandID, domain.UpdateArgs{
+		"Status":     domain.CommandStatusSucceeded,
+		"Attempts":   attempts,
+		"Response":   res,
+		"StartedAt":  startedAt,
+		"FinishedAt": finishedAt,
+	})
+
+	e.logger.Info("Executed command on server",
+		zap.String("Command", cmd.Command),
+		zap.Int64("Server ID", cmd.ServerID))
+}
+
+func (e *executor) updateQueuedCommand(ctx context.Context, id int64, args domain.UpdateArgs) {
+	if _, err := e.queueRepo.Update(ctx, id, args); err != nil {
+		e.logger.Error("Could not update queued command", zap.Int64("Command ID", id), zap.Error(err))
+	}
+}
+
+// retryBackoff returns how long to wait before a command is attempted again. The delay doubles with every failed
+// attempt up to retryMaxDelay.
+func retryBackoff(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+
+	return delay
 }
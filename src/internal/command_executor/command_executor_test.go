@@ -25,10 +25,12 @@ import (
 	"github.com/franela/goblin"
 	"github.com/guregu/null"
 	. "github.com/onsi/gomega"
+	kratos "github.com/ory/kratos-client-go"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 	"testing"
+	"time"
 )
 
 // The command executor is one of the most heavily tested parts of Refractor. This is important because thee commands
@@ -46,6 +48,9 @@ func Test(t *testing.T) {
 		var gameService *mocks.GameService
 		var serverRepo *mocks.ServerRepo
 		var playerNameRepo *mocks.PlayerNameRepo
+		var queueRepo *mocks.CommandQueueRepo
+		var userRepo *mocks.UserMetaRepo
+		var ruleRepo *mocks.RuleRepo
 		var cmdexec *executor
 		var game *mocks.Game
 		var ctx context.Context
@@ -55,12 +60,19 @@ func Test(t *testing.T) {
 			gameService = new(mocks.GameService)
 			serverRepo = new(mocks.ServerRepo)
 			playerNameRepo = new(mocks.PlayerNameRepo)
+			queueRepo = new(mocks.CommandQueueRepo)
+			userRepo = new(mocks.UserMetaRepo)
+			ruleRepo = new(mocks.RuleRepo)
 			cmdexec = &executor{
 				rconService:    rconService,
 				gameService:    gameService,
 				serverRepo:     serverRepo,
+				userRepo:       userRepo,
 				playerNameRepo: playerNameRepo,
+				queueRepo:      queueRepo,
+				ruleRepo:       ruleRepo,
 				logger:         zap.NewNop(),
+				wake:           make(chan struct{}, 1),
 			}
 			game = new(mocks.Game)
 			ctx = context.TODO()
@@ -159,6 +171,74 @@ func Test(t *testing.T) {
 					}
 				})
 
+				g.It("Should not expand placeholders inside of the reason", func() {
+					infraction.Reason = null.StringFrom("{{DURATION}} {{.PlayerID}}")
+
+					payload, err := cmdexec.PrepareInfractionCommands(ctx, infraction, domain.InfractionCommandCreate, serverID)
+					Expect(err).To(BeNil())
+					Expect(payload.GetCommands()[0].GetCommand()).To(Equal("Ban Test Player Name 420 {{DURATION}} {{.PlayerID}}"))
+				})
+
+				g.It("Should fill in the rule title if the infraction references a rule", func() {
+					infraction.RuleID = null.IntFrom(3)
+					ruleRepo.On("GetByID", mock.Anything, int64(3)).Return(&domain.Rule{RuleID: 3, Title: "No racism"}, nil)
+					gameService.ExpectedCalls = nil
+					gameService.On("GetGame", "testgame").Return(game, nil)
+					gameService.On("GetGameSettings", mock.Anything).Return(&domain.GameSettings{
+						Commands: &domain.GameCommandSettings{
+							CreateInfractionCommands: &domain.InfractionCommands{
+								Ban: []*domain.InfractionCommand{
+									{
+										Command:  "Ban {{PLAYER_NAME}} {{RULE}}",
+										RunOnAll: true,
+									},
+								},
+							},
+						},
+					}, nil)
+
+					payload, err := cmdexec.PrepareInfractionCommands(ctx, infraction, domain.InfractionCommandCreate, serverID)
+					Expect(err).To(BeNil())
+					Expect(payload.GetCommands()[0].GetCommand()).To(Equal("Ban Test Player Name No racism"))
+				})
+
+				g.It("Should attribute the commands to the infraction", func() {
+					infraction.UserID = null.StringFrom("creator")
+					userRepo.On("GetUsername", mock.Anything, "creator").Return("Creator", nil)
+
+					payload, err := cmdexec.PrepareInfractionCommands(ctx, infraction, domain.InfractionCommandCreate, serverID)
+					Expect(err).To(BeNil())
+					for _, cmd := range payload.GetCommands() {
+						Expect(cmd.GetInfractionID()).To(Equal(infraction.InfractionID))
+						Expect(cmd.GetUserID()).To(Equal("creator"))
+					}
+				})
+
+				g.It("Should record the player, infraction type and action of the commands", func() {
+					payload, err := cmdexec.PrepareInfractionCommands(ctx, infraction, domain.InfractionCommandCreate, serverID)
+					Expect(err).To(BeNil())
+					for _, cmd := range payload.GetCommands() {
+						Expect(cmd.GetPlayerID()).To(Equal(infraction.PlayerID))
+						Expect(cmd.GetPlatform()).To(Equal(infraction.Platform))
+						Expect(cmd.GetInfractionType()).To(Equal(domain.InfractionTypeBan))
+						Expect(cmd.GetAction()).To(Equal(domain.InfractionCommandCreate))
+					}
+				})
+
+				g.It("Should attribute the commands to the user in context if one is set", func() {
+					infraction.UserID = null.StringFrom("creator")
+					userRepo.On("GetUsername", mock.Anything, "creator").Return("Creator", nil)
+					ctx = context.WithValue(ctx, "user", &domain.AuthUser{
+						Session: &kratos.Session{Identity: kratos.Identity{Id: "actor"}},
+					})
+
+					payload, err := cmdexec.PrepareInfractionCommands(ctx, infraction, domain.InfractionCommandCreate, serverID)
+					Expect(err).To(BeNil())
+					for _, cmd := range payload.GetCommands() {
+						Expect(cmd.GetUserID()).To(Equal("actor"))
+					}
+				})
+
 				g.Describe("Player was not set", func() {
 					g.BeforeEach(func() {
 						infraction.PlayerName = ""
@@ -268,5 +348,247 @@ func Test(t *testing.T) {
 				})
 			})
 		})
+
+		g.Describe("PreviewCommands()", func() {
+			g.BeforeEach(func() {
+				game.On("GetName").Return("testgame")
+				serverRepo.On("GetByGame", mock.Anything, "testgame").Return([]*domain.Server{
+					{ID: 1},
+					{ID: 2, Deactivated: true},
+					{ID: 3},
+				}, nil)
+			})
+
+			g.It("Should expand commands to the servers they would run on", func() {
+				previews, err := cmdexec.PreviewCommands(ctx, newInfractionCommandPayload([]domain.Command{
+					&infractionCommand{Command: "all", RunOnAll: true, ServerID: 1},
+					&infractionCommand{Command: "single", RunOnAll: false, ServerID: 3},
+				}, game))
+
+				Expect(err).To(BeNil())
+				Expect(previews).To(Equal([]*domain.CommandPreview{
+					{Command: "all", RunOnAll: true, ServerIDs: []int64{1, 3}},
+					{Command: "single", RunOnAll: false, ServerIDs: []int64{3}},
+				}))
+			})
+
+			g.It("Should not queue anything", func() {
+				_, err := cmdexec.PreviewCommands(ctx, newInfractionCommandPayload([]domain.Command{
+					&infractionCommand{Command: "all", RunOnAll: true, ServerID: 1},
+				}, game))
+
+				Expect(err).To(BeNil())
+				queueRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				Expect(len(cmdexec.wake)).To(Equal(0))
+			})
+		})
+
+		g.Describe("QueueCommands()", func() {
+			g.BeforeEach(func() {
+				game.On("GetName").Return("testgame")
+				serverRepo.On("GetByGame", mock.Anything, "testgame").Return([]*domain.Server{
+					{ID: 1},
+					{ID: 2, Deactivated: true},
+					{ID: 3},
+				}, nil)
+			})
+
+			g.Describe("Command should run on all servers", func() {
+				g.BeforeEach(func() {
+					queueRepo.On("Store", mock.Anything, mock.AnythingOfType("*domain.QueuedCommand")).Return(nil)
+				})
+
+				g.It("Should queue the command on every active server", func() {
+					err := cmdexec.QueueCommands(newInfractionCommandPayload([]domain.Command{
+						&infractionCommand{Command: "cmd", RunOnAll: true, ServerID: 1},
+					}, game))
+
+					Expect(err).To(BeNil())
+					queueRepo.AssertNumberOfCalls(t, "Store", 2)
+					queueRepo.AssertCalled(t, "Store", mock.Anything, mock.MatchedBy(func(c *domain.QueuedCommand) bool {
+						return c.ServerID == 1 && c.Command == "cmd" && c.Status == domain.CommandStatusPending
+					}))
+					queueRepo.AssertCalled(t, "Store", mock.Anything, mock.MatchedBy(func(c *domain.QueuedCommand) bool {
+						return c.ServerID == 3
+					}))
+				})
+
+				g.It("Should wake up the runner", func() {
+					err := cmdexec.QueueCommands(newInfractionCommandPayload([]domain.Command{
+						&infractionCommand{Command: "cmd", RunOnAll: true, ServerID: 1},
+					}, game))
+
+					Expect(err).To(BeNil())
+					Expect(len(cmdexec.wake)).To(Equal(1))
+				})
+			})
+
+			g.Describe("Command should run on a single server", func() {
+				g.BeforeEach(func() {
+					queueRepo.On("Store", mock.Anything, mock.AnythingOfType("*domain.QueuedCommand")).Return(nil)
+				})
+
+				g.It("Should record the originating infraction and user", func() {
+					err := cmdexec.QueueCommands(newInfractionCommandPayload([]domain.Command{
+						&infractionCommand{Command: "cmd", RunOnAll: false, ServerID: 3, InfractionID: 9, UserID: "userid"},
+					}, game))
+
+					Expect(err).To(BeNil())
+					queueRepo.AssertCalled(t, "Store", mock.Anything, mock.MatchedBy(func(c *domain.QueuedCommand) bool {
+						return c.InfractionID == null.IntFrom(9) && c.UserID == null.StringFrom("userid")
+					}))
+				})
+
+				g.It("Should record the player the command was prepared for", func() {
+					err := cmdexec.QueueCommands(newInfractionCommandPayload([]domain.Command{
+						&infractionCommand{Command: "cmd", ServerID: 3, PlayerID: "playerid", Platform: "playfab",
+							InfractionType: domain.InfractionTypeBan, Action: domain.InfractionCommandSync},
+					}, game))
+
+					Expect(err).To(BeNil())
+					queueRepo.AssertCalled(t, "Store", mock.Anything, mock.MatchedBy(func(c *domain.QueuedCommand) bool {
+						return c.PlayerID == null.StringFrom("playerid") && c.Platform == null.StringFrom("playfab") &&
+							c.InfractionType == null.StringFrom(domain.InfractionTypeBan) &&
+							c.Action == null.StringFrom(domain.InfractionCommandSync)
+					}))
+				})
+
+				g.It("Should only queue the command on the specified server", func() {
+					err := cmdexec.QueueCommands(newInfractionCommandPayload([]domain.Command{
+						&infractionCommand{Command: "cmd", RunOnAll: false, ServerID: 3},
+					}, game))
+
+					Expect(err).To(BeNil())
+					queueRepo.AssertNumberOfCalls(t, "Store", 1)
+					queueRepo.AssertCalled(t, "Store", mock.Anything, mock.MatchedBy(func(c *domain.QueuedCommand) bool {
+						return c.ServerID == 3 && c.MaxAttempts == maxCommandAttempts && c.ExpiresAt.Valid
+					}))
+				})
+			})
+
+			g.Describe("Queue repo error", func() {
+				g.BeforeEach(func() {
+					queueRepo.On("Store", mock.Anything, mock.Anything).Return(fmt.Errorf("err"))
+				})
+
+				g.It("Should return an error", func() {
+					err := cmdexec.QueueCommands(newInfractionCommandPayload([]domain.Command{
+						&infractionCommand{Command: "cmd", RunOnAll: false, ServerID: 3},
+					}, game))
+
+					Expect(err).ToNot(BeNil())
+				})
+			})
+		})
+
+		g.Describe("processQueue()", func() {
+			g.BeforeEach(func() {
+				queueRepo.On("ResetRunning", mock.Anything, mock.Anything).Return(int64(1), nil)
+				queueRepo.On("ExpireStale", mock.Anything).Return(int64(0), nil)
+				queueRepo.On("GetPendingServers", mock.Anything).Return([]int64{}, nil)
+			})
+
+			g.It("Should only reset commands claimed before the claim lease", func() {
+				cmdexec.processQueue()
+
+				queueRepo.AssertCalled(t, "ResetRunning", mock.Anything, mock.MatchedBy(func(claimedBefore time.Time) bool {
+					return time.Since(claimedBefore) >= commandClaimLease &&
+						time.Since(claimedBefore) < commandClaimLease+time.Second*5
+				}))
+			})
+		})
+
+		g.Describe("runQueuedCommand()", func() {
+			var rconClient *mocks.RCONClient
+			var queued *domain.QueuedCommand
+
+			g.BeforeEach(func() {
+				rconClient = new(mocks.RCONClient)
+				queued = &domain.QueuedCommand{
+					CommandID:   1,
+					ServerID:    2,
+					Command:     "cmd",
+					Status:      domain.CommandStatusRunning,
+					Attempts:    0,
+					MaxAttempts: 3,
+				}
+				queueRepo.On("Update", mock.Anything, int64(1), mock.Anything).Return(&domain.QueuedCommand{}, nil)
+			})
+
+			g.Describe("Command ran successfully", func() {
+				g.BeforeEach(func() {
+					rconService.On("GetServerClient", int64(2)).Return(rconClient)
+					rconClient.On("RunCommand", "cmd").Return("response", nil)
+				})
+
+				g.It("Should mark the command as succeeded", func() {
+					cmdexec.runQueuedCommand(ctx, queued)
+
+					queueRepo.AssertCalled(t, "Update", mock.Anything, int64(1), mock.MatchedBy(func(args domain.UpdateArgs) bool {
+						return args["Status"] == domain.CommandStatusSucceeded && args["Attempts"] == 1 &&
+							args["Response"] == "response" && args["StartedAt"] != nil && args["FinishedAt"] != nil
+					}))
+				})
+			})
+
+			g.Describe("Command failed with attempts remaining", func() {
+				g.BeforeEach(func() {
+					rconService.On("GetServerClient", int64(2)).Return(rconClient)
+					rconClient.On("RunCommand", "cmd").Return("", fmt.Errorf("err"))
+				})
+
+				g.It("Should return the command to the queue with a delay", func() {
+					cmdexec.runQueuedCommand(ctx, queued)
+
+					queueRepo.AssertCalled(t, "Update", mock.Anything, int64(1), mock.MatchedBy(func(args domain.UpdateArgs) bool {
+						return args["Status"] == domain.CommandStatusPending && args["Attempts"] == 1 &&
+							args["LastError"] == "err" && args["NextAttemptAt"] != nil
+					}))
+				})
+			})
+
+			g.Describe("Command failed on its last attempt", func() {
+				g.BeforeEach(func() {
+					queued.Attempts = 2
+					rconService.On("GetServerClient", int64(2)).Return(rconClient)
+					rconClient.On("RunCommand", "cmd").Return("", fmt.Errorf("err"))
+				})
+
+				g.It("Should mark the command as failed", func() {
+					cmdexec.runQueuedCommand(ctx, queued)
+
+					queueRepo.AssertCalled(t, "Update", mock.Anything, int64(1), mock.MatchedBy(func(args domain.UpdateArgs) bool {
+						return args["Status"] == domain.CommandStatusFailed && args["Attempts"] == 3 &&
+							args["LastError"] == "err"
+					}))
+				})
+			})
+
+			g.Describe("Server is offline", func() {
+				g.BeforeEach(func() {
+					rconService.On("GetServerClient", int64(2)).Return(nil)
+				})
+
+				g.It("Should return the command to the queue without counting an attempt", func() {
+					cmdexec.runQueuedCommand(ctx, queued)
+
+					queueRepo.AssertCalled(t, "Update", mock.Anything, int64(1), domain.UpdateArgs{
+						"Status": domain.CommandStatusPending,
+					})
+				})
+			})
+		})
+
+		g.Describe("retryBackoff()", func() {
+			g.It("Should double the delay on each attempt", func() {
+				Expect(retryBackoff(1)).To(Equal(retryBaseDelay))
+				Expect(retryBackoff(2)).To(Equal(retryBaseDelay * 2))
+				Expect(retryBackoff(3)).To(Equal(retryBaseDelay * 4))
+			})
+
+			g.It("Should not exceed the max delay", func() {
+				Expect(retryBackoff(100)).To(Equal(retryMaxDelay))
+			})
+		})
 	})
 }
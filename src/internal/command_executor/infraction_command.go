@@ -37,9 +37,15 @@ func (ic *infractionCommandPayload) GetGame() domain.Game {
 }
 
 type infractionCommand struct {
-	Command  string
-	RunOnAll bool
-	ServerID int64
+	Command        string
+	RunOnAll       bool
+	ServerID       int64
+	InfractionID   int64
+	UserID         string
+	PlayerID       string
+	Platform       string
+	InfractionType string
+	Action         string
 }
 
 func (i *infractionCommand) GetCommand() string {
@@ -53,3 +59,27 @@ func (i *infractionCommand) ShouldRunOnAll() bool {
 func (i *infractionCommand) GetServerID() int64 {
 	return i.ServerID
 }
+
+func (i *infractionCommand) GetInfractionID() int64 {
+	return i.InfractionID
+}
+
+func (i *infractionCommand) GetUserID() string {
+	return i.UserID
+}
+
+func (i *infractionCommand) GetPlayerID() string {
+	return i.PlayerID
+}
+
+func (i *infractionCommand) GetPlatform() string {
+	return i.Platform
+}
+
+func (i *infractionCommand) GetInfractionType() string {
+	return i.InfractionType
+}
+
+func (i *infractionCommand) GetAction() string {
+	return i.Action
+}
@@ -0,0 +1,305 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"Refractor/pkg/querybuilders/psqlqb"
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+const opTag = "CommandQueueRepo.Postgres."
+
+type commandQueueRepo struct {
+	db     *sql.DB
+	logger *zap.Logger
+	qb     domain.QueryBuilder
+}
+
+func NewCommandQueueRepo(db *sql.DB, logger *zap.Logger) domain.CommandQueueRepo {
+	return &commandQueueRepo{
+		db:     db,
+		logger: logger,
+		qb:     psqlqb.NewPostgresQueryBuilder(),
+	}
+}
+
+var returnFields = []string{
+	"CommandID", "ServerID", "InfractionID", "UserID", "Platform", "PlayerID", "InfractionType", "Action", "Command",
+	"Status", "Attempts", "MaxAttempts", "Response", "LastError", "NextAttemptAt", "ExpiresAt", "StartedAt",
+	"FinishedAt", "CreatedAt", "ModifiedAt",
+}
+
+func (r *commandQueueRepo) fetch(ctx context.Context, query string, args ...interface{}) ([]*domain.QueuedCommand, error) {
+	const op = opTag + "Fetch"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	// Clean up on function exit
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.QueuedCommand, 0)
+	for rows.Next() {
+		cmd := &domain.QueuedCommand{}
+
+		if err := r.scanRows(rows, cmd); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, errors.Wrap(domain.ErrNotFound, op)
+			}
+
+			return nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, cmd)
+	}
+
+	return results, nil
+}
+
+func (r *commandQueueRepo) Store(ctx context.Context, cmd *domain.QueuedCommand) error {
+	const op = opTag + "Store"
+
+	query := `INSERT INTO QueuedCommands (ServerID, InfractionID, UserID, Platform, PlayerID, InfractionType, Action,
+			Command, Status, Attempts, MaxAttempts, Response, LastError, NextAttemptAt, ExpiresAt, StartedAt, FinishedAt)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING CommandID, CreatedAt;`
+
+	stmt, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Could not prepare statement", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	row := stmt.QueryRowContext(ctx, cmd.ServerID, cmd.InfractionID, cmd.UserID, cmd.Platform, cmd.PlayerID,
+		cmd.InfractionType, cmd.Action, cmd.Command, cmd.Status, cmd.Attempts, cmd.MaxAttempts, cmd.Response,
+		cmd.LastError, cmd.NextAttemptAt, cmd.ExpiresAt, cmd.StartedAt, cmd.FinishedAt)
+
+	if err := row.Scan(&cmd.CommandID, &cmd.CreatedAt); err != nil {
+		r.logger.Error("Could not execute prepared statement", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *commandQueueRepo) Update(ctx context.Context, id int64, args domain.UpdateArgs) (*domain.QueuedCommand, error) {
+	const op = opTag + "Update"
+
+	query, values := r.qb.BuildUpdateQuery("QueuedCommands", id, "CommandID", args, returnFields)
+
+	stmt, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Could not prepare statement", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	row := stmt.QueryRowContext(ctx, values...)
+
+	updated := &domain.QueuedCommand{}
+	if err := r.scanRow(row, updated); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.Wrap(domain.ErrNotFound, op)
+		}
+
+		r.logger.Error("Could not scan updated queued command", zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return updated, nil
+}
+
+func (r *commandQueueRepo) GetPendingServers(ctx context.Context) ([]int64, error) {
+	const op = opTag + "GetPendingServers"
+
+	query := `SELECT DISTINCT ServerID FROM QueuedCommands
+		WHERE Status = 'pending' AND NextAttemptAt <= CURRENT_TIMESTAMP;`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	serverIDs := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+
+		serverIDs = append(serverIDs, id)
+	}
+
+	return serverIDs, nil
+}
+
+func (r *commandQueueRepo) ClaimPending(ctx context.Context, serverID int64, limit int) ([]*domain.QueuedCommand, error) {
+	const op = opTag + "ClaimPending"
+
+	// SKIP LOCKED makes sure that the same command can never be claimed twice, even if multiple runners are active. The
+	// claim sets ModifiedAt, which is what ResetRunning uses to find claims which have outlived their lease.
+	query := `
+		WITH claimed AS (
+			UPDATE QueuedCommands SET Status = 'running'
+			WHERE CommandID IN (
+				SELECT CommandID FROM QueuedCommands
+				WHERE ServerID = $1 AND Status = 'pending' AND NextAttemptAt <= CURRENT_TIMESTAMP
+				ORDER BY CommandID ASC
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING CommandID, ServerID, InfractionID, UserID, Platform, PlayerID, InfractionType, Action, Command,
+				Status, Attempts, MaxAttempts, Response, LastError, NextAttemptAt, ExpiresAt, StartedAt, FinishedAt,
+				CreatedAt, ModifiedAt
+		)
+		SELECT * FROM claimed ORDER BY CommandID ASC;`
+
+	results, err := r.fetch(ctx, query, serverID, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return results, nil
+}
+
+func (r *commandQueueRepo) ExpireStale(ctx context.Context) (int64, error) {
+	const op = opTag + "ExpireStale"
+
+	query := `UPDATE QueuedCommands SET Status = 'expired'
+		WHERE Status = 'pending' AND ExpiresAt IS NOT NULL AND ExpiresAt <= CURRENT_TIMESTAMP;`
+
+	res, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return 0, errors.Wrap(err, op)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Could not get affected rows", zap.Error(err))
+		return 0, errors.Wrap(err, op)
+	}
+
+	return rowsAffected, nil
+}
+
+func (r *commandQueueRepo) ResetRunning(ctx context.Context, claimedBefore time.Time) (int64, error) {
+	const op = opTag + "ResetRunning"
+
+	// Running commands are not updated again until they finish, so ModifiedAt holds the time they were claimed
+	query := `UPDATE QueuedCommands SET Status = 'pending'
+		WHERE Status = 'running' AND (ModifiedAt IS NULL OR ModifiedAt < $1);`
+
+	res, err := r.db.ExecContext(ctx, query, claimedBefore)
+	if err != nil {
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return 0, errors.Wrap(err, op)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Could not get affected rows", zap.Error(err))
+		return 0, errors.Wrap(err, op)
+	}
+
+	return rowsAffected, nil
+}
+
+func (r *commandQueueRepo) GetByServer(ctx context.Context, serverID int64, limit, offset int) (int, []*domain.QueuedCommand, error) {
+	const op = opTag + "GetByServer"
+
+	query := `
+		SELECT CommandID, ServerID, InfractionID, UserID, Platform, PlayerID, InfractionType, Action, Command, Status,
+			Attempts, MaxAttempts, Response, LastError, NextAttemptAt, ExpiresAt, StartedAt, FinishedAt, CreatedAt,
+			ModifiedAt
+		FROM QueuedCommands
+		WHERE ServerID = $1
+		ORDER BY CommandID DESC
+		LIMIT $2 OFFSET $3;`
+
+	results, err := r.fetch(ctx, query, serverID, limit, offset)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, op)
+	}
+
+	// Get total number of commands for this server
+	query = "SELECT COUNT(1) AS Count FROM QueuedCommands WHERE ServerID = $1;"
+
+	row := r.db.QueryRowContext(ctx, query, serverID)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		r.logger.Error("Could not scan command count", zap.Error(err))
+		return 0, nil, errors.Wrap(err, op)
+	}
+
+	return count, results, nil
+}
+
+func (r *commandQueueRepo) HasRecentPlayerCommand(ctx context.Context, serverID int64, platform, playerID,
+	infractionType string, actions []string, since time.Time) (bool, error) {
+	const op = opTag + "HasRecentPlayerCommand"
+
+	query := `SELECT EXISTS(
+			SELECT 1 FROM QueuedCommands
+			WHERE ServerID = $1 AND Platform = $2 AND PlayerID = $3 AND InfractionType = $4 AND Action = ANY($5)
+				AND (Status = 'running' OR FinishedAt >= $6)
+		);`
+
+	var exists bool
+	row := r.db.QueryRowContext(ctx, query, serverID, platform, playerID, infractionType, pq.Array(actions), since)
+	if err := row.Scan(&exists); err != nil {
+		r.logger.Error("Could not scan recent player command check", zap.Error(err))
+		return false, errors.Wrap(err, op)
+	}
+
+	return exists, nil
+}
+
+// Scan helpers
+func (r *commandQueueRepo) scanRow(row *sql.Row, cmd *domain.QueuedCommand) error {
+	return row.Scan(&cmd.CommandID, &cmd.ServerID, &cmd.InfractionID, &cmd.UserID, &cmd.Platform, &cmd.PlayerID,
+		&cmd.InfractionType, &cmd.Action, &cmd.Command, &cmd.Status, &cmd.Attempts, &cmd.MaxAttempts, &cmd.Response,
+		&cmd.LastError, &cmd.NextAttemptAt, &cmd.ExpiresAt, &cmd.StartedAt, &cmd.FinishedAt, &cmd.CreatedAt,
+		&cmd.ModifiedAt)
+}
+
+func (r *commandQueueRepo) scanRows(rows *sql.Rows, cmd *domain.QueuedCommand) error {
+	return rows.Scan(&cmd.CommandID, &cmd.ServerID, &cmd.InfractionID, &cmd.UserID, &cmd.Platform, &cmd.PlayerID,
+		&cmd.InfractionType, &cmd.Action, &cmd.Command, &cmd.Status, &cmd.Attempts, &cmd.MaxAttempts, &cmd.Response,
+		&cmd.LastError, &cmd.NextAttemptAt, &cmd.ExpiresAt, &cmd.StartedAt, &cmd.FinishedAt, &cmd.CreatedAt,
+		&cmd.ModifiedAt)
+}
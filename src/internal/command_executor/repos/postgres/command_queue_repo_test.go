@@ -0,0 +1,357 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/franela/goblin"
+	"github.com/guregu/null"
+	"github.com/lib/pq"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var cols = []string{"CommandID", "ServerID", "InfractionID", "UserID", "Platform", "PlayerID", "InfractionType",
+		"Action", "Command", "Status", "Attempts", "MaxAttempts", "Response", "LastError", "NextAttemptAt", "ExpiresAt",
+		"StartedAt", "FinishedAt", "CreatedAt", "ModifiedAt"}
+
+	g.Describe("Postgres Command Queue Repo", func() {
+		var repo domain.CommandQueueRepo
+		var mock sqlmock.Sqlmock
+		var db *sql.DB
+		var ctx context.Context
+
+		g.BeforeEach(func() {
+			var err error
+
+			db, mock, err = sqlmock.New()
+			if err != nil {
+				t.Fatalf("Could not create new sqlmock instance. Error: %v", err)
+			}
+
+			repo = NewCommandQueueRepo(db, zap.NewNop())
+			ctx = context.TODO()
+		})
+
+		g.AfterEach(func() {
+			_ = db.Close()
+		})
+
+		g.Describe("Store()", func() {
+			g.Describe("Successful store", func() {
+				var cmd *domain.QueuedCommand
+				var createdAt time.Time
+
+				g.BeforeEach(func() {
+					createdAt = time.Now()
+					cmd = &domain.QueuedCommand{
+						ServerID:      1,
+						Command:       "ban player",
+						Status:        domain.CommandStatusPending,
+						MaxAttempts:   5,
+						NextAttemptAt: time.Now(),
+						ExpiresAt:     null.TimeFrom(time.Now().Add(time.Hour)),
+					}
+
+					mock.ExpectPrepare("INSERT INTO QueuedCommands")
+					mock.ExpectQuery("INSERT INTO QueuedCommands").WillReturnRows(
+						sqlmock.NewRows([]string{"CommandID", "CreatedAt"}).AddRow(int64(12), createdAt))
+				})
+
+				g.It("Should not return an error", func() {
+					err := repo.Store(ctx, cmd)
+
+					Expect(err).To(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+
+				g.It("Should update the passed in command to have the new ID", func() {
+					err := repo.Store(ctx, cmd)
+
+					Expect(err).To(BeNil())
+					Expect(cmd.CommandID).To(Equal(int64(12)))
+					Expect(cmd.CreatedAt).To(Equal(createdAt))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Database error", func() {
+				g.BeforeEach(func() {
+					mock.ExpectPrepare("INSERT INTO QueuedCommands")
+					mock.ExpectQuery("INSERT INTO QueuedCommands").WillReturnError(fmt.Errorf("err"))
+				})
+
+				g.It("Should return an error", func() {
+					err := repo.Store(ctx, &domain.QueuedCommand{})
+
+					Expect(err).ToNot(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("Update()", func() {
+			g.Describe("Successful update", func() {
+				g.BeforeEach(func() {
+					mock.ExpectPrepare("UPDATE QueuedCommands")
+					mock.ExpectQuery("UPDATE QueuedCommands").WillReturnRows(sqlmock.NewRows(cols).
+						AddRow(1, 2, 6, "userid", "playfab", "playerid", domain.InfractionTypeBan, domain.InfractionCommandCreate,
+							"cmd", domain.CommandStatusSucceeded, 1, 5, "ok", nil, time.Now(), nil, time.Now(), time.Now(),
+							time.Now(), time.Now()))
+				})
+
+				g.It("Should return the updated command", func() {
+					updated, err := repo.Update(ctx, 1, domain.UpdateArgs{"Status": domain.CommandStatusSucceeded})
+
+					Expect(err).To(BeNil())
+					Expect(updated.CommandID).To(Equal(int64(1)))
+					Expect(updated.Status).To(Equal(domain.CommandStatusSucceeded))
+					Expect(updated.Attempts).To(Equal(1))
+					Expect(updated.InfractionID).To(Equal(null.IntFrom(6)))
+					Expect(updated.Response).To(Equal(null.StringFrom("ok")))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Command not found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectPrepare("UPDATE QueuedCommands")
+					mock.ExpectQuery("UPDATE QueuedCommands").WillReturnError(sql.ErrNoRows)
+				})
+
+				g.It("Should return domain.ErrNotFound", func() {
+					_, err := repo.Update(ctx, 1, domain.UpdateArgs{"Status": domain.CommandStatusSucceeded})
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("GetPendingServers()", func() {
+			g.Describe("Servers found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("SELECT DISTINCT ServerID FROM QueuedCommands").WillReturnRows(
+						sqlmock.NewRows([]string{"ServerID"}).AddRow(1).AddRow(4))
+				})
+
+				g.It("Should return the server IDs", func() {
+					ids, err := repo.GetPendingServers(ctx)
+
+					Expect(err).To(BeNil())
+					Expect(ids).To(Equal([]int64{1, 4}))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Database error", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("SELECT DISTINCT ServerID FROM QueuedCommands").WillReturnError(fmt.Errorf("err"))
+				})
+
+				g.It("Should return an error", func() {
+					_, err := repo.GetPendingServers(ctx)
+
+					Expect(err).ToNot(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("ClaimPending()", func() {
+			g.Describe("Commands claimed", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("UPDATE QueuedCommands SET Status = 'running'").WithArgs(int64(3), 10).
+						WillReturnRows(sqlmock.NewRows(cols).
+							AddRow(1, 3, nil, nil, nil, nil, nil, nil, "cmd1", domain.CommandStatusRunning, 0, 5, nil, nil,
+								time.Now(), nil, nil, nil, time.Now(), nil).
+							AddRow(2, 3, 8, nil, "playfab", "playerid", domain.InfractionTypeMute, domain.InfractionCommandSync,
+								"cmd2", domain.CommandStatusRunning, 2, 5, nil, "err", time.Now(), nil, time.Now(), time.Now(),
+								time.Now(), time.Now()))
+				})
+
+				g.It("Should return the claimed commands", func() {
+					cmds, err := repo.ClaimPending(ctx, 3, 10)
+
+					Expect(err).To(BeNil())
+					Expect(len(cmds)).To(Equal(2))
+					Expect(cmds[0].Command).To(Equal("cmd1"))
+					Expect(cmds[1].LastError).To(Equal(null.StringFrom("err")))
+					Expect(cmds[1].PlayerID).To(Equal(null.StringFrom("playerid")))
+					Expect(cmds[1].Action).To(Equal(null.StringFrom(domain.InfractionCommandSync)))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Database error", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("UPDATE QueuedCommands").WillReturnError(fmt.Errorf("err"))
+				})
+
+				g.It("Should return an error", func() {
+					_, err := repo.ClaimPending(ctx, 3, 10)
+
+					Expect(err).ToNot(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("GetByServer()", func() {
+			g.Describe("Commands found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("SELECT (.+) FROM QueuedCommands").WithArgs(int64(3), 10, 20).
+						WillReturnRows(sqlmock.NewRows(cols).
+							AddRow(5, 3, 1, "userid", "playfab", "playerid", domain.InfractionTypeBan,
+								domain.InfractionCommandCreate, "cmd5", domain.CommandStatusSucceeded, 1, 5, "ok", nil,
+								time.Now(), nil, time.Now(), time.Now(), time.Now(), time.Now()).
+							AddRow(4, 3, nil, nil, nil, nil, nil, nil, "cmd4", domain.CommandStatusFailed, 5, 5, nil, "err",
+								time.Now(), nil, time.Now(), time.Now(), time.Now(), time.Now()))
+					mock.ExpectQuery("SELECT COUNT").WithArgs(int64(3)).WillReturnRows(
+						sqlmock.NewRows([]string{"Count"}).AddRow(32))
+				})
+
+				g.It("Should return the total and the page of commands", func() {
+					total, cmds, err := repo.GetByServer(ctx, 3, 10, 20)
+
+					Expect(err).To(BeNil())
+					Expect(total).To(Equal(32))
+					Expect(len(cmds)).To(Equal(2))
+					Expect(cmds[0].CommandID).To(Equal(int64(5)))
+					Expect(cmds[1].LastError).To(Equal(null.StringFrom("err")))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Database error", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("SELECT (.+) FROM QueuedCommands").WillReturnError(fmt.Errorf("err"))
+				})
+
+				g.It("Should return an error", func() {
+					_, _, err := repo.GetByServer(ctx, 3, 10, 0)
+
+					Expect(err).ToNot(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("HasRecentPlayerCommand()", func() {
+			var since time.Time
+			var actions []string
+
+			g.BeforeEach(func() {
+				since = time.Now().Add(-time.Minute)
+				actions = []string{domain.InfractionCommandCreate, domain.InfractionCommandSync}
+			})
+
+			g.Describe("Command found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("SELECT EXISTS").
+						WithArgs(int64(3), "playfab", "playerid", domain.InfractionTypeBan, pq.Array(actions), since).
+						WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				})
+
+				g.It("Should return true", func() {
+					found, err := repo.HasRecentPlayerCommand(ctx, 3, "playfab", "playerid", domain.InfractionTypeBan,
+						actions, since)
+
+					Expect(err).To(BeNil())
+					Expect(found).To(BeTrue())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("No command found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("SELECT EXISTS").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				})
+
+				g.It("Should return false", func() {
+					found, err := repo.HasRecentPlayerCommand(ctx, 3, "playfab", "playerid", domain.InfractionTypeBan,
+						actions, since)
+
+					Expect(err).To(BeNil())
+					Expect(found).To(BeFalse())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Database error", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("SELECT EXISTS").WillReturnError(fmt.Errorf("err"))
+				})
+
+				g.It("Should return an error", func() {
+					_, err := repo.HasRecentPlayerCommand(ctx, 3, "playfab", "playerid", domain.InfractionTypeBan,
+						actions, since)
+
+					Expect(err).ToNot(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("ExpireStale()", func() {
+			g.BeforeEach(func() {
+				mock.ExpectExec("UPDATE QueuedCommands SET Status = 'expired'").WillReturnResult(sqlmock.NewResult(0, 3))
+			})
+
+			g.It("Should return the number of expired commands", func() {
+				count, err := repo.ExpireStale(ctx)
+
+				Expect(err).To(BeNil())
+				Expect(count).To(Equal(int64(3)))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("ResetRunning()", func() {
+			var claimedBefore time.Time
+
+			g.BeforeEach(func() {
+				claimedBefore = time.Now().Add(-time.Minute)
+
+				mock.ExpectExec("UPDATE QueuedCommands SET Status = 'pending'").WithArgs(claimedBefore).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			})
+
+			g.It("Should return the number of reset commands", func() {
+				count, err := repo.ResetRunning(ctx, claimedBefore)
+
+				Expect(err).To(BeNil())
+				Expect(count).To(Equal(int64(2)))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+	})
+}
@@ -0,0 +1,158 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package http
+
+import (
+	"Refractor/authcheckers"
+	"Refractor/domain"
+	"Refractor/params"
+	"Refractor/pkg/api"
+	"Refractor/pkg/api/middleware"
+	"Refractor/pkg/perms"
+	"context"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type consoleHandler struct {
+	service domain.ConsoleService
+	logger  *zap.Logger
+}
+
+func ApplyConsoleHandler(apiGroup *echo.Group, s domain.ConsoleService, a domain.Authorizer, mware domain.Middleware, log *zap.Logger) {
+	handler := &consoleHandler{
+		service: s,
+		logger:  log,
+	}
+
+	// Create the console routing group
+	consoleGroup := apiGroup.Group("/console", mware.ProtectMiddleware, mware.ActivationMiddleware)
+
+	// Create an enforcer to authorize the user on the various endpoints
+	rEnforcer := middleware.NewEnforcer(a, domain.AuthScope{
+		Type: domain.AuthObjRefractor,
+	}, log)
+
+	sEnforcer := middleware.NewEnforcer(a, domain.AuthScope{
+		Type:        domain.AuthObjServer,
+		IDFieldName: "serverId",
+	}, log)
+
+	consoleGroup.POST("/:serverId", handler.RunCommand,
+		sEnforcer.CheckAuth(authcheckers.HasPermission(perms.FlagUseRCONConsole, true)))
+	consoleGroup.GET("/rules/:groupId", handler.GetGroupRule, rEnforcer.CheckAuth(authcheckers.RequireAdmin))
+	consoleGroup.PUT("/rules/:groupId", handler.SetGroupRule, rEnforcer.CheckAuth(authcheckers.RequireAdmin))
+}
+
+func (h *consoleHandler) RunCommand(c echo.Context) error {
+	serverIDString := c.Param("serverId")
+
+	serverID, err := strconv.ParseInt(serverIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid server id"), http.StatusBadRequest, "")
+	}
+
+	// Validate request body
+	var body params.RunConsoleCommandParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	result, err := h.service.RunCommand(ctx, serverID, body.Command)
+	if err != nil {
+		return err
+	}
+
+	message := "Command executed"
+	if result.Status != domain.CommandStatusSucceeded {
+		message = "Command failed"
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: result.Status == domain.CommandStatusSucceeded,
+		Message: message,
+		Payload: result,
+	})
+}
+
+func (h *consoleHandler) GetGroupRule(c echo.Context) error {
+	groupIDString := c.Param("groupId")
+
+	groupID, err := strconv.ParseInt(groupIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid group id"), http.StatusBadRequest, "")
+	}
+
+	rule, err := h.service.GetGroupRule(c.Request().Context(), groupID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: rule,
+	})
+}
+
+func (h *consoleHandler) SetGroupRule(c echo.Context) error {
+	groupIDString := c.Param("groupId")
+
+	groupID, err := strconv.ParseInt(groupIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid group id"), http.StatusBadRequest, "")
+	}
+
+	// Validate request body
+	var body params.SetConsoleRuleParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	rule := &domain.ConsoleRule{
+		GroupID:       groupID,
+		AllowPrefixes: body.AllowPrefixes,
+		DenyPrefixes:  body.DenyPrefixes,
+	}
+
+	if err := h.service.SetGroupRule(c.Request().Context(), rule); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Console rule set",
+		Payload: rule,
+	})
+}
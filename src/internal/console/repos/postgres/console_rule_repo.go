@@ -0,0 +1,112 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const opTag = "ConsoleRuleRepo.Postgres."
+
+type consoleRuleRepo struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewConsoleRuleRepo(db *sql.DB, logger *zap.Logger) domain.ConsoleRuleRepo {
+	return &consoleRuleRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *consoleRuleRepo) fetch(ctx context.Context, query string, args ...interface{}) ([]*domain.ConsoleRule, error) {
+	const op = opTag + "Fetch"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	// Clean up on function exit
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.ConsoleRule, 0)
+	for rows.Next() {
+		rule := &domain.ConsoleRule{}
+
+		if err := r.scanRows(rows, rule); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, errors.Wrap(domain.ErrNotFound, op)
+			}
+
+			return nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, rule)
+	}
+
+	return results, nil
+}
+
+func (r *consoleRuleRepo) GetByGroups(ctx context.Context, groupIDs []int64) ([]*domain.ConsoleRule, error) {
+	const op = opTag + "GetByGroups"
+
+	query := "SELECT GroupID, AllowPrefixes, DenyPrefixes FROM ConsoleRules WHERE GroupID = ANY($1);"
+
+	results, err := r.fetch(ctx, query, pq.Array(groupIDs))
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if len(results) > 0 {
+		return results, nil
+	}
+
+	return nil, errors.Wrap(domain.ErrNotFound, op)
+}
+
+func (r *consoleRuleRepo) Set(ctx context.Context, rule *domain.ConsoleRule) error {
+	const op = opTag + "Set"
+
+	query := `INSERT INTO ConsoleRules (GroupID, AllowPrefixes, DenyPrefixes) VALUES ($1, $2, $3)
+				ON CONFLICT (GroupID) DO UPDATE SET AllowPrefixes = $2, DenyPrefixes = $3;`
+
+	if _, err := r.db.ExecContext(ctx, query, rule.GroupID, pq.Array(rule.AllowPrefixes), pq.Array(rule.DenyPrefixes)); err != nil {
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// Scan helpers
+func (r *consoleRuleRepo) scanRows(rows *sql.Rows, rule *domain.ConsoleRule) error {
+	return rows.Scan(&rule.GroupID, pq.Array(&rule.AllowPrefixes), pq.Array(&rule.DenyPrefixes))
+}
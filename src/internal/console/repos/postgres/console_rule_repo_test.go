@@ -0,0 +1,135 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/franela/goblin"
+	"github.com/lib/pq"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"regexp"
+	"testing"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var cols = []string{"GroupID", "AllowPrefixes", "DenyPrefixes"}
+
+	g.Describe("Postgres Console Rule Repo", func() {
+		var repo domain.ConsoleRuleRepo
+		var mock sqlmock.Sqlmock
+		var db *sql.DB
+		var ctx context.Context
+
+		g.BeforeEach(func() {
+			var err error
+
+			db, mock, err = sqlmock.New()
+			if err != nil {
+				t.Fatalf("Could not create new sqlmock instance. Error: %v", err)
+			}
+
+			repo = NewConsoleRuleRepo(db, zap.NewNop())
+			ctx = context.TODO()
+		})
+
+		g.AfterEach(func() {
+			_ = db.Close()
+		})
+
+		g.Describe("GetByGroups()", func() {
+			g.Describe("Rules found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT GroupID, AllowPrefixes, DenyPrefixes FROM ConsoleRules")).
+						WillReturnRows(sqlmock.NewRows(cols).
+							AddRow(1, "{say,kick}", "{}").
+							AddRow(2, "{}", "{ban}"))
+				})
+
+				g.It("Should return the rules", func() {
+					rules, err := repo.GetByGroups(ctx, []int64{1, 2})
+
+					Expect(err).To(BeNil())
+					Expect(rules).To(Equal([]*domain.ConsoleRule{
+						{GroupID: 1, AllowPrefixes: []string{"say", "kick"}, DenyPrefixes: []string{}},
+						{GroupID: 2, AllowPrefixes: []string{}, DenyPrefixes: []string{"ban"}},
+					}))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("No rules found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT GroupID, AllowPrefixes, DenyPrefixes FROM ConsoleRules")).
+						WillReturnRows(sqlmock.NewRows(cols))
+				})
+
+				g.It("Should return domain.ErrNotFound", func() {
+					_, err := repo.GetByGroups(ctx, []int64{1})
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("Set()", func() {
+			g.Describe("Successful set", func() {
+				g.BeforeEach(func() {
+					mock.ExpectExec("INSERT INTO ConsoleRules").
+						WithArgs(int64(1), pq.Array([]string{"say"}), pq.Array([]string{"ban"})).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				})
+
+				g.It("Should not return an error", func() {
+					err := repo.Set(ctx, &domain.ConsoleRule{
+						GroupID:       1,
+						AllowPrefixes: []string{"say"},
+						DenyPrefixes:  []string{"ban"},
+					})
+
+					Expect(err).To(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Database error", func() {
+				g.BeforeEach(func() {
+					mock.ExpectExec("INSERT INTO ConsoleRules").WillReturnError(fmt.Errorf("err"))
+				})
+
+				g.It("Should return an error", func() {
+					err := repo.Set(ctx, &domain.ConsoleRule{GroupID: 1})
+
+					Expect(err).ToNot(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,313 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/authcheckers"
+	"Refractor/domain"
+	"Refractor/pkg/perms"
+	"context"
+	"github.com/guregu/null"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type consoleService struct {
+	rconService      domain.RCONService
+	serverRepo       domain.ServerRepo
+	groupRepo        domain.GroupRepo
+	ruleRepo         domain.ConsoleRuleRepo
+	commandQueueRepo domain.CommandQueueRepo
+	websocketService domain.WebsocketService
+	authorizer       domain.Authorizer
+	timeout          time.Duration
+	logger           *zap.Logger
+}
+
+func NewConsoleService(rs domain.RCONService, sr domain.ServerRepo, gr domain.GroupRepo, crr domain.ConsoleRuleRepo,
+	cqr domain.CommandQueueRepo, wss domain.WebsocketService, a domain.Authorizer, to time.Duration,
+	log *zap.Logger) domain.ConsoleService {
+	return &consoleService{
+		rconService:      rs,
+		serverRepo:       sr,
+		groupRepo:        gr,
+		ruleRepo:         crr,
+		commandQueueRepo: cqr,
+		websocketService: wss,
+		authorizer:       a,
+		timeout:          to,
+		logger:           log,
+	}
+}
+
+// RunCommand runs a command on a server. If a user is set in the passed in context with the key of "user" then the
+// user's authorization to use the console on the server and their group console rules are checked. Otherwise, this is
+// assumed to be a system call and authorization is skipped.
+func (s *consoleService) RunCommand(c context.Context, serverID int64, command string) (*domain.QueuedCommand, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	var userID string
+	if user, ok := ctx.Value("user").(*domain.AuthUser); ok {
+		userID = user.Identity.Id
+	}
+
+	return s.runCommand(ctx, userID, serverID, command)
+}
+
+func (s *consoleService) runCommand(ctx context.Context, userID string, serverID int64, command string) (*domain.QueuedCommand, error) {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return nil, &domain.HTTPError{
+			Success:          false,
+			Message:          "Input errors exist",
+			ValidationErrors: map[string]string{"command": "cannot be blank"},
+			Status:           http.StatusBadRequest,
+		}
+	}
+
+	if userID != "" {
+		if err := s.checkAuthorization(ctx, userID, serverID, command); err != nil {
+			return nil, err
+		}
+	}
+
+	// Make sure the server exists
+	if _, err := s.serverRepo.GetByID(ctx, serverID); err != nil {
+		return nil, err
+	}
+
+	client := s.rconService.GetServerClient(serverID)
+	if client == nil {
+		return nil, domain.NewHTTPError(nil, http.StatusBadRequest, "The server is offline")
+	}
+
+	startedAt := time.Now()
+	res, err := client.RunCommand(command)
+	finishedAt := time.Now()
+
+	record := &domain.QueuedCommand{
+		ServerID:      serverID,
+		UserID:        null.NewString(userID, userID != ""),
+		Command:       command,
+		Status:        domain.CommandStatusSucceeded,
+		Attempts:      1,
+		MaxAttempts:   1,
+		Response:      null.StringFrom(res),
+		NextAttemptAt: startedAt,
+		StartedAt:     null.TimeFrom(startedAt),
+		FinishedAt:    null.TimeFrom(finishedAt),
+	}
+
+	if err != nil {
+		s.logger.Warn("Console command failed",
+			zap.Int64("Server ID", serverID),
+			zap.String("Command", command),
+			zap.String("User ID", userID),
+			zap.Error(err))
+
+		record.Status = domain.CommandStatusFailed
+		record.Response = null.String{}
+		record.LastError = null.StringFrom(err.Error())
+	}
+
+	// Record the command in the server's command history
+	if err := s.commandQueueRepo.Store(ctx, record); err != nil {
+		s.logger.Error("Could not record console command", zap.Int64("Server ID", serverID), zap.Error(err))
+	}
+
+	s.logger.Info("Console command executed",
+		zap.Int64("Server ID", serverID),
+		zap.String("Command", command),
+		zap.String("User ID", userID),
+		zap.String("Status", record.Status))
+
+	return record, nil
+}
+
+func (s *consoleService) checkAuthorization(ctx context.Context, userID string, serverID int64, command string) error {
+	scope := domain.AuthScope{
+		Type: domain.AuthObjServer,
+		ID:   serverID,
+	}
+
+	hasPermission, err := s.authorizer.HasPermission(ctx, scope, userID,
+		authcheckers.HasPermission(perms.FlagUseRCONConsole, true))
+	if err != nil {
+		return err
+	}
+
+	if !hasPermission {
+		return domain.NewHTTPError(nil, http.StatusUnauthorized,
+			"You do not have permission to use the console on this server.")
+	}
+
+	// Admins are not restricted by console rules
+	isAdmin, err := s.authorizer.HasPermission(ctx, scope, userID, authcheckers.RequireAdmin)
+	if err != nil {
+		return err
+	}
+
+	if isAdmin {
+		return nil
+	}
+
+	rules, err := s.getUserRules(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !domain.ConsoleRuleSlice(rules).Allows(command) {
+		return domain.NewHTTPError(nil, http.StatusUnauthorized,
+			"You are not allowed to run this command.")
+	}
+
+	return nil
+}
+
+// getUserRules gets the console rules of all groups the user is a member of, including the base group.
+func (s *consoleService) getUserRules(ctx context.Context, userID string) ([]*domain.ConsoleRule, error) {
+	groups, err := s.groupRepo.GetUserGroups(ctx, userID)
+	if err != nil && errors.Cause(err) != domain.ErrNotFound {
+		return nil, err
+	}
+
+	baseGroup, err := s.groupRepo.GetBaseGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	groupIDs := []int64{baseGroup.ID}
+	for _, group := range groups {
+		groupIDs = append(groupIDs, group.ID)
+	}
+
+	rules, err := s.ruleRepo.GetByGroups(ctx, groupIDs)
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return []*domain.ConsoleRule{}, nil
+		}
+
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (s *consoleService) GetGroupRule(c context.Context, groupID int64) (*domain.ConsoleRule, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	rules, err := s.ruleRepo.GetByGroups(ctx, []int64{groupID})
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			// Groups without a rule are unrestricted
+			return &domain.ConsoleRule{
+				GroupID:       groupID,
+				AllowPrefixes: []string{},
+				DenyPrefixes:  []string{},
+			}, nil
+		}
+
+		return nil, err
+	}
+
+	return rules[0], nil
+}
+
+func (s *consoleService) SetGroupRule(c context.Context, rule *domain.ConsoleRule) error {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	// Make sure the group exists. The base group is not stored alongside the other groups so it's checked separately.
+	baseGroup, err := s.groupRepo.GetBaseGroup(ctx)
+	if err != nil {
+		return err
+	}
+
+	if rule.GroupID != baseGroup.ID {
+		if _, err := s.groupRepo.GetByID(ctx, rule.GroupID); err != nil {
+			return err
+		}
+	}
+
+	rule.AllowPrefixes = cleanPrefixes(rule.AllowPrefixes)
+	rule.DenyPrefixes = cleanPrefixes(rule.DenyPrefixes)
+
+	return s.ruleRepo.Set(ctx, rule)
+}
+
+func cleanPrefixes(prefixes []string) []string {
+	cleaned := make([]string, 0, len(prefixes))
+
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSpace(prefix)
+		if prefix != "" {
+			cleaned = append(cleaned, prefix)
+		}
+	}
+
+	return cleaned
+}
+
+type consoleResponseBody struct {
+	ServerID int64  `json:"server_id"`
+	Command  string `json:"command"`
+	Success  bool   `json:"success"`
+	Response string `json:"response"`
+	Error    string `json:"error,omitempty"`
+}
+
+// HandleConsoleCommand runs a console command sent over a websocket connection and sends the result back to the user
+// who sent it as a console-response message.
+func (s *consoleService) HandleConsoleCommand(body *domain.ConsoleCommandBody) {
+	ctx, cancel := context.WithTimeout(context.TODO(), s.timeout)
+	defer cancel()
+
+	resBody := &consoleResponseBody{
+		ServerID: body.ServerID,
+		Command:  body.Command,
+	}
+
+	record, err := s.runCommand(ctx, body.UserID, body.ServerID, body.Command)
+	if err != nil {
+		if httpErr, ok := err.(*domain.HTTPError); ok {
+			resBody.Error = httpErr.Message
+		} else if errors.Cause(err) == domain.ErrNotFound {
+			resBody.Error = "Server not found"
+		} else {
+			s.logger.Error("Could not run console command",
+				zap.Int64("Server ID", body.ServerID),
+				zap.String("User ID", body.UserID),
+				zap.Error(err))
+			resBody.Error = "Could not run command"
+		}
+	} else {
+		resBody.Success = record.Status == domain.CommandStatusSucceeded
+		resBody.Response = record.Response.ValueOrZero()
+		resBody.Error = record.LastError.ValueOrZero()
+	}
+
+	s.websocketService.SendDirectMessage(&domain.WebsocketMessage{
+		Type: "console-response",
+		Body: resBody,
+	}, body.UserID)
+}
@@ -0,0 +1,324 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/domain"
+	"Refractor/domain/mocks"
+	"context"
+	"fmt"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	kratos "github.com/ory/kratos-client-go"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Console Service", func() {
+		var rconService *mocks.RCONService
+		var serverRepo *mocks.ServerRepo
+		var groupRepo *mocks.GroupRepo
+		var ruleRepo *mocks.ConsoleRuleRepo
+		var commandQueueRepo *mocks.CommandQueueRepo
+		var websocketService *mocks.WebsocketService
+		var authorizer *mocks.Authorizer
+		var rconClient *mocks.RCONClient
+		var service *consoleService
+		var ctx context.Context
+
+		g.BeforeEach(func() {
+			rconService = new(mocks.RCONService)
+			serverRepo = new(mocks.ServerRepo)
+			groupRepo = new(mocks.GroupRepo)
+			ruleRepo = new(mocks.ConsoleRuleRepo)
+			commandQueueRepo = new(mocks.CommandQueueRepo)
+			websocketService = new(mocks.WebsocketService)
+			authorizer = new(mocks.Authorizer)
+			rconClient = new(mocks.RCONClient)
+
+			service = &consoleService{
+				rconService:      rconService,
+				serverRepo:       serverRepo,
+				groupRepo:        groupRepo,
+				ruleRepo:         ruleRepo,
+				commandQueueRepo: commandQueueRepo,
+				websocketService: websocketService,
+				authorizer:       authorizer,
+				timeout:          time.Second * 2,
+				logger:           zap.NewNop(),
+			}
+
+			ctx = context.TODO()
+		})
+
+		g.Describe("RunCommand()", func() {
+			g.Describe("System call", func() {
+				g.Describe("Command ran successfully", func() {
+					g.BeforeEach(func() {
+						serverRepo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Server{ID: 1}, nil)
+						rconService.On("GetServerClient", int64(1)).Return(rconClient)
+						rconClient.On("RunCommand", "playerlist").Return("no players", nil)
+						commandQueueRepo.On("Store", mock.Anything, mock.Anything).Return(nil)
+					})
+
+					g.It("Should return a succeeded record with the response", func() {
+						record, err := service.RunCommand(ctx, 1, " playerlist ")
+
+						Expect(err).To(BeNil())
+						Expect(record.Status).To(Equal(domain.CommandStatusSucceeded))
+						Expect(record.Command).To(Equal("playerlist"))
+						Expect(record.Response.ValueOrZero()).To(Equal("no players"))
+						Expect(record.StartedAt.Valid).To(BeTrue())
+						Expect(record.FinishedAt.Valid).To(BeTrue())
+					})
+
+					g.It("Should record the command in the command history", func() {
+						_, err := service.RunCommand(ctx, 1, "playerlist")
+
+						Expect(err).To(BeNil())
+						commandQueueRepo.AssertCalled(t, "Store", mock.Anything, mock.MatchedBy(func(c *domain.QueuedCommand) bool {
+							return c.ServerID == 1 && c.Command == "playerlist" && c.Status == domain.CommandStatusSucceeded
+						}))
+					})
+				})
+
+				g.Describe("Command failed", func() {
+					g.BeforeEach(func() {
+						serverRepo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Server{ID: 1}, nil)
+						rconService.On("GetServerClient", int64(1)).Return(rconClient)
+						rconClient.On("RunCommand", "playerlist").Return("", fmt.Errorf("timeout"))
+						commandQueueRepo.On("Store", mock.Anything, mock.Anything).Return(nil)
+					})
+
+					g.It("Should return a failed record with the error", func() {
+						record, err := service.RunCommand(ctx, 1, "playerlist")
+
+						Expect(err).To(BeNil())
+						Expect(record.Status).To(Equal(domain.CommandStatusFailed))
+						Expect(record.LastError.ValueOrZero()).To(Equal("timeout"))
+					})
+				})
+
+				g.Describe("Server is offline", func() {
+					g.BeforeEach(func() {
+						serverRepo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Server{ID: 1}, nil)
+						rconService.On("GetServerClient", int64(1)).Return(nil)
+					})
+
+					g.It("Should return an error", func() {
+						_, err := service.RunCommand(ctx, 1, "playerlist")
+
+						Expect(err).ToNot(BeNil())
+						Expect(err.(*domain.HTTPError).Status).To(Equal(http.StatusBadRequest))
+					})
+				})
+
+				g.Describe("Blank command", func() {
+					g.It("Should return an error", func() {
+						_, err := service.RunCommand(ctx, 1, "   ")
+
+						Expect(err).ToNot(BeNil())
+						serverRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+					})
+				})
+			})
+
+			g.Describe("User call", func() {
+				g.BeforeEach(func() {
+					ctx = context.WithValue(ctx, "user", &domain.AuthUser{
+						Session: &kratos.Session{Identity: kratos.Identity{Id: "userid"}},
+					})
+				})
+
+				g.Describe("User does not have permission", func() {
+					g.BeforeEach(func() {
+						authorizer.On("HasPermission", mock.Anything, mock.Anything, "userid", mock.Anything).
+							Return(false, nil)
+					})
+
+					g.It("Should return an unauthorized error", func() {
+						_, err := service.RunCommand(ctx, 1, "playerlist")
+
+						Expect(err).ToNot(BeNil())
+						Expect(err.(*domain.HTTPError).Status).To(Equal(http.StatusUnauthorized))
+						rconService.AssertNotCalled(t, "GetServerClient", mock.Anything)
+					})
+				})
+
+				g.Describe("User has permission but is not an admin", func() {
+					g.BeforeEach(func() {
+						authorizer.On("HasPermission", mock.Anything, mock.Anything, "userid", mock.Anything).
+							Return(true, nil).Once()
+						authorizer.On("HasPermission", mock.Anything, mock.Anything, "userid", mock.Anything).
+							Return(false, nil).Once()
+						groupRepo.On("GetUserGroups", mock.Anything, "userid").Return([]*domain.Group{{ID: 4}}, nil)
+						groupRepo.On("GetBaseGroup", mock.Anything).Return(&domain.Group{ID: -1}, nil)
+						ruleRepo.On("GetByGroups", mock.Anything, []int64{-1, 4}).Return([]*domain.ConsoleRule{
+							{GroupID: 4, AllowPrefixes: []string{"playerlist", "say"}, DenyPrefixes: []string{"say secret"}},
+						}, nil)
+						serverRepo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Server{ID: 1}, nil)
+						rconService.On("GetServerClient", int64(1)).Return(rconClient)
+						rconClient.On("RunCommand", mock.Anything).Return("", nil)
+						commandQueueRepo.On("Store", mock.Anything, mock.Anything).Return(nil)
+					})
+
+					g.It("Should run allowed commands", func() {
+						record, err := service.RunCommand(ctx, 1, "PlayerList")
+
+						Expect(err).To(BeNil())
+						Expect(record.UserID.ValueOrZero()).To(Equal("userid"))
+					})
+
+					g.It("Should reject commands which are not allowed", func() {
+						_, err := service.RunCommand(ctx, 1, "ban someone")
+
+						Expect(err).ToNot(BeNil())
+						Expect(err.(*domain.HTTPError).Status).To(Equal(http.StatusUnauthorized))
+					})
+
+					g.It("Should reject denied commands", func() {
+						_, err := service.RunCommand(ctx, 1, "say secret stuff")
+
+						Expect(err).ToNot(BeNil())
+						Expect(err.(*domain.HTTPError).Status).To(Equal(http.StatusUnauthorized))
+					})
+				})
+
+				g.Describe("User is an admin", func() {
+					g.BeforeEach(func() {
+						authorizer.On("HasPermission", mock.Anything, mock.Anything, "userid", mock.Anything).
+							Return(true, nil)
+						serverRepo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Server{ID: 1}, nil)
+						rconService.On("GetServerClient", int64(1)).Return(rconClient)
+						rconClient.On("RunCommand", mock.Anything).Return("", nil)
+						commandQueueRepo.On("Store", mock.Anything, mock.Anything).Return(nil)
+					})
+
+					g.It("Should not check console rules", func() {
+						_, err := service.RunCommand(ctx, 1, "anything")
+
+						Expect(err).To(BeNil())
+						ruleRepo.AssertNotCalled(t, "GetByGroups", mock.Anything, mock.Anything)
+					})
+				})
+			})
+		})
+
+		g.Describe("GetGroupRule()", func() {
+			g.Describe("Rule not found", func() {
+				g.BeforeEach(func() {
+					ruleRepo.On("GetByGroups", mock.Anything, []int64{3}).Return(nil, domain.ErrNotFound)
+				})
+
+				g.It("Should return an unrestricted rule", func() {
+					rule, err := service.GetGroupRule(ctx, 3)
+
+					Expect(err).To(BeNil())
+					Expect(rule).To(Equal(&domain.ConsoleRule{
+						GroupID:       3,
+						AllowPrefixes: []string{},
+						DenyPrefixes:  []string{},
+					}))
+				})
+			})
+		})
+
+		g.Describe("SetGroupRule()", func() {
+			g.BeforeEach(func() {
+				groupRepo.On("GetBaseGroup", mock.Anything).Return(&domain.Group{ID: -1}, nil)
+			})
+
+			g.Describe("Group exists", func() {
+				g.BeforeEach(func() {
+					groupRepo.On("GetByID", mock.Anything, int64(3)).Return(&domain.Group{ID: 3}, nil)
+					ruleRepo.On("Set", mock.Anything, mock.Anything).Return(nil)
+				})
+
+				g.It("Should store the rule without blank prefixes", func() {
+					err := service.SetGroupRule(ctx, &domain.ConsoleRule{
+						GroupID:       3,
+						AllowPrefixes: []string{" say ", ""},
+						DenyPrefixes:  []string{"   "},
+					})
+
+					Expect(err).To(BeNil())
+					ruleRepo.AssertCalled(t, "Set", mock.Anything, &domain.ConsoleRule{
+						GroupID:       3,
+						AllowPrefixes: []string{"say"},
+						DenyPrefixes:  []string{},
+					})
+				})
+			})
+
+			g.Describe("Base group", func() {
+				g.BeforeEach(func() {
+					ruleRepo.On("Set", mock.Anything, mock.Anything).Return(nil)
+				})
+
+				g.It("Should not look up the group", func() {
+					err := service.SetGroupRule(ctx, &domain.ConsoleRule{GroupID: -1})
+
+					Expect(err).To(BeNil())
+					groupRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Group does not exist", func() {
+				g.BeforeEach(func() {
+					groupRepo.On("GetByID", mock.Anything, int64(3)).Return(nil, domain.ErrNotFound)
+				})
+
+				g.It("Should return an error", func() {
+					err := service.SetGroupRule(ctx, &domain.ConsoleRule{GroupID: 3})
+
+					Expect(err).ToNot(BeNil())
+					ruleRepo.AssertNotCalled(t, "Set", mock.Anything, mock.Anything)
+				})
+			})
+		})
+
+		g.Describe("HandleConsoleCommand()", func() {
+			g.BeforeEach(func() {
+				authorizer.On("HasPermission", mock.Anything, mock.Anything, "userid", mock.Anything).Return(false, nil)
+				websocketService.On("SendDirectMessage", mock.Anything, "userid").Return()
+			})
+
+			g.It("Should send the error back to the user", func() {
+				service.HandleConsoleCommand(&domain.ConsoleCommandBody{
+					ServerID: 1,
+					Command:  "playerlist",
+					UserID:   "userid",
+				})
+
+				websocketService.AssertCalled(t, "SendDirectMessage", mock.MatchedBy(func(msg *domain.WebsocketMessage) bool {
+					body, ok := msg.Body.(*consoleResponseBody)
+					return ok && msg.Type == "console-response" && !body.Success && body.Error != ""
+				}), "userid")
+			})
+		})
+	})
+}
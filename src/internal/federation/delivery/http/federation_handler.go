@@ -0,0 +1,242 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package http
+
+import (
+	"Refractor/authcheckers"
+	"Refractor/domain"
+	"Refractor/params"
+	"Refractor/pkg/api"
+	"Refractor/pkg/api/middleware"
+	"Refractor/pkg/perms"
+	"Refractor/pkg/structutils"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type federationHandler struct {
+	service domain.FederationService
+	logger  *zap.Logger
+}
+
+func ApplyFederationHandler(apiGroup *echo.Group, s domain.FederationService, a domain.Authorizer, mware domain.Middleware,
+	log *zap.Logger) {
+	handler := &federationHandler{
+		service: s,
+		logger:  log,
+	}
+
+	// The feed and public key are fetched by partner instances, so they do not require user authentication. Instead,
+	// the feed is only served if a signing key and feed key are configured, and partners must send the feed key.
+	federationGroup := apiGroup.Group("/federation")
+	sourceGroup := apiGroup.Group("/federation/sources", mware.ProtectMiddleware, mware.ActivationMiddleware)
+	playerGroup := apiGroup.Group("/players", mware.ProtectMiddleware, mware.ActivationMiddleware)
+
+	// Create an enforcer to authorize the user on the various endpoints
+	enforcer := middleware.NewEnforcer(a, domain.AuthScope{
+		Type: domain.AuthObjRefractor,
+	}, log)
+
+	canManage := enforcer.CheckAuth(authcheckers.HasPermission(perms.FlagManageFederation, true))
+
+	federationGroup.GET("/feed", handler.GetFeed)
+	federationGroup.GET("/key", handler.GetPublicKey)
+
+	sourceGroup.GET("", handler.GetSources, canManage)
+	sourceGroup.POST("", handler.CreateSource, canManage)
+	sourceGroup.PATCH("/:id", handler.UpdateSource, canManage)
+	sourceGroup.DELETE("/:id", handler.DeleteSource, canManage)
+	sourceGroup.POST("/:id/sync", handler.SyncSource, canManage)
+
+	playerGroup.GET("/:platform/:id/federated-bans", handler.GetPlayerBans,
+		enforcer.CheckAuth(authcheckers.CanViewPlayerRecords))
+}
+
+// GetFeed responds with the raw feed body rather than a domain.Response since the signature header covers the exact
+// bytes of the body.
+func (h *federationHandler) GetFeed(c echo.Context) error {
+	feedKey := c.Request().Header.Get(domain.FederationFeedKeyHeader)
+
+	body, signature, err := h.service.GetFeed(c.Request().Context(), feedKey)
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(domain.FederationSignatureHeader, signature)
+
+	return c.Blob(http.StatusOK, echo.MIMEApplicationJSONCharsetUTF8, body)
+}
+
+func (h *federationHandler) GetPublicKey(c echo.Context) error {
+	key, err := h.service.GetPublicKey()
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: key,
+	})
+}
+
+func (h *federationHandler) GetSources(c echo.Context) error {
+	sources, err := h.service.GetSources(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: sources,
+	})
+}
+
+func (h *federationHandler) CreateSource(c echo.Context) error {
+	// Validate request body
+	var body params.CreateFederationSourceParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	source, err := h.service.CreateSource(c.Request().Context(), &domain.FederationSource{
+		Name:       strings.TrimSpace(body.Name),
+		FeedURL:    strings.TrimSpace(body.FeedURL),
+		PublicKey:  strings.TrimSpace(body.PublicKey),
+		Instance:   strings.TrimSpace(body.Instance),
+		FeedKey:    body.FeedKey,
+		TrustLevel: body.TrustLevel,
+		Enabled:    true,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Source created",
+		Payload: source,
+	})
+}
+
+func (h *federationHandler) UpdateSource(c echo.Context) error {
+	sourceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid source id"), http.StatusBadRequest, "")
+	}
+
+	// Validate request body
+	var body params.UpdateFederationSourceParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	// Get update args
+	updateArgs, err := structutils.GetNonNilFieldMap(body)
+	if err != nil {
+		return err
+	}
+
+	if len(updateArgs) < 1 {
+		return c.JSON(http.StatusBadRequest, &domain.Response{
+			Success: false,
+			Message: "No update fields provided",
+		})
+	}
+
+	updated, err := h.service.UpdateSource(c.Request().Context(), sourceID, updateArgs)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Source updated",
+		Payload: updated,
+	})
+}
+
+func (h *federationHandler) DeleteSource(c echo.Context) error {
+	sourceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid source id"), http.StatusBadRequest, "")
+	}
+
+	if err := h.service.DeleteSource(c.Request().Context(), sourceID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Source deleted",
+	})
+}
+
+func (h *federationHandler) SyncSource(c echo.Context) error {
+	sourceID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid source id"), http.StatusBadRequest, "")
+	}
+
+	source, err := h.service.SyncSource(c.Request().Context(), sourceID)
+	if err != nil {
+		return err
+	}
+
+	if source.LastSyncError.Valid {
+		return c.JSON(http.StatusOK, &domain.Response{
+			Success: false,
+			Message: fmt.Sprintf("Could not sync source: %s", source.LastSyncError.String),
+			Payload: source,
+		})
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Source synced",
+		Payload: source,
+	})
+}
+
+func (h *federationHandler) GetPlayerBans(c echo.Context) error {
+	platform, id, err := api.GetPlayerParams(c)
+	if err != nil {
+		return err
+	}
+
+	bans, err := h.service.GetPlayerBans(c.Request().Context(), platform, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: bans,
+	})
+}
@@ -0,0 +1,382 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"Refractor/pkg/aeshelper"
+	"Refractor/pkg/conf"
+	"Refractor/pkg/querybuilders/psqlqb"
+	"context"
+	"database/sql"
+	"github.com/guregu/null"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+const opTag = "FederationRepo.Postgres."
+
+const pgUniqueViolationCode = "23505"
+
+type federationRepo struct {
+	db     *sql.DB
+	logger *zap.Logger
+	qb     domain.QueryBuilder
+	conf   *conf.Config
+}
+
+func NewFederationRepo(db *sql.DB, logger *zap.Logger, conf *conf.Config) domain.FederationRepo {
+	return &federationRepo{
+		db:     db,
+		logger: logger,
+		qb:     psqlqb.NewPostgresQueryBuilder(),
+		conf:   conf,
+	}
+}
+
+func (r *federationRepo) StoreSource(ctx context.Context, source *domain.FederationSource) error {
+	const op = opTag + "StoreSource"
+
+	query := `INSERT INTO FederationSources (Name, FeedURL, PublicKey, Instance, FeedKey, TrustLevel, Enabled)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING SourceID, CreatedAt;`
+
+	// Encrypt the source's feed key
+	encrypted, err := aeshelper.Encrypt([]byte(source.FeedKey), r.conf.EncryptionKey)
+	if err != nil {
+		r.logger.Error("Could not encrypt federation source feed key", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	row := r.db.QueryRowContext(ctx, query, source.Name, source.FeedURL, source.PublicKey, source.Instance, encrypted,
+		source.TrustLevel, source.Enabled)
+	if err := row.Scan(&source.SourceID, &source.CreatedAt); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == pgUniqueViolationCode {
+			return errors.Wrap(domain.ErrConflict, op)
+		}
+
+		r.logger.Error("Could not store federation source", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *federationRepo) GetSources(ctx context.Context) ([]*domain.FederationSource, error) {
+	const op = opTag + "GetSources"
+
+	query := "SELECT * FROM FederationSources ORDER BY SourceID;"
+
+	results, err := r.fetchSources(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return results, nil
+}
+
+func (r *federationRepo) GetSourceByID(ctx context.Context, id int64) (*domain.FederationSource, error) {
+	const op = opTag + "GetSourceByID"
+
+	query := "SELECT * FROM FederationSources WHERE SourceID = $1;"
+
+	results, err := r.fetchSources(ctx, query, id)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if len(results) == 0 {
+		return nil, errors.Wrap(domain.ErrNotFound, op)
+	}
+
+	return results[0], nil
+}
+
+func (r *federationRepo) UpdateSource(ctx context.Context, id int64, args domain.UpdateArgs) (*domain.FederationSource, error) {
+	const op = opTag + "UpdateSource"
+
+	// If the feed key is being updated, encrypt it.
+	if args["FeedKey"] != nil {
+		encrypted, err := aeshelper.Encrypt([]byte(*args["FeedKey"].(*string)), r.conf.EncryptionKey)
+		if err != nil {
+			r.logger.Error("Could not encrypt federation source feed key", zap.Error(err))
+			return nil, errors.Wrap(err, op)
+		}
+
+		args["FeedKey"] = encrypted
+	}
+
+	query, values := r.qb.BuildUpdateQuery("FederationSources", id, "SourceID", args, nil)
+
+	source := &domain.FederationSource{}
+
+	row := r.db.QueryRowContext(ctx, query, values...)
+	if err := r.scanSource(row, source); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.Wrap(domain.ErrNotFound, op)
+		}
+
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == pgUniqueViolationCode {
+			return nil, errors.Wrap(domain.ErrConflict, op)
+		}
+
+		r.logger.Error("Could not scan updated federation source", zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return source, nil
+}
+
+func (r *federationRepo) DeleteSource(ctx context.Context, id int64) error {
+	const op = opTag + "DeleteSource"
+
+	query := "DELETE FROM FederationSources WHERE SourceID = $1;"
+
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Could not get affected rows", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	if rowsAffected < 1 {
+		return errors.Wrap(domain.ErrNotFound, op)
+	}
+
+	return nil
+}
+
+func (r *federationRepo) SetSyncResult(ctx context.Context, id int64, syncedAt time.Time, syncError null.String) error {
+	const op = opTag + "SetSyncResult"
+
+	query := "UPDATE FederationSources SET LastSyncedAt = $1, LastSyncError = $2 WHERE SourceID = $3;"
+
+	if _, err := r.db.ExecContext(ctx, query, syncedAt, syncError, id); err != nil {
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *federationRepo) ReplaceBans(ctx context.Context, sourceID int64, generatedAt time.Time, bans []*domain.FederatedBan) error {
+	const op = opTag + "ReplaceBans"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.Error("Could not begin transaction", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	// Record the feed's generation time first so that a concurrent sync of an older feed can not overwrite these bans.
+	query := `UPDATE FederationSources SET LastFeedAt = $1
+			WHERE SourceID = $2 AND (LastFeedAt IS NULL OR LastFeedAt < $1);`
+
+	res, err := tx.ExecContext(ctx, query, generatedAt, sourceID)
+	if err != nil {
+		_ = tx.Rollback()
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		r.logger.Error("Could not get affected rows", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	if rowsAffected < 1 {
+		_ = tx.Rollback()
+		return errors.Wrap(domain.ErrConflict, op)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM FederatedBans WHERE SourceID = $1;", sourceID); err != nil {
+		_ = tx.Rollback()
+		r.logger.Error("Could not delete existing federated bans", zap.Int64("Source ID", sourceID), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	query = `INSERT INTO FederatedBans (SourceID, RemoteID, Platform, PlayerID, Reason, Duration, RemoteCreatedAt)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT DO NOTHING;`
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		_ = tx.Rollback()
+		r.logger.Error("Could not prepare statement", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	for _, ban := range bans {
+		if _, err := stmt.ExecContext(ctx, sourceID, ban.RemoteID, ban.Platform, ban.PlayerID, ban.Reason,
+			ban.Duration, ban.RemoteCreatedAt); err != nil {
+			_ = stmt.Close()
+			_ = tx.Rollback()
+			r.logger.Error("Could not insert federated ban", zap.Int64("Remote ID", ban.RemoteID), zap.Error(err))
+			return errors.Wrap(err, op)
+		}
+	}
+
+	if err := stmt.Close(); err != nil {
+		r.logger.Warn("Could not close statement", zap.Error(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Could not commit transaction", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *federationRepo) GetActiveBansByPlayer(ctx context.Context, platform, playerID string) ([]*domain.FederatedBan, error) {
+	const op = opTag + "GetActiveBansByPlayer"
+
+	query := `SELECT fb.SourceID, fb.RemoteID, fb.Platform, fb.PlayerID, fb.Reason, fb.Duration, fb.RemoteCreatedAt,
+				fb.ImportedAt, fs.Name, fs.TrustLevel
+			FROM FederatedBans fb
+			JOIN FederationSources fs ON fs.SourceID = fb.SourceID
+			WHERE fb.Platform = $1 AND fb.PlayerID = $2 AND fs.Enabled = TRUE AND (
+				fb.Duration = $3 OR fb.RemoteCreatedAt + (fb.Duration * INTERVAL '1 minute') > CURRENT_TIMESTAMP
+			)
+			ORDER BY fb.RemoteCreatedAt DESC;`
+
+	rows, err := r.db.QueryContext(ctx, query, platform, playerID, domain.PermanentInfractionValue)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.FederatedBan, 0)
+	for rows.Next() {
+		ban := &domain.FederatedBan{}
+
+		if err := rows.Scan(&ban.SourceID, &ban.RemoteID, &ban.Platform, &ban.PlayerID, &ban.Reason, &ban.Duration,
+			&ban.RemoteCreatedAt, &ban.ImportedAt, &ban.SourceName, &ban.TrustLevel); err != nil {
+			r.logger.Error("Could not scan federated ban", zap.Error(err))
+			return nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, ban)
+	}
+
+	return results, nil
+}
+
+// GetExportBans uses the same definition of an active ban as InfractionRepo.GetMostSignificantInfraction.
+func (r *federationRepo) GetExportBans(ctx context.Context) ([]*domain.FederatedBanEntry, error) {
+	const op = opTag + "GetExportBans"
+
+	query := `SELECT InfractionID, Platform, PlayerID, COALESCE(Reason, ''), Duration, CreatedAt FROM Infractions
+			WHERE Type = $1 AND Repealed = FALSE AND DeletedAt IS NULL AND (
+				Duration = $2 OR (EXTRACT(EPOCH FROM CreatedAt) + (Duration * 60)) >= EXTRACT(EPOCH FROM CURRENT_TIMESTAMP)
+			)
+			ORDER BY InfractionID;`
+
+	rows, err := r.db.QueryContext(ctx, query, domain.InfractionTypeBan, domain.PermanentInfractionValue)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.FederatedBanEntry, 0)
+	for rows.Next() {
+		entry := &domain.FederatedBanEntry{}
+
+		if err := rows.Scan(&entry.ID, &entry.Platform, &entry.PlayerID, &entry.Reason, &entry.Duration,
+			&entry.CreatedAt); err != nil {
+			r.logger.Error("Could not scan export ban", zap.Error(err))
+			return nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, entry)
+	}
+
+	return results, nil
+}
+
+func (r *federationRepo) fetchSources(ctx context.Context, query string, args ...interface{}) ([]*domain.FederationSource, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.FederationSource, 0)
+	for rows.Next() {
+		source := &domain.FederationSource{}
+
+		if err := r.scanSource(rows, source); err != nil {
+			r.logger.Error("Could not scan federation source", zap.Error(err))
+			return nil, err
+		}
+
+		results = append(results, source)
+	}
+
+	return results, nil
+}
+
+// Scan helpers
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (r *federationRepo) scanSource(row rowScanner, source *domain.FederationSource) error {
+	var feedKey []byte
+
+	if err := row.Scan(&source.SourceID, &source.Name, &source.FeedURL, &source.PublicKey, &source.Instance, &feedKey,
+		&source.TrustLevel, &source.Enabled, &source.LastSyncedAt, &source.LastSyncError, &source.LastFeedAt,
+		&source.CreatedAt, &source.ModifiedAt); err != nil {
+		return err
+	}
+
+	// Decrypt the source's feed key
+	decrypted, err := aeshelper.Decrypt(feedKey, r.conf.EncryptionKey)
+	if err != nil {
+		r.logger.Error("Could not decrypt federation source feed key", zap.Error(err))
+		return err
+	}
+
+	source.FeedKey = string(decrypted)
+	return nil
+}
@@ -0,0 +1,266 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"Refractor/pkg/aeshelper"
+	"Refractor/pkg/conf"
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/franela/goblin"
+	"github.com/guregu/null"
+	"github.com/lib/pq"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var config = &conf.Config{
+		EncryptionKey: strings.Repeat("a", 32),
+	}
+
+	var sourceCols = []string{"SourceID", "Name", "FeedURL", "PublicKey", "Instance", "FeedKey", "TrustLevel", "Enabled",
+		"LastSyncedAt", "LastSyncError", "LastFeedAt", "CreatedAt", "ModifiedAt"}
+	var ctx = context.TODO()
+
+	encrypted, _ := aeshelper.Encrypt([]byte("feed-key-feed-key"), config.EncryptionKey)
+	var feedKeyEncrypted = string(encrypted)
+
+	g.Describe("Federation Postgres Repo", func() {
+		var repo domain.FederationRepo
+		var mock sqlmock.Sqlmock
+		var db *sql.DB
+
+		g.BeforeEach(func() {
+			var err error
+
+			db, mock, err = sqlmock.New()
+			if err != nil {
+				t.Fatalf("Could not create new sqlmock instance. Error: %v", err)
+			}
+
+			repo = NewFederationRepo(db, zap.NewNop(), config)
+		})
+
+		g.After(func() {
+			_ = db.Close()
+		})
+
+		g.Describe("StoreSource()", func() {
+			var source *domain.FederationSource
+
+			g.BeforeEach(func() {
+				source = &domain.FederationSource{
+					Name:       "Partner",
+					FeedURL:    "https://partner.example/api/v1/federation/feed",
+					PublicKey:  "key",
+					Instance:   "Partner Instance",
+					FeedKey:    "feed-key-feed-key",
+					TrustLevel: domain.FederationTrustAlert,
+					Enabled:    true,
+				}
+			})
+
+			g.It("Should set the source ID and created at time", func() {
+				createdAt := time.Now()
+
+				mock.ExpectQuery("INSERT INTO FederationSources").
+					WithArgs("Partner", source.FeedURL, "key", "Partner Instance", sqlmock.AnyArg(),
+						domain.FederationTrustAlert, true).
+					WillReturnRows(sqlmock.NewRows([]string{"SourceID", "CreatedAt"}).AddRow(3, createdAt))
+
+				err := repo.StoreSource(ctx, source)
+
+				Expect(err).To(BeNil())
+				Expect(source.SourceID).To(Equal(int64(3)))
+				Expect(source.CreatedAt).To(Equal(createdAt))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return domain.ErrConflict if the feed URL is already used", func() {
+				mock.ExpectQuery("INSERT INTO FederationSources").WillReturnError(&pq.Error{Code: pgUniqueViolationCode})
+
+				err := repo.StoreSource(ctx, source)
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrConflict))
+			})
+		})
+
+		g.Describe("GetSourceByID()", func() {
+			g.It("Should return the source with its feed key decrypted", func() {
+				mock.ExpectQuery("SELECT (.+) FROM FederationSources").WithArgs(3).
+					WillReturnRows(sqlmock.NewRows(sourceCols).AddRow(3, "Partner", "url", "key", "Partner Instance",
+						feedKeyEncrypted, domain.FederationTrustEnforce, true, nil, nil, nil, time.Now(), nil))
+
+				source, err := repo.GetSourceByID(ctx, 3)
+
+				Expect(err).To(BeNil())
+				Expect(source.TrustLevel).To(Equal(domain.FederationTrustEnforce))
+				Expect(source.FeedKey).To(Equal("feed-key-feed-key"))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return domain.ErrNotFound if no source was found", func() {
+				mock.ExpectQuery("SELECT (.+) FROM FederationSources").WillReturnRows(sqlmock.NewRows(sourceCols))
+
+				_, err := repo.GetSourceByID(ctx, 3)
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+			})
+		})
+
+		g.Describe("UpdateSource()", func() {
+			g.It("Should return the updated source", func() {
+				mock.ExpectQuery("UPDATE FederationSources SET TrustLevel = \\$1 WHERE SourceID = \\$2").
+					WithArgs(domain.FederationTrustAdvisory, 3).
+					WillReturnRows(sqlmock.NewRows(sourceCols).AddRow(3, "Partner", "url", "key", "Partner Instance",
+						feedKeyEncrypted, domain.FederationTrustAdvisory, true, nil, nil, nil, time.Now(), time.Now()))
+
+				source, err := repo.UpdateSource(ctx, 3, domain.UpdateArgs{"TrustLevel": domain.FederationTrustAdvisory})
+
+				Expect(err).To(BeNil())
+				Expect(source.TrustLevel).To(Equal(domain.FederationTrustAdvisory))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return domain.ErrNotFound if no source was found", func() {
+				mock.ExpectQuery("UPDATE FederationSources").WillReturnRows(sqlmock.NewRows(sourceCols))
+
+				_, err := repo.UpdateSource(ctx, 3, domain.UpdateArgs{"Enabled": false})
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+			})
+		})
+
+		g.Describe("DeleteSource()", func() {
+			g.It("Should return domain.ErrNotFound if the source does not exist", func() {
+				mock.ExpectExec("DELETE FROM FederationSources").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 0))
+
+				err := repo.DeleteSource(ctx, 3)
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+			})
+		})
+
+		g.Describe("ReplaceBans()", func() {
+			var bans []*domain.FederatedBan
+			var generatedAt time.Time
+
+			g.BeforeEach(func() {
+				generatedAt = time.Now()
+
+				bans = []*domain.FederatedBan{
+					{RemoteID: 1, Platform: "playfab", PlayerID: "p1", Reason: null.StringFrom("Cheating"),
+						Duration: -1, RemoteCreatedAt: time.Now()},
+					{RemoteID: 2, Platform: "playfab", PlayerID: "p2", Duration: 60, RemoteCreatedAt: time.Now()},
+				}
+			})
+
+			g.It("Should replace the bans of the source in a transaction", func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE FederationSources SET LastFeedAt").WithArgs(generatedAt, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM FederatedBans").WithArgs(3).WillReturnResult(sqlmock.NewResult(0, 5))
+				prep := mock.ExpectPrepare("INSERT INTO FederatedBans")
+				prep.ExpectExec().WithArgs(3, 1, "playfab", "p1", bans[0].Reason, -1, bans[0].RemoteCreatedAt).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				prep.ExpectExec().WithArgs(3, 2, "playfab", "p2", bans[1].Reason, 60, bans[1].RemoteCreatedAt).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+
+				err := repo.ReplaceBans(ctx, 3, generatedAt, bans)
+
+				Expect(err).To(BeNil())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should roll back if a ban could not be inserted", func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE FederationSources SET LastFeedAt").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("DELETE FROM FederatedBans").WillReturnResult(sqlmock.NewResult(0, 0))
+				prep := mock.ExpectPrepare("INSERT INTO FederatedBans")
+				prep.ExpectExec().WillReturnError(sql.ErrConnDone)
+				mock.ExpectRollback()
+
+				err := repo.ReplaceBans(ctx, 3, generatedAt, bans)
+
+				Expect(err).ToNot(BeNil())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return domain.ErrConflict if a newer feed was already imported", func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE FederationSources SET LastFeedAt").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+
+				err := repo.ReplaceBans(ctx, 3, generatedAt, bans)
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrConflict))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("GetActiveBansByPlayer()", func() {
+			g.It("Should return the bans with their source details", func() {
+				mock.ExpectQuery("SELECT (.+) FROM FederatedBans").
+					WithArgs("playfab", "p1", domain.PermanentInfractionValue).
+					WillReturnRows(sqlmock.NewRows([]string{"SourceID", "RemoteID", "Platform", "PlayerID", "Reason",
+						"Duration", "RemoteCreatedAt", "ImportedAt", "Name", "TrustLevel"}).
+						AddRow(3, 1, "playfab", "p1", "Cheating", -1, time.Now(), time.Now(), "Partner",
+							domain.FederationTrustEnforce))
+
+				bans, err := repo.GetActiveBansByPlayer(ctx, "playfab", "p1")
+
+				Expect(err).To(BeNil())
+				Expect(bans).To(HaveLen(1))
+				Expect(bans[0].SourceName).To(Equal("Partner"))
+				Expect(bans[0].TrustLevel).To(Equal(domain.FederationTrustEnforce))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("GetExportBans()", func() {
+			g.It("Should return active bans", func() {
+				mock.ExpectQuery("SELECT (.+) FROM Infractions").
+					WithArgs(domain.InfractionTypeBan, domain.PermanentInfractionValue).
+					WillReturnRows(sqlmock.NewRows([]string{"InfractionID", "Platform", "PlayerID", "Reason",
+						"Duration", "CreatedAt"}).
+						AddRow(7, "playfab", "p1", "Cheating", 1440, time.Now()))
+
+				bans, err := repo.GetExportBans(ctx)
+
+				Expect(err).To(BeNil())
+				Expect(bans).To(HaveLen(1))
+				Expect(bans[0].ID).To(Equal(int64(7)))
+				Expect(bans[0].Duration).To(Equal(int64(1440)))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+	})
+}
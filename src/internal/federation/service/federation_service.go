@@ -0,0 +1,517 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/domain"
+	"Refractor/pkg/broadcast"
+	"context"
+	"crypto/ed25519"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/guregu/null"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type federationService struct {
+	repo            domain.FederationRepo
+	commandExecutor domain.CommandExecutor
+	privateKey      ed25519.PrivateKey
+	feedKey         string
+	instanceName    string
+	httpClient      *http.Client
+	timeout         time.Duration
+	logger          *zap.Logger
+	alertSubs       []domain.FederatedBanSubscriber
+}
+
+const (
+	// syncInterval is how often every enabled federation source is synced.
+	syncInterval = time.Minute * 15
+
+	// syncTimeout is how long a single source has to respond with its feed and have it stored.
+	syncTimeout = time.Second * 30
+
+	// maxFeedSize is the largest feed body in bytes which will be read from a source.
+	maxFeedSize = 16 << 20
+
+	// maxFeedClockSkew is how far in the future a feed's generation time may be before the feed is rejected.
+	maxFeedClockSkew = time.Minute * 5
+
+	// minFeedKeyLength is the shortest shared feed key which is accepted.
+	minFeedKeyLength = 16
+)
+
+// NewFederationService creates a new federation service. signingKey is the base64 encoded ed25519 seed used to sign the
+// exported ban feed and feedKey is the shared key partner instances must send to fetch it. If either is empty, the ban
+// feed is not exported but feeds from other instances can still be imported.
+func NewFederationService(repo domain.FederationRepo, ce domain.CommandExecutor, signingKey, feedKey, instanceName string,
+	to time.Duration, log *zap.Logger) (domain.FederationService, error) {
+	var privateKey ed25519.PrivateKey
+
+	if feedKey != "" && len(feedKey) < minFeedKeyLength {
+		return nil, fmt.Errorf("federation feed key must be at least %d characters long", minFeedKeyLength)
+	}
+
+	if signingKey != "" {
+		seed, err := base64.StdEncoding.DecodeString(signingKey)
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("federation signing key must be a base64 encoded %d byte seed", ed25519.SeedSize)
+		}
+
+		privateKey = ed25519.NewKeyFromSeed(seed)
+	}
+
+	return &federationService{
+		repo:            repo,
+		commandExecutor: ce,
+		privateKey:      privateKey,
+		feedKey:         feedKey,
+		instanceName:    instanceName,
+		httpClient:      &http.Client{Timeout: syncTimeout},
+		timeout:         to,
+		logger:          log,
+		alertSubs:       []domain.FederatedBanSubscriber{},
+	}, nil
+}
+
+var errFeedDisabled = domain.NewHTTPError(nil, http.StatusNotFound, "Ban list sharing is not enabled on this instance")
+var errInvalidFeedKey = domain.NewHTTPError(nil, http.StatusUnauthorized, "Invalid feed key")
+
+// errStaleFeed is returned when a feed is not newer than the last feed imported from its source, which means it was
+// replayed or arrived out of order.
+var errStaleFeed = fmt.Errorf("feed is not newer than the last imported feed")
+
+// GetFeed returns the ban feed of this instance along with its signature. Bans which were imported from other
+// instances are never part of the feed.
+func (s *federationService) GetFeed(c context.Context, feedKey string) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if s.privateKey == nil || s.feedKey == "" {
+		return nil, "", errFeedDisabled
+	}
+
+	if subtle.ConstantTimeCompare([]byte(feedKey), []byte(s.feedKey)) != 1 {
+		return nil, "", errInvalidFeedKey
+	}
+
+	bans, err := s.repo.GetExportBans(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	body, err := json.Marshal(&domain.FederatedBanFeed{
+		Instance:    s.instanceName,
+		GeneratedAt: time.Now().UTC(),
+		Bans:        bans,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(s.privateKey, body))
+
+	return body, signature, nil
+}
+
+// GetPublicKey returns the base64 encoded public key partner instances use to verify this instance's ban feed.
+func (s *federationService) GetPublicKey() (string, error) {
+	if s.privateKey == nil {
+		return "", errFeedDisabled
+	}
+
+	return base64.StdEncoding.EncodeToString(s.privateKey.Public().(ed25519.PublicKey)), nil
+}
+
+func (s *federationService) CreateSource(c context.Context, source *domain.FederationSource) (*domain.FederationSource, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if err := s.repo.StoreSource(ctx, source); err != nil {
+		if errors.Cause(err) == domain.ErrConflict {
+			return nil, domain.NewHTTPError(err, http.StatusConflict, "A source with this feed URL already exists")
+		}
+
+		return nil, err
+	}
+
+	return source, nil
+}
+
+func (s *federationService) GetSources(c context.Context) ([]*domain.FederationSource, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.repo.GetSources(ctx)
+}
+
+func (s *federationService) UpdateSource(c context.Context, id int64, args domain.UpdateArgs) (*domain.FederationSource, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	updated, err := s.repo.UpdateSource(ctx, id, args)
+	if err != nil {
+		switch errors.Cause(err) {
+		case domain.ErrNotFound:
+			return nil, domain.NewHTTPError(err, http.StatusNotFound, "Source not found")
+		case domain.ErrConflict:
+			return nil, domain.NewHTTPError(err, http.StatusConflict, "A source with this feed URL already exists")
+		}
+
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (s *federationService) DeleteSource(c context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if err := s.repo.DeleteSource(ctx, id); err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return domain.NewHTTPError(err, http.StatusNotFound, "Source not found")
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// SyncSource imports the current bans of a source right away. The result of the sync is recorded on the returned
+// source rather than returned as an error, since a failed sync is an expected outcome when a partner is unreachable.
+func (s *federationService) SyncSource(c context.Context, id int64) (*domain.FederationSource, error) {
+	ctx, cancel := context.WithTimeout(c, syncTimeout)
+	defer cancel()
+
+	source, err := s.repo.GetSourceByID(ctx, id)
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return nil, domain.NewHTTPError(err, http.StatusNotFound, "Source not found")
+		}
+
+		return nil, err
+	}
+
+	if err := s.syncSource(ctx, source); err != nil {
+		return nil, err
+	}
+
+	return source, nil
+}
+
+func (s *federationService) GetPlayerBans(c context.Context, platform, playerID string) ([]*domain.FederatedBan, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.repo.GetActiveBansByPlayer(ctx, platform, playerID)
+}
+
+// StartSyncWatcher syncs all enabled sources right away, then again every syncInterval until terminate receives.
+func (s *federationService) StartSyncWatcher(terminate chan uint8) {
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+
+	for {
+		s.syncAll()
+
+		select {
+		case <-terminate:
+			s.logger.Info("Terminating federation sync watcher routine")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *federationService) syncAll() {
+	ctx, cancel := context.WithTimeout(context.TODO(), s.timeout)
+	defer cancel()
+
+	sources, err := s.repo.GetSources(ctx)
+	if err != nil {
+		s.logger.Error("Could not get federation sources", zap.Error(err))
+		return
+	}
+
+	for _, source := range sources {
+		if !source.Enabled {
+			continue
+		}
+
+		syncCtx, syncCancel := context.WithTimeout(context.TODO(), syncTimeout)
+		if err := s.syncSource(syncCtx, source); err != nil {
+			s.logger.Error("Could not record federation source sync result",
+				zap.Int64("Source ID", source.SourceID),
+				zap.Error(err))
+		}
+		syncCancel()
+	}
+}
+
+// syncSource fetches and imports the feed of a source, then records the result of the sync on the source. Only an
+// error recording the result is returned.
+func (s *federationService) syncSource(ctx context.Context, source *domain.FederationSource) error {
+	syncedAt := time.Now()
+	syncError := null.String{}
+
+	count, err := s.importFeed(ctx, source)
+	if err != nil {
+		s.logger.Warn("Could not sync federation source",
+			zap.Int64("Source ID", source.SourceID),
+			zap.String("Feed URL", source.FeedURL),
+			zap.Error(err))
+		syncError = null.StringFrom(err.Error())
+	} else {
+		s.logger.Info("Synced federation source",
+			zap.Int64("Source ID", source.SourceID),
+			zap.Int("Bans", count))
+	}
+
+	if err := s.repo.SetSyncResult(ctx, source.SourceID, syncedAt, syncError); err != nil {
+		return err
+	}
+
+	source.LastSyncedAt = null.TimeFrom(syncedAt)
+	source.LastSyncError = syncError
+
+	return nil
+}
+
+// importFeed fetches the feed of a source, verifies its signature and replaces the source's bans with the bans in it.
+// Feeds from a different instance than the source expects and feeds which are not newer than the last imported feed are
+// rejected. The number of imported bans is returned.
+func (s *federationService) importFeed(ctx context.Context, source *domain.FederationSource) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source.FeedURL, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set(domain.FederationFeedKeyHeader, source.FeedKey)
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("feed responded with status %d", res.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxFeedSize))
+	if err != nil {
+		return 0, err
+	}
+
+	if err := verifyFeed(source.PublicKey, body, res.Header.Get(domain.FederationSignatureHeader)); err != nil {
+		return 0, err
+	}
+
+	feed := &domain.FederatedBanFeed{}
+	if err := json.Unmarshal(body, feed); err != nil {
+		return 0, fmt.Errorf("could not parse feed: %v", err)
+	}
+
+	if feed.Instance != source.Instance {
+		return 0, fmt.Errorf("feed is from instance %q instead of %q", feed.Instance, source.Instance)
+	}
+
+	if feed.GeneratedAt.After(time.Now().Add(maxFeedClockSkew)) {
+		return 0, fmt.Errorf("feed generation time is in the future")
+	}
+
+	if source.LastFeedAt.Valid && !feed.GeneratedAt.After(source.LastFeedAt.Time) {
+		return 0, errStaleFeed
+	}
+
+	bans := make([]*domain.FederatedBan, 0, len(feed.Bans))
+	for _, entry := range feed.Bans {
+		if strings.TrimSpace(entry.Platform) == "" || strings.TrimSpace(entry.PlayerID) == "" {
+			continue
+		}
+
+		bans = append(bans, &domain.FederatedBan{
+			SourceID:        source.SourceID,
+			RemoteID:        entry.ID,
+			Platform:        strings.ToLower(entry.Platform),
+			PlayerID:        entry.PlayerID,
+			Reason:          null.NewString(entry.Reason, entry.Reason != ""),
+			Duration:        entry.Duration,
+			RemoteCreatedAt: entry.CreatedAt,
+		})
+	}
+
+	if err := s.repo.ReplaceBans(ctx, source.SourceID, feed.GeneratedAt, bans); err != nil {
+		if errors.Cause(err) == domain.ErrConflict {
+			return 0, errStaleFeed
+		}
+
+		return 0, err
+	}
+
+	source.LastFeedAt = null.TimeFrom(feed.GeneratedAt)
+
+	return len(bans), nil
+}
+
+// verifyFeed checks the signature of a feed body against the base64 encoded public key of its source.
+func verifyFeed(publicKey string, body []byte, signature string) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("source public key is invalid")
+	}
+
+	if signature == "" {
+		return fmt.Errorf("feed is not signed")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("feed signature is invalid")
+	}
+
+	if !ed25519.Verify(key, body, sig) {
+		return fmt.Errorf("feed signature does not match the source public key")
+	}
+
+	return nil
+}
+
+// HandlePlayerJoin alerts staff when a player with bans from alert or enforce level sources joins a server. If any of
+// the bans come from an enforce level source, the one with the most time remaining is enforced using the ban sync
+// commands of the server's game.
+func (s *federationService) HandlePlayerJoin(fields broadcast.Fields, serverID int64, game domain.Game) {
+	ctx, cancel := context.WithTimeout(context.TODO(), s.timeout)
+	defer cancel()
+
+	platform := game.GetPlatform().GetName()
+	playerID := fields["PlayerID"]
+
+	bans, err := s.repo.GetActiveBansByPlayer(ctx, platform, playerID)
+	if err != nil {
+		s.logger.Error("Could not get player federated bans",
+			zap.String("Platform", platform),
+			zap.String("Player ID", playerID),
+			zap.Error(err))
+		return
+	}
+
+	alertBans := make([]*domain.FederatedBan, 0)
+	var enforceBan *domain.FederatedBan
+
+	for _, ban := range bans {
+		if ban.TrustLevel == domain.FederationTrustAdvisory {
+			continue
+		}
+
+		alertBans = append(alertBans, ban)
+
+		if ban.TrustLevel != domain.FederationTrustEnforce {
+			continue
+		}
+
+		if outlasts(ban, enforceBan) {
+			enforceBan = ban
+		}
+	}
+
+	if len(alertBans) == 0 {
+		return
+	}
+
+	alert := &domain.FederatedBanAlert{
+		ServerID:   serverID,
+		Platform:   platform,
+		PlayerID:   playerID,
+		PlayerName: fields["Name"],
+		Bans:       alertBans,
+	}
+
+	if enforceBan != nil {
+		if err := s.enforceBan(ctx, enforceBan, fields["Name"], serverID, game); err != nil {
+			s.logger.Error("Could not enforce federated ban",
+				zap.String("Platform", platform),
+				zap.String("Player ID", playerID),
+				zap.Int64("Source ID", enforceBan.SourceID),
+				zap.Error(err))
+		} else {
+			alert.Enforced = true
+		}
+	}
+
+	for _, sub := range s.alertSubs {
+		sub(alert)
+	}
+}
+
+// outlasts returns true if ban a ends after ban b. A nil ban b is always outlasted.
+func outlasts(a, b *domain.FederatedBan) bool {
+	if b == nil {
+		return true
+	}
+
+	if b.Duration == domain.PermanentInfractionValue {
+		return false
+	}
+
+	return a.Duration == domain.PermanentInfractionValue || a.MinutesRemaining() > b.MinutesRemaining()
+}
+
+func (s *federationService) enforceBan(ctx context.Context, ban *domain.FederatedBan, name string, serverID int64,
+	game domain.Game) error {
+	durationRemaining := ban.MinutesRemaining()
+
+	if ban.Duration == domain.PermanentInfractionValue {
+		durationRemaining = game.GetConfig().PermanentDurationValue
+	} else if durationRemaining < 2 {
+		// Same as regular ban sync, bans which are almost over are not worth re-applying
+		return nil
+	}
+
+	preparedCommands, err := s.commandExecutor.PrepareInfractionCommands(ctx, &domain.CustomInfractionPayload{
+		PlayerID:          ban.PlayerID,
+		Platform:          ban.Platform,
+		PlayerName:        name,
+		Type:              domain.InfractionTypeBan,
+		Duration:          ban.Duration,
+		DurationRemaining: durationRemaining,
+		Reason:            fmt.Sprintf("Federated ban from %s", ban.SourceName),
+	}, domain.InfractionCommandSync, serverID)
+	if err != nil {
+		return err
+	}
+
+	return s.commandExecutor.QueueCommands(preparedCommands)
+}
+
+func (s *federationService) SubscribeFederatedBanAlert(sub domain.FederatedBanSubscriber) {
+	s.alertSubs = append(s.alertSubs, sub)
+}
@@ -0,0 +1,363 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/domain"
+	"Refractor/domain/mocks"
+	"Refractor/pkg/broadcast"
+	"Refractor/platforms/playfab"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/franela/goblin"
+	"github.com/guregu/null"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var seed = make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	var signingKey = base64.StdEncoding.EncodeToString(seed)
+	var publicKey = base64.StdEncoding.EncodeToString(ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey))
+	var feedKey = "partner-feed-key"
+
+	g.Describe("Federation Service", func() {
+		var mockRepo *mocks.FederationRepo
+		var commandExecutor *mocks.CommandExecutor
+		var service *federationService
+		var ctx = context.TODO()
+
+		g.BeforeEach(func() {
+			mockRepo = new(mocks.FederationRepo)
+			commandExecutor = new(mocks.CommandExecutor)
+
+			s, err := NewFederationService(mockRepo, commandExecutor, signingKey, feedKey, "Partner", time.Second*2,
+				zap.NewNop())
+			Expect(err).To(BeNil())
+
+			service = s.(*federationService)
+		})
+
+		g.Describe("NewFederationService()", func() {
+			g.It("Should return an error if the signing key is invalid", func() {
+				_, err := NewFederationService(mockRepo, commandExecutor, "bm90IGEga2V5", feedKey, "", time.Second,
+					zap.NewNop())
+
+				Expect(err).ToNot(BeNil())
+			})
+
+			g.It("Should return an error if the feed key is too short", func() {
+				_, err := NewFederationService(mockRepo, commandExecutor, signingKey, "short", "", time.Second,
+					zap.NewNop())
+
+				Expect(err).ToNot(BeNil())
+			})
+
+			g.It("Should disable the feed if no signing key is set", func() {
+				s, err := NewFederationService(mockRepo, commandExecutor, "", feedKey, "", time.Second, zap.NewNop())
+				Expect(err).To(BeNil())
+
+				_, _, err = s.GetFeed(ctx, feedKey)
+				Expect(err).To(Equal(errFeedDisabled))
+			})
+
+			g.It("Should disable the feed if no feed key is set", func() {
+				s, err := NewFederationService(mockRepo, commandExecutor, signingKey, "", "", time.Second, zap.NewNop())
+				Expect(err).To(BeNil())
+
+				_, _, err = s.GetFeed(ctx, "")
+				Expect(err).To(Equal(errFeedDisabled))
+			})
+		})
+
+		g.Describe("GetFeed()", func() {
+			g.It("Should return a feed signed with the instance key", func() {
+				mockRepo.On("GetExportBans", mock.Anything).Return([]*domain.FederatedBanEntry{
+					{ID: 1, Platform: "playfab", PlayerID: "p1", Reason: "Cheating", Duration: -1},
+				}, nil)
+
+				body, signature, err := service.GetFeed(ctx, feedKey)
+
+				Expect(err).To(BeNil())
+				Expect(verifyFeed(publicKey, body, signature)).To(BeNil())
+
+				feed := &domain.FederatedBanFeed{}
+				Expect(json.Unmarshal(body, feed)).To(BeNil())
+				Expect(feed.Instance).To(Equal("Partner"))
+				Expect(feed.Bans).To(HaveLen(1))
+			})
+
+			g.It("Should return an error if the feed key does not match", func() {
+				_, _, err := service.GetFeed(ctx, "wrong-feed-key-value")
+
+				Expect(err).To(Equal(errInvalidFeedKey))
+				mockRepo.AssertNotCalled(t, "GetExportBans", mock.Anything)
+			})
+		})
+
+		g.Describe("SyncSource()", func() {
+			// partner is a second instance which serves its feed over HTTP
+			var partnerRepo *mocks.FederationRepo
+			var partner domain.FederationService
+			var server *httptest.Server
+			var tamper bool
+			var source *domain.FederationSource
+			var createdAt time.Time
+			var replaceErr error
+
+			g.BeforeEach(func() {
+				createdAt = time.Now().UTC().Truncate(time.Second)
+				tamper = false
+				replaceErr = nil
+
+				partnerRepo = new(mocks.FederationRepo)
+				partnerRepo.On("GetExportBans", mock.Anything).Return([]*domain.FederatedBanEntry{
+					{ID: 1, Platform: "PlayFab", PlayerID: "p1", Reason: "Cheating", Duration: -1, CreatedAt: createdAt},
+					{ID: 2, Platform: "playfab", PlayerID: "p2", Duration: 60, CreatedAt: createdAt},
+					{ID: 3, Platform: "", PlayerID: "p3", Duration: 60, CreatedAt: createdAt},
+				}, nil)
+
+				var err error
+				partner, err = NewFederationService(partnerRepo, nil, signingKey, feedKey, "Partner", time.Second*2,
+					zap.NewNop())
+				Expect(err).To(BeNil())
+
+				server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					body, signature, err := partner.GetFeed(r.Context(), r.Header.Get(domain.FederationFeedKeyHeader))
+					if err != nil {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+
+					if tamper {
+						body = append(body, ' ')
+					}
+
+					w.Header().Set(domain.FederationSignatureHeader, signature)
+					_, _ = w.Write(body)
+				}))
+
+				source = &domain.FederationSource{
+					SourceID:   4,
+					Name:       "Partner",
+					FeedURL:    server.URL,
+					PublicKey:  publicKey,
+					Instance:   "Partner",
+					FeedKey:    feedKey,
+					TrustLevel: domain.FederationTrustEnforce,
+					Enabled:    true,
+				}
+
+				mockRepo.On("GetSourceByID", mock.Anything, int64(4)).Return(source, nil)
+				mockRepo.On("SetSyncResult", mock.Anything, int64(4), mock.Anything, mock.Anything).Return(nil)
+				mockRepo.On("ReplaceBans", mock.Anything, int64(4), mock.Anything, mock.Anything).Return(
+					func(context.Context, int64, time.Time, []*domain.FederatedBan) error {
+						return replaceErr
+					})
+			})
+
+			g.AfterEach(func() {
+				server.Close()
+			})
+
+			g.It("Should import the valid bans from the partner feed", func() {
+				synced, err := service.SyncSource(ctx, 4)
+
+				Expect(err).To(BeNil())
+				Expect(synced.LastSyncError.Valid).To(BeFalse())
+				Expect(synced.LastSyncedAt.Valid).To(BeTrue())
+				Expect(synced.LastFeedAt.Valid).To(BeTrue())
+
+				mockRepo.AssertCalled(t, "ReplaceBans", mock.Anything, int64(4), synced.LastFeedAt.Time, []*domain.FederatedBan{
+					{SourceID: 4, RemoteID: 1, Platform: "playfab", PlayerID: "p1", Reason: null.StringFrom("Cheating"),
+						Duration: -1, RemoteCreatedAt: createdAt},
+					{SourceID: 4, RemoteID: 2, Platform: "playfab", PlayerID: "p2", Duration: 60,
+						RemoteCreatedAt: createdAt},
+				})
+				mockRepo.AssertCalled(t, "SetSyncResult", mock.Anything, int64(4), mock.Anything, null.String{})
+			})
+
+			g.It("Should not import a feed with an invalid signature", func() {
+				tamper = true
+
+				synced, err := service.SyncSource(ctx, 4)
+
+				Expect(err).To(BeNil())
+				Expect(synced.LastSyncError.Valid).To(BeTrue())
+				mockRepo.AssertNotCalled(t, "ReplaceBans", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			})
+
+			g.It("Should not import a feed signed by a different key", func() {
+				otherKey := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)).Public().(ed25519.PublicKey)
+				source.PublicKey = base64.StdEncoding.EncodeToString(otherKey)
+
+				synced, err := service.SyncSource(ctx, 4)
+
+				Expect(err).To(BeNil())
+				Expect(synced.LastSyncError.Valid).To(BeTrue())
+				mockRepo.AssertNotCalled(t, "ReplaceBans", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			})
+
+			g.It("Should not import a feed if the partner rejects the feed key", func() {
+				source.FeedKey = "wrong-feed-key-value"
+
+				synced, err := service.SyncSource(ctx, 4)
+
+				Expect(err).To(BeNil())
+				Expect(synced.LastSyncError.Valid).To(BeTrue())
+				mockRepo.AssertNotCalled(t, "ReplaceBans", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			})
+
+			g.It("Should not import a feed from a different instance", func() {
+				source.Instance = "Other"
+
+				synced, err := service.SyncSource(ctx, 4)
+
+				Expect(err).To(BeNil())
+				Expect(synced.LastSyncError.Valid).To(BeTrue())
+				mockRepo.AssertNotCalled(t, "ReplaceBans", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			})
+
+			g.It("Should not import a feed older than the last imported feed", func() {
+				source.LastFeedAt = null.TimeFrom(time.Now().Add(time.Minute))
+
+				synced, err := service.SyncSource(ctx, 4)
+
+				Expect(err).To(BeNil())
+				Expect(synced.LastSyncError).To(Equal(null.StringFrom(errStaleFeed.Error())))
+				mockRepo.AssertNotCalled(t, "ReplaceBans", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			})
+
+			g.It("Should record a stale feed if a newer feed was imported concurrently", func() {
+				replaceErr = domain.ErrConflict
+
+				synced, err := service.SyncSource(ctx, 4)
+
+				Expect(err).To(BeNil())
+				Expect(synced.LastSyncError).To(Equal(null.StringFrom(errStaleFeed.Error())))
+				Expect(synced.LastFeedAt.Valid).To(BeFalse())
+			})
+		})
+
+		g.Describe("HandlePlayerJoin()", func() {
+			var game *mocks.Game
+			var fields broadcast.Fields
+			var alerts []*domain.FederatedBanAlert
+
+			g.BeforeEach(func() {
+				game = new(mocks.Game)
+				game.On("GetPlatform").Return(playfab.NewPlayfabPlatform())
+				game.On("GetConfig").Return(&domain.GameConfig{PermanentDurationValue: 99999999})
+
+				fields = broadcast.Fields{
+					"PlayerID": "p1",
+					"Name":     "Player",
+				}
+
+				alerts = []*domain.FederatedBanAlert{}
+				service.SubscribeFederatedBanAlert(func(alert *domain.FederatedBanAlert) {
+					alerts = append(alerts, alert)
+				})
+			})
+
+			g.Describe("Player has an enforce level ban", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("GetActiveBansByPlayer", mock.Anything, "playfab", "p1").Return([]*domain.FederatedBan{
+						{SourceID: 1, Platform: "playfab", PlayerID: "p1", Duration: 60, RemoteCreatedAt: time.Now(),
+							SourceName: "A", TrustLevel: domain.FederationTrustEnforce},
+						{SourceID: 2, Platform: "playfab", PlayerID: "p1", Duration: -1, RemoteCreatedAt: time.Now(),
+							SourceName: "B", TrustLevel: domain.FederationTrustEnforce},
+						{SourceID: 3, Platform: "playfab", PlayerID: "p1", Duration: -1, RemoteCreatedAt: time.Now(),
+							SourceName: "C", TrustLevel: domain.FederationTrustAdvisory},
+					}, nil)
+
+					commandExecutor.On("PrepareInfractionCommands", mock.Anything, mock.Anything,
+						domain.InfractionCommandSync, int64(5)).Return(new(mocks.CommandPayload), nil)
+					commandExecutor.On("QueueCommands", mock.Anything).Return(nil)
+				})
+
+				g.It("Should enforce the ban with the most time remaining", func() {
+					service.HandlePlayerJoin(fields, 5, game)
+
+					commandExecutor.AssertCalled(t, "PrepareInfractionCommands", mock.Anything,
+						mock.MatchedBy(func(p *domain.CustomInfractionPayload) bool {
+							return p.Type == domain.InfractionTypeBan && p.DurationRemaining == 99999999 &&
+								p.Reason == "Federated ban from B"
+						}), domain.InfractionCommandSync, int64(5))
+					commandExecutor.AssertCalled(t, "QueueCommands", mock.Anything)
+				})
+
+				g.It("Should alert subscribers without advisory bans", func() {
+					service.HandlePlayerJoin(fields, 5, game)
+
+					Expect(alerts).To(HaveLen(1))
+					Expect(alerts[0].Enforced).To(BeTrue())
+					Expect(alerts[0].Bans).To(HaveLen(2))
+				})
+			})
+
+			g.Describe("Player has an alert level ban", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("GetActiveBansByPlayer", mock.Anything, "playfab", "p1").Return([]*domain.FederatedBan{
+						{SourceID: 1, Platform: "playfab", PlayerID: "p1", Duration: -1, RemoteCreatedAt: time.Now(),
+							SourceName: "A", TrustLevel: domain.FederationTrustAlert},
+					}, nil)
+				})
+
+				g.It("Should alert subscribers without enforcing the ban", func() {
+					service.HandlePlayerJoin(fields, 5, game)
+
+					Expect(alerts).To(HaveLen(1))
+					Expect(alerts[0].Enforced).To(BeFalse())
+					commandExecutor.AssertNotCalled(t, "PrepareInfractionCommands", mock.Anything, mock.Anything,
+						mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Player only has advisory bans", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("GetActiveBansByPlayer", mock.Anything, "playfab", "p1").Return([]*domain.FederatedBan{
+						{SourceID: 1, Platform: "playfab", PlayerID: "p1", Duration: -1, RemoteCreatedAt: time.Now(),
+							SourceName: "A", TrustLevel: domain.FederationTrustAdvisory},
+					}, nil)
+				})
+
+				g.It("Should not alert subscribers", func() {
+					service.HandlePlayerJoin(fields, 5, game)
+
+					Expect(alerts).To(BeEmpty())
+				})
+			})
+		})
+	})
+}
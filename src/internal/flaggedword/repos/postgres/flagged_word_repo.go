@@ -19,17 +19,25 @@ package postgres
 
 import (
 	"Refractor/domain"
+	"Refractor/pkg/querybuilders/psqlqb"
 	"context"
 	"database/sql"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
 const opTag = "FlaggedWordRepo.Postgres."
 
+const (
+	pgUniqueViolationCode     = "23505"
+	pgForeignKeyViolationCode = "23503"
+)
+
 type repo struct {
 	db           *sql.DB
 	logger       *zap.Logger
+	qb           domain.QueryBuilder
 	flaggedWords map[int64]*domain.FlaggedWord
 }
 
@@ -37,6 +45,7 @@ func NewFlaggedWordRepo(db *sql.DB, log *zap.Logger) domain.FlaggedWordRepo {
 	repo := &repo{
 		db:           db,
 		logger:       log,
+		qb:           psqlqb.NewPostgresQueryBuilder(),
 		flaggedWords: map[int64]*domain.FlaggedWord{},
 	}
 
@@ -84,7 +93,8 @@ func (r *repo) fetch(ctx context.Context, query string, args ...interface{}) ([]
 func (r *repo) Store(ctx context.Context, word *domain.FlaggedWord) error {
 	const op = opTag + "Store"
 
-	query := `INSERT INTO FlaggedWords (Word) VALUES ($1) RETURNING WordID;`
+	query := `INSERT INTO FlaggedWords (Word, Mode, MaxDistance, Severity, Action, ActionDuration, Channels)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING WordID;`
 
 	stmt, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
@@ -92,7 +102,8 @@ func (r *repo) Store(ctx context.Context, word *domain.FlaggedWord) error {
 		return errors.Wrap(err, op)
 	}
 
-	row := stmt.QueryRowContext(ctx, word.Word)
+	row := stmt.QueryRowContext(ctx, word.Word, word.Mode, word.MaxDistance, word.Severity, word.Action,
+		word.ActionDuration, pq.Array(word.Channels))
 
 	var id int64
 	if err := row.Scan(&id); err != nil {
@@ -122,8 +133,14 @@ func (r *repo) GetAll(ctx context.Context) ([]*domain.FlaggedWord, error) {
 	}
 
 	if len(results) > 0 {
+		exemptions, err := r.getExemptions(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+
 		// Cache results
 		for _, result := range results {
+			result.ExemptServers = exemptions[result.ID]
 			r.flaggedWords[result.ID] = result
 		}
 
@@ -133,10 +150,14 @@ func (r *repo) GetAll(ctx context.Context) ([]*domain.FlaggedWord, error) {
 	return nil, errors.Wrap(domain.ErrNotFound, op)
 }
 
-func (r *repo) Update(ctx context.Context, id int64, newWord string) (*domain.FlaggedWord, error) {
+func (r *repo) Update(ctx context.Context, id int64, args domain.UpdateArgs) (*domain.FlaggedWord, error) {
 	const op = opTag + "Update"
 
-	query := "UPDATE FlaggedWords SET Word = $1 WHERE WordID = $2 RETURNING *;"
+	if channels, ok := args["Channels"].([]string); ok {
+		args["Channels"] = pq.Array(channels)
+	}
+
+	query, values := r.qb.BuildUpdateQuery("FlaggedWords", id, "WordID", args, nil)
 
 	stmt, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
@@ -144,7 +165,7 @@ func (r *repo) Update(ctx context.Context, id int64, newWord string) (*domain.Fl
 		return nil, errors.Wrap(err, op)
 	}
 
-	row := stmt.QueryRowContext(ctx, newWord, id)
+	row := stmt.QueryRowContext(ctx, values...)
 
 	updated := &domain.FlaggedWord{}
 	if err := r.scanRow(row, updated); err != nil {
@@ -156,6 +177,11 @@ func (r *repo) Update(ctx context.Context, id int64, newWord string) (*domain.Fl
 		return nil, errors.Wrap(err, op)
 	}
 
+	// Exemptions are not part of the row so they are carried over from the cached word
+	if cached, ok := r.flaggedWords[updated.ID]; ok {
+		updated.ExemptServers = cached.ExemptServers
+	}
+
 	// Update in cache
 	r.flaggedWords[updated.ID] = updated
 
@@ -189,6 +215,107 @@ func (r *repo) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
+// getExemptions returns the exempt server IDs of every flagged word, keyed by the ID of the flagged word.
+func (r *repo) getExemptions(ctx context.Context) (map[int64][]int64, error) {
+	const op = opTag + "GetExemptions"
+
+	query := "SELECT WordID, ServerID FROM FlaggedWordExemptions;"
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	// Clean up on function exit
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	exemptions := map[int64][]int64{}
+	for rows.Next() {
+		var wordID, serverID int64
+
+		if err := rows.Scan(&wordID, &serverID); err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+
+		exemptions[wordID] = append(exemptions[wordID], serverID)
+	}
+
+	return exemptions, nil
+}
+
+func (r *repo) AddExemption(ctx context.Context, id, serverID int64) error {
+	const op = opTag + "AddExemption"
+
+	query := "INSERT INTO FlaggedWordExemptions (WordID, ServerID) VALUES ($1, $2);"
+
+	if _, err := r.db.ExecContext(ctx, query, id, serverID); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok {
+			switch pgErr.Code {
+			case pgUniqueViolationCode:
+				return errors.Wrap(domain.ErrConflict, op)
+			case pgForeignKeyViolationCode:
+				// either the flagged word or the server does not exist
+				return errors.Wrap(domain.ErrNotFound, op)
+			}
+		}
+
+		r.logger.Error("Could not insert flagged word exemption",
+			zap.Int64("Word ID", id),
+			zap.Int64("Server ID", serverID),
+			zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	// Update in cache
+	if cached, ok := r.flaggedWords[id]; ok {
+		cached.ExemptServers = append(cached.ExemptServers, serverID)
+	}
+
+	return nil
+}
+
+func (r *repo) RemoveExemption(ctx context.Context, id, serverID int64) error {
+	const op = opTag + "RemoveExemption"
+
+	query := "DELETE FROM FlaggedWordExemptions WHERE WordID = $1 AND ServerID = $2;"
+
+	res, err := r.db.ExecContext(ctx, query, id, serverID)
+	if err != nil {
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Could not get affected rows", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	if rowsAffected < 1 {
+		return errors.Wrap(domain.ErrNotFound, op)
+	}
+
+	// Update in cache
+	if cached, ok := r.flaggedWords[id]; ok {
+		remaining := make([]int64, 0, len(cached.ExemptServers))
+		for _, exempt := range cached.ExemptServers {
+			if exempt != serverID {
+				remaining = append(remaining, exempt)
+			}
+		}
+
+		cached.ExemptServers = remaining
+	}
+
+	return nil
+}
+
 func (r *repo) getFromCache() []*domain.FlaggedWord {
 	var words []*domain.FlaggedWord
 
@@ -201,9 +328,11 @@ func (r *repo) getFromCache() []*domain.FlaggedWord {
 
 // Scan helpers
 func (r *repo) scanRow(row *sql.Row, fw *domain.FlaggedWord) error {
-	return row.Scan(&fw.ID, &fw.Word)
+	return row.Scan(&fw.ID, &fw.Word, &fw.Mode, &fw.MaxDistance, &fw.Severity, &fw.Action, &fw.ActionDuration,
+		pq.Array(&fw.Channels))
 }
 
 func (r *repo) scanRows(rows *sql.Rows, fw *domain.FlaggedWord) error {
-	return rows.Scan(&fw.ID, &fw.Word)
+	return rows.Scan(&fw.ID, &fw.Word, &fw.Mode, &fw.MaxDistance, &fw.Severity, &fw.Action, &fw.ActionDuration,
+		pq.Array(&fw.Channels))
 }
@@ -24,10 +24,12 @@ import (
 	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/franela/goblin"
+	"github.com/lib/pq"
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -37,7 +39,8 @@ func Test(t *testing.T) {
 	// Special hook for gomega
 	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
 
-	var cols = []string{"WordID", "Word"}
+	var cols = []string{"WordID", "Word", "Mode", "MaxDistance", "Severity", "Action", "ActionDuration",
+		"Channels"}
 	var ctx = context.TODO()
 
 	g.Describe("Postgres Flagged Words Repo", func() {
@@ -111,8 +114,11 @@ func Test(t *testing.T) {
 				g.BeforeEach(func() {
 					expected = []*domain.FlaggedWord{
 						{
-							ID:   1,
-							Word: "word1",
+							ID:            1,
+							Word:          "word1",
+							Action:        domain.FlaggedWordActionMute,
+							Channels:      []string{domain.ChatChannelAll},
+							ExemptServers: []int64{2, 3},
 						},
 						{
 							ID:   2,
@@ -138,10 +144,17 @@ func Test(t *testing.T) {
 
 					rows := sqlmock.NewRows(cols)
 					for _, fw := range expected {
-						rows.AddRow(fw.ID, fw.Word)
+						if fw.Channels == nil {
+							fw.Channels = []string{}
+						}
+
+						rows.AddRow(fw.ID, fw.Word, fw.Mode, fw.MaxDistance, fw.Severity, fw.Action, fw.ActionDuration,
+							"{"+strings.Join(fw.Channels, ",")+"}")
 					}
 
 					mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM FlaggedWords")).WillReturnRows(rows)
+					mock.ExpectQuery("SELECT WordID, ServerID FROM FlaggedWordExemptions").WillReturnRows(
+						sqlmock.NewRows([]string{"WordID", "ServerID"}).AddRow(1, 2).AddRow(1, 3))
 				})
 
 				g.It("Should not return an error", func() {
@@ -160,6 +173,21 @@ func Test(t *testing.T) {
 				})
 			})
 
+			g.Describe("Exemptions database error", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM FlaggedWords")).WillReturnRows(sqlmock.NewRows(cols).
+						AddRow(1, "word", "", 0, "", "", 0, "{}"))
+					mock.ExpectQuery("SELECT WordID, ServerID FROM FlaggedWordExemptions").WillReturnError(fmt.Errorf("err"))
+				})
+
+				g.It("Should return an error", func() {
+					_, err := repo.GetAll(ctx)
+
+					Expect(err).ToNot(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
 			g.Describe("No results found", func() {
 				g.BeforeEach(func() {
 					mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM FlaggedWords")).WillReturnRows(sqlmock.NewRows(cols))
@@ -197,23 +225,27 @@ func Test(t *testing.T) {
 
 				g.BeforeEach(func() {
 					updated = &domain.FlaggedWord{
-						ID:   2,
-						Word: "updated word",
+						ID:          2,
+						Word:        "updated word",
+						Mode:        domain.FlaggedWordModeFuzzy,
+						MaxDistance: 1,
+						Channels:    []string{domain.ChatChannelTeam},
 					}
 
 					mock.ExpectQuery("UPDATE FlaggedWords SET").WillReturnRows(sqlmock.NewRows(cols).
-						AddRow(updated.ID, updated.Word))
+						AddRow(updated.ID, updated.Word, updated.Mode, updated.MaxDistance, updated.Severity, updated.Action,
+							updated.ActionDuration, "{team}"))
 				})
 
 				g.It("Should not return an error", func() {
-					_, err := repo.Update(ctx, updated.ID, updated.Word)
+					_, err := repo.Update(ctx, updated.ID, domain.UpdateArgs{"Word": updated.Word})
 
 					Expect(err).To(BeNil())
 					Expect(mock.ExpectationsWereMet()).To(BeNil())
 				})
 
 				g.It("Should scan and return the correct infraction", func() {
-					got, err := repo.Update(ctx, updated.ID, updated.Word)
+					got, err := repo.Update(ctx, updated.ID, domain.UpdateArgs{"Word": updated.Word})
 
 					Expect(err).To(BeNil())
 					Expect(got).To(Equal(updated))
@@ -227,7 +259,7 @@ func Test(t *testing.T) {
 				})
 
 				g.It("Should return a doamin.ErrNotFound error and a nil FlaggedWord", func() {
-					got, err := repo.Update(ctx, 1, "")
+					got, err := repo.Update(ctx, 1, domain.UpdateArgs{"Word": ""})
 
 					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
 					Expect(got).To(BeNil())
@@ -263,5 +295,78 @@ func Test(t *testing.T) {
 				})
 			})
 		})
+
+		g.Describe("AddExemption()", func() {
+			g.Describe("Successful insert", func() {
+				g.BeforeEach(func() {
+					mock.ExpectExec("INSERT INTO FlaggedWordExemptions").WithArgs(1, 2).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				})
+
+				g.It("Should not return an error", func() {
+					err := repo.AddExemption(ctx, 1, 2)
+
+					Expect(err).To(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Server already exempt", func() {
+				g.BeforeEach(func() {
+					mock.ExpectExec("INSERT INTO FlaggedWordExemptions").
+						WillReturnError(&pq.Error{Code: pgUniqueViolationCode})
+				})
+
+				g.It("Should return a domain.ErrConflict error", func() {
+					err := repo.AddExemption(ctx, 1, 2)
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrConflict))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Flagged word or server does not exist", func() {
+				g.BeforeEach(func() {
+					mock.ExpectExec("INSERT INTO FlaggedWordExemptions").
+						WillReturnError(&pq.Error{Code: pgForeignKeyViolationCode})
+				})
+
+				g.It("Should return a domain.ErrNotFound error", func() {
+					err := repo.AddExemption(ctx, 1, 2)
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("RemoveExemption()", func() {
+			g.Describe("Successful delete", func() {
+				g.BeforeEach(func() {
+					mock.ExpectExec("DELETE FROM FlaggedWordExemptions").WithArgs(1, 2).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				})
+
+				g.It("Should not return an error", func() {
+					err := repo.RemoveExemption(ctx, 1, 2)
+
+					Expect(err).To(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Exemption not found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectExec("DELETE FROM FlaggedWordExemptions").WillReturnResult(sqlmock.NewResult(0, 0))
+				})
+
+				g.It("Should return a domain.ErrNotFound error", func() {
+					err := repo.RemoveExemption(ctx, 1, 2)
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
 	})
 }
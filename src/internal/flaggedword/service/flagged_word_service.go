@@ -20,9 +20,12 @@ package service
 import (
 	"Refractor/domain"
 	"context"
+	"fmt"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
-	"strings"
+	"net/http"
+	"regexp"
+	"sync"
 	"time"
 )
 
@@ -30,6 +33,11 @@ type flaggedWordService struct {
 	repo    domain.FlaggedWordRepo
 	timeout time.Duration
 	logger  *zap.Logger
+
+	// matcher is compiled from all flagged words the first time a message is checked. It is rebuilt every time a
+	// flagged word is changed.
+	matcher     *matcher
+	matcherLock sync.RWMutex
 }
 
 func NewFlaggedWordService(repo domain.FlaggedWordRepo, to time.Duration, log *zap.Logger) domain.FlaggedWordService {
@@ -44,7 +52,33 @@ func (s *flaggedWordService) Store(c context.Context, word *domain.FlaggedWord)
 	ctx, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
-	return s.repo.Store(ctx, word)
+	if word.Mode == "" {
+		word.Mode = domain.FlaggedWordModeExact
+	}
+
+	if word.Severity == "" {
+		word.Severity = domain.FlaggedWordSeverityLow
+	}
+
+	if word.Action == "" {
+		word.Action = domain.FlaggedWordActionFlag
+	}
+
+	if word.Channels == nil {
+		word.Channels = []string{}
+	}
+
+	if err := validateFlaggedWord(word); err != nil {
+		return err
+	}
+
+	if err := s.repo.Store(ctx, word); err != nil {
+		return err
+	}
+
+	s.refreshMatcher(ctx)
+
+	return nil
 }
 
 func (s *flaggedWordService) GetAll(c context.Context) ([]*domain.FlaggedWord, error) {
@@ -63,45 +97,253 @@ func (s *flaggedWordService) GetAll(c context.Context) ([]*domain.FlaggedWord, e
 	return flaggedWords, nil
 }
 
-func (s *flaggedWordService) Update(c context.Context, id int64, newWord string) (*domain.FlaggedWord, error) {
+func (s *flaggedWordService) Update(c context.Context, id int64, args domain.UpdateArgs) (*domain.FlaggedWord, error) {
 	ctx, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
-	return s.repo.Update(ctx, id, newWord)
+	if len(args) < 1 {
+		return nil, &domain.HTTPError{
+			Success:          false,
+			Message:          "No updatable fields were provided",
+			ValidationErrors: nil,
+			Status:           http.StatusBadRequest,
+		}
+	}
+
+	// Validate the flagged word as it will be after the update, since a new word might not be valid for the current mode
+	// or the other way around.
+	current, err := s.getByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := *current
+	if word, ok := args["Word"].(*string); ok {
+		merged.Word = *word
+	}
+
+	if mode, ok := args["Mode"].(*string); ok {
+		merged.Mode = *mode
+	}
+
+	if maxDistance, ok := args["MaxDistance"].(*int); ok {
+		merged.MaxDistance = *maxDistance
+	}
+
+	if action, ok := args["Action"].(*string); ok {
+		merged.Action = *action
+	}
+
+	if actionDuration, ok := args["ActionDuration"].(*int); ok {
+		merged.ActionDuration = *actionDuration
+	}
+
+	if err := validateFlaggedWord(&merged); err != nil {
+		return nil, err
+	}
+
+	// Fields reset by validation have to be written as well
+	if merged.MaxDistance != current.MaxDistance {
+		args["MaxDistance"] = merged.MaxDistance
+	}
+
+	if merged.ActionDuration != current.ActionDuration {
+		args["ActionDuration"] = merged.ActionDuration
+	}
+
+	if channels, ok := args["Channels"].(*[]string); ok {
+		args["Channels"] = *channels
+	}
+
+	updated, err := s.repo.Update(ctx, id, args)
+	if err != nil {
+		return nil, err
+	}
+
+	s.refreshMatcher(ctx)
+
+	return updated, nil
+}
+
+func (s *flaggedWordService) getByID(ctx context.Context, id int64) (*domain.FlaggedWord, error) {
+	flaggedWords, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, word := range flaggedWords {
+		if word.ID == id {
+			return word, nil
+		}
+	}
+
+	return nil, domain.ErrNotFound
 }
 
 func (s *flaggedWordService) Delete(c context.Context, id int64) error {
 	ctx, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
-	return s.repo.Delete(ctx, id)
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.refreshMatcher(ctx)
+
+	return nil
+}
+
+// AddExemption exempts a server from a flagged word, so that messages sent on the server are not matched against it.
+func (s *flaggedWordService) AddExemption(c context.Context, id, serverID int64) error {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if err := s.repo.AddExemption(ctx, id, serverID); err != nil {
+		switch errors.Cause(err) {
+		case domain.ErrConflict:
+			return &domain.HTTPError{
+				Success: false,
+				Message: "Input errors exist",
+				ValidationErrors: map[string]string{
+					"server_id": "server is already exempt from this flagged word",
+				},
+				Status: http.StatusBadRequest,
+			}
+		case domain.ErrNotFound:
+			return &domain.HTTPError{
+				Success:          false,
+				Message:          "Flagged word or server not found",
+				ValidationErrors: nil,
+				Status:           http.StatusNotFound,
+			}
+		}
+
+		return err
+	}
+
+	s.refreshMatcher(ctx)
+
+	return nil
 }
 
-func (s *flaggedWordService) MessageContainsFlaggedWord(c context.Context, message string) (bool, error) {
+func (s *flaggedWordService) RemoveExemption(c context.Context, id, serverID int64) error {
 	ctx, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
+	if err := s.repo.RemoveExemption(ctx, id, serverID); err != nil {
+		return err
+	}
+
+	s.refreshMatcher(ctx)
+
+	return nil
+}
+
+// validateFlaggedWord checks the parts of a flagged word which depend on its mode and action. Modes other than fuzzy do
+// not use MaxDistance and actions other than mute do not use ActionDuration, so they are reset for them.
+func validateFlaggedWord(word *domain.FlaggedWord) error {
+	validationErrors := map[string]string{}
+
+	switch word.Mode {
+	case domain.FlaggedWordModeRegex:
+		if _, err := regexp.Compile(word.Word); err != nil {
+			validationErrors["word"] = "must be a valid regular expression"
+		}
+	case domain.FlaggedWordModeFuzzy:
+		if limit := maxFuzzyDistance(word.Word); limit < 1 {
+			validationErrors["word"] = "must be at least 4 characters long for fuzzy matching"
+		} else if word.MaxDistance < 1 || word.MaxDistance > limit {
+			validationErrors["max_distance"] = fmt.Sprintf("must be between 1 and %d for fuzzy matching of this word",
+				limit)
+		}
+	}
+
+	if word.Mode != domain.FlaggedWordModeFuzzy {
+		word.MaxDistance = 0
+	}
+
+	if word.Action == domain.FlaggedWordActionMute {
+		if word.ActionDuration < 1 {
+			validationErrors["action_duration"] = "must be at least 1 minute for automatic mutes"
+		}
+	} else {
+		word.ActionDuration = 0
+	}
+
+	if len(validationErrors) > 0 {
+		return &domain.HTTPError{
+			Success:          false,
+			Message:          "Input errors exist",
+			ValidationErrors: validationErrors,
+			Status:           http.StatusBadRequest,
+		}
+	}
+
+	return nil
+}
+
+// refreshMatcher rebuilds the matcher from the current flagged words. If they can't be fetched, the matcher is
+// discarded so that it is rebuilt the next time a message is checked.
+func (s *flaggedWordService) refreshMatcher(ctx context.Context) {
+	m, err := s.buildMatcher(ctx)
+	if err != nil {
+		s.logger.Error("Could not rebuild flagged word matcher", zap.Error(err))
+	}
+
+	s.matcherLock.Lock()
+	s.matcher = m
+	s.matcherLock.Unlock()
+}
+
+func (s *flaggedWordService) buildMatcher(ctx context.Context) (*matcher, error) {
 	flaggedWords, err := s.repo.GetAll(ctx)
 	if err != nil {
-		if errors.Cause(err) == domain.ErrNotFound {
-			return false, nil
+		if errors.Cause(err) != domain.ErrNotFound {
+			return nil, err
 		}
 
-		return false, err
+		flaggedWords = []*domain.FlaggedWord{}
 	}
 
-	words := strings.Split(message, " ")
+	m, invalid := newMatcher(flaggedWords)
+	for _, word := range invalid {
+		s.logger.Warn("Skipping flagged word which could not be compiled",
+			zap.Int64("Word ID", word.ID),
+			zap.String("Mode", word.Mode))
+	}
 
-	flagged := false
-	for _, word := range words {
-		// Check if flagged words contains this word
-		for _, fword := range flaggedWords {
-			if strings.ToLower(word) == strings.ToLower(fword.Word) {
-				flagged = true
-				break
-			}
-		}
+	return m, nil
+}
+
+func (s *flaggedWordService) getMatcher(ctx context.Context) (*matcher, error) {
+	s.matcherLock.RLock()
+	m := s.matcher
+	s.matcherLock.RUnlock()
+
+	if m != nil {
+		return m, nil
+	}
+
+	m, err := s.buildMatcher(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.matcherLock.Lock()
+	s.matcher = m
+	s.matcherLock.Unlock()
+
+	return m, nil
+}
+
+func (s *flaggedWordService) FindFlaggedWords(c context.Context, serverID int64, channel, message string) ([]*domain.FlaggedWordMatch, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	m, err := s.getMatcher(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return flagged, nil
+	return m.match(serverID, channel, message), nil
 }
@@ -27,6 +27,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
+	"net/http"
 	"testing"
 	"time"
 )
@@ -67,6 +68,7 @@ func Test(t *testing.T) {
 				g.BeforeEach(func() {
 					repo.On("Store", mock.Anything, mock.AnythingOfType("*domain.FlaggedWord")).
 						Return(nil)
+					repo.On("GetAll", mock.Anything).Return([]*domain.FlaggedWord{flaggedWord}, nil)
 				})
 
 				g.It("Should not return an error", func() {
@@ -75,6 +77,83 @@ func Test(t *testing.T) {
 					Expect(err).To(BeNil())
 					repo.AssertExpectations(t)
 				})
+
+				g.It("Should default to the exact mode", func() {
+					err := service.Store(ctx, flaggedWord)
+
+					Expect(err).To(BeNil())
+					Expect(flaggedWord.Mode).To(Equal(domain.FlaggedWordModeExact))
+				})
+
+				g.It("Should default to a low severity flag action", func() {
+					err := service.Store(ctx, flaggedWord)
+
+					Expect(err).To(BeNil())
+					Expect(flaggedWord.Severity).To(Equal(domain.FlaggedWordSeverityLow))
+					Expect(flaggedWord.Action).To(Equal(domain.FlaggedWordActionFlag))
+				})
+
+				g.It("Should refresh the matcher", func() {
+					err := service.Store(ctx, flaggedWord)
+
+					Expect(err).To(BeNil())
+					Expect(service.matcher).ToNot(BeNil())
+				})
+			})
+
+			g.Describe("Invalid regular expression", func() {
+				g.It("Should return a bad request HTTP error", func() {
+					err := service.Store(ctx, &domain.FlaggedWord{Word: "bad(word", Mode: domain.FlaggedWordModeRegex})
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.ValidationErrors).To(HaveKey("word"))
+					repo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Mute action without a duration", func() {
+				g.It("Should return a bad request HTTP error", func() {
+					err := service.Store(ctx, &domain.FlaggedWord{Word: "word", Action: domain.FlaggedWordActionMute})
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.ValidationErrors).To(HaveKey("action_duration"))
+					repo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Fuzzy word without a distance", func() {
+				g.It("Should return a bad request HTTP error", func() {
+					err := service.Store(ctx, &domain.FlaggedWord{Word: "word", Mode: domain.FlaggedWordModeFuzzy})
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.ValidationErrors).To(HaveKey("max_distance"))
+					repo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Fuzzy word with a distance too high for its length", func() {
+				g.It("Should return a bad request HTTP error", func() {
+					err := service.Store(ctx, &domain.FlaggedWord{Word: "word", Mode: domain.FlaggedWordModeFuzzy, MaxDistance: 2})
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.ValidationErrors).To(HaveKey("max_distance"))
+					repo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Fuzzy word too short for fuzzy matching", func() {
+				g.It("Should return a bad request HTTP error", func() {
+					err := service.Store(ctx, &domain.FlaggedWord{Word: "ass", Mode: domain.FlaggedWordModeFuzzy, MaxDistance: 1})
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.ValidationErrors).To(HaveKey("word"))
+					repo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
 			})
 
 			g.Describe("Repo error", func() {
@@ -152,30 +231,43 @@ func Test(t *testing.T) {
 		})
 
 		g.Describe("Update()", func() {
+			var current *domain.FlaggedWord
 			var updated *domain.FlaggedWord
+			var args domain.UpdateArgs
 
 			g.BeforeEach(func() {
+				current = &domain.FlaggedWord{
+					ID:   1,
+					Word: "word",
+					Mode: domain.FlaggedWordModeExact,
+				}
+
 				updated = &domain.FlaggedWord{
 					ID:   1,
 					Word: "updated word",
+					Mode: domain.FlaggedWordModeExact,
 				}
+
+				word := "updated word"
+				args = domain.UpdateArgs{"Word": &word}
 			})
 
 			g.Describe("Successful update", func() {
 				g.BeforeEach(func() {
-					repo.On("Update", mock.Anything, mock.AnythingOfType("int64"), mock.AnythingOfType("string")).
+					repo.On("GetAll", mock.Anything).Return([]*domain.FlaggedWord{current}, nil)
+					repo.On("Update", mock.Anything, int64(1), mock.AnythingOfType("domain.UpdateArgs")).
 						Return(updated, nil)
 				})
 
 				g.It("Should not return an error", func() {
-					_, err := service.Update(ctx, updated.ID, "updated word")
+					_, err := service.Update(ctx, updated.ID, args)
 
 					Expect(err).To(BeNil())
 					repo.AssertExpectations(t)
 				})
 
 				g.It("Should not return the updated struct", func() {
-					got, err := service.Update(ctx, updated.ID, "updated word")
+					got, err := service.Update(ctx, updated.ID, args)
 
 					Expect(err).To(BeNil())
 					Expect(got).To(Equal(updated))
@@ -183,14 +275,29 @@ func Test(t *testing.T) {
 				})
 			})
 
+			g.Describe("Mode changed to fuzzy without a distance", func() {
+				g.BeforeEach(func() {
+					repo.On("GetAll", mock.Anything).Return([]*domain.FlaggedWord{current}, nil)
+				})
+
+				g.It("Should return a bad request HTTP error", func() {
+					mode := domain.FlaggedWordModeFuzzy
+					_, err := service.Update(ctx, 1, domain.UpdateArgs{"Mode": &mode})
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.ValidationErrors).To(HaveKey("max_distance"))
+					repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+				})
+			})
+
 			g.Describe("Target not found", func() {
 				g.BeforeEach(func() {
-					repo.On("Update", mock.Anything, mock.AnythingOfType("int64"), mock.AnythingOfType("string")).
-						Return(nil, domain.ErrNotFound)
+					repo.On("GetAll", mock.Anything).Return([]*domain.FlaggedWord{{ID: 2, Word: "other"}}, nil)
 				})
 
 				g.It("Should return a domain.ErrNotfound error", func() {
-					_, err := service.Update(ctx, updated.ID, "updated word")
+					_, err := service.Update(ctx, updated.ID, args)
 
 					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
 					repo.AssertExpectations(t)
@@ -199,18 +306,19 @@ func Test(t *testing.T) {
 
 			g.Describe("Repo error", func() {
 				g.BeforeEach(func() {
+					repo.On("GetAll", mock.Anything).Return([]*domain.FlaggedWord{current}, nil)
 					repo.On("Update", mock.Anything, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("err"))
 				})
 
 				g.It("Should return an error", func() {
-					_, err := service.Update(ctx, 1, "new word")
+					_, err := service.Update(ctx, 1, args)
 
 					Expect(err).ToNot(BeNil())
 					repo.AssertExpectations(t)
 				})
 
 				g.It("Should return nil", func() {
-					got, err := service.Update(ctx, 1, "new word")
+					got, err := service.Update(ctx, 1, args)
 
 					Expect(err).ToNot(BeNil())
 					Expect(got).To(BeNil())
@@ -223,6 +331,7 @@ func Test(t *testing.T) {
 			g.Describe("Successful delete", func() {
 				g.BeforeEach(func() {
 					repo.On("Delete", mock.Anything, mock.Anything).Return(nil)
+					repo.On("GetAll", mock.Anything).Return(nil, domain.ErrNotFound)
 				})
 
 				g.It("Should not return an error", func() {
@@ -260,7 +369,76 @@ func Test(t *testing.T) {
 			})
 		})
 
-		g.Describe("MessageContainsFlaggedWord()", func() {
+		g.Describe("AddExemption()", func() {
+			g.Describe("Successful insert", func() {
+				g.BeforeEach(func() {
+					repo.On("AddExemption", mock.Anything, int64(1), int64(2)).Return(nil)
+					repo.On("GetAll", mock.Anything).Return([]*domain.FlaggedWord{{ID: 1, Word: "word",
+						ExemptServers: []int64{2}}}, nil)
+				})
+
+				g.It("Should not match the word on the exempt server", func() {
+					err := service.AddExemption(ctx, 1, 2)
+					Expect(err).To(BeNil())
+
+					matches, err := service.FindFlaggedWords(ctx, 2, domain.ChatChannelAll, "word")
+					Expect(err).To(BeNil())
+					Expect(matches).To(BeEmpty())
+
+					matches, err = service.FindFlaggedWords(ctx, 1, domain.ChatChannelAll, "word")
+					Expect(err).To(BeNil())
+					Expect(matches).To(HaveLen(1))
+					repo.AssertExpectations(t)
+				})
+			})
+
+			g.Describe("Server already exempt", func() {
+				g.BeforeEach(func() {
+					repo.On("AddExemption", mock.Anything, mock.Anything, mock.Anything).
+						Return(errors.Wrap(domain.ErrConflict, ""))
+				})
+
+				g.It("Should return a bad request HTTP error", func() {
+					err := service.AddExemption(ctx, 1, 2)
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.Status).To(Equal(http.StatusBadRequest))
+					Expect(httpErr.ValidationErrors).To(HaveKey("server_id"))
+				})
+			})
+
+			g.Describe("Flagged word or server not found", func() {
+				g.BeforeEach(func() {
+					repo.On("AddExemption", mock.Anything, mock.Anything, mock.Anything).
+						Return(errors.Wrap(domain.ErrNotFound, ""))
+				})
+
+				g.It("Should return a not found HTTP error", func() {
+					err := service.AddExemption(ctx, 1, 2)
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.Status).To(Equal(http.StatusNotFound))
+				})
+			})
+		})
+
+		g.Describe("FindFlaggedWords()", func() {
+			g.Describe("Matcher was already compiled", func() {
+				g.BeforeEach(func() {
+					service.matcher, _ = newMatcher([]*domain.FlaggedWord{{ID: 1, Word: "test"}})
+				})
+
+				g.It("Should not query the repo", func() {
+					matches, err := service.FindFlaggedWords(ctx, 1, domain.ChatChannelAll, "a test")
+
+					Expect(err).To(BeNil())
+					Expect(matches).To(HaveLen(1))
+					repo.AssertNotCalled(t, "GetAll", mock.Anything)
+				})
+			})
+
 			g.Describe("Message contains flagged word", func() {
 				var flaggedWords []*domain.FlaggedWord
 
@@ -291,17 +469,17 @@ func Test(t *testing.T) {
 					})
 
 					g.It("Should not return an error", func() {
-						_, err := service.MessageContainsFlaggedWord(ctx, message)
+						_, err := service.FindFlaggedWords(ctx, 1, domain.ChatChannelAll, message)
 
 						Expect(err).To(BeNil())
 						repo.AssertExpectations(t)
 					})
 
 					g.It("Should return true", func() {
-						containsFlaggedWord, err := service.MessageContainsFlaggedWord(ctx, message)
+						matches, err := service.FindFlaggedWords(ctx, 1, domain.ChatChannelAll, message)
 
 						Expect(err).To(BeNil())
-						Expect(containsFlaggedWord).To(BeTrue())
+						Expect(matches).ToNot(BeEmpty())
 						repo.AssertExpectations(t)
 					})
 				})
@@ -314,17 +492,17 @@ func Test(t *testing.T) {
 					})
 
 					g.It("Should not return an error", func() {
-						_, err := service.MessageContainsFlaggedWord(ctx, message)
+						_, err := service.FindFlaggedWords(ctx, 1, domain.ChatChannelAll, message)
 
 						Expect(err).To(BeNil())
 						repo.AssertExpectations(t)
 					})
 
 					g.It("Should return true", func() {
-						containsFlaggedWord, err := service.MessageContainsFlaggedWord(ctx, message)
+						matches, err := service.FindFlaggedWords(ctx, 1, domain.ChatChannelAll, message)
 
 						Expect(err).To(BeNil())
-						Expect(containsFlaggedWord).To(BeTrue())
+						Expect(matches).ToNot(BeEmpty())
 						repo.AssertExpectations(t)
 					})
 				})
@@ -355,17 +533,17 @@ func Test(t *testing.T) {
 				})
 
 				g.It("Should not return an error", func() {
-					_, err := service.MessageContainsFlaggedWord(ctx, message)
+					_, err := service.FindFlaggedWords(ctx, 1, domain.ChatChannelAll, message)
 
 					Expect(err).To(BeNil())
 					repo.AssertExpectations(t)
 				})
 
 				g.It("Should return false", func() {
-					containsFlaggedWord, err := service.MessageContainsFlaggedWord(ctx, message)
+					matches, err := service.FindFlaggedWords(ctx, 1, domain.ChatChannelAll, message)
 
 					Expect(err).To(BeNil())
-					Expect(containsFlaggedWord).To(BeFalse())
+					Expect(matches).To(BeEmpty())
 					repo.AssertExpectations(t)
 				})
 			})
@@ -376,20 +554,206 @@ func Test(t *testing.T) {
 				})
 
 				g.It("Should not return an error", func() {
-					_, err := service.MessageContainsFlaggedWord(ctx, "msg")
+					_, err := service.FindFlaggedWords(ctx, 1, domain.ChatChannelAll, "msg")
 
 					Expect(err).To(BeNil())
 					repo.AssertExpectations(t)
 				})
 
 				g.It("Should return false", func() {
-					got, err := service.MessageContainsFlaggedWord(ctx, "msg")
+					got, err := service.FindFlaggedWords(ctx, 1, domain.ChatChannelAll, "msg")
 
 					Expect(err).To(BeNil())
-					Expect(got).To(BeFalse())
+					Expect(got).To(BeEmpty())
 					repo.AssertExpectations(t)
 				})
 			})
 		})
+
+		g.Describe("matcher", func() {
+			var find = func(word *domain.FlaggedWord, message string) []*domain.FlaggedWordMatch {
+				m, invalid := newMatcher([]*domain.FlaggedWord{word})
+				Expect(invalid).To(BeEmpty())
+
+				return m.match(1, domain.ChatChannelAll, message)
+			}
+
+			g.Describe("Exact mode", func() {
+				g.It("Should ignore surrounding punctuation", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "test", Mode: domain.FlaggedWordModeExact}, "a test! message")
+
+					Expect(matches).To(Equal([]*domain.FlaggedWordMatch{{
+						WordID: 1,
+						Word:   "test",
+						Mode:   domain.FlaggedWordModeExact,
+						Start:  2,
+						End:    6,
+					}}))
+				})
+
+				g.It("Should not match part of a word", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "test", Mode: domain.FlaggedWordModeExact}, "testing")
+
+					Expect(matches).To(BeEmpty())
+				})
+			})
+
+			g.Describe("Phrase mode", func() {
+				g.It("Should match a phrase split by any separator", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "bad word", Mode: domain.FlaggedWordModePhrase}, "a Bad-Word here")
+
+					Expect(matches).To(HaveLen(1))
+					Expect(matches[0].Start).To(Equal(2))
+					Expect(matches[0].End).To(Equal(10))
+				})
+
+				g.It("Should not match the words out of order", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "bad word", Mode: domain.FlaggedWordModePhrase}, "word bad")
+
+					Expect(matches).To(BeEmpty())
+				})
+			})
+
+			g.Describe("Regex mode", func() {
+				g.It("Should match case insensitively", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "b[a4]d+", Mode: domain.FlaggedWordModeRegex}, "so B4DDD")
+
+					Expect(matches).To(HaveLen(1))
+					Expect(matches[0].Start).To(Equal(3))
+					Expect(matches[0].End).To(Equal(8))
+				})
+
+				g.It("Should report an invalid expression", func() {
+					_, invalid := newMatcher([]*domain.FlaggedWord{{ID: 1, Word: "bad(", Mode: domain.FlaggedWordModeRegex}})
+
+					Expect(invalid).To(HaveLen(1))
+				})
+			})
+
+			g.Describe("Normalized mode", func() {
+				g.It("Should match leetspeak", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "noob", Mode: domain.FlaggedWordModeNormalized}, "you n00b")
+
+					Expect(matches).To(HaveLen(1))
+					Expect(matches[0].Start).To(Equal(4))
+					Expect(matches[0].End).To(Equal(8))
+				})
+
+				g.It("Should ignore separators inside the word", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "badword", Mode: domain.FlaggedWordModeNormalized}, "B4d-w0rd")
+
+					Expect(matches).To(HaveLen(1))
+					Expect(matches[0].Start).To(Equal(0))
+					Expect(matches[0].End).To(Equal(8))
+				})
+
+				g.It("Should match diacritics", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "cafe", Mode: domain.FlaggedWordModeNormalized}, "café")
+
+					Expect(matches).To(HaveLen(1))
+					Expect(matches[0].End).To(Equal(len("café")))
+				})
+
+				g.It("Should not match part of a word", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "ass", Mode: domain.FlaggedWordModeNormalized}, "first class")
+
+					Expect(matches).To(BeEmpty())
+				})
+
+				g.It("Should not collapse separators between words", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "ass", Mode: domain.FlaggedWordModeNormalized}, "a sass")
+
+					Expect(matches).To(BeEmpty())
+				})
+
+				g.It("Should match a word split by separators", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "ass", Mode: domain.FlaggedWordModeNormalized}, "you a.s.s")
+
+					Expect(matches).To(HaveLen(1))
+					Expect(matches[0].Start).To(Equal(4))
+					Expect(matches[0].End).To(Equal(9))
+				})
+
+				g.It("Should ignore surrounding punctuation", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "noob", Mode: domain.FlaggedWordModeNormalized}, "(n00b)!")
+
+					Expect(matches).To(HaveLen(1))
+					Expect(matches[0].Start).To(Equal(1))
+					Expect(matches[0].End).To(Equal(5))
+				})
+
+				g.It("Should match a phrase spread over multiple words", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "bad word", Mode: domain.FlaggedWordModeNormalized}, "a b4d w0rd")
+
+					Expect(matches).To(HaveLen(1))
+					Expect(matches[0].Start).To(Equal(2))
+					Expect(matches[0].End).To(Equal(10))
+				})
+			})
+
+			g.Describe("Fuzzy mode", func() {
+				g.It("Should match words within the max distance", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "word", Mode: domain.FlaggedWordModeFuzzy, MaxDistance: 1}, "a wrd")
+
+					Expect(matches).To(HaveLen(1))
+				})
+
+				g.It("Should not match words outside of the max distance", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "word", Mode: domain.FlaggedWordModeFuzzy, MaxDistance: 1}, "a wd")
+
+					Expect(matches).To(BeEmpty())
+				})
+
+				g.It("Should cap the max distance of short words", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "ass", Mode: domain.FlaggedWordModeFuzzy, MaxDistance: 1}, "as is")
+
+					Expect(matches).To(BeEmpty())
+				})
+			})
+
+			g.Describe("Match details", func() {
+				g.It("Should include the severity and action of the word", func() {
+					matches := find(&domain.FlaggedWord{ID: 1, Word: "word", Severity: domain.FlaggedWordSeverityHigh,
+						Action: domain.FlaggedWordActionMute, ActionDuration: 10}, "word")
+
+					Expect(matches).To(HaveLen(1))
+					Expect(matches[0].Severity).To(Equal(domain.FlaggedWordSeverityHigh))
+					Expect(matches[0].Action).To(Equal(domain.FlaggedWordActionMute))
+					Expect(matches[0].ActionDuration).To(Equal(10))
+				})
+			})
+
+			g.Describe("Multiple words", func() {
+				g.It("Should return matches ordered by offset", func() {
+					m, _ := newMatcher([]*domain.FlaggedWord{
+						{ID: 1, Word: "second"},
+						{ID: 2, Word: "first"},
+					})
+
+					matches := m.match(1, domain.ChatChannelAll, "first then second")
+
+					Expect(matches).To(HaveLen(2))
+					Expect(matches[0].WordID).To(Equal(int64(2)))
+					Expect(matches[1].WordID).To(Equal(int64(1)))
+				})
+			})
+
+			g.Describe("Channel restricted words", func() {
+				g.It("Should only match in the word's channels", func() {
+					m, _ := newMatcher([]*domain.FlaggedWord{
+						{ID: 1, Word: "word", Channels: []string{domain.ChatChannelAll}},
+					})
+
+					Expect(m.match(1, domain.ChatChannelAll, "word")).To(HaveLen(1))
+					Expect(m.match(1, domain.ChatChannelTeam, "word")).To(BeEmpty())
+				})
+
+				g.It("Should match in every channel if no channels are set", func() {
+					m, _ := newMatcher([]*domain.FlaggedWord{{ID: 1, Word: "word"}})
+
+					Expect(m.match(1, domain.ChatChannelSquad, "word")).To(HaveLen(1))
+				})
+			})
+		})
 	})
 }
@@ -0,0 +1,507 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/domain"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// matcher finds flagged words inside of messages. It is compiled once from the full list of flagged words and is
+// rebuilt whenever the list changes, so matching a message never has to touch the repo.
+type matcher struct {
+	words []*compiledWord
+}
+
+type compiledWord struct {
+	word *domain.FlaggedWord
+
+	// exempt holds the IDs of servers the word is not matched on. It is copied from the flagged word so that the
+	// matcher is not affected by exemptions changing before it is rebuilt.
+	exempt map[int64]bool
+
+	// channels holds the chat channels the word is matched in. If empty, the word is matched in every channel.
+	channels map[string]bool
+
+	// tokens holds the lowercased words of the flagged word for the exact, phrase and fuzzy modes. Exact mode always
+	// has a single token.
+	tokens []string
+
+	// pattern is the compiled expression for the regex mode.
+	pattern *regexp.Regexp
+
+	// normalized is the normalized flagged word for the normalized mode.
+	normalized []rune
+
+	// maxDistance is the number of edits allowed by the fuzzy mode, capped so that short words can't match unrelated
+	// words. Flagged words are validated against the same limit, but ones stored before it existed may exceed it.
+	maxDistance int
+}
+
+// newMatcher compiles the provided flagged words. Words which can't be compiled, such as invalid regular expressions
+// stored before they were validated, are returned separately and are left out of the matcher.
+func newMatcher(words []*domain.FlaggedWord) (*matcher, []*domain.FlaggedWord) {
+	m := &matcher{}
+	var invalid []*domain.FlaggedWord
+
+	for _, word := range words {
+		compiled, err := compileWord(word)
+		if err != nil {
+			invalid = append(invalid, word)
+			continue
+		}
+
+		m.words = append(m.words, compiled)
+	}
+
+	return m, invalid
+}
+
+// compileWord compiles a flagged word according to its mode. An unknown mode is treated as exact, since that is how
+// flagged words were matched before they had a mode.
+func compileWord(word *domain.FlaggedWord) (*compiledWord, error) {
+	compiled := &compiledWord{word: word, exempt: map[int64]bool{}, channels: map[string]bool{}}
+
+	for _, serverID := range word.ExemptServers {
+		compiled.exempt[serverID] = true
+	}
+
+	for _, channel := range word.Channels {
+		compiled.channels[channel] = true
+	}
+
+	switch word.Mode {
+	case domain.FlaggedWordModeRegex:
+		pattern, err := regexp.Compile("(?i)" + word.Word)
+		if err != nil {
+			return nil, err
+		}
+
+		compiled.pattern = pattern
+	case domain.FlaggedWordModeNormalized:
+		compiled.normalized = normalize(word.Word)
+	case domain.FlaggedWordModePhrase, domain.FlaggedWordModeFuzzy:
+		for _, t := range tokenize(word.Word, isSeparator) {
+			compiled.tokens = append(compiled.tokens, t.text)
+		}
+
+		compiled.maxDistance = word.MaxDistance
+		if limit := maxFuzzyDistance(word.Word); compiled.maxDistance > limit {
+			compiled.maxDistance = limit
+		}
+	default:
+		compiled.tokens = []string{strings.ToLower(strings.TrimFunc(word.Word, isSeparator))}
+	}
+
+	return compiled, nil
+}
+
+// match returns every match of a flagged word inside of a message sent on the provided server and channel, ordered by
+// where they start. Words the server is exempt from and words restricted to other channels are skipped.
+func (m *matcher) match(serverID int64, channel, message string) []*domain.FlaggedWordMatch {
+	matches := make([]*domain.FlaggedWordMatch, 0)
+	if m == nil || len(m.words) == 0 {
+		return matches
+	}
+
+	// Tokenize the message once for all words which need it
+	var words, phraseWords []token
+	var fields [][2]int
+
+	for _, cw := range m.words {
+		if cw.exempt[serverID] {
+			continue
+		}
+
+		if len(cw.channels) > 0 && !cw.channels[channel] {
+			continue
+		}
+
+		var spans [][2]int
+
+		switch cw.word.Mode {
+		case domain.FlaggedWordModeRegex:
+			for _, loc := range cw.pattern.FindAllStringIndex(message, -1) {
+				spans = append(spans, [2]int{loc[0], loc[1]})
+			}
+		case domain.FlaggedWordModeNormalized:
+			if fields == nil {
+				fields = splitFields(message)
+			}
+
+			spans = matchNormalized(message, fields, cw.normalized)
+		case domain.FlaggedWordModePhrase:
+			if phraseWords == nil {
+				phraseWords = tokenize(message, isSeparator)
+			}
+
+			spans = matchTokens(phraseWords, cw.tokens, func(a, b string) bool { return a == b })
+		case domain.FlaggedWordModeFuzzy:
+			if phraseWords == nil {
+				phraseWords = tokenize(message, isSeparator)
+			}
+
+			maxDistance := cw.maxDistance
+			spans = matchTokens(phraseWords, cw.tokens, func(a, b string) bool {
+				return withinDistance(a, b, maxDistance)
+			})
+		default:
+			if words == nil {
+				words = tokenize(message, unicode.IsSpace)
+			}
+
+			spans = matchTokens(words, cw.tokens, func(a, b string) bool { return a == b })
+		}
+
+		for _, span := range spans {
+			matches = append(matches, &domain.FlaggedWordMatch{
+				WordID:         cw.word.ID,
+				Word:           cw.word.Word,
+				Mode:           cw.word.Mode,
+				Severity:       cw.word.Severity,
+				Action:         cw.word.Action,
+				ActionDuration: cw.word.ActionDuration,
+				Start:          span[0],
+				End:            span[1],
+			})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Start < matches[j].Start
+	})
+
+	return matches
+}
+
+// token is a lowercased word of a message along with the byte offsets of the word in the original message.
+type token struct {
+	text  string
+	start int
+	end   int
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+// tokenize splits a message into words wherever split returns true. Separators around each word are trimmed, so
+// "(word!)" becomes "word".
+func tokenize(message string, split func(r rune) bool) []token {
+	tokens := make([]token, 0)
+
+	start := -1
+	for i, r := range message {
+		if split(r) {
+			if start >= 0 {
+				tokens = appendToken(tokens, message, start, i)
+				start = -1
+			}
+
+			continue
+		}
+
+		if start < 0 {
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		tokens = appendToken(tokens, message, start, len(message))
+	}
+
+	return tokens
+}
+
+func appendToken(tokens []token, message string, start, end int) []token {
+	raw := message[start:end]
+
+	trimmed := strings.TrimLeftFunc(raw, isSeparator)
+	start += len(raw) - len(trimmed)
+	trimmed = strings.TrimRightFunc(trimmed, isSeparator)
+
+	if trimmed == "" {
+		return tokens
+	}
+
+	return append(tokens, token{
+		text:  strings.ToLower(trimmed),
+		start: start,
+		end:   start + len(trimmed),
+	})
+}
+
+// matchTokens returns the spans of every run of consecutive tokens which are equal to the words according to eq.
+// Fuzzy phrases are compared as a whole so that the allowed edits can be spread across the words.
+func matchTokens(tokens []token, words []string, eq func(a, b string) bool) [][2]int {
+	var spans [][2]int
+	if len(words) == 0 {
+		return spans
+	}
+
+	phrase := strings.Join(words, " ")
+
+	for i := 0; i+len(words) <= len(tokens); i++ {
+		window := tokens[i : i+len(words)]
+
+		texts := make([]string, len(window))
+		for j, t := range window {
+			texts[j] = t.text
+		}
+
+		if eq(strings.Join(texts, " "), phrase) {
+			spans = append(spans, [2]int{window[0].start, window[len(window)-1].end})
+		}
+	}
+
+	return spans
+}
+
+// leetReplacements maps characters commonly used in leetspeak to the letters they stand in for.
+var leetReplacements = map[rune]rune{
+	'0': 'o',
+	'1': 'i',
+	'3': 'e',
+	'4': 'a',
+	'5': 's',
+	'7': 't',
+	'8': 'b',
+	'9': 'g',
+	'@': 'a',
+	'$': 's',
+	'!': 'i',
+	'|': 'l',
+	'+': 't',
+}
+
+// diacriticReplacements maps accented latin letters to their base letter.
+var diacriticReplacements = map[rune]rune{}
+
+func init() {
+	groups := map[rune]string{
+		'a': "àáâãäåāăą",
+		'c': "çćĉċč",
+		'd': "ďđ",
+		'e': "èéêëēĕėęě",
+		'g': "ĝğġģ",
+		'h': "ĥħ",
+		'i': "ìíîïĩīĭįı",
+		'j': "ĵ",
+		'k': "ķ",
+		'l': "ĺļľŀł",
+		'n': "ñńņňŉ",
+		'o': "òóôõöøōŏő",
+		'r': "ŕŗř",
+		's': "śŝşšș",
+		't': "ţťŧț",
+		'u': "ùúûüũūŭůűų",
+		'w': "ŵ",
+		'y': "ýÿŷ",
+		'z': "źżž",
+	}
+
+	for base, accented := range groups {
+		for _, r := range accented {
+			diacriticReplacements[r] = base
+		}
+	}
+}
+
+// normalize lowercases the message, replaces leetspeak characters and diacritics with the letters they stand in for,
+// and drops everything which is not a letter or a number.
+func normalize(message string) []rune {
+	normalized := make([]rune, 0, len(message))
+
+	for _, original := range message {
+		r := unicode.ToLower(original)
+
+		if replacement, ok := leetReplacements[r]; ok {
+			r = replacement
+		} else if replacement, ok := diacriticReplacements[r]; ok {
+			r = replacement
+		}
+
+		if isSeparator(r) {
+			continue
+		}
+
+		normalized = append(normalized, r)
+	}
+
+	return normalized
+}
+
+// splitFields returns the byte offsets of every whitespace separated field of a message.
+func splitFields(message string) [][2]int {
+	fields := make([][2]int, 0)
+
+	start := -1
+	for i, r := range message {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				fields = append(fields, [2]int{start, i})
+				start = -1
+			}
+
+			continue
+		}
+
+		if start < 0 {
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		fields = append(fields, [2]int{start, len(message)})
+	}
+
+	return fields
+}
+
+// matchNormalized returns the spans of every run of consecutive fields which normalizes to exactly word. Separators
+// are only collapsed inside of the run, so "a.s.s" matches "ass" but "class" does not. Punctuation around the run is
+// ignored unless it stands in for a letter of the word, so both "ass!" and "$hit" match.
+func matchNormalized(message string, fields [][2]int, word []rune) [][2]int {
+	var spans [][2]int
+	if len(word) == 0 {
+		return spans
+	}
+
+	for i := 0; i < len(fields); i++ {
+		for j := i; j < len(fields); j++ {
+			start, end := fields[i][0], fields[j][1]
+			raw := message[start:end]
+
+			if runesEqual(normalize(raw), word) {
+				spans = append(spans, [2]int{start, end})
+				i = j
+				break
+			}
+
+			trimmed := strings.TrimLeftFunc(raw, isSeparator)
+			start += len(raw) - len(trimmed)
+			trimmed = strings.TrimRightFunc(trimmed, isSeparator)
+
+			normalized := normalize(trimmed)
+			if trimmed != "" && runesEqual(normalized, word) {
+				spans = append(spans, [2]int{start, start + len(trimmed)})
+				i = j
+				break
+			}
+
+			// Adding more fields can only make the run longer
+			if len(normalized) > len(word) {
+				break
+			}
+		}
+	}
+
+	return spans
+}
+
+func runesEqual(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// maxFuzzyDistance returns the highest edit distance a fuzzy flagged word can allow. The distance must stay below half
+// of the word's length, since otherwise short words match unrelated words, such as "ass" matching "as".
+func maxFuzzyDistance(word string) int {
+	length := 0
+	for _, r := range word {
+		if !isSeparator(r) {
+			length++
+		}
+	}
+
+	limit := length/2 - 1
+	if limit > domain.MaxFlaggedWordDistance {
+		limit = domain.MaxFlaggedWordDistance
+	}
+
+	if limit < 0 {
+		return 0
+	}
+
+	return limit
+}
+
+// withinDistance returns true if the Levenshtein distance between a and b is at most max.
+func withinDistance(a, b string, max int) bool {
+	ra, rb := []rune(a), []rune(b)
+
+	if diff := len(ra) - len(rb); diff > max || -diff > max {
+		return false
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if curr[j] < rowMin {
+				rowMin = curr[j]
+			}
+		}
+
+		// Stop early if every path already needs too many edits
+		if rowMin > max {
+			return false
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)] <= max
+}
+
+func minInt(values ...int) int {
+	min := values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+	}
+
+	return min
+}
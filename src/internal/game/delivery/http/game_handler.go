@@ -48,10 +48,11 @@ func ApplyGameHandler(apiGroup *echo.Group, s domain.GameService, mware domain.M
 	gameGroup := apiGroup.Group("/games", mware.ProtectMiddleware, mware.ActivationMiddleware)
 
 	gameGroup.GET("/", handler.GetGames)
-	gameGroup.GET("/settings/:game", handler.GetGameSettings, enforcer.CheckAuth(authcheckers.DenyAll))                   // super admin only
-	gameGroup.PATCH("/settings/:game/commands", handler.SetGameCommandSettings, enforcer.CheckAuth(authcheckers.DenyAll)) // super admin only
-	gameGroup.PATCH("/settings/:game/general", handler.SetGeneralSettings, enforcer.CheckAuth(authcheckers.DenyAll))      // super admin only
-	gameGroup.GET("/settings/:game/default", handler.GetDefaultGameSettings, enforcer.CheckAuth(authcheckers.DenyAll))    // super admin only
+	gameGroup.GET("/settings/:game", handler.GetGameSettings, enforcer.CheckAuth(authcheckers.DenyAll))                    // super admin only
+	gameGroup.PATCH("/settings/:game/commands", handler.SetGameCommandSettings, enforcer.CheckAuth(authcheckers.DenyAll))  // super admin only
+	gameGroup.PATCH("/settings/:game/general", handler.SetGeneralSettings, enforcer.CheckAuth(authcheckers.DenyAll))       // super admin only
+	gameGroup.PATCH("/settings/:game/escalation", handler.SetEscalationSettings, enforcer.CheckAuth(authcheckers.DenyAll)) // super admin only
+	gameGroup.GET("/settings/:game/default", handler.GetDefaultGameSettings, enforcer.CheckAuth(authcheckers.DenyAll))     // super admin only
 }
 
 type publicGameSettings struct {
@@ -145,6 +146,12 @@ func (h *gameHandler) SetGameCommandSettings(c echo.Context) error {
 		return err
 	}
 
+	// Only mutes and bans can expire, so drop any warn and kick expire commands
+	if body.InfractionExpire != nil {
+		body.InfractionExpire.Warn = nil
+		body.InfractionExpire.Kick = nil
+	}
+
 	// Get current game settings
 	gs, err := h.service.GetGameSettings(game)
 	if err != nil {
@@ -158,6 +165,7 @@ func (h *gameHandler) SetGameCommandSettings(c echo.Context) error {
 		DeleteInfractionCommands: body.InfractionDelete,
 		RepealInfractionCommands: body.InfractionRepeal,
 		SyncInfractionCommands:   body.InfractionSync,
+		ExpireInfractionCommands: body.InfractionExpire,
 	}
 
 	if err := h.service.SetGameSettings(game, gs); err != nil {
@@ -208,6 +216,7 @@ func (h *gameHandler) SetGeneralSettings(c echo.Context) error {
 		EnableMuteSync:            body.EnableMuteSync,
 		PlayerInfractionThreshold: body.PlayerInfractionThreshold,
 		PlayerInfractionTimespan:  body.PlayerInfractionTimespan,
+		ReportCommand:             body.ReportCommand,
 	}
 
 	if err := h.service.SetGameSettings(game, gs); err != nil {
@@ -221,6 +230,53 @@ func (h *gameHandler) SetGeneralSettings(c echo.Context) error {
 	})
 }
 
+func (h *gameHandler) SetEscalationSettings(c echo.Context) error {
+	gameName := c.Param("game")
+
+	if len(strings.TrimSpace(gameName)) == 0 || !h.service.GameExists(gameName) {
+		return c.JSON(http.StatusBadRequest, &domain.Response{
+			Success: false,
+			Message: "Invalid game",
+		})
+	}
+
+	game, err := h.service.GetGame(gameName)
+	if err != nil {
+		return err
+	}
+
+	// Validate request body
+	var body params.SetGameEscalationSettingsParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	// Get current game settings
+	gs, err := h.service.GetGameSettings(game)
+	if err != nil {
+		return err
+	}
+
+	// Set game escalation settings
+	gs.Escalation = &domain.EscalationSettings{
+		Policies: body.Policies,
+	}
+
+	if err := h.service.SetGameSettings(game, gs); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Game escalation settings set",
+		Payload: gs,
+	})
+}
+
 func (h *gameHandler) GetDefaultGameSettings(c echo.Context) error {
 	gameName := c.Param("game")
 
@@ -32,7 +32,10 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type infractionHandler struct {
@@ -72,14 +75,27 @@ func ApplyInfractionHandler(apiGroup *echo.Group, s domain.InfractionService, as
 		sEnforcer.CheckAuth(authcheckers.HasPermission(perms.FlagCreateKick, true)))
 	infractionGroup.POST("/ban/:serverId", handler.CreateBan,
 		sEnforcer.CheckAuth(authcheckers.HasPermission(perms.FlagCreateBan, true)))
-	infractionGroup.PATCH("/:id", handler.UpdateInfraction)              // perms checked in service
-	infractionGroup.POST("/:id/repealed", handler.SetInfractionRepealed) // perms checked in service
-	infractionGroup.DELETE("/:id", handler.DeleteInfraction)             // perms checked in service
+	infractionGroup.POST("/custom/:type/:serverId", handler.CreateCustom) // perms checked in service
+	infractionGroup.PATCH("/:id", handler.UpdateInfraction)               // perms checked in service
+	infractionGroup.POST("/:id/repealed", handler.SetInfractionRepealed)  // perms checked in service
+	infractionGroup.DELETE("/:id", handler.DeleteInfraction)              // perms checked in service
+	infractionGroup.GET("/deleted", handler.GetDeleted,
+		rEnforcer.CheckAuth(authcheckers.RequireAdmin))
+	infractionGroup.POST("/:id/restore", handler.RestoreInfraction,
+		rEnforcer.CheckAuth(authcheckers.RequireAdmin))
 	infractionGroup.GET("/player/:platform/:playerId", handler.GetPlayerInfractions,
 		rEnforcer.CheckAuth(authcheckers.HasPermission(perms.FlagViewPlayerRecords, true))) // additional server specific perms checks done in service
-	infractionGroup.GET("/:id", handler.GetByID)                        // perms checked in service
-	infractionGroup.POST("/:id/attachment", handler.AddAttachment)      // perms checked in service
-	infractionGroup.DELETE("/attachment/:id", handler.RemoveAttachment) // perms checked in service
+	infractionGroup.GET("/:id", handler.GetByID)                            // perms checked in service
+	infractionGroup.GET("/:id/history", handler.GetHistory)                 // perms checked in service
+	infractionGroup.POST("/:id/attachment", handler.AddAttachment)          // perms checked in service
+	infractionGroup.DELETE("/attachment/:id", handler.RemoveAttachment)     // perms checked in service
+	infractionGroup.GET("/attachment/:id/file", handler.DownloadAttachment) // perms checked in service
+	infractionGroup.POST("/preview/:serverId", handler.PreviewCommands,
+		rEnforcer.CheckAuth(authcheckers.DenyAll)) // super admin only
+	infractionGroup.POST("/export", handler.ExportInfractions,
+		rEnforcer.CheckAuth(authcheckers.HasPermission(perms.FlagImportExportInfractions, true)))
+	infractionGroup.POST("/import", handler.ImportInfractions,
+		rEnforcer.CheckAuth(authcheckers.HasPermission(perms.FlagImportExportInfractions, true)))
 }
 
 type infractionRes struct {
@@ -132,6 +148,32 @@ func (h *infractionHandler) GetByID(c echo.Context) error {
 	})
 }
 
+func (h *infractionHandler) GetHistory(c echo.Context) error {
+	infractionIDString := c.Param("id")
+
+	infractionID, err := strconv.ParseInt(infractionIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid infraction id"), http.StatusBadRequest, "")
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := c.Request().Context()
+	ctx = context.WithValue(ctx, "user", user)
+	revisions, err := h.service.GetHistory(ctx, infractionID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: revisions,
+	})
+}
+
 func (h *infractionHandler) CreateWarning(c echo.Context) error {
 	serverIDString := c.Param("serverId")
 
@@ -163,6 +205,7 @@ func (h *infractionHandler) CreateWarning(c echo.Context) error {
 		ServerID:     serverID,
 		Type:         domain.InfractionTypeWarning,
 		Reason:       null.NewString(body.Reason, true),
+		RuleID:       null.IntFromPtr(body.RuleID),
 		Duration:     null.Int{},
 		SystemAction: false,
 		CreatedAt:    null.Time{},
@@ -233,6 +276,7 @@ func (h *infractionHandler) CreateMute(c echo.Context) error {
 		ServerID:     serverID,
 		Type:         domain.InfractionTypeMute,
 		Reason:       null.NewString(body.Reason, true),
+		RuleID:       null.IntFromPtr(body.RuleID),
 		Duration:     null.NewInt(int64(*body.Duration), true),
 		SystemAction: false,
 		CreatedAt:    null.Time{},
@@ -304,6 +348,7 @@ func (h *infractionHandler) CreateKick(c echo.Context) error {
 		ServerID:     serverID,
 		Type:         domain.InfractionTypeKick,
 		Reason:       null.NewString(body.Reason, true),
+		RuleID:       null.IntFromPtr(body.RuleID),
 		Duration:     null.Int{},
 		SystemAction: false,
 		CreatedAt:    null.Time{},
@@ -374,6 +419,7 @@ func (h *infractionHandler) CreateBan(c echo.Context) error {
 		ServerID:     serverID,
 		Type:         domain.InfractionTypeBan,
 		Reason:       null.NewString(body.Reason, true),
+		RuleID:       null.IntFromPtr(body.RuleID),
 		Duration:     null.NewInt(int64(*body.Duration), true),
 		SystemAction: false,
 		CreatedAt:    null.Time{},
@@ -414,6 +460,90 @@ func (h *infractionHandler) CreateBan(c echo.Context) error {
 	})
 }
 
+func (h *infractionHandler) CreateCustom(c echo.Context) error {
+	serverIDString := c.Param("serverId")
+
+	serverID, err := strconv.ParseInt(serverIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid server id"), http.StatusBadRequest, "")
+	}
+
+	infractionType := strings.ToUpper(c.Param("type"))
+
+	// Built-in types have their own routes with their own permission checks
+	if domain.IsBuiltInInfractionType(infractionType) {
+		return domain.NewHTTPError(fmt.Errorf("built-in infraction type"), http.StatusBadRequest,
+			"Built-in infraction types cannot be created through this route")
+	}
+
+	// Validate request body
+	var body params.CreateCustomInfractionParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	// Create the new infraction. Duration semantics are checked against the type in the service.
+	newInfraction := &domain.Infraction{
+		PlayerID:     body.PlayerID,
+		Platform:     body.Platform,
+		UserID:       null.NewString(user.Identity.Id, true),
+		ServerID:     serverID,
+		Type:         infractionType,
+		Reason:       null.NewString(body.Reason, true),
+		RuleID:       null.IntFromPtr(body.RuleID),
+		SystemAction: false,
+		CreatedAt:    null.Time{},
+		ModifiedAt:   null.Time{},
+	}
+
+	if body.Duration != nil {
+		newInfraction.Duration = null.NewInt(int64(*body.Duration), true)
+	}
+
+	// Convert attachments body field to slice of attachment slices
+	var attachments []*domain.Attachment
+	for _, att := range body.Attachments {
+		attachments = append(attachments, &domain.Attachment{
+			URL:  att.URL,
+			Note: att.Note,
+		})
+	}
+
+	// Attach user to request context for use in the service
+	ctx := c.Request().Context()
+	ctx = context.WithValue(ctx, "user", user)
+	newInfraction, err = h.service.Store(ctx, newInfraction, attachments, body.LinkedMessages)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Custom infraction record created",
+		zap.Int64("Infraction ID", newInfraction.InfractionID),
+		zap.String("Type", newInfraction.Type),
+		zap.String("Player ID", newInfraction.PlayerID),
+		zap.String("Platform", newInfraction.Platform),
+		zap.Int64("Server ID", newInfraction.ServerID),
+		zap.String("User ID", newInfraction.UserID.ValueOrZero()),
+		zap.String("Reason", newInfraction.Reason.ValueOrZero()),
+		zap.Int64("Duration", newInfraction.Duration.ValueOrZero()),
+	)
+
+	return c.JSON(http.StatusCreated, &domain.Response{
+		Success: true,
+		Message: "Infraction created",
+		Payload: newInfraction,
+	})
+}
+
 func (h *infractionHandler) UpdateInfraction(c echo.Context) error {
 	infractionIDString := c.Param("id")
 
@@ -443,6 +573,13 @@ func (h *infractionHandler) UpdateInfraction(c echo.Context) error {
 		return err
 	}
 
+	// The edit note is not an infraction field, so it is passed to the service separately
+	var editNote string
+	if body.EditNote != nil {
+		editNote = strings.TrimSpace(*body.EditNote)
+		delete(updateArgs, "EditNote")
+	}
+
 	if len(updateArgs) < 1 {
 		return c.JSON(http.StatusBadRequest, &domain.Response{
 			Success: false,
@@ -455,7 +592,7 @@ func (h *infractionHandler) UpdateInfraction(c echo.Context) error {
 	ctx = context.WithValue(ctx, "user", user)
 
 	// Update warning
-	updated, err := h.service.Update(ctx, infractionID, updateArgs)
+	updated, err := h.service.Update(ctx, infractionID, updateArgs, editNote)
 	if err != nil {
 		return err
 	}
@@ -500,7 +637,7 @@ func (h *infractionHandler) SetInfractionRepealed(c echo.Context) error {
 	ctx = context.WithValue(ctx, "user", user)
 
 	// Set repealed status of infraction
-	updated, err := h.service.SetRepealed(ctx, infractionID, body.Repealed)
+	updated, err := h.service.SetRepealed(ctx, infractionID, body.Repealed, strings.TrimSpace(body.EditNote))
 	if err != nil {
 		return err
 	}
@@ -517,6 +654,52 @@ func (h *infractionHandler) SetInfractionRepealed(c echo.Context) error {
 	})
 }
 
+func (h *infractionHandler) PreviewCommands(c echo.Context) error {
+	serverIDString := c.Param("serverId")
+
+	serverID, err := strconv.ParseInt(serverIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid server id"), http.StatusBadRequest, "")
+	}
+
+	// Validate request body
+	var body params.PreviewInfractionCommandsParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	draft := &domain.CustomInfractionPayload{
+		PlayerID:          body.PlayerID,
+		Platform:          body.Platform,
+		PlayerName:        body.PlayerName,
+		Type:              body.Type,
+		Duration:          int64(body.Duration),
+		DurationRemaining: int64(body.Duration),
+		Reason:            body.Reason,
+		RuleID:            body.RuleID,
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	previews, err := h.service.PreviewCommands(ctx, serverID, body.Action, draft)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: previews,
+	})
+}
+
 func (h *infractionHandler) DeleteInfraction(c echo.Context) error {
 	infractionIDString := c.Param("id")
 
@@ -525,6 +708,16 @@ func (h *infractionHandler) DeleteInfraction(c echo.Context) error {
 		return domain.NewHTTPError(fmt.Errorf("invalid infraction id"), http.StatusBadRequest, "")
 	}
 
+	// Validate request body. The deletion reason is optional so an empty body is allowed.
+	var body params.DeleteInfractionParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
 	user, ok := c.Get("user").(*domain.AuthUser)
 	if !ok {
 		return fmt.Errorf("could not cast user to *domain.AuthUser")
@@ -535,7 +728,7 @@ func (h *infractionHandler) DeleteInfraction(c echo.Context) error {
 	ctx = context.WithValue(ctx, "user", user)
 
 	// Delete the infraction
-	if err := h.service.Delete(ctx, infractionID); err != nil {
+	if err := h.service.Delete(ctx, infractionID, strings.TrimSpace(body.Reason)); err != nil {
 		return err
 	}
 
@@ -550,6 +743,97 @@ func (h *infractionHandler) DeleteInfraction(c echo.Context) error {
 	})
 }
 
+const (
+	defaultDeletedInfractionsLimit = 20
+	maxDeletedInfractionsLimit     = 100
+)
+
+type deletedInfractionsRes struct {
+	Total       int                  `json:"total"`
+	Infractions []*domain.Infraction `json:"infractions"`
+}
+
+// GetDeleted is the route handler for /api/v1/infractions/deleted
+// It returns a page of the infractions in the trash, most recently deleted first. The page is controlled by the limit
+// and offset query params.
+func (h *infractionHandler) GetDeleted(c echo.Context) error {
+	var err error
+
+	var limit int64 = defaultDeletedInfractionsLimit
+	if limitString := c.QueryParam("limit"); limitString != "" {
+		limit, err = strconv.ParseInt(limitString, 10, 32)
+		if err != nil || limit < 1 || limit > maxDeletedInfractionsLimit {
+			return &domain.HTTPError{
+				Success:          false,
+				Message:          "limit input error",
+				ValidationErrors: map[string]string{"limit": fmt.Sprintf("must be between 1 and %d", maxDeletedInfractionsLimit)},
+				Status:           http.StatusBadRequest,
+			}
+		}
+	}
+
+	var offset int64 = 0
+	if offsetString := c.QueryParam("offset"); offsetString != "" {
+		offset, err = strconv.ParseInt(offsetString, 10, 32)
+		if err != nil || offset < 0 {
+			return &domain.HTTPError{
+				Success:          false,
+				Message:          "offset input error",
+				ValidationErrors: map[string]string{"offset": "must be a positive integer"},
+				Status:           http.StatusBadRequest,
+			}
+		}
+	}
+
+	total, infractions, err := h.service.GetDeleted(c.Request().Context(), int(limit), int(offset))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: fmt.Sprintf("Fetched %d deleted infractions", len(infractions)),
+		Payload: &deletedInfractionsRes{
+			Total:       total,
+			Infractions: infractions,
+		},
+	})
+}
+
+func (h *infractionHandler) RestoreInfraction(c echo.Context) error {
+	infractionIDString := c.Param("id")
+
+	infractionID, err := strconv.ParseInt(infractionIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid infraction id"), http.StatusBadRequest, "")
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	// Attach user to request context so that the restore is attributed to them
+	ctx := c.Request().Context()
+	ctx = context.WithValue(ctx, "user", user)
+
+	restored, err := h.service.Restore(ctx, infractionID)
+	if err != nil {
+		return err
+	}
+
+	h.logger.Info("Infraction restored",
+		zap.Int64("Infraction ID", infractionID),
+		zap.String("User ID", user.Identity.Id),
+	)
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Infraction restored",
+		Payload: restored,
+	})
+}
+
 func (h *infractionHandler) GetPlayerInfractions(c echo.Context) error {
 	platform := c.Param("platform")
 	playerID := c.Param("playerId")
@@ -600,6 +884,11 @@ func (h *infractionHandler) AddAttachment(c echo.Context) error {
 		return domain.NewHTTPError(fmt.Errorf("invalid infraction id"), http.StatusBadRequest, "")
 	}
 
+	// Multipart requests contain a file upload rather than a link to an externally hosted file
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		return h.uploadAttachment(c, infractionID)
+	}
+
 	// Validate request body
 	var body params.CreateAttachmentParams
 	if err := c.Bind(&body); err != nil {
@@ -637,6 +926,84 @@ func (h *infractionHandler) AddAttachment(c echo.Context) error {
 	})
 }
 
+func (h *infractionHandler) uploadAttachment(c echo.Context, infractionID int64) error {
+	// Validate request body
+	var body params.UploadAttachmentParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return domain.NewHTTPError(err, http.StatusBadRequest, "No file was uploaded")
+	}
+
+	if fileHeader.Size > domain.MaxAttachmentFileSize {
+		return domain.NewHTTPError(nil, http.StatusBadRequest,
+			fmt.Sprintf("Attachments cannot be larger than %d MB", domain.MaxAttachmentFileSize>>20))
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	// Attach user to request context for use in the service
+	ctx := c.Request().Context()
+	ctx = context.WithValue(ctx, "user", user)
+
+	attachment, err := h.attachmentService.Upload(ctx, infractionID, strings.TrimSpace(body.Note), file)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Attachment uploaded",
+		Payload: attachment,
+	})
+}
+
+func (h *infractionHandler) DownloadAttachment(c echo.Context) error {
+	attachmentIDString := c.Param("id")
+
+	attachmentID, err := strconv.ParseInt(attachmentIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid attachment id"), http.StatusBadRequest, "")
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	// Attach user to request context for use in the service
+	ctx := c.Request().Context()
+	ctx = context.WithValue(ctx, "user", user)
+
+	attachment, file, err := h.attachmentService.Download(ctx, attachmentID)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Uploaded files are never modified since they are addressed by their content
+	c.Response().Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	c.Response().Header().Set("X-Content-Type-Options", "nosniff")
+
+	return c.Stream(http.StatusOK, attachment.ContentType.ValueOrZero(), file)
+}
+
 // RemoveAttachment would belong more in a separate Attachment handler, but it doesn't hurt anything while being here.
 // If something else calls for the creation of a separate attachment service, this method should be moved there.
 func (h *infractionHandler) RemoveAttachment(c echo.Context) error {
@@ -666,3 +1033,110 @@ func (h *infractionHandler) RemoveAttachment(c echo.Context) error {
 		Message: "Attachment deleted",
 	})
 }
+
+func (h *infractionHandler) ExportInfractions(c echo.Context) error {
+	// Validate request body
+	var body params.ExportInfractionParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	// Get export filters. Unlike a search, no filters means that every infraction is exported.
+	args, err := structutils.GetNonNilFieldMap(body.SearchInfractionParams)
+	if err != nil {
+		return err
+	}
+
+	contentType := "text/csv"
+	if body.Format == domain.InfractionTransferFormatJSON {
+		contentType = echo.MIMEApplicationJSON
+	}
+
+	filename := fmt.Sprintf("infractions-%s.%s", time.Now().UTC().Format("2006-01-02"), body.Format)
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, contentType)
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+
+	// Attach user to request context for use in the service
+	ctx := c.Request().Context()
+	ctx = context.WithValue(ctx, "user", user)
+
+	// The export is streamed straight to the response, so errors after this point cannot change the status code
+	res.WriteHeader(http.StatusOK)
+
+	if err := h.service.ExportInfractions(ctx, args, body.Format, res); err != nil {
+		h.logger.Error("Could not write infraction export", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (h *infractionHandler) ImportInfractions(c echo.Context) error {
+	// Validate request body
+	var body params.ImportInfractionsParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return domain.NewHTTPError(err, http.StatusBadRequest, "No file was uploaded")
+	}
+
+	if fileHeader.Size > domain.MaxInfractionImportFileSize {
+		return domain.NewHTTPError(nil, http.StatusBadRequest,
+			fmt.Sprintf("Import files cannot be larger than %d MB", domain.MaxInfractionImportFileSize>>20))
+	}
+
+	// If no format was provided, detect it from the file extension
+	format := body.Format
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+
+		if format != domain.InfractionTransferFormatCSV && format != domain.InfractionTransferFormatJSON {
+			return domain.NewHTTPError(nil, http.StatusBadRequest,
+				"Could not detect the file format. Upload a .csv or .json file or set the format field.")
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	// Attach user to request context for use in the service
+	ctx := c.Request().Context()
+	ctx = context.WithValue(ctx, "user", user)
+
+	result, err := h.service.ImportInfractions(ctx, body.ServerID, format, body.MapIssuers, file)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: fmt.Sprintf("Imported %d infractions. %d rows had errors.", result.Imported, len(result.Errors)),
+		Payload: result,
+	})
+}
@@ -22,6 +22,7 @@ import (
 	"Refractor/pkg/querybuilders/psqlqb"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"github.com/guregu/null"
 	"github.com/lib/pq"
@@ -84,8 +85,8 @@ func (r *infractionRepo) fetch(ctx context.Context, query string, args ...interf
 func (r *infractionRepo) Store(ctx context.Context, i *domain.Infraction) (*domain.Infraction, error) {
 	const op = opTag + "Store"
 
-	query := `INSERT INTO Infractions (PlayerID, Platform, UserID, ServerID, Type, Reason, Duration, SystemAction)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING *;`
+	query := `INSERT INTO Infractions (PlayerID, Platform, UserID, ServerID, Type, Reason, Duration, SystemAction, RuleID)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING *;`
 
 	stmt, err := r.db.PrepareContext(ctx, query)
 	if err != nil {
@@ -93,7 +94,8 @@ func (r *infractionRepo) Store(ctx context.Context, i *domain.Infraction) (*doma
 		return nil, errors.Wrap(err, op)
 	}
 
-	row := stmt.QueryRowContext(ctx, i.PlayerID, i.Platform, i.UserID, i.ServerID, i.Type, i.Reason, i.Duration, i.SystemAction)
+	row := stmt.QueryRowContext(ctx, i.PlayerID, i.Platform, i.UserID, i.ServerID, i.Type, i.Reason, i.Duration, i.SystemAction,
+		i.RuleID)
 
 	infraction := &domain.Infraction{}
 
@@ -108,7 +110,7 @@ func (r *infractionRepo) Store(ctx context.Context, i *domain.Infraction) (*doma
 func (r *infractionRepo) GetByID(ctx context.Context, id int64) (*domain.Infraction, error) {
 	const op = opTag + "GetByID"
 
-	query := "SELECT * FROM Infractions WHERE InfractionID = $1;"
+	query := "SELECT * FROM Infractions WHERE InfractionID = $1 AND DeletedAt IS NULL;"
 
 	results, err := r.fetch(ctx, query, id)
 	if err != nil {
@@ -125,7 +127,7 @@ func (r *infractionRepo) GetByID(ctx context.Context, id int64) (*domain.Infract
 func (r *infractionRepo) GetByPlayer(ctx context.Context, playerID, platform string) ([]*domain.Infraction, error) {
 	const op = opTag + "GetByPlayer"
 
-	query := "SELECT * FROM Infractions WHERE PlayerID = $1 AND Platform = $2;"
+	query := "SELECT * FROM Infractions WHERE PlayerID = $1 AND Platform = $2 AND DeletedAt IS NULL;"
 
 	results, err := r.fetch(ctx, query, playerID, platform)
 	if err != nil {
@@ -165,28 +167,168 @@ func (r *infractionRepo) Update(ctx context.Context, id int64, args domain.Updat
 	return updatedInfraction, nil
 }
 
-func (r *infractionRepo) Delete(ctx context.Context, id int64) error {
-	const op = opTag + "Delete"
+// SoftDelete moves an infraction to the trash. Linked chat messages and attachments are kept so that they come back
+// if the infraction is restored.
+func (r *infractionRepo) SoftDelete(ctx context.Context, id int64, deletedBy, reason null.String) (*domain.Infraction, error) {
+	const op = opTag + "SoftDelete"
 
-	query := "DELETE FROM Infractions WHERE InfractionID = $1;"
+	query := `UPDATE Infractions SET DeletedAt = CURRENT_TIMESTAMP, DeletedBy = $1, DeleteReason = $2
+			WHERE InfractionID = $3 AND DeletedAt IS NULL RETURNING *;`
 
-	res, err := r.db.ExecContext(ctx, query, id)
+	row := r.db.QueryRowContext(ctx, query, deletedBy, reason, id)
+
+	deleted := &domain.Infraction{}
+	if err := r.scanRow(row, deleted); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.Wrap(domain.ErrNotFound, op)
+		}
+
+		r.logger.Error("Could not scan deleted infraction", zap.Int64("Infraction ID", id), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return deleted, nil
+}
+
+func (r *infractionRepo) Restore(ctx context.Context, id int64) (*domain.Infraction, error) {
+	const op = opTag + "Restore"
+
+	query := `UPDATE Infractions SET DeletedAt = NULL, DeletedBy = NULL, DeleteReason = NULL
+			WHERE InfractionID = $1 AND DeletedAt IS NOT NULL RETURNING *;`
+
+	row := r.db.QueryRowContext(ctx, query, id)
+
+	restored := &domain.Infraction{}
+	if err := r.scanRow(row, restored); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.Wrap(domain.ErrNotFound, op)
+		}
+
+		r.logger.Error("Could not scan restored infraction", zap.Int64("Infraction ID", id), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return restored, nil
+}
+
+func (r *infractionRepo) GetDeleted(ctx context.Context, limit, offset int) (int, []*domain.Infraction, error) {
+	const op = opTag + "GetDeleted"
+
+	query := `SELECT * FROM Infractions WHERE DeletedAt IS NOT NULL ORDER BY DeletedAt DESC, InfractionID DESC
+			LIMIT $1 OFFSET $2;`
+
+	results, err := r.fetch(ctx, query, limit, offset)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, op)
+	}
+
+	// Get total number of deleted infractions
+	query = "SELECT COUNT(1) AS Count FROM Infractions WHERE DeletedAt IS NOT NULL;"
+
+	row := r.db.QueryRowContext(ctx, query)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		r.logger.Error("Could not scan deleted infraction count", zap.Error(err))
+		return 0, nil, errors.Wrap(err, op)
+	}
+
+	return count, results, nil
+}
+
+func (r *infractionRepo) PurgeDeleted(ctx context.Context, before time.Time) (int64, []string, error) {
+	const op = opTag + "PurgeDeleted"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.Error("Could not begin transaction", zap.Error(err))
+		return 0, nil, errors.Wrap(err, op)
+	}
+
+	// Collect the files of uploaded attachments before the attachments are removed along with their infractions
+	query := `SELECT DISTINCT a.SHA256 FROM Attachments a
+			JOIN Infractions i ON i.InfractionID = a.InfractionID
+			WHERE i.DeletedAt IS NOT NULL AND i.DeletedAt < $1 AND a.SHA256 IS NOT NULL;`
+
+	keys, err := r.queryStrings(ctx, tx, query, before)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, nil, errors.Wrap(err, op)
+	}
+
+	query = "DELETE FROM Infractions WHERE DeletedAt IS NOT NULL AND DeletedAt < $1;"
+
+	res, err := tx.ExecContext(ctx, query, before)
 	if err != nil {
+		_ = tx.Rollback()
 		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
-		return errors.Wrap(err, op)
+		return 0, nil, errors.Wrap(err, op)
 	}
 
 	rowsAffected, err := res.RowsAffected()
 	if err != nil {
+		_ = tx.Rollback()
 		r.logger.Error("Could not get affected rows", zap.Error(err))
-		return errors.Wrap(err, op)
+		return 0, nil, errors.Wrap(err, op)
 	}
 
-	if rowsAffected < 1 {
-		return errors.Wrap(domain.ErrNotFound, op)
+	// Uploads are deduplicated by hash, so files may still be referenced by attachments of other infractions
+	var unreferenced []string
+	if len(keys) > 0 {
+		query = "SELECT DISTINCT SHA256 FROM Attachments WHERE SHA256 = ANY($1);"
+
+		referenced, err := r.queryStrings(ctx, tx, query, pq.Array(keys))
+		if err != nil {
+			_ = tx.Rollback()
+			return 0, nil, errors.Wrap(err, op)
+		}
+
+		stillReferenced := map[string]bool{}
+		for _, key := range referenced {
+			stillReferenced[key] = true
+		}
+
+		for _, key := range keys {
+			if !stillReferenced[key] {
+				unreferenced = append(unreferenced, key)
+			}
+		}
 	}
 
-	return nil
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Could not commit transaction", zap.Error(err))
+		return 0, nil, errors.Wrap(err, op)
+	}
+
+	return rowsAffected, unreferenced, nil
+}
+
+// queryStrings runs a query inside of a transaction which selects a single string column.
+func (r *infractionRepo) queryStrings(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return nil, err
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	var results []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			r.logger.Error("Could not scan row", zap.Error(err))
+			return nil, err
+		}
+
+		results = append(results, value)
+	}
+
+	return results, rows.Err()
 }
 
 func (r *infractionRepo) Search(ctx context.Context, args domain.FindArgs, serverIDs []int64, limit, offset int) (int, []*domain.Infraction, error) {
@@ -202,17 +344,19 @@ func (r *infractionRepo) Search(ctx context.Context, args domain.FindArgs, serve
 			FROM Infractions i
 			INNER JOIN Servers s ON i.ServerID = s.ServerID
 			WHERE
+				i.DeletedAt IS NULL AND
 			    ($1::INT[] IS NULL OR $1::INT[] = '{}' OR i.ServerID = ANY ($1::INT[])) AND
-				($2::InfractionType IS NULL OR i.Type = $2) AND
+				($2::VARCHAR IS NULL OR i.Type = $2) AND
 				($3::VARCHAR IS NULL OR i.PlayerID = $3) AND
 				($4::VARCHAR IS NULL OR i.Platform = $4) AND
 				($5::VARCHAR IS NULL OR i.UserID = $5) AND
 				($6::INT IS NULL OR i.ServerID = $6) AND
-				($7::VARCHAR IS NULL OR s.Game = $7)
+				($7::VARCHAR IS NULL OR s.Game = $7) AND
+				($8::INT IS NULL OR i.RuleID = $8)
 			) res
 		LEFT JOIN UserMeta um ON res.UserID IS NOT NULL AND res.UserID = um.UserID
-		ORDER BY res.CreatedAt DESC
-		LIMIT $8 OFFSET $9;
+		ORDER BY res.CreatedAt DESC, res.InfractionID DESC
+		LIMIT $9 OFFSET $10;
 	`
 
 	var (
@@ -222,9 +366,11 @@ func (r *infractionRepo) Search(ctx context.Context, args domain.FindArgs, serve
 		userID   = args["UserID"]
 		serverID = args["ServerID"]
 		game     = args["Game"]
+		ruleID   = args["RuleID"]
 	)
 
-	rows, err := r.db.QueryContext(ctx, query, pq.Array(serverIDs), iType, playerID, platform, userID, serverID, game, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(serverIDs), iType, playerID, platform, userID, serverID, game,
+		ruleID, limit, offset)
 	if err != nil {
 		r.logger.Error("Could not execute infraction search query",
 			zap.Any("Filters", args),
@@ -241,7 +387,8 @@ func (r *infractionRepo) Search(ctx context.Context, args domain.FindArgs, serve
 		staffName := null.String{}
 
 		if err := rows.Scan(&res.InfractionID, &res.PlayerID, &res.Platform, &res.UserID, &res.ServerID, &res.Type,
-			&res.Reason, &res.Duration, &res.SystemAction, &res.CreatedAt, &res.ModifiedAt, &res.Repealed, &staffName); err != nil {
+			&res.Reason, &res.Duration, &res.SystemAction, &res.CreatedAt, &res.ModifiedAt, &res.Repealed, &res.ExpiredAt,
+			&res.RuleID, &res.DeletedAt, &res.DeletedBy, &res.DeleteReason, &staffName); err != nil {
 			r.logger.Error("Could not scan infraction search result", zap.Error(err))
 			return 0, nil, errors.Wrap(err, op)
 		}
@@ -261,16 +408,18 @@ func (r *infractionRepo) Search(ctx context.Context, args domain.FindArgs, serve
 		FROM Infractions i
 		INNER JOIN Servers s ON i.ServerID = s.ServerID
 		WHERE
+			i.DeletedAt IS NULL AND
 		    ($1::INT[] IS NULL OR $1::INT[] = '{}' OR i.ServerID = ANY ($1::INT[])) AND
-			($2::InfractionType IS NULL OR i.Type = $2) AND
+			($2::VARCHAR IS NULL OR i.Type = $2) AND
 			($3::VARCHAR IS NULL OR i.PlayerID = $3) AND
 		    ($4::VARCHAR IS NULL OR i.Platform = $4) AND
 			($5::VARCHAR = '' OR $5 IS NULL OR i.UserID = $5) AND
 			($6::INT IS NULL OR i.ServerID = $6) AND
-			($7::VARCHAR IS NULL OR s.Game = $7)
+			($7::VARCHAR IS NULL OR s.Game = $7) AND
+			($8::INT IS NULL OR i.RuleID = $8)
 	`
 
-	row := r.db.QueryRowContext(ctx, query, pq.Array(serverIDs), iType, playerID, platform, userID, serverID, game)
+	row := r.db.QueryRowContext(ctx, query, pq.Array(serverIDs), iType, playerID, platform, userID, serverID, game, ruleID)
 
 	var count int
 	if err := row.Scan(&count); err != nil {
@@ -298,6 +447,7 @@ func (r *infractionRepo) GetLinkedChatMessages(ctx context.Context, id int64) ([
 		    cm.Platform,
 		    cm.ServerID,
 		    cm.Message,
+		    cm.Channel,
 		    cm.Flagged,
 		    cm.CreatedAt,
 		    cm.ModifiedAt
@@ -317,7 +467,7 @@ func (r *infractionRepo) GetLinkedChatMessages(ctx context.Context, id int64) ([
 		msg := &domain.ChatMessage{}
 
 		if err := rows.Scan(&msg.MessageID, &msg.PlayerID, &msg.Platform, &msg.ServerID, &msg.Message,
-			&msg.Flagged, &msg.CreatedAt, &msg.ModifiedAt); err != nil {
+			&msg.Channel, &msg.Flagged, &msg.CreatedAt, &msg.ModifiedAt); err != nil {
 			r.logger.Error("Could not scan chat message", zap.Error(err))
 			return nil, errors.Wrap(err, op)
 		}
@@ -403,6 +553,7 @@ func (r *infractionRepo) GetMostSignificantInfraction(ctx context.Context, infrT
 			platform = $1 and
 			playerid = $2 and
 			repealed = false and
+			deletedat is null and
 			"type" = $3 and
 			duration = -1
 		union
@@ -413,6 +564,7 @@ func (r *infractionRepo) GetMostSignificantInfraction(ctx context.Context, infrT
 				platform = $1 and
 				playerid = $2 and
 				repealed = false and
+				deletedat is null and
 				"type" = $3 and
 				duration = -1
 		)
@@ -420,6 +572,7 @@ func (r *infractionRepo) GetMostSignificantInfraction(ctx context.Context, infrT
 			platform = $1 and
 			playerid = $2 and
 			repealed = false and
+			deletedat is null and
 			"type" = $3 and
 			(extract(epoch from createdat) + (duration * 60)) >= extract(epoch from current_timestamp)
 		order by duration desc
@@ -446,7 +599,7 @@ func (r *infractionRepo) GetMostSignificantInfraction(ctx context.Context, infrT
 func (r *infractionRepo) GetPlayerTotalInfractions(ctx context.Context, platform, playerID string) (int, error) {
 	const op = opTag + "GetPlayerTotalInfractions"
 
-	query := "SELECT COUNT(1) FROM Infractions WHERE Platform = $1 AND PlayerID = $2;"
+	query := "SELECT COUNT(1) FROM Infractions WHERE Platform = $1 AND PlayerID = $2 AND DeletedAt IS NULL;"
 
 	row := r.db.QueryRowContext(ctx, query, platform, playerID)
 
@@ -462,15 +615,21 @@ func (r *infractionRepo) GetPlayerTotalInfractions(ctx context.Context, platform
 	return count, nil
 }
 
-func (r *infractionRepo) GetPlayerInfractionCountSince(ctx context.Context, platform, playerID string, since time.Time) (int, error) {
+// GetPlayerInfractionCountSince returns the number of infractions a player received since the provided time. If any
+// infraction types are provided, only infractions of those types are counted.
+func (r *infractionRepo) GetPlayerInfractionCountSince(ctx context.Context, platform, playerID string, since time.Time,
+	types ...string) (int, error) {
 	const op = opTag + "GetPlayerInfractionCountSince"
 
 	query := `SELECT COUNT(1) FROM Infractions WHERE
                                        Platform = $1 AND
                                        PlayerID = $2 AND
-                                       CreatedAt >= TO_TIMESTAMP($3);`
+                                       CreatedAt >= TO_TIMESTAMP($3) AND
+                                       Repealed = FALSE AND
+                                       DeletedAt IS NULL AND
+                                       ($4::VARCHAR[] IS NULL OR Type::VARCHAR = ANY($4::VARCHAR[]));`
 
-	row := r.db.QueryRowContext(ctx, query, platform, playerID, since.Unix())
+	row := r.db.QueryRowContext(ctx, query, platform, playerID, since.Unix(), pq.Array(types))
 
 	var count int
 	if err := row.Scan(&count); err != nil {
@@ -485,13 +644,237 @@ func (r *infractionRepo) GetPlayerInfractionCountSince(ctx context.Context, plat
 	return count, nil
 }
 
+// GetExpired returns timed infractions which have run out but have not yet been marked as expired. Repealed infractions
+// are not returned.
+func (r *infractionRepo) GetExpired(ctx context.Context, limit int) ([]*domain.Infraction, error) {
+	const op = opTag + "GetExpired"
+
+	query := `
+		SELECT * FROM Infractions
+		WHERE
+			ExpiredAt IS NULL AND
+			Repealed = FALSE AND
+			DeletedAt IS NULL AND
+			Duration > 0 AND
+			CreatedAt + (Duration * INTERVAL '1 minute') <= CURRENT_TIMESTAMP
+		ORDER BY CreatedAt
+		LIMIT $1;
+	`
+
+	results, err := r.fetch(ctx, query, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return results, nil
+}
+
+// MarkExpired marks an infraction as expired. If the infraction does not exist or was already marked as expired,
+// domain.ErrNotFound is returned.
+func (r *infractionRepo) MarkExpired(ctx context.Context, id int64, expiredAt time.Time) error {
+	const op = opTag + "MarkExpired"
+
+	query := "UPDATE Infractions SET ExpiredAt = $1 WHERE InfractionID = $2 AND ExpiredAt IS NULL;"
+
+	res, err := r.db.ExecContext(ctx, query, expiredAt, id)
+	if err != nil {
+		r.logger.Error("Could not mark infraction as expired", zap.Int64("Infraction ID", id), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, op)
+	}
+
+	if rows < 1 {
+		return errors.Wrap(domain.ErrNotFound, op)
+	}
+
+	return nil
+}
+
+func (r *infractionRepo) StoreRevision(ctx context.Context, revision *domain.InfractionRevision) (*domain.InfractionRevision, error) {
+	const op = opTag + "StoreRevision"
+
+	changes, err := json.Marshal(revision.Changes)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	query := `INSERT INTO InfractionRevisions (InfractionID, UserID, Changes, Note) VALUES ($1, $2, $3, $4)
+			RETURNING RevisionID, InfractionID, UserID, Changes, Note, CreatedAt;`
+
+	row := r.db.QueryRowContext(ctx, query, revision.InfractionID, revision.UserID, changes, revision.Note)
+
+	newRevision := &domain.InfractionRevision{}
+	if err := r.scanRevision(row, newRevision); err != nil {
+		r.logger.Error("Could not scan newly created infraction revision", zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return newRevision, nil
+}
+
+// GetRevisions returns the revisions of an infraction, oldest first. If the infraction has no revisions, an empty
+// slice is returned.
+func (r *infractionRepo) GetRevisions(ctx context.Context, id int64) ([]*domain.InfractionRevision, error) {
+	const op = opTag + "GetRevisions"
+
+	query := `SELECT RevisionID, InfractionID, UserID, Changes, Note, CreatedAt FROM InfractionRevisions
+			WHERE InfractionID = $1 ORDER BY CreatedAt, RevisionID;`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.InfractionRevision, 0)
+	for rows.Next() {
+		revision := &domain.InfractionRevision{}
+
+		if err := r.scanRevision(rows, revision); err != nil {
+			r.logger.Error("Could not scan infraction revision", zap.Error(err))
+			return nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, revision)
+	}
+
+	return results, nil
+}
+
+func (r *infractionRepo) Import(ctx context.Context, infractions []*domain.Infraction) error {
+	const op = opTag + "Import"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.Error("Could not begin transaction", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	// Imported bans can belong to players who have never joined a server since Refractor was set up
+	playerQuery := `INSERT INTO Players (PlayerID, Platform, LastSeen, CreatedAt) VALUES ($1, $2, $3, $3)
+			ON CONFLICT DO NOTHING;`
+
+	playerStmt, err := tx.PrepareContext(ctx, playerQuery)
+	if err != nil {
+		_ = tx.Rollback()
+		r.logger.Error("Could not prepare statement", zap.String("query", playerQuery), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+	defer playerStmt.Close()
+
+	// Names are recorded at the time of the infraction so that they never replace a name the player was seen with later
+	nameQuery := `INSERT INTO PlayerNames (PlayerID, Platform, Name, DateRecorded) VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING;`
+
+	nameStmt, err := tx.PrepareContext(ctx, nameQuery)
+	if err != nil {
+		_ = tx.Rollback()
+		r.logger.Error("Could not prepare statement", zap.String("query", nameQuery), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+	defer nameStmt.Close()
+
+	query := `INSERT INTO Infractions (PlayerID, Platform, UserID, ServerID, Type, Reason, Duration, SystemAction,
+				CreatedAt, ExpiredAt)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);`
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		_ = tx.Rollback()
+		r.logger.Error("Could not prepare statement", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+	defer stmt.Close()
+
+	for _, i := range infractions {
+		res, err := playerStmt.ExecContext(ctx, i.PlayerID, i.Platform, i.CreatedAt)
+		if err != nil {
+			_ = tx.Rollback()
+			r.logger.Error("Could not insert imported player",
+				zap.String("Platform", i.Platform),
+				zap.String("Player ID", i.PlayerID),
+				zap.Error(err))
+			return errors.Wrap(err, op)
+		}
+
+		created, err := res.RowsAffected()
+		if err != nil {
+			_ = tx.Rollback()
+			r.logger.Error("Could not get affected rows", zap.Error(err))
+			return errors.Wrap(err, op)
+		}
+
+		// Players are looked up along with their names, so new players must always get one. If the import file did
+		// not include a name, the player's ID is used until they join a server and their real name is recorded.
+		name := i.PlayerName
+		if name == "" && created > 0 {
+			name = i.PlayerID
+		}
+
+		if name != "" {
+			if _, err := nameStmt.ExecContext(ctx, i.PlayerID, i.Platform, name, i.CreatedAt); err != nil {
+				_ = tx.Rollback()
+				r.logger.Error("Could not insert imported player name",
+					zap.String("Platform", i.Platform),
+					zap.String("Player ID", i.PlayerID),
+					zap.Error(err))
+				return errors.Wrap(err, op)
+			}
+		}
+
+		if _, err := stmt.ExecContext(ctx, i.PlayerID, i.Platform, i.UserID, i.ServerID, i.Type,
+			i.Reason.ValueOrZero(), i.Duration, i.SystemAction, i.CreatedAt, i.ExpiredAt); err != nil {
+			_ = tx.Rollback()
+			r.logger.Error("Could not insert imported infraction",
+				zap.String("Platform", i.Platform),
+				zap.String("Player ID", i.PlayerID),
+				zap.Error(err))
+			return errors.Wrap(err, op)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Could not commit transaction", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
 // Scan helpers
 func (r *infractionRepo) scanRow(row *sql.Row, i *domain.Infraction) error {
 	return row.Scan(&i.InfractionID, &i.PlayerID, &i.Platform, &i.UserID, &i.ServerID, &i.Type, &i.Reason, &i.Duration,
-		&i.SystemAction, &i.CreatedAt, &i.ModifiedAt, &i.Repealed)
+		&i.SystemAction, &i.CreatedAt, &i.ModifiedAt, &i.Repealed, &i.ExpiredAt, &i.RuleID, &i.DeletedAt, &i.DeletedBy,
+		&i.DeleteReason)
 }
 
 func (r *infractionRepo) scanRows(rows *sql.Rows, i *domain.Infraction) error {
 	return rows.Scan(&i.InfractionID, &i.PlayerID, &i.Platform, &i.UserID, &i.ServerID, &i.Type, &i.Reason, &i.Duration,
-		&i.SystemAction, &i.CreatedAt, &i.ModifiedAt, &i.Repealed)
+		&i.SystemAction, &i.CreatedAt, &i.ModifiedAt, &i.Repealed, &i.ExpiredAt, &i.RuleID, &i.DeletedAt, &i.DeletedBy,
+		&i.DeleteReason)
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (r *infractionRepo) scanRevision(row rowScanner, rev *domain.InfractionRevision) error {
+	var changes []byte
+
+	if err := row.Scan(&rev.RevisionID, &rev.InfractionID, &rev.UserID, &changes, &rev.Note, &rev.CreatedAt); err != nil {
+		return err
+	}
+
+	rev.Changes = domain.InfractionChanges{}
+	return json.Unmarshal(changes, &rev.Changes)
 }
@@ -19,6 +19,8 @@ package postgres
 
 import (
 	"Refractor/domain"
+	"Refractor/internal/player/repos/postgres/player"
+	"Refractor/internal/player/repos/postgres/playername"
 	"context"
 	"database/sql"
 	"fmt"
@@ -53,6 +55,11 @@ func Test(t *testing.T) {
 		"CreatedAt",
 		"ModifiedAt",
 		"Repealed",
+		"ExpiredAt",
+		"RuleID",
+		"DeletedAt",
+		"DeletedBy",
+		"DeleteReason",
 	}
 	var ctx = context.TODO()
 
@@ -101,7 +108,7 @@ func Test(t *testing.T) {
 					mock.ExpectQuery("INSERT INTO Infractions").WillReturnRows(
 						sqlmock.NewRows(cols).
 							AddRow(i.InfractionID, i.PlayerID, i.Platform, i.UserID, i.ServerID,
-								i.Type, i.Reason, i.Duration, i.SystemAction, i.CreatedAt, i.ModifiedAt, i.Repealed))
+								i.Type, i.Reason, i.Duration, i.SystemAction, i.CreatedAt, i.ModifiedAt, i.Repealed, i.ExpiredAt, i.RuleID, i.DeletedAt, i.DeletedBy, i.DeleteReason))
 				})
 
 				g.It("Should not return an error", func() {
@@ -160,7 +167,7 @@ func Test(t *testing.T) {
 
 					mockRows = sqlmock.NewRows(cols).
 						AddRow(i.InfractionID, i.PlayerID, i.Platform, i.UserID, i.ServerID, i.Type, i.Reason, i.Duration,
-							i.SystemAction, i.CreatedAt, i.ModifiedAt, i.Repealed)
+							i.SystemAction, i.CreatedAt, i.ModifiedAt, i.Repealed, i.ExpiredAt, i.RuleID, i.DeletedAt, i.DeletedBy, i.DeleteReason)
 
 					mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Infractions")).WillReturnRows(mockRows)
 				})
@@ -258,7 +265,7 @@ func Test(t *testing.T) {
 					rows := sqlmock.NewRows(cols)
 					for _, i := range infractions {
 						rows.AddRow(i.InfractionID, i.PlayerID, i.Platform, i.UserID, i.ServerID, i.Type, i.Reason, i.Duration,
-							i.SystemAction, i.CreatedAt, i.ModifiedAt, i.Repealed)
+							i.SystemAction, i.CreatedAt, i.ModifiedAt, i.Repealed, i.ExpiredAt, i.RuleID, i.DeletedAt, i.DeletedBy, i.DeleteReason)
 					}
 					mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Infractions")).WillReturnRows(rows)
 				})
@@ -339,7 +346,7 @@ func Test(t *testing.T) {
 
 					mock.ExpectQuery("UPDATE Infractions SET").WillReturnRows(sqlmock.NewRows(cols).
 						AddRow(ui.InfractionID, ui.PlayerID, ui.Platform, ui.UserID, ui.ServerID, ui.Type, ui.Reason,
-							ui.Duration, ui.SystemAction, ui.CreatedAt, ui.ModifiedAt, ui.Repealed))
+							ui.Duration, ui.SystemAction, ui.CreatedAt, ui.ModifiedAt, ui.Repealed, ui.ExpiredAt, ui.RuleID, ui.DeletedAt, ui.DeletedBy, ui.DeleteReason))
 				})
 
 				g.It("Should not return an error", func() {
@@ -379,35 +386,173 @@ func Test(t *testing.T) {
 			})
 		})
 
-		g.Describe("Delete()", func() {
+		g.Describe("SoftDelete()", func() {
 			g.Describe("Target infraction exists", func() {
 				g.BeforeEach(func() {
-					mock.ExpectExec("DELETE FROM Infractions").WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectQuery("UPDATE Infractions SET DeletedAt = CURRENT_TIMESTAMP").
+						WithArgs(null.StringFrom("userid"), null.StringFrom("duplicate"), 1).
+						WillReturnRows(sqlmock.NewRows(cols).
+							AddRow(1, "playerid", "platform", "userid", 1, domain.InfractionTypeBan, "reason", 60, false,
+								time.Now(), nil, false, nil, nil, time.Now(), "userid", "duplicate"))
 				})
 
-				g.It("Should not return an error", func() {
-					err := repo.Delete(ctx, 1)
+				g.It("Should return the deleted infraction", func() {
+					deleted, err := repo.SoftDelete(ctx, 1, null.StringFrom("userid"), null.StringFrom("duplicate"))
 
 					Expect(err).To(BeNil())
+					Expect(deleted.DeletedAt.Valid).To(BeTrue())
+					Expect(deleted.DeletedBy).To(Equal(null.StringFrom("userid")))
+					Expect(deleted.DeleteReason).To(Equal(null.StringFrom("duplicate")))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
 				})
 			})
 
-			g.Describe("Target infraction does not exist", func() {
+			g.Describe("Target infraction does not exist or was already deleted", func() {
 				g.BeforeEach(func() {
-					mock.ExpectExec("DELETE FROM Infractions").WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectQuery("UPDATE Infractions SET DeletedAt = CURRENT_TIMESTAMP").WillReturnRows(sqlmock.NewRows(cols))
 				})
 
 				g.It("Should return a domain.ErrNotFound error", func() {
-					err := repo.Delete(ctx, 1)
+					_, err := repo.SoftDelete(ctx, 1, null.String{}, null.String{})
 
 					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
 				})
 			})
 		})
 
+		g.Describe("Restore()", func() {
+			g.Describe("Target infraction is deleted", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("UPDATE Infractions SET DeletedAt = NULL").WithArgs(1).
+						WillReturnRows(sqlmock.NewRows(cols).
+							AddRow(1, "playerid", "platform", "userid", 1, domain.InfractionTypeBan, "reason", 60, false,
+								time.Now(), nil, false, nil, nil, nil, nil, nil))
+				})
+
+				g.It("Should return the restored infraction", func() {
+					restored, err := repo.Restore(ctx, 1)
+
+					Expect(err).To(BeNil())
+					Expect(restored.InfractionID).To(Equal(int64(1)))
+					Expect(restored.DeletedAt.Valid).To(BeFalse())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Target infraction is not deleted", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("UPDATE Infractions SET DeletedAt = NULL").WillReturnRows(sqlmock.NewRows(cols))
+				})
+
+				g.It("Should return a domain.ErrNotFound error", func() {
+					_, err := repo.Restore(ctx, 1)
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+				})
+			})
+		})
+
+		g.Describe("GetDeleted()", func() {
+			g.BeforeEach(func() {
+				mock.ExpectQuery("SELECT \\* FROM Infractions WHERE DeletedAt IS NOT NULL").WithArgs(20, 0).
+					WillReturnRows(sqlmock.NewRows(cols).
+						AddRow(1, "playerid", "platform", "userid", 1, domain.InfractionTypeBan, "reason", 60, false,
+							time.Now(), nil, false, nil, nil, time.Now(), "userid", nil))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(1) AS Count FROM Infractions WHERE DeletedAt IS NOT NULL")).
+					WillReturnRows(sqlmock.NewRows([]string{"Count"}).AddRow(21))
+			})
+
+			g.It("Should return the page of deleted infractions and the total", func() {
+				total, results, err := repo.GetDeleted(ctx, 20, 0)
+
+				Expect(err).To(BeNil())
+				Expect(total).To(Equal(21))
+				Expect(len(results)).To(Equal(1))
+				Expect(results[0].DeletedBy).To(Equal(null.StringFrom("userid")))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("PurgeDeleted()", func() {
+			var before time.Time
+
+			g.BeforeEach(func() {
+				before = time.Now()
+			})
+
+			g.Describe("Purged infractions had uploaded attachments", func() {
+				g.BeforeEach(func() {
+					mock.ExpectBegin()
+					mock.ExpectQuery("SELECT DISTINCT a.SHA256 FROM Attachments a").WithArgs(before).
+						WillReturnRows(sqlmock.NewRows([]string{"SHA256"}).AddRow("hash1").AddRow("hash2"))
+					mock.ExpectExec("DELETE FROM Infractions WHERE DeletedAt IS NOT NULL").WithArgs(before).
+						WillReturnResult(sqlmock.NewResult(0, 3))
+					mock.ExpectQuery("SELECT DISTINCT SHA256 FROM Attachments WHERE SHA256 = ANY").
+						WithArgs(pq.Array([]string{"hash1", "hash2"})).
+						WillReturnRows(sqlmock.NewRows([]string{"SHA256"}).AddRow("hash2"))
+					mock.ExpectCommit()
+				})
+
+				g.It("Should return the number of purged infractions", func() {
+					purged, _, err := repo.PurgeDeleted(ctx, before)
+
+					Expect(err).To(BeNil())
+					Expect(purged).To(Equal(int64(3)))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+
+				g.It("Should only return the files no longer referenced by another attachment", func() {
+					_, keys, err := repo.PurgeDeleted(ctx, before)
+
+					Expect(err).To(BeNil())
+					Expect(keys).To(Equal([]string{"hash1"}))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Purged infractions had no uploaded attachments", func() {
+				g.BeforeEach(func() {
+					mock.ExpectBegin()
+					mock.ExpectQuery("SELECT DISTINCT a.SHA256 FROM Attachments a").WithArgs(before).
+						WillReturnRows(sqlmock.NewRows([]string{"SHA256"}))
+					mock.ExpectExec("DELETE FROM Infractions WHERE DeletedAt IS NOT NULL").WithArgs(before).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+				})
+
+				g.It("Should not return any files", func() {
+					purged, keys, err := repo.PurgeDeleted(ctx, before)
+
+					Expect(err).To(BeNil())
+					Expect(purged).To(Equal(int64(1)))
+					Expect(keys).To(BeEmpty())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Delete failed", func() {
+				g.BeforeEach(func() {
+					mock.ExpectBegin()
+					mock.ExpectQuery("SELECT DISTINCT a.SHA256 FROM Attachments a").WithArgs(before).
+						WillReturnRows(sqlmock.NewRows([]string{"SHA256"}).AddRow("hash1"))
+					mock.ExpectExec("DELETE FROM Infractions WHERE DeletedAt IS NOT NULL").WillReturnError(fmt.Errorf("err"))
+					mock.ExpectRollback()
+				})
+
+				g.It("Should return an error", func() {
+					_, keys, err := repo.PurgeDeleted(ctx, before)
+
+					Expect(err).ToNot(BeNil())
+					Expect(keys).To(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
 		g.Describe("Search()", func() {
 			var cols = []string{"InfractionID", "PlayerID", "Platform", "UserID", "ServerID", "Type", "Reason", "Duration",
-				"SystemAction", "CreatedAt", "ModifiedAt", "Repealed", "StaffName"}
+				"SystemAction", "CreatedAt", "ModifiedAt", "Repealed", "ExpiredAt", "RuleID", "DeletedAt", "DeletedBy",
+				"DeleteReason", "StaffName"}
 
 			g.Describe("Results found", func() {
 				var results []*domain.Infraction
@@ -462,7 +607,8 @@ func Test(t *testing.T) {
 
 					for _, i := range results {
 						rows.AddRow(i.InfractionID, i.PlayerID, i.Platform, i.UserID, i.ServerID, i.Type, i.Reason,
-							i.Duration, i.SystemAction, i.CreatedAt, i.ModifiedAt, i.Repealed, i.IssuerName)
+							i.Duration, i.SystemAction, i.CreatedAt, i.ModifiedAt, i.Repealed, i.ExpiredAt, i.RuleID, i.DeletedAt, i.DeletedBy,
+							i.DeleteReason, i.IssuerName)
 					}
 
 					mock.ExpectQuery(regexp.QuoteMeta("SELECT res.*, um.Username AS StaffName FROM (")).WillReturnRows(rows)
@@ -517,6 +663,20 @@ func Test(t *testing.T) {
 				})
 			})
 
+			g.Describe("Results share a creation time", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta("ORDER BY res.CreatedAt DESC, res.InfractionID DESC LIMIT")).
+						WillReturnRows(sqlmock.NewRows(cols))
+				})
+
+				g.It("Should order results by ID after their creation time so that pages are stable", func() {
+					_, _, err := repo.Search(ctx, domain.FindArgs{}, nil, 10, 10)
+
+					Expect(err).To(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
 			g.Describe("Database error", func() {
 				g.BeforeEach(func() {
 					mock.ExpectQuery(regexp.QuoteMeta("SELECT res.*, um.Username AS StaffName FROM (")).WillReturnError(fmt.Errorf("err"))
@@ -564,11 +724,13 @@ func Test(t *testing.T) {
 					}
 
 					rows := sqlmock.NewRows([]string{
-						"MessageID", "PlayerID", "Platform", "ServerID", "Message", "Flagged", "CreatedAt", "ModifiedAt",
+						"MessageID", "PlayerID", "Platform", "ServerID", "Message", "Channel", "Flagged", "CreatedAt",
+						"ModifiedAt",
 					})
 
 					for _, r := range expected {
-						rows.AddRow(r.MessageID, r.PlayerID, r.Platform, r.ServerID, r.Message, r.Flagged, r.CreatedAt, r.ModifiedAt)
+						rows.AddRow(r.MessageID, r.PlayerID, r.Platform, r.ServerID, r.Message, r.Channel, r.Flagged, r.CreatedAt,
+							r.ModifiedAt)
 					}
 
 					mock.ExpectQuery(regexp.QuoteMeta("SELECT cm.MessageID")).WillReturnRows(rows)
@@ -595,7 +757,8 @@ func Test(t *testing.T) {
 					expected = []*domain.ChatMessage{}
 
 					rows := sqlmock.NewRows([]string{
-						"MessageID", "PlayerID", "Platform", "ServerID", "Message", "Flagged", "CreatedAt", "ModifiedAt",
+						"MessageID", "PlayerID", "Platform", "ServerID", "Message", "Channel", "Flagged", "CreatedAt",
+						"ModifiedAt",
 					})
 
 					mock.ExpectQuery(regexp.QuoteMeta("SELECT cm.MessageID")).WillReturnRows(rows)
@@ -755,7 +918,7 @@ func Test(t *testing.T) {
 
 					for _, i := range results {
 						rows.AddRow(i.InfractionID, i.PlayerID, i.Platform, i.UserID, i.ServerID, i.Type, i.Reason,
-							i.Duration, i.SystemAction, i.CreatedAt, i.ModifiedAt, i.Repealed)
+							i.Duration, i.SystemAction, i.CreatedAt, i.ModifiedAt, i.Repealed, i.ExpiredAt, i.RuleID, i.DeletedAt, i.DeletedBy, i.DeleteReason)
 					}
 
 					mock.ExpectQuery(regexp.QuoteMeta("select * from infractions")).WillReturnRows(rows)
@@ -821,6 +984,69 @@ func Test(t *testing.T) {
 			})
 		})
 
+		g.Describe("GetExpired()", func() {
+			g.Describe("Expired infractions found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("SELECT \\* FROM Infractions").WithArgs(50).
+						WillReturnRows(sqlmock.NewRows(cols).
+							AddRow(1, "playerid", "platform", "userid", 1, domain.InfractionTypeBan, "reason", 60, false,
+								time.Now().Add(-time.Hour*2), nil, false, nil, nil, nil, nil, nil))
+				})
+
+				g.It("Should return the expired infractions", func() {
+					results, err := repo.GetExpired(ctx, 50)
+
+					Expect(err).To(BeNil())
+					Expect(len(results)).To(Equal(1))
+					Expect(results[0].InfractionID).To(Equal(int64(1)))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("No expired infractions found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("SELECT \\* FROM Infractions").WillReturnRows(sqlmock.NewRows(cols))
+				})
+
+				g.It("Should return an empty slice", func() {
+					results, err := repo.GetExpired(ctx, 50)
+
+					Expect(err).To(BeNil())
+					Expect(results).To(BeEmpty())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("MarkExpired()", func() {
+			g.Describe("Infraction marked", func() {
+				g.BeforeEach(func() {
+					mock.ExpectExec("UPDATE Infractions SET ExpiredAt").WithArgs(sqlmock.AnyArg(), int64(1)).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				})
+
+				g.It("Should not return an error", func() {
+					err := repo.MarkExpired(ctx, 1, time.Now())
+
+					Expect(err).To(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Infraction already marked", func() {
+				g.BeforeEach(func() {
+					mock.ExpectExec("UPDATE Infractions SET ExpiredAt").WillReturnResult(sqlmock.NewResult(0, 0))
+				})
+
+				g.It("Should return domain.ErrNotFound", func() {
+					err := repo.MarkExpired(ctx, 1, time.Now())
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
 		g.Describe("GetPlayerTotalInfractions()", func() {
 			g.Describe("Infraction count returned successfully", func() {
 				g.BeforeEach(func() {
@@ -879,5 +1105,200 @@ func Test(t *testing.T) {
 				})
 			})
 		})
+
+		g.Describe("StoreRevision()", func() {
+			revisionCols := []string{"RevisionID", "InfractionID", "UserID", "Changes", "Note", "CreatedAt"}
+
+			g.It("Should store the changes as JSON and return the new revision", func() {
+				changes := `{"reason":{"old":"old reason","new":"new reason"}}`
+
+				mock.ExpectQuery("INSERT INTO InfractionRevisions").
+					WithArgs(int64(1), null.StringFrom("userid"), []byte(changes), null.StringFrom("typo")).
+					WillReturnRows(sqlmock.NewRows(revisionCols).
+						AddRow(3, 1, "userid", []byte(changes), "typo", time.Time{}))
+
+				revision, err := repo.StoreRevision(ctx, &domain.InfractionRevision{
+					InfractionID: 1,
+					UserID:       null.StringFrom("userid"),
+					Changes: domain.InfractionChanges{
+						"reason": {Old: "old reason", New: "new reason"},
+					},
+					Note: null.StringFrom("typo"),
+				})
+
+				Expect(err).To(BeNil())
+				Expect(revision.RevisionID).To(Equal(int64(3)))
+				Expect(revision.Changes).To(Equal(domain.InfractionChanges{
+					"reason": {Old: "old reason", New: "new reason"},
+				}))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("GetRevisions()", func() {
+			revisionCols := []string{"RevisionID", "InfractionID", "UserID", "Changes", "Note", "CreatedAt"}
+
+			g.Describe("Revisions found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("SELECT (.+) FROM InfractionRevisions").WithArgs(1).
+						WillReturnRows(sqlmock.NewRows(revisionCols).
+							AddRow(1, 1, nil, []byte(`{"repealed":{"old":false,"new":true}}`), nil, time.Time{}))
+				})
+
+				g.It("Should return the decoded revisions", func() {
+					revisions, err := repo.GetRevisions(ctx, 1)
+
+					Expect(err).To(BeNil())
+					Expect(revisions).To(HaveLen(1))
+					Expect(revisions[0].UserID.Valid).To(BeFalse())
+					Expect(revisions[0].Changes).To(Equal(domain.InfractionChanges{
+						"repealed": {Old: false, New: true},
+					}))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("No revisions found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("SELECT (.+) FROM InfractionRevisions").WithArgs(1).
+						WillReturnRows(sqlmock.NewRows(revisionCols))
+				})
+
+				g.It("Should return an empty slice", func() {
+					revisions, err := repo.GetRevisions(ctx, 1)
+
+					Expect(err).To(BeNil())
+					Expect(revisions).To(Equal([]*domain.InfractionRevision{}))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("Import()", func() {
+			var infractions []*domain.Infraction
+
+			g.BeforeEach(func() {
+				infractions = []*domain.Infraction{
+					{
+						PlayerID:  "playerid",
+						Platform:  "platform",
+						ServerID:  1,
+						Type:      domain.InfractionTypeBan,
+						Reason:    null.NewString("Cheating", true),
+						Duration:  null.NewInt(domain.PermanentInfractionValue, true),
+						CreatedAt: null.NewTime(time.Now().AddDate(-3, 0, 0), true),
+					},
+					{
+						PlayerID:  "playerid2",
+						Platform:  "platform",
+						UserID:    null.NewString("userid", true),
+						ServerID:  1,
+						Type:      domain.InfractionTypeWarning,
+						Reason:    null.NewString("Spam", true),
+						CreatedAt: null.NewTime(time.Now().AddDate(-2, 0, 0), true),
+					},
+				}
+			})
+
+			g.Describe("All rows inserted", func() {
+				g.BeforeEach(func() {
+					mock.ExpectBegin()
+					mock.ExpectPrepare("INSERT INTO Players")
+					mock.ExpectPrepare("INSERT INTO PlayerNames")
+					mock.ExpectPrepare("INSERT INTO Infractions")
+
+					for _, i := range infractions {
+						mock.ExpectExec("INSERT INTO Players").WithArgs(i.PlayerID, i.Platform, i.CreatedAt).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+						mock.ExpectExec("INSERT INTO PlayerNames").WithArgs(i.PlayerID, i.Platform, i.PlayerID,
+							i.CreatedAt).WillReturnResult(sqlmock.NewResult(0, 1))
+						mock.ExpectExec("INSERT INTO Infractions").WithArgs(i.PlayerID, i.Platform, i.UserID, i.ServerID,
+							i.Type, i.Reason.ValueOrZero(), i.Duration, i.SystemAction, i.CreatedAt, i.ExpiredAt).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+					}
+
+					mock.ExpectCommit()
+				})
+
+				g.It("Should not return an error", func() {
+					err := repo.Import(ctx, infractions)
+
+					Expect(err).To(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Imported player names", func() {
+				g.BeforeEach(func() {
+					infractions[0].PlayerName = "OldName"
+
+					mock.ExpectBegin()
+					mock.ExpectPrepare("INSERT INTO Players")
+					mock.ExpectPrepare("INSERT INTO PlayerNames")
+					mock.ExpectPrepare("INSERT INTO Infractions")
+
+					// New player with a name
+					mock.ExpectExec("INSERT INTO Players").WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectExec("INSERT INTO PlayerNames").WithArgs("playerid", "platform", "OldName",
+						infractions[0].CreatedAt).WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectExec("INSERT INTO Infractions").WillReturnResult(sqlmock.NewResult(0, 1))
+
+					// Existing player without a name
+					mock.ExpectExec("INSERT INTO Players").WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec("INSERT INTO Infractions").WillReturnResult(sqlmock.NewResult(0, 1))
+
+					mock.ExpectCommit()
+				})
+
+				g.It("Should record the provided name and not add a name to existing players", func() {
+					err := repo.Import(ctx, infractions)
+
+					Expect(err).To(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+
+				g.It("Should allow the imported player to be fetched by ID", func() {
+					err := repo.Import(ctx, infractions)
+					Expect(err).To(BeNil())
+
+					mock.ExpectQuery("SELECT \\* FROM Players").WithArgs("playerid", "platform").
+						WillReturnRows(sqlmock.NewRows([]string{"PlayerID", "Platform", "Watched", "LastSeen",
+							"CreatedAt", "ModifiedAt"}).
+							AddRow("playerid", "platform", false, infractions[0].CreatedAt.Time,
+								infractions[0].CreatedAt.Time, nil))
+					mock.ExpectQuery("SELECT Name FROM PlayerNames").WithArgs("playerid", "platform").
+						WillReturnRows(sqlmock.NewRows([]string{"Name"}).AddRow("OldName"))
+
+					nameRepo := playername.NewPlayerNameRepo(db, zap.NewNop())
+					playerRepo := player.NewPlayerRepo(db, nameRepo, zap.NewNop())
+
+					foundPlayer, err := playerRepo.GetByID(ctx, "platform", "playerid")
+
+					Expect(err).To(BeNil())
+					Expect(foundPlayer.CurrentName).To(Equal("OldName"))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("An insert fails", func() {
+				g.BeforeEach(func() {
+					mock.ExpectBegin()
+					mock.ExpectPrepare("INSERT INTO Players")
+					mock.ExpectPrepare("INSERT INTO PlayerNames")
+					mock.ExpectPrepare("INSERT INTO Infractions")
+					mock.ExpectExec("INSERT INTO Players").WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectExec("INSERT INTO PlayerNames").WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectExec("INSERT INTO Infractions").WillReturnError(fmt.Errorf("err"))
+					mock.ExpectRollback()
+				})
+
+				g.It("Should roll back and return an error", func() {
+					err := repo.Import(ctx, infractions)
+
+					Expect(err).ToNot(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
 	})
 }
@@ -25,9 +25,12 @@ import (
 	"Refractor/pkg/perms"
 	"Refractor/pkg/whitelist"
 	"context"
+	"github.com/guregu/null"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -37,7 +40,11 @@ type infractionService struct {
 	playerNameRepo  domain.PlayerNameRepo
 	serverRepo      domain.ServerRepo
 	attachmentRepo  domain.AttachmentRepo
+	attachmentStore domain.AttachmentStore
 	userMetaRepo    domain.UserMetaRepo
+	altRepo         domain.AltRepo
+	typeRepo        domain.InfractionTypeRepo
+	ruleRepo        domain.RuleRepo
 	gameService     domain.GameService
 	authorizer      domain.Authorizer
 	commandExecutor domain.CommandExecutor
@@ -46,18 +53,39 @@ type infractionService struct {
 	infractionTypes map[string]domain.InfractionType
 
 	createSubs []domain.InfractionSubscriber
+	expireSubs []domain.InfractionSubscriber
 }
 
+const (
+	// expiryCheckInterval is how often the expiry watcher checks for infractions which have run out.
+	expiryCheckInterval = time.Second * 30
+
+	// expiryBatchSize is the maximum number of expired infractions processed in a single check.
+	expiryBatchSize = 100
+
+	// deletedPurgeInterval is how often the deleted infraction purger checks for infractions past their retention.
+	deletedPurgeInterval = time.Hour
+
+	// moderationEchoWindow is how far back a command run by Refractor against a player is considered to be the cause of
+	// an in-game moderation action.
+	moderationEchoWindow = time.Minute * 2
+)
+
 func NewInfractionService(repo domain.InfractionRepo, pr domain.PlayerRepo, pnr domain.PlayerNameRepo, sr domain.ServerRepo,
-	ar domain.AttachmentRepo, umr domain.UserMetaRepo, gs domain.GameService, a domain.Authorizer,
-	ce domain.CommandExecutor, to time.Duration, log *zap.Logger) domain.InfractionService {
+	ar domain.AttachmentRepo, as domain.AttachmentStore, umr domain.UserMetaRepo, alr domain.AltRepo, itr domain.InfractionTypeRepo,
+	rr domain.RuleRepo, gs domain.GameService, a domain.Authorizer, ce domain.CommandExecutor, to time.Duration,
+	log *zap.Logger) domain.InfractionService {
 	return &infractionService{
 		repo:            repo,
 		playerRepo:      pr,
 		playerNameRepo:  pnr,
 		serverRepo:      sr,
 		attachmentRepo:  ar,
+		attachmentStore: as,
 		userMetaRepo:    umr,
+		altRepo:         alr,
+		typeRepo:        itr,
+		ruleRepo:        rr,
 		gameService:     gs,
 		authorizer:      a,
 		commandExecutor: ce,
@@ -65,6 +93,7 @@ func NewInfractionService(repo domain.InfractionRepo, pr domain.PlayerRepo, pnr
 		logger:          log,
 		infractionTypes: getInfractionTypes(),
 		createSubs:      []domain.InfractionSubscriber{},
+		expireSubs:      []domain.InfractionSubscriber{},
 	}
 }
 
@@ -77,6 +106,115 @@ func getInfractionTypes() map[string]domain.InfractionType {
 	}
 }
 
+// getInfractionType returns the infraction type with the provided name. If it is not a built-in type, the custom types
+// of the game of the server with the provided ID are searched. If no type is found, domain.ErrNotFound is returned.
+func (s *infractionService) getInfractionType(ctx context.Context, serverID int64, name string) (domain.InfractionType, error) {
+	if infractionType := s.infractionTypes[name]; infractionType != nil {
+		return infractionType, nil
+	}
+
+	server, err := s.serverRepo.GetByID(ctx, serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	customType, err := s.typeRepo.GetByName(ctx, server.Game, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Custom{Type: customType}, nil
+}
+
+// getRule returns the rule with the provided ID. If the rule does not exist, an HTTP error is returned.
+func (s *infractionService) getRule(ctx context.Context, id int64) (*domain.Rule, error) {
+	rule, err := s.ruleRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return nil, &domain.HTTPError{
+				Success:          false,
+				Message:          "Input errors exist",
+				ValidationErrors: map[string]string{"rule_id": "rule not found"},
+				Status:           http.StatusBadRequest,
+			}
+		}
+
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+var errInvalidInfractionType = &domain.HTTPError{
+	Success:          false,
+	Message:          "Input errors exist",
+	ValidationErrors: map[string]string{"type": "invalid infraction type"},
+	Status:           http.StatusBadRequest,
+}
+
+// checkCustomInfraction checks a new infraction of a custom type against the type's definition. If a user is set in
+// context, they must have the type's generated permission on the infraction's server.
+func (s *infractionService) checkCustomInfraction(ctx context.Context, infraction *domain.Infraction) error {
+	infractionType, err := s.getInfractionType(ctx, infraction.ServerID, infraction.Type)
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return errInvalidInfractionType
+		}
+
+		return err
+	}
+
+	custom, ok := infractionType.(*types.Custom)
+	if !ok {
+		return nil
+	}
+
+	if user, ok := ctx.Value("user").(*domain.AuthUser); ok {
+		hasPermission, err := s.authorizer.HasPermission(ctx, domain.AuthScope{
+			Type: domain.AuthObjServer,
+			ID:   infraction.ServerID,
+		}, user.Identity.Id, authcheckers.HasPermission(perms.FlagName(custom.Type.PermissionName()), true))
+		if err != nil {
+			return err
+		}
+
+		if !hasPermission {
+			return domain.NewHTTPError(nil, http.StatusUnauthorized,
+				"You do not have permission to create infractions of this type.")
+		}
+	}
+
+	return checkCustomDuration(custom.Type, infraction)
+}
+
+// checkCustomDuration makes sure an infraction's duration matches the duration semantics of its custom type. Durations
+// set on infractions of types without a duration are cleared.
+func checkCustomDuration(customType *domain.CustomInfractionType, infraction *domain.Infraction) error {
+	if !customType.HasDuration {
+		infraction.Duration = null.Int{}
+		return nil
+	}
+
+	durationErr := func(msg string) error {
+		return &domain.HTTPError{
+			Success:          false,
+			Message:          "Input errors exist",
+			ValidationErrors: map[string]string{"duration": msg},
+			Status:           http.StatusBadRequest,
+		}
+	}
+
+	if !infraction.Duration.Valid {
+		return durationErr("duration is required")
+	}
+
+	if infraction.IsPermanent() && !customType.AllowPermanent {
+		return durationErr("infractions of this type cannot be permanent")
+	}
+
+	return nil
+}
+
 func (s *infractionService) Store(c context.Context, infraction *domain.Infraction, attachments []*domain.Attachment,
 	linkedMessages []int64) (*domain.Infraction, error) {
 	ctx, cancel := context.WithTimeout(c, s.timeout)
@@ -121,6 +259,25 @@ func (s *infractionService) Store(c context.Context, infraction *domain.Infracti
 		}
 	}
 
+	// Built-in types are checked by their params and route permissions. Custom types are checked against their definition.
+	if !domain.IsBuiltInInfractionType(infraction.Type) {
+		if err := s.checkCustomInfraction(ctx, infraction); err != nil {
+			return nil, err
+		}
+	}
+
+	// If a rule was broken, make sure it exists. Its title is used as the reason if no reason was provided.
+	if infraction.RuleID.Valid {
+		rule, err := s.getRule(ctx, infraction.RuleID.Int64)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.TrimSpace(infraction.Reason.ValueOrZero()) == "" {
+			infraction.Reason = null.StringFrom(rule.Title)
+		}
+	}
+
 	infraction, err = s.repo.Store(ctx, infraction)
 	if err != nil {
 		return nil, err
@@ -150,13 +307,23 @@ func (s *infractionService) Store(c context.Context, infraction *domain.Infracti
 		}
 	}
 
+	s.handleCreated(ctx, infraction)
+
+	// Check if this infraction triggers any escalation policies
+	infraction.Escalation = s.escalate(ctx, infraction)
+
+	return infraction, nil
+}
+
+// handleCreated queues the creation commands of a newly created infraction and notifies create subscribers.
+func (s *infractionService) handleCreated(ctx context.Context, infraction *domain.Infraction) {
 	// Run infraction creation commands
 	preparedCommands, err := s.commandExecutor.PrepareInfractionCommands(ctx, infraction,
 		domain.InfractionCommandCreate, infraction.ServerID)
 	if err != nil {
 		s.logger.Error("Could not prepare infraction create commands",
 			zap.Error(err))
-		return infraction, nil
+		return
 	}
 
 	// Run commands
@@ -169,8 +336,100 @@ func (s *infractionService) Store(c context.Context, infraction *domain.Infracti
 	for _, sub := range s.createSubs {
 		sub(infraction)
 	}
+}
 
-	return infraction, nil
+// escalate evaluates the escalation policies of the game the infraction was created on. If multiple policies are
+// triggered, the one with the highest trigger count wins. Automatic policies create their infraction as a system
+// action, while other policies are returned as a suggestion for the moderator.
+//
+// Infractions created by an escalation policy do not trigger further escalation. nil is returned if no policy was
+// triggered.
+func (s *infractionService) escalate(ctx context.Context, infraction *domain.Infraction) *domain.EscalationResult {
+	server, err := s.serverRepo.GetByID(ctx, infraction.ServerID)
+	if err != nil {
+		s.logger.Error("Could not get server for escalation", zap.Int64("Server ID", infraction.ServerID), zap.Error(err))
+		return nil
+	}
+
+	game, err := s.gameService.GetGame(server.Game)
+	if err != nil {
+		s.logger.Error("Could not get game for escalation", zap.String("Game", server.Game), zap.Error(err))
+		return nil
+	}
+
+	settings, err := s.gameService.GetGameSettings(game)
+	if err != nil {
+		s.logger.Error("Could not get game settings for escalation", zap.Error(err))
+		return nil
+	}
+
+	var triggered *domain.EscalationPolicy
+
+	for _, policy := range settings.Escalation.PoliciesFor(infraction.Type) {
+		if triggered != nil && policy.TriggerCount <= triggered.TriggerCount {
+			continue
+		}
+
+		since := time.Now().Add(time.Duration(-policy.Timespan) * time.Minute)
+
+		count, err := s.repo.GetPlayerInfractionCountSince(ctx, infraction.Platform, infraction.PlayerID, since,
+			policy.TriggerType)
+		if err != nil {
+			s.logger.Error("Could not get player infraction count for escalation",
+				zap.String("Platform", infraction.Platform),
+				zap.String("Player ID", infraction.PlayerID),
+				zap.Error(err))
+			return nil
+		}
+
+		// Only the infraction which reaches the trigger count escalates, so that every infraction after it does not
+		// apply the policy's action again.
+		if count == policy.TriggerCount {
+			triggered = policy
+		}
+	}
+
+	if triggered == nil {
+		return nil
+	}
+
+	result := &domain.EscalationResult{
+		Policy:  triggered,
+		Applied: false,
+	}
+
+	if !triggered.Automatic {
+		return result
+	}
+
+	escalated := &domain.Infraction{
+		PlayerID:     infraction.PlayerID,
+		Platform:     infraction.Platform,
+		ServerID:     infraction.ServerID,
+		Type:         triggered.ActionType,
+		Reason:       null.NewString(triggered.ActionReason, triggered.ActionReason != ""),
+		SystemAction: true,
+	}
+
+	if triggered.ActionType == domain.InfractionTypeMute || triggered.ActionType == domain.InfractionTypeBan {
+		escalated.Duration = null.IntFrom(triggered.ActionDuration)
+	}
+
+	escalated, err = s.repo.Store(ctx, escalated)
+	if err != nil {
+		s.logger.Error("Could not store escalated infraction",
+			zap.Int64("Triggering Infraction ID", infraction.InfractionID),
+			zap.Error(err))
+		return result
+	}
+
+	escalated.PlayerName = infraction.PlayerName
+	s.handleCreated(ctx, escalated)
+
+	result.Applied = true
+	result.Infraction = escalated
+
+	return result
 }
 
 // GetByID returns an infraction with a matching ID.
@@ -188,6 +447,59 @@ func (s *infractionService) GetByID(c context.Context, id int64) (*domain.Infrac
 		return nil, err
 	}
 
+	if err := s.checkViewPermission(ctx, infraction); err != nil {
+		return nil, err
+	}
+
+	// Get issuer username
+	username, err := s.userMetaRepo.GetUsername(ctx, infraction.UserID.ValueOrZero())
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			// Infractions don't require a user ID, so if no user was found we assume this is an unknown user
+			username = "[UNKNOWN]"
+		} else {
+			s.logger.Error("Could not get infraction issuer's username",
+				zap.Int64("Infraction ID", infraction.InfractionID),
+				zap.String("User ID", infraction.UserID.ValueOrZero()),
+				zap.Error(err),
+			)
+		}
+	}
+
+	infraction.IssuerName = username
+
+	// Get revision history
+	infraction.Revisions, err = s.getRevisions(ctx, infraction.InfractionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return infraction, nil
+}
+
+// GetHistory returns the revision history of an infraction, oldest revision first.
+//
+// If a user is set inside the provided context with the key "user" then permissions are checked against the server
+// this infraction was recorded on. If no user is provided in context, then authorization is skipped.
+func (s *infractionService) GetHistory(c context.Context, id int64) ([]*domain.InfractionRevision, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	infraction, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkViewPermission(ctx, infraction); err != nil {
+		return nil, err
+	}
+
+	return s.getRevisions(ctx, infraction.InfractionID)
+}
+
+// checkViewPermission checks if the user set in context is allowed to view an infraction. If no user is set in
+// context, the check is skipped.
+func (s *infractionService) checkViewPermission(ctx context.Context, infraction *domain.Infraction) error {
 	// Check if a user exists in the context. If they do, check permissions.
 	user, checkAuth := ctx.Value("user").(*domain.AuthUser)
 	isAuthorized := false
@@ -197,9 +509,9 @@ func (s *infractionService) GetByID(c context.Context, id int64) (*domain.Infrac
 		hasPermission, err := s.authorizer.HasPermission(ctx, domain.AuthScope{
 			Type: domain.AuthObjServer,
 			ID:   infraction.ServerID,
-		}, user.Identity.Id, authcheckers.HasOneOfPermissions(true, perms.FlagViewPlayerRecords, perms.FlagViewInfractionRecords))
+		}, user.Identity.Id, authcheckers.CanViewInfraction)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		// NOTE: It may seem a little backwards that we are checking both the FlagViewPlayerRecords or FlagViewInfractionRecords
@@ -219,28 +531,41 @@ func (s *infractionService) GetByID(c context.Context, id int64) (*domain.Infrac
 	}
 
 	if checkAuth && !isAuthorized {
-		return nil, domain.NewHTTPError(nil, http.StatusUnauthorized,
+		return domain.NewHTTPError(nil, http.StatusUnauthorized,
 			"You do not have permission to view this infraction.")
 	}
 
-	// Get issuer username
-	username, err := s.userMetaRepo.GetUsername(ctx, infraction.UserID.ValueOrZero())
+	return nil
+}
+
+// getRevisions returns the revisions of an infraction with the username of each revision's author populated.
+func (s *infractionService) getRevisions(ctx context.Context, id int64) ([]*domain.InfractionRevision, error) {
+	revisions, err := s.repo.GetRevisions(ctx, id)
 	if err != nil {
-		if errors.Cause(err) == domain.ErrNotFound {
-			// Infractions don't require a user ID, so if no user was found we assume this is an unknown user
+		return nil, err
+	}
+
+	for _, revision := range revisions {
+		if !revision.UserID.Valid {
+			revision.Username = "[SYSTEM]"
+			continue
+		}
+
+		username, err := s.userMetaRepo.GetUsername(ctx, revision.UserID.ValueOrZero())
+		if err != nil {
+			if errors.Cause(err) != domain.ErrNotFound {
+				s.logger.Error("Could not get username of infraction revision author",
+					zap.Int64("Revision ID", revision.RevisionID),
+					zap.Error(err))
+			}
+
 			username = "[UNKNOWN]"
-		} else {
-			s.logger.Error("Could not get infraction issuer's username",
-				zap.Int64("Infraction ID", infraction.InfractionID),
-				zap.String("User ID", infraction.UserID.ValueOrZero()),
-				zap.Error(err),
-			)
 		}
-	}
 
-	infraction.IssuerName = username
+		revision.Username = username
+	}
 
-	return infraction, nil
+	return revisions, nil
 }
 
 // GetByPlayer returns all infractions for a player on a given platform.
@@ -340,12 +665,12 @@ func (s *infractionService) GetByPlayer(c context.Context, playerID, platform st
 //
 // When allowing this function to be executed by user requests, make sure they are authorized by setting the user in
 // context under the key "user".
-func (s *infractionService) Update(c context.Context, id int64, args domain.UpdateArgs) (*domain.Infraction, error) {
+func (s *infractionService) Update(c context.Context, id int64, args domain.UpdateArgs, note string) (*domain.Infraction, error) {
 	ctx, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
 	// Update the infraction
-	updated, err := s.update(ctx, id, args)
+	updated, err := s.update(ctx, id, args, note)
 	if err != nil {
 		return nil, err
 	}
@@ -374,15 +699,16 @@ func (s *infractionService) Update(c context.Context, id int64, args domain.Upda
 // are not authorized.
 //
 // When allowing this function to be executed by user requests, make sure they are authorized by setting the user in
-// context under the key "user".
-func (s *infractionService) SetRepealed(c context.Context, id int64, isRepealed bool) (*domain.Infraction, error) {
+// context under the key "user". If the user was already authorized some other way, domain.WithAuthBypass can be used
+// to skip the permission check while still crediting them with the change.
+func (s *infractionService) SetRepealed(c context.Context, id int64, isRepealed bool, note string) (*domain.Infraction, error) {
 	ctx, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
 	// Update the infraction
 	updated, err := s.update(ctx, id, domain.UpdateArgs{
 		"Repealed": isRepealed,
-	})
+	}, note)
 	if err != nil {
 		return nil, err
 	}
@@ -407,8 +733,9 @@ func (s *infractionService) SetRepealed(c context.Context, id int64, isRepealed
 	return updated, nil
 }
 
-// update runs the actual update logic for other update related functions which wrap around it.
-func (s *infractionService) update(ctx context.Context, id int64, args domain.UpdateArgs) (*domain.Infraction, error) {
+// update runs the actual update logic for other update related functions which wrap around it. Each successful update
+// is recorded as a revision of the infraction along with the optional edit note.
+func (s *infractionService) update(ctx context.Context, id int64, args domain.UpdateArgs, note string) (*domain.Infraction, error) {
 	// Get infraction which will be modified
 	infraction, err := s.repo.GetByID(ctx, id)
 	if err != nil {
@@ -418,7 +745,7 @@ func (s *infractionService) update(ctx context.Context, id int64, args domain.Up
 	// Check if the user is present in the passed in context. If they are, run permission checks. Otherwise, assume this
 	// service call was not caused by a user and does not need to be authorized.
 	user, ok := ctx.Value("user").(*domain.AuthUser)
-	if ok {
+	if ok && !domain.HasAuthBypass(ctx) {
 		hasPermission, err := s.hasUpdatePermissions(ctx, infraction, user)
 		if err != nil {
 			return nil, err
@@ -431,7 +758,7 @@ func (s *infractionService) update(ctx context.Context, id int64, args domain.Up
 	}
 
 	// Get filtered args
-	args, err = s.filterUpdateArgs(infraction, args)
+	args, err = s.filterUpdateArgs(ctx, infraction, args)
 	if err != nil {
 		return nil, err
 	}
@@ -445,8 +772,67 @@ func (s *infractionService) update(ctx context.Context, id int64, args domain.Up
 		}
 	}
 
+	// A rule ID of 0 removes the infraction's rule. Any other rule must exist.
+	if ruleID, ok := args["RuleID"].(*int64); ok {
+		if *ruleID == 0 {
+			args["RuleID"] = null.Int{}
+		} else {
+			if _, err := s.getRule(ctx, *ruleID); err != nil {
+				return nil, err
+			}
+
+			args["RuleID"] = null.IntFrom(*ruleID)
+		}
+	}
+
+	// If the duration changed, the infraction's expiry needs to be processed again
+	if _, ok := args["Duration"]; ok && infraction.ExpiredAt.Valid {
+		args["ExpiredAt"] = null.Time{}
+	}
+
 	// Update the infraction
-	return s.repo.Update(ctx, id, args)
+	updated, err := s.repo.Update(ctx, id, args)
+	if err != nil {
+		return nil, err
+	}
+
+	s.storeRevision(ctx, infraction, updated, note)
+
+	return updated, nil
+}
+
+// storeRevision records the changes between two versions of an infraction. Nothing is recorded if no user editable
+// fields were changed.
+func (s *infractionService) storeRevision(ctx context.Context, old, updated *domain.Infraction, note string) {
+	changes := domain.DiffInfractions(old, updated)
+	if len(changes) < 1 {
+		return
+	}
+
+	s.storeChanges(ctx, updated.InfractionID, changes, note)
+}
+
+// storeChanges records a revision of an infraction. The user in context, if any, is credited with the changes.
+func (s *infractionService) storeChanges(ctx context.Context, id int64, changes domain.InfractionChanges, note string) {
+	revision := &domain.InfractionRevision{
+		InfractionID: id,
+		Changes:      changes,
+	}
+
+	if user, ok := ctx.Value("user").(*domain.AuthUser); ok {
+		revision.UserID = null.StringFrom(user.Identity.Id)
+	}
+
+	if note != "" {
+		revision.Note = null.StringFrom(note)
+	}
+
+	if _, err := s.repo.StoreRevision(ctx, revision); err != nil {
+		s.logger.Error("Could not store infraction revision",
+			zap.Int64("Infraction ID", id),
+			zap.Any("Changes", changes),
+			zap.Error(err))
+	}
 }
 
 func (s *infractionService) hasUpdatePermissions(ctx context.Context, infraction *domain.Infraction, user *domain.AuthUser) (bool, error) {
@@ -488,12 +874,16 @@ func (s *infractionService) hasUpdatePermissions(ctx context.Context, infraction
 }
 
 // filterUpdateArgs filters the arguments to only include the allowed update fields of the target infraction type.
-func (s *infractionService) filterUpdateArgs(infraction *domain.Infraction, args domain.UpdateArgs) (domain.UpdateArgs, error) {
+func (s *infractionService) filterUpdateArgs(ctx context.Context, infraction *domain.Infraction, args domain.UpdateArgs) (domain.UpdateArgs, error) {
 	// Get allowed update fields from the infraction type to determine whitelist
-	infractionType := s.infractionTypes[infraction.Type]
-	if infractionType == nil {
-		s.logger.Warn("An attempt was made to update an infraction with an unknown type", zap.String("Type", infraction.Type))
-		return nil, errors.New("invalid infraction type")
+	infractionType, err := s.getInfractionType(ctx, infraction.ServerID, infraction.Type)
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			s.logger.Warn("An attempt was made to update an infraction with an unknown type", zap.String("Type", infraction.Type))
+			return nil, errors.New("invalid infraction type")
+		}
+
+		return nil, err
 	}
 
 	// Create a whitelist from the allowed update fields of this infraction type
@@ -502,16 +892,29 @@ func (s *infractionService) filterUpdateArgs(infraction *domain.Infraction, args
 	// Filter update args with whitelist
 	args = wl.FilterKeys(args)
 
+	// Custom types can forbid permanent durations
+	if custom, ok := infractionType.(*types.Custom); ok && !custom.Type.AllowPermanent {
+		if duration, ok := args["Duration"].(*int); ok && duration != nil && *duration == domain.PermanentInfractionValue {
+			return nil, &domain.HTTPError{
+				Success:          false,
+				Message:          "Input errors exist",
+				ValidationErrors: map[string]string{"duration": "infractions of this type cannot be permanent"},
+				Status:           http.StatusBadRequest,
+			}
+		}
+	}
+
 	return args, nil
 }
 
-// Delete deletes an infraction. If a user is set inside the passed in context with the key "user" then that user's
-// permission to delete the target infraction is checked. Otherwise, calls to this function are seen as trusted and
-// are not authorized.
+// Delete moves an infraction to the trash. The user who deleted it and the optional reason are recorded, and deleted
+// infractions can be restored until they are purged. If a user is set inside the passed in context with the key "user"
+// then that user's permission to delete the target infraction is checked. Otherwise, calls to this function are seen
+// as trusted and are not authorized.
 //
 // When allowing this function to be executed by user requests, make sure they are authorized by setting the user in
 // context under the key "user".
-func (s *infractionService) Delete(c context.Context, id int64) error {
+func (s *infractionService) Delete(c context.Context, id int64, reason string) error {
 	ctx, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
@@ -523,6 +926,8 @@ func (s *infractionService) Delete(c context.Context, id int64) error {
 
 	// Check if the user is present in the passed in context. If they are, run permission checks. Otherwise, assume this
 	// service call was not caused by a user and does not need to be authorized.
+	deletedBy := null.String{}
+
 	user, ok := ctx.Value("user").(*domain.AuthUser)
 	if ok {
 		hasPermission, err := s.hasDeletePermissions(ctx, infraction, user)
@@ -534,12 +939,17 @@ func (s *infractionService) Delete(c context.Context, id int64) error {
 			return domain.NewHTTPError(nil, http.StatusUnauthorized,
 				"You do not have permission to delete this infraction.")
 		}
+
+		deletedBy = null.StringFrom(user.Identity.Id)
 	}
 
-	if err := s.repo.Delete(ctx, id); err != nil {
+	deleted, err := s.repo.SoftDelete(ctx, id, deletedBy, null.NewString(reason, reason != ""))
+	if err != nil {
 		return err
 	}
 
+	s.storeRevision(ctx, infraction, deleted, reason)
+
 	// Run infraction deletion commands
 	preparedCommands, err := s.commandExecutor.PrepareInfractionCommands(ctx, infraction,
 		domain.InfractionCommandDelete, infraction.ServerID)
@@ -558,6 +968,105 @@ func (s *infractionService) Delete(c context.Context, id int64) error {
 	return nil
 }
 
+// Restore takes an infraction out of the trash. If the restored infraction is still in effect, its create commands are
+// queued again so that it is re-applied in game.
+//
+// This method has no built-in authorization checking. Only admins should be able to restore infractions, so this must
+// be enforced before it is called.
+func (s *infractionService) Restore(c context.Context, id int64) (*domain.Infraction, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	restored, err := s.repo.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Record the restore as a single change of the infraction's deleted state
+	s.storeChanges(ctx, restored.InfractionID, domain.InfractionChanges{
+		"deleted": &domain.InfractionFieldChange{Old: true, New: false},
+	}, "")
+
+	if !isInEffect(restored) {
+		return restored, nil
+	}
+
+	// Run infraction creation commands
+	preparedCommands, err := s.commandExecutor.PrepareInfractionCommands(ctx, restored,
+		domain.InfractionCommandCreate, restored.ServerID)
+	if err != nil {
+		s.logger.Error("Could not prepare restored infraction create commands",
+			zap.Int64("Infraction ID", restored.InfractionID),
+			zap.Error(err))
+		return restored, nil
+	}
+
+	// Run commands
+	if err := s.commandExecutor.QueueCommands(preparedCommands); err != nil {
+		s.logger.Error("Could not run restored infraction create commands",
+			zap.Int64("Infraction ID", restored.InfractionID),
+			zap.Error(err))
+	}
+
+	return restored, nil
+}
+
+// isInEffect returns true if an infraction still has an effect in game. Only infractions with a duration which have
+// not been repealed and have not run out are in effect. Warnings and kicks are never in effect after they were issued.
+func isInEffect(infraction *domain.Infraction) bool {
+	if infraction.Repealed || !infraction.Duration.Valid {
+		return false
+	}
+
+	if infraction.IsPermanent() {
+		return true
+	}
+
+	return !infraction.ExpiredAt.Valid && infraction.MinutesRemaining() > 0
+}
+
+// GetDeleted returns a page of the infractions in the trash along with the total number of deleted infractions.
+//
+// This method has no built-in authorization checking. Only admins should be able to view the trash, so this must be
+// enforced before it is called.
+func (s *infractionService) GetDeleted(c context.Context, limit, offset int) (int, []*domain.Infraction, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	total, infractions, err := s.repo.GetDeleted(ctx, limit, offset)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Get the usernames of the issuer and the deleter of each infraction
+	usernames := map[string]string{}
+	getUsername := func(userID null.String) string {
+		if !userID.Valid {
+			return ""
+		}
+
+		if username, ok := usernames[userID.String]; ok {
+			return username
+		}
+
+		username, err := s.userMetaRepo.GetUsername(ctx, userID.String)
+		if err != nil {
+			s.logger.Error("Could not get username", zap.String("User ID", userID.String), zap.Error(err))
+			return ""
+		}
+
+		usernames[userID.String] = username
+		return username
+	}
+
+	for _, infr := range infractions {
+		infr.IssuerName = getUsername(infr.UserID)
+		infr.DeleterName = getUsername(infr.DeletedBy)
+	}
+
+	return total, infractions, nil
+}
+
 func (s *infractionService) hasDeletePermissions(ctx context.Context, infraction *domain.Infraction, user *domain.AuthUser) (bool, error) {
 	// The user will be granted permission to delete this infraction if any of the following paths are satisfied:
 	// 1. The user is an admin or super admin
@@ -810,6 +1319,7 @@ func (s *infractionService) syncMute(ctx context.Context, platform, playerID, na
 
 	// Prepare mute sync commands using infraction sync commands
 	preparedCommands, err := s.commandExecutor.PrepareInfractionCommands(ctx, &domain.CustomInfractionPayload{
+		InfractionID:      currentMute.InfractionID,
 		PlayerID:          playerID,
 		Platform:          platform,
 		PlayerName:        name,
@@ -848,13 +1358,30 @@ func (s *infractionService) syncBan(ctx context.Context, platform, playerID, nam
 		return err
 	}
 
+	reason := "Refractor Ban Sync"
+
 	if currentBan == nil {
-		return nil
+		// If this player isn't banned, check if any of their confirmed linked players are
+		currentBan, err = s.getLinkedBan(ctx, platform, playerID)
+		if err != nil {
+			s.logger.Error("Could not get current bans of linked players",
+				zap.String("Player ID", playerID),
+				zap.String("Platform", platform),
+				zap.Error(err))
+			return err
+		}
+
+		if currentBan == nil {
+			return nil
+		}
+
+		reason = "Refractor Ban Sync (linked account)"
 	}
 
 	s.logger.Info("Syncing bans for player",
 		zap.String("Platform", platform),
-		zap.String("Player ID", playerID))
+		zap.String("Player ID", playerID),
+		zap.Int64("Ban ID", currentBan.InfractionID))
 
 	duration := currentBan.Duration.ValueOrZero()
 	durationRemaining := currentBan.MinutesRemaining()
@@ -875,6 +1402,7 @@ func (s *infractionService) syncBan(ctx context.Context, platform, playerID, nam
 
 	// Prepare mute sync commands using infraction sync commands
 	preparedCommands, err := s.commandExecutor.PrepareInfractionCommands(ctx, &domain.CustomInfractionPayload{
+		InfractionID:      currentBan.InfractionID,
 		PlayerID:          playerID,
 		Platform:          platform,
 		PlayerName:        name,
@@ -882,7 +1410,7 @@ func (s *infractionService) syncBan(ctx context.Context, platform, playerID, nam
 		UserID:            currentBan.UserID.ValueOrZero(),
 		Duration:          duration,
 		DurationRemaining: durationRemaining,
-		Reason:            "Refractor Ban Sync",
+		Reason:            reason,
 	}, domain.InfractionCommandSync, serverID)
 	if err != nil {
 		s.logger.Error("Could not prepare ban sync commands",
@@ -902,19 +1430,392 @@ func (s *infractionService) syncBan(ctx context.Context, platform, playerID, nam
 	return nil
 }
 
+// getLinkedBan returns the current ban of the player's confirmed linked players which has the most time remaining. If
+// none of the linked players are banned, nil is returned.
+func (s *infractionService) getLinkedBan(ctx context.Context, platform, playerID string) (*domain.Infraction, error) {
+	links, err := s.altRepo.GetLinks(ctx, platform, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var linkedBan *domain.Infraction
+
+	for _, link := range links {
+		linkedPlatform, linkedPlayerID := link.Other(platform, playerID)
+
+		ban, err := s.GetCurrentBan(ctx, linkedPlatform, linkedPlayerID)
+		if err != nil {
+			return nil, err
+		}
+
+		if ban == nil {
+			continue
+		}
+
+		// Permanent bans take precedence over all others
+		if ban.Duration.ValueOrZero() == domain.PermanentInfractionValue {
+			return ban, nil
+		}
+
+		if linkedBan == nil || ban.MinutesRemaining() > linkedBan.MinutesRemaining() {
+			linkedBan = ban
+		}
+	}
+
+	return linkedBan, nil
+}
+
+// HandleModerationAction imports a ban, kick or mute which was issued in-game by a server admin as an infraction. The
+// issuing admin is matched to a Refractor user through their linked players. If no linked user is found, the
+// infraction is recorded as a system action.
+//
+// Actions which were caused by Refractor's own infraction commands are echoed back by the server, so they are skipped
+// to avoid importing them a second time. Imported infractions do not run any infraction commands since the action
+// was already carried out on the server.
 func (s *infractionService) HandleModerationAction(fields broadcast.Fields, serverID int64, game domain.Game) {
-	//ctx, cancel := context.WithTimeout(context.TODO(), time.Second*2)
-	//defer cancel()
-	//
-	//adminName := fields["AdminName"]
-	//adminPlayerID := fields["AdminPlayerID"]
-	//playerID := fields["PlayerID"]
-	//duration := fields["Duration"]
-	//reason := fields["Reason"]
+	ctx, cancel := context.WithTimeout(context.TODO(), s.timeout)
+	defer cancel()
+
+	platform := game.GetPlatform().GetName()
+	playerID := fields["PlayerID"]
+	adminPlayerID := fields["AdminPlayerID"]
+
+	var infractionType string
+	switch fields[broadcast.FieldActionType] {
+	case broadcast.TypeBan:
+		infractionType = domain.InfractionTypeBan
+	case broadcast.TypeKick:
+		infractionType = domain.InfractionTypeKick
+	case broadcast.TypeMute:
+		infractionType = domain.InfractionTypeMute
+	default:
+		s.logger.Warn("Unknown moderation action type", zap.String("Type", fields[broadcast.FieldActionType]))
+		return
+	}
+
+	infraction := &domain.Infraction{
+		PlayerID: playerID,
+		Platform: platform,
+		ServerID: serverID,
+		Type:     infractionType,
+		Reason:   null.NewString(fields["Reason"], fields["Reason"] != ""),
+	}
+
+	if infractionType == domain.InfractionTypeBan || infractionType == domain.InfractionTypeMute {
+		duration, err := strconv.ParseInt(fields["Duration"], 10, 64)
+		if err != nil {
+			s.logger.Error("Could not parse moderation action duration",
+				zap.String("Duration", fields["Duration"]),
+				zap.Error(err))
+			return
+		}
+
+		// Games represent permanent actions with a duration of 0 or their permanent duration value
+		if duration == 0 || duration >= game.GetConfig().PermanentDurationValue {
+			duration = domain.PermanentInfractionValue
+		}
+
+		infraction.Duration = null.IntFrom(duration)
+	}
+
+	echoed, err := s.isEchoedAction(ctx, infraction)
+	if err != nil {
+		s.logger.Error("Could not check if moderation action was issued by Refractor",
+			zap.String("Platform", platform),
+			zap.String("Player ID", playerID),
+			zap.Error(err))
+		return
+	}
+
+	if echoed {
+		s.logger.Info("Skipping moderation action issued by Refractor",
+			zap.String("Type", infractionType),
+			zap.String("Platform", platform),
+			zap.String("Player ID", playerID))
+		return
+	}
+
+	// Match the admin to a Refractor user
+	userID, err := s.userMetaRepo.GetLinkedUser(ctx, platform, adminPlayerID)
+	if err != nil {
+		if errors.Cause(err) != domain.ErrNotFound {
+			s.logger.Error("Could not get linked user of admin",
+				zap.String("Platform", platform),
+				zap.String("Admin Player ID", adminPlayerID),
+				zap.Error(err))
+		}
+
+		s.logger.Info("No linked user found for admin. Recording moderation action as a system action.",
+			zap.String("Admin Name", fields["AdminName"]),
+			zap.String("Admin Player ID", adminPlayerID))
+		infraction.SystemAction = true
+	} else {
+		infraction.UserID = null.StringFrom(userID)
+	}
+
+	playerExists, err := s.playerRepo.Exists(ctx, domain.FindArgs{
+		"PlayerID": playerID,
+		"Platform": platform,
+	})
+	if err != nil {
+		s.logger.Error("Could not check if player exists", zap.Error(err))
+		return
+	}
+
+	if !playerExists {
+		s.logger.Warn("Not importing moderation action against unknown player",
+			zap.String("Platform", platform),
+			zap.String("Player ID", playerID))
+		return
+	}
 
-	// NOTE: Functionality paused for now. See issue #3 in RefractorGSCM/Refractor.
+	infraction, err = s.repo.Store(ctx, infraction)
+	if err != nil {
+		s.logger.Error("Could not store imported moderation action",
+			zap.String("Type", infractionType),
+			zap.String("Platform", platform),
+			zap.String("Player ID", playerID),
+			zap.Error(err))
+		return
+	}
+
+	for _, sub := range s.createSubs {
+		sub(infraction)
+	}
+}
+
+// isEchoedAction checks if an in-game moderation action was most likely caused by Refractor. This is the case if
+// Refractor ran a command applying an infraction of the same type to the player on the server within
+// moderationEchoWindow, since that command is what the game is reporting back. Sync, update and expiry commands send
+// the remaining duration rather than the original one, so the reported duration is not compared.
+//
+// Bans and mutes are also skipped if the player already has an active infraction of the same type, as games may
+// report these again when a player rejoins and Refractor enforces the infraction once more.
+func (s *infractionService) isEchoedAction(ctx context.Context, infraction *domain.Infraction) (bool, error) {
+	since := time.Now().Add(-moderationEchoWindow)
+
+	ranCommand, err := s.commandExecutor.HasRecentPunishment(ctx, infraction.ServerID, infraction.Platform,
+		infraction.PlayerID, infraction.Type, since)
+	if err != nil {
+		return false, err
+	}
+
+	if ranCommand {
+		return true, nil
+	}
+
+	if infraction.Type != domain.InfractionTypeBan && infraction.Type != domain.InfractionTypeMute {
+		return false, nil
+	}
+
+	active, err := s.repo.GetMostSignificantInfraction(ctx, infraction.Type, infraction.Platform, infraction.PlayerID)
+	if err != nil {
+		return false, err
+	}
+
+	return active != nil, nil
 }
 
 func (s *infractionService) SubscribeInfractionCreate(sub domain.InfractionSubscriber) {
 	s.createSubs = append(s.createSubs, sub)
 }
+
+func (s *infractionService) SubscribeInfractionExpire(sub domain.InfractionSubscriber) {
+	s.expireSubs = append(s.expireSubs, sub)
+}
+
+// StartExpiryWatcher periodically checks for timed infractions which have run out. Each expired infraction is marked
+// as expired, has its game's expire commands queued if any are set, and is passed to expiry subscribers.
+func (s *infractionService) StartExpiryWatcher(terminate chan uint8) {
+	ticker := time.NewTicker(expiryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-terminate:
+			s.logger.Info("Terminating infraction expiry watcher routine")
+			return
+		case <-ticker.C:
+		}
+
+		s.processExpired()
+	}
+}
+
+// StartDeletedPurger periodically and permanently deletes infractions which have been in the trash for longer than
+// the provided retention period.
+func (s *infractionService) StartDeletedPurger(retention time.Duration, terminate chan uint8) {
+	ticker := time.NewTicker(deletedPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-terminate:
+			s.logger.Info("Terminating deleted infraction purger routine")
+			return
+		case <-ticker.C:
+		}
+
+		s.purgeDeleted(retention)
+	}
+}
+
+func (s *infractionService) purgeDeleted(retention time.Duration) {
+	ctx, cancel := context.WithTimeout(context.TODO(), s.timeout)
+	defer cancel()
+
+	purged, fileKeys, err := s.repo.PurgeDeleted(ctx, time.Now().Add(-retention))
+	if err != nil {
+		s.logger.Error("Could not purge deleted infractions", zap.Error(err))
+		return
+	}
+
+	// Remove the uploaded files which were only attached to the purged infractions
+	for _, key := range fileKeys {
+		if err := s.attachmentStore.Delete(ctx, key); err != nil && errors.Cause(err) != domain.ErrNotFound {
+			s.logger.Error("Could not delete attachment file of purged infraction", zap.String("SHA256", key),
+				zap.Error(err))
+		}
+	}
+
+	if purged > 0 {
+		s.logger.Info("Purged deleted infractions", zap.Int64("Count", purged))
+	}
+}
+
+func (s *infractionService) processExpired() {
+	ctx, cancel := context.WithTimeout(context.TODO(), s.timeout)
+	defer cancel()
+
+	expired, err := s.repo.GetExpired(ctx, expiryBatchSize)
+	if err != nil {
+		s.logger.Error("Could not get expired infractions", zap.Error(err))
+		return
+	}
+
+	for _, infraction := range expired {
+		if err := s.repo.MarkExpired(ctx, infraction.InfractionID, time.Now()); err != nil {
+			if errors.Cause(err) != domain.ErrNotFound {
+				s.logger.Error("Could not mark infraction as expired",
+					zap.Int64("Infraction ID", infraction.InfractionID),
+					zap.Error(err))
+			}
+
+			// If the infraction wasn't found, it was already processed elsewhere
+			continue
+		}
+
+		s.queueExpireCommands(ctx, infraction)
+
+		// Notify subscribers
+		for _, sub := range s.expireSubs {
+			sub(infraction)
+		}
+	}
+}
+
+// queueExpireCommands queues the expire commands for an infraction's game. Expire commands are optional, so nothing
+// is queued if none are set for the infraction's type.
+func (s *infractionService) queueExpireCommands(ctx context.Context, infraction *domain.Infraction) {
+	server, err := s.serverRepo.GetByID(ctx, infraction.ServerID)
+	if err != nil {
+		s.logger.Error("Could not get server of expired infraction",
+			zap.Int64("Infraction ID", infraction.InfractionID),
+			zap.Error(err))
+		return
+	}
+
+	game, err := s.gameService.GetGame(server.Game)
+	if err != nil {
+		s.logger.Error("Could not get game of expired infraction", zap.String("Game", server.Game), zap.Error(err))
+		return
+	}
+
+	settings, err := s.gameService.GetGameSettings(game)
+	if err != nil {
+		s.logger.Error("Could not get game settings", zap.Error(err))
+		return
+	}
+
+	if settings.Commands == nil || settings.Commands.ExpireInfractionCommands == nil ||
+		len(settings.Commands.ExpireInfractionCommands.Map()[infraction.Type]) == 0 {
+		return
+	}
+
+	preparedCommands, err := s.commandExecutor.PrepareInfractionCommands(ctx, infraction,
+		domain.InfractionCommandExpire, infraction.ServerID)
+	if err != nil {
+		s.logger.Error("Could not prepare infraction expire commands",
+			zap.Int64("Infraction ID", infraction.InfractionID),
+			zap.Error(err))
+		return
+	}
+
+	if err := s.commandExecutor.QueueCommands(preparedCommands); err != nil {
+		s.logger.Error("Could not run infraction expire commands",
+			zap.Int64("Infraction ID", infraction.InfractionID),
+			zap.Error(err))
+	}
+}
+
+// PreviewCommands returns the commands which would be queued if an infraction matching the draft was acted on with the
+// provided action on the server with the provided ID. Nothing is stored, queued or executed.
+func (s *infractionService) PreviewCommands(c context.Context, serverID int64, action string,
+	draft *domain.CustomInfractionPayload) ([]*domain.CommandPreview, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	// Make sure the server exists
+	if _, err := s.serverRepo.GetByID(ctx, serverID); err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return nil, domain.NewHTTPError(err, http.StatusBadRequest, "Server not found")
+		}
+
+		return nil, err
+	}
+
+	if _, err := s.getInfractionType(ctx, serverID, draft.Type); err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return nil, errInvalidInfractionType
+		}
+
+		return nil, err
+	}
+
+	if draft.RuleID > 0 {
+		if _, err := s.getRule(ctx, draft.RuleID); err != nil {
+			return nil, err
+		}
+	}
+
+	// The preview is attributed to the user requesting it
+	if user, ok := ctx.Value("user").(*domain.AuthUser); ok {
+		draft.UserID = user.Identity.Id
+	}
+
+	// Players in a draft don't need to exist yet, so fall back to a placeholder name if one isn't known
+	if draft.PlayerName == "" {
+		name, _, err := s.playerNameRepo.GetNames(ctx, draft.PlayerID, draft.Platform)
+		if err != nil && errors.Cause(err) != domain.ErrNotFound {
+			return nil, err
+		}
+
+		if name == "" {
+			name = "[UNKNOWN]"
+		}
+
+		draft.PlayerName = name
+	}
+
+	payload, err := s.commandExecutor.PrepareInfractionCommands(ctx, draft, action, serverID)
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			// No commands are set for this game
+			return []*domain.CommandPreview{}, nil
+		}
+
+		return nil, domain.NewHTTPError(err, http.StatusBadRequest, err.Error())
+	}
+
+	return s.commandExecutor.PreviewCommands(ctx, payload)
+}
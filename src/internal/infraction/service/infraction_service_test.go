@@ -21,8 +21,12 @@ import (
 	"Refractor/domain"
 	"Refractor/domain/mocks"
 	"Refractor/pkg/bitperms"
+	"Refractor/pkg/broadcast"
 	"Refractor/pkg/perms"
+	"Refractor/platforms/playfab"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/franela/goblin"
 	"github.com/guregu/null"
@@ -31,6 +35,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 )
@@ -48,6 +54,8 @@ func Test(t *testing.T) {
 		var serverRepo *mocks.ServerRepo
 		var userMetaRepo *mocks.UserMetaRepo
 		var authorizer *mocks.Authorizer
+		var typeRepo *mocks.InfractionTypeRepo
+		var ruleRepo *mocks.RuleRepo
 		var service *infractionService
 		var ctx = context.TODO()
 
@@ -58,12 +66,16 @@ func Test(t *testing.T) {
 			serverRepo = new(mocks.ServerRepo)
 			userMetaRepo = new(mocks.UserMetaRepo)
 			authorizer = new(mocks.Authorizer)
+			typeRepo = new(mocks.InfractionTypeRepo)
+			ruleRepo = new(mocks.RuleRepo)
 			service = &infractionService{
 				repo:            mockRepo,
 				playerRepo:      playerRepo,
 				playerNameRepo:  playerNameRepo,
 				serverRepo:      serverRepo,
 				userMetaRepo:    userMetaRepo,
+				typeRepo:        typeRepo,
+				ruleRepo:        ruleRepo,
 				authorizer:      authorizer,
 				timeout:         time.Second * 2,
 				logger:          zap.NewNop(),
@@ -190,12 +202,164 @@ func Test(t *testing.T) {
 				})
 
 				g.It("Should return an error", func() {
-					_, err := service.Store(ctx, &domain.Infraction{}, nil, nil)
+					_, err := service.Store(ctx, &domain.Infraction{Type: domain.InfractionTypeWarning}, nil, nil)
 
 					Expect(err).ToNot(BeNil())
 					mockRepo.AssertExpectations(t)
 				})
 			})
+
+			g.Describe("Custom infraction type", func() {
+				var customType *domain.CustomInfractionType
+				var infraction *domain.Infraction
+
+				g.BeforeEach(func() {
+					customType = &domain.CustomInfractionType{
+						TypeID:              1,
+						Game:                "Mordhau",
+						Name:                "VOICE_MUTE",
+						DisplayName:         "Voice Mute",
+						HasDuration:         true,
+						AllowPermanent:      false,
+						AllowedUpdateFields: []string{"Reason", "Duration"},
+					}
+
+					infraction = &domain.Infraction{
+						PlayerID: "playerid",
+						Platform: "platform",
+						ServerID: 1,
+						Type:     "VOICE_MUTE",
+						Reason:   null.NewString("Test reason", true),
+						Duration: null.NewInt(60, true),
+					}
+
+					playerRepo.On("Exists", mock.Anything, mock.Anything).Return(true, nil)
+					serverRepo.On("Exists", mock.Anything, mock.Anything).Return(true, nil)
+					serverRepo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Server{ID: 1, Game: "Mordhau"}, nil)
+				})
+
+				g.Describe("Type does not exist", func() {
+					g.BeforeEach(func() {
+						typeRepo.On("GetByName", mock.Anything, "Mordhau", "VOICE_MUTE").Return(nil, domain.ErrNotFound)
+					})
+
+					g.It("Should return a bad request HTTP error", func() {
+						_, err := service.Store(ctx, infraction, nil, nil)
+
+						httpErr, ok := err.(*domain.HTTPError)
+						Expect(ok).To(BeTrue())
+						Expect(httpErr.Status).To(Equal(http.StatusBadRequest))
+						Expect(httpErr.ValidationErrors).To(HaveKey("type"))
+						mockRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+					})
+				})
+
+				g.Describe("Type exists", func() {
+					g.BeforeEach(func() {
+						typeRepo.On("GetByName", mock.Anything, "Mordhau", "VOICE_MUTE").Return(customType, nil)
+					})
+
+					g.Describe("User does not have the type's permission", func() {
+						g.BeforeEach(func() {
+							authorizer.On("HasPermission", mock.Anything, mock.Anything, "userid", mock.Anything).Return(false, nil)
+						})
+
+						g.It("Should return an unauthorized HTTP error", func() {
+							userCtx := context.WithValue(ctx, "user", &domain.AuthUser{
+								Session: &kratos.Session{
+									Identity: kratos.Identity{
+										Id: "userid",
+									},
+								},
+							})
+
+							_, err := service.Store(userCtx, infraction, nil, nil)
+
+							httpErr, ok := err.(*domain.HTTPError)
+							Expect(ok).To(BeTrue())
+							Expect(httpErr.Status).To(Equal(http.StatusUnauthorized))
+							mockRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+						})
+					})
+
+					g.Describe("Duration was not provided", func() {
+						g.It("Should return a bad request HTTP error", func() {
+							infraction.Duration = null.Int{}
+
+							_, err := service.Store(ctx, infraction, nil, nil)
+
+							httpErr, ok := err.(*domain.HTTPError)
+							Expect(ok).To(BeTrue())
+							Expect(httpErr.ValidationErrors).To(HaveKey("duration"))
+						})
+					})
+
+					g.Describe("Permanent duration is not allowed", func() {
+						g.It("Should return a bad request HTTP error", func() {
+							infraction.Duration = null.NewInt(domain.PermanentInfractionValue, true)
+
+							_, err := service.Store(ctx, infraction, nil, nil)
+
+							httpErr, ok := err.(*domain.HTTPError)
+							Expect(ok).To(BeTrue())
+							Expect(httpErr.ValidationErrors).To(HaveKey("duration"))
+						})
+					})
+
+				})
+			})
+
+			g.Describe("Rule provided", func() {
+				var infraction *domain.Infraction
+
+				g.BeforeEach(func() {
+					infraction = &domain.Infraction{
+						Platform: "platform",
+						PlayerID: "playerid",
+						ServerID: 1,
+						Type:     domain.InfractionTypeWarning,
+						RuleID:   null.IntFrom(3),
+					}
+
+					playerRepo.On("Exists", mock.Anything, mock.Anything).Return(true, nil)
+					serverRepo.On("Exists", mock.Anything, mock.Anything).Return(true, nil)
+				})
+
+				g.Describe("Rule does not exist", func() {
+					g.BeforeEach(func() {
+						ruleRepo.On("GetByID", mock.Anything, int64(3)).Return(nil, domain.ErrNotFound)
+					})
+
+					g.It("Should return a bad request HTTP error", func() {
+						_, err := service.Store(ctx, infraction, nil, nil)
+
+						httpErr, ok := err.(*domain.HTTPError)
+						Expect(ok).To(BeTrue())
+						Expect(httpErr.Status).To(Equal(http.StatusBadRequest))
+						Expect(httpErr.ValidationErrors).To(HaveKey("rule_id"))
+						mockRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+					})
+				})
+			})
+		})
+
+		g.Describe("checkCustomDuration()", func() {
+			g.It("Should clear the duration if the type has no duration", func() {
+				infraction := &domain.Infraction{Duration: null.NewInt(60, true)}
+
+				err := checkCustomDuration(&domain.CustomInfractionType{HasDuration: false}, infraction)
+
+				Expect(err).To(BeNil())
+				Expect(infraction.Duration.Valid).To(BeFalse())
+			})
+
+			g.It("Should allow a permanent duration if the type allows it", func() {
+				infraction := &domain.Infraction{Duration: null.NewInt(domain.PermanentInfractionValue, true)}
+
+				err := checkCustomDuration(&domain.CustomInfractionType{HasDuration: true, AllowPermanent: true}, infraction)
+
+				Expect(err).To(BeNil())
+			})
 		})
 
 		g.Describe("GetByID()", func() {
@@ -219,6 +383,7 @@ func Test(t *testing.T) {
 						}
 
 						mockRepo.On("GetByID", mock.Anything, mock.Anything).Return(mockInfraction, nil)
+						mockRepo.On("GetRevisions", mock.Anything, int64(1)).Return([]*domain.InfractionRevision{}, nil)
 						userMetaRepo.On("GetUsername", mock.Anything, mock.Anything).Return("username", nil)
 					})
 
@@ -273,6 +438,7 @@ func Test(t *testing.T) {
 					g.BeforeEach(func() {
 						authorizer.On("HasPermission", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 							Return(true, nil)
+						mockRepo.On("GetRevisions", mock.Anything, mock.Anything).Return([]*domain.InfractionRevision{}, nil)
 					})
 
 					g.It("Should not return an error", func() {
@@ -299,6 +465,113 @@ func Test(t *testing.T) {
 			})
 		})
 
+		g.Describe("GetHistory()", func() {
+			g.Describe("Revisions found", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Infraction{InfractionID: 1}, nil)
+					mockRepo.On("GetRevisions", mock.Anything, int64(1)).Return([]*domain.InfractionRevision{
+						{RevisionID: 1, InfractionID: 1, UserID: null.StringFrom("userid")},
+						{RevisionID: 2, InfractionID: 1},
+					}, nil)
+					userMetaRepo.On("GetUsername", mock.Anything, "userid").Return("username", nil)
+				})
+
+				g.It("Should return the revisions with usernames populated", func() {
+					revisions, err := service.GetHistory(ctx, 1)
+
+					Expect(err).To(BeNil())
+					Expect(revisions).To(HaveLen(2))
+					Expect(revisions[0].Username).To(Equal("username"))
+					Expect(revisions[1].Username).To(Equal("[SYSTEM]"))
+				})
+			})
+
+			g.Describe("User does not have authorization", func() {
+				g.BeforeEach(func() {
+					ctx = context.WithValue(ctx, "user", &domain.AuthUser{
+						Session: &kratos.Session{Identity: kratos.Identity{Id: "userid"}},
+					})
+
+					mockRepo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Infraction{InfractionID: 1}, nil)
+					authorizer.On("HasPermission", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+						Return(false, nil)
+				})
+
+				g.It("Should return an error", func() {
+					_, err := service.GetHistory(ctx, 1)
+
+					Expect(err).ToNot(BeNil())
+					mockRepo.AssertNotCalled(t, "GetRevisions", mock.Anything, mock.Anything)
+				})
+			})
+		})
+
+		g.Describe("update()", func() {
+			var infraction *domain.Infraction
+
+			g.BeforeEach(func() {
+				infraction = &domain.Infraction{
+					InfractionID: 1,
+					Type:         domain.InfractionTypeBan,
+					Reason:       null.StringFrom("old reason"),
+					Duration:     null.IntFrom(60),
+				}
+
+				mockRepo.On("GetByID", mock.Anything, int64(1)).Return(infraction, nil)
+				mockRepo.On("StoreRevision", mock.Anything, mock.Anything).Return(&domain.InfractionRevision{}, nil)
+			})
+
+			g.Describe("Fields were changed", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("Update", mock.Anything, int64(1), mock.Anything).Return(&domain.Infraction{
+						InfractionID: 1,
+						Type:         domain.InfractionTypeBan,
+						Reason:       null.StringFrom("new reason"),
+						Duration:     null.IntFrom(60),
+					}, nil)
+				})
+
+				g.It("Should store a revision with the changed fields and note", func() {
+					_, err := service.update(ctx, 1, domain.UpdateArgs{"Reason": "new reason"}, "typo")
+
+					Expect(err).To(BeNil())
+					mockRepo.AssertCalled(t, "StoreRevision", mock.Anything, mock.MatchedBy(func(r *domain.InfractionRevision) bool {
+						change := r.Changes["reason"]
+						return r.InfractionID == 1 && len(r.Changes) == 1 && change != nil &&
+							change.Old == null.StringFrom("old reason") && change.New == null.StringFrom("new reason") &&
+							r.Note == null.StringFrom("typo") && !r.UserID.Valid
+					}))
+				})
+
+				g.It("Should credit the user without checking their permissions if auth is bypassed", func() {
+					userCtx := domain.WithAuthBypass(context.WithValue(ctx, "user", &domain.AuthUser{
+						Session: &kratos.Session{Identity: kratos.Identity{Id: "userid"}},
+					}))
+
+					_, err := service.update(userCtx, 1, domain.UpdateArgs{"Reason": "new reason"}, "")
+
+					Expect(err).To(BeNil())
+					authorizer.AssertNotCalled(t, "HasPermission", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+					mockRepo.AssertCalled(t, "StoreRevision", mock.Anything, mock.MatchedBy(func(r *domain.InfractionRevision) bool {
+						return r.UserID == null.StringFrom("userid")
+					}))
+				})
+			})
+
+			g.Describe("No fields were changed", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("Update", mock.Anything, int64(1), mock.Anything).Return(infraction, nil)
+				})
+
+				g.It("Should not store a revision", func() {
+					_, err := service.update(ctx, 1, domain.UpdateArgs{"Reason": "old reason"}, "")
+
+					Expect(err).To(BeNil())
+					mockRepo.AssertNotCalled(t, "StoreRevision", mock.Anything, mock.Anything)
+				})
+			})
+		})
+
 		g.Describe("GetByPlayer()", func() {
 			var mockInfractions []*domain.Infraction
 
@@ -622,7 +895,7 @@ func Test(t *testing.T) {
 				})
 
 				g.It("Should not return an error", func() {
-					_, err := service.filterUpdateArgs(infraction, args)
+					_, err := service.filterUpdateArgs(ctx, infraction, args)
 
 					Expect(err).To(BeNil())
 					mockRepo.AssertExpectations(t)
@@ -633,7 +906,7 @@ func Test(t *testing.T) {
 						"Reason": "Updated Reason",
 					}
 
-					args, err := service.filterUpdateArgs(infraction, args)
+					args, err := service.filterUpdateArgs(ctx, infraction, args)
 
 					Expect(err).To(BeNil())
 					Expect(args).To(Equal(expected))
@@ -647,7 +920,7 @@ func Test(t *testing.T) {
 				})
 
 				g.It("Should not return an error", func() {
-					_, err := service.filterUpdateArgs(infraction, args)
+					_, err := service.filterUpdateArgs(ctx, infraction, args)
 
 					Expect(err).To(BeNil())
 					mockRepo.AssertExpectations(t)
@@ -659,7 +932,7 @@ func Test(t *testing.T) {
 						"Duration": null.NewInt(1000, true),
 					}
 
-					args, err := service.filterUpdateArgs(infraction, args)
+					args, err := service.filterUpdateArgs(ctx, infraction, args)
 
 					Expect(err).To(BeNil())
 					Expect(args).To(Equal(expected))
@@ -673,7 +946,7 @@ func Test(t *testing.T) {
 				})
 
 				g.It("Should not return an error", func() {
-					_, err := service.filterUpdateArgs(infraction, args)
+					_, err := service.filterUpdateArgs(ctx, infraction, args)
 
 					Expect(err).To(BeNil())
 					mockRepo.AssertExpectations(t)
@@ -684,7 +957,7 @@ func Test(t *testing.T) {
 						"Reason": "Updated Reason",
 					}
 
-					args, err := service.filterUpdateArgs(infraction, args)
+					args, err := service.filterUpdateArgs(ctx, infraction, args)
 
 					Expect(err).To(BeNil())
 					Expect(args).To(Equal(expected))
@@ -698,7 +971,7 @@ func Test(t *testing.T) {
 				})
 
 				g.It("Should not return an error", func() {
-					_, err := service.filterUpdateArgs(infraction, args)
+					_, err := service.filterUpdateArgs(ctx, infraction, args)
 
 					Expect(err).To(BeNil())
 					mockRepo.AssertExpectations(t)
@@ -710,7 +983,7 @@ func Test(t *testing.T) {
 						"Duration": null.NewInt(1000, true),
 					}
 
-					args, err := service.filterUpdateArgs(infraction, args)
+					args, err := service.filterUpdateArgs(ctx, infraction, args)
 
 					Expect(err).To(BeNil())
 					Expect(args).To(Equal(expected))
@@ -1020,5 +1293,1087 @@ func Test(t *testing.T) {
 				})
 			})
 		})
+
+		g.Describe("PreviewCommands()", func() {
+			var commandExecutor *mocks.CommandExecutor
+			var draft *domain.CustomInfractionPayload
+
+			g.BeforeEach(func() {
+				commandExecutor = new(mocks.CommandExecutor)
+				service.commandExecutor = commandExecutor
+
+				draft = &domain.CustomInfractionPayload{
+					PlayerID: "playerid",
+					Platform: "platform",
+					Type:     domain.InfractionTypeBan,
+					Duration: 60,
+					Reason:   "reason",
+				}
+			})
+
+			g.Describe("Valid draft", func() {
+				var previews []*domain.CommandPreview
+
+				g.BeforeEach(func() {
+					previews = []*domain.CommandPreview{{Command: "Ban playerid 60 reason", RunOnAll: true, ServerIDs: []int64{1, 2}}}
+
+					serverRepo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Server{ID: 1}, nil)
+					playerNameRepo.On("GetNames", mock.Anything, "playerid", "platform").Return("", nil, domain.ErrNotFound)
+					commandExecutor.On("PrepareInfractionCommands", mock.Anything, draft, domain.InfractionCommandCreate, int64(1)).
+						Return(new(mocks.CommandPayload), nil)
+					commandExecutor.On("PreviewCommands", mock.Anything, mock.Anything).Return(previews, nil)
+				})
+
+				g.It("Should return the previews", func() {
+					res, err := service.PreviewCommands(ctx, 1, domain.InfractionCommandCreate, draft)
+
+					Expect(err).To(BeNil())
+					Expect(res).To(Equal(previews))
+				})
+
+				g.It("Should use a placeholder name for unknown players", func() {
+					_, err := service.PreviewCommands(ctx, 1, domain.InfractionCommandCreate, draft)
+
+					Expect(err).To(BeNil())
+					Expect(draft.PlayerName).To(Equal("[UNKNOWN]"))
+				})
+
+				g.It("Should not queue any commands", func() {
+					_, err := service.PreviewCommands(ctx, 1, domain.InfractionCommandCreate, draft)
+
+					Expect(err).To(BeNil())
+					commandExecutor.AssertNotCalled(t, "QueueCommands", mock.Anything)
+				})
+			})
+
+			g.Describe("No commands set for the game", func() {
+				g.BeforeEach(func() {
+					draft.PlayerName = "name"
+					serverRepo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Server{ID: 1}, nil)
+					commandExecutor.On("PrepareInfractionCommands", mock.Anything, draft, domain.InfractionCommandCreate, int64(1)).
+						Return(nil, domain.ErrNotFound)
+				})
+
+				g.It("Should return an empty slice", func() {
+					res, err := service.PreviewCommands(ctx, 1, domain.InfractionCommandCreate, draft)
+
+					Expect(err).To(BeNil())
+					Expect(res).To(Equal([]*domain.CommandPreview{}))
+				})
+			})
+
+			g.Describe("Server does not exist", func() {
+				g.BeforeEach(func() {
+					serverRepo.On("GetByID", mock.Anything, int64(1)).Return(nil, domain.ErrNotFound)
+				})
+
+				g.It("Should return an error", func() {
+					_, err := service.PreviewCommands(ctx, 1, domain.InfractionCommandCreate, draft)
+
+					Expect(err).ToNot(BeNil())
+					commandExecutor.AssertNotCalled(t, "PrepareInfractionCommands", mock.Anything, mock.Anything,
+						mock.Anything, mock.Anything)
+				})
+			})
+		})
+
+		g.Describe("Delete()", func() {
+			var commandExecutor *mocks.CommandExecutor
+			var payload *mocks.CommandPayload
+			var infraction *domain.Infraction
+
+			g.BeforeEach(func() {
+				commandExecutor = new(mocks.CommandExecutor)
+				payload = new(mocks.CommandPayload)
+				service.commandExecutor = commandExecutor
+
+				infraction = &domain.Infraction{
+					InfractionID: 1,
+					ServerID:     2,
+					Type:         domain.InfractionTypeBan,
+					Duration:     null.IntFrom(60),
+				}
+				deleted := *infraction
+				deleted.DeletedAt = null.TimeFrom(time.Now())
+
+				mockRepo.On("GetByID", mock.Anything, int64(1)).Return(infraction, nil)
+				mockRepo.On("SoftDelete", mock.Anything, int64(1), null.String{}, null.StringFrom("duplicate")).
+					Return(&deleted, nil)
+				mockRepo.On("StoreRevision", mock.Anything, mock.Anything).Return(&domain.InfractionRevision{}, nil)
+				commandExecutor.On("PrepareInfractionCommands", mock.Anything, infraction, domain.InfractionCommandDelete,
+					int64(2)).Return(payload, nil)
+				commandExecutor.On("QueueCommands", payload).Return(nil)
+			})
+
+			g.It("Should move the infraction to the trash with the reason", func() {
+				err := service.Delete(ctx, 1, "duplicate")
+
+				Expect(err).To(BeNil())
+				mockRepo.AssertCalled(t, "SoftDelete", mock.Anything, int64(1), null.String{}, null.StringFrom("duplicate"))
+			})
+
+			g.It("Should record the deletion as a revision", func() {
+				err := service.Delete(ctx, 1, "duplicate")
+
+				Expect(err).To(BeNil())
+				mockRepo.AssertCalled(t, "StoreRevision", mock.Anything, mock.MatchedBy(func(rev *domain.InfractionRevision) bool {
+					return rev.Changes["deleted"] != nil && rev.Note == null.StringFrom("duplicate")
+				}))
+			})
+
+			g.It("Should queue the delete commands", func() {
+				err := service.Delete(ctx, 1, "duplicate")
+
+				Expect(err).To(BeNil())
+				commandExecutor.AssertCalled(t, "QueueCommands", payload)
+			})
+		})
+
+		g.Describe("Restore()", func() {
+			var commandExecutor *mocks.CommandExecutor
+			var payload *mocks.CommandPayload
+
+			g.BeforeEach(func() {
+				commandExecutor = new(mocks.CommandExecutor)
+				payload = new(mocks.CommandPayload)
+				service.commandExecutor = commandExecutor
+
+				mockRepo.On("StoreRevision", mock.Anything, mock.Anything).Return(&domain.InfractionRevision{}, nil)
+				commandExecutor.On("PrepareInfractionCommands", mock.Anything, mock.Anything, domain.InfractionCommandCreate,
+					int64(2)).Return(payload, nil)
+				commandExecutor.On("QueueCommands", payload).Return(nil)
+			})
+
+			g.Describe("Restored infraction is still in effect", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("Restore", mock.Anything, int64(1)).Return(&domain.Infraction{
+						InfractionID: 1,
+						ServerID:     2,
+						Type:         domain.InfractionTypeBan,
+						Duration:     null.IntFrom(60),
+						CreatedAt:    null.TimeFrom(time.Now().Add(-time.Minute * 10)),
+					}, nil)
+				})
+
+				g.It("Should queue the create commands again", func() {
+					_, err := service.Restore(ctx, 1)
+
+					Expect(err).To(BeNil())
+					commandExecutor.AssertCalled(t, "QueueCommands", payload)
+				})
+
+				g.It("Should record the restore as a single revision", func() {
+					_, err := service.Restore(ctx, 1)
+
+					Expect(err).To(BeNil())
+					mockRepo.AssertNumberOfCalls(t, "StoreRevision", 1)
+					mockRepo.AssertCalled(t, "StoreRevision", mock.Anything, mock.MatchedBy(func(r *domain.InfractionRevision) bool {
+						change := r.Changes["deleted"]
+						return r.InfractionID == 1 && len(r.Changes) == 1 && change != nil &&
+							change.Old == true && change.New == false
+					}))
+				})
+			})
+
+			g.Describe("Restored infraction has run out", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("Restore", mock.Anything, int64(1)).Return(&domain.Infraction{
+						InfractionID: 1,
+						ServerID:     2,
+						Type:         domain.InfractionTypeBan,
+						Duration:     null.IntFrom(60),
+						CreatedAt:    null.TimeFrom(time.Now().Add(-time.Hour * 2)),
+					}, nil)
+				})
+
+				g.It("Should not queue any commands", func() {
+					_, err := service.Restore(ctx, 1)
+
+					Expect(err).To(BeNil())
+					commandExecutor.AssertNotCalled(t, "QueueCommands", mock.Anything)
+				})
+			})
+
+			g.Describe("Restored infraction is a warning", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("Restore", mock.Anything, int64(1)).Return(&domain.Infraction{
+						InfractionID: 1,
+						ServerID:     2,
+						Type:         domain.InfractionTypeWarning,
+					}, nil)
+				})
+
+				g.It("Should not queue any commands", func() {
+					_, err := service.Restore(ctx, 1)
+
+					Expect(err).To(BeNil())
+					commandExecutor.AssertNotCalled(t, "QueueCommands", mock.Anything)
+				})
+			})
+		})
+
+		g.Describe("purgeDeleted()", func() {
+			var attachmentStore *mocks.AttachmentStore
+
+			g.BeforeEach(func() {
+				attachmentStore = new(mocks.AttachmentStore)
+				service.attachmentStore = attachmentStore
+			})
+
+			g.It("Should purge infractions deleted before the retention period", func() {
+				mockRepo.On("PurgeDeleted", mock.Anything, mock.Anything).Return(int64(2), nil, nil)
+
+				service.purgeDeleted(time.Hour * 24)
+
+				mockRepo.AssertCalled(t, "PurgeDeleted", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+					return time.Since(before) >= time.Hour*24 && time.Since(before) < time.Hour*25
+				}))
+				attachmentStore.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+			})
+
+			g.It("Should delete the files which are no longer referenced", func() {
+				mockRepo.On("PurgeDeleted", mock.Anything, mock.Anything).Return(int64(2), []string{"hash1", "hash2"}, nil)
+				attachmentStore.On("Delete", mock.Anything, "hash1").Return(errors.Wrap(domain.ErrNotFound, ""))
+				attachmentStore.On("Delete", mock.Anything, "hash2").Return(nil)
+
+				service.purgeDeleted(time.Hour * 24)
+
+				attachmentStore.AssertNumberOfCalls(t, "Delete", 2)
+				attachmentStore.AssertCalled(t, "Delete", mock.Anything, "hash2")
+			})
+
+			g.It("Should not delete any files if the purge failed", func() {
+				mockRepo.On("PurgeDeleted", mock.Anything, mock.Anything).Return(int64(0), nil, fmt.Errorf("err"))
+
+				service.purgeDeleted(time.Hour * 24)
+
+				attachmentStore.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+			})
+		})
+
+		g.Describe("processExpired()", func() {
+			var gameService *mocks.GameService
+			var commandExecutor *mocks.CommandExecutor
+			var game *mocks.Game
+			var expired *domain.Infraction
+			var notified []*domain.Infraction
+
+			g.BeforeEach(func() {
+				gameService = new(mocks.GameService)
+				commandExecutor = new(mocks.CommandExecutor)
+				game = new(mocks.Game)
+				service.gameService = gameService
+				service.commandExecutor = commandExecutor
+
+				notified = []*domain.Infraction{}
+				service.SubscribeInfractionExpire(func(infraction *domain.Infraction) {
+					notified = append(notified, infraction)
+				})
+
+				expired = &domain.Infraction{
+					InfractionID: 1,
+					PlayerID:     "playerid",
+					Platform:     "platform",
+					ServerID:     2,
+					Type:         domain.InfractionTypeBan,
+					Duration:     null.IntFrom(60),
+				}
+
+				mockRepo.On("GetExpired", mock.Anything, expiryBatchSize).Return([]*domain.Infraction{expired}, nil)
+				serverRepo.On("GetByID", mock.Anything, int64(2)).Return(&domain.Server{ID: 2, Game: "testgame"}, nil)
+				gameService.On("GetGame", "testgame").Return(game, nil)
+			})
+
+			g.Describe("Expire commands are set", func() {
+				var payload *mocks.CommandPayload
+
+				g.BeforeEach(func() {
+					payload = new(mocks.CommandPayload)
+
+					gameService.On("GetGameSettings", game).Return(&domain.GameSettings{
+						Commands: &domain.GameCommandSettings{
+							ExpireInfractionCommands: &domain.InfractionCommands{
+								Ban: []*domain.InfractionCommand{{Command: "Unban {{PLAYER_ID}}", RunOnAll: true}},
+							},
+						},
+					}, nil)
+					commandExecutor.On("PrepareInfractionCommands", mock.Anything, expired, domain.InfractionCommandExpire,
+						int64(2)).Return(payload, nil)
+					commandExecutor.On("QueueCommands", payload).Return(nil)
+				})
+
+				g.Describe("Infraction was not yet processed", func() {
+					g.BeforeEach(func() {
+						mockRepo.On("MarkExpired", mock.Anything, int64(1), mock.Anything).Return(nil)
+					})
+
+					g.It("Should queue the expire commands", func() {
+						service.processExpired()
+
+						commandExecutor.AssertCalled(t, "QueueCommands", payload)
+					})
+
+					g.It("Should notify subscribers", func() {
+						service.processExpired()
+
+						Expect(notified).To(Equal([]*domain.Infraction{expired}))
+					})
+				})
+
+				g.Describe("Infraction was already processed", func() {
+					g.BeforeEach(func() {
+						mockRepo.On("MarkExpired", mock.Anything, int64(1), mock.Anything).
+							Return(errors.Wrap(domain.ErrNotFound, ""))
+					})
+
+					g.It("Should not queue commands or notify subscribers", func() {
+						service.processExpired()
+
+						commandExecutor.AssertNotCalled(t, "QueueCommands", mock.Anything)
+						Expect(notified).To(BeEmpty())
+					})
+				})
+			})
+
+			g.Describe("No expire commands are set", func() {
+				g.BeforeEach(func() {
+					gameService.On("GetGameSettings", game).Return(&domain.GameSettings{
+						Commands: &domain.GameCommandSettings{},
+					}, nil)
+					mockRepo.On("MarkExpired", mock.Anything, int64(1), mock.Anything).Return(nil)
+				})
+
+				g.It("Should not queue any commands", func() {
+					service.processExpired()
+
+					commandExecutor.AssertNotCalled(t, "PrepareInfractionCommands", mock.Anything, mock.Anything,
+						mock.Anything, mock.Anything)
+					commandExecutor.AssertNotCalled(t, "QueueCommands", mock.Anything)
+				})
+
+				g.It("Should still notify subscribers", func() {
+					service.processExpired()
+
+					Expect(notified).To(Equal([]*domain.Infraction{expired}))
+				})
+			})
+		})
+
+		g.Describe("escalate()", func() {
+			var gameService *mocks.GameService
+			var commandExecutor *mocks.CommandExecutor
+			var game *mocks.Game
+			var infraction *domain.Infraction
+			var mutePolicy *domain.EscalationPolicy
+			var banPolicy *domain.EscalationPolicy
+
+			g.BeforeEach(func() {
+				gameService = new(mocks.GameService)
+				commandExecutor = new(mocks.CommandExecutor)
+				game = new(mocks.Game)
+				service.gameService = gameService
+				service.commandExecutor = commandExecutor
+
+				infraction = &domain.Infraction{
+					InfractionID: 10,
+					PlayerID:     "playerid",
+					Platform:     "platform",
+					ServerID:     2,
+					Type:         domain.InfractionTypeWarning,
+				}
+
+				mutePolicy = &domain.EscalationPolicy{
+					TriggerType:    domain.InfractionTypeWarning,
+					TriggerCount:   3,
+					Timespan:       10080,
+					ActionType:     domain.InfractionTypeMute,
+					ActionDuration: 60,
+					ActionReason:   "Repeated warnings",
+				}
+
+				banPolicy = &domain.EscalationPolicy{
+					TriggerType:    domain.InfractionTypeWarning,
+					TriggerCount:   5,
+					Timespan:       10080,
+					ActionType:     domain.InfractionTypeBan,
+					ActionDuration: 1440,
+				}
+
+				serverRepo.On("GetByID", mock.Anything, int64(2)).Return(&domain.Server{ID: 2, Game: "testgame"}, nil)
+				gameService.On("GetGame", "testgame").Return(game, nil)
+				gameService.On("GetGameSettings", game).Return(&domain.GameSettings{
+					Escalation: &domain.EscalationSettings{
+						Policies: []*domain.EscalationPolicy{mutePolicy, banPolicy},
+					},
+				}, nil)
+			})
+
+			g.Describe("No policy triggered", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("GetPlayerInfractionCountSince", mock.Anything, "platform", "playerid", mock.Anything,
+						domain.InfractionTypeWarning).Return(2, nil)
+				})
+
+				g.It("Should return nil", func() {
+					Expect(service.escalate(ctx, infraction)).To(BeNil())
+				})
+			})
+
+			g.Describe("Suggested policy triggered", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("GetPlayerInfractionCountSince", mock.Anything, "platform", "playerid", mock.Anything,
+						domain.InfractionTypeWarning).Return(3, nil)
+				})
+
+				g.It("Should suggest the policy without creating an infraction", func() {
+					result := service.escalate(ctx, infraction)
+
+					Expect(result).To(Equal(&domain.EscalationResult{Policy: mutePolicy, Applied: false}))
+					mockRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Higher policy triggered", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("GetPlayerInfractionCountSince", mock.Anything, "platform", "playerid", mock.Anything,
+						domain.InfractionTypeWarning).Return(5, nil)
+				})
+
+				g.It("Should pick the policy with the matching trigger count", func() {
+					result := service.escalate(ctx, infraction)
+
+					Expect(result.Policy).To(Equal(banPolicy))
+				})
+			})
+
+			g.Describe("Trigger count already passed", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("GetPlayerInfractionCountSince", mock.Anything, "platform", "playerid", mock.Anything,
+						domain.InfractionTypeWarning).Return(4, nil)
+				})
+
+				g.It("Should not trigger the policy again", func() {
+					Expect(service.escalate(ctx, infraction)).To(BeNil())
+				})
+			})
+
+			g.Describe("Automatic policy triggered", func() {
+				var stored *domain.Infraction
+
+				g.BeforeEach(func() {
+					mutePolicy.Automatic = true
+					stored = &domain.Infraction{
+						InfractionID: 11,
+						PlayerID:     "playerid",
+						Platform:     "platform",
+						ServerID:     2,
+						Type:         domain.InfractionTypeMute,
+						Duration:     null.IntFrom(60),
+						SystemAction: true,
+					}
+
+					mockRepo.On("GetPlayerInfractionCountSince", mock.Anything, "platform", "playerid", mock.Anything,
+						domain.InfractionTypeWarning).Return(3, nil)
+					mockRepo.On("Store", mock.Anything, mock.Anything).Return(stored, nil)
+					commandExecutor.On("PrepareInfractionCommands", mock.Anything, stored, domain.InfractionCommandCreate,
+						int64(2)).Return(new(mocks.CommandPayload), nil)
+					commandExecutor.On("QueueCommands", mock.Anything).Return(nil)
+				})
+
+				g.It("Should create the infraction as a system action", func() {
+					result := service.escalate(ctx, infraction)
+
+					Expect(result.Applied).To(BeTrue())
+					Expect(result.Infraction).To(Equal(stored))
+					mockRepo.AssertCalled(t, "Store", mock.Anything, mock.MatchedBy(func(i *domain.Infraction) bool {
+						return i.Type == domain.InfractionTypeMute && i.SystemAction && i.Duration.ValueOrZero() == 60 &&
+							i.Reason.ValueOrZero() == "Repeated warnings" && !i.UserID.Valid
+					}))
+				})
+
+				g.It("Should queue the created infraction's commands", func() {
+					service.escalate(ctx, infraction)
+
+					commandExecutor.AssertCalled(t, "QueueCommands", mock.Anything)
+				})
+			})
+		})
+
+		g.Describe("HandleModerationAction()", func() {
+			var game *mocks.Game
+			var commandExecutor *mocks.CommandExecutor
+			var fields broadcast.Fields
+			var notified []*domain.Infraction
+
+			g.BeforeEach(func() {
+				commandExecutor = new(mocks.CommandExecutor)
+				service.commandExecutor = commandExecutor
+
+				game = new(mocks.Game)
+				game.On("GetPlatform").Return(playfab.NewPlayfabPlatform())
+				game.On("GetConfig").Return(&domain.GameConfig{PermanentDurationValue: 99999999})
+
+				notified = []*domain.Infraction{}
+				service.SubscribeInfractionCreate(func(infraction *domain.Infraction) {
+					notified = append(notified, infraction)
+				})
+
+				fields = broadcast.Fields{
+					broadcast.FieldActionType: broadcast.TypeBan,
+					"AdminName":               "Admin",
+					"AdminPlayerID":           "adminid",
+					"PlayerID":                "playerid",
+					"Duration":                "0",
+					"Reason":                  "Cheating",
+				}
+
+				playerRepo.On("Exists", mock.Anything, domain.FindArgs{
+					"PlayerID": "playerid",
+					"Platform": "playfab",
+				}).Return(true, nil)
+			})
+
+			g.Describe("Action was not issued by Refractor", func() {
+				g.BeforeEach(func() {
+					commandExecutor.On("HasRecentPunishment", mock.Anything, int64(2), "playfab", "playerid",
+						domain.InfractionTypeBan, mock.Anything).Return(false, nil)
+					mockRepo.On("GetMostSignificantInfraction", mock.Anything, domain.InfractionTypeBan, "playfab",
+						"playerid").Return(nil, nil)
+					mockRepo.On("Store", mock.Anything, mock.Anything).Return(&domain.Infraction{InfractionID: 1}, nil)
+				})
+
+				g.Describe("Admin is linked to a user", func() {
+					g.BeforeEach(func() {
+						userMetaRepo.On("GetLinkedUser", mock.Anything, "playfab", "adminid").Return("userid", nil)
+					})
+
+					g.It("Should store a permanent ban issued by the linked user", func() {
+						service.HandleModerationAction(fields, 2, game)
+
+						mockRepo.AssertCalled(t, "Store", mock.Anything, mock.MatchedBy(func(i *domain.Infraction) bool {
+							return i.Type == domain.InfractionTypeBan && i.ServerID == 2 &&
+								i.UserID.ValueOrZero() == "userid" && !i.SystemAction &&
+								i.Duration.ValueOrZero() == domain.PermanentInfractionValue &&
+								i.Reason.ValueOrZero() == "Cheating"
+						}))
+					})
+
+					g.It("Should notify create subscribers", func() {
+						service.HandleModerationAction(fields, 2, game)
+
+						Expect(notified).To(Equal([]*domain.Infraction{{InfractionID: 1}}))
+					})
+				})
+
+				g.Describe("Admin is not linked to a user", func() {
+					g.BeforeEach(func() {
+						userMetaRepo.On("GetLinkedUser", mock.Anything, "playfab", "adminid").
+							Return("", errors.Wrap(domain.ErrNotFound, ""))
+					})
+
+					g.It("Should store the infraction as a system action", func() {
+						service.HandleModerationAction(fields, 2, game)
+
+						mockRepo.AssertCalled(t, "Store", mock.Anything, mock.MatchedBy(func(i *domain.Infraction) bool {
+							return i.SystemAction && !i.UserID.Valid
+						}))
+					})
+				})
+			})
+
+			g.Describe("Action was issued by Refractor", func() {
+				g.BeforeEach(func() {
+					commandExecutor.On("HasRecentPunishment", mock.Anything, int64(2), "playfab", "playerid",
+						domain.InfractionTypeBan, mock.Anything).Return(true, nil)
+				})
+
+				g.It("Should not store an infraction", func() {
+					service.HandleModerationAction(fields, 2, game)
+
+					mockRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+					Expect(notified).To(BeEmpty())
+				})
+
+				g.It("Should only match commands run within the echo window", func() {
+					service.HandleModerationAction(fields, 2, game)
+
+					commandExecutor.AssertCalled(t, "HasRecentPunishment", mock.Anything, int64(2), "playfab",
+						"playerid", domain.InfractionTypeBan, mock.MatchedBy(func(since time.Time) bool {
+							return time.Since(since) >= moderationEchoWindow &&
+								time.Since(since) < moderationEchoWindow+time.Second*5
+						}))
+				})
+			})
+
+			g.Describe("Federated ban was synced to the player", func() {
+				g.BeforeEach(func() {
+					// A federated ban sync is queued as a sync command with the remaining duration of the ban, which
+					// the game reports back as a shorter ban than any stored infraction.
+					fields["Duration"] = "1440"
+
+					commandExecutor.On("HasRecentPunishment", mock.Anything, int64(2), "playfab", "playerid",
+						domain.InfractionTypeBan, mock.Anything).Return(true, nil)
+				})
+
+				g.It("Should not store an infraction", func() {
+					service.HandleModerationAction(fields, 2, game)
+
+					mockRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+					Expect(notified).To(BeEmpty())
+				})
+			})
+
+			g.Describe("Banned player rejoined", func() {
+				g.BeforeEach(func() {
+					// The ban was synced when the player joined, but the game reported it after the echo window
+					fields["Duration"] = "30"
+
+					commandExecutor.On("HasRecentPunishment", mock.Anything, int64(2), "playfab", "playerid",
+						domain.InfractionTypeBan, mock.Anything).Return(false, nil)
+					mockRepo.On("GetMostSignificantInfraction", mock.Anything, domain.InfractionTypeBan, "playfab",
+						"playerid").Return(&domain.Infraction{
+						InfractionID: 3,
+						Type:         domain.InfractionTypeBan,
+						Duration:     null.IntFrom(60),
+						CreatedAt:    null.TimeFrom(time.Now().Add(-time.Minute * 30)),
+					}, nil)
+				})
+
+				g.It("Should not store a duplicate ban", func() {
+					service.HandleModerationAction(fields, 2, game)
+
+					mockRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+					Expect(notified).To(BeEmpty())
+				})
+			})
+
+			g.Describe("Player was kicked by an admin after being banned", func() {
+				g.BeforeEach(func() {
+					fields[broadcast.FieldActionType] = broadcast.TypeKick
+
+					commandExecutor.On("HasRecentPunishment", mock.Anything, int64(2), "playfab", "playerid",
+						domain.InfractionTypeKick, mock.Anything).Return(false, nil)
+					mockRepo.On("Store", mock.Anything, mock.Anything).Return(&domain.Infraction{InfractionID: 6}, nil)
+					userMetaRepo.On("GetLinkedUser", mock.Anything, "playfab", "adminid").Return("userid", nil)
+				})
+
+				g.It("Should store the kick without checking for active infractions", func() {
+					service.HandleModerationAction(fields, 2, game)
+
+					mockRepo.AssertNotCalled(t, "GetMostSignificantInfraction", mock.Anything, mock.Anything,
+						mock.Anything, mock.Anything)
+					mockRepo.AssertCalled(t, "Store", mock.Anything, mock.MatchedBy(func(i *domain.Infraction) bool {
+						return i.Type == domain.InfractionTypeKick
+					}))
+				})
+			})
+
+			g.Describe("Echo check failed", func() {
+				g.BeforeEach(func() {
+					commandExecutor.On("HasRecentPunishment", mock.Anything, mock.Anything, mock.Anything,
+						mock.Anything, mock.Anything, mock.Anything).Return(false, errors.New("err"))
+				})
+
+				g.It("Should not store an infraction", func() {
+					service.HandleModerationAction(fields, 2, game)
+
+					mockRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+		})
+		g.Describe("syncBan()", func() {
+			var game *mocks.Game
+			var altRepo *mocks.AltRepo
+			var commandExecutor *mocks.CommandExecutor
+
+			g.BeforeEach(func() {
+				game = new(mocks.Game)
+				game.On("GetConfig").Return(&domain.GameConfig{PermanentDurationValue: 99999999})
+
+				altRepo = new(mocks.AltRepo)
+				service.altRepo = altRepo
+
+				commandExecutor = new(mocks.CommandExecutor)
+				service.commandExecutor = commandExecutor
+				commandExecutor.On("PrepareInfractionCommands", mock.Anything, mock.Anything, domain.InfractionCommandSync,
+					int64(2)).Return(new(mocks.CommandPayload), nil)
+				commandExecutor.On("QueueCommands", mock.Anything).Return(nil)
+
+				mockRepo.On("GetMostSignificantInfraction", mock.Anything, domain.InfractionTypeBan, "playfab",
+					"playerid").Return(nil, nil)
+			})
+
+			g.Describe("A confirmed linked player is banned", func() {
+				g.BeforeEach(func() {
+					altRepo.On("GetLinks", mock.Anything, "playfab", "playerid").Return([]*domain.PlayerLink{
+						{LinkID: 1, PlayerID: "alt1", Platform: "playfab", LinkedPlayerID: "playerid", LinkedPlatform: "playfab"},
+						{LinkID: 2, PlayerID: "playerid", Platform: "playfab", LinkedPlayerID: "alt2", LinkedPlatform: "playfab"},
+					}, nil)
+
+					mockRepo.On("GetMostSignificantInfraction", mock.Anything, domain.InfractionTypeBan, "playfab",
+						"alt1").Return(&domain.Infraction{
+						InfractionID: 10,
+						Duration:     null.IntFrom(60),
+						CreatedAt:    null.TimeFrom(time.Now()),
+					}, nil)
+					mockRepo.On("GetMostSignificantInfraction", mock.Anything, domain.InfractionTypeBan, "playfab",
+						"alt2").Return(&domain.Infraction{
+						InfractionID: 11,
+						Duration:     null.IntFrom(600),
+						CreatedAt:    null.TimeFrom(time.Now()),
+					}, nil)
+				})
+
+				g.It("Should sync the linked ban with the most time remaining to the player", func() {
+					err := service.syncBan(ctx, "playfab", "playerid", "name", 2, game)
+
+					Expect(err).To(BeNil())
+					commandExecutor.AssertCalled(t, "PrepareInfractionCommands", mock.Anything,
+						mock.MatchedBy(func(p *domain.CustomInfractionPayload) bool {
+							return p.InfractionID == 11 && p.PlayerID == "playerid" && p.Duration == 600 &&
+								p.Reason == "Refractor Ban Sync (linked account)"
+						}), domain.InfractionCommandSync, int64(2))
+					commandExecutor.AssertCalled(t, "QueueCommands", mock.Anything)
+				})
+			})
+
+			g.Describe("No linked players are banned", func() {
+				g.BeforeEach(func() {
+					altRepo.On("GetLinks", mock.Anything, "playfab", "playerid").Return([]*domain.PlayerLink{
+						{LinkID: 1, PlayerID: "alt1", Platform: "playfab", LinkedPlayerID: "playerid", LinkedPlatform: "playfab"},
+					}, nil)
+
+					mockRepo.On("GetMostSignificantInfraction", mock.Anything, domain.InfractionTypeBan, "playfab",
+						"alt1").Return(nil, nil)
+				})
+
+				g.It("Should not run any sync commands", func() {
+					err := service.syncBan(ctx, "playfab", "playerid", "name", 2, game)
+
+					Expect(err).To(BeNil())
+					commandExecutor.AssertNotCalled(t, "PrepareInfractionCommands", mock.Anything, mock.Anything,
+						mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Link repo error", func() {
+				g.BeforeEach(func() {
+					altRepo.On("GetLinks", mock.Anything, "playfab", "playerid").Return(nil, fmt.Errorf("err"))
+				})
+
+				g.It("Should return an error", func() {
+					err := service.syncBan(ctx, "playfab", "playerid", "name", 2, game)
+
+					Expect(err).ToNot(BeNil())
+				})
+			})
+		})
+
+		g.Describe("ExportInfractions()", func() {
+			var infractions []*domain.Infraction
+
+			g.BeforeEach(func() {
+				infractions = []*domain.Infraction{
+					{
+						InfractionID: 2,
+						PlayerID:     "playerid",
+						Platform:     "playfab",
+						ServerID:     1,
+						Type:         domain.InfractionTypeBan,
+						Reason:       null.NewString("Cheating, again", true),
+						Duration:     null.NewInt(domain.PermanentInfractionValue, true),
+						CreatedAt:    null.NewTime(time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC), true),
+						UserID:       null.NewString("userid", true),
+						IssuerName:   "SomeAdmin",
+					},
+					{
+						InfractionID: 1,
+						PlayerID:     "playerid",
+						Platform:     "playfab",
+						ServerID:     1,
+						Type:         domain.InfractionTypeWarning,
+						Reason:       null.NewString("Spam", true),
+						CreatedAt:    null.NewTime(time.Date(2019, 1, 2, 8, 30, 0, 0, time.UTC), true),
+						SystemAction: true,
+					},
+				}
+
+				ctx = context.WithValue(ctx, "user", &domain.AuthUser{
+					Session: &kratos.Session{Identity: kratos.Identity{Id: "userid"}},
+				})
+
+				authorizer.On("GetAuthorizedServers", mock.Anything, "userid", mock.Anything).Return([]int64{1}, nil)
+				playerNameRepo.On("GetNames", mock.Anything, "playerid", "playfab").Return("PlayerName", []string{}, nil)
+			})
+
+			g.Describe("CSV export", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("Search", mock.Anything, domain.FindArgs{"Type": "BAN"}, []int64{1}, exportBatchSize, 0).
+						Return(2, infractions, nil)
+				})
+
+				g.It("Should write a header and one row per infraction", func() {
+					buf := &bytes.Buffer{}
+
+					err := service.ExportInfractions(ctx, domain.FindArgs{"Type": "BAN", "Illegal": "x"},
+						domain.InfractionTransferFormatCSV, buf)
+
+					Expect(err).To(BeNil())
+					Expect(buf.String()).To(Equal(strings.Join([]string{
+						"id,player_id,platform,player_name,type,duration,reason,date,issuer_name,user_id,server_id,system_action,repealed",
+						`2,playerid,playfab,PlayerName,BAN,-1,"Cheating, again",2020-05-01T12:00:00Z,SomeAdmin,userid,1,false,false`,
+						"1,playerid,playfab,PlayerName,WARNING,,Spam,2019-01-02T08:30:00Z,,,1,true,false",
+					}, "\n") + "\n"))
+					mockRepo.AssertExpectations(t)
+				})
+
+				g.It("Should only look up each player name once", func() {
+					err := service.ExportInfractions(ctx, domain.FindArgs{"Type": "BAN"},
+						domain.InfractionTransferFormatCSV, &bytes.Buffer{})
+
+					Expect(err).To(BeNil())
+					playerNameRepo.AssertNumberOfCalls(t, "GetNames", 1)
+				})
+			})
+
+			g.Describe("Export filtered by rule", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("Search", mock.Anything, domain.FindArgs{"RuleID": null.IntFrom(3)}, []int64{1},
+						exportBatchSize, 0).Return(1, infractions[:1], nil)
+				})
+
+				g.It("Should pass the rule filter to the search", func() {
+					ruleID := int64(3)
+
+					err := service.ExportInfractions(ctx, domain.FindArgs{"RuleID": &ruleID},
+						domain.InfractionTransferFormatCSV, &bytes.Buffer{})
+
+					Expect(err).To(BeNil())
+					mockRepo.AssertExpectations(t)
+				})
+			})
+
+			g.Describe("JSON export", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("Search", mock.Anything, domain.FindArgs{}, []int64{1}, exportBatchSize, 0).
+						Return(2, infractions, nil)
+				})
+
+				g.It("Should write a valid JSON array", func() {
+					buf := &bytes.Buffer{}
+
+					err := service.ExportInfractions(ctx, domain.FindArgs{}, domain.InfractionTransferFormatJSON, buf)
+
+					var exported []*exportedInfraction
+
+					Expect(err).To(BeNil())
+					Expect(json.Unmarshal(buf.Bytes(), &exported)).To(BeNil())
+					Expect(exported).To(HaveLen(2))
+					Expect(exported[0].Duration).To(Equal(null.NewInt(-1, true)))
+					Expect(exported[1].Duration.Valid).To(BeFalse())
+					Expect(exported[1].Date).To(Equal("2019-01-02T08:30:00Z"))
+				})
+			})
+
+			g.Describe("More infractions than fit in one batch", func() {
+				g.BeforeEach(func() {
+					fullBatch := make([]*domain.Infraction, exportBatchSize)
+					for i := range fullBatch {
+						fullBatch[i] = infractions[1]
+					}
+
+					mockRepo.On("Search", mock.Anything, domain.FindArgs{}, []int64{1}, exportBatchSize, 0).
+						Return(exportBatchSize+1, fullBatch, nil)
+					mockRepo.On("Search", mock.Anything, domain.FindArgs{}, []int64{1}, exportBatchSize, exportBatchSize).
+						Return(exportBatchSize+1, infractions[:1], nil)
+				})
+
+				g.It("Should fetch every batch", func() {
+					buf := &bytes.Buffer{}
+
+					err := service.ExportInfractions(ctx, domain.FindArgs{}, domain.InfractionTransferFormatCSV, buf)
+
+					Expect(err).To(BeNil())
+					Expect(strings.Count(buf.String(), "\n")).To(Equal(exportBatchSize + 2))
+					mockRepo.AssertExpectations(t)
+				})
+			})
+
+			g.Describe("User is not authorized on any servers", func() {
+				g.BeforeEach(func() {
+					authorizer.ExpectedCalls = nil
+					authorizer.On("GetAuthorizedServers", mock.Anything, "userid", mock.Anything).
+						Return(nil, domain.ErrNotFound)
+				})
+
+				g.It("Should write an empty export without searching", func() {
+					buf := &bytes.Buffer{}
+
+					err := service.ExportInfractions(ctx, domain.FindArgs{}, domain.InfractionTransferFormatJSON, buf)
+
+					Expect(err).To(BeNil())
+					Expect(buf.String()).To(Equal("[]"))
+					mockRepo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				})
+			})
+		})
+
+		g.Describe("ImportInfractions()", func() {
+			var imported []*domain.Infraction
+
+			g.BeforeEach(func() {
+				imported = nil
+
+				serverRepo.On("Exists", mock.Anything, domain.FindArgs{"ServerID": int64(1)}).Return(true, nil)
+				mockRepo.On("Import", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+					imported = args.Get(1).([]*domain.Infraction)
+				}).Return(nil)
+			})
+
+			g.Describe("CSV file with valid and invalid rows", func() {
+				var file string
+
+				g.BeforeEach(func() {
+					file = strings.Join([]string{
+						"Player ID,Platform,Player Name,Type,Duration,Reason,Date,Issuer Name",
+						"p1,playfab, Cheater ,ban,,Aimbot,2018-03-04,OldAdmin",
+						"p2,PlayFab,,Mute,60,Spam,2018-03-04 10:00:00,",
+						",playfab,,ban,60,No player,2018-03-04,",
+						"p4,steam,,warning,,Bad platform,2018-03-04,",
+						"p5,playfab,,kick,30,Kick with duration,someday,",
+						"p6,playfab,,ban,0,Zero duration,2018-03-04,",
+					}, "\n")
+				})
+
+				g.It("Should import the valid rows", func() {
+					result, err := service.ImportInfractions(ctx, 1, domain.InfractionTransferFormatCSV, false,
+						strings.NewReader(file))
+
+					Expect(err).To(BeNil())
+					Expect(result.Imported).To(Equal(2))
+					Expect(imported).To(HaveLen(2))
+					Expect(imported[0].PlayerID).To(Equal("p1"))
+					Expect(imported[0].PlayerName).To(Equal("Cheater"))
+					Expect(imported[1].PlayerName).To(Equal(""))
+					Expect(imported[0].Type).To(Equal(domain.InfractionTypeBan))
+					Expect(imported[0].IsPermanent()).To(BeTrue())
+					Expect(imported[0].ServerID).To(Equal(int64(1)))
+					Expect(imported[0].CreatedAt.ValueOrZero()).To(Equal(time.Date(2018, 3, 4, 0, 0, 0, 0, time.UTC)))
+					Expect(imported[0].ExpiredAt.Valid).To(BeFalse())
+					Expect(imported[1].Platform).To(Equal("playfab"))
+					Expect(imported[1].Duration).To(Equal(null.NewInt(60, true)))
+				})
+
+				g.It("Should mark bans which already ran out as expired", func() {
+					_, err := service.ImportInfractions(ctx, 1, domain.InfractionTransferFormatCSV, false,
+						strings.NewReader(file))
+
+					Expect(err).To(BeNil())
+					Expect(imported[1].ExpiredAt.Valid).To(BeTrue())
+				})
+
+				g.It("Should report errors for each invalid row", func() {
+					result, err := service.ImportInfractions(ctx, 1, domain.InfractionTransferFormatCSV, false,
+						strings.NewReader(file))
+
+					Expect(err).To(BeNil())
+					Expect(result.Errors).To(HaveLen(4))
+					Expect(result.Errors[0].Row).To(Equal(3))
+					Expect(result.Errors[0].Errors).To(HaveKey("player_id"))
+					Expect(result.Errors[1].Errors).To(HaveKey("platform"))
+					Expect(result.Errors[2].Errors).To(HaveKey("duration"))
+					Expect(result.Errors[2].Errors).To(HaveKey("date"))
+					Expect(result.Errors[3].Errors).To(HaveKey("duration"))
+				})
+
+				g.It("Should keep the issuer name in the reason when issuers are not mapped", func() {
+					result, err := service.ImportInfractions(ctx, 1, domain.InfractionTransferFormatCSV, false,
+						strings.NewReader(file))
+
+					Expect(err).To(BeNil())
+					Expect(imported[0].UserID.Valid).To(BeFalse())
+					Expect(imported[0].Reason.ValueOrZero()).To(Equal("Aimbot (issued by OldAdmin)"))
+					Expect(result.UnmappedIssuers).To(Equal([]string{"OldAdmin"}))
+					userMetaRepo.AssertNotCalled(t, "GetIDByUsername", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("JSON file with issuer mapping", func() {
+				var file string
+
+				g.BeforeEach(func() {
+					file = `[
+						{"player_id": "p1", "platform": "playfab", "type": "BAN", "duration": 1440, "reason": "Griefing",
+						 "date": "2017-06-01T12:00:00Z", "issuer_name": "someadmin"},
+						{"player_id": "p2", "platform": "playfab", "type": "KICK", "reason": "AFK",
+						 "date": "2017-06-02", "issuer_name": "Unknown"},
+						{"player_id": "p3", "platform": "playfab", "type": "WARNING", "reason": "Language",
+						 "date": "2017-06-03", "issuer_name": "SomeAdmin"}
+					]`
+
+					userMetaRepo.On("GetIDByUsername", mock.Anything, "someadmin").Return("userid", nil)
+					userMetaRepo.On("GetIDByUsername", mock.Anything, "Unknown").
+						Return("", errors.Wrap(domain.ErrNotFound, ""))
+				})
+
+				g.It("Should attribute infractions to matching users", func() {
+					result, err := service.ImportInfractions(ctx, 1, domain.InfractionTransferFormatJSON, true,
+						strings.NewReader(file))
+
+					Expect(err).To(BeNil())
+					Expect(result.Imported).To(Equal(3))
+					Expect(imported[0].UserID).To(Equal(null.NewString("userid", true)))
+					Expect(imported[0].Duration).To(Equal(null.NewInt(1440, true)))
+					Expect(imported[0].Reason.ValueOrZero()).To(Equal("Griefing"))
+					Expect(imported[2].UserID).To(Equal(null.NewString("userid", true)))
+				})
+
+				g.It("Should only look up each issuer once", func() {
+					_, err := service.ImportInfractions(ctx, 1, domain.InfractionTransferFormatJSON, true,
+						strings.NewReader(file))
+
+					Expect(err).To(BeNil())
+					userMetaRepo.AssertNumberOfCalls(t, "GetIDByUsername", 2)
+				})
+
+				g.It("Should fall back to the reason for unknown issuers", func() {
+					result, err := service.ImportInfractions(ctx, 1, domain.InfractionTransferFormatJSON, true,
+						strings.NewReader(file))
+
+					Expect(err).To(BeNil())
+					Expect(imported[1].UserID.Valid).To(BeFalse())
+					Expect(imported[1].Duration.Valid).To(BeFalse())
+					Expect(imported[1].Reason.ValueOrZero()).To(Equal("AFK (issued by Unknown)"))
+					Expect(result.UnmappedIssuers).To(Equal([]string{"Unknown"}))
+				})
+			})
+
+			g.Describe("CSV file is missing a required column", func() {
+				g.It("Should return a bad request error", func() {
+					_, err := service.ImportInfractions(ctx, 1, domain.InfractionTransferFormatCSV, false,
+						strings.NewReader("player_id,platform,type\np1,playfab,BAN"))
+
+					Expect(err).ToNot(BeNil())
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.Status).To(Equal(400))
+					mockRepo.AssertNotCalled(t, "Import", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Server does not exist", func() {
+				g.It("Should return a not found error", func() {
+					serverRepo.On("Exists", mock.Anything, domain.FindArgs{"ServerID": int64(2)}).Return(false, nil)
+
+					_, err := service.ImportInfractions(ctx, 2, domain.InfractionTransferFormatCSV, false,
+						strings.NewReader(""))
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.Status).To(Equal(404))
+				})
+			})
+		})
 	})
 }
@@ -0,0 +1,635 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/authcheckers"
+	"Refractor/domain"
+	"Refractor/pkg/perms"
+	"Refractor/pkg/whitelist"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/guregu/null"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// transferTimeout is used instead of the regular service timeout for imports and exports since they can cover
+	// years of records.
+	transferTimeout = time.Minute * 5
+
+	// exportBatchSize is the number of infractions fetched from the repo at a time while an export is being written.
+	exportBatchSize = 500
+
+	// maxImportRows is the maximum number of rows accepted in a single import file.
+	maxImportRows = 100000
+)
+
+// transferColumns are the columns of a CSV export. Imports read the columns they need by the same names, so a file
+// exported from one Refractor instance can be imported into another.
+var transferColumns = []string{"id", "player_id", "platform", "player_name", "type", "duration", "reason", "date",
+	"issuer_name", "user_id", "server_id", "system_action", "repealed"}
+
+// ExportInfractions writes all infractions matching args to w in the requested format. If a user is provided in the
+// context under the key "user", only infractions on servers the user is authorized to view infractions on are exported.
+//
+// An empty set of args exports every infraction.
+func (s *infractionService) ExportInfractions(c context.Context, args domain.FindArgs, format string, w io.Writer) error {
+	ctx, cancel := context.WithTimeout(c, transferTimeout)
+	defer cancel()
+
+	// Filter out illegal values
+	wl := whitelist.StringKeyMap([]string{"Type", "Game", "PlayerID", "Platform", "ServerID", "UserID", "RuleID"})
+	args = wl.FilterKeys(args)
+
+	if ruleID, ok := args["RuleID"].(*int64); ok {
+		args["RuleID"] = null.IntFrom(*ruleID)
+	}
+
+	var authorizedServers []int64 = nil
+	hasServers := true
+
+	if user, ok := ctx.Value("user").(*domain.AuthUser); ok {
+		var err error
+		authorizedServers, err = s.authorizer.GetAuthorizedServers(ctx, user.Identity.Id,
+			authcheckers.HasPermission(perms.FlagViewInfractionRecords, true))
+		if err != nil && errors.Cause(err) != domain.ErrNotFound {
+			return err
+		}
+
+		// The repo treats an empty server list as "all servers", so users with no authorized servers are handled here
+		hasServers = len(authorizedServers) > 0
+	}
+
+	ew, err := newExportWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	playerNames := map[string]string{}
+
+	for offset := 0; hasServers; offset += exportBatchSize {
+		_, infractions, err := s.repo.Search(ctx, args, authorizedServers, exportBatchSize, offset)
+		if err != nil && errors.Cause(err) != domain.ErrNotFound {
+			s.logger.Error("Could not fetch infractions for export", zap.Int("Offset", offset), zap.Error(err))
+			return err
+		}
+
+		for _, infraction := range infractions {
+			infraction.PlayerName = s.getExportPlayerName(ctx, playerNames, infraction)
+
+			if err := ew.Write(infraction); err != nil {
+				return err
+			}
+		}
+
+		if len(infractions) < exportBatchSize {
+			break
+		}
+	}
+
+	return ew.Close()
+}
+
+// getExportPlayerName returns the current name of an infraction's player. Names are cached in names for the duration of
+// an export since most players in a large export have more than one infraction.
+func (s *infractionService) getExportPlayerName(ctx context.Context, names map[string]string, infraction *domain.Infraction) string {
+	key := infraction.Platform + ":" + infraction.PlayerID
+
+	if name, ok := names[key]; ok {
+		return name
+	}
+
+	name, _, err := s.playerNameRepo.GetNames(ctx, infraction.PlayerID, infraction.Platform)
+	if err != nil && errors.Cause(err) != domain.ErrNotFound {
+		s.logger.Warn("Could not get player name for infraction export",
+			zap.String("Platform", infraction.Platform),
+			zap.String("Player ID", infraction.PlayerID),
+			zap.Error(err))
+	}
+
+	names[key] = name
+	return name
+}
+
+type exportWriter interface {
+	Write(infraction *domain.Infraction) error
+	Close() error
+}
+
+func newExportWriter(format string, w io.Writer) (exportWriter, error) {
+	switch format {
+	case domain.InfractionTransferFormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(transferColumns); err != nil {
+			return nil, err
+		}
+
+		return &csvExportWriter{w: cw}, nil
+	case domain.InfractionTransferFormatJSON:
+		if _, err := io.WriteString(w, "["); err != nil {
+			return nil, err
+		}
+
+		return &jsonExportWriter{w: w}, nil
+	}
+
+	return nil, domain.NewHTTPError(fmt.Errorf("unknown export format %s", format), http.StatusBadRequest,
+		"Invalid export format")
+}
+
+// exportedInfraction is the representation of an infraction used in exports.
+type exportedInfraction struct {
+	InfractionID int64    `json:"id"`
+	PlayerID     string   `json:"player_id"`
+	Platform     string   `json:"platform"`
+	PlayerName   string   `json:"player_name"`
+	Type         string   `json:"type"`
+	Duration     null.Int `json:"duration"`
+	Reason       string   `json:"reason"`
+	Date         string   `json:"date"`
+	IssuerName   string   `json:"issuer_name"`
+	UserID       string   `json:"user_id"`
+	ServerID     int64    `json:"server_id"`
+	SystemAction bool     `json:"system_action"`
+	Repealed     bool     `json:"repealed"`
+}
+
+func newExportedInfraction(i *domain.Infraction) *exportedInfraction {
+	return &exportedInfraction{
+		InfractionID: i.InfractionID,
+		PlayerID:     i.PlayerID,
+		Platform:     i.Platform,
+		PlayerName:   i.PlayerName,
+		Type:         i.Type,
+		Duration:     i.Duration,
+		Reason:       i.Reason.ValueOrZero(),
+		Date:         i.CreatedAt.ValueOrZero().UTC().Format(time.RFC3339),
+		IssuerName:   i.IssuerName,
+		UserID:       i.UserID.ValueOrZero(),
+		ServerID:     i.ServerID,
+		SystemAction: i.SystemAction,
+		Repealed:     i.Repealed,
+	}
+}
+
+// csvRow returns the fields of the infraction in the order of transferColumns.
+func (e *exportedInfraction) csvRow() []string {
+	duration := ""
+	if e.Duration.Valid {
+		duration = strconv.FormatInt(e.Duration.Int64, 10)
+	}
+
+	return []string{
+		strconv.FormatInt(e.InfractionID, 10),
+		e.PlayerID,
+		e.Platform,
+		e.PlayerName,
+		e.Type,
+		duration,
+		e.Reason,
+		e.Date,
+		e.IssuerName,
+		e.UserID,
+		strconv.FormatInt(e.ServerID, 10),
+		strconv.FormatBool(e.SystemAction),
+		strconv.FormatBool(e.Repealed),
+	}
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (ew *csvExportWriter) Write(infraction *domain.Infraction) error {
+	return ew.w.Write(newExportedInfraction(infraction).csvRow())
+}
+
+func (ew *csvExportWriter) Close() error {
+	ew.w.Flush()
+	return ew.w.Error()
+}
+
+// jsonExportWriter writes infractions as a JSON array one element at a time so that large exports are never held in
+// memory all at once.
+type jsonExportWriter struct {
+	w       io.Writer
+	written int
+}
+
+func (ew *jsonExportWriter) Write(infraction *domain.Infraction) error {
+	data, err := json.Marshal(newExportedInfraction(infraction))
+	if err != nil {
+		return err
+	}
+
+	if ew.written > 0 {
+		data = append([]byte(","), data...)
+	}
+
+	if _, err := ew.w.Write(data); err != nil {
+		return err
+	}
+
+	ew.written++
+	return nil
+}
+
+func (ew *jsonExportWriter) Close() error {
+	_, err := io.WriteString(ew.w, "]")
+	return err
+}
+
+// ImportInfractions reads historical infractions from file and stores the valid ones on the server with the provided ID.
+// Every row is validated, and rows with errors are reported in the result rather than failing the import.
+//
+// If mapIssuers is true, issuer names are matched to the usernames of Refractor users and matched infractions are
+// attributed to them. Otherwise, or if no user has the name, the issuer name is appended to the infraction reason so
+// that it is not lost.
+//
+// Imported infractions do not run any infraction commands. Active imported bans are enforced by the regular ban sync
+// when the player next joins.
+func (s *infractionService) ImportInfractions(c context.Context, serverID int64, format string, mapIssuers bool,
+	file io.Reader) (*domain.InfractionImportResult, error) {
+	ctx, cancel := context.WithTimeout(c, transferTimeout)
+	defer cancel()
+
+	// Ensure the server exists
+	serverExists, err := s.serverRepo.Exists(ctx, domain.FindArgs{
+		"ServerID": serverID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !serverExists {
+		return nil, &domain.HTTPError{
+			Cause:   nil,
+			Message: "Server not found",
+			ValidationErrors: map[string]string{
+				"server_id": "server not found",
+			},
+			Status: http.StatusNotFound,
+		}
+	}
+
+	rows, err := readImportRows(format, file)
+	if err != nil {
+		return nil, domain.NewHTTPError(err, http.StatusBadRequest, fmt.Sprintf("Could not read import file: %s", err))
+	}
+
+	result := &domain.InfractionImportResult{
+		UnmappedIssuers: []string{},
+		Errors:          []*domain.InfractionImportRowError{},
+	}
+
+	issuerIDs := map[string]string{}
+	unmapped := map[string]bool{}
+	now := time.Now()
+
+	var infractions []*domain.Infraction
+
+	for idx, row := range rows {
+		infraction, rowErrs := parseImportRow(row, now)
+		if len(rowErrs) > 0 {
+			result.Errors = append(result.Errors, &domain.InfractionImportRowError{
+				Row:    idx + 1,
+				Errors: rowErrs,
+			})
+			continue
+		}
+
+		infraction.ServerID = serverID
+
+		if issuer := strings.TrimSpace(row.IssuerName); issuer != "" {
+			userID := ""
+			if mapIssuers {
+				userID, err = s.getImportIssuerID(ctx, issuerIDs, issuer)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if userID != "" {
+				infraction.UserID = null.NewString(userID, true)
+			} else {
+				unmapped[issuer] = true
+				infraction.Reason = null.NewString(strings.TrimSpace(
+					fmt.Sprintf("%s (issued by %s)", infraction.Reason.ValueOrZero(), issuer)), true)
+			}
+		}
+
+		infractions = append(infractions, infraction)
+	}
+
+	if len(infractions) > 0 {
+		if err := s.repo.Import(ctx, infractions); err != nil {
+			return nil, err
+		}
+	}
+
+	for issuer := range unmapped {
+		result.UnmappedIssuers = append(result.UnmappedIssuers, issuer)
+	}
+	sort.Strings(result.UnmappedIssuers)
+
+	result.Imported = len(infractions)
+
+	s.logger.Info("Infractions imported",
+		zap.Int64("Server ID", serverID),
+		zap.Int("Imported", result.Imported),
+		zap.Int("Rejected", len(result.Errors)))
+
+	return result, nil
+}
+
+// getImportIssuerID returns the ID of the user with the provided username, or an empty string if there is none.
+// Lookups are cached in ids for the duration of an import.
+func (s *infractionService) getImportIssuerID(ctx context.Context, ids map[string]string, issuer string) (string, error) {
+	key := strings.ToLower(issuer)
+
+	if userID, ok := ids[key]; ok {
+		return userID, nil
+	}
+
+	userID, err := s.userMetaRepo.GetIDByUsername(ctx, issuer)
+	if err != nil && errors.Cause(err) != domain.ErrNotFound {
+		s.logger.Error("Could not look up infraction issuer", zap.String("Issuer", issuer), zap.Error(err))
+		return "", err
+	}
+
+	ids[key] = userID
+	return userID, nil
+}
+
+func readImportRows(format string, file io.Reader) ([]*domain.InfractionImportRow, error) {
+	var (
+		rows []*domain.InfractionImportRow
+		err  error
+	)
+
+	switch format {
+	case domain.InfractionTransferFormatCSV:
+		rows, err = readCSVImportRows(file)
+	case domain.InfractionTransferFormatJSON:
+		rows, err = readJSONImportRows(file)
+	default:
+		return nil, fmt.Errorf("unknown format %s", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) < 1 {
+		return nil, fmt.Errorf("file contains no rows")
+	}
+
+	if len(rows) > maxImportRows {
+		return nil, fmt.Errorf("file contains more than %d rows", maxImportRows)
+	}
+
+	return rows, nil
+}
+
+// readCSVImportRows reads import rows from a CSV file. The first line must be a header naming the columns. Column
+// names are matched case-insensitively and unknown columns are ignored.
+func readCSVImportRows(file io.Reader) ([]*domain.InfractionImportRow, error) {
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("file is empty")
+		}
+
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for idx, name := range header {
+		// Spreadsheet software often prefixes CSV files with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[strings.ReplaceAll(name, " ", "_")] = idx
+	}
+
+	for _, required := range []string{"player_id", "platform", "type", "date"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing required column %s", required)
+		}
+	}
+
+	var rows []*domain.InfractionImportRow
+
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		field := func(name string) string {
+			idx, ok := columns[name]
+			if !ok || idx >= len(record) {
+				return ""
+			}
+
+			return record[idx]
+		}
+
+		rows = append(rows, &domain.InfractionImportRow{
+			PlayerID:   field("player_id"),
+			Platform:   field("platform"),
+			PlayerName: field("player_name"),
+			Type:       field("type"),
+			Duration:   field("duration"),
+			Reason:     field("reason"),
+			Date:       field("date"),
+			IssuerName: field("issuer_name"),
+		})
+
+		if len(rows) > maxImportRows {
+			break
+		}
+	}
+
+	return rows, nil
+}
+
+// readJSONImportRows reads import rows from a JSON array of objects. Values of any type are accepted and converted to
+// text so that they can be validated along with the rest of the row.
+func readJSONImportRows(file io.Reader) ([]*domain.InfractionImportRow, error) {
+	dec := json.NewDecoder(file)
+	dec.UseNumber()
+
+	var objects []map[string]interface{}
+	if err := dec.Decode(&objects); err != nil {
+		return nil, err
+	}
+
+	rows := make([]*domain.InfractionImportRow, len(objects))
+
+	for idx, obj := range objects {
+		field := func(name string) string {
+			value, ok := obj[name]
+			if !ok || value == nil {
+				return ""
+			}
+
+			return fmt.Sprint(value)
+		}
+
+		rows[idx] = &domain.InfractionImportRow{
+			PlayerID:   field("player_id"),
+			Platform:   field("platform"),
+			PlayerName: field("player_name"),
+			Type:       field("type"),
+			Duration:   field("duration"),
+			Reason:     field("reason"),
+			Date:       field("date"),
+			IssuerName: field("issuer_name"),
+		}
+	}
+
+	return rows, nil
+}
+
+// importDateLayouts are the date formats accepted in import files.
+var importDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseImportRow validates an import row and converts it to an infraction. If the row is invalid, the returned map
+// holds an error message for each invalid field keyed by the field's JSON name.
+func parseImportRow(row *domain.InfractionImportRow, now time.Time) (*domain.Infraction, map[string]string) {
+	errs := map[string]string{}
+	infraction := &domain.Infraction{}
+
+	// Player
+	infraction.PlayerID = strings.TrimSpace(row.PlayerID)
+	if infraction.PlayerID == "" {
+		errs["player_id"] = "cannot be blank"
+	} else if len(infraction.PlayerID) > 80 {
+		errs["player_id"] = "the length must be no more than 80"
+	}
+
+	platform := strings.ToLower(strings.TrimSpace(row.Platform))
+	for _, p := range domain.AllPlatforms {
+		if platform == p {
+			infraction.Platform = p
+		}
+	}
+
+	if infraction.Platform == "" {
+		errs["platform"] = fmt.Sprintf("must be one of: %s", strings.Join(domain.AllPlatforms, ", "))
+	}
+
+	infraction.PlayerName = strings.TrimSpace(row.PlayerName)
+	if utf8.RuneCountInString(infraction.PlayerName) > 128 {
+		errs["player_name"] = "the length must be no more than 128"
+	}
+
+	// Type and duration
+	infraction.Type = strings.ToUpper(strings.TrimSpace(row.Type))
+	duration := strings.ToLower(strings.TrimSpace(row.Duration))
+
+	switch infraction.Type {
+	case domain.InfractionTypeMute, domain.InfractionTypeBan:
+		// Older tools commonly leave the duration of permanent infractions blank
+		if duration == "" || duration == "permanent" || duration == "perm" {
+			infraction.Duration = null.NewInt(domain.PermanentInfractionValue, true)
+			break
+		}
+
+		value, err := strconv.ParseInt(duration, 10, 64)
+		if err != nil {
+			errs["duration"] = "must be a number of minutes or \"permanent\""
+		} else if value == 0 || value < domain.PermanentInfractionValue || value > math.MaxInt32 {
+			errs["duration"] = "must be a positive number of minutes, or -1 for permanent"
+		} else {
+			infraction.Duration = null.NewInt(value, true)
+		}
+	case domain.InfractionTypeWarning, domain.InfractionTypeKick:
+		if duration != "" && duration != "0" {
+			errs["duration"] = "warnings and kicks cannot have a duration"
+		}
+	default:
+		errs["type"] = "must be one of: WARNING, MUTE, KICK, BAN"
+	}
+
+	// Reason
+	reason := strings.TrimSpace(row.Reason)
+	if len(reason) > 1024 {
+		errs["reason"] = "the length must be no more than 1024"
+	}
+	infraction.Reason = null.NewString(reason, true)
+
+	// Date
+	date := strings.TrimSpace(row.Date)
+	if date == "" {
+		errs["date"] = "cannot be blank"
+	} else {
+		var createdAt time.Time
+		var err error
+
+		for _, layout := range importDateLayouts {
+			if createdAt, err = time.Parse(layout, date); err == nil {
+				break
+			}
+		}
+
+		if err != nil {
+			errs["date"] = "must be a date in the format YYYY-MM-DD, YYYY-MM-DD HH:MM:SS or RFC 3339"
+		} else if createdAt.After(now) {
+			errs["date"] = "cannot be in the future"
+		} else {
+			infraction.CreatedAt = null.NewTime(createdAt, true)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	// Infractions which ran out before they were imported are marked as expired so that the expiry watcher does not
+	// queue expiry commands for them.
+	if infraction.Duration.Valid && !infraction.IsPermanent() && infraction.MinutesRemaining() <= 0 {
+		infraction.ExpiredAt = null.NewTime(now, true)
+	}
+
+	return infraction, nil
+}
@@ -0,0 +1,43 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package types
+
+import "Refractor/domain"
+
+// Custom wraps an admin defined infraction type so it can be used alongside the built-in types.
+type Custom struct {
+	Type *domain.CustomInfractionType
+}
+
+func (c *Custom) Name() string {
+	return c.Type.Name
+}
+
+// AllowedUpdateFields returns the fields allowed by the custom type. The rule is treated as part of the reason, so it can
+// be updated whenever the reason can.
+func (c *Custom) AllowedUpdateFields() []string {
+	fields := c.Type.AllowedUpdateFields
+
+	for _, field := range fields {
+		if field == "Reason" {
+			return append(append([]string{}, fields...), "RuleID")
+		}
+	}
+
+	return fields
+}
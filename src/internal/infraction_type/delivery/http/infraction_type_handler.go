@@ -0,0 +1,175 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package http
+
+import (
+	"Refractor/authcheckers"
+	"Refractor/domain"
+	"Refractor/params"
+	"Refractor/pkg/api"
+	"Refractor/pkg/api/middleware"
+	"Refractor/pkg/structutils"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type infractionTypeHandler struct {
+	service domain.InfractionTypeService
+	logger  *zap.Logger
+}
+
+func ApplyInfractionTypeHandler(apiGroup *echo.Group, s domain.InfractionTypeService, a domain.Authorizer,
+	mware domain.Middleware, log *zap.Logger) {
+	handler := &infractionTypeHandler{
+		service: s,
+		logger:  log,
+	}
+
+	// Create the infraction type routing group
+	typeGroup := apiGroup.Group("/infraction-types", mware.ProtectMiddleware, mware.ActivationMiddleware)
+
+	// Create an enforcer to authorize the user on the various endpoints
+	enforcer := middleware.NewEnforcer(a, domain.AuthScope{
+		Type: domain.AuthObjRefractor,
+	}, log)
+
+	typeGroup.GET("/", handler.GetInfractionTypes)
+	typeGroup.GET("/game/:game", handler.GetGameInfractionTypes)
+	typeGroup.POST("/", handler.CreateInfractionType, enforcer.CheckAuth(authcheckers.DenyAll))      // super admin only
+	typeGroup.PATCH("/:id", handler.UpdateInfractionType, enforcer.CheckAuth(authcheckers.DenyAll))  // super admin only
+	typeGroup.DELETE("/:id", handler.DeleteInfractionType, enforcer.CheckAuth(authcheckers.DenyAll)) // super admin only
+}
+
+func (h *infractionTypeHandler) GetInfractionTypes(c echo.Context) error {
+	infractionTypes, err := h.service.GetAll(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: infractionTypes,
+	})
+}
+
+func (h *infractionTypeHandler) GetGameInfractionTypes(c echo.Context) error {
+	infractionTypes, err := h.service.GetByGame(c.Request().Context(), c.Param("game"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: infractionTypes,
+	})
+}
+
+func (h *infractionTypeHandler) CreateInfractionType(c echo.Context) error {
+	// Validate request body
+	var body params.CreateInfractionTypeParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	infractionType := &domain.CustomInfractionType{
+		Game:                body.Game,
+		Name:                body.Name,
+		DisplayName:         body.DisplayName,
+		HasDuration:         body.HasDuration,
+		AllowPermanent:      body.AllowPermanent,
+		AllowedUpdateFields: body.AllowedUpdateFields,
+	}
+
+	if err := h.service.Store(c.Request().Context(), infractionType); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, &domain.Response{
+		Success: true,
+		Message: "Infraction type created",
+		Payload: infractionType,
+	})
+}
+
+func (h *infractionTypeHandler) UpdateInfractionType(c echo.Context) error {
+	typeIDString := c.Param("id")
+
+	typeID, err := strconv.ParseInt(typeIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid infraction type id"), http.StatusBadRequest, "")
+	}
+
+	// Validate request body
+	var body params.UpdateInfractionTypeParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	// Get update args
+	updateArgs, err := structutils.GetNonNilFieldMap(body)
+	if err != nil {
+		return err
+	}
+
+	if len(updateArgs) < 1 {
+		return c.JSON(http.StatusBadRequest, &domain.Response{
+			Success: false,
+			Message: "No update fields provided",
+		})
+	}
+
+	updated, err := h.service.Update(c.Request().Context(), typeID, updateArgs)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Infraction type updated",
+		Payload: updated,
+	})
+}
+
+func (h *infractionTypeHandler) DeleteInfractionType(c echo.Context) error {
+	typeIDString := c.Param("id")
+
+	typeID, err := strconv.ParseInt(typeIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid infraction type id"), http.StatusBadRequest, "")
+	}
+
+	if err := h.service.Delete(c.Request().Context(), typeID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Infraction type deleted",
+	})
+}
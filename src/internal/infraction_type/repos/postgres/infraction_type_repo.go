@@ -0,0 +1,235 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"Refractor/pkg/querybuilders/psqlqb"
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const opTag = "InfractionTypeRepo.Postgres."
+
+const pgUniqueViolationCode = "23505"
+
+type infractionTypeRepo struct {
+	db     *sql.DB
+	logger *zap.Logger
+	qb     domain.QueryBuilder
+}
+
+func NewInfractionTypeRepo(db *sql.DB, logger *zap.Logger) domain.InfractionTypeRepo {
+	return &infractionTypeRepo{
+		db:     db,
+		logger: logger,
+		qb:     psqlqb.NewPostgresQueryBuilder(),
+	}
+}
+
+func (r *infractionTypeRepo) fetch(ctx context.Context, query string, args ...interface{}) ([]*domain.CustomInfractionType, error) {
+	const op = opTag + "Fetch"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.CustomInfractionType, 0)
+	for rows.Next() {
+		t := &domain.CustomInfractionType{}
+
+		if err := r.scanType(rows, t); err != nil {
+			r.logger.Error("Could not scan infraction type", zap.Error(err))
+			return nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, t)
+	}
+
+	return results, nil
+}
+
+func (r *infractionTypeRepo) Store(ctx context.Context, t *domain.CustomInfractionType) error {
+	const op = opTag + "Store"
+
+	query := `INSERT INTO InfractionTypes (Game, Name, DisplayName, HasDuration, AllowPermanent, AllowedUpdateFields)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING TypeID, CreatedAt;`
+
+	row := r.db.QueryRowContext(ctx, query, t.Game, t.Name, t.DisplayName, t.HasDuration, t.AllowPermanent,
+		pq.Array(t.AllowedUpdateFields))
+	if err := row.Scan(&t.TypeID, &t.CreatedAt); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == pgUniqueViolationCode {
+			return errors.Wrap(domain.ErrConflict, op)
+		}
+
+		r.logger.Error("Could not store infraction type", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *infractionTypeRepo) GetAll(ctx context.Context) ([]*domain.CustomInfractionType, error) {
+	const op = opTag + "GetAll"
+
+	query := "SELECT * FROM InfractionTypes ORDER BY Game, Name;"
+
+	results, err := r.fetch(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return results, nil
+}
+
+func (r *infractionTypeRepo) GetByID(ctx context.Context, id int64) (*domain.CustomInfractionType, error) {
+	const op = opTag + "GetByID"
+
+	query := "SELECT * FROM InfractionTypes WHERE TypeID = $1;"
+
+	results, err := r.fetch(ctx, query, id)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if len(results) == 0 {
+		return nil, errors.Wrap(domain.ErrNotFound, op)
+	}
+
+	return results[0], nil
+}
+
+func (r *infractionTypeRepo) GetByGame(ctx context.Context, game string) ([]*domain.CustomInfractionType, error) {
+	const op = opTag + "GetByGame"
+
+	query := "SELECT * FROM InfractionTypes WHERE Game = $1 ORDER BY Name;"
+
+	results, err := r.fetch(ctx, query, game)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return results, nil
+}
+
+func (r *infractionTypeRepo) GetByName(ctx context.Context, game, name string) (*domain.CustomInfractionType, error) {
+	const op = opTag + "GetByName"
+
+	query := "SELECT * FROM InfractionTypes WHERE Game = $1 AND Name = $2;"
+
+	results, err := r.fetch(ctx, query, game, name)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if len(results) == 0 {
+		return nil, errors.Wrap(domain.ErrNotFound, op)
+	}
+
+	return results[0], nil
+}
+
+func (r *infractionTypeRepo) Update(ctx context.Context, id int64, args domain.UpdateArgs) (*domain.CustomInfractionType, error) {
+	const op = opTag + "Update"
+
+	if fields, ok := args["AllowedUpdateFields"].([]string); ok {
+		args["AllowedUpdateFields"] = pq.Array(fields)
+	}
+
+	query, values := r.qb.BuildUpdateQuery("InfractionTypes", id, "TypeID", args, nil)
+
+	t := &domain.CustomInfractionType{}
+
+	row := r.db.QueryRowContext(ctx, query, values...)
+	if err := r.scanType(row, t); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.Wrap(domain.ErrNotFound, op)
+		}
+
+		r.logger.Error("Could not scan updated infraction type", zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return t, nil
+}
+
+func (r *infractionTypeRepo) Delete(ctx context.Context, id int64) error {
+	const op = opTag + "Delete"
+
+	query := "DELETE FROM InfractionTypes WHERE TypeID = $1;"
+
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Could not get affected rows", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	if rowsAffected < 1 {
+		return errors.Wrap(domain.ErrNotFound, op)
+	}
+
+	return nil
+}
+
+func (r *infractionTypeRepo) IsInUse(ctx context.Context, id int64) (bool, error) {
+	const op = opTag + "IsInUse"
+
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM Infractions i
+			JOIN Servers s ON s.ServerID = i.ServerID
+			JOIN InfractionTypes t ON t.Game = s.Game AND t.Name = i.Type
+			WHERE t.TypeID = $1
+		);
+	`
+
+	var inUse bool
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&inUse); err != nil {
+		r.logger.Error("Could not check if infraction type is in use", zap.Int64("Type ID", id), zap.Error(err))
+		return false, errors.Wrap(err, op)
+	}
+
+	return inUse, nil
+}
+
+// Scan helpers
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (r *infractionTypeRepo) scanType(row rowScanner, t *domain.CustomInfractionType) error {
+	return row.Scan(&t.TypeID, &t.Game, &t.Name, &t.DisplayName, &t.HasDuration, &t.AllowPermanent,
+		pq.Array(&t.AllowedUpdateFields), &t.CreatedAt, &t.ModifiedAt)
+}
@@ -0,0 +1,180 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/franela/goblin"
+	"github.com/lib/pq"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var cols = []string{"TypeID", "Game", "Name", "DisplayName", "HasDuration", "AllowPermanent", "AllowedUpdateFields",
+		"CreatedAt", "ModifiedAt"}
+	var ctx = context.TODO()
+
+	g.Describe("InfractionType Postgres Repo", func() {
+		var repo domain.InfractionTypeRepo
+		var mock sqlmock.Sqlmock
+		var db *sql.DB
+
+		g.BeforeEach(func() {
+			var err error
+
+			db, mock, err = sqlmock.New()
+			if err != nil {
+				t.Fatalf("Could not create new sqlmock instance. Error: %v", err)
+			}
+
+			repo = NewInfractionTypeRepo(db, zap.NewNop())
+		})
+
+		g.After(func() {
+			_ = db.Close()
+		})
+
+		g.Describe("Store()", func() {
+			var infractionType *domain.CustomInfractionType
+
+			g.BeforeEach(func() {
+				infractionType = &domain.CustomInfractionType{
+					Game:                "Mordhau",
+					Name:                "VOICE_MUTE",
+					DisplayName:         "Voice mute",
+					HasDuration:         true,
+					AllowPermanent:      true,
+					AllowedUpdateFields: []string{"Reason", "Duration"},
+				}
+			})
+
+			g.It("Should set the type ID and created at time", func() {
+				createdAt := time.Now()
+
+				mock.ExpectQuery("INSERT INTO InfractionTypes").
+					WithArgs("Mordhau", "VOICE_MUTE", "Voice mute", true, true, pq.Array([]string{"Reason", "Duration"})).
+					WillReturnRows(sqlmock.NewRows([]string{"TypeID", "CreatedAt"}).AddRow(3, createdAt))
+
+				err := repo.Store(ctx, infractionType)
+
+				Expect(err).To(BeNil())
+				Expect(infractionType.TypeID).To(Equal(int64(3)))
+				Expect(infractionType.CreatedAt.ValueOrZero()).To(Equal(createdAt))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return domain.ErrConflict if the type already exists for the game", func() {
+				mock.ExpectQuery("INSERT INTO InfractionTypes").WillReturnError(&pq.Error{Code: pgUniqueViolationCode})
+
+				err := repo.Store(ctx, infractionType)
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrConflict))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("GetByName()", func() {
+			g.It("Should return the scanned infraction type", func() {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM InfractionTypes WHERE Game = $1 AND Name = $2")).
+					WithArgs("Mordhau", "VOICE_MUTE").
+					WillReturnRows(sqlmock.NewRows(cols).
+						AddRow(3, "Mordhau", "VOICE_MUTE", "Voice mute", true, false, "{Reason,Repealed}", time.Now(), nil))
+
+				infractionType, err := repo.GetByName(ctx, "Mordhau", "VOICE_MUTE")
+
+				Expect(err).To(BeNil())
+				Expect(infractionType.TypeID).To(Equal(int64(3)))
+				Expect(infractionType.HasDuration).To(BeTrue())
+				Expect(infractionType.AllowedUpdateFields).To(Equal([]string{"Reason", "Repealed"}))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return domain.ErrNotFound if there is no such type", func() {
+				mock.ExpectQuery("SELECT \\* FROM InfractionTypes").WillReturnRows(sqlmock.NewRows(cols))
+
+				_, err := repo.GetByName(ctx, "Mordhau", "VOICE_MUTE")
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("Update()", func() {
+			g.It("Should store the allowed update fields as an array", func() {
+				mock.ExpectQuery("UPDATE InfractionTypes SET").
+					WithArgs(pq.Array([]string{"Reason"}), int64(3)).
+					WillReturnRows(sqlmock.NewRows(cols).
+						AddRow(3, "Mordhau", "VOICE_MUTE", "Voice mute", true, false, "{Reason}", time.Now(), time.Now()))
+
+				infractionType, err := repo.Update(ctx, 3, domain.UpdateArgs{"AllowedUpdateFields": []string{"Reason"}})
+
+				Expect(err).To(BeNil())
+				Expect(infractionType.AllowedUpdateFields).To(Equal([]string{"Reason"}))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("Delete()", func() {
+			g.It("Should return domain.ErrNotFound if no rows were deleted", func() {
+				mock.ExpectExec("DELETE FROM InfractionTypes").WithArgs(int64(3)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+
+				err := repo.Delete(ctx, 3)
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("IsInUse()", func() {
+			g.It("Should return whether infractions of the type exist", func() {
+				mock.ExpectQuery("SELECT EXISTS").WithArgs(int64(3)).
+					WillReturnRows(sqlmock.NewRows([]string{"Exists"}).AddRow(true))
+
+				inUse, err := repo.IsInUse(ctx, 3)
+
+				Expect(err).To(BeNil())
+				Expect(inUse).To(BeTrue())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return an error if the query fails", func() {
+				mock.ExpectQuery("SELECT EXISTS").WillReturnError(fmt.Errorf("err"))
+
+				_, err := repo.IsInUse(ctx, 3)
+
+				Expect(err).ToNot(BeNil())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+	})
+}
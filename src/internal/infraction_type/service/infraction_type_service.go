@@ -0,0 +1,237 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/domain"
+	"Refractor/pkg/perms"
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+type infractionTypeService struct {
+	repo    domain.InfractionTypeRepo
+	timeout time.Duration
+	logger  *zap.Logger
+}
+
+func NewInfractionTypeService(repo domain.InfractionTypeRepo, to time.Duration, log *zap.Logger) domain.InfractionTypeService {
+	return &infractionTypeService{
+		repo:    repo,
+		timeout: to,
+		logger:  log,
+	}
+}
+
+// Store creates a custom infraction type and registers its generated permission flag.
+func (s *infractionTypeService) Store(c context.Context, infractionType *domain.CustomInfractionType) error {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if err := checkDurationSemantics(infractionType.HasDuration, infractionType.AllowPermanent,
+		infractionType.AllowedUpdateFields); err != nil {
+		return err
+	}
+
+	if err := s.repo.Store(ctx, infractionType); err != nil {
+		if errors.Cause(err) == domain.ErrConflict {
+			return domain.NewHTTPError(err, http.StatusConflict, "An infraction type with this name already exists for this game")
+		}
+
+		return err
+	}
+
+	if err := registerPermission(infractionType); err != nil {
+		// Without a permission flag nobody could create infractions of this type, so don't keep it
+		if err := s.repo.Delete(ctx, infractionType.TypeID); err != nil {
+			s.logger.Error("Could not delete infraction type after failing to register its permission",
+				zap.Int64("Type ID", infractionType.TypeID), zap.Error(err))
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *infractionTypeService) GetAll(c context.Context) ([]*domain.CustomInfractionType, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.repo.GetAll(ctx)
+}
+
+func (s *infractionTypeService) GetByGame(c context.Context, game string) ([]*domain.CustomInfractionType, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.repo.GetByGame(ctx, game)
+}
+
+// Update updates a custom infraction type. The name, game and duration of a type cannot be changed since existing
+// infractions and permissions depend on them.
+func (s *infractionTypeService) Update(c context.Context, id int64, args domain.UpdateArgs) (*domain.CustomInfractionType, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	existing, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return nil, domain.NewHTTPError(err, http.StatusNotFound, "Infraction type not found")
+		}
+
+		return nil, err
+	}
+
+	// Pointer values from update params are dereferenced so that the repo receives the slice itself
+	if fields, ok := args["AllowedUpdateFields"].(*[]string); ok {
+		args["AllowedUpdateFields"] = *fields
+	}
+
+	allowPermanent := existing.AllowPermanent
+	if value, ok := args["AllowPermanent"].(*bool); ok {
+		allowPermanent = *value
+	}
+
+	allowedUpdateFields := existing.AllowedUpdateFields
+	if value, ok := args["AllowedUpdateFields"].([]string); ok {
+		allowedUpdateFields = value
+	}
+
+	if err := checkDurationSemantics(existing.HasDuration, allowPermanent, allowedUpdateFields); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.Update(ctx, id, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// The display name is part of the permission, so register it again
+	if err := registerPermission(updated); err != nil {
+		s.logger.Error("Could not register updated infraction type permission", zap.Int64("Type ID", id), zap.Error(err))
+	}
+
+	return updated, nil
+}
+
+// Delete deletes a custom infraction type and unregisters its permission. Types which infractions have been created
+// with cannot be deleted.
+func (s *infractionTypeService) Delete(c context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	infractionType, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return domain.NewHTTPError(err, http.StatusNotFound, "Infraction type not found")
+		}
+
+		return err
+	}
+
+	inUse, err := s.repo.IsInUse(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if inUse {
+		return domain.NewHTTPError(nil, http.StatusConflict,
+			"Infractions of this type exist. It cannot be deleted until they are removed.")
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	perms.UnregisterCustomPermission(perms.FlagName(infractionType.PermissionName()))
+
+	return nil
+}
+
+func (s *infractionTypeService) RegisterPermissions(c context.Context) error {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	infractionTypes, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, infractionType := range infractionTypes {
+		if err := registerPermission(infractionType); err != nil {
+			s.logger.Error("Could not register infraction type permission",
+				zap.Int64("Type ID", infractionType.TypeID),
+				zap.String("Name", infractionType.Name),
+				zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// registerPermission registers the generated permission flag of a custom infraction type. The type ID is used as the
+// flag index since IDs are never reused.
+func registerPermission(infractionType *domain.CustomInfractionType) error {
+	err := perms.RegisterCustomPermission(perms.Permission{
+		Name:        perms.FlagName(infractionType.PermissionName()),
+		DisplayName: fmt.Sprintf("Log %s (%s)", infractionType.DisplayName, infractionType.Game),
+		Description: fmt.Sprintf("Allows creation of %s records on %s servers.", infractionType.DisplayName,
+			infractionType.Game),
+		Scope: perms.ScopeApp,
+	}, uint(infractionType.TypeID))
+	if err != nil {
+		return domain.NewHTTPError(err, http.StatusInternalServerError, "Could not register infraction type permission")
+	}
+
+	return nil
+}
+
+// checkDurationSemantics makes sure that only types which have a duration can be permanent or have their duration
+// updated.
+func checkDurationSemantics(hasDuration, allowPermanent bool, allowedUpdateFields []string) error {
+	if hasDuration {
+		return nil
+	}
+
+	if allowPermanent {
+		return &domain.HTTPError{
+			Message:          "Input errors exist",
+			ValidationErrors: map[string]string{"allow_permanent": "only types with a duration can be permanent"},
+			Status:           http.StatusBadRequest,
+		}
+	}
+
+	for _, field := range allowedUpdateFields {
+		if field == "Duration" {
+			return &domain.HTTPError{
+				Message: "Input errors exist",
+				ValidationErrors: map[string]string{
+					"allowed_update_fields": "only types with a duration can have their duration updated",
+				},
+				Status: http.StatusBadRequest,
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/domain"
+	"Refractor/domain/mocks"
+	"Refractor/pkg/perms"
+	"context"
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Infraction Type Service", func() {
+		var mockRepo *mocks.InfractionTypeRepo
+		var service *infractionTypeService
+		var ctx = context.TODO()
+
+		g.BeforeEach(func() {
+			mockRepo = new(mocks.InfractionTypeRepo)
+			service = &infractionTypeService{
+				repo:    mockRepo,
+				timeout: time.Second * 2,
+				logger:  zap.NewNop(),
+			}
+		})
+
+		g.Describe("Store()", func() {
+			var infractionType *domain.CustomInfractionType
+
+			g.BeforeEach(func() {
+				infractionType = &domain.CustomInfractionType{
+					Game:                "Mordhau",
+					Name:                "VOICE_MUTE",
+					DisplayName:         "Voice Mute",
+					HasDuration:         true,
+					AllowPermanent:      true,
+					AllowedUpdateFields: []string{"Reason", "Duration"},
+				}
+			})
+
+			g.Describe("Type stored successfully", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("Store", mock.Anything, infractionType).Run(func(args mock.Arguments) {
+						args.Get(1).(*domain.CustomInfractionType).TypeID = 1
+					}).Return(nil)
+				})
+
+				g.AfterEach(func() {
+					perms.UnregisterCustomPermission(perms.FlagName(infractionType.PermissionName()))
+				})
+
+				g.It("Should not return an error", func() {
+					err := service.Store(ctx, infractionType)
+
+					Expect(err).To(BeNil())
+					mockRepo.AssertExpectations(t)
+				})
+
+				g.It("Should register the type's permission", func() {
+					err := service.Store(ctx, infractionType)
+
+					Expect(err).To(BeNil())
+					Expect(perms.GetFlag(perms.FlagName(infractionType.PermissionName()))).ToNot(BeNil())
+				})
+			})
+
+			g.Describe("Type without a duration allows permanent", func() {
+				g.It("Should return a bad request HTTP error", func() {
+					infractionType.HasDuration = false
+					infractionType.AllowedUpdateFields = []string{"Reason"}
+
+					err := service.Store(ctx, infractionType)
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.ValidationErrors).To(HaveKey("allow_permanent"))
+					mockRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Type without a duration allows duration updates", func() {
+				g.It("Should return a bad request HTTP error", func() {
+					infractionType.HasDuration = false
+					infractionType.AllowPermanent = false
+
+					err := service.Store(ctx, infractionType)
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.ValidationErrors).To(HaveKey("allowed_update_fields"))
+					mockRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Type already exists", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("Store", mock.Anything, mock.Anything).Return(domain.ErrConflict)
+				})
+
+				g.It("Should return a conflict HTTP error", func() {
+					err := service.Store(ctx, infractionType)
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.Status).To(Equal(http.StatusConflict))
+				})
+			})
+		})
+
+		g.Describe("Update()", func() {
+			g.BeforeEach(func() {
+				mockRepo.On("GetByID", mock.Anything, int64(1)).Return(&domain.CustomInfractionType{
+					TypeID:              1,
+					Game:                "Mordhau",
+					Name:                "WATCHLIST",
+					DisplayName:         "Watchlist",
+					HasDuration:         false,
+					AllowPermanent:      false,
+					AllowedUpdateFields: []string{"Reason"},
+				}, nil)
+			})
+
+			g.Describe("Permanent allowed on a type without a duration", func() {
+				g.It("Should return a bad request HTTP error", func() {
+					allowPermanent := true
+
+					_, err := service.Update(ctx, 1, domain.UpdateArgs{"AllowPermanent": &allowPermanent})
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.Status).To(Equal(http.StatusBadRequest))
+					mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Valid update", func() {
+				var updated *domain.CustomInfractionType
+
+				g.BeforeEach(func() {
+					updated = &domain.CustomInfractionType{
+						TypeID:              1,
+						Game:                "Mordhau",
+						Name:                "WATCHLIST",
+						DisplayName:         "Watchlist",
+						AllowedUpdateFields: []string{"Reason", "Repealed"},
+					}
+
+					mockRepo.On("Update", mock.Anything, int64(1), domain.UpdateArgs{
+						"AllowedUpdateFields": []string{"Reason", "Repealed"},
+					}).Return(updated, nil)
+				})
+
+				g.AfterEach(func() {
+					perms.UnregisterCustomPermission(perms.FlagName(updated.PermissionName()))
+				})
+
+				g.It("Should pass the dereferenced fields to the repo", func() {
+					fields := []string{"Reason", "Repealed"}
+
+					result, err := service.Update(ctx, 1, domain.UpdateArgs{"AllowedUpdateFields": &fields})
+
+					Expect(err).To(BeNil())
+					Expect(result).To(Equal(updated))
+					mockRepo.AssertExpectations(t)
+				})
+			})
+		})
+
+		g.Describe("Delete()", func() {
+			g.BeforeEach(func() {
+				mockRepo.On("GetByID", mock.Anything, int64(1)).Return(&domain.CustomInfractionType{
+					TypeID: 1,
+					Game:   "Mordhau",
+					Name:   "VOICE_MUTE",
+				}, nil)
+			})
+
+			g.Describe("Type is in use", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("IsInUse", mock.Anything, int64(1)).Return(true, nil)
+				})
+
+				g.It("Should return a conflict HTTP error", func() {
+					err := service.Delete(ctx, 1)
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.Status).To(Equal(http.StatusConflict))
+					mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Type is not in use", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("IsInUse", mock.Anything, int64(1)).Return(false, nil)
+					mockRepo.On("Delete", mock.Anything, int64(1)).Return(nil)
+				})
+
+				g.It("Should delete the type", func() {
+					err := service.Delete(ctx, 1)
+
+					Expect(err).To(BeNil())
+					mockRepo.AssertExpectations(t)
+				})
+			})
+
+			g.Describe("Type not found", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("GetByID", mock.Anything, int64(2)).Return(nil, domain.ErrNotFound)
+				})
+
+				g.It("Should return a not found HTTP error", func() {
+					err := service.Delete(ctx, 2)
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.Status).To(Equal(http.StatusNotFound))
+				})
+			})
+		})
+	})
+}
@@ -99,6 +99,38 @@ func (s *mailService) SendWelcomeEmail(to, inviterName, link string) error {
 	return nil
 }
 
+type watchedPlayerEmailData struct {
+	PlayerName string
+	PlayerID   string
+	Platform   string
+	ServerName string
+	Reason     string
+}
+
+func (s *mailService) SendWatchedPlayerEmail(to []string, alert *domain.WatchedPlayerAlert, serverName string) error {
+	data := watchedPlayerEmailData{
+		PlayerName: alert.PlayerName,
+		PlayerID:   alert.PlayerID,
+		Platform:   alert.Platform,
+		ServerName: serverName,
+	}
+
+	if alert.Watch != nil {
+		data.Reason = alert.Watch.Reason.ValueOrZero()
+	}
+
+	body, err := s.parseTemplate("./internal/mail/templates/watched_player.html", data)
+	if err != nil {
+		return err
+	}
+
+	if err := s.SendMail(to, "Watched player joined "+serverName, body.String()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (s *mailService) parseTemplate(templateFile string, data interface{}) (*bytes.Buffer, error) {
 	t, err := template.ParseFiles(templateFile)
 	if err != nil {
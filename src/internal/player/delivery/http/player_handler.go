@@ -20,10 +20,17 @@ package http
 import (
 	"Refractor/authcheckers"
 	"Refractor/domain"
+	"Refractor/params"
+	"Refractor/pkg/api"
 	"Refractor/pkg/api/middleware"
+	"Refractor/pkg/perms"
+	"Refractor/pkg/structutils"
+	"context"
+	"fmt"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
@@ -48,29 +55,32 @@ func ApplyPlayerHandler(apiGroup *echo.Group, s domain.PlayerService, a domain.A
 		Type: domain.AuthObjRefractor,
 	}, log)
 
+	viewNotes := enforcer.CheckAuth(authcheckers.HasPermission(perms.FlagViewPlayerNotes, true))
+	editNotes := enforcer.CheckAuth(authcheckers.HasPermission(perms.FlagEditPlayerNotes, true))
+
 	playerGroup.GET("/:platform/:id", handler.GetPlayer, enforcer.CheckAuth(authcheckers.CanViewPlayerRecords))
+	playerGroup.GET("/:platform/:id/notes", handler.GetNotes, viewNotes)
+	playerGroup.POST("/:platform/:id/notes", handler.CreateNote, editNotes)
+	playerGroup.PATCH("/:platform/:id/notes/:noteId", handler.UpdateNote, editNotes)
+	playerGroup.DELETE("/:platform/:id/notes/:noteId", handler.DeleteNote, editNotes)
+	playerGroup.GET("/:platform/:id/tags", handler.GetTags, viewNotes)
+	playerGroup.POST("/:platform/:id/tags", handler.AddTag, editNotes)
+	playerGroup.DELETE("/:platform/:id/tags/:tag", handler.RemoveTag, editNotes)
 }
 
 func (h *playerHandler) GetPlayer(c echo.Context) error {
-	platform := strings.ToLower(c.Param("platform"))
-	id := c.Param("id")
-
-	validPlatform := false
-	for _, p := range domain.AllPlatforms {
-		if p == platform {
-			validPlatform = true
-			break
-		}
+	platform, id, err := api.GetPlayerParams(c)
+	if err != nil {
+		return err
 	}
 
-	if !validPlatform {
-		return c.JSON(http.StatusBadRequest, &domain.Response{
-			Success: false,
-			Message: "Invalid platform",
-		})
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
 	}
 
-	player, err := h.service.GetPlayer(c.Request().Context(), id, platform)
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	player, err := h.service.GetPlayer(ctx, id, platform)
 	if err != nil {
 		return err
 	}
@@ -81,3 +91,198 @@ func (h *playerHandler) GetPlayer(c echo.Context) error {
 		Payload: player,
 	})
 }
+
+func (h *playerHandler) GetNotes(c echo.Context) error {
+	platform, id, err := api.GetPlayerParams(c)
+	if err != nil {
+		return err
+	}
+
+	notes, err := h.service.GetNotes(c.Request().Context(), platform, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: notes,
+	})
+}
+
+func (h *playerHandler) CreateNote(c echo.Context) error {
+	platform, id, err := api.GetPlayerParams(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate request body
+	var body params.CreatePlayerNoteParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	note, err := h.service.CreateNote(ctx, platform, id, strings.TrimSpace(body.Note), body.Pinned)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Note created",
+		Payload: note,
+	})
+}
+
+func (h *playerHandler) UpdateNote(c echo.Context) error {
+	platform, id, err := api.GetPlayerParams(c)
+	if err != nil {
+		return err
+	}
+
+	noteID, err := strconv.ParseInt(c.Param("noteId"), 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid note id"), http.StatusBadRequest, "")
+	}
+
+	// Validate request body
+	var body params.UpdatePlayerNoteParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	if body.Note != nil {
+		trimmed := strings.TrimSpace(*body.Note)
+		body.Note = &trimmed
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	// Get update args
+	updateArgs, err := structutils.GetNonNilFieldMap(body)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	note, err := h.service.UpdateNote(ctx, platform, id, noteID, updateArgs)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Note updated",
+		Payload: note,
+	})
+}
+
+func (h *playerHandler) DeleteNote(c echo.Context) error {
+	platform, id, err := api.GetPlayerParams(c)
+	if err != nil {
+		return err
+	}
+
+	noteID, err := strconv.ParseInt(c.Param("noteId"), 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid note id"), http.StatusBadRequest, "")
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	if err := h.service.DeleteNote(ctx, platform, id, noteID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Note deleted",
+	})
+}
+
+func (h *playerHandler) GetTags(c echo.Context) error {
+	platform, id, err := api.GetPlayerParams(c)
+	if err != nil {
+		return err
+	}
+
+	tags, err := h.service.GetTags(c.Request().Context(), platform, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: tags,
+	})
+}
+
+func (h *playerHandler) AddTag(c echo.Context) error {
+	platform, id, err := api.GetPlayerParams(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate request body
+	var body params.AddPlayerTagParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	tag, err := h.service.AddTag(ctx, platform, id, strings.TrimSpace(body.Tag))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Tag added",
+		Payload: tag,
+	})
+}
+
+func (h *playerHandler) RemoveTag(c echo.Context) error {
+	platform, id, err := api.GetPlayerParams(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.RemoveTag(c.Request().Context(), platform, id, c.Param("tag")); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Tag removed",
+	})
+}
@@ -243,6 +243,62 @@ func (r *playerRepo) SearchByName(ctx context.Context, name string, limit, offse
 	return totalResults, results, nil
 }
 
+// SearchByTag returns players who have been given a tag. Tags are matched case-insensitively. If platform is not empty,
+// only players on the platform are returned.
+func (r *playerRepo) SearchByTag(ctx context.Context, tag, platform string, limit, offset int) (int, []*domain.Player, error) {
+	const op = opTag + "SearchByTag"
+
+	query := `
+		SELECT p.PlayerID, p.Platform, p.LastSeen, (
+			SELECT pn.Name FROM PlayerNames pn
+			WHERE pn.PlayerID = p.PlayerID AND pn.Platform = p.Platform
+			ORDER BY pn.DateRecorded DESC LIMIT 1
+		) AS CurrentName
+		FROM Players p
+		JOIN PlayerTags pt ON pt.PlayerID = p.PlayerID AND pt.Platform = p.Platform
+		WHERE LOWER(pt.Tag) = LOWER($1) AND ($2::VARCHAR = '' OR p.Platform = $2)
+		ORDER BY p.LastSeen DESC
+		LIMIT $3 OFFSET $4;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tag, platform, limit, offset)
+	if err != nil {
+		r.logger.Error("Could not execute player tag search query", zap.String("query", query), zap.Error(err))
+		return 0, nil, errors.Wrap(err, op)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := []*domain.Player{}
+
+	for rows.Next() {
+		res := &domain.Player{}
+
+		if err := rows.Scan(&res.PlayerID, &res.Platform, &res.LastSeen, &res.CurrentName); err != nil {
+			r.logger.Error("Could not scan player search result", zap.Error(err))
+			return 0, nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, res)
+	}
+
+	var totalResults int
+
+	query = "SELECT COUNT(1) FROM PlayerTags WHERE LOWER(Tag) = LOWER($1) AND ($2::VARCHAR = '' OR Platform = $2);"
+
+	row := r.db.QueryRowContext(ctx, query, tag, platform)
+	if err := row.Scan(&totalResults); err != nil {
+		r.logger.Error("Could not scan total player tag search result count", zap.Error(err))
+		return 0, nil, errors.Wrap(err, op)
+	}
+
+	return totalResults, results, nil
+}
+
 // Scan helpers
 func (r *playerRepo) scanRow(row *sql.Row, player *domain.DBPlayer) error {
 	return row.Scan(&player.PlayerID, &player.Platform, &player.Watched, &player.LastSeen, &player.CreatedAt, &player.ModifiedAt)
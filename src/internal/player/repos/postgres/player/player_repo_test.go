@@ -416,5 +416,60 @@ func Test(t *testing.T) {
 				})
 			})
 		})
+
+		g.Describe("SearchByTag()", func() {
+			g.Describe("Results found", func() {
+				var results []*domain.Player
+
+				g.BeforeEach(func() {
+					results = []*domain.Player{
+						{
+							PlayerID:    "1",
+							Platform:    "Platform",
+							LastSeen:    time.Now(),
+							CurrentName: "1-name",
+						},
+						{
+							PlayerID:    "2",
+							Platform:    "Platform",
+							LastSeen:    time.Now(),
+							CurrentName: "2-name",
+						},
+					}
+
+					rows := sqlmock.NewRows([]string{"playerid", "platform", "lastseen", "currentname"})
+
+					for _, res := range results {
+						rows.AddRow(res.PlayerID, res.Platform, res.LastSeen, res.CurrentName)
+					}
+
+					mockRepo.ExpectQuery("SELECT (.+) FROM Players p JOIN PlayerTags").WithArgs("VIP", "playfab", 10, 0).
+						WillReturnRows(rows)
+					mockRepo.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(1) FROM PlayerTags")).WithArgs("VIP", "playfab").
+						WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+				})
+
+				g.It("Should return the tagged players and the total count", func() {
+					totalCount, res, err := repo.SearchByTag(ctx, "VIP", "playfab", 10, 0)
+
+					Expect(err).To(BeNil())
+					Expect(res).To(Equal(results))
+					Expect(totalCount).To(Equal(12))
+					Expect(mockRepo.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Query error", func() {
+				g.BeforeEach(func() {
+					mockRepo.ExpectQuery("SELECT (.+) FROM Players p JOIN PlayerTags").WillReturnError(fmt.Errorf("err"))
+				})
+
+				g.It("Should return an error", func() {
+					_, _, err := repo.SearchByTag(ctx, "VIP", "playfab", 10, 0)
+
+					Expect(err).ToNot(BeNil())
+				})
+			})
+		})
 	})
 }
@@ -0,0 +1,190 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package playernote
+
+import (
+	"Refractor/domain"
+	"Refractor/pkg/querybuilders/psqlqb"
+	"context"
+	"database/sql"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const opTag = "PlayerNoteRepo.Postgres."
+
+type playerNoteRepo struct {
+	db     *sql.DB
+	logger *zap.Logger
+	qb     domain.QueryBuilder
+}
+
+func NewPlayerNoteRepo(db *sql.DB, logger *zap.Logger) domain.PlayerNoteRepo {
+	return &playerNoteRepo{
+		db:     db,
+		logger: logger,
+		qb:     psqlqb.NewPostgresQueryBuilder(),
+	}
+}
+
+func (r *playerNoteRepo) fetch(ctx context.Context, query string, args ...interface{}) ([]*domain.PlayerNote, error) {
+	const op = opTag + "Fetch"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	// Clean up on function exit
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.PlayerNote, 0)
+	for rows.Next() {
+		note := &domain.PlayerNote{}
+
+		if err := r.scanRows(rows, note); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, errors.Wrap(domain.ErrNotFound, op)
+			}
+
+			return nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, note)
+	}
+
+	return results, nil
+}
+
+func (r *playerNoteRepo) Store(ctx context.Context, note *domain.PlayerNote) (*domain.PlayerNote, error) {
+	const op = opTag + "Store"
+
+	query := `INSERT INTO PlayerNotes (PlayerID, Platform, UserID, Note, Pinned) VALUES ($1, $2, $3, $4, $5) RETURNING *;`
+
+	stmt, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Could not prepare statement", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	row := stmt.QueryRowContext(ctx, note.PlayerID, note.Platform, note.UserID, note.Note, note.Pinned)
+
+	newNote := &domain.PlayerNote{}
+	if err := r.scanRow(row, newNote); err != nil {
+		r.logger.Error("Could not scan newly created player note", zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return newNote, nil
+}
+
+func (r *playerNoteRepo) GetByID(ctx context.Context, id int64) (*domain.PlayerNote, error) {
+	const op = opTag + "GetByID"
+
+	query := "SELECT * FROM PlayerNotes WHERE NoteID = $1;"
+
+	results, err := r.fetch(ctx, query, id)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if len(results) > 0 {
+		return results[0], nil
+	}
+
+	return nil, errors.Wrap(domain.ErrNotFound, op)
+}
+
+func (r *playerNoteRepo) GetByPlayer(ctx context.Context, platform, playerID string) ([]*domain.PlayerNote, error) {
+	const op = opTag + "GetByPlayer"
+
+	query := `SELECT * FROM PlayerNotes WHERE Platform = $1 AND PlayerID = $2
+			ORDER BY Pinned DESC, CreatedAt DESC, NoteID DESC;`
+
+	results, err := r.fetch(ctx, query, platform, playerID)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return results, nil
+}
+
+func (r *playerNoteRepo) Update(ctx context.Context, id int64, args domain.UpdateArgs) (*domain.PlayerNote, error) {
+	const op = opTag + "Update"
+
+	query, values := r.qb.BuildUpdateQuery("PlayerNotes", id, "NoteID", args, nil)
+
+	stmt, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Could not prepare statement", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	row := stmt.QueryRowContext(ctx, values...)
+
+	updated := &domain.PlayerNote{}
+	if err := r.scanRow(row, updated); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.Wrap(domain.ErrNotFound, op)
+		}
+
+		r.logger.Error("Could not scan updated player note", zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return updated, nil
+}
+
+func (r *playerNoteRepo) Delete(ctx context.Context, id int64) error {
+	const op = opTag + "Delete"
+
+	query := "DELETE FROM PlayerNotes WHERE NoteID = $1;"
+
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Could not get affected rows", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	if rowsAffected < 1 {
+		return errors.Wrap(domain.ErrNotFound, op)
+	}
+
+	return nil
+}
+
+// Scan helpers
+func (r *playerNoteRepo) scanRow(row *sql.Row, n *domain.PlayerNote) error {
+	return row.Scan(&n.NoteID, &n.PlayerID, &n.Platform, &n.UserID, &n.Note, &n.Pinned, &n.CreatedAt, &n.ModifiedAt)
+}
+
+func (r *playerNoteRepo) scanRows(rows *sql.Rows, n *domain.PlayerNote) error {
+	return rows.Scan(&n.NoteID, &n.PlayerID, &n.Platform, &n.UserID, &n.Note, &n.Pinned, &n.CreatedAt, &n.ModifiedAt)
+}
@@ -0,0 +1,220 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package playernote
+
+import (
+	"Refractor/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/franela/goblin"
+	"github.com/guregu/null"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var noteCols = []string{"NoteID", "PlayerID", "Platform", "UserID", "Note", "Pinned", "CreatedAt", "ModifiedAt"}
+	var ctx = context.TODO()
+
+	g.Describe("PlayerNote Postgres Repo", func() {
+		var repo domain.PlayerNoteRepo
+		var mock sqlmock.Sqlmock
+		var db *sql.DB
+
+		g.BeforeEach(func() {
+			var err error
+
+			db, mock, err = sqlmock.New()
+			if err != nil {
+				t.Fatalf("Could not create new sqlmock instance. Error: %v", err)
+			}
+
+			repo = NewPlayerNoteRepo(db, zap.NewNop())
+		})
+
+		g.After(func() {
+			_ = db.Close()
+		})
+
+		g.Describe("Store()", func() {
+			var note *domain.PlayerNote
+
+			g.BeforeEach(func() {
+				note = &domain.PlayerNote{
+					PlayerID: "playerid",
+					Platform: "platform",
+					UserID:   "userid",
+					Note:     "Suspected aimbot",
+					Pinned:   true,
+				}
+
+				mock.ExpectPrepare("INSERT INTO PlayerNotes")
+			})
+
+			g.Describe("Success", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("INSERT INTO PlayerNotes").
+						WithArgs(note.PlayerID, note.Platform, note.UserID, note.Note, note.Pinned).
+						WillReturnRows(sqlmock.NewRows(noteCols).
+							AddRow(1, note.PlayerID, note.Platform, note.UserID, note.Note, note.Pinned, time.Now(), nil))
+				})
+
+				g.It("Should return the new note", func() {
+					newNote, err := repo.Store(ctx, note)
+
+					Expect(err).To(BeNil())
+					Expect(newNote.NoteID).To(Equal(int64(1)))
+					Expect(newNote.Pinned).To(BeTrue())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Error", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("INSERT INTO PlayerNotes").WillReturnError(fmt.Errorf("err"))
+				})
+
+				g.It("Should return an error", func() {
+					_, err := repo.Store(ctx, note)
+
+					Expect(err).ToNot(BeNil())
+				})
+			})
+		})
+
+		g.Describe("GetByID()", func() {
+			g.Describe("Note found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM PlayerNotes WHERE NoteID = $1")).WithArgs(1).
+						WillReturnRows(sqlmock.NewRows(noteCols).
+							AddRow(1, "playerid", "platform", "userid", "note", false, time.Now(), nil))
+				})
+
+				g.It("Should return the note", func() {
+					note, err := repo.GetByID(ctx, 1)
+
+					Expect(err).To(BeNil())
+					Expect(note.NoteID).To(Equal(int64(1)))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Note not found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM PlayerNotes WHERE NoteID = $1")).
+						WillReturnRows(sqlmock.NewRows(noteCols))
+				})
+
+				g.It("Should return domain.ErrNotFound", func() {
+					_, err := repo.GetByID(ctx, 1)
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+				})
+			})
+		})
+
+		g.Describe("GetByPlayer()", func() {
+			g.It("Should return the player's notes", func() {
+				mock.ExpectQuery("SELECT (.+) FROM PlayerNotes WHERE Platform = (.+) ORDER BY Pinned DESC").
+					WithArgs("platform", "playerid").
+					WillReturnRows(sqlmock.NewRows(noteCols).
+						AddRow(2, "playerid", "platform", "userid", "pinned", true, time.Now(), nil).
+						AddRow(1, "playerid", "platform", "userid", "note", false, time.Now(), time.Now()))
+
+				notes, err := repo.GetByPlayer(ctx, "platform", "playerid")
+
+				Expect(err).To(BeNil())
+				Expect(notes).To(HaveLen(2))
+				Expect(notes[1].ModifiedAt.Valid).To(BeTrue())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return an empty slice if the player has no notes", func() {
+				mock.ExpectQuery("SELECT (.+) FROM PlayerNotes").WillReturnRows(sqlmock.NewRows(noteCols))
+
+				notes, err := repo.GetByPlayer(ctx, "platform", "playerid")
+
+				Expect(err).To(BeNil())
+				Expect(notes).To(BeEmpty())
+			})
+		})
+
+		g.Describe("Update()", func() {
+			g.Describe("Note updated", func() {
+				g.BeforeEach(func() {
+					mock.ExpectPrepare("UPDATE PlayerNotes SET")
+					mock.ExpectQuery("UPDATE PlayerNotes SET").
+						WillReturnRows(sqlmock.NewRows(noteCols).
+							AddRow(1, "playerid", "platform", "userid", "note", true, time.Now(), time.Now()))
+				})
+
+				g.It("Should return the updated note", func() {
+					note, err := repo.Update(ctx, 1, domain.UpdateArgs{"Pinned": true})
+
+					Expect(err).To(BeNil())
+					Expect(note.Pinned).To(BeTrue())
+					Expect(note.ModifiedAt).ToNot(Equal(null.Time{}))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Note not found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectPrepare("UPDATE PlayerNotes SET")
+					mock.ExpectQuery("UPDATE PlayerNotes SET").WillReturnError(sql.ErrNoRows)
+				})
+
+				g.It("Should return domain.ErrNotFound", func() {
+					_, err := repo.Update(ctx, 1, domain.UpdateArgs{"Pinned": true})
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+				})
+			})
+		})
+
+		g.Describe("Delete()", func() {
+			g.It("Should not return an error if the note was deleted", func() {
+				mock.ExpectExec("DELETE FROM PlayerNotes").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+				err := repo.Delete(ctx, 1)
+
+				Expect(err).To(BeNil())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return domain.ErrNotFound if no note was deleted", func() {
+				mock.ExpectExec("DELETE FROM PlayerNotes").WillReturnResult(sqlmock.NewResult(0, 0))
+
+				err := repo.Delete(ctx, 1)
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+			})
+		})
+	})
+}
@@ -0,0 +1,224 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package playersession
+
+import (
+	"Refractor/domain"
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const opTag = "PlayerSessionRepo.Postgres."
+
+type playerSessionRepo struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPlayerSessionRepo(db *sql.DB, logger *zap.Logger) domain.PlayerSessionRepo {
+	return &playerSessionRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *playerSessionRepo) fetch(ctx context.Context, query string, args ...interface{}) ([]*domain.PlayerSession, error) {
+	const op = opTag + "Fetch"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	// Clean up on function exit
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.PlayerSession, 0)
+	for rows.Next() {
+		session := &domain.PlayerSession{}
+
+		if err := r.scanRows(rows, session); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, errors.Wrap(domain.ErrNotFound, op)
+			}
+
+			return nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, session)
+	}
+
+	return results, nil
+}
+
+func (r *playerSessionRepo) Start(ctx context.Context, platform, playerID string, serverID int64) (*domain.PlayerSession, error) {
+	const op = opTag + "Start"
+
+	query := `INSERT INTO PlayerSessions (Platform, PlayerID, ServerID) VALUES ($1, $2, $3)
+			ON CONFLICT (Platform, PlayerID, ServerID) WHERE EndedAt IS NULL DO NOTHING
+			RETURNING SessionID, PlayerID, Platform, ServerID, StartedAt, EndedAt, EndReason;`
+
+	stmt, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Could not prepare statement", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	session := &domain.PlayerSession{}
+	if err := r.scanRow(stmt.QueryRowContext(ctx, platform, playerID, serverID), session); err != nil {
+		// No row is returned if the player already has an open session on the server
+		if err == sql.ErrNoRows {
+			return nil, errors.Wrap(domain.ErrConflict, op)
+		}
+
+		r.logger.Error("Could not scan newly created player session", zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return session, nil
+}
+
+func (r *playerSessionRepo) End(ctx context.Context, platform, playerID string, serverID int64, reason string) error {
+	const op = opTag + "End"
+
+	query := `UPDATE PlayerSessions SET EndedAt = CURRENT_TIMESTAMP, EndReason = $1
+			WHERE Platform = $2 AND PlayerID = $3 AND ServerID = $4 AND EndedAt IS NULL;`
+
+	if _, err := r.db.ExecContext(ctx, query, reason, platform, playerID, serverID); err != nil {
+		r.logger.Error("Could not execute session end query", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *playerSessionRepo) EndByIDs(ctx context.Context, reason string, sessionIDs ...int64) error {
+	const op = opTag + "EndByIDs"
+
+	if len(sessionIDs) < 1 {
+		return nil
+	}
+
+	query := `UPDATE PlayerSessions SET EndedAt = CURRENT_TIMESTAMP, EndReason = $1
+			WHERE SessionID = ANY($2) AND EndedAt IS NULL;`
+
+	if _, err := r.db.ExecContext(ctx, query, reason, pq.Array(sessionIDs)); err != nil {
+		r.logger.Error("Could not execute session end query", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *playerSessionRepo) EndAllOnServer(ctx context.Context, serverID int64, reason string) error {
+	const op = opTag + "EndAllOnServer"
+
+	query := `UPDATE PlayerSessions SET EndedAt = CURRENT_TIMESTAMP, EndReason = $1
+			WHERE ServerID = $2 AND EndedAt IS NULL;`
+
+	if _, err := r.db.ExecContext(ctx, query, reason, serverID); err != nil {
+		r.logger.Error("Could not execute session end query", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *playerSessionRepo) GetOpenOnServer(ctx context.Context, serverID int64) ([]*domain.PlayerSession, error) {
+	const op = opTag + "GetOpenOnServer"
+
+	query := `SELECT SessionID, PlayerID, Platform, ServerID, StartedAt, EndedAt, EndReason FROM PlayerSessions
+			WHERE ServerID = $1 AND EndedAt IS NULL;`
+
+	results, err := r.fetch(ctx, query, serverID)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return results, nil
+}
+
+func (r *playerSessionRepo) GetByPlayer(ctx context.Context, platform, playerID string, limit int) ([]*domain.PlayerSession, error) {
+	const op = opTag + "GetByPlayer"
+
+	query := `SELECT SessionID, PlayerID, Platform, ServerID, StartedAt, EndedAt, EndReason FROM PlayerSessions
+			WHERE Platform = $1 AND PlayerID = $2 ORDER BY StartedAt DESC LIMIT $3;`
+
+	results, err := r.fetch(ctx, query, platform, playerID, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if len(results) > 0 {
+		return results, nil
+	}
+
+	return nil, errors.Wrap(domain.ErrNotFound, op)
+}
+
+func (r *playerSessionRepo) GetPlaytime(ctx context.Context, platform, playerID string) ([]*domain.ServerPlaytime, error) {
+	const op = opTag + "GetPlaytime"
+
+	// Open sessions are counted up to the current time
+	query := `SELECT ServerID, SUM(EXTRACT(EPOCH FROM (COALESCE(EndedAt, CURRENT_TIMESTAMP) - StartedAt)))::BIGINT
+			FROM PlayerSessions WHERE Platform = $1 AND PlayerID = $2 GROUP BY ServerID ORDER BY ServerID;`
+
+	rows, err := r.db.QueryContext(ctx, query, platform, playerID)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.ServerPlaytime, 0)
+	for rows.Next() {
+		playtime := &domain.ServerPlaytime{}
+
+		if err := rows.Scan(&playtime.ServerID, &playtime.Playtime); err != nil {
+			r.logger.Error("Could not scan server playtime", zap.Error(err))
+			return nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, playtime)
+	}
+
+	return results, nil
+}
+
+// Scan helpers
+func (r *playerSessionRepo) scanRow(row *sql.Row, s *domain.PlayerSession) error {
+	return row.Scan(&s.SessionID, &s.PlayerID, &s.Platform, &s.ServerID, &s.StartedAt, &s.EndedAt, &s.EndReason)
+}
+
+func (r *playerSessionRepo) scanRows(rows *sql.Rows, s *domain.PlayerSession) error {
+	return rows.Scan(&s.SessionID, &s.PlayerID, &s.Platform, &s.ServerID, &s.StartedAt, &s.EndedAt, &s.EndReason)
+}
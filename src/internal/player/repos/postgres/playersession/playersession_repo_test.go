@@ -0,0 +1,214 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package playersession
+
+import (
+	"Refractor/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/franela/goblin"
+	"github.com/lib/pq"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var sessionCols = []string{"SessionID", "PlayerID", "Platform", "ServerID", "StartedAt", "EndedAt", "EndReason"}
+	var ctx = context.TODO()
+
+	g.Describe("PlayerSession Postgres Repo", func() {
+		var repo domain.PlayerSessionRepo
+		var mock sqlmock.Sqlmock
+		var db *sql.DB
+
+		g.BeforeEach(func() {
+			var err error
+
+			db, mock, err = sqlmock.New()
+			if err != nil {
+				t.Fatalf("Could not create new sqlmock instance. Error: %v", err)
+			}
+
+			repo = NewPlayerSessionRepo(db, zap.NewNop())
+		})
+
+		g.After(func() {
+			_ = db.Close()
+		})
+
+		g.Describe("Start()", func() {
+			g.Describe("Success", func() {
+				var startedAt time.Time
+
+				g.BeforeEach(func() {
+					startedAt = time.Now()
+
+					mock.ExpectPrepare("INSERT INTO PlayerSessions")
+					mock.ExpectQuery("INSERT INTO PlayerSessions").WithArgs("platform", "playerid", int64(1)).
+						WillReturnRows(sqlmock.NewRows(sessionCols).
+							AddRow(3, "playerid", "platform", 1, startedAt, nil, nil))
+				})
+
+				g.It("Should return the new open session", func() {
+					session, err := repo.Start(ctx, "platform", "playerid", 1)
+
+					Expect(err).To(BeNil())
+					Expect(session.SessionID).To(Equal(int64(3)))
+					Expect(session.StartedAt).To(Equal(startedAt))
+					Expect(session.IsOpen()).To(BeTrue())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Player already has an open session", func() {
+				g.BeforeEach(func() {
+					mock.ExpectPrepare("INSERT INTO PlayerSessions")
+					mock.ExpectQuery("INSERT INTO PlayerSessions (.+) ON CONFLICT (.+) DO NOTHING").
+						WithArgs("platform", "playerid", int64(1)).
+						WillReturnRows(sqlmock.NewRows(sessionCols))
+				})
+
+				g.It("Should return domain.ErrConflict", func() {
+					_, err := repo.Start(ctx, "platform", "playerid", 1)
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrConflict))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Error", func() {
+				g.BeforeEach(func() {
+					mock.ExpectPrepare("INSERT INTO PlayerSessions")
+					mock.ExpectQuery("INSERT INTO PlayerSessions").WillReturnError(fmt.Errorf("err"))
+				})
+
+				g.It("Should return an error", func() {
+					_, err := repo.Start(ctx, "platform", "playerid", 1)
+
+					Expect(err).ToNot(BeNil())
+				})
+			})
+		})
+
+		g.Describe("End()", func() {
+			g.It("Should close the player's open sessions on the server", func() {
+				mock.ExpectExec("UPDATE PlayerSessions SET EndedAt").
+					WithArgs(domain.SessionEndQuit, "platform", "playerid", int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				err := repo.End(ctx, "platform", "playerid", 1, domain.SessionEndQuit)
+
+				Expect(err).To(BeNil())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("EndByIDs()", func() {
+			g.It("Should close the sessions with matching IDs", func() {
+				mock.ExpectExec("UPDATE PlayerSessions SET EndedAt").
+					WithArgs(domain.SessionEndDesync, pq.Array([]int64{1, 2})).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+
+				err := repo.EndByIDs(ctx, domain.SessionEndDesync, 1, 2)
+
+				Expect(err).To(BeNil())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should not run a query if no IDs were provided", func() {
+				err := repo.EndByIDs(ctx, domain.SessionEndDesync)
+
+				Expect(err).To(BeNil())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("EndAllOnServer()", func() {
+			g.It("Should close all open sessions on the server", func() {
+				mock.ExpectExec("UPDATE PlayerSessions SET EndedAt").
+					WithArgs(domain.SessionEndDisconnect, int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 4))
+
+				err := repo.EndAllOnServer(ctx, 1, domain.SessionEndDisconnect)
+
+				Expect(err).To(BeNil())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("GetByPlayer()", func() {
+			g.Describe("Sessions found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("SELECT (.+) FROM PlayerSessions").WithArgs("platform", "playerid", 10).
+						WillReturnRows(sqlmock.NewRows(sessionCols).
+							AddRow(2, "playerid", "platform", 1, time.Now(), nil, nil).
+							AddRow(1, "playerid", "platform", 1, time.Now(), time.Now(), domain.SessionEndQuit))
+				})
+
+				g.It("Should return the sessions", func() {
+					sessions, err := repo.GetByPlayer(ctx, "platform", "playerid", 10)
+
+					Expect(err).To(BeNil())
+					Expect(sessions).To(HaveLen(2))
+					Expect(sessions[1].EndReason.ValueOrZero()).To(Equal(domain.SessionEndQuit))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("No sessions found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("SELECT (.+) FROM PlayerSessions").WillReturnRows(sqlmock.NewRows(sessionCols))
+				})
+
+				g.It("Should return domain.ErrNotFound", func() {
+					_, err := repo.GetByPlayer(ctx, "platform", "playerid", 10)
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+				})
+			})
+		})
+
+		g.Describe("GetPlaytime()", func() {
+			g.It("Should return the playtime on each server", func() {
+				mock.ExpectQuery("SELECT ServerID, SUM").WithArgs("platform", "playerid").
+					WillReturnRows(sqlmock.NewRows([]string{"ServerID", "Playtime"}).
+						AddRow(1, 3600).
+						AddRow(2, 120))
+
+				playtime, err := repo.GetPlaytime(ctx, "platform", "playerid")
+
+				Expect(err).To(BeNil())
+				Expect(playtime).To(Equal([]*domain.ServerPlaytime{
+					{ServerID: 1, Playtime: 3600},
+					{ServerID: 2, Playtime: 120},
+				}))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+	})
+}
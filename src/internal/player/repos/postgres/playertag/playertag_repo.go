@@ -0,0 +1,122 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package playertag
+
+import (
+	"Refractor/domain"
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const opTag = "PlayerTagRepo.Postgres."
+
+const pgUniqueViolationCode = "23505"
+
+type playerTagRepo struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewPlayerTagRepo(db *sql.DB, logger *zap.Logger) domain.PlayerTagRepo {
+	return &playerTagRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *playerTagRepo) Store(ctx context.Context, tag *domain.PlayerTag) error {
+	const op = opTag + "Store"
+
+	query := `INSERT INTO PlayerTags (PlayerID, Platform, Tag, UserID) VALUES ($1, $2, $3, $4) RETURNING CreatedAt;`
+
+	row := r.db.QueryRowContext(ctx, query, tag.PlayerID, tag.Platform, tag.Tag, tag.UserID)
+	if err := row.Scan(&tag.CreatedAt); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == pgUniqueViolationCode {
+			// if the player already has this tag, we expect a unique violation error
+			return errors.Wrap(domain.ErrConflict, op)
+		}
+
+		r.logger.Error("Could not insert into PlayerTags table",
+			zap.String("Platform", tag.Platform),
+			zap.String("Player ID", tag.PlayerID),
+			zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *playerTagRepo) GetByPlayer(ctx context.Context, platform, playerID string) ([]*domain.PlayerTag, error) {
+	const op = opTag + "GetByPlayer"
+
+	query := `SELECT PlayerID, Platform, Tag, UserID, CreatedAt FROM PlayerTags
+			WHERE Platform = $1 AND PlayerID = $2 ORDER BY CreatedAt;`
+
+	rows, err := r.db.QueryContext(ctx, query, platform, playerID)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.PlayerTag, 0)
+	for rows.Next() {
+		t := &domain.PlayerTag{}
+
+		if err := rows.Scan(&t.PlayerID, &t.Platform, &t.Tag, &t.UserID, &t.CreatedAt); err != nil {
+			r.logger.Error("Could not scan player tag", zap.Error(err))
+			return nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, t)
+	}
+
+	return results, nil
+}
+
+func (r *playerTagRepo) Delete(ctx context.Context, platform, playerID, tag string) error {
+	const op = opTag + "Delete"
+
+	query := "DELETE FROM PlayerTags WHERE Platform = $1 AND PlayerID = $2 AND LOWER(Tag) = LOWER($3);"
+
+	res, err := r.db.ExecContext(ctx, query, platform, playerID, tag)
+	if err != nil {
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Could not get affected rows", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	if rowsAffected < 1 {
+		return errors.Wrap(domain.ErrNotFound, op)
+	}
+
+	return nil
+}
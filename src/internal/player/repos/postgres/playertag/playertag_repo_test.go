@@ -0,0 +1,143 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package playertag
+
+import (
+	"Refractor/domain"
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/franela/goblin"
+	"github.com/lib/pq"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var tagCols = []string{"PlayerID", "Platform", "Tag", "UserID", "CreatedAt"}
+	var ctx = context.TODO()
+
+	g.Describe("PlayerTag Postgres Repo", func() {
+		var repo domain.PlayerTagRepo
+		var mock sqlmock.Sqlmock
+		var db *sql.DB
+
+		g.BeforeEach(func() {
+			var err error
+
+			db, mock, err = sqlmock.New()
+			if err != nil {
+				t.Fatalf("Could not create new sqlmock instance. Error: %v", err)
+			}
+
+			repo = NewPlayerTagRepo(db, zap.NewNop())
+		})
+
+		g.After(func() {
+			_ = db.Close()
+		})
+
+		g.Describe("Store()", func() {
+			var tag *domain.PlayerTag
+
+			g.BeforeEach(func() {
+				tag = &domain.PlayerTag{
+					PlayerID: "playerid",
+					Platform: "platform",
+					Tag:      "VIP",
+					UserID:   "userid",
+				}
+			})
+
+			g.Describe("Tag added", func() {
+				var createdAt time.Time
+
+				g.BeforeEach(func() {
+					createdAt = time.Now()
+
+					mock.ExpectQuery("INSERT INTO PlayerTags").WithArgs("playerid", "platform", "VIP", "userid").
+						WillReturnRows(sqlmock.NewRows([]string{"CreatedAt"}).AddRow(createdAt))
+				})
+
+				g.It("Should set the created at time", func() {
+					err := repo.Store(ctx, tag)
+
+					Expect(err).To(BeNil())
+					Expect(tag.CreatedAt).To(Equal(createdAt))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Player already has the tag", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery("INSERT INTO PlayerTags").WillReturnError(&pq.Error{Code: pgUniqueViolationCode})
+				})
+
+				g.It("Should return domain.ErrConflict", func() {
+					err := repo.Store(ctx, tag)
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrConflict))
+				})
+			})
+		})
+
+		g.Describe("GetByPlayer()", func() {
+			g.It("Should return the player's tags", func() {
+				mock.ExpectQuery("SELECT (.+) FROM PlayerTags").WithArgs("platform", "playerid").
+					WillReturnRows(sqlmock.NewRows(tagCols).
+						AddRow("playerid", "platform", "VIP", "userid", time.Now()).
+						AddRow("playerid", "platform", "known cheater suspect", "userid", time.Now()))
+
+				tags, err := repo.GetByPlayer(ctx, "platform", "playerid")
+
+				Expect(err).To(BeNil())
+				Expect(tags).To(HaveLen(2))
+				Expect(tags[1].Tag).To(Equal("known cheater suspect"))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("Delete()", func() {
+			g.It("Should not return an error if the tag was removed", func() {
+				mock.ExpectExec("DELETE FROM PlayerTags").WithArgs("platform", "playerid", "vip").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+
+				err := repo.Delete(ctx, "platform", "playerid", "vip")
+
+				Expect(err).To(BeNil())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return domain.ErrNotFound if the player does not have the tag", func() {
+				mock.ExpectExec("DELETE FROM PlayerTags").WillReturnResult(sqlmock.NewResult(0, 0))
+
+				err := repo.Delete(ctx, "platform", "playerid", "vip")
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+			})
+		})
+	})
+}
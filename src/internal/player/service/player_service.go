@@ -18,27 +18,46 @@
 package service
 
 import (
+	"Refractor/authcheckers"
 	"Refractor/domain"
 	"Refractor/pkg/broadcast"
+	"Refractor/pkg/perms"
+	"Refractor/pkg/whitelist"
 	"context"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"net/http"
 	"time"
 )
 
 type playerService struct {
-	repo     domain.PlayerRepo
-	nameRepo domain.PlayerNameRepo
-	timeout  time.Duration
-	logger   *zap.Logger
+	repo         domain.PlayerRepo
+	nameRepo     domain.PlayerNameRepo
+	sessionRepo  domain.PlayerSessionRepo
+	noteRepo     domain.PlayerNoteRepo
+	tagRepo      domain.PlayerTagRepo
+	userMetaRepo domain.UserMetaRepo
+	authorizer   domain.Authorizer
+	timeout      time.Duration
+	logger       *zap.Logger
 }
 
-func NewPlayerService(repo domain.PlayerRepo, nameRepo domain.PlayerNameRepo, to time.Duration, log *zap.Logger) domain.PlayerService {
+// sessionTimelineLimit is the maximum number of sessions included in a player's session timeline.
+const sessionTimelineLimit = 100
+
+func NewPlayerService(repo domain.PlayerRepo, nameRepo domain.PlayerNameRepo, sessionRepo domain.PlayerSessionRepo,
+	noteRepo domain.PlayerNoteRepo, tagRepo domain.PlayerTagRepo, umr domain.UserMetaRepo, a domain.Authorizer,
+	to time.Duration, log *zap.Logger) domain.PlayerService {
 	return &playerService{
-		repo:     repo,
-		nameRepo: nameRepo,
-		timeout:  to,
-		logger:   log,
+		repo:         repo,
+		nameRepo:     nameRepo,
+		sessionRepo:  sessionRepo,
+		noteRepo:     noteRepo,
+		tagRepo:      tagRepo,
+		userMetaRepo: umr,
+		authorizer:   a,
+		timeout:      to,
+		logger:       log,
 	}
 }
 
@@ -80,6 +99,8 @@ func (s *playerService) HandlePlayerJoin(fields broadcast.Fields, serverID int64
 		s.logger.Info("New player recorded",
 			zap.String("PlayerID", playerID),
 			zap.String("Platform", platform))
+
+		s.startSession(ctx, platform, playerID, serverID)
 		return
 	}
 
@@ -91,6 +112,7 @@ func (s *playerService) HandlePlayerJoin(fields broadcast.Fields, serverID int64
 			zap.String("CurrentName", foundPlayer.CurrentName),
 			zap.String("NewName", name))
 
+		// A failed name update should not stop the player's visit from being recorded
 		if err := s.nameRepo.UpdateName(ctx, foundPlayer, name); err != nil {
 			s.logger.Error("Could not update player name",
 				zap.String("PlayerID", playerID),
@@ -98,7 +120,6 @@ func (s *playerService) HandlePlayerJoin(fields broadcast.Fields, serverID int64
 				zap.String("CurrentName", foundPlayer.CurrentName),
 				zap.String("NewName", name),
 				zap.Error(err))
-			return
 		}
 	}
 
@@ -110,8 +131,35 @@ func (s *playerService) HandlePlayerJoin(fields broadcast.Fields, serverID int64
 			zap.String("PlayerID", playerID),
 			zap.String("Platform", platform),
 			zap.Error(err))
+	}
+
+	s.startSession(ctx, platform, playerID, serverID)
+}
+
+// startSession opens a new session for a player who joined a server. If the player still has an open session on the
+// server, their quit was missed so it is closed first. If another join for the player opened a session in the meantime,
+// that session is kept.
+func (s *playerService) startSession(ctx context.Context, platform, playerID string, serverID int64) {
+	if err := s.sessionRepo.End(ctx, platform, playerID, serverID, domain.SessionEndDesync); err != nil {
+		s.logger.Error("Could not close previous player session",
+			zap.String("PlayerID", playerID),
+			zap.String("Platform", platform),
+			zap.Int64("Server ID", serverID),
+			zap.Error(err))
 		return
 	}
+
+	if _, err := s.sessionRepo.Start(ctx, platform, playerID, serverID); err != nil {
+		if errors.Cause(err) == domain.ErrConflict {
+			return
+		}
+
+		s.logger.Error("Could not start player session",
+			zap.String("PlayerID", playerID),
+			zap.String("Platform", platform),
+			zap.Int64("Server ID", serverID),
+			zap.Error(err))
+	}
 }
 
 func (s *playerService) HandlePlayerQuit(fields broadcast.Fields, serverID int64, game domain.Game) {
@@ -147,13 +195,380 @@ func (s *playerService) HandlePlayerQuit(fields broadcast.Fields, serverID int64
 			zap.String("PlayerID", playerID),
 			zap.String("Platform", platform),
 			zap.Error(err))
+	}
+
+	if err := s.sessionRepo.End(ctx, platform, playerID, serverID, domain.SessionEndQuit); err != nil {
+		s.logger.Error("Could not end player session",
+			zap.String("PlayerID", playerID),
+			zap.String("Platform", platform),
+			zap.Int64("Server ID", serverID),
+			zap.Error(err))
+	}
+}
+
+// HandlePlayerListUpdate brings the open sessions on a server in line with its refreshed player list. Sessions of
+// players who are no longer online are closed, and sessions are opened for online players who do not have one.
+func (s *playerService) HandlePlayerListUpdate(serverID int64, players []*domain.OnlinePlayer, game domain.Game) {
+	ctx, cancel := context.WithTimeout(context.TODO(), s.timeout)
+	defer cancel()
+
+	platform := game.GetPlatform().GetName()
+
+	openSessions, err := s.sessionRepo.GetOpenOnServer(ctx, serverID)
+	if err != nil {
+		s.logger.Error("Could not get open player sessions", zap.Int64("Server ID", serverID), zap.Error(err))
+		return
+	}
+
+	online := map[string]bool{}
+	for _, op := range players {
+		online[op.PlayerID] = true
+	}
+
+	hasSession := map[string]bool{}
+	var staleIDs []int64
+
+	for _, session := range openSessions {
+		if session.Platform == platform && online[session.PlayerID] {
+			hasSession[session.PlayerID] = true
+			continue
+		}
+
+		staleIDs = append(staleIDs, session.SessionID)
+	}
+
+	if err := s.sessionRepo.EndByIDs(ctx, domain.SessionEndDesync, staleIDs...); err != nil {
+		s.logger.Error("Could not close desynced player sessions", zap.Int64("Server ID", serverID), zap.Error(err))
+	}
+
+	for _, op := range players {
+		if hasSession[op.PlayerID] {
+			continue
+		}
+
+		if _, err := s.sessionRepo.Start(ctx, platform, op.PlayerID, serverID); err != nil {
+			s.logger.Error("Could not start player session",
+				zap.String("PlayerID", op.PlayerID),
+				zap.String("Platform", platform),
+				zap.Int64("Server ID", serverID),
+				zap.Error(err))
+		}
+	}
+}
+
+// HandleServerStatusChange closes all open sessions on a server once Refractor loses its connection to it, since
+// joins and quits can no longer be observed.
+func (s *playerService) HandleServerStatusChange(serverID int64, status string) {
+	if status != "Offline" {
 		return
 	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), s.timeout)
+	defer cancel()
+
+	if err := s.sessionRepo.EndAllOnServer(ctx, serverID, domain.SessionEndDisconnect); err != nil {
+		s.logger.Error("Could not close player sessions on disconnected server",
+			zap.Int64("Server ID", serverID),
+			zap.Error(err))
+	}
 }
 
+// GetPlayer returns the player with a matching ID and platform along with their playtime and session timeline.
 func (s *playerService) GetPlayer(c context.Context, id, platform string) (*domain.Player, error) {
 	ctx, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
 
-	return s.repo.GetByID(ctx, platform, id)
+	player, err := s.repo.GetByID(ctx, platform, id)
+	if err != nil {
+		return nil, err
+	}
+
+	player.Playtime, err = s.getPlaytime(ctx, player)
+	if err != nil {
+		return nil, err
+	}
+
+	// Include notes and tags if the user is allowed to view them
+	if user, ok := ctx.Value("user").(*domain.AuthUser); ok {
+		canView, err := s.hasNotePermission(ctx, user, perms.FlagViewPlayerNotes)
+		if err != nil {
+			return nil, err
+		}
+
+		if canView {
+			if player.Notes, err = s.getNotes(ctx, platform, id); err != nil {
+				return nil, err
+			}
+
+			tags, err := s.tagRepo.GetByPlayer(ctx, platform, id)
+			if err != nil {
+				return nil, err
+			}
+
+			player.Tags = make([]string, len(tags))
+			for i, tag := range tags {
+				player.Tags[i] = tag.Tag
+			}
+		}
+	}
+
+	return player, nil
+}
+
+func (s *playerService) getPlaytime(ctx context.Context, player *domain.Player) (*domain.PlayerPlaytime, error) {
+	servers, err := s.sessionRepo.GetPlaytime(ctx, player.Platform, player.PlayerID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessionRepo.GetByPlayer(ctx, player.Platform, player.PlayerID, sessionTimelineLimit)
+	if err != nil && errors.Cause(err) != domain.ErrNotFound {
+		return nil, err
+	}
+
+	if sessions == nil {
+		sessions = []*domain.PlayerSession{}
+	}
+
+	playtime := &domain.PlayerPlaytime{
+		Servers:   servers,
+		FirstSeen: player.CreatedAt,
+		Sessions:  sessions,
+	}
+
+	for _, server := range servers {
+		playtime.Total += server.Playtime
+	}
+
+	return playtime, nil
+}
+
+// GetNotes returns the staff notes recorded on a player, with pinned notes first.
+func (s *playerService) GetNotes(c context.Context, platform, id string) ([]*domain.PlayerNote, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if err := s.ensurePlayerExists(ctx, platform, id); err != nil {
+		return nil, err
+	}
+
+	return s.getNotes(ctx, platform, id)
+}
+
+func (s *playerService) getNotes(ctx context.Context, platform, id string) ([]*domain.PlayerNote, error) {
+	notes, err := s.noteRepo.GetByPlayer(ctx, platform, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, note := range notes {
+		s.setAuthorName(ctx, note)
+	}
+
+	return notes, nil
+}
+
+// CreateNote records a staff note on a player. The note's author is the user set in context.
+func (s *playerService) CreateNote(c context.Context, platform, id, note string, pinned bool) (*domain.PlayerNote, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	user, ok := ctx.Value("user").(*domain.AuthUser)
+	if !ok {
+		return nil, errors.New("user not set in context")
+	}
+
+	if err := s.ensurePlayerExists(ctx, platform, id); err != nil {
+		return nil, err
+	}
+
+	newNote, err := s.noteRepo.Store(ctx, &domain.PlayerNote{
+		PlayerID: id,
+		Platform: platform,
+		UserID:   user.Identity.Id,
+		Note:     note,
+		Pinned:   pinned,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.setAuthorName(ctx, newNote)
+
+	return newNote, nil
+}
+
+// UpdateNote updates a note recorded on a player. Notes can only be updated by their author or an administrator.
+func (s *playerService) UpdateNote(c context.Context, platform, id string, noteID int64, args domain.UpdateArgs) (*domain.PlayerNote, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if _, err := s.getModifiableNote(ctx, platform, id, noteID); err != nil {
+		return nil, err
+	}
+
+	// Filter out illegal values
+	wl := whitelist.StringKeyMap([]string{"Note", "Pinned"})
+	args = wl.FilterKeys(args)
+
+	if len(args) == 0 {
+		return nil, domain.NewHTTPError(errors.New("no update fields provided"), http.StatusBadRequest,
+			"No update fields provided")
+	}
+
+	updated, err := s.noteRepo.Update(ctx, noteID, args)
+	if err != nil {
+		return nil, err
+	}
+
+	s.setAuthorName(ctx, updated)
+
+	return updated, nil
+}
+
+// DeleteNote deletes a note recorded on a player. Notes can only be deleted by their author or an administrator.
+func (s *playerService) DeleteNote(c context.Context, platform, id string, noteID int64) error {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if _, err := s.getModifiableNote(ctx, platform, id, noteID); err != nil {
+		return err
+	}
+
+	return s.noteRepo.Delete(ctx, noteID)
+}
+
+// getModifiableNote returns the note with a matching ID if it belongs to the player and the user set in context is
+// allowed to modify it.
+func (s *playerService) getModifiableNote(ctx context.Context, platform, id string, noteID int64) (*domain.PlayerNote, error) {
+	user, ok := ctx.Value("user").(*domain.AuthUser)
+	if !ok {
+		return nil, errors.New("user not set in context")
+	}
+
+	note, err := s.noteRepo.GetByID(ctx, noteID)
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return nil, domain.NewHTTPError(err, http.StatusNotFound, "Note not found")
+		}
+
+		return nil, err
+	}
+
+	if note.Platform != platform || note.PlayerID != id {
+		return nil, domain.NewHTTPError(domain.ErrNotFound, http.StatusNotFound, "Note not found")
+	}
+
+	if note.UserID == user.Identity.Id {
+		return note, nil
+	}
+
+	isAdmin, err := s.authorizer.HasPermission(ctx, domain.AuthScope{
+		Type: domain.AuthObjRefractor,
+	}, user.Identity.Id, authcheckers.RequireAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isAdmin {
+		return nil, domain.NewHTTPError(nil, http.StatusUnauthorized,
+			"You do not have permission to modify this note")
+	}
+
+	return note, nil
+}
+
+func (s *playerService) setAuthorName(ctx context.Context, note *domain.PlayerNote) {
+	username, err := s.userMetaRepo.GetUsername(ctx, note.UserID)
+	if err != nil {
+		s.logger.Warn("Could not get player note author username",
+			zap.String("User ID", note.UserID),
+			zap.Error(err))
+		note.AuthorName = "[UNKNOWN]"
+		return
+	}
+
+	note.AuthorName = username
+}
+
+// GetTags returns the tags given to a player.
+func (s *playerService) GetTags(c context.Context, platform, id string) ([]*domain.PlayerTag, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if err := s.ensurePlayerExists(ctx, platform, id); err != nil {
+		return nil, err
+	}
+
+	return s.tagRepo.GetByPlayer(ctx, platform, id)
+}
+
+// AddTag gives a tag to a player. Tags are unique per player regardless of case.
+func (s *playerService) AddTag(c context.Context, platform, id, tag string) (*domain.PlayerTag, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	user, ok := ctx.Value("user").(*domain.AuthUser)
+	if !ok {
+		return nil, errors.New("user not set in context")
+	}
+
+	if err := s.ensurePlayerExists(ctx, platform, id); err != nil {
+		return nil, err
+	}
+
+	newTag := &domain.PlayerTag{
+		PlayerID: id,
+		Platform: platform,
+		Tag:      tag,
+		UserID:   user.Identity.Id,
+	}
+
+	if err := s.tagRepo.Store(ctx, newTag); err != nil {
+		if errors.Cause(err) == domain.ErrConflict {
+			return nil, domain.NewHTTPError(err, http.StatusConflict, "This player already has that tag")
+		}
+
+		return nil, err
+	}
+
+	return newTag, nil
+}
+
+// RemoveTag removes a tag from a player.
+func (s *playerService) RemoveTag(c context.Context, platform, id, tag string) error {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if err := s.tagRepo.Delete(ctx, platform, id, tag); err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return domain.NewHTTPError(err, http.StatusNotFound, "Tag not found")
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *playerService) ensurePlayerExists(ctx context.Context, platform, id string) error {
+	exists, err := s.repo.Exists(ctx, domain.FindArgs{
+		"PlayerID": id,
+		"Platform": platform,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return domain.NewHTTPError(domain.ErrNotFound, http.StatusNotFound, "Player not found")
+	}
+
+	return nil
+}
+
+func (s *playerService) hasNotePermission(ctx context.Context, user *domain.AuthUser, flag perms.FlagName) (bool, error) {
+	return s.authorizer.HasPermission(ctx, domain.AuthScope{
+		Type: domain.AuthObjRefractor,
+	}, user.Identity.Id, authcheckers.HasPermission(flag, true))
 }
@@ -27,16 +27,20 @@ import (
 type pStatService struct {
 	playerRepo     domain.PlayerRepo
 	infractionRepo domain.InfractionRepo
+	federationRepo domain.FederationRepo
+	altService     domain.AltService
 	gameService    domain.GameService
 	timeout        time.Duration
 	logger         *zap.Logger
 }
 
-func NewPlayerStatsService(pr domain.PlayerRepo, ir domain.InfractionRepo, gs domain.GameService, to time.Duration,
-	log *zap.Logger) domain.PlayerStatsService {
+func NewPlayerStatsService(pr domain.PlayerRepo, ir domain.InfractionRepo, fr domain.FederationRepo, as domain.AltService,
+	gs domain.GameService, to time.Duration, log *zap.Logger) domain.PlayerStatsService {
 	return &pStatService{
 		playerRepo:     pr,
 		infractionRepo: ir,
+		federationRepo: fr,
+		altService:     as,
 		gameService:    gs,
 		timeout:        to,
 		logger:         log,
@@ -95,9 +99,30 @@ func (s *pStatService) GetPlayerPayload(c context.Context, platform, playerID st
 		return nil, err
 	}
 
+	// Get possible linked players. A failure here should not prevent the rest of the payload from being sent.
+	possibleLinks, err := s.altService.GetPossibleLinks(ctx, foundPlayer.Platform, foundPlayer.PlayerID)
+	if err != nil {
+		s.logger.Warn("Could not get player possible links",
+			zap.String("Platform", foundPlayer.Platform),
+			zap.String("Player ID", foundPlayer.PlayerID),
+			zap.Error(err))
+	}
+
+	// Get bans imported from federation sources. These are kept apart from the player's infractions since they are
+	// owned by the source which issued them. A failure here should not prevent the rest of the payload from being sent.
+	federatedBans, err := s.federationRepo.GetActiveBansByPlayer(ctx, foundPlayer.Platform, foundPlayer.PlayerID)
+	if err != nil {
+		s.logger.Warn("Could not get player federated bans",
+			zap.String("Platform", foundPlayer.Platform),
+			zap.String("Player ID", foundPlayer.PlayerID),
+			zap.Error(err))
+	}
+
 	return &domain.PlayerPayload{
 		Player:                       foundPlayer,
 		InfractionCount:              infractionCount,
 		InfractionCountSinceTimespan: infractionCountSinceTimespan,
+		PossibleLinks:                possibleLinks,
+		FederatedBans:                federatedBans,
 	}, nil
 }
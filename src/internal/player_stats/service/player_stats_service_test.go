@@ -39,6 +39,8 @@ func Test(t *testing.T) {
 	g.Describe("Player Stats Service", func() {
 		var playerRepo *mocks.PlayerRepo
 		var infractionRepo *mocks.InfractionRepo
+		var federationRepo *mocks.FederationRepo
+		var altService *mocks.AltService
 		var gameService *mocks.GameService
 		var service *pStatService
 		var ctx context.Context
@@ -46,10 +48,14 @@ func Test(t *testing.T) {
 		g.BeforeEach(func() {
 			playerRepo = new(mocks.PlayerRepo)
 			infractionRepo = new(mocks.InfractionRepo)
+			federationRepo = new(mocks.FederationRepo)
+			altService = new(mocks.AltService)
 			gameService = new(mocks.GameService)
 			service = &pStatService{
 				playerRepo:     playerRepo,
 				infractionRepo: infractionRepo,
+				federationRepo: federationRepo,
+				altService:     altService,
 				gameService:    gameService,
 				timeout:        time.Second * 2,
 				logger:         zap.NewNop(),
@@ -141,6 +147,14 @@ func Test(t *testing.T) {
 						},
 						InfractionCount:              16,
 						InfractionCountSinceTimespan: 5,
+						PossibleLinks: []*domain.PossibleLink{
+							{PlayerID: "alt", Platform: "platform", Score: 50,
+								Signals: map[string]int{domain.AltSignalSharedName: 2}},
+						},
+						FederatedBans: []*domain.FederatedBan{
+							{SourceID: 2, RemoteID: 8, Platform: "platform", PlayerID: "playerid",
+								Duration: domain.PermanentInfractionValue, SourceName: "Partner"},
+						},
 					}
 
 					gameService.On("GetGameSettings", mock.Anything).Return(&domain.GameSettings{
@@ -156,6 +170,11 @@ func Test(t *testing.T) {
 						Return(expected.InfractionCount, nil)
 					infractionRepo.On("GetPlayerInfractionCountSince", mock.Anything, "platform", "playerid", mock.Anything).
 						Return(expected.InfractionCountSinceTimespan, nil)
+
+					altService.On("GetPossibleLinks", mock.Anything, "platform", "playerid").
+						Return(expected.PossibleLinks, nil)
+					federationRepo.On("GetActiveBansByPlayer", mock.Anything, "platform", "playerid").
+						Return(expected.FederatedBans, nil)
 				})
 
 				g.It("Should not return an error", func() {
@@ -178,6 +197,43 @@ func Test(t *testing.T) {
 				})
 			})
 
+			g.Describe("Possible links and federated bans error", func() {
+				g.BeforeEach(func() {
+					gameService.On("GetGameSettings", mock.Anything).Return(&domain.GameSettings{
+						General: &domain.GeneralSettings{
+							PlayerInfractionTimespan: 1440, // 1 day in minutes
+						},
+					}, nil)
+
+					playerRepo.On("GetByID", mock.Anything, "platform", "playerid").
+						Return(&domain.Player{
+							PlayerID: "playerid",
+							Platform: "platform",
+						}, nil)
+
+					infractionRepo.On("GetPlayerTotalInfractions", mock.Anything, "platform", "playerid").
+						Return(3, nil)
+					infractionRepo.On("GetPlayerInfractionCountSince", mock.Anything, "platform", "playerid", mock.Anything).
+						Return(1, nil)
+
+					altService.On("GetPossibleLinks", mock.Anything, "platform", "playerid").
+						Return(nil, fmt.Errorf("err"))
+					federationRepo.On("GetActiveBansByPlayer", mock.Anything, "platform", "playerid").
+						Return(nil, fmt.Errorf("err"))
+				})
+
+				g.It("Should still return the player payload", func() {
+					payload, err := service.GetPlayerPayload(ctx, "platform", "playerid", game)
+
+					Expect(err).To(BeNil())
+					Expect(payload.InfractionCount).To(Equal(3))
+					Expect(payload.PossibleLinks).To(BeNil())
+					Expect(payload.FederatedBans).To(BeNil())
+					altService.AssertExpectations(t)
+					federationRepo.AssertExpectations(t)
+				})
+			})
+
 			g.Describe("Game service error", func() {
 				g.BeforeEach(func() {
 					gameService.On("GetGameSettings", mock.Anything).Return(nil, fmt.Errorf("err"))
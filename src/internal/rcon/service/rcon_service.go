@@ -25,12 +25,17 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
 	"go.uber.org/zap"
 )
 
+// chatChannelPattern matches the channel and message which follow the sender's name in a chat broadcast.
+var chatChannelPattern = regexp.MustCompile("^\\((?P<Channel>[A-Za-z]+)\\) (?P<Message>.+)$")
+
 type rconService struct {
 	logger        *zap.Logger
 	clients       map[int64]domain.RCONClient
@@ -292,7 +297,7 @@ func (s *rconService) getBroadcastHandler(serverID int64, game domain.Game) func
 			}
 			break
 		case broadcast.TypeChat:
-			fields := bcast.Fields
+			fields := s.resolveChatSender(serverID, bcast.Fields)
 
 			msgBody := &domain.ChatReceiveBody{
 				ServerID:   serverID,
@@ -300,6 +305,7 @@ func (s *rconService) getBroadcastHandler(serverID int64, game domain.Game) func
 				Platform:   game.GetPlatform().GetName(),
 				Name:       fields["Name"],
 				Message:    fields["Message"],
+				Channel:    domain.NormalizeChatChannel(fields["Channel"]),
 				SentByUser: false,
 			}
 
@@ -307,15 +313,57 @@ func (s *rconService) getBroadcastHandler(serverID int64, game domain.Game) func
 				sub(msgBody, serverID, game)
 			}
 			break
-			//case broadcast.TypeBan:
-			//	for _, sub := range s.modActionSubs {
-			//		sub(bcast.Fields, serverID, game)
-			//	}
-			//	break
+		case broadcast.TypeBan, broadcast.TypeKick, broadcast.TypeMute:
+			// Moderator action subscribers handle every type of action, so the type is passed along in the fields
+			bcast.Fields[broadcast.FieldActionType] = bcast.Type
+
+			for _, sub := range s.modActionSubs {
+				sub(bcast.Fields, serverID, game)
+			}
+			break
 		}
 	}
 }
 
+// resolveChatSender splits a chat broadcast using the name of the online player who sent it. Chat patterns match the
+// shortest possible name, so a name which itself contains a channel marker such as ", (Team) " would otherwise be cut
+// short, moving the rest of the name and a spoofed channel into the message.
+func (s *rconService) resolveChatSender(serverID int64, fields broadcast.Fields) broadcast.Fields {
+	if fields["Channel"] == "" {
+		return fields
+	}
+
+	s.prevPlayersLock.Lock()
+	player := s.prevPlayers[serverID][fields["PlayerID"]]
+	s.prevPlayersLock.Unlock()
+
+	if player == nil || player.Name == fields["Name"] {
+		return fields
+	}
+
+	// Rebuild the part of the broadcast following the player ID and split it after the known name instead
+	sent := fmt.Sprintf("%s, (%s) %s", fields["Name"], fields["Channel"], fields["Message"])
+	if !strings.HasPrefix(sent, player.Name+", ") {
+		return fields
+	}
+
+	match := regexutils.MapNamedMatches(chatChannelPattern, strings.TrimPrefix(sent, player.Name+", "))
+	if match == nil {
+		return fields
+	}
+
+	resolved := broadcast.Fields{}
+	for key, value := range fields {
+		resolved[key] = value
+	}
+
+	resolved["Name"] = player.Name
+	resolved["Channel"] = match["Channel"]
+	resolved["Message"] = match["Message"]
+
+	return resolved
+}
+
 func (s *rconService) getDisconnectHandler(serverID int64) func(error, bool) {
 	return func(err error, expected bool) {
 		s.logger.Warn("RCON client disconnected", zap.Int64("Server", serverID), zap.Bool("Expected", expected), zap.Error(err))
@@ -20,6 +20,9 @@ package service
 import (
 	"Refractor/domain"
 	"Refractor/domain/mocks"
+	"Refractor/games/mordhau"
+	"Refractor/pkg/broadcast"
+	"Refractor/platforms/playfab"
 	"fmt"
 	"github.com/franela/goblin"
 	. "github.com/onsi/gomega"
@@ -210,5 +213,69 @@ func Test(t *testing.T) {
 				})
 			})
 		})
+
+		g.Describe("Chat broadcast received", func() {
+			var received []*domain.ChatReceiveBody
+			var handler func(string)
+
+			g.BeforeEach(func() {
+				gameConfig.BroadcastPatterns = map[string]*regexp.Regexp{
+					broadcast.TypeChat: mordhau.NewMordhauGame(nil).GetConfig().BroadcastPatterns[broadcast.TypeChat],
+				}
+				game.On("GetPlatform").Return(playfab.NewPlayfabPlatform())
+
+				received = []*domain.ChatReceiveBody{}
+				service.SubscribeChat(func(body *domain.ChatReceiveBody, serverID int64, game domain.Game) {
+					received = append(received, body)
+				})
+
+				handler = service.getBroadcastHandler(serverID, game)
+			})
+
+			g.Describe("Player name contains a channel marker", func() {
+				g.BeforeEach(func() {
+					service.prevPlayers[serverID] = map[string]*domain.OnlinePlayer{
+						"1ab": {PlayerID: "1ab", Name: "Evil, (All) Bob"},
+					}
+				})
+
+				g.It("Should split the broadcast after the player's full name", func() {
+					handler("Chat: 1ab, Evil, (All) Bob, (Team) hi")
+
+					Expect(received).To(HaveLen(1))
+					Expect(received[0].Name).To(Equal("Evil, (All) Bob"))
+					Expect(received[0].Channel).To(Equal(domain.ChatChannelTeam))
+					Expect(received[0].Message).To(Equal("hi"))
+				})
+			})
+
+			g.Describe("Message contains a channel marker", func() {
+				g.BeforeEach(func() {
+					service.prevPlayers[serverID] = map[string]*domain.OnlinePlayer{
+						"1ab": {PlayerID: "1ab", Name: "Bob"},
+					}
+				})
+
+				g.It("Should keep the channel the message was sent in", func() {
+					handler("Chat: 1ab, Bob, (Team) wait, (All) ok then")
+
+					Expect(received).To(HaveLen(1))
+					Expect(received[0].Name).To(Equal("Bob"))
+					Expect(received[0].Channel).To(Equal(domain.ChatChannelTeam))
+					Expect(received[0].Message).To(Equal("wait, (All) ok then"))
+				})
+			})
+
+			g.Describe("Player is not in the online player list", func() {
+				g.It("Should use the fields matched by the chat pattern", func() {
+					handler("Chat: 1ab, Bob, (All) hello")
+
+					Expect(received).To(HaveLen(1))
+					Expect(received[0].Name).To(Equal("Bob"))
+					Expect(received[0].Channel).To(Equal(domain.ChatChannelAll))
+					Expect(received[0].Message).To(Equal("hello"))
+				})
+			})
+		})
 	})
 }
@@ -0,0 +1,231 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package http
+
+import (
+	"Refractor/domain"
+	"Refractor/params"
+	"Refractor/pkg/api"
+	"Refractor/pkg/structutils"
+	"context"
+	"fmt"
+	"github.com/guregu/null"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type reportHandler struct {
+	service domain.ReportService
+	logger  *zap.Logger
+}
+
+// ApplyReportHandler registers the report endpoints. Permissions are checked by the report service since the server a
+// report belongs to is only known once the report has been fetched.
+func ApplyReportHandler(apiGroup *echo.Group, s domain.ReportService, a domain.Authorizer, mware domain.Middleware, log *zap.Logger) {
+	handler := &reportHandler{
+		service: s,
+		logger:  log,
+	}
+
+	// Create the report routing group
+	reportGroup := apiGroup.Group("/reports", mware.ProtectMiddleware, mware.ActivationMiddleware)
+
+	reportGroup.POST("/search", handler.SearchReports)
+	reportGroup.GET("/:id", handler.GetReport)
+	reportGroup.POST("/:id/claim", handler.ClaimReport)
+	reportGroup.POST("/:id/resolve", handler.ResolveReport)
+	reportGroup.POST("/:id/convert", handler.ConvertReport)
+}
+
+type searchRes struct {
+	Total   int              `json:"total"`
+	Results []*domain.Report `json:"results"`
+}
+
+func (h *reportHandler) SearchReports(c echo.Context) error {
+	// Validate request body
+	var body params.SearchReportParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	// Get search args. Reports can be listed without any filters.
+	searchArgs, err := structutils.GetNonNilFieldMap(body)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	total, results, err := h.service.Search(ctx, searchArgs, body.Limit, body.Offset)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: &searchRes{
+			Total:   total,
+			Results: results,
+		},
+	})
+}
+
+func (h *reportHandler) GetReport(c echo.Context) error {
+	reportID, err := getReportID(c)
+	if err != nil {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	report, err := h.service.GetByID(ctx, reportID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: report,
+	})
+}
+
+func (h *reportHandler) ClaimReport(c echo.Context) error {
+	reportID, err := getReportID(c)
+	if err != nil {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	report, err := h.service.Claim(ctx, reportID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Report claimed",
+		Payload: report,
+	})
+}
+
+func (h *reportHandler) ResolveReport(c echo.Context) error {
+	reportID, err := getReportID(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate request body
+	var body params.ResolveReportParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	report, err := h.service.Resolve(ctx, reportID, body.Resolution)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Report resolved",
+		Payload: report,
+	})
+}
+
+func (h *reportHandler) ConvertReport(c echo.Context) error {
+	reportID, err := getReportID(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate request body
+	var body params.ConvertReportParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	infraction := &domain.Infraction{
+		Type:   body.Type,
+		Reason: null.NewString(body.Reason, body.Reason != ""),
+		RuleID: null.IntFromPtr(body.RuleID),
+	}
+
+	if body.Duration != nil {
+		infraction.Duration = null.IntFrom(int64(*body.Duration))
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	report, err := h.service.Convert(ctx, reportID, infraction)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Report converted into an infraction",
+		Payload: report,
+	})
+}
+
+func getReportID(c echo.Context) (int64, error) {
+	reportID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, domain.NewHTTPError(fmt.Errorf("invalid report id"), http.StatusBadRequest, "")
+	}
+
+	return reportID, nil
+}
@@ -0,0 +1,271 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"Refractor/pkg/querybuilders/psqlqb"
+	"context"
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const opTag = "ReportRepo.Postgres."
+
+type reportRepo struct {
+	db     *sql.DB
+	logger *zap.Logger
+	qb     domain.QueryBuilder
+}
+
+func NewReportRepo(db *sql.DB, logger *zap.Logger) domain.ReportRepo {
+	return &reportRepo{
+		db:     db,
+		logger: logger,
+		qb:     psqlqb.NewPostgresQueryBuilder(),
+	}
+}
+
+func (r *reportRepo) fetch(ctx context.Context, query string, args ...interface{}) ([]*domain.Report, error) {
+	const op = opTag + "Fetch"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	// Clean up on function exit
+	defer func() {
+		errRow := rows.Close()
+		if errRow != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.Report, 0)
+	for rows.Next() {
+		report := &domain.Report{}
+
+		if err := r.scanRows(rows, report); err != nil {
+			if err == sql.ErrNoRows {
+				return nil, errors.Wrap(domain.ErrNotFound, op)
+			}
+
+			return nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, report)
+	}
+
+	return results, nil
+}
+
+func (r *reportRepo) Store(ctx context.Context, report *domain.Report) (*domain.Report, error) {
+	const op = opTag + "Store"
+
+	query := `INSERT INTO Reports (ServerID, Platform, ReporterID, TargetID, Reason, Status)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING *;`
+
+	stmt, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Could not prepare statement", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	row := stmt.QueryRowContext(ctx, report.ServerID, report.Platform, report.ReporterID, report.TargetID,
+		report.Reason, report.Status)
+
+	newReport := &domain.Report{}
+	if err := r.scanRow(row, newReport); err != nil {
+		r.logger.Error("Could not scan newly created report", zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return newReport, nil
+}
+
+func (r *reportRepo) GetByID(ctx context.Context, id int64) (*domain.Report, error) {
+	const op = opTag + "GetByID"
+
+	query := "SELECT * FROM Reports WHERE ReportID = $1;"
+
+	results, err := r.fetch(ctx, query, id)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if len(results) > 0 {
+		return results[0], nil
+	}
+
+	return nil, errors.Wrap(domain.ErrNotFound, op)
+}
+
+func (r *reportRepo) Update(ctx context.Context, id int64, args domain.UpdateArgs) (*domain.Report, error) {
+	const op = opTag + "Update"
+
+	query, values := r.qb.BuildUpdateQuery("Reports", id, "ReportID", args, nil)
+
+	stmt, err := r.db.PrepareContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Could not prepare statement", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	row := stmt.QueryRowContext(ctx, values...)
+
+	updated := &domain.Report{}
+	if err := r.scanRow(row, updated); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.Wrap(domain.ErrNotFound, op)
+		}
+
+		r.logger.Error("Could not scan updated report", zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return updated, nil
+}
+
+func (r *reportRepo) Search(ctx context.Context, args domain.FindArgs, serverIDs []int64, limit, offset int) (int, []*domain.Report, error) {
+	const op = opTag + "Search"
+
+	where := `
+		($1::INT[] IS NULL OR $1::INT[] = '{}' OR ServerID = ANY ($1::INT[])) AND
+		($2::ReportStatus IS NULL OR Status = $2) AND
+		($3::INT IS NULL OR ServerID = $3) AND
+		($4::VARCHAR IS NULL OR Platform = $4) AND
+		($5::VARCHAR IS NULL OR TargetID = $5) AND
+		($6::VARCHAR IS NULL OR ReporterID = $6)
+	`
+
+	query := `SELECT * FROM Reports WHERE ` + where + ` ORDER BY CreatedAt DESC LIMIT $7 OFFSET $8;`
+
+	var (
+		status     = args["Status"]
+		serverID   = args["ServerID"]
+		platform   = args["Platform"]
+		targetID   = args["TargetID"]
+		reporterID = args["ReporterID"]
+	)
+
+	results, err := r.fetch(ctx, query, pq.Array(serverIDs), status, serverID, platform, targetID, reporterID,
+		limit, offset)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, op)
+	}
+
+	if len(results) < 1 {
+		return 0, results, nil
+	}
+
+	// Get total number of matches
+	query = `SELECT COUNT(1) AS Count FROM Reports WHERE ` + where + `;`
+
+	row := r.db.QueryRowContext(ctx, query, pq.Array(serverIDs), status, serverID, platform, targetID, reporterID)
+
+	var count int
+	if err := row.Scan(&count); err != nil {
+		r.logger.Error("Could not scan total search results for report search", zap.Error(err))
+		return 0, nil, errors.Wrap(err, op)
+	}
+
+	return count, results, nil
+}
+
+func (r *reportRepo) LinkChatMessages(ctx context.Context, id int64, messageIDs ...int64) error {
+	const op = opTag + "LinkChatMessages"
+
+	query := `INSERT INTO ReportChatMessages (ReportID, MessageID) SELECT $1, UNNEST($2::INT[])
+			ON CONFLICT DO NOTHING;`
+
+	if _, err := r.db.ExecContext(ctx, query, id, pq.Array(messageIDs)); err != nil {
+		r.logger.Error("Could not link chat messages to report",
+			zap.Int64("Report ID", id),
+			zap.Any("Message IDs", messageIDs),
+			zap.Error(err),
+		)
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *reportRepo) GetLinkedChatMessages(ctx context.Context, id int64) ([]*domain.ChatMessage, error) {
+	const op = opTag + "GetLinkedChatMessages"
+
+	query := `
+		SELECT
+			cm.MessageID,
+			cm.PlayerID,
+			cm.Platform,
+			cm.ServerID,
+			cm.Message,
+			cm.Channel,
+			cm.Flagged,
+			cm.CreatedAt,
+			cm.ModifiedAt
+		FROM ReportChatMessages rcm
+		INNER JOIN ChatMessages cm ON cm.MessageID = rcm.MessageID
+		WHERE rcm.ReportID = $1
+		ORDER BY cm.CreatedAt, cm.MessageID;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, id)
+	if err != nil {
+		r.logger.Error("Could not execute linked report-chatmessages query", zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	messages := make([]*domain.ChatMessage, 0)
+	for rows.Next() {
+		msg := &domain.ChatMessage{}
+
+		if err := rows.Scan(&msg.MessageID, &msg.PlayerID, &msg.Platform, &msg.ServerID, &msg.Message,
+			&msg.Channel, &msg.Flagged, &msg.CreatedAt, &msg.ModifiedAt); err != nil {
+			r.logger.Error("Could not scan chat message", zap.Error(err))
+			return nil, errors.Wrap(err, op)
+		}
+
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
+// Scan helpers
+func (r *reportRepo) scanRow(row *sql.Row, rp *domain.Report) error {
+	return row.Scan(&rp.ReportID, &rp.ServerID, &rp.Platform, &rp.ReporterID, &rp.TargetID, &rp.Reason, &rp.Status,
+		&rp.ClaimedBy, &rp.ClaimedAt, &rp.ResolvedBy, &rp.ResolvedAt, &rp.Resolution, &rp.InfractionID, &rp.CreatedAt,
+		&rp.ModifiedAt)
+}
+
+func (r *reportRepo) scanRows(rows *sql.Rows, rp *domain.Report) error {
+	return rows.Scan(&rp.ReportID, &rp.ServerID, &rp.Platform, &rp.ReporterID, &rp.TargetID, &rp.Reason, &rp.Status,
+		&rp.ClaimedBy, &rp.ClaimedAt, &rp.ResolvedBy, &rp.ResolvedAt, &rp.Resolution, &rp.InfractionID, &rp.CreatedAt,
+		&rp.ModifiedAt)
+}
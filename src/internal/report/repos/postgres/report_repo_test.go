@@ -0,0 +1,215 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/franela/goblin"
+	"github.com/guregu/null"
+	"github.com/lib/pq"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var cols = []string{"ReportID", "ServerID", "Platform", "ReporterID", "TargetID", "Reason", "Status", "ClaimedBy",
+		"ClaimedAt", "ResolvedBy", "ResolvedAt", "Resolution", "InfractionID", "CreatedAt", "ModifiedAt"}
+	var messageCols = []string{"MessageID", "PlayerID", "Platform", "ServerID", "Message", "Channel", "Flagged",
+		"CreatedAt", "ModifiedAt"}
+
+	g.Describe("Postgres Report Repo", func() {
+		var repo domain.ReportRepo
+		var mock sqlmock.Sqlmock
+		var db *sql.DB
+		var ctx context.Context
+
+		g.BeforeEach(func() {
+			var err error
+
+			db, mock, err = sqlmock.New()
+			if err != nil {
+				t.Fatalf("Could not create new sqlmock instance. Error: %v", err)
+			}
+
+			repo = NewReportRepo(db, zap.NewNop())
+			ctx = context.TODO()
+		})
+
+		g.AfterEach(func() {
+			_ = db.Close()
+		})
+
+		g.Describe("Store()", func() {
+			var report *domain.Report
+
+			g.BeforeEach(func() {
+				report = &domain.Report{
+					ServerID:   1,
+					Platform:   "platform",
+					ReporterID: "reporter",
+					TargetID:   "target",
+					Reason:     "spamming",
+					Status:     domain.ReportStatusOpen,
+				}
+			})
+
+			g.Describe("Report stored successfully", func() {
+				g.BeforeEach(func() {
+					mock.ExpectPrepare("INSERT INTO Reports").ExpectQuery().
+						WithArgs(report.ServerID, report.Platform, report.ReporterID, report.TargetID, report.Reason,
+							report.Status).
+						WillReturnRows(sqlmock.NewRows(cols).AddRow(1, 1, "platform", "reporter", "target", "spamming",
+							"open", nil, nil, nil, nil, nil, nil, time.Time{}, time.Time{}))
+				})
+
+				g.It("Should return the new report", func() {
+					stored, err := repo.Store(ctx, report)
+
+					Expect(err).To(BeNil())
+					Expect(stored.ReportID).To(Equal(int64(1)))
+					Expect(stored.Status).To(Equal(domain.ReportStatusOpen))
+					Expect(stored.ClaimedBy.Valid).To(BeFalse())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Database error", func() {
+				g.BeforeEach(func() {
+					mock.ExpectPrepare("INSERT INTO Reports").ExpectQuery().WillReturnError(fmt.Errorf("err"))
+				})
+
+				g.It("Should return an error", func() {
+					_, err := repo.Store(ctx, report)
+
+					Expect(err).ToNot(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("GetByID()", func() {
+			g.Describe("Report found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Reports WHERE ReportID = $1")).WithArgs(1).
+						WillReturnRows(sqlmock.NewRows(cols).AddRow(1, 1, "platform", "reporter", "target", "spamming",
+							"claimed", "user", time.Time{}, nil, nil, nil, nil, time.Time{}, time.Time{}))
+				})
+
+				g.It("Should return the report", func() {
+					report, err := repo.GetByID(ctx, 1)
+
+					Expect(err).To(BeNil())
+					Expect(report.ReportID).To(Equal(int64(1)))
+					Expect(report.Status).To(Equal(domain.ReportStatusClaimed))
+					Expect(report.ClaimedBy).To(Equal(null.StringFrom("user")))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Report not found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Reports WHERE ReportID = $1")).WithArgs(1).
+						WillReturnRows(sqlmock.NewRows(cols))
+				})
+
+				g.It("Should return domain.ErrNotFound", func() {
+					_, err := repo.GetByID(ctx, 1)
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("Update()", func() {
+			g.Describe("Report updated", func() {
+				g.BeforeEach(func() {
+					mock.ExpectPrepare("UPDATE Reports SET").ExpectQuery().
+						WithArgs(domain.ReportStatusResolved, 1).
+						WillReturnRows(sqlmock.NewRows(cols).AddRow(1, 1, "platform", "reporter", "target", "spamming",
+							"resolved", nil, nil, nil, nil, nil, nil, time.Time{}, time.Time{}))
+				})
+
+				g.It("Should return the updated report", func() {
+					report, err := repo.Update(ctx, 1, domain.UpdateArgs{"Status": domain.ReportStatusResolved})
+
+					Expect(err).To(BeNil())
+					Expect(report.Status).To(Equal(domain.ReportStatusResolved))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Report not found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectPrepare("UPDATE Reports SET").ExpectQuery().
+						WithArgs(domain.ReportStatusResolved, 1).
+						WillReturnRows(sqlmock.NewRows(cols))
+				})
+
+				g.It("Should return domain.ErrNotFound", func() {
+					_, err := repo.Update(ctx, 1, domain.UpdateArgs{"Status": domain.ReportStatusResolved})
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("LinkChatMessages()", func() {
+			g.It("Should link the messages", func() {
+				mock.ExpectExec("INSERT INTO ReportChatMessages").
+					WithArgs(1, pq.Array([]int64{4, 5})).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+
+				err := repo.LinkChatMessages(ctx, 1, 4, 5)
+
+				Expect(err).To(BeNil())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("GetLinkedChatMessages()", func() {
+			g.It("Should return the linked messages", func() {
+				mock.ExpectQuery("SELECT (.+) FROM ReportChatMessages rcm").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(messageCols).
+						AddRow(4, "player", "platform", 1, "hello", "all", false, time.Time{}, time.Time{}).
+						AddRow(5, "player", "platform", 1, "!report bob spam", "all", false, time.Time{}, time.Time{}))
+
+				messages, err := repo.GetLinkedChatMessages(ctx, 1)
+
+				Expect(err).To(BeNil())
+				Expect(len(messages)).To(Equal(2))
+				Expect(messages[1].MessageID).To(Equal(int64(5)))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+	})
+}
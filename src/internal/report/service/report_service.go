@@ -0,0 +1,484 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/authcheckers"
+	"Refractor/domain"
+	"Refractor/pkg/cmdtemplate"
+	"Refractor/pkg/perms"
+	"Refractor/pkg/whitelist"
+	"context"
+	"fmt"
+	"github.com/guregu/null"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reportCommandCooldown is how long a player has to wait between report commands. Commands sent during the cooldown
+// are ignored so that a player can't flood staff with reports or the server chat with replies.
+const reportCommandCooldown = time.Second * 30
+
+type reportService struct {
+	repo              domain.ReportRepo
+	chatRepo          domain.ChatRepo
+	playerNameRepo    domain.PlayerNameRepo
+	serverService     domain.ServerService
+	rconService       domain.RCONService
+	infractionService domain.InfractionService
+	authorizer        domain.Authorizer
+	timeout           time.Duration
+	logger            *zap.Logger
+	createSubs        []domain.ReportSubscriber
+
+	// lastCommands holds when each player last used the report command
+	lastCommands     map[string]time.Time
+	lastCommandsLock sync.Mutex
+}
+
+func NewReportService(repo domain.ReportRepo, cr domain.ChatRepo, pnr domain.PlayerNameRepo, ss domain.ServerService,
+	rs domain.RCONService, is domain.InfractionService, a domain.Authorizer, to time.Duration,
+	log *zap.Logger) domain.ReportService {
+	return &reportService{
+		repo:              repo,
+		chatRepo:          cr,
+		playerNameRepo:    pnr,
+		serverService:     ss,
+		rconService:       rs,
+		infractionService: is,
+		authorizer:        a,
+		timeout:           to,
+		logger:            log,
+		createSubs:        []domain.ReportSubscriber{},
+		lastCommands:      map[string]time.Time{},
+	}
+}
+
+func (s *reportService) HandleReportCommand(command *domain.ReportCommand, game domain.Game) {
+	ctx, cancel := context.WithTimeout(context.TODO(), s.timeout)
+	defer cancel()
+
+	if !s.claimCommand(command.Platform, command.ReporterID) {
+		s.logger.Debug("Ignoring report command sent during cooldown",
+			zap.Int64("Server ID", command.ServerID),
+			zap.String("Reporter ID", command.ReporterID))
+		return
+	}
+
+	if command.Target == "" || command.Reason == "" {
+		s.reply(command.ServerID, game, fmt.Sprintf("%s, to report a player use: %s <name> <reason>",
+			command.ReporterName, command.Command))
+		return
+	}
+
+	data, err := s.serverService.GetServerData(command.ServerID)
+	if err != nil {
+		s.logger.Error("Could not get server data to resolve reported player",
+			zap.Int64("Server ID", command.ServerID), zap.Error(err))
+		return
+	}
+
+	candidates := findOnlinePlayers(data.OnlinePlayers, command.Target)
+	switch {
+	case len(candidates) < 1:
+		s.reply(command.ServerID, game, fmt.Sprintf("%s, no online player matches \"%s\".", command.ReporterName,
+			command.Target))
+		return
+	case len(candidates) > 1:
+		s.reply(command.ServerID, game, fmt.Sprintf("%s, more than one online player matches \"%s\". Please be more "+
+			"specific.", command.ReporterName, command.Target))
+		return
+	}
+
+	target := candidates[0]
+	if target.GetPlayerID() == command.ReporterID {
+		s.reply(command.ServerID, game, fmt.Sprintf("%s, you can't report yourself.", command.ReporterName))
+		return
+	}
+
+	report, err := s.repo.Store(ctx, &domain.Report{
+		ServerID:   command.ServerID,
+		Platform:   command.Platform,
+		ReporterID: command.ReporterID,
+		TargetID:   target.GetPlayerID(),
+		Reason:     command.Reason,
+		Status:     domain.ReportStatusOpen,
+	})
+	if err != nil {
+		s.logger.Error("Could not store report",
+			zap.Int64("Server ID", command.ServerID),
+			zap.String("Reporter ID", command.ReporterID),
+			zap.String("Target ID", target.GetPlayerID()),
+			zap.Error(err))
+		return
+	}
+
+	report.ReporterName = command.ReporterName
+	report.TargetName = target.GetCurrentName()
+
+	// Link the chat which surrounded the report. The report command itself is the most recent message.
+	recent, err := s.chatRepo.GetRecentByServer(ctx, command.ServerID, domain.ReportChatContextSize)
+	if err != nil && errors.Cause(err) != domain.ErrNotFound {
+		s.logger.Error("Could not get recent chat messages for report", zap.Int64("Report ID", report.ReportID),
+			zap.Error(err))
+	}
+
+	if len(recent) > 0 {
+		messageIDs := make([]int64, 0, len(recent))
+		for _, msg := range recent {
+			messageIDs = append(messageIDs, msg.MessageID)
+		}
+
+		if err := s.repo.LinkChatMessages(ctx, report.ReportID, messageIDs...); err != nil {
+			s.logger.Error("Could not link chat messages to report", zap.Int64("Report ID", report.ReportID),
+				zap.Error(err))
+		}
+	}
+
+	for _, sub := range s.createSubs {
+		sub(report)
+	}
+
+	s.reply(command.ServerID, game, fmt.Sprintf("%s, your report against %s was sent to staff.",
+		command.ReporterName, report.TargetName))
+}
+
+// claimCommand records a report command from a player. It returns false if the player already used the command within
+// reportCommandCooldown.
+func (s *reportService) claimCommand(platform, playerID string) bool {
+	s.lastCommandsLock.Lock()
+	defer s.lastCommandsLock.Unlock()
+
+	now := time.Now()
+
+	for key, last := range s.lastCommands {
+		if now.Sub(last) >= reportCommandCooldown {
+			delete(s.lastCommands, key)
+		}
+	}
+
+	key := fmt.Sprintf("%s:%s", platform, playerID)
+	if _, ok := s.lastCommands[key]; ok {
+		return false
+	}
+
+	s.lastCommands[key] = now
+	return true
+}
+
+// findOnlinePlayers returns the online players matching a name typed by a player. A case-insensitive exact match is
+// preferred. Otherwise, all players whose name contains the typed name are returned.
+func findOnlinePlayers(players map[string]domain.IPlayer, name string) []domain.IPlayer {
+	name = strings.ToLower(name)
+	var partial []domain.IPlayer
+
+	for _, player := range players {
+		current := strings.ToLower(player.GetCurrentName())
+
+		if current == name {
+			return []domain.IPlayer{player}
+		}
+
+		if strings.Contains(current, name) {
+			partial = append(partial, player)
+		}
+	}
+
+	return partial
+}
+
+// reply sends a message to a server's chat using its game's broadcast command. Replies include text typed by players,
+// so control characters are stripped the same way they are from infraction command values.
+func (s *reportService) reply(serverID int64, game domain.Game, message string) {
+	message = cmdtemplate.StripControl(message)
+
+	client := s.rconService.GetServerClient(serverID)
+	if client == nil {
+		s.logger.Warn("Could not reply to report command, server has no RCON client", zap.Int64("Server ID", serverID))
+		return
+	}
+
+	if _, err := client.RunCommand(fmt.Sprintf(game.GetBroadcastCommand(), message)); err != nil {
+		s.logger.Error("Could not reply to report command",
+			zap.Int64("Server ID", serverID),
+			zap.String("Message", message),
+			zap.Error(err))
+	}
+}
+
+// GetByID returns a report along with the chat which surrounded it.
+//
+// If a user is set in the provided context, they must have permission to handle reports on the report's server.
+func (s *reportService) GetByID(c context.Context, id int64) (*domain.Report, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	report, err := s.getReport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	report.ChatMessages, err = s.repo.GetLinkedChatMessages(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, msg := range report.ChatMessages {
+		msg.Name = s.getName(ctx, msg.PlayerID, msg.Platform)
+	}
+
+	return report, nil
+}
+
+// Search returns reports matching the provided args, newest first.
+//
+// If a user is set in the provided context, only reports on servers they can handle reports on are returned.
+func (s *reportService) Search(c context.Context, args domain.FindArgs, limit, offset int) (int, []*domain.Report, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	// Filter out illegal values
+	wl := whitelist.StringKeyMap([]string{"Status", "ServerID", "Platform", "TargetID", "ReporterID"})
+	args = wl.FilterKeys(args)
+
+	var authorizedServers []int64 = nil
+	if user, ok := ctx.Value("user").(*domain.AuthUser); ok {
+		var err error
+		authorizedServers, err = s.authorizer.GetAuthorizedServers(ctx, user.Identity.Id,
+			authcheckers.HasPermission(perms.FlagHandleReports, true))
+		if err != nil {
+			if errors.Cause(err) == domain.ErrNotFound {
+				return 0, []*domain.Report{}, nil
+			}
+
+			return 0, nil, err
+		}
+
+		// An empty server list would not filter the search at all
+		if len(authorizedServers) < 1 {
+			return 0, []*domain.Report{}, nil
+		}
+	}
+
+	total, reports, err := s.repo.Search(ctx, args, authorizedServers, limit, offset)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	for _, report := range reports {
+		s.populateNames(ctx, report)
+	}
+
+	return total, reports, nil
+}
+
+// Claim marks a report as being handled by the user set in the provided context. Only open reports can be claimed.
+func (s *reportService) Claim(c context.Context, id int64) (*domain.Report, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	report, err := s.getReport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if report.Status != domain.ReportStatusOpen {
+		return nil, domain.NewHTTPError(nil, http.StatusBadRequest,
+			fmt.Sprintf("This report has already been %s", report.Status))
+	}
+
+	updated, err := s.repo.Update(ctx, id, domain.UpdateArgs{
+		"Status":    domain.ReportStatusClaimed,
+		"ClaimedBy": getUserID(ctx),
+		"ClaimedAt": null.TimeFrom(time.Now()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.populateNames(ctx, updated)
+
+	return updated, nil
+}
+
+// Resolve closes a report with an optional note on how it was handled.
+func (s *reportService) Resolve(c context.Context, id int64, resolution string) (*domain.Report, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	report, err := s.getReport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.resolve(ctx, report, domain.UpdateArgs{
+		"Resolution": null.NewString(resolution, resolution != ""),
+	})
+}
+
+func (s *reportService) resolve(ctx context.Context, report *domain.Report, args domain.UpdateArgs) (*domain.Report, error) {
+	if report.Status == domain.ReportStatusResolved {
+		return nil, domain.NewHTTPError(nil, http.StatusBadRequest, "This report has already been resolved")
+	}
+
+	args["Status"] = domain.ReportStatusResolved
+	args["ResolvedBy"] = getUserID(ctx)
+	args["ResolvedAt"] = null.TimeFrom(time.Now())
+
+	updated, err := s.repo.Update(ctx, report.ReportID, args)
+	if err != nil {
+		return nil, err
+	}
+
+	s.populateNames(ctx, updated)
+
+	return updated, nil
+}
+
+// createInfractionFlags are the permissions needed to create the built-in infraction types. Permissions for custom
+// types are checked by the infraction service.
+var createInfractionFlags = map[string]perms.FlagName{
+	domain.InfractionTypeWarning: perms.FlagCreateWarning,
+	domain.InfractionTypeMute:    perms.FlagCreateMute,
+	domain.InfractionTypeKick:    perms.FlagCreateKick,
+	domain.InfractionTypeBan:     perms.FlagCreateBan,
+}
+
+// Convert creates an infraction against the reported player, links the report's chat to it and resolves the report.
+//
+// If a user is set in the provided context, they must have permission to handle reports and to create the infraction's
+// type on the report's server.
+func (s *reportService) Convert(c context.Context, id int64, infraction *domain.Infraction) (*domain.Report, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	report, err := s.getReport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if report.Status == domain.ReportStatusResolved {
+		return nil, domain.NewHTTPError(nil, http.StatusBadRequest, "This report has already been resolved")
+	}
+
+	if flag, ok := createInfractionFlags[infraction.Type]; ok {
+		if err := s.checkPermission(ctx, report.ServerID, flag,
+			"You do not have permission to create infractions of this type."); err != nil {
+			return nil, err
+		}
+	}
+
+	infraction.PlayerID = report.TargetID
+	infraction.Platform = report.Platform
+	infraction.ServerID = report.ServerID
+	infraction.UserID = getUserID(ctx)
+
+	messages, err := s.repo.GetLinkedChatMessages(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	messageIDs := make([]int64, 0, len(messages))
+	for _, msg := range messages {
+		messageIDs = append(messageIDs, msg.MessageID)
+	}
+
+	created, err := s.infractionService.Store(ctx, infraction, nil, messageIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.resolve(ctx, report, domain.UpdateArgs{
+		"InfractionID": null.IntFrom(created.InfractionID),
+		"Resolution":   null.StringFrom(fmt.Sprintf("Converted into infraction #%d", created.InfractionID)),
+	})
+}
+
+func (s *reportService) SubscribeReportCreate(sub domain.ReportSubscriber) {
+	s.createSubs = append(s.createSubs, sub)
+}
+
+// getReport returns a report after checking that the user in context can handle reports on the report's server.
+func (s *reportService) getReport(ctx context.Context, id int64) (*domain.Report, error) {
+	report, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkPermission(ctx, report.ServerID, perms.FlagHandleReports,
+		"You do not have permission to handle this report."); err != nil {
+		return nil, err
+	}
+
+	s.populateNames(ctx, report)
+
+	return report, nil
+}
+
+func (s *reportService) populateNames(ctx context.Context, report *domain.Report) {
+	report.ReporterName = s.getName(ctx, report.ReporterID, report.Platform)
+	report.TargetName = s.getName(ctx, report.TargetID, report.Platform)
+}
+
+func (s *reportService) getName(ctx context.Context, playerID, platform string) string {
+	currentName, _, err := s.playerNameRepo.GetNames(ctx, playerID, platform)
+	if err != nil {
+		s.logger.Error("Could not get player name for report",
+			zap.String("Platform", platform),
+			zap.String("Player ID", playerID),
+			zap.Error(err))
+		return ""
+	}
+
+	return currentName
+}
+
+// checkPermission checks that the user in context has the provided permission on a server. If no user is set in
+// context, the call is seen as a system call and is not checked.
+func (s *reportService) checkPermission(ctx context.Context, serverID int64, flag perms.FlagName, message string) error {
+	user, ok := ctx.Value("user").(*domain.AuthUser)
+	if !ok {
+		return nil
+	}
+
+	hasPermission, err := s.authorizer.HasPermission(ctx, domain.AuthScope{
+		Type: domain.AuthObjServer,
+		ID:   serverID,
+	}, user.Identity.Id, authcheckers.HasPermission(flag, true))
+	if err != nil {
+		return err
+	}
+
+	if !hasPermission {
+		return domain.NewHTTPError(nil, http.StatusUnauthorized, message)
+	}
+
+	return nil
+}
+
+func getUserID(ctx context.Context) null.String {
+	if user, ok := ctx.Value("user").(*domain.AuthUser); ok {
+		return null.StringFrom(user.Identity.Id)
+	}
+
+	return null.String{}
+}
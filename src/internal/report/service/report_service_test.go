@@ -0,0 +1,495 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/domain"
+	"Refractor/domain/mocks"
+	"context"
+	"fmt"
+	"github.com/franela/goblin"
+	"github.com/guregu/null"
+	. "github.com/onsi/gomega"
+	kratos "github.com/ory/kratos-client-go"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Report Service", func() {
+		var repo *mocks.ReportRepo
+		var chatRepo *mocks.ChatRepo
+		var playerNameRepo *mocks.PlayerNameRepo
+		var serverService *mocks.ServerService
+		var rconService *mocks.RCONService
+		var rconClient *mocks.RCONClient
+		var infractionService *mocks.InfractionService
+		var authorizer *mocks.Authorizer
+		var game *mocks.Game
+		var service *reportService
+		var ctx context.Context
+
+		g.BeforeEach(func() {
+			repo = new(mocks.ReportRepo)
+			chatRepo = new(mocks.ChatRepo)
+			playerNameRepo = new(mocks.PlayerNameRepo)
+			serverService = new(mocks.ServerService)
+			rconService = new(mocks.RCONService)
+			rconClient = new(mocks.RCONClient)
+			infractionService = new(mocks.InfractionService)
+			authorizer = new(mocks.Authorizer)
+			game = new(mocks.Game)
+			service = &reportService{
+				repo:              repo,
+				chatRepo:          chatRepo,
+				playerNameRepo:    playerNameRepo,
+				serverService:     serverService,
+				rconService:       rconService,
+				infractionService: infractionService,
+				authorizer:        authorizer,
+				timeout:           time.Second * 2,
+				logger:            zap.NewNop(),
+				lastCommands:      map[string]time.Time{},
+			}
+			ctx = context.TODO()
+
+			game.On("GetBroadcastCommand").Return("say %s")
+			rconService.On("GetServerClient", mock.Anything).Return(rconClient)
+			rconClient.On("RunCommand", mock.Anything).Return("", nil)
+			playerNameRepo.On("GetNames", mock.Anything, mock.Anything, mock.Anything).
+				Return("name", []string{}, nil)
+		})
+
+		g.Describe("findOnlinePlayers()", func() {
+			var players map[string]domain.IPlayer
+
+			g.BeforeEach(func() {
+				players = map[string]domain.IPlayer{
+					"1": &domain.Player{PlayerID: "1", CurrentName: "Bob"},
+					"2": &domain.Player{PlayerID: "2", CurrentName: "Bobby"},
+					"3": &domain.Player{PlayerID: "3", CurrentName: "Alice"},
+				}
+			})
+
+			g.It("Should prefer a case-insensitive exact match", func() {
+				found := findOnlinePlayers(players, "bob")
+
+				Expect(len(found)).To(Equal(1))
+				Expect(found[0].GetPlayerID()).To(Equal("1"))
+			})
+
+			g.It("Should return a unique partial match", func() {
+				found := findOnlinePlayers(players, "lic")
+
+				Expect(len(found)).To(Equal(1))
+				Expect(found[0].GetPlayerID()).To(Equal("3"))
+			})
+
+			g.It("Should return every partial match", func() {
+				found := findOnlinePlayers(players, "bo")
+
+				Expect(len(found)).To(Equal(2))
+			})
+
+			g.It("Should return no players if none match", func() {
+				found := findOnlinePlayers(players, "carl")
+
+				Expect(len(found)).To(Equal(0))
+			})
+		})
+
+		g.Describe("HandleReportCommand()", func() {
+			var command *domain.ReportCommand
+
+			g.BeforeEach(func() {
+				command = &domain.ReportCommand{
+					Command:      "!report",
+					ServerID:     1,
+					Platform:     "platform",
+					ReporterID:   "reporter",
+					ReporterName: "Reporter",
+					Target:       "bob",
+					Reason:       "spamming",
+				}
+
+				serverService.On("GetServerData", int64(1)).Return(&domain.ServerData{
+					OnlinePlayers: map[string]domain.IPlayer{
+						"reporter": &domain.Player{PlayerID: "reporter", CurrentName: "Reporter"},
+						"target":   &domain.Player{PlayerID: "target", CurrentName: "Bob"},
+					},
+				}, nil)
+			})
+
+			g.Describe("Report created", func() {
+				var notified *domain.Report
+
+				g.BeforeEach(func() {
+					notified = nil
+					service.SubscribeReportCreate(func(report *domain.Report) {
+						notified = report
+					})
+
+					repo.On("Store", mock.Anything, mock.Anything).Return(&domain.Report{
+						ReportID:   5,
+						ServerID:   1,
+						Platform:   "platform",
+						ReporterID: "reporter",
+						TargetID:   "target",
+						Reason:     "spamming",
+						Status:     domain.ReportStatusOpen,
+					}, nil)
+					chatRepo.On("GetRecentByServer", mock.Anything, int64(1), domain.ReportChatContextSize).
+						Return([]*domain.ChatMessage{{MessageID: 10}, {MessageID: 11}}, nil)
+					repo.On("LinkChatMessages", mock.Anything, int64(5), int64(10), int64(11)).Return(nil)
+				})
+
+				g.It("Should store the report against the matched player", func() {
+					service.HandleReportCommand(command, game)
+
+					repo.AssertCalled(t, "Store", mock.Anything, &domain.Report{
+						ServerID:   1,
+						Platform:   "platform",
+						ReporterID: "reporter",
+						TargetID:   "target",
+						Reason:     "spamming",
+						Status:     domain.ReportStatusOpen,
+					})
+				})
+
+				g.It("Should link the recent chat messages", func() {
+					service.HandleReportCommand(command, game)
+
+					repo.AssertExpectations(t)
+					chatRepo.AssertExpectations(t)
+				})
+
+				g.It("Should notify subscribers", func() {
+					service.HandleReportCommand(command, game)
+
+					Expect(notified).ToNot(BeNil())
+					Expect(notified.ReportID).To(Equal(int64(5)))
+					Expect(notified.ReporterName).To(Equal("Reporter"))
+					Expect(notified.TargetName).To(Equal("Bob"))
+				})
+
+				g.It("Should acknowledge the report in-game", func() {
+					service.HandleReportCommand(command, game)
+
+					rconClient.AssertCalled(t, "RunCommand", "say Reporter, your report against Bob was sent to staff.")
+				})
+
+				g.It("Should ignore another report from the same player during the cooldown", func() {
+					service.HandleReportCommand(command, game)
+					service.HandleReportCommand(command, game)
+
+					repo.AssertNumberOfCalls(t, "Store", 1)
+					rconClient.AssertNumberOfCalls(t, "RunCommand", 1)
+				})
+
+				g.It("Should accept a report from the same player after the cooldown", func() {
+					service.HandleReportCommand(command, game)
+					service.lastCommands["platform:reporter"] = time.Now().Add(-reportCommandCooldown)
+					service.HandleReportCommand(command, game)
+
+					repo.AssertNumberOfCalls(t, "Store", 2)
+				})
+
+				g.It("Should accept reports from other players during the cooldown", func() {
+					service.HandleReportCommand(command, game)
+
+					command.ReporterID = "other"
+					service.HandleReportCommand(command, game)
+
+					repo.AssertNumberOfCalls(t, "Store", 2)
+				})
+			})
+
+			g.Describe("Reason missing", func() {
+				g.BeforeEach(func() {
+					command.Reason = ""
+				})
+
+				g.It("Should reply with the command usage and not store a report", func() {
+					service.HandleReportCommand(command, game)
+
+					rconClient.AssertCalled(t, "RunCommand",
+						"say Reporter, to report a player use: !report <name> <reason>")
+					repo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("Target not online", func() {
+				g.BeforeEach(func() {
+					command.Target = "carl"
+				})
+
+				g.It("Should not store a report", func() {
+					service.HandleReportCommand(command, game)
+
+					rconClient.AssertCalled(t, "RunCommand", "say Reporter, no online player matches \"carl\".")
+					repo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+
+				g.It("Should strip control characters from the typed name in the reply", func() {
+					command.Target = "carl\nkickall"
+
+					service.HandleReportCommand(command, game)
+
+					rconClient.AssertCalled(t, "RunCommand", "say Reporter, no online player matches \"carl kickall\".")
+				})
+			})
+
+			g.Describe("Reporter reports themselves", func() {
+				g.BeforeEach(func() {
+					command.Target = "reporter"
+				})
+
+				g.It("Should not store a report", func() {
+					service.HandleReportCommand(command, game)
+
+					rconClient.AssertCalled(t, "RunCommand", "say Reporter, you can't report yourself.")
+					repo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+		})
+
+		g.Describe("Claim()", func() {
+			g.BeforeEach(func() {
+				ctx = context.WithValue(ctx, "user", &domain.AuthUser{
+					Session: &kratos.Session{Identity: kratos.Identity{Id: "user"}},
+				})
+			})
+
+			g.Describe("Open report", func() {
+				g.BeforeEach(func() {
+					repo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Report{
+						ReportID: 1,
+						ServerID: 1,
+						Status:   domain.ReportStatusOpen,
+					}, nil)
+					authorizer.On("HasPermission", mock.Anything, mock.Anything, "user", mock.Anything).
+						Return(true, nil)
+					repo.On("Update", mock.Anything, int64(1), mock.Anything).Return(&domain.Report{
+						ReportID:  1,
+						ServerID:  1,
+						Status:    domain.ReportStatusClaimed,
+						ClaimedBy: null.StringFrom("user"),
+					}, nil)
+				})
+
+				g.It("Should claim the report for the user", func() {
+					report, err := service.Claim(ctx, 1)
+
+					Expect(err).To(BeNil())
+					Expect(report.Status).To(Equal(domain.ReportStatusClaimed))
+					repo.AssertCalled(t, "Update", mock.Anything, int64(1), mock.MatchedBy(func(args domain.UpdateArgs) bool {
+						return args["Status"] == domain.ReportStatusClaimed && args["ClaimedBy"] == null.StringFrom("user")
+					}))
+				})
+			})
+
+			g.Describe("Report already claimed", func() {
+				g.BeforeEach(func() {
+					repo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Report{
+						ReportID: 1,
+						ServerID: 1,
+						Status:   domain.ReportStatusClaimed,
+					}, nil)
+					authorizer.On("HasPermission", mock.Anything, mock.Anything, "user", mock.Anything).
+						Return(true, nil)
+				})
+
+				g.It("Should return a bad request error", func() {
+					_, err := service.Claim(ctx, 1)
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.Status).To(Equal(http.StatusBadRequest))
+					repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+				})
+			})
+
+			g.Describe("User cannot handle reports", func() {
+				g.BeforeEach(func() {
+					repo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Report{
+						ReportID: 1,
+						ServerID: 1,
+						Status:   domain.ReportStatusOpen,
+					}, nil)
+					authorizer.On("HasPermission", mock.Anything, mock.Anything, "user", mock.Anything).
+						Return(false, nil)
+				})
+
+				g.It("Should return an unauthorized error", func() {
+					_, err := service.Claim(ctx, 1)
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.Status).To(Equal(http.StatusUnauthorized))
+				})
+			})
+		})
+
+		g.Describe("Resolve()", func() {
+			g.Describe("Report already resolved", func() {
+				g.BeforeEach(func() {
+					repo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Report{
+						ReportID: 1,
+						ServerID: 1,
+						Status:   domain.ReportStatusResolved,
+					}, nil)
+				})
+
+				g.It("Should return a bad request error", func() {
+					_, err := service.Resolve(ctx, 1, "")
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.Status).To(Equal(http.StatusBadRequest))
+				})
+			})
+
+			g.Describe("Report resolved", func() {
+				g.BeforeEach(func() {
+					repo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Report{
+						ReportID: 1,
+						ServerID: 1,
+						Status:   domain.ReportStatusClaimed,
+					}, nil)
+					repo.On("Update", mock.Anything, int64(1), mock.Anything).Return(&domain.Report{
+						ReportID: 1,
+						Status:   domain.ReportStatusResolved,
+					}, nil)
+				})
+
+				g.It("Should store the resolution", func() {
+					_, err := service.Resolve(ctx, 1, "Talked to the player")
+
+					Expect(err).To(BeNil())
+					repo.AssertCalled(t, "Update", mock.Anything, int64(1), mock.MatchedBy(func(args domain.UpdateArgs) bool {
+						return args["Status"] == domain.ReportStatusResolved &&
+							args["Resolution"] == null.StringFrom("Talked to the player")
+					}))
+				})
+			})
+		})
+
+		g.Describe("Convert()", func() {
+			var infraction *domain.Infraction
+
+			g.BeforeEach(func() {
+				infraction = &domain.Infraction{
+					Type:   domain.InfractionTypeBan,
+					Reason: null.StringFrom("Spamming"),
+				}
+
+				ctx = context.WithValue(ctx, "user", &domain.AuthUser{
+					Session: &kratos.Session{Identity: kratos.Identity{Id: "user"}},
+				})
+				repo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Report{
+					ReportID: 1,
+					ServerID: 2,
+					Platform: "platform",
+					TargetID: "target",
+					Status:   domain.ReportStatusClaimed,
+				}, nil)
+			})
+
+			g.Describe("Report converted", func() {
+				g.BeforeEach(func() {
+					authorizer.On("HasPermission", mock.Anything, mock.Anything, "user", mock.Anything).
+						Return(true, nil)
+					repo.On("GetLinkedChatMessages", mock.Anything, int64(1)).
+						Return([]*domain.ChatMessage{{MessageID: 10}}, nil)
+					infractionService.On("Store", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+						Return(&domain.Infraction{InfractionID: 7}, nil)
+					repo.On("Update", mock.Anything, int64(1), mock.Anything).Return(&domain.Report{
+						ReportID:     1,
+						Status:       domain.ReportStatusResolved,
+						InfractionID: null.IntFrom(7),
+					}, nil)
+				})
+
+				g.It("Should create the infraction against the reported player", func() {
+					_, err := service.Convert(ctx, 1, infraction)
+
+					Expect(err).To(BeNil())
+					infractionService.AssertCalled(t, "Store", mock.Anything, mock.MatchedBy(func(i *domain.Infraction) bool {
+						return i.PlayerID == "target" && i.Platform == "platform" && i.ServerID == 2 &&
+							i.UserID == null.StringFrom("user")
+					}), mock.Anything, []int64{10})
+				})
+
+				g.It("Should resolve the report with the infraction ID", func() {
+					report, err := service.Convert(ctx, 1, infraction)
+
+					Expect(err).To(BeNil())
+					Expect(report.InfractionID).To(Equal(null.IntFrom(7)))
+					repo.AssertCalled(t, "Update", mock.Anything, int64(1), mock.MatchedBy(func(args domain.UpdateArgs) bool {
+						return args["Status"] == domain.ReportStatusResolved && args["InfractionID"] == null.IntFrom(7)
+					}))
+				})
+			})
+
+			g.Describe("User cannot create the infraction type", func() {
+				g.BeforeEach(func() {
+					authorizer.On("HasPermission", mock.Anything, mock.Anything, "user", mock.Anything).
+						Return(true, nil).Once()
+					authorizer.On("HasPermission", mock.Anything, mock.Anything, "user", mock.Anything).
+						Return(false, nil).Once()
+				})
+
+				g.It("Should return an unauthorized error", func() {
+					_, err := service.Convert(ctx, 1, infraction)
+
+					httpErr, ok := err.(*domain.HTTPError)
+					Expect(ok).To(BeTrue())
+					Expect(httpErr.Status).To(Equal(http.StatusUnauthorized))
+					infractionService.AssertNotCalled(t, "Store", mock.Anything, mock.Anything, mock.Anything,
+						mock.Anything)
+				})
+			})
+
+			g.Describe("Infraction service error", func() {
+				g.BeforeEach(func() {
+					authorizer.On("HasPermission", mock.Anything, mock.Anything, "user", mock.Anything).
+						Return(true, nil)
+					repo.On("GetLinkedChatMessages", mock.Anything, int64(1)).
+						Return([]*domain.ChatMessage{}, nil)
+					infractionService.On("Store", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+						Return(nil, fmt.Errorf("err"))
+				})
+
+				g.It("Should not resolve the report", func() {
+					_, err := service.Convert(ctx, 1, infraction)
+
+					Expect(err).ToNot(BeNil())
+					repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,224 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package http
+
+import (
+	"Refractor/authcheckers"
+	"Refractor/domain"
+	"Refractor/params"
+	"Refractor/pkg/api"
+	"Refractor/pkg/api/middleware"
+	"Refractor/pkg/perms"
+	"Refractor/pkg/structutils"
+	"fmt"
+	"github.com/guregu/null"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+)
+
+type ruleHandler struct {
+	service domain.RuleService
+	logger  *zap.Logger
+}
+
+func ApplyRuleHandler(apiGroup *echo.Group, s domain.RuleService, a domain.Authorizer, mware domain.Middleware,
+	log *zap.Logger) {
+	handler := &ruleHandler{
+		service: s,
+		logger:  log,
+	}
+
+	// Create the rule routing group
+	ruleGroup := apiGroup.Group("/rules", mware.ProtectMiddleware, mware.ActivationMiddleware)
+
+	// Create an enforcer to authorize the user on the various endpoints
+	enforcer := middleware.NewEnforcer(a, domain.AuthScope{
+		Type: domain.AuthObjRefractor,
+	}, log)
+
+	ruleGroup.GET("/", handler.GetRules)
+	ruleGroup.GET("/:id", handler.GetRule)
+	ruleGroup.GET("/:id/suggestion/:platform/:playerId", handler.GetSuggestion,
+		enforcer.CheckAuth(authcheckers.HasPermission(perms.FlagViewPlayerRecords, true)))
+	ruleGroup.POST("/", handler.CreateRule, enforcer.CheckAuth(authcheckers.HasPermission(perms.FlagManageRules, true)))
+	ruleGroup.PATCH("/:id", handler.UpdateRule, enforcer.CheckAuth(authcheckers.HasPermission(perms.FlagManageRules, true)))
+	ruleGroup.DELETE("/:id", handler.DeleteRule, enforcer.CheckAuth(authcheckers.HasPermission(perms.FlagManageRules, true)))
+}
+
+func (h *ruleHandler) GetRules(c echo.Context) error {
+	rules, err := h.service.GetAll(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: rules,
+	})
+}
+
+func (h *ruleHandler) GetRule(c echo.Context) error {
+	ruleIDString := c.Param("id")
+
+	ruleID, err := strconv.ParseInt(ruleIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid rule id"), http.StatusBadRequest, "")
+	}
+
+	rule, err := h.service.GetByID(c.Request().Context(), ruleID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: rule,
+	})
+}
+
+func (h *ruleHandler) GetSuggestion(c echo.Context) error {
+	ruleIDString := c.Param("id")
+
+	ruleID, err := strconv.ParseInt(ruleIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid rule id"), http.StatusBadRequest, "")
+	}
+
+	result, err := h.service.GetSuggestion(c.Request().Context(), ruleID, c.Param("platform"), c.Param("playerId"))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: result,
+	})
+}
+
+func (h *ruleHandler) CreateRule(c echo.Context) error {
+	// Validate request body
+	var body params.CreateRuleParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	rule, err := h.service.Store(c.Request().Context(), &domain.Rule{
+		Title:       body.Title,
+		Description: body.Description,
+		Suggestions: toRuleSuggestions(body.Suggestions),
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, &domain.Response{
+		Success: true,
+		Message: "Rule created",
+		Payload: rule,
+	})
+}
+
+func (h *ruleHandler) UpdateRule(c echo.Context) error {
+	ruleIDString := c.Param("id")
+
+	ruleID, err := strconv.ParseInt(ruleIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid rule id"), http.StatusBadRequest, "")
+	}
+
+	// Validate request body
+	var body params.UpdateRuleParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	// Get update args
+	updateArgs, err := structutils.GetNonNilFieldMap(body)
+	if err != nil {
+		return err
+	}
+
+	if len(updateArgs) < 1 {
+		return c.JSON(http.StatusBadRequest, &domain.Response{
+			Success: false,
+			Message: "No update fields provided",
+		})
+	}
+
+	if body.Suggestions != nil {
+		updateArgs["Suggestions"] = toRuleSuggestions(*body.Suggestions)
+	}
+
+	updated, err := h.service.Update(c.Request().Context(), ruleID, updateArgs)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Rule updated",
+		Payload: updated,
+	})
+}
+
+func (h *ruleHandler) DeleteRule(c echo.Context) error {
+	ruleIDString := c.Param("id")
+
+	ruleID, err := strconv.ParseInt(ruleIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid rule id"), http.StatusBadRequest, "")
+	}
+
+	if err := h.service.Delete(c.Request().Context(), ruleID); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Rule deleted",
+	})
+}
+
+func toRuleSuggestions(body []params.RuleSuggestionParams) []*domain.RuleSuggestion {
+	suggestions := make([]*domain.RuleSuggestion, 0, len(body))
+
+	for _, s := range body {
+		suggestion := &domain.RuleSuggestion{
+			Offense: s.Offense,
+			Type:    s.Type,
+		}
+
+		if s.Duration != nil {
+			suggestion.Duration = null.IntFrom(int64(*s.Duration))
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions
+}
@@ -0,0 +1,218 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"Refractor/pkg/querybuilders/psqlqb"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const opTag = "RuleRepo.Postgres."
+
+type ruleRepo struct {
+	db     *sql.DB
+	logger *zap.Logger
+	qb     domain.QueryBuilder
+}
+
+func NewRuleRepo(db *sql.DB, logger *zap.Logger) domain.RuleRepo {
+	return &ruleRepo{
+		db:     db,
+		logger: logger,
+		qb:     psqlqb.NewPostgresQueryBuilder(),
+	}
+}
+
+func (r *ruleRepo) fetch(ctx context.Context, query string, args ...interface{}) ([]*domain.Rule, error) {
+	const op = opTag + "Fetch"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.Rule, 0)
+	for rows.Next() {
+		rule := &domain.Rule{}
+
+		if err := r.scanRule(rows, rule); err != nil {
+			r.logger.Error("Could not scan rule", zap.Error(err))
+			return nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, rule)
+	}
+
+	return results, nil
+}
+
+func (r *ruleRepo) Store(ctx context.Context, rule *domain.Rule) (*domain.Rule, error) {
+	const op = opTag + "Store"
+
+	suggestions, err := marshalSuggestions(rule.Suggestions)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	query := `INSERT INTO Rules (Title, Description, Suggestions) VALUES ($1, $2, $3) RETURNING *;`
+
+	newRule := &domain.Rule{}
+
+	row := r.db.QueryRowContext(ctx, query, rule.Title, rule.Description, suggestions)
+	if err := r.scanRule(row, newRule); err != nil {
+		r.logger.Error("Could not scan newly created rule", zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return newRule, nil
+}
+
+func (r *ruleRepo) GetAll(ctx context.Context) ([]*domain.Rule, error) {
+	const op = opTag + "GetAll"
+
+	query := "SELECT * FROM Rules ORDER BY RuleID;"
+
+	results, err := r.fetch(ctx, query)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	return results, nil
+}
+
+func (r *ruleRepo) GetByID(ctx context.Context, id int64) (*domain.Rule, error) {
+	const op = opTag + "GetByID"
+
+	query := "SELECT * FROM Rules WHERE RuleID = $1;"
+
+	results, err := r.fetch(ctx, query, id)
+	if err != nil {
+		return nil, errors.Wrap(err, op)
+	}
+
+	if len(results) == 0 {
+		return nil, errors.Wrap(domain.ErrNotFound, op)
+	}
+
+	return results[0], nil
+}
+
+func (r *ruleRepo) Update(ctx context.Context, id int64, args domain.UpdateArgs) (*domain.Rule, error) {
+	const op = opTag + "Update"
+
+	if suggestions, ok := args["Suggestions"].([]*domain.RuleSuggestion); ok {
+		data, err := marshalSuggestions(suggestions)
+		if err != nil {
+			return nil, errors.Wrap(err, op)
+		}
+
+		args["Suggestions"] = data
+	}
+
+	query, values := r.qb.BuildUpdateQuery("Rules", id, "RuleID", args, nil)
+
+	rule := &domain.Rule{}
+
+	row := r.db.QueryRowContext(ctx, query, values...)
+	if err := r.scanRule(row, rule); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.Wrap(domain.ErrNotFound, op)
+		}
+
+		r.logger.Error("Could not scan updated rule", zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return rule, nil
+}
+
+func (r *ruleRepo) Delete(ctx context.Context, id int64) error {
+	const op = opTag + "Delete"
+
+	query := "DELETE FROM Rules WHERE RuleID = $1;"
+
+	res, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Could not get affected rows", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	if rowsAffected < 1 {
+		return errors.Wrap(domain.ErrNotFound, op)
+	}
+
+	return nil
+}
+
+func (r *ruleRepo) GetPlayerOffenseCount(ctx context.Context, id int64, platform, playerID string) (int, error) {
+	const op = opTag + "GetPlayerOffenseCount"
+
+	query := `SELECT COUNT(1) FROM Infractions WHERE RuleID = $1 AND Platform = $2 AND PlayerID = $3 AND Repealed = FALSE
+			AND DeletedAt IS NULL;`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, id, platform, playerID).Scan(&count); err != nil {
+		r.logger.Error("Could not get player offense count", zap.Int64("Rule ID", id), zap.Error(err))
+		return 0, errors.Wrap(err, op)
+	}
+
+	return count, nil
+}
+
+func marshalSuggestions(suggestions []*domain.RuleSuggestion) ([]byte, error) {
+	if suggestions == nil {
+		suggestions = []*domain.RuleSuggestion{}
+	}
+
+	return json.Marshal(suggestions)
+}
+
+// Scan helpers
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (r *ruleRepo) scanRule(row rowScanner, rule *domain.Rule) error {
+	var suggestions []byte
+
+	if err := row.Scan(&rule.RuleID, &rule.Title, &rule.Description, &suggestions, &rule.CreatedAt,
+		&rule.ModifiedAt); err != nil {
+		return err
+	}
+
+	rule.Suggestions = []*domain.RuleSuggestion{}
+	return json.Unmarshal(suggestions, &rule.Suggestions)
+}
@@ -0,0 +1,180 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/franela/goblin"
+	"github.com/guregu/null"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var cols = []string{"RuleID", "Title", "Description", "Suggestions", "CreatedAt", "ModifiedAt"}
+	var ctx = context.TODO()
+
+	g.Describe("Rule Postgres Repo", func() {
+		var repo domain.RuleRepo
+		var mock sqlmock.Sqlmock
+		var db *sql.DB
+
+		g.BeforeEach(func() {
+			var err error
+
+			db, mock, err = sqlmock.New()
+			if err != nil {
+				t.Fatalf("Could not create new sqlmock instance. Error: %v", err)
+			}
+
+			repo = NewRuleRepo(db, zap.NewNop())
+		})
+
+		g.After(func() {
+			_ = db.Close()
+		})
+
+		g.Describe("Store()", func() {
+			g.It("Should store the suggestions as JSON", func() {
+				suggestions := `[{"offense":1,"type":"WARNING","duration":null},{"offense":2,"type":"BAN","duration":1440}]`
+
+				mock.ExpectQuery("INSERT INTO Rules").
+					WithArgs("No cheating", "Cheating is not allowed", []byte(suggestions)).
+					WillReturnRows(sqlmock.NewRows(cols).
+						AddRow(1, "No cheating", "Cheating is not allowed", []byte(suggestions), time.Now(), nil))
+
+				rule, err := repo.Store(ctx, &domain.Rule{
+					Title:       "No cheating",
+					Description: "Cheating is not allowed",
+					Suggestions: []*domain.RuleSuggestion{
+						{Offense: 1, Type: domain.InfractionTypeWarning},
+						{Offense: 2, Type: domain.InfractionTypeBan, Duration: null.IntFrom(1440)},
+					},
+				})
+
+				Expect(err).To(BeNil())
+				Expect(rule.RuleID).To(Equal(int64(1)))
+				Expect(rule.Suggestions).To(HaveLen(2))
+				Expect(rule.Suggestions[1].Duration).To(Equal(null.IntFrom(1440)))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should store an empty array if there are no suggestions", func() {
+				mock.ExpectQuery("INSERT INTO Rules").
+					WithArgs("No spam", "", []byte("[]")).
+					WillReturnRows(sqlmock.NewRows(cols).AddRow(2, "No spam", "", []byte("[]"), time.Now(), nil))
+
+				rule, err := repo.Store(ctx, &domain.Rule{Title: "No spam"})
+
+				Expect(err).To(BeNil())
+				Expect(rule.Suggestions).To(BeEmpty())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("GetByID()", func() {
+			g.It("Should return the scanned rule", func() {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM Rules WHERE RuleID = $1")).
+					WithArgs(int64(1)).
+					WillReturnRows(sqlmock.NewRows(cols).
+						AddRow(1, "No cheating", "", []byte(`[{"offense":1,"type":"BAN","duration":-1}]`), time.Now(), nil))
+
+				rule, err := repo.GetByID(ctx, 1)
+
+				Expect(err).To(BeNil())
+				Expect(rule.Title).To(Equal("No cheating"))
+				Expect(rule.Suggestions[0].Duration).To(Equal(null.IntFrom(-1)))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return domain.ErrNotFound if there is no such rule", func() {
+				mock.ExpectQuery("SELECT \\* FROM Rules").WillReturnRows(sqlmock.NewRows(cols))
+
+				_, err := repo.GetByID(ctx, 1)
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("Update()", func() {
+			g.It("Should store the updated suggestions as JSON", func() {
+				suggestions := `[{"offense":1,"type":"KICK","duration":null}]`
+
+				mock.ExpectQuery("UPDATE Rules SET").
+					WithArgs([]byte(suggestions), int64(1)).
+					WillReturnRows(sqlmock.NewRows(cols).
+						AddRow(1, "No cheating", "", []byte(suggestions), time.Now(), time.Now()))
+
+				rule, err := repo.Update(ctx, 1, domain.UpdateArgs{
+					"Suggestions": []*domain.RuleSuggestion{{Offense: 1, Type: domain.InfractionTypeKick}},
+				})
+
+				Expect(err).To(BeNil())
+				Expect(rule.Suggestions[0].Type).To(Equal(domain.InfractionTypeKick))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return domain.ErrNotFound if there is no such rule", func() {
+				mock.ExpectQuery("UPDATE Rules SET").WillReturnError(sql.ErrNoRows)
+
+				_, err := repo.Update(ctx, 1, domain.UpdateArgs{"Title": "Title"})
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("Delete()", func() {
+			g.It("Should return domain.ErrNotFound if no rows were affected", func() {
+				mock.ExpectExec("DELETE FROM Rules").WithArgs(int64(1)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+				err := repo.Delete(ctx, 1)
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("GetPlayerOffenseCount()", func() {
+			g.It("Should return the number of non-repealed infractions for the rule", func() {
+				mock.ExpectQuery("SELECT COUNT\\(1\\) FROM Infractions WHERE RuleID = \\$1").
+					WithArgs(int64(1), "playfab", "playerid").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+				count, err := repo.GetPlayerOffenseCount(ctx, 1, "playfab", "playerid")
+
+				Expect(err).To(BeNil())
+				Expect(count).To(Equal(2))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+	})
+}
@@ -0,0 +1,130 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/domain"
+	"context"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+type ruleService struct {
+	repo    domain.RuleRepo
+	timeout time.Duration
+	logger  *zap.Logger
+}
+
+func NewRuleService(repo domain.RuleRepo, to time.Duration, log *zap.Logger) domain.RuleService {
+	return &ruleService{
+		repo:    repo,
+		timeout: to,
+		logger:  log,
+	}
+}
+
+func (s *ruleService) Store(c context.Context, rule *domain.Rule) (*domain.Rule, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.repo.Store(ctx, rule)
+}
+
+func (s *ruleService) GetAll(c context.Context) ([]*domain.Rule, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	return s.repo.GetAll(ctx)
+}
+
+func (s *ruleService) GetByID(c context.Context, id int64) (*domain.Rule, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	rule, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return nil, domain.NewHTTPError(err, http.StatusNotFound, "Rule not found")
+		}
+
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+func (s *ruleService) Update(c context.Context, id int64, args domain.UpdateArgs) (*domain.Rule, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	updated, err := s.repo.Update(ctx, id, args)
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return nil, domain.NewHTTPError(err, http.StatusNotFound, "Rule not found")
+		}
+
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// Delete deletes a rule. Infractions which referenced the rule keep their reason but no longer reference a rule.
+func (s *ruleService) Delete(c context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return domain.NewHTTPError(err, http.StatusNotFound, "Rule not found")
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *ruleService) GetSuggestion(c context.Context, id int64, platform, playerID string) (*domain.RuleSuggestionResult, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	rule, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return nil, domain.NewHTTPError(err, http.StatusNotFound, "Rule not found")
+		}
+
+		return nil, err
+	}
+
+	count, err := s.repo.GetPlayerOffenseCount(ctx, id, platform, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	offense := count + 1
+
+	return &domain.RuleSuggestionResult{
+		RuleID:     rule.RuleID,
+		Offense:    offense,
+		Suggestion: rule.GetSuggestion(offense),
+	}, nil
+}
@@ -0,0 +1,121 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/domain"
+	"Refractor/domain/mocks"
+	"context"
+	"github.com/franela/goblin"
+	"github.com/guregu/null"
+	. "github.com/onsi/gomega"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Rule Service", func() {
+		var mockRepo *mocks.RuleRepo
+		var service *ruleService
+		var ctx = context.TODO()
+
+		g.BeforeEach(func() {
+			mockRepo = new(mocks.RuleRepo)
+			service = &ruleService{
+				repo:    mockRepo,
+				timeout: time.Second * 2,
+				logger:  zap.NewNop(),
+			}
+		})
+
+		g.Describe("GetSuggestion()", func() {
+			g.BeforeEach(func() {
+				mockRepo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Rule{
+					RuleID: 1,
+					Title:  "No cheating",
+					Suggestions: []*domain.RuleSuggestion{
+						{Offense: 1, Type: domain.InfractionTypeWarning},
+						{Offense: 2, Type: domain.InfractionTypeBan, Duration: null.IntFrom(1440)},
+						{Offense: 4, Type: domain.InfractionTypeBan, Duration: null.IntFrom(-1)},
+					},
+				}, nil)
+			})
+
+			g.It("Should suggest the first punishment for a first offense", func() {
+				mockRepo.On("GetPlayerOffenseCount", mock.Anything, int64(1), "playfab", "playerid").Return(0, nil)
+
+				result, err := service.GetSuggestion(ctx, 1, "playfab", "playerid")
+
+				Expect(err).To(BeNil())
+				Expect(result.Offense).To(Equal(1))
+				Expect(result.Suggestion.Type).To(Equal(domain.InfractionTypeWarning))
+			})
+
+			g.It("Should use the closest lower suggestion if the offense has none", func() {
+				mockRepo.On("GetPlayerOffenseCount", mock.Anything, int64(1), "playfab", "playerid").Return(2, nil)
+
+				result, err := service.GetSuggestion(ctx, 1, "playfab", "playerid")
+
+				Expect(err).To(BeNil())
+				Expect(result.Offense).To(Equal(3))
+				Expect(result.Suggestion.Duration).To(Equal(null.IntFrom(1440)))
+			})
+
+			g.It("Should use the highest suggestion for repeat offenders", func() {
+				mockRepo.On("GetPlayerOffenseCount", mock.Anything, int64(1), "playfab", "playerid").Return(9, nil)
+
+				result, err := service.GetSuggestion(ctx, 1, "playfab", "playerid")
+
+				Expect(err).To(BeNil())
+				Expect(result.Suggestion.Offense).To(Equal(4))
+			})
+		})
+
+		g.Describe("GetSuggestion() rule not found", func() {
+			g.It("Should return a not found HTTP error", func() {
+				mockRepo.On("GetByID", mock.Anything, int64(2)).Return(nil, domain.ErrNotFound)
+
+				_, err := service.GetSuggestion(ctx, 2, "playfab", "playerid")
+
+				httpErr, ok := err.(*domain.HTTPError)
+				Expect(ok).To(BeTrue())
+				Expect(httpErr.Status).To(Equal(http.StatusNotFound))
+			})
+		})
+
+		g.Describe("Delete()", func() {
+			g.It("Should return a not found HTTP error if the rule does not exist", func() {
+				mockRepo.On("Delete", mock.Anything, int64(1)).Return(domain.ErrNotFound)
+
+				err := service.Delete(ctx, 1)
+
+				httpErr, ok := err.(*domain.HTTPError)
+				Expect(ok).To(BeTrue())
+				Expect(httpErr.Status).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+}
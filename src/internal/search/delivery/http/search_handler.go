@@ -56,6 +56,8 @@ func ApplySearchHandler(apiGroup *echo.Group, s domain.SearchService, a domain.A
 		enforcer.CheckAuth(authcheckers.HasPermission(perms.FlagViewInfractionRecords, true)))
 	searchGroup.POST("/chat", handler.SearchChatMessages,
 		enforcer.CheckAuth(authcheckers.HasPermission(perms.FlagViewChatRecords, true)))
+	searchGroup.POST("/appeals", handler.SearchAppeals,
+		enforcer.CheckAuth(authcheckers.HasPermission(perms.FlagViewAppeals, true)))
 }
 
 type searchRes struct {
@@ -74,8 +76,14 @@ func (h *searchHandler) SearchPlayers(c echo.Context) error {
 		return err
 	}
 
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
 	// Execute search
-	total, results, err := h.service.SearchPlayers(c.Request().Context(), body.Term, body.Type, body.Platform, body.Limit, body.Offset)
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	total, results, err := h.service.SearchPlayers(ctx, body.Term, body.Type, body.Platform, body.Limit, body.Offset)
 	if err != nil {
 		return err
 	}
@@ -178,3 +186,48 @@ func (h *searchHandler) SearchChatMessages(c echo.Context) error {
 		},
 	})
 }
+
+func (h *searchHandler) SearchAppeals(c echo.Context) error {
+	// Validate request body
+	var body params.SearchAppealParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	// Get search args
+	searchArgs, err := structutils.GetNonNilFieldMap(body)
+	if err != nil {
+		return err
+	}
+
+	if len(searchArgs) < 1 {
+		return c.JSON(http.StatusBadRequest, &domain.Response{
+			Success: false,
+			Message: "No search fields provided",
+		})
+	}
+
+	// Execute search
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	total, results, err := h.service.SearchAppeals(ctx, searchArgs, body.Limit, body.Offset)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: &searchRes{
+			Total:   total,
+			Results: results,
+		},
+	})
+}
@@ -23,6 +23,7 @@ import (
 	"Refractor/pkg/perms"
 	"Refractor/pkg/whitelist"
 	"context"
+	"github.com/guregu/null"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"net/http"
@@ -34,24 +35,28 @@ type searchService struct {
 	playerNameRepo domain.PlayerNameRepo
 	infractionRepo domain.InfractionRepo
 	chatRepo       domain.ChatRepo
+	appealRepo     domain.AppealRepo
 	authorizer     domain.Authorizer
 	timeout        time.Duration
 	logger         *zap.Logger
 }
 
 func NewSearchService(pr domain.PlayerRepo, pnr domain.PlayerNameRepo, ir domain.InfractionRepo, cr domain.ChatRepo,
-	a domain.Authorizer, to time.Duration, log *zap.Logger) domain.SearchService {
+	ar domain.AppealRepo, a domain.Authorizer, to time.Duration, log *zap.Logger) domain.SearchService {
 	return &searchService{
 		playerRepo:     pr,
 		playerNameRepo: pnr,
 		infractionRepo: ir,
 		chatRepo:       cr,
+		appealRepo:     ar,
 		authorizer:     a,
 		timeout:        to,
 		logger:         log,
 	}
 }
 
+// SearchPlayers searches players by name, ID or tag. If a user is provided in the context under the key "user", they
+// must have permission to view player notes to search by tag.
 func (s searchService) SearchPlayers(c context.Context, term, searchType, platform string, limit, offset int) (int, []*domain.Player, error) {
 	ctx, cancel := context.WithTimeout(c, s.timeout)
 	defer cancel()
@@ -87,6 +92,34 @@ func (s searchService) SearchPlayers(c context.Context, term, searchType, platfo
 		}
 
 		return 1, []*domain.Player{result}, nil
+	case "tag":
+		// Tags are only visible to users who can view player notes
+		if user, ok := ctx.Value("user").(*domain.AuthUser); ok {
+			hasPermission, err := s.authorizer.HasPermission(ctx, domain.AuthScope{
+				Type: domain.AuthObjRefractor,
+			}, user.Identity.Id, authcheckers.HasPermission(perms.FlagViewPlayerNotes, true))
+			if err != nil {
+				return 0, nil, err
+			}
+
+			if !hasPermission {
+				return 0, nil, domain.NewHTTPError(nil, http.StatusUnauthorized,
+					"You do not have permission to search players by tag.")
+			}
+		}
+
+		totalResults, results, err := s.playerRepo.SearchByTag(ctx, term, platform, limit, offset)
+		if err != nil {
+			s.logger.Error("Could not search player by tag",
+				zap.String("Tag", term),
+				zap.Int("Limit", limit),
+				zap.Int("Offset", offset),
+				zap.Error(err),
+			)
+			return 0, nil, err
+		}
+
+		return totalResults, results, nil
 	}
 
 	return 0, nil, errors.New("unknown search type")
@@ -101,9 +134,13 @@ func (s searchService) SearchInfractions(c context.Context, args domain.FindArgs
 	defer cancel()
 
 	// Filter out illegal values
-	wl := whitelist.StringKeyMap([]string{"Type", "Game", "PlayerID", "Platform", "ServerID", "UserID"})
+	wl := whitelist.StringKeyMap([]string{"Type", "Game", "PlayerID", "Platform", "ServerID", "UserID", "RuleID"})
 	args = wl.FilterKeys(args)
 
+	if ruleID, ok := args["RuleID"].(*int64); ok {
+		args["RuleID"] = null.IntFrom(*ruleID)
+	}
+
 	if len(args) == 0 {
 		return 0, []*domain.Infraction{}, &domain.HTTPError{
 			Success:          false,
@@ -168,7 +205,8 @@ func (s searchService) SearchChatMessages(c context.Context, args domain.FindArg
 	defer cancel()
 
 	// Filter out illegal values
-	wl := whitelist.StringKeyMap([]string{"PlayerID", "Platform", "ServerID", "Game", "StartDate", "EndDate", "Query"})
+	wl := whitelist.StringKeyMap([]string{"PlayerID", "Platform", "ServerID", "Game", "StartDate", "EndDate", "Query",
+		"Channel"})
 	args = wl.FilterKeys(args)
 
 	if len(args) == 0 {
@@ -236,3 +274,50 @@ func (s searchService) SearchChatMessages(c context.Context, args domain.FindArg
 
 	return count, messages, nil
 }
+
+// SearchAppeals searches appeals and returns matching results. If a user is provided in the context under the key
+// "user", only appeals against infractions on servers the user is authorized to view appeals on are searched.
+//
+// If no user is set in context then this is seen as a system request and all servers are searched without any auth checks.
+func (s searchService) SearchAppeals(c context.Context, args domain.FindArgs, limit, offset int) (int, []*domain.Appeal, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	// Filter out illegal values
+	wl := whitelist.StringKeyMap([]string{"Status", "InfractionID", "PlayerID", "Platform", "ServerID"})
+	args = wl.FilterKeys(args)
+
+	if len(args) == 0 {
+		return 0, []*domain.Appeal{}, &domain.HTTPError{
+			Success:          false,
+			Message:          "No search fields were provided",
+			ValidationErrors: nil,
+			Status:           http.StatusBadRequest,
+		}
+	}
+
+	var authorizedServers []int64 = nil
+
+	// If user is set in context, get the slice of the IDs of the servers on which they are authorized to view appeals.
+	if user, ok := ctx.Value("user").(*domain.AuthUser); ok {
+		var err error
+		authorizedServers, err = s.authorizer.GetAuthorizedServers(ctx, user.Identity.Id,
+			authcheckers.HasPermission(perms.FlagViewAppeals, true))
+		if err != nil {
+			if errors.Cause(err) == domain.ErrNotFound {
+				return 0, []*domain.Appeal{}, nil
+			}
+
+			return 0, nil, err
+		}
+	}
+
+	// Execute search
+	count, appeals, err := s.appealRepo.Search(ctx, args, authorizedServers, limit, offset)
+	if err != nil {
+		s.logger.Error("Could not search appeals", zap.Error(err))
+		return 0, []*domain.Appeal{}, err
+	}
+
+	return count, appeals, nil
+}
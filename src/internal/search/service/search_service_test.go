@@ -24,8 +24,10 @@ import (
 	"github.com/franela/goblin"
 	"github.com/guregu/null"
 	. "github.com/onsi/gomega"
+	kratos "github.com/ory/kratos-client-go"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
+	"net/http"
 	"testing"
 	"time"
 )
@@ -42,6 +44,7 @@ func Test(t *testing.T) {
 		var playerNameRepo *mocks.PlayerNameRepo
 		var infractionRepo *mocks.InfractionRepo
 		var chatRepo *mocks.ChatRepo
+		var authorizer *mocks.Authorizer
 		var ctx context.Context
 
 		g.BeforeEach(func() {
@@ -49,11 +52,13 @@ func Test(t *testing.T) {
 			infractionRepo = new(mocks.InfractionRepo)
 			playerNameRepo = new(mocks.PlayerNameRepo)
 			chatRepo = new(mocks.ChatRepo)
+			authorizer = new(mocks.Authorizer)
 			service = &searchService{
 				playerRepo:     playerRepo,
 				playerNameRepo: playerNameRepo,
 				infractionRepo: infractionRepo,
 				chatRepo:       chatRepo,
+				authorizer:     authorizer,
 				timeout:        time.Second * 2,
 				logger:         zap.NewNop(),
 			}
@@ -138,6 +143,60 @@ func Test(t *testing.T) {
 						playerRepo.AssertExpectations(t)
 					})
 				})
+
+				g.Describe("Search type is 'tag'", func() {
+					g.BeforeEach(func() {
+						playerRepo.On("SearchByTag", mock.Anything, "VIP", "playfab", 10, 0).
+							Return(len(results), results, nil)
+					})
+
+					g.It("Should return the tagged players on the platform", func() {
+						totalCount, found, err := service.SearchPlayers(ctx, "VIP", "tag", "playfab", 10, 0)
+
+						Expect(err).To(BeNil())
+						Expect(found).To(Equal(results))
+						Expect(totalCount).To(Equal(len(results)))
+						playerRepo.AssertExpectations(t)
+					})
+
+					g.Describe("User can view player notes", func() {
+						g.BeforeEach(func() {
+							ctx = context.WithValue(ctx, "user", &domain.AuthUser{
+								Session: &kratos.Session{Identity: kratos.Identity{Id: "userid"}},
+							})
+
+							authorizer.On("HasPermission", mock.Anything, domain.AuthScope{Type: domain.AuthObjRefractor},
+								"userid", mock.Anything).Return(true, nil)
+						})
+
+						g.It("Should return the tagged players", func() {
+							_, found, err := service.SearchPlayers(ctx, "VIP", "tag", "playfab", 10, 0)
+
+							Expect(err).To(BeNil())
+							Expect(found).To(Equal(results))
+						})
+					})
+
+					g.Describe("User cannot view player notes", func() {
+						g.BeforeEach(func() {
+							ctx = context.WithValue(ctx, "user", &domain.AuthUser{
+								Session: &kratos.Session{Identity: kratos.Identity{Id: "userid"}},
+							})
+
+							authorizer.On("HasPermission", mock.Anything, domain.AuthScope{Type: domain.AuthObjRefractor},
+								"userid", mock.Anything).Return(false, nil)
+						})
+
+						g.It("Should return an unauthorized error", func() {
+							_, _, err := service.SearchPlayers(ctx, "VIP", "tag", "playfab", 10, 0)
+
+							Expect(err).ToNot(BeNil())
+							Expect(err.(*domain.HTTPError).Status).To(Equal(http.StatusUnauthorized))
+							playerRepo.AssertNotCalled(t, "SearchByTag", mock.Anything, mock.Anything, mock.Anything,
+								mock.Anything, mock.Anything)
+						})
+					})
+				})
 			})
 		})
 
@@ -217,6 +276,16 @@ func Test(t *testing.T) {
 					infractionRepo.AssertExpectations(t)
 				})
 
+				g.It("Should search by rule", func() {
+					ruleID := int64(3)
+
+					_, _, err := service.SearchInfractions(ctx, domain.FindArgs{"RuleID": &ruleID}, 0, 10)
+
+					Expect(err).To(BeNil())
+					infractionRepo.AssertCalled(t, "Search", mock.Anything, domain.FindArgs{"RuleID": null.IntFrom(3)},
+						mock.Anything, mock.Anything, mock.Anything)
+				})
+
 				g.It("Should return the correct results and total count", func() {
 					total, got, err := service.SearchInfractions(ctx, domain.FindArgs{"UserID": "id"}, 0, 10)
 
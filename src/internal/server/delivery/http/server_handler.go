@@ -23,6 +23,7 @@ import (
 	"Refractor/params"
 	"Refractor/pkg/api"
 	"Refractor/pkg/api/middleware"
+	"Refractor/pkg/perms"
 	"Refractor/pkg/structutils"
 	"context"
 	"fmt"
@@ -71,6 +72,70 @@ func ApplyServerHandler(apiGroup *echo.Group, s domain.ServerService, rs domain.
 	serverGroup.PATCH("/:id", handler.UpdateServer, rEnforcer.CheckAuth(authcheckers.RequireAdmin))
 	serverGroup.GET("/:id/permissions", handler.GetScopedPermissions)
 	serverGroup.POST("/:id/refreshplayers", handler.RefreshPlayerList, rEnforcer.CheckAuth(authcheckers.RequireAdmin))
+	serverGroup.GET("/:id/commands", handler.GetCommandHistory,
+		sEnforcer.CheckAuth(authcheckers.HasPermission(perms.FlagViewInfractionRecords, true)))
+}
+
+const (
+	defaultCommandHistoryLimit = 20
+	maxCommandHistoryLimit     = 100
+)
+
+type commandHistoryRes struct {
+	Total    int                     `json:"total"`
+	Commands []*domain.QueuedCommand `json:"commands"`
+}
+
+// GetCommandHistory is the route handler for /api/v1/servers/:id/commands
+// It returns a page of the commands which were queued to run on the server, newest first. The page is controlled by
+// the limit and offset query params.
+func (h *serverHandler) GetCommandHistory(c echo.Context) error {
+	serverIDString := c.Param("id")
+
+	serverID, err := strconv.ParseInt(serverIDString, 10, 64)
+	if err != nil {
+		return domain.NewHTTPError(fmt.Errorf("invalid server id"), http.StatusBadRequest, "")
+	}
+
+	var limit int64 = defaultCommandHistoryLimit
+	if limitString := c.QueryParam("limit"); limitString != "" {
+		limit, err = strconv.ParseInt(limitString, 10, 32)
+		if err != nil || limit < 1 || limit > maxCommandHistoryLimit {
+			return &domain.HTTPError{
+				Success:          false,
+				Message:          "limit input error",
+				ValidationErrors: map[string]string{"limit": fmt.Sprintf("must be between 1 and %d", maxCommandHistoryLimit)},
+				Status:           http.StatusBadRequest,
+			}
+		}
+	}
+
+	var offset int64 = 0
+	if offsetString := c.QueryParam("offset"); offsetString != "" {
+		offset, err = strconv.ParseInt(offsetString, 10, 32)
+		if err != nil || offset < 0 {
+			return &domain.HTTPError{
+				Success:          false,
+				Message:          "offset input error",
+				ValidationErrors: map[string]string{"offset": "must be a positive integer"},
+				Status:           http.StatusBadRequest,
+			}
+		}
+	}
+
+	total, cmds, err := h.service.GetCommandHistory(c.Request().Context(), serverID, int(limit), int(offset))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: fmt.Sprintf("Fetched %d commands", len(cmds)),
+		Payload: &commandHistoryRes{
+			Total:    total,
+			Commands: cmds,
+		},
+	})
 }
 
 func (h *serverHandler) RefreshPlayerList(c echo.Context) error {
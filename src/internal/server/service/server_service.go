@@ -35,6 +35,7 @@ type serverService struct {
 	playerRepo         domain.PlayerRepo
 	playerStatsService domain.PlayerStatsService
 	gameService        domain.GameService
+	commandQueueRepo   domain.CommandQueueRepo
 	authorizer         domain.Authorizer
 	timeout            time.Duration
 	logger             *zap.Logger
@@ -43,12 +44,13 @@ type serverService struct {
 }
 
 func NewServerService(repo domain.ServerRepo, pr domain.PlayerRepo, pss domain.PlayerStatsService,
-	gs domain.GameService, a domain.Authorizer, timeout time.Duration, log *zap.Logger) domain.ServerService {
+	gs domain.GameService, cqr domain.CommandQueueRepo, a domain.Authorizer, timeout time.Duration, log *zap.Logger) domain.ServerService {
 	return &serverService{
 		repo:               repo,
 		playerRepo:         pr,
 		playerStatsService: pss,
 		gameService:        gs,
+		commandQueueRepo:   cqr,
 		authorizer:         a,
 		timeout:            timeout,
 		logger:             log,
@@ -203,6 +205,24 @@ func (s *serverService) Update(c context.Context, id int64, args domain.UpdateAr
 	return updated, nil
 }
 
+func (s *serverService) GetCommandHistory(c context.Context, serverID int64, limit, offset int) (int, []*domain.QueuedCommand, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	// Make sure the server exists so that a bad server ID isn't mistaken for an empty history
+	if _, err := s.repo.GetByID(ctx, serverID); err != nil {
+		return 0, nil, err
+	}
+
+	total, cmds, err := s.commandQueueRepo.GetByServer(ctx, serverID, limit, offset)
+	if err != nil {
+		s.logger.Error("Could not get server command history", zap.Int64("Server ID", serverID), zap.Error(err))
+		return 0, nil, err
+	}
+
+	return total, cmds, nil
+}
+
 func (s *serverService) CreateServerData(id int64, gameName string) error {
 	s.serverData[id] = &domain.ServerData{
 		NeedsUpdate:   true,
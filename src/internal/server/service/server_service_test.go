@@ -23,6 +23,7 @@ import (
 	"context"
 	"github.com/franela/goblin"
 	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 	"testing"
@@ -41,6 +42,7 @@ func Test(t *testing.T) {
 		var playerRepo *mocks.PlayerRepo
 		var playerStatsService *mocks.PlayerStatsService
 		var gameService *mocks.GameService
+		var commandQueueRepo *mocks.CommandQueueRepo
 		var service domain.ServerService
 		var ctx context.Context
 
@@ -50,7 +52,9 @@ func Test(t *testing.T) {
 			playerRepo = new(mocks.PlayerRepo)
 			playerStatsService = new(mocks.PlayerStatsService)
 			gameService = new(mocks.GameService)
-			service = NewServerService(mockRepo, playerRepo, playerStatsService, gameService, authorizer, time.Second*2, zap.NewNop())
+			commandQueueRepo = new(mocks.CommandQueueRepo)
+			service = NewServerService(mockRepo, playerRepo, playerStatsService, gameService, commandQueueRepo, authorizer,
+				time.Second*2, zap.NewNop())
 			ctx = context.TODO()
 		})
 
@@ -99,6 +103,44 @@ func Test(t *testing.T) {
 			})
 		})
 
+		g.Describe("GetCommandHistory()", func() {
+			g.Describe("Server found", func() {
+				var mockCommands []*domain.QueuedCommand
+
+				g.BeforeEach(func() {
+					mockCommands = []*domain.QueuedCommand{
+						{CommandID: 2, ServerID: 1, Command: "cmd2", Status: domain.CommandStatusSucceeded},
+						{CommandID: 1, ServerID: 1, Command: "cmd1", Status: domain.CommandStatusFailed},
+					}
+
+					mockRepo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Server{ID: 1}, nil)
+					commandQueueRepo.On("GetByServer", mock.Anything, int64(1), 10, 0).Return(14, mockCommands, nil)
+				})
+
+				g.It("Should return the total and the page of commands", func() {
+					total, cmds, err := service.GetCommandHistory(ctx, 1, 10, 0)
+
+					Expect(err).To(BeNil())
+					Expect(total).To(Equal(14))
+					Expect(cmds).To(Equal(mockCommands))
+					commandQueueRepo.AssertExpectations(t)
+				})
+			})
+
+			g.Describe("Server not found", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("GetByID", mock.Anything, int64(1)).Return(nil, domain.ErrNotFound)
+				})
+
+				g.It("Should return domain.ErrNotFound", func() {
+					_, _, err := service.GetCommandHistory(ctx, 1, 10, 0)
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+					commandQueueRepo.AssertNotCalled(t, "GetByServer", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+				})
+			})
+		})
+
 		g.Describe("Deactivate()", func() {
 			g.Describe("Target server found", func() {
 				g.BeforeEach(func() {
@@ -19,9 +19,11 @@ package http
 
 import (
 	"Refractor/domain"
+	"fmt"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 	"net/http"
+	"strconv"
 )
 
 type statsHandler struct {
@@ -42,6 +44,7 @@ func ApplyStatsHandler(apiGroup *echo.Group, s domain.StatsService, a domain.Aut
 	statsGroup := apiGroup.Group("/stats", mware.ProtectMiddleware, mware.ActivationMiddleware)
 
 	statsGroup.GET("/", handler.GetStats)
+	statsGroup.GET("/rules", handler.GetRuleStats)
 }
 
 func (h *statsHandler) GetStats(c echo.Context) error {
@@ -55,3 +58,30 @@ func (h *statsHandler) GetStats(c echo.Context) error {
 		Payload: stats,
 	})
 }
+
+// maxRuleStatsDays is the largest number of days rule stats can be requested for. Larger ranges should use 0 (all time).
+const maxRuleStatsDays = 3650
+
+func (h *statsHandler) GetRuleStats(c echo.Context) error {
+	days := 0
+
+	if daysString := c.QueryParam("days"); daysString != "" {
+		var err error
+
+		days, err = strconv.Atoi(daysString)
+		if err != nil || days < 0 || days > maxRuleStatsDays {
+			return domain.NewHTTPError(fmt.Errorf("invalid days"), http.StatusBadRequest,
+				fmt.Sprintf("days must be a number between 0 and %d", maxRuleStatsDays))
+		}
+	}
+
+	stats, err := h.service.GetRuleStats(c.Request().Context(), days)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: stats,
+	})
+}
@@ -71,7 +71,7 @@ func (r *statsRepo) GetTotalPlayers(ctx context.Context) (int, error) {
 func (r *statsRepo) GetTotalInfractions(ctx context.Context) (int, error) {
 	const op = opTag + "GetTotalInfractions"
 
-	query := "SELECT COUNT(1) FROM Infractions;"
+	query := "SELECT COUNT(1) FROM Infractions WHERE DeletedAt IS NULL;"
 
 	count, err := r.fetchCount(ctx, query)
 	if err != nil {
@@ -99,7 +99,8 @@ func (r *statsRepo) GetTotalNewPlayersInRange(ctx context.Context, start, end ti
 func (r *statsRepo) GetTotalNewInfractionsInRange(ctx context.Context, start, end time.Time) (int, error) {
 	const op = opTag + "GetTotalNewPlayersInRange"
 
-	query := "SELECT COUNT(1) FROM Infractions WHERE CreatedAt BETWEEN $1::TIMESTAMP AND $2::TIMESTAMP;"
+	query := `SELECT COUNT(1) FROM Infractions
+			WHERE DeletedAt IS NULL AND CreatedAt BETWEEN $1::TIMESTAMP AND $2::TIMESTAMP;`
 
 	count, err := r.fetchCount(ctx, query, pq.FormatTimestamp(start), pq.FormatTimestamp(end))
 	if err != nil {
@@ -152,3 +153,42 @@ func (r *statsRepo) GetTotalChatMessagesInRange(ctx context.Context, start, end
 
 	return count, nil
 }
+
+func (r *statsRepo) GetRuleStatsInRange(ctx context.Context, start, end time.Time) ([]*domain.RuleStats, error) {
+	const op = opTag + "GetRuleStatsInRange"
+
+	query := `
+		SELECT r.RuleID, r.Title, COUNT(i.InfractionID) AS Infractions
+		FROM Rules r
+		LEFT JOIN Infractions i ON i.RuleID = r.RuleID AND i.Repealed = FALSE AND i.DeletedAt IS NULL AND
+			i.CreatedAt BETWEEN $1::TIMESTAMP AND $2::TIMESTAMP
+		GROUP BY r.RuleID, r.Title
+		ORDER BY Infractions DESC, r.RuleID;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.FormatTimestamp(start), pq.FormatTimestamp(end))
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]*domain.RuleStats, 0)
+	for rows.Next() {
+		stats := &domain.RuleStats{}
+
+		if err := rows.Scan(&stats.RuleID, &stats.Title, &stats.Infractions); err != nil {
+			r.logger.Error("Could not scan rule stats", zap.Error(err))
+			return nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, stats)
+	}
+
+	return results, nil
+}
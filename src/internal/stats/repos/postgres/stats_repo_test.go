@@ -211,5 +211,23 @@ func Test(t *testing.T) {
 				Expect(mock.ExpectationsWereMet()).To(BeNil())
 			})
 		})
+
+		g.Describe("GetRuleStatsInRange()", func() {
+			g.It("Should return the scanned rule stats", func() {
+				mock.ExpectQuery("SELECT r.RuleID, r.Title, COUNT\\(i.InfractionID\\) AS Infractions FROM Rules r").
+					WillReturnRows(sqlmock.NewRows([]string{"RuleID", "Title", "Infractions"}).
+						AddRow(2, "No spamming", 14).
+						AddRow(1, "No cheating", 3))
+
+				stats, err := repo.GetRuleStatsInRange(ctx, time.Now().Add(-time.Hour*24), time.Now())
+
+				Expect(err).To(BeNil())
+				Expect(stats).To(Equal([]*domain.RuleStats{
+					{RuleID: 2, Title: "No spamming", Infractions: 14},
+					{RuleID: 1, Title: "No cheating", Infractions: 3},
+				}))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
 	})
 }
@@ -20,6 +20,7 @@ package service
 import (
 	"Refractor/domain"
 	"context"
+	"fmt"
 	gocache "github.com/patrickmn/go-cache"
 	"time"
 )
@@ -100,3 +101,31 @@ func (s *statsService) GetStats(c context.Context) (*domain.Stats, error) {
 
 	return stats, nil
 }
+
+func (s *statsService) GetRuleStats(c context.Context, days int) ([]*domain.RuleStats, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	cacheKey := fmt.Sprintf("rule-stats-%d", days)
+
+	// Check if stats are cached
+	if s, present := s.cache.Get(cacheKey); present {
+		return s.([]*domain.RuleStats), nil
+	}
+
+	end := time.Now().UTC()
+	start := time.Unix(0, 0).UTC()
+	if days > 0 {
+		start = end.AddDate(0, 0, -days)
+	}
+
+	stats, err := s.repo.GetRuleStatsInRange(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache stats
+	s.cache.SetDefault(cacheKey, stats)
+
+	return stats, nil
+}
@@ -286,6 +286,45 @@ func (r *userRepo) GetLinkedPlayers(ctx context.Context, userID string) ([]*doma
 	return results, nil
 }
 
+func (r *userRepo) GetLinkedUser(ctx context.Context, platform, playerID string) (string, error) {
+	const op = opTag + "GetLinkedUser"
+
+	query := "SELECT UserID FROM UserPlayers WHERE Platform = $1 AND PlayerID = $2;"
+
+	var userID string
+	if err := r.db.QueryRowContext(ctx, query, platform, playerID).Scan(&userID); err != nil {
+		if err == sql.ErrNoRows {
+			return "", errors.Wrap(domain.ErrNotFound, op)
+		}
+
+		r.logger.Error("Could not query UserPlayers table",
+			zap.String("Platform", platform),
+			zap.String("Player ID", playerID),
+			zap.Error(err))
+		return "", errors.Wrap(err, op)
+	}
+
+	return userID, nil
+}
+
+func (r *userRepo) GetIDByUsername(ctx context.Context, username string) (string, error) {
+	const op = opTag + "GetIDByUsername"
+
+	query := "SELECT UserID FROM UserMeta WHERE LOWER(Username) = LOWER($1) ORDER BY Deactivated LIMIT 1;"
+
+	var userID string
+	if err := r.db.QueryRowContext(ctx, query, username).Scan(&userID); err != nil {
+		if err == sql.ErrNoRows {
+			return "", errors.Wrap(domain.ErrNotFound, op)
+		}
+
+		r.logger.Error("Could not query UserMeta table", zap.String("Username", username), zap.Error(err))
+		return "", errors.Wrap(err, op)
+	}
+
+	return userID, nil
+}
+
 // Scan helpers
 func (r *userRepo) scanRow(row *sql.Row, meta *domain.UserMeta) error {
 	return row.Scan(&meta.ID, &meta.InitialUsername, &meta.Username, &meta.Deactivated)
@@ -537,5 +537,71 @@ func Test(t *testing.T) {
 				})
 			})
 		})
+
+		g.Describe("GetLinkedUser()", func() {
+			g.Describe("Linked user found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT UserID FROM UserPlayers")).
+						WithArgs("platform", "playerid").
+						WillReturnRows(sqlmock.NewRows([]string{"UserID"}).AddRow("userid"))
+				})
+
+				g.It("Should return the user ID", func() {
+					userID, err := repo.GetLinkedUser(ctx, "platform", "playerid")
+
+					Expect(err).To(BeNil())
+					Expect(userID).To(Equal("userid"))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Player is not linked", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT UserID FROM UserPlayers")).
+						WithArgs("platform", "playerid").
+						WillReturnRows(sqlmock.NewRows([]string{"UserID"}))
+				})
+
+				g.It("Should return domain.ErrNotFound", func() {
+					_, err := repo.GetLinkedUser(ctx, "platform", "playerid")
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("GetIDByUsername()", func() {
+			g.Describe("User found", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT UserID FROM UserMeta WHERE LOWER(Username) = LOWER($1)")).
+						WithArgs("SomeAdmin").
+						WillReturnRows(sqlmock.NewRows([]string{"UserID"}).AddRow("userid"))
+				})
+
+				g.It("Should return the user ID", func() {
+					userID, err := repo.GetIDByUsername(ctx, "SomeAdmin")
+
+					Expect(err).To(BeNil())
+					Expect(userID).To(Equal("userid"))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("No user has the username", func() {
+				g.BeforeEach(func() {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT UserID FROM UserMeta")).
+						WithArgs("nobody").
+						WillReturnRows(sqlmock.NewRows([]string{"UserID"}))
+				})
+
+				g.It("Should return domain.ErrNotFound", func() {
+					_, err := repo.GetIDByUsername(ctx, "nobody")
+
+					Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
 	})
 }
@@ -0,0 +1,173 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package http
+
+import (
+	"Refractor/authcheckers"
+	"Refractor/domain"
+	"Refractor/params"
+	"Refractor/pkg/api"
+	"Refractor/pkg/api/middleware"
+	"Refractor/pkg/perms"
+	"context"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+)
+
+type watchHandler struct {
+	service domain.WatchService
+	logger  *zap.Logger
+}
+
+func ApplyWatchHandler(apiGroup *echo.Group, s domain.WatchService, a domain.Authorizer, mware domain.Middleware, log *zap.Logger) {
+	handler := &watchHandler{
+		service: s,
+		logger:  log,
+	}
+
+	// Player watches are managed on the player routing group
+	playerGroup := apiGroup.Group("/players", mware.ProtectMiddleware, mware.ActivationMiddleware)
+	watchGroup := apiGroup.Group("/watches", mware.ProtectMiddleware, mware.ActivationMiddleware)
+
+	// Create an enforcer to authorize the user on the various endpoints
+	enforcer := middleware.NewEnforcer(a, domain.AuthScope{
+		Type: domain.AuthObjRefractor,
+	}, log)
+
+	canWatch := enforcer.CheckAuth(authcheckers.HasPermission(perms.FlagWatchPlayers, true))
+
+	playerGroup.GET("/:platform/:id/watch", handler.GetWatch, enforcer.CheckAuth(authcheckers.CanViewPlayerRecords))
+	playerGroup.POST("/:platform/:id/watch", handler.WatchPlayer, canWatch)
+	playerGroup.DELETE("/:platform/:id/watch", handler.UnwatchPlayer, canWatch)
+
+	watchGroup.GET("/subscription", handler.GetSubscription)
+	watchGroup.POST("/subscription", handler.SetSubscription(true))
+	watchGroup.DELETE("/subscription", handler.SetSubscription(false))
+}
+
+func (h *watchHandler) GetWatch(c echo.Context) error {
+	platform, id, err := api.GetPlayerParams(c)
+	if err != nil {
+		return err
+	}
+
+	watch, err := h.service.GetWatch(c.Request().Context(), platform, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: watch,
+	})
+}
+
+func (h *watchHandler) WatchPlayer(c echo.Context) error {
+	platform, id, err := api.GetPlayerParams(c)
+	if err != nil {
+		return err
+	}
+
+	// Validate request body
+	var body params.WatchPlayerParams
+	if err := c.Bind(&body); err != nil {
+		return err
+	}
+
+	if ok, err := api.ValidateRequestBody(body); !ok {
+		return err
+	}
+
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	watch, err := h.service.Watch(ctx, platform, id, strings.TrimSpace(body.Reason), body.Duration)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Player watched",
+		Payload: watch,
+	})
+}
+
+func (h *watchHandler) UnwatchPlayer(c echo.Context) error {
+	platform, id, err := api.GetPlayerParams(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.Unwatch(c.Request().Context(), platform, id); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Message: "Player unwatched",
+	})
+}
+
+func (h *watchHandler) GetSubscription(c echo.Context) error {
+	user, ok := c.Get("user").(*domain.AuthUser)
+	if !ok {
+		return fmt.Errorf("could not cast user to *domain.AuthUser")
+	}
+
+	ctx := context.WithValue(c.Request().Context(), "user", user)
+	subscribed, err := h.service.IsSubscribed(ctx)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, &domain.Response{
+		Success: true,
+		Payload: subscribed,
+	})
+}
+
+func (h *watchHandler) SetSubscription(subscribed bool) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		user, ok := c.Get("user").(*domain.AuthUser)
+		if !ok {
+			return fmt.Errorf("could not cast user to *domain.AuthUser")
+		}
+
+		ctx := context.WithValue(c.Request().Context(), "user", user)
+		if err := h.service.SetAlertSubscription(ctx, subscribed); err != nil {
+			return err
+		}
+
+		message := "Unsubscribed from watched player alerts"
+		if subscribed {
+			message = "Subscribed to watched player alerts"
+		}
+
+		return c.JSON(http.StatusOK, &domain.Response{
+			Success: true,
+			Message: message,
+		})
+	}
+}
@@ -0,0 +1,251 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"context"
+	"database/sql"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"time"
+)
+
+const opTag = "WatchRepo.Postgres."
+
+type watchRepo struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+func NewWatchRepo(db *sql.DB, logger *zap.Logger) domain.WatchRepo {
+	return &watchRepo{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Store creates or replaces the watch of a player and sets the player's Watched flag.
+func (r *watchRepo) Store(ctx context.Context, watch *domain.PlayerWatch) error {
+	const op = opTag + "Store"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.Error("Could not begin transaction", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	query := `INSERT INTO PlayerWatches (PlayerID, Platform, UserID, Reason, ExpiresAt) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (PlayerID, Platform) DO UPDATE SET UserID = $3, Reason = $4, ExpiresAt = $5,
+			CreatedAt = CURRENT_TIMESTAMP
+			RETURNING CreatedAt;`
+
+	row := tx.QueryRowContext(ctx, query, watch.PlayerID, watch.Platform, watch.UserID, watch.Reason, watch.ExpiresAt)
+	if err := row.Scan(&watch.CreatedAt); err != nil {
+		_ = tx.Rollback()
+		r.logger.Error("Could not store player watch", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	if err := r.setWatched(ctx, tx, watch.Platform, watch.PlayerID, true); err != nil {
+		_ = tx.Rollback()
+		return errors.Wrap(err, op)
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Could not commit transaction", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *watchRepo) GetByPlayer(ctx context.Context, platform, playerID string) (*domain.PlayerWatch, error) {
+	const op = opTag + "GetByPlayer"
+
+	query := `SELECT PlayerID, Platform, UserID, Reason, ExpiresAt, CreatedAt FROM PlayerWatches
+			WHERE Platform = $1 AND PlayerID = $2;`
+
+	watch := &domain.PlayerWatch{}
+
+	row := r.db.QueryRowContext(ctx, query, platform, playerID)
+	if err := row.Scan(&watch.PlayerID, &watch.Platform, &watch.UserID, &watch.Reason, &watch.ExpiresAt,
+		&watch.CreatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.Wrap(domain.ErrNotFound, op)
+		}
+
+		r.logger.Error("Could not scan player watch", zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	return watch, nil
+}
+
+// Delete removes the watch of a player and clears the player's Watched flag.
+func (r *watchRepo) Delete(ctx context.Context, platform, playerID string) error {
+	const op = opTag + "Delete"
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.logger.Error("Could not begin transaction", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	query := "DELETE FROM PlayerWatches WHERE Platform = $1 AND PlayerID = $2;"
+
+	res, err := tx.ExecContext(ctx, query, platform, playerID)
+	if err != nil {
+		_ = tx.Rollback()
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		r.logger.Error("Could not get affected rows", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	if rowsAffected < 1 {
+		_ = tx.Rollback()
+		return errors.Wrap(domain.ErrNotFound, op)
+	}
+
+	if err := r.setWatched(ctx, tx, platform, playerID, false); err != nil {
+		_ = tx.Rollback()
+		return errors.Wrap(err, op)
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Could not commit transaction", zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes the expired watches and clears their players' Watched flags in a single statement so that the
+// flag can't be left set for a player without a watch.
+func (r *watchRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	const op = opTag + "DeleteExpired"
+
+	query := `WITH expired AS (
+				DELETE FROM PlayerWatches WHERE ExpiresAt IS NOT NULL AND ExpiresAt <= $1 RETURNING PlayerID, Platform
+			)
+			UPDATE Players p SET Watched = FALSE FROM expired e
+			WHERE p.PlayerID = e.PlayerID AND p.Platform = e.Platform;`
+
+	res, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return 0, errors.Wrap(err, op)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		r.logger.Error("Could not get affected rows", zap.Error(err))
+		return 0, errors.Wrap(err, op)
+	}
+
+	return rowsAffected, nil
+}
+
+func (r *watchRepo) setWatched(ctx context.Context, tx *sql.Tx, platform, playerID string, watched bool) error {
+	query := "UPDATE Players SET Watched = $1 WHERE Platform = $2 AND PlayerID = $3;"
+
+	if _, err := tx.ExecContext(ctx, query, watched, platform, playerID); err != nil {
+		r.logger.Error("Could not update player watched flag", zap.String("query", query), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *watchRepo) Subscribe(ctx context.Context, userID string) error {
+	const op = opTag + "Subscribe"
+
+	query := "INSERT INTO WatchAlertSubscriptions (UserID) VALUES ($1) ON CONFLICT DO NOTHING;"
+
+	if _, err := r.db.ExecContext(ctx, query, userID); err != nil {
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *watchRepo) Unsubscribe(ctx context.Context, userID string) error {
+	const op = opTag + "Unsubscribe"
+
+	query := "DELETE FROM WatchAlertSubscriptions WHERE UserID = $1;"
+
+	if _, err := r.db.ExecContext(ctx, query, userID); err != nil {
+		r.logger.Error("Could not execute query", zap.String("query", query), zap.Error(err))
+		return errors.Wrap(err, op)
+	}
+
+	return nil
+}
+
+func (r *watchRepo) IsSubscribed(ctx context.Context, userID string) (bool, error) {
+	const op = opTag + "IsSubscribed"
+
+	query := "SELECT EXISTS(SELECT 1 FROM WatchAlertSubscriptions WHERE UserID = $1);"
+
+	var subscribed bool
+	if err := r.db.QueryRowContext(ctx, query, userID).Scan(&subscribed); err != nil {
+		r.logger.Error("Could not scan subscription status", zap.Error(err))
+		return false, errors.Wrap(err, op)
+	}
+
+	return subscribed, nil
+}
+
+func (r *watchRepo) GetSubscribers(ctx context.Context) ([]string, error) {
+	const op = opTag + "GetSubscribers"
+
+	query := "SELECT UserID FROM WatchAlertSubscriptions;"
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		r.logger.Error("Could not execute SQL query", zap.String("query", query), zap.Error(err))
+		return nil, errors.Wrap(err, op)
+	}
+
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn("Could not close SQL rows", zap.Error(err))
+		}
+	}()
+
+	results := make([]string, 0)
+	for rows.Next() {
+		var userID string
+
+		if err := rows.Scan(&userID); err != nil {
+			r.logger.Error("Could not scan subscriber user id", zap.Error(err))
+			return nil, errors.Wrap(err, op)
+		}
+
+		results = append(results, userID)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,194 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package postgres
+
+import (
+	"Refractor/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/franela/goblin"
+	"github.com/guregu/null"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	var watchCols = []string{"PlayerID", "Platform", "UserID", "Reason", "ExpiresAt", "CreatedAt"}
+	var ctx = context.TODO()
+
+	g.Describe("Watch Postgres Repo", func() {
+		var repo domain.WatchRepo
+		var mock sqlmock.Sqlmock
+		var db *sql.DB
+
+		g.BeforeEach(func() {
+			var err error
+
+			db, mock, err = sqlmock.New()
+			if err != nil {
+				t.Fatalf("Could not create new sqlmock instance. Error: %v", err)
+			}
+
+			repo = NewWatchRepo(db, zap.NewNop())
+		})
+
+		g.After(func() {
+			_ = db.Close()
+		})
+
+		g.Describe("Store()", func() {
+			var watch *domain.PlayerWatch
+
+			g.BeforeEach(func() {
+				watch = &domain.PlayerWatch{
+					PlayerID: "playerid",
+					Platform: "platform",
+					UserID:   "userid",
+					Reason:   null.StringFrom("reason"),
+				}
+			})
+
+			g.Describe("Success", func() {
+				var createdAt time.Time
+
+				g.BeforeEach(func() {
+					createdAt = time.Now()
+
+					mock.ExpectBegin()
+					mock.ExpectQuery("INSERT INTO PlayerWatches").
+						WithArgs("playerid", "platform", "userid", watch.Reason, watch.ExpiresAt).
+						WillReturnRows(sqlmock.NewRows([]string{"CreatedAt"}).AddRow(createdAt))
+					mock.ExpectExec("UPDATE Players SET Watched").WithArgs(true, "platform", "playerid").
+						WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+				})
+
+				g.It("Should store the watch and mark the player as watched", func() {
+					err := repo.Store(ctx, watch)
+
+					Expect(err).To(BeNil())
+					Expect(watch.CreatedAt).To(Equal(createdAt))
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+
+			g.Describe("Insert error", func() {
+				g.BeforeEach(func() {
+					mock.ExpectBegin()
+					mock.ExpectQuery("INSERT INTO PlayerWatches").WillReturnError(fmt.Errorf("err"))
+					mock.ExpectRollback()
+				})
+
+				g.It("Should roll back and return an error", func() {
+					err := repo.Store(ctx, watch)
+
+					Expect(err).ToNot(BeNil())
+					Expect(mock.ExpectationsWereMet()).To(BeNil())
+				})
+			})
+		})
+
+		g.Describe("GetByPlayer()", func() {
+			g.It("Should return the watch", func() {
+				mock.ExpectQuery("SELECT (.+) FROM PlayerWatches").WithArgs("platform", "playerid").
+					WillReturnRows(sqlmock.NewRows(watchCols).
+						AddRow("playerid", "platform", "userid", "reason", nil, time.Now()))
+
+				watch, err := repo.GetByPlayer(ctx, "platform", "playerid")
+
+				Expect(err).To(BeNil())
+				Expect(watch.Reason.ValueOrZero()).To(Equal("reason"))
+				Expect(watch.ExpiresAt.Valid).To(BeFalse())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return domain.ErrNotFound if the player is not watched", func() {
+				mock.ExpectQuery("SELECT (.+) FROM PlayerWatches").WillReturnRows(sqlmock.NewRows(watchCols))
+
+				_, err := repo.GetByPlayer(ctx, "platform", "playerid")
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+			})
+		})
+
+		g.Describe("Delete()", func() {
+			g.It("Should remove the watch and clear the player's watched flag", func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM PlayerWatches").WithArgs("platform", "playerid").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("UPDATE Players SET Watched").WithArgs(false, "platform", "playerid").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+
+				err := repo.Delete(ctx, "platform", "playerid")
+
+				Expect(err).To(BeNil())
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+
+			g.It("Should return domain.ErrNotFound if the player is not watched", func() {
+				mock.ExpectBegin()
+				mock.ExpectExec("DELETE FROM PlayerWatches").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+
+				err := repo.Delete(ctx, "platform", "playerid")
+
+				Expect(errors.Cause(err)).To(Equal(domain.ErrNotFound))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("DeleteExpired()", func() {
+			g.It("Should remove expired watches and clear their players' watched flags", func() {
+				before := time.Now()
+
+				mock.ExpectExec("DELETE FROM PlayerWatches WHERE ExpiresAt IS NOT NULL AND ExpiresAt <= \\$1 (.+) " +
+					"UPDATE Players p SET Watched = FALSE").WithArgs(before).WillReturnResult(sqlmock.NewResult(0, 2))
+
+				removed, err := repo.DeleteExpired(ctx, before)
+
+				Expect(err).To(BeNil())
+				Expect(removed).To(Equal(int64(2)))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+
+		g.Describe("GetSubscribers()", func() {
+			g.It("Should return the subscribed user IDs", func() {
+				mock.ExpectQuery("SELECT UserID FROM WatchAlertSubscriptions").
+					WillReturnRows(sqlmock.NewRows([]string{"UserID"}).AddRow("user1").AddRow("user2"))
+
+				subscribers, err := repo.GetSubscribers(ctx)
+
+				Expect(err).To(BeNil())
+				Expect(subscribers).To(Equal([]string{"user1", "user2"}))
+				Expect(mock.ExpectationsWereMet()).To(BeNil())
+			})
+		})
+	})
+}
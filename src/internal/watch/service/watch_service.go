@@ -0,0 +1,350 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/authcheckers"
+	"Refractor/domain"
+	"Refractor/pkg/broadcast"
+	"context"
+	"github.com/guregu/null"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// expiryCheckInterval is how often the expiry watcher removes watches which have run out.
+const expiryCheckInterval = time.Minute
+
+type watchService struct {
+	repo        domain.WatchRepo
+	playerRepo  domain.PlayerRepo
+	serverRepo  domain.ServerRepo
+	authRepo    domain.AuthRepo
+	mailService domain.MailService
+	authorizer  domain.Authorizer
+	timeout     time.Duration
+	logger      *zap.Logger
+	alertSubs   []domain.WatchedPlayerSubscriber
+}
+
+func NewWatchService(repo domain.WatchRepo, pr domain.PlayerRepo, sr domain.ServerRepo, ar domain.AuthRepo,
+	ms domain.MailService, a domain.Authorizer, to time.Duration, log *zap.Logger) domain.WatchService {
+	return &watchService{
+		repo:        repo,
+		playerRepo:  pr,
+		serverRepo:  sr,
+		authRepo:    ar,
+		mailService: ms,
+		authorizer:  a,
+		timeout:     to,
+		logger:      log,
+		alertSubs:   []domain.WatchedPlayerSubscriber{},
+	}
+}
+
+// Watch adds a player to the watch list. If duration is greater than 0, the watch expires after duration minutes.
+// Watching a player who is already watched replaces their existing watch.
+func (s *watchService) Watch(c context.Context, platform, playerID, reason string, duration int64) (*domain.PlayerWatch, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	user, ok := ctx.Value("user").(*domain.AuthUser)
+	if !ok {
+		return nil, errors.New("user not set in context")
+	}
+
+	exists, err := s.playerRepo.Exists(ctx, domain.FindArgs{
+		"PlayerID": playerID,
+		"Platform": platform,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, domain.NewHTTPError(domain.ErrNotFound, http.StatusNotFound, "Player not found")
+	}
+
+	watch := &domain.PlayerWatch{
+		PlayerID: playerID,
+		Platform: platform,
+		UserID:   user.Identity.Id,
+		Reason:   null.NewString(reason, reason != ""),
+	}
+
+	if duration > 0 {
+		watch.ExpiresAt = null.TimeFrom(time.Now().Add(time.Duration(duration) * time.Minute))
+	}
+
+	if err := s.repo.Store(ctx, watch); err != nil {
+		return nil, err
+	}
+
+	return watch, nil
+}
+
+func (s *watchService) Unwatch(c context.Context, platform, playerID string) error {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	if err := s.repo.Delete(ctx, platform, playerID); err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return domain.NewHTTPError(err, http.StatusNotFound, "Player is not being watched")
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *watchService) GetWatch(c context.Context, platform, playerID string) (*domain.PlayerWatch, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	watch, err := s.getActiveWatch(ctx, platform, playerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if watch == nil {
+		return nil, domain.NewHTTPError(domain.ErrNotFound, http.StatusNotFound, "Player is not being watched")
+	}
+
+	return watch, nil
+}
+
+// getActiveWatch returns the watch of a player, or nil if they are not watched. Expired watches are removed so that a
+// watch which ran out since the last expiry check is not alerted on.
+func (s *watchService) getActiveWatch(ctx context.Context, platform, playerID string) (*domain.PlayerWatch, error) {
+	watch, err := s.repo.GetByPlayer(ctx, platform, playerID)
+	if err != nil {
+		if errors.Cause(err) == domain.ErrNotFound {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if watch.IsExpired() {
+		if err := s.repo.Delete(ctx, platform, playerID); err != nil && errors.Cause(err) != domain.ErrNotFound {
+			s.logger.Error("Could not remove expired player watch",
+				zap.String("Platform", platform),
+				zap.String("Player ID", playerID),
+				zap.Error(err))
+		}
+
+		return nil, nil
+	}
+
+	return watch, nil
+}
+
+// SetAlertSubscription subscribes or unsubscribes the user set in context from watched player alert emails.
+func (s *watchService) SetAlertSubscription(c context.Context, subscribed bool) error {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	user, ok := ctx.Value("user").(*domain.AuthUser)
+	if !ok {
+		return errors.New("user not set in context")
+	}
+
+	if subscribed {
+		return s.repo.Subscribe(ctx, user.Identity.Id)
+	}
+
+	return s.repo.Unsubscribe(ctx, user.Identity.Id)
+}
+
+func (s *watchService) IsSubscribed(c context.Context) (bool, error) {
+	ctx, cancel := context.WithTimeout(c, s.timeout)
+	defer cancel()
+
+	user, ok := ctx.Value("user").(*domain.AuthUser)
+	if !ok {
+		return false, errors.New("user not set in context")
+	}
+
+	return s.repo.IsSubscribed(ctx, user.Identity.Id)
+}
+
+func (s *watchService) HandlePlayerJoin(fields broadcast.Fields, serverID int64, game domain.Game) {
+	ctx, cancel := context.WithTimeout(context.TODO(), s.timeout)
+	defer cancel()
+
+	platform := game.GetPlatform().GetName()
+	playerID := fields["PlayerID"]
+
+	watch, err := s.getActiveWatch(ctx, platform, playerID)
+	if err != nil {
+		s.logger.Error("Could not get player watch",
+			zap.String("Platform", platform),
+			zap.String("Player ID", playerID),
+			zap.Error(err))
+		return
+	}
+
+	if watch == nil {
+		return
+	}
+
+	alert := &domain.WatchedPlayerAlert{
+		Event:      domain.WatchEventJoin,
+		ServerID:   serverID,
+		PlayerID:   playerID,
+		Platform:   platform,
+		PlayerName: fields["Name"],
+		Watch:      watch,
+	}
+
+	for _, sub := range s.alertSubs {
+		sub(alert)
+	}
+
+	// Sending emails can be slow, so don't hold up other join handlers
+	go s.sendAlertEmails(alert)
+}
+
+func (s *watchService) HandleChatReceive(body *domain.ChatReceiveBody, serverID int64, game domain.Game) {
+	if body.SentByUser {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), s.timeout)
+	defer cancel()
+
+	platform := game.GetPlatform().GetName()
+
+	watch, err := s.getActiveWatch(ctx, platform, body.PlayerID)
+	if err != nil {
+		s.logger.Error("Could not get player watch",
+			zap.String("Platform", platform),
+			zap.String("Player ID", body.PlayerID),
+			zap.Error(err))
+		return
+	}
+
+	if watch == nil {
+		return
+	}
+
+	alert := &domain.WatchedPlayerAlert{
+		Event:      domain.WatchEventChat,
+		ServerID:   serverID,
+		PlayerID:   body.PlayerID,
+		Platform:   platform,
+		PlayerName: body.Name,
+		Message:    body.Message,
+		Watch:      watch,
+	}
+
+	for _, sub := range s.alertSubs {
+		sub(alert)
+	}
+}
+
+// sendAlertEmails emails a watched player alert to each subscribed user who can view the server the alert is for.
+// Each user is emailed separately so that addresses are not shared between recipients.
+func (s *watchService) sendAlertEmails(alert *domain.WatchedPlayerAlert) {
+	ctx, cancel := context.WithTimeout(context.TODO(), s.timeout)
+	defer cancel()
+
+	subscribers, err := s.repo.GetSubscribers(ctx)
+	if err != nil {
+		s.logger.Error("Could not get watched player alert subscribers", zap.Error(err))
+		return
+	}
+
+	if len(subscribers) == 0 {
+		return
+	}
+
+	server, err := s.serverRepo.GetByID(ctx, alert.ServerID)
+	if err != nil {
+		s.logger.Error("Could not get server for watched player alert", zap.Int64("Server ID", alert.ServerID),
+			zap.Error(err))
+		return
+	}
+
+	for _, userID := range subscribers {
+		canView, err := s.authorizer.HasPermission(ctx, domain.AuthScope{
+			Type: domain.AuthObjServer,
+			ID:   alert.ServerID,
+		}, userID, authcheckers.CanViewServer)
+		if err != nil {
+			s.logger.Error("Could not check if subscriber can view server", zap.String("User ID", userID),
+				zap.Error(err))
+			continue
+		}
+
+		if !canView {
+			continue
+		}
+
+		user, err := s.authRepo.GetUserByID(ctx, userID)
+		if err != nil {
+			s.logger.Error("Could not get subscriber", zap.String("User ID", userID), zap.Error(err))
+			continue
+		}
+
+		if err := s.mailService.SendWatchedPlayerEmail([]string{user.Traits.Email}, alert, server.Name); err != nil {
+			s.logger.Error("Could not send watched player alert email", zap.String("User ID", userID),
+				zap.Error(err))
+		}
+	}
+}
+
+func (s *watchService) SubscribeWatchedPlayerAlert(sub domain.WatchedPlayerSubscriber) {
+	s.alertSubs = append(s.alertSubs, sub)
+}
+
+// StartExpiryWatcher periodically removes watches which have run out, so that players are no longer marked as watched
+// once their watch expires even if they never join a server again.
+func (s *watchService) StartExpiryWatcher(terminate chan uint8) {
+	ticker := time.NewTicker(expiryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-terminate:
+			s.logger.Info("Terminating player watch expiry watcher routine")
+			return
+		case <-ticker.C:
+		}
+
+		s.removeExpired()
+	}
+}
+
+func (s *watchService) removeExpired() {
+	ctx, cancel := context.WithTimeout(context.TODO(), s.timeout)
+	defer cancel()
+
+	removed, err := s.repo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		s.logger.Error("Could not remove expired player watches", zap.Error(err))
+		return
+	}
+
+	if removed > 0 {
+		s.logger.Info("Removed expired player watches", zap.Int64("Count", removed))
+	}
+}
@@ -0,0 +1,260 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package service
+
+import (
+	"Refractor/domain"
+	"Refractor/domain/mocks"
+	"Refractor/pkg/broadcast"
+	"Refractor/platforms/playfab"
+	"context"
+	"github.com/franela/goblin"
+	"github.com/guregu/null"
+	. "github.com/onsi/gomega"
+	kratos "github.com/ory/kratos-client-go"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Watch Service", func() {
+		var mockRepo *mocks.WatchRepo
+		var playerRepo *mocks.PlayerRepo
+		var serverRepo *mocks.ServerRepo
+		var authRepo *mocks.AuthRepo
+		var mailService *mocks.MailService
+		var authorizer *mocks.Authorizer
+		var service *watchService
+		var ctx context.Context
+
+		g.BeforeEach(func() {
+			mockRepo = new(mocks.WatchRepo)
+			playerRepo = new(mocks.PlayerRepo)
+			serverRepo = new(mocks.ServerRepo)
+			authRepo = new(mocks.AuthRepo)
+			mailService = new(mocks.MailService)
+			authorizer = new(mocks.Authorizer)
+			service = &watchService{
+				repo:        mockRepo,
+				playerRepo:  playerRepo,
+				serverRepo:  serverRepo,
+				authRepo:    authRepo,
+				mailService: mailService,
+				authorizer:  authorizer,
+				timeout:     time.Second * 2,
+				logger:      zap.NewNop(),
+			}
+			ctx = context.WithValue(context.TODO(), "user", &domain.AuthUser{
+				Session: &kratos.Session{Identity: kratos.Identity{Id: "userid"}},
+			})
+		})
+
+		g.Describe("Watch()", func() {
+			g.Describe("Player exists", func() {
+				g.BeforeEach(func() {
+					playerRepo.On("Exists", mock.Anything, domain.FindArgs{
+						"PlayerID": "playerid",
+						"Platform": "platform",
+					}).Return(true, nil)
+					mockRepo.On("Store", mock.Anything, mock.Anything).Return(nil)
+				})
+
+				g.It("Should store a watch which expires after the duration", func() {
+					watch, err := service.Watch(ctx, "platform", "playerid", "Suspected cheater", 60)
+
+					Expect(err).To(BeNil())
+					Expect(watch.UserID).To(Equal("userid"))
+					Expect(watch.Reason.ValueOrZero()).To(Equal("Suspected cheater"))
+					Expect(watch.ExpiresAt.ValueOrZero()).To(BeTemporally("~", time.Now().Add(time.Hour), time.Second))
+					mockRepo.AssertExpectations(t)
+				})
+
+				g.It("Should store a watch without an expiry if no duration was provided", func() {
+					watch, err := service.Watch(ctx, "platform", "playerid", "", 0)
+
+					Expect(err).To(BeNil())
+					Expect(watch.ExpiresAt.Valid).To(BeFalse())
+					Expect(watch.Reason.Valid).To(BeFalse())
+				})
+			})
+
+			g.Describe("Player does not exist", func() {
+				g.BeforeEach(func() {
+					playerRepo.On("Exists", mock.Anything, mock.Anything).Return(false, nil)
+				})
+
+				g.It("Should return a not found error", func() {
+					_, err := service.Watch(ctx, "platform", "playerid", "", 0)
+
+					Expect(err).ToNot(BeNil())
+					Expect(err.(*domain.HTTPError).Status).To(Equal(404))
+					mockRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+				})
+			})
+		})
+
+		g.Describe("HandlePlayerJoin()", func() {
+			var game *mocks.Game
+			var alerts []*domain.WatchedPlayerAlert
+			var fields broadcast.Fields
+
+			g.BeforeEach(func() {
+				game = new(mocks.Game)
+				game.On("GetPlatform").Return(playfab.NewPlayfabPlatform())
+
+				alerts = []*domain.WatchedPlayerAlert{}
+				service.SubscribeWatchedPlayerAlert(func(alert *domain.WatchedPlayerAlert) {
+					alerts = append(alerts, alert)
+				})
+
+				fields = broadcast.Fields{"PlayerID": "playerid", "Name": "Player"}
+
+				// Email alerts are sent in the background. No users are subscribed in these tests.
+				mockRepo.On("GetSubscribers", mock.Anything).Return([]string{}, nil)
+			})
+
+			g.Describe("Player is watched", func() {
+				var watch *domain.PlayerWatch
+
+				g.BeforeEach(func() {
+					watch = &domain.PlayerWatch{PlayerID: "playerid", Platform: "playfab"}
+					mockRepo.On("GetByPlayer", mock.Anything, "playfab", "playerid").Return(watch, nil)
+				})
+
+				g.It("Should notify alert subscribers", func() {
+					service.HandlePlayerJoin(fields, 1, game)
+
+					Expect(alerts).To(Equal([]*domain.WatchedPlayerAlert{{
+						Event:      domain.WatchEventJoin,
+						ServerID:   1,
+						PlayerID:   "playerid",
+						Platform:   "playfab",
+						PlayerName: "Player",
+						Watch:      watch,
+					}}))
+				})
+			})
+
+			g.Describe("Player watch has expired", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("GetByPlayer", mock.Anything, "playfab", "playerid").Return(&domain.PlayerWatch{
+						PlayerID:  "playerid",
+						Platform:  "playfab",
+						ExpiresAt: null.TimeFrom(time.Now().Add(-time.Minute)),
+					}, nil)
+					mockRepo.On("Delete", mock.Anything, "playfab", "playerid").Return(nil)
+				})
+
+				g.It("Should remove the watch and not send an alert", func() {
+					service.HandlePlayerJoin(fields, 1, game)
+
+					mockRepo.AssertCalled(t, "Delete", mock.Anything, "playfab", "playerid")
+					Expect(alerts).To(BeEmpty())
+				})
+			})
+
+			g.Describe("Player is not watched", func() {
+				g.BeforeEach(func() {
+					mockRepo.On("GetByPlayer", mock.Anything, "playfab", "playerid").
+						Return(nil, errors.Wrap(domain.ErrNotFound, ""))
+				})
+
+				g.It("Should not send an alert", func() {
+					service.HandlePlayerJoin(fields, 1, game)
+
+					Expect(alerts).To(BeEmpty())
+				})
+			})
+		})
+
+		g.Describe("HandleChatReceive()", func() {
+			var game *mocks.Game
+			var alerts []*domain.WatchedPlayerAlert
+
+			g.BeforeEach(func() {
+				game = new(mocks.Game)
+				game.On("GetPlatform").Return(playfab.NewPlayfabPlatform())
+
+				alerts = []*domain.WatchedPlayerAlert{}
+				service.SubscribeWatchedPlayerAlert(func(alert *domain.WatchedPlayerAlert) {
+					alerts = append(alerts, alert)
+				})
+
+				mockRepo.On("GetByPlayer", mock.Anything, "playfab", "playerid").
+					Return(&domain.PlayerWatch{PlayerID: "playerid", Platform: "playfab"}, nil)
+			})
+
+			g.It("Should send a chat alert including the message", func() {
+				service.HandleChatReceive(&domain.ChatReceiveBody{
+					PlayerID: "playerid",
+					Name:     "Player",
+					Message:  "hello",
+				}, 1, game)
+
+				Expect(alerts).To(HaveLen(1))
+				Expect(alerts[0].Event).To(Equal(domain.WatchEventChat))
+				Expect(alerts[0].Message).To(Equal("hello"))
+			})
+		})
+
+		g.Describe("removeExpired()", func() {
+			g.It("Should remove the watches which expired before now", func() {
+				mockRepo.On("DeleteExpired", mock.Anything, mock.AnythingOfType("time.Time")).Return(int64(2), nil)
+
+				before := time.Now()
+				service.removeExpired()
+
+				mockRepo.AssertCalled(t, "DeleteExpired", mock.Anything, mock.MatchedBy(func(at time.Time) bool {
+					return !at.Before(before) && !at.After(time.Now())
+				}))
+			})
+		})
+
+		g.Describe("sendAlertEmails()", func() {
+			var alert *domain.WatchedPlayerAlert
+
+			g.BeforeEach(func() {
+				alert = &domain.WatchedPlayerAlert{ServerID: 1, PlayerID: "playerid", Platform: "playfab"}
+
+				mockRepo.On("GetSubscribers", mock.Anything).Return([]string{"user1", "user2"}, nil)
+				serverRepo.On("GetByID", mock.Anything, int64(1)).Return(&domain.Server{ID: 1, Name: "Server"}, nil)
+				authorizer.On("HasPermission", mock.Anything, mock.Anything, "user1", mock.Anything).Return(true, nil)
+				authorizer.On("HasPermission", mock.Anything, mock.Anything, "user2", mock.Anything).Return(false, nil)
+				authRepo.On("GetUserByID", mock.Anything, "user1").Return(&domain.AuthUser{
+					Traits: &domain.Traits{Email: "user1@example.com"},
+				}, nil)
+				mailService.On("SendWatchedPlayerEmail", []string{"user1@example.com"}, alert, "Server").Return(nil)
+			})
+
+			g.It("Should only email subscribers who can view the server", func() {
+				service.sendAlertEmails(alert)
+
+				mailService.AssertNumberOfCalls(t, "SendWatchedPlayerEmail", 1)
+				authRepo.AssertNotCalled(t, "GetUserByID", mock.Anything, "user2")
+			})
+		})
+	})
+}
@@ -21,6 +21,7 @@ import (
 	"Refractor/authcheckers"
 	"Refractor/domain"
 	"Refractor/pkg/broadcast"
+	"Refractor/pkg/perms"
 	"Refractor/pkg/websocket"
 	"context"
 	"go.uber.org/zap"
@@ -38,6 +39,7 @@ type websocketService struct {
 	timeout            time.Duration
 	logger             *zap.Logger
 	chatSendSubs       []domain.ChatSendSubscriber
+	consoleCommandSubs []domain.ConsoleCommandSubscriber
 }
 
 func NewWebsocketService(pr domain.PlayerRepo, umr domain.UserMetaRepo, pss domain.PlayerStatsService, gs domain.GameService,
@@ -52,11 +54,12 @@ func NewWebsocketService(pr domain.PlayerRepo, umr domain.UserMetaRepo, pss doma
 		timeout:            to,
 		logger:             log,
 		chatSendSubs:       []domain.ChatSendSubscriber{},
+		consoleCommandSubs: []domain.ConsoleCommandSubscriber{},
 	}
 }
 
 func (s *websocketService) CreateClient(userID string, conn net.Conn) {
-	client := websocket.NewClient(userID, conn, s.pool, s.sendChatHandler, s.logger)
+	client := websocket.NewClient(userID, conn, s.pool, s.sendChatHandler, s.consoleCommandHandler, s.logger)
 
 	s.pool.Register <- client
 	client.Read()
@@ -85,6 +88,12 @@ func (s *websocketService) sendChatHandler(body *websocket.SendChatBody) {
 	}
 }
 
+func (s *websocketService) consoleCommandHandler(body *domain.ConsoleCommandBody) {
+	for _, sub := range s.consoleCommandSubs {
+		sub(body)
+	}
+}
+
 func (s *websocketService) StartPool() {
 	s.pool.Start()
 }
@@ -278,6 +287,105 @@ func (s *websocketService) HandleInfractionCreate(infraction *domain.Infraction)
 	})
 }
 
+func (s *websocketService) HandleInfractionExpire(infraction *domain.Infraction) {
+	s.Broadcast(&domain.WebsocketMessage{
+		Type: "infraction-expire",
+		Body: &infractionBody{
+			InfractionID: infraction.InfractionID,
+			ServerID:     infraction.ServerID,
+			Platform:     infraction.Platform,
+			PlayerID:     infraction.PlayerID,
+			Type:         infraction.Type,
+			Reason:       infraction.Reason.ValueOrZero(),
+			Duration:     infraction.Duration.ValueOrZero(),
+		},
+	})
+}
+
+type appealBody struct {
+	AppealID     int64  `json:"id"`
+	InfractionID int64  `json:"infraction_id"`
+	ServerID     int64  `json:"server_id"`
+	Platform     string `json:"platform"`
+	PlayerID     string `json:"player_id"`
+	Status       string `json:"status"`
+}
+
+func (s *websocketService) HandleAppealUpdate(appeal *domain.Appeal) {
+	if err := s.BroadcastServerMessage(&domain.WebsocketMessage{
+		Type: "appeal-update",
+		Body: &appealBody{
+			AppealID:     appeal.AppealID,
+			InfractionID: appeal.InfractionID,
+			ServerID:     appeal.ServerID,
+			Platform:     appeal.Platform,
+			PlayerID:     appeal.PlayerID,
+			Status:       appeal.Status,
+		},
+	}, appeal.ServerID, authcheckers.HasPermission(perms.FlagViewAppeals, true)); err != nil {
+		s.logger.Warn("Could not broadcast appeal update message", zap.Error(err))
+		return
+	}
+}
+
+// HandleWatchedPlayerAlert sends a watched-player-join message to users who can view the server the alert is for. The
+// same message type is used for join and chat alerts. The event field of the body tells them apart.
+func (s *websocketService) HandleWatchedPlayerAlert(alert *domain.WatchedPlayerAlert) {
+	if err := s.BroadcastServerMessage(&domain.WebsocketMessage{
+		Type: "watched-player-join",
+		Body: alert,
+	}, alert.ServerID, authcheckers.CanViewServer); err != nil {
+		s.logger.Warn("Could not broadcast watched player alert message", zap.Error(err))
+		return
+	}
+}
+
+// HandleFederatedBanAlert sends a federated-ban-join message to users who can view the server the alert is for.
+func (s *websocketService) HandleFederatedBanAlert(alert *domain.FederatedBanAlert) {
+	if err := s.BroadcastServerMessage(&domain.WebsocketMessage{
+		Type: "federated-ban-join",
+		Body: alert,
+	}, alert.ServerID, authcheckers.CanViewServer); err != nil {
+		s.logger.Warn("Could not broadcast federated ban alert message", zap.Error(err))
+		return
+	}
+}
+
+type reportBody struct {
+	ReportID     int64  `json:"id"`
+	ServerID     int64  `json:"server_id"`
+	Platform     string `json:"platform"`
+	ReporterID   string `json:"reporter_id"`
+	ReporterName string `json:"reporter_name"`
+	TargetID     string `json:"target_id"`
+	TargetName   string `json:"target_name"`
+	Reason       string `json:"reason"`
+}
+
+// HandlePlayerReport sends a player-report message to users who can handle reports on the server the report is for.
+func (s *websocketService) HandlePlayerReport(report *domain.Report) {
+	if err := s.BroadcastServerMessage(&domain.WebsocketMessage{
+		Type: "player-report",
+		Body: &reportBody{
+			ReportID:     report.ReportID,
+			ServerID:     report.ServerID,
+			Platform:     report.Platform,
+			ReporterID:   report.ReporterID,
+			ReporterName: report.ReporterName,
+			TargetID:     report.TargetID,
+			TargetName:   report.TargetName,
+			Reason:       report.Reason,
+		},
+	}, report.ServerID, authcheckers.HasPermission(perms.FlagHandleReports, true)); err != nil {
+		s.logger.Warn("Could not broadcast player report message", zap.Error(err))
+		return
+	}
+}
+
 func (s *websocketService) SubscribeChatSend(sub domain.ChatSendSubscriber) {
 	s.chatSendSubs = append(s.chatSendSubs, sub)
 }
+
+func (s *websocketService) SubscribeConsoleCommand(sub domain.ConsoleCommandSubscriber) {
+	s.consoleCommandSubs = append(s.consoleCommandSubs, sub)
+}
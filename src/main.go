@@ -22,8 +22,15 @@ import (
 	"Refractor/domain"
 	"Refractor/games/minecraft"
 	"Refractor/games/mordhau"
+	_altHandler "Refractor/internal/alt/delivery/http"
+	_altRepo "Refractor/internal/alt/repos/postgres"
+	_altService "Refractor/internal/alt/service"
+	_appealHandler "Refractor/internal/appeal/delivery/http"
+	_appealRepo "Refractor/internal/appeal/repos/postgres"
+	_appealService "Refractor/internal/appeal/service"
 	_attachmentRepo "Refractor/internal/attachment/repos/postgres"
 	_attachmentService "Refractor/internal/attachment/service"
+	_attachmentStore "Refractor/internal/attachment/stores/local"
 	_authRepo "Refractor/internal/auth/repos/kratos"
 	_authService "Refractor/internal/auth/service"
 	_authorizer "Refractor/internal/authorizer"
@@ -31,6 +38,13 @@ import (
 	_chatRepo "Refractor/internal/chat/repos/postgres"
 	_chatService "Refractor/internal/chat/service"
 	"Refractor/internal/command_executor"
+	_commandQueueRepo "Refractor/internal/command_executor/repos/postgres"
+	_consoleHandler "Refractor/internal/console/delivery/http"
+	_consoleRuleRepo "Refractor/internal/console/repos/postgres"
+	_consoleService "Refractor/internal/console/service"
+	_federationHandler "Refractor/internal/federation/delivery/http"
+	_federationRepo "Refractor/internal/federation/repos/postgres"
+	_federationService "Refractor/internal/federation/service"
 	_flaggedWordRepo "Refractor/internal/flaggedword/repos/postgres"
 	_flaggedWordService "Refractor/internal/flaggedword/service"
 	_gameHandler "Refractor/internal/game/delivery/http"
@@ -42,13 +56,25 @@ import (
 	_infractionHandler "Refractor/internal/infraction/delivery/http"
 	_infractionRepo "Refractor/internal/infraction/repos/postgres"
 	_infractionService "Refractor/internal/infraction/service"
+	_infractionTypeHandler "Refractor/internal/infraction_type/delivery/http"
+	_infractionTypeRepo "Refractor/internal/infraction_type/repos/postgres"
+	_infractionTypeService "Refractor/internal/infraction_type/service"
 	"Refractor/internal/mail/service"
 	_playerHandler "Refractor/internal/player/delivery/http"
 	_playerRepo "Refractor/internal/player/repos/postgres/player"
 	_playerNameRepo "Refractor/internal/player/repos/postgres/playername"
+	_playerNoteRepo "Refractor/internal/player/repos/postgres/playernote"
+	_playerSessionRepo "Refractor/internal/player/repos/postgres/playersession"
+	_playerTagRepo "Refractor/internal/player/repos/postgres/playertag"
 	_playerService "Refractor/internal/player/service"
 	_playerStatsService "Refractor/internal/player_stats/service"
 	_rconService "Refractor/internal/rcon/service"
+	_reportHandler "Refractor/internal/report/delivery/http"
+	_reportRepo "Refractor/internal/report/repos/postgres"
+	_reportService "Refractor/internal/report/service"
+	_ruleHandler "Refractor/internal/rule/delivery/http"
+	_ruleRepo "Refractor/internal/rule/repos/postgres"
+	_ruleService "Refractor/internal/rule/service"
 	_searchHandler "Refractor/internal/search/delivery/http"
 	_searchService "Refractor/internal/search/service"
 	_serverHandler "Refractor/internal/server/delivery/http"
@@ -60,6 +86,9 @@ import (
 	_userHandler "Refractor/internal/user/delivery/http"
 	_userRepo "Refractor/internal/user/repos/postgres"
 	_userService "Refractor/internal/user/service"
+	_watchHandler "Refractor/internal/watch/delivery/http"
+	_watchRepo "Refractor/internal/watch/repos/postgres"
+	_watchService "Refractor/internal/watch/service"
 	"Refractor/internal/watchdog"
 	_websocketHandler "Refractor/internal/websocket/delivery/http"
 	_websocketService "Refractor/internal/websocket/service"
@@ -181,21 +210,49 @@ func main() {
 
 	playerNameRepo := _playerNameRepo.NewPlayerNameRepo(db, logger)
 	playerRepo := _playerRepo.NewPlayerRepo(db, playerNameRepo, logger)
+	playerSessionRepo := _playerSessionRepo.NewPlayerSessionRepo(db, logger)
+	playerNoteRepo := _playerNoteRepo.NewPlayerNoteRepo(db, logger)
+	playerTagRepo := _playerTagRepo.NewPlayerTagRepo(db, logger)
+
+	altRepo := _altRepo.NewAltRepo(db, logger)
+	altService := _altService.NewAltService(altRepo, playerRepo, playerNameRepo, time.Second*2, logger)
+	_altHandler.ApplyAltHandler(apiGroup, altService, authorizer, middlewareBundle, logger)
+
+	infractionTypeRepo := _infractionTypeRepo.NewInfractionTypeRepo(db, logger)
+	infractionTypeService := _infractionTypeService.NewInfractionTypeService(infractionTypeRepo, time.Second*2, logger)
+	if err := infractionTypeService.RegisterPermissions(context.TODO()); err != nil {
+		log.Fatalf("Could not register custom infraction type permissions. Error: %v", err)
+	}
+	_infractionTypeHandler.ApplyInfractionTypeHandler(apiGroup, infractionTypeService, authorizer, middlewareBundle, logger)
 
 	infractionRepo := _infractionRepo.NewInfractionRepo(db, logger)
-	playerStatsService := _playerStatsService.NewPlayerStatsService(playerRepo, infractionRepo, gameService, time.Second*2, logger)
+	federationRepo := _federationRepo.NewFederationRepo(db, logger, config)
+	playerStatsService := _playerStatsService.NewPlayerStatsService(playerRepo, infractionRepo, federationRepo, altService,
+		gameService, time.Second*2, logger)
 
-	serverService := _serverService.NewServerService(serverRepo, playerRepo, playerStatsService, gameService, authorizer, time.Second*2, logger)
+	commandQueueRepo := _commandQueueRepo.NewCommandQueueRepo(db, logger)
+	serverService := _serverService.NewServerService(serverRepo, playerRepo, playerStatsService, gameService, commandQueueRepo,
+		authorizer, time.Second*2, logger)
 
 	userService := _userService.NewUserService(userMetaRepo, authRepo, groupRepo, playerRepo, playerNameRepo,
 		authorizer, time.Second*2, logger)
 	_userHandler.ApplyUserHandler(apiGroup, userService, authService, authorizer, middlewareBundle, logger)
 
 	attachmentRepo := _attachmentRepo.NewAttachmentRepo(db, logger)
-	attachmentService := _attachmentService.NewAttachmentService(attachmentRepo, infractionRepo, authorizer, time.Second*2, logger)
+	attachmentStore, err := _attachmentStore.NewLocalAttachmentStore(config.AttachmentStorage, logger)
+	if err != nil {
+		log.Fatalf("Could not set up attachment store. Error: %v", err)
+	}
+	attachmentService := _attachmentService.NewAttachmentService(attachmentRepo, infractionRepo, attachmentStore, authorizer,
+		time.Second*2, logger)
+
+	ruleRepo := _ruleRepo.NewRuleRepo(db, logger)
+	ruleService := _ruleService.NewRuleService(ruleRepo, time.Second*2, logger)
+	_ruleHandler.ApplyRuleHandler(apiGroup, ruleService, authorizer, middlewareBundle, logger)
 
 	rconService := _rconService.NewRCONService(logger, gameService, serverRepo)
-	commandExecutor := command_executor.NewCommandExecutor(rconService, gameService, serverRepo, userMetaRepo, playerNameRepo, logger)
+	commandExecutor := command_executor.NewCommandExecutor(rconService, gameService, serverRepo, userMetaRepo, playerNameRepo,
+		commandQueueRepo, ruleRepo, logger)
 
 	_serverHandler.ApplyServerHandler(apiGroup, serverService, rconService, gameService, authorizer, middlewareBundle, logger)
 
@@ -207,22 +264,52 @@ func main() {
 	groupService := _groupService.NewGroupService(groupRepo, websocketService, authorizer, time.Second*2, logger)
 	_groupHandler.ApplyGroupHandler(apiGroup, groupService, authorizer, middlewareBundle, logger)
 
+	consoleRuleRepo := _consoleRuleRepo.NewConsoleRuleRepo(db, logger)
+	consoleService := _consoleService.NewConsoleService(rconService, serverRepo, groupRepo, consoleRuleRepo, commandQueueRepo,
+		websocketService, authorizer, time.Second*5, logger)
+	_consoleHandler.ApplyConsoleHandler(apiGroup, consoleService, authorizer, middlewareBundle, logger)
+
 	infractionService := _infractionService.NewInfractionService(infractionRepo, playerRepo, playerNameRepo, serverRepo,
-		attachmentRepo, userMetaRepo, gameService, authorizer, commandExecutor, time.Second*2, logger)
+		attachmentRepo, attachmentStore, userMetaRepo, altRepo, infractionTypeRepo, ruleRepo, gameService, authorizer,
+		commandExecutor, time.Second*2, logger)
 	_infractionHandler.ApplyInfractionHandler(apiGroup, infractionService, attachmentService, authorizer, middlewareBundle, logger)
 
-	playerService := _playerService.NewPlayerService(playerRepo, playerNameRepo, time.Second*2, logger)
+	appealRepo := _appealRepo.NewAppealRepo(db, logger)
+	appealService := _appealService.NewAppealService(appealRepo, infractionRepo, infractionService, userMetaRepo, authorizer,
+		time.Second*2, logger)
+	_appealHandler.ApplyAppealHandler(apiGroup, appealService, authorizer, middlewareBundle, logger)
+
+	playerService := _playerService.NewPlayerService(playerRepo, playerNameRepo, playerSessionRepo, playerNoteRepo,
+		playerTagRepo, userMetaRepo, authorizer, time.Second*2, logger)
 	_playerHandler.ApplyPlayerHandler(apiGroup, playerService, authorizer, middlewareBundle, logger)
 
+	watchRepo := _watchRepo.NewWatchRepo(db, logger)
+	watchService := _watchService.NewWatchService(watchRepo, playerRepo, serverRepo, authRepo, mailService, authorizer,
+		time.Second*2, logger)
+	_watchHandler.ApplyWatchHandler(apiGroup, watchService, authorizer, middlewareBundle, logger)
+
+	federationService, err := _federationService.NewFederationService(federationRepo, commandExecutor,
+		config.FederationSigningKey, config.FederationFeedKey, config.FederationName, time.Second*2, logger)
+	if err != nil {
+		log.Fatalf("Could not set up federation service. Error: %v", err)
+	}
+	_federationHandler.ApplyFederationHandler(apiGroup, federationService, authorizer, middlewareBundle, logger)
+
 	flaggedWordRepo := _flaggedWordRepo.NewFlaggedWordRepo(db, logger)
 	flaggedWordService := _flaggedWordService.NewFlaggedWordService(flaggedWordRepo, time.Second*2, logger)
 
 	chatRepo := _chatRepo.NewChatRepo(db, logger)
+
+	reportRepo := _reportRepo.NewReportRepo(db, logger)
+	reportService := _reportService.NewReportService(reportRepo, chatRepo, playerNameRepo, serverService, rconService,
+		infractionService, authorizer, time.Second*2, logger)
+	_reportHandler.ApplyReportHandler(apiGroup, reportService, authorizer, middlewareBundle, logger)
+
 	chatService := _chatService.NewChatService(chatRepo, playerRepo, playerNameRepo, serverService, websocketService,
-		flaggedWordService, authorizer, time.Second*2, logger)
+		flaggedWordService, infractionService, gameService, reportService, authorizer, time.Second*2, logger)
 	_chatHandler.ApplyChatHandler(apiGroup, chatService, flaggedWordService, authorizer, middlewareBundle, logger)
 
-	searchService := _searchService.NewSearchService(playerRepo, playerNameRepo, infractionRepo, chatRepo, authorizer, time.Second*2, logger)
+	searchService := _searchService.NewSearchService(playerRepo, playerNameRepo, infractionRepo, chatRepo, appealRepo, authorizer, time.Second*2, logger)
 	_searchHandler.ApplySearchHandler(apiGroup, searchService, authorizer, middlewareBundle, logger)
 
 	statsRepo := _statsRepo.NewStatsRepo(db, logger)
@@ -238,15 +325,27 @@ func main() {
 	rconService.SubscribeQuit(serverService.HandlePlayerQuit)
 	rconService.SubscribeServerStatus(serverService.HandleServerStatusChange)
 	rconService.SubscribeServerStatus(websocketService.HandleServerStatusChange)
+	rconService.SubscribeServerStatus(commandExecutor.HandleServerStatusChange)
+	rconService.SubscribeServerStatus(playerService.HandleServerStatusChange)
 	rconService.SubscribeChat(chatService.HandleChatReceive)
 	rconService.SubscribeJoin(infractionService.HandlePlayerJoin)
 	rconService.SubscribePlayerListUpdate(serverService.HandlePlayerListUpdate)
 	rconService.SubscribePlayerListUpdate(websocketService.HandlePlayerListUpdate)
+	rconService.SubscribePlayerListUpdate(playerService.HandlePlayerListUpdate)
 	rconService.SubscribeModeratorAction(infractionService.HandleModerationAction)
 	websocketService.SubscribeChatSend(rconService.SendChatMessage)
 	websocketService.SubscribeChatSend(chatService.HandleUserSendChat)
+	websocketService.SubscribeConsoleCommand(consoleService.HandleConsoleCommand)
 	serverService.SubscribeServerUpdate(rconService.HandleServerUpdate)
 	infractionService.SubscribeInfractionCreate(websocketService.HandleInfractionCreate)
+	infractionService.SubscribeInfractionExpire(websocketService.HandleInfractionExpire)
+	appealService.SubscribeAppealUpdate(websocketService.HandleAppealUpdate)
+	reportService.SubscribeReportCreate(websocketService.HandlePlayerReport)
+	rconService.SubscribeJoin(watchService.HandlePlayerJoin)
+	rconService.SubscribeChat(watchService.HandleChatReceive)
+	watchService.SubscribeWatchedPlayerAlert(websocketService.HandleWatchedPlayerAlert)
+	rconService.SubscribeJoin(federationService.HandlePlayerJoin)
+	federationService.SubscribeFederatedBanAlert(websocketService.HandleFederatedBanAlert)
 
 	// Connect RCON clients for all existing servers
 	if err := SetupServerClients(rconService, serverService, logger); err != nil {
@@ -263,6 +362,14 @@ func main() {
 
 	// Start command executor runner routine
 	go commandExecutor.StartRunner(nil)
+	go infractionService.StartExpiryWatcher(nil)
+	go watchService.StartExpiryWatcher(nil)
+	go federationService.StartSyncWatcher(nil)
+
+	if config.DeletedInfractionRetentionDays > 0 {
+		retention := time.Hour * 24 * time.Duration(config.DeletedInfractionRetentionDays)
+		go infractionService.StartDeletedPurger(retention, nil)
+	}
 
 	// Setup complete. Begin serving requests.
 	logger.Info("Setup complete!")
@@ -0,0 +1,44 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+DO $$ BEGIN
+    CREATE TYPE CommandStatus AS ENUM ('pending', 'running', 'succeeded', 'failed', 'expired');
+EXCEPTION
+    WHEN duplicate_object THEN null;
+END $$;
+
+CREATE TABLE IF NOT EXISTS QueuedCommands(
+    CommandID SERIAL NOT NULL PRIMARY KEY,
+    ServerID SERIAL NOT NULL,
+    Command TEXT NOT NULL,
+    Status CommandStatus NOT NULL DEFAULT 'pending',
+    Attempts INT NOT NULL DEFAULT 0,
+    MaxAttempts INT NOT NULL DEFAULT 5,
+    LastError TEXT,
+    NextAttemptAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ExpiresAt TIMESTAMP,
+    CreatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ModifiedAt TIMESTAMP,
+
+    FOREIGN KEY (ServerID) REFERENCES Servers (ServerID)
+);
+
+CREATE INDEX IF NOT EXISTS queuedcommands_pending_idx ON QueuedCommands (ServerID, NextAttemptAt) WHERE Status = 'pending';
+
+DROP TRIGGER IF EXISTS update_queuedcommands_modat ON QueuedCommands;
+CREATE TRIGGER update_queuedcommands_modat BEFORE UPDATE ON QueuedCommands
+    FOR EACH ROW EXECUTE PROCEDURE update_modified_at_column();
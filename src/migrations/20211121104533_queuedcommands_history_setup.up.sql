@@ -0,0 +1,26 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+ALTER TABLE QueuedCommands
+    ADD COLUMN IF NOT EXISTS InfractionID INT,
+    ADD COLUMN IF NOT EXISTS UserID VARCHAR(36),
+    ADD COLUMN IF NOT EXISTS Response TEXT,
+    ADD COLUMN IF NOT EXISTS StartedAt TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS FinishedAt TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS queuedcommands_server_history_idx ON QueuedCommands (ServerID, CommandID DESC);
+CREATE INDEX IF NOT EXISTS queuedcommands_infraction_idx ON QueuedCommands (InfractionID);
@@ -0,0 +1,29 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+-- GroupID has no foreign key since the base group (ID -1) is not stored in the Groups table.
+CREATE TABLE IF NOT EXISTS ConsoleRules(
+    GroupID INT NOT NULL PRIMARY KEY,
+    AllowPrefixes TEXT[] NOT NULL DEFAULT '{}',
+    DenyPrefixes TEXT[] NOT NULL DEFAULT '{}',
+    CreatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ModifiedAt TIMESTAMP
+);
+
+DROP TRIGGER IF EXISTS update_consolerules_modat ON ConsoleRules;
+CREATE TRIGGER update_consolerules_modat BEFORE UPDATE ON ConsoleRules
+    FOR EACH ROW EXECUTE PROCEDURE update_modified_at_column();
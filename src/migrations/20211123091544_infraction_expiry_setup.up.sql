@@ -0,0 +1,25 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+ALTER TABLE Infractions ADD COLUMN IF NOT EXISTS ExpiredAt TIMESTAMP NULL;
+
+-- Infractions which have already expired are marked as processed so that they don't trigger expiry events once the
+-- expiry watcher starts.
+UPDATE Infractions SET ExpiredAt = CreatedAt + (Duration * INTERVAL '1 minute')
+WHERE Duration > 0 AND CreatedAt + (Duration * INTERVAL '1 minute') <= CURRENT_TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS infractions_unexpired_idx ON Infractions (CreatedAt)
+    WHERE ExpiredAt IS NULL AND Repealed = false AND Duration > 0;
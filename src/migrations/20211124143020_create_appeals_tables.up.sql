@@ -0,0 +1,60 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+DO $$ BEGIN
+    CREATE TYPE AppealStatus AS ENUM ('open', 'accepted', 'rejected');
+EXCEPTION
+    WHEN duplicate_object THEN null;
+END $$;
+
+CREATE TABLE IF NOT EXISTS Appeals(
+    AppealID SERIAL NOT NULL PRIMARY KEY,
+    InfractionID INT NOT NULL,
+    UserID VARCHAR(36),
+    Status AppealStatus NOT NULL DEFAULT 'open',
+    Statement TEXT NOT NULL,
+    DecidedBy VARCHAR(36),
+    DecidedAt TIMESTAMP,
+    CreatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ModifiedAt TIMESTAMP,
+
+    FOREIGN KEY (InfractionID) REFERENCES Infractions (InfractionID) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS appeals_infractionid_idx ON Appeals (InfractionID);
+CREATE INDEX IF NOT EXISTS appeals_status_idx ON Appeals (Status);
+
+-- An infraction can only have one open appeal at a time
+CREATE UNIQUE INDEX IF NOT EXISTS appeals_open_infraction_idx ON Appeals (InfractionID) WHERE Status = 'open';
+
+DROP TRIGGER IF EXISTS update_appeals_modat ON Appeals;
+CREATE TRIGGER update_appeals_modat BEFORE UPDATE ON Appeals
+    FOR EACH ROW EXECUTE PROCEDURE update_modified_at_column();
+
+-- AppealComments holds staff comments on an appeal. Comments which changed the appeal's status have NewStatus set,
+-- making up the appeal's decision trail.
+CREATE TABLE IF NOT EXISTS AppealComments(
+    CommentID SERIAL NOT NULL PRIMARY KEY,
+    AppealID INT NOT NULL,
+    UserID VARCHAR(36),
+    Comment TEXT NOT NULL,
+    NewStatus AppealStatus,
+    CreatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (AppealID) REFERENCES Appeals (AppealID) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS appealcomments_appealid_idx ON AppealComments (AppealID);
@@ -0,0 +1,35 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+-- InfractionRevisions holds an immutable record of every change made to an infraction. Changes holds a JSON object
+-- mapping each changed field to its old and new values.
+CREATE TABLE IF NOT EXISTS InfractionRevisions(
+    RevisionID SERIAL NOT NULL PRIMARY KEY,
+    InfractionID INT NOT NULL,
+    UserID VARCHAR(36),
+    Changes JSONB NOT NULL DEFAULT '{}',
+    Note TEXT,
+    CreatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (InfractionID) REFERENCES Infractions (InfractionID) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS infractionrevisions_infractionid_idx ON InfractionRevisions (InfractionID);
+
+-- Revisions are immutable. Silently discard any updates made to them.
+DROP RULE IF EXISTS infractionrevisions_no_update ON InfractionRevisions;
+CREATE RULE infractionrevisions_no_update AS ON UPDATE TO InfractionRevisions DO INSTEAD NOTHING;
@@ -0,0 +1,24 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+-- Uploaded attachments are stored in an attachment store rather than being hosted externally. Their files are
+-- addressed by the SHA-256 hash of their content. Attachments which only link to an external URL leave these NULL.
+ALTER TABLE Attachments ADD COLUMN IF NOT EXISTS ContentType VARCHAR(128);
+ALTER TABLE Attachments ADD COLUMN IF NOT EXISTS FileSize BIGINT;
+ALTER TABLE Attachments ADD COLUMN IF NOT EXISTS SHA256 CHAR(64);
+
+CREATE INDEX IF NOT EXISTS attachments_sha256_idx ON Attachments (SHA256) WHERE SHA256 IS NOT NULL;
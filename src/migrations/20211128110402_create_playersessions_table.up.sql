@@ -0,0 +1,38 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+-- PlayerSessions records each period of time a player spent on a server. EndedAt and EndReason are NULL while the
+-- session is still open.
+CREATE TABLE IF NOT EXISTS PlayerSessions(
+    SessionID SERIAL NOT NULL PRIMARY KEY,
+    PlayerID VARCHAR(80) NOT NULL,
+    Platform VARCHAR(128) NOT NULL,
+    ServerID INT NOT NULL,
+    StartedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    EndedAt TIMESTAMP,
+    EndReason VARCHAR(32),
+
+    FOREIGN KEY (PlayerID, Platform) REFERENCES Players (PlayerID, Platform) ON DELETE CASCADE,
+    FOREIGN KEY (ServerID) REFERENCES Servers (ServerID) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS playersessions_player_idx ON PlayerSessions (Platform, PlayerID, StartedAt DESC);
+CREATE INDEX IF NOT EXISTS playersessions_open_idx ON PlayerSessions (ServerID) WHERE EndedAt IS NULL;
+
+-- A player can only have one open session on a server at a time, even if their join is handled twice concurrently.
+CREATE UNIQUE INDEX IF NOT EXISTS playersessions_open_player_idx ON PlayerSessions (Platform, PlayerID, ServerID)
+    WHERE EndedAt IS NULL;
@@ -0,0 +1,50 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+-- PlayerNotes holds staff-only notes about a player. Pinned notes are shown before all others.
+CREATE TABLE IF NOT EXISTS PlayerNotes(
+    NoteID SERIAL NOT NULL PRIMARY KEY,
+    PlayerID VARCHAR(80) NOT NULL,
+    Platform VARCHAR(128) NOT NULL,
+    UserID VARCHAR(36) NOT NULL,
+    Note TEXT NOT NULL,
+    Pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    CreatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ModifiedAt TIMESTAMP,
+
+    FOREIGN KEY (PlayerID, Platform) REFERENCES Players (PlayerID, Platform) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS playernotes_player_idx ON PlayerNotes (Platform, PlayerID);
+
+DROP TRIGGER IF EXISTS update_playernotes_modat ON PlayerNotes;
+CREATE TRIGGER update_playernotes_modat BEFORE UPDATE ON PlayerNotes
+    FOR EACH ROW EXECUTE PROCEDURE update_modified_at_column();
+
+-- PlayerTags holds free-form labels attached to a player. Tags are unique per player regardless of case.
+CREATE TABLE IF NOT EXISTS PlayerTags(
+    PlayerID VARCHAR(80) NOT NULL,
+    Platform VARCHAR(128) NOT NULL,
+    Tag VARCHAR(32) NOT NULL,
+    UserID VARCHAR(36) NOT NULL,
+    CreatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (PlayerID, Platform) REFERENCES Players (PlayerID, Platform) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS playertags_player_tag_idx ON PlayerTags (Platform, PlayerID, LOWER(Tag));
+CREATE INDEX IF NOT EXISTS playertags_tag_idx ON PlayerTags (LOWER(Tag));
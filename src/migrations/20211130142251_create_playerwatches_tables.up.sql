@@ -0,0 +1,36 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+-- PlayerWatches holds the details of each watched player. Players.Watched is kept in sync with this table. A watch
+-- with no ExpiresAt value does not expire.
+CREATE TABLE IF NOT EXISTS PlayerWatches(
+    PlayerID VARCHAR(80) NOT NULL,
+    Platform VARCHAR(128) NOT NULL,
+    UserID VARCHAR(36) NOT NULL,
+    Reason TEXT,
+    ExpiresAt TIMESTAMP,
+    CreatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (PlayerID, Platform),
+    FOREIGN KEY (PlayerID, Platform) REFERENCES Players (PlayerID, Platform) ON DELETE CASCADE
+);
+
+-- WatchAlertSubscriptions holds the users who receive an email when a watched player joins a server.
+CREATE TABLE IF NOT EXISTS WatchAlertSubscriptions(
+    UserID VARCHAR(36) NOT NULL PRIMARY KEY,
+    CreatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
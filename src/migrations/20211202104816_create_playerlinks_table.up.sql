@@ -0,0 +1,39 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+-- PlayerLinks holds links between player accounts which staff have confirmed belong to the same person. Each pair is
+-- stored once, with the lower of the two player keys in PlayerID and Platform.
+CREATE TABLE IF NOT EXISTS PlayerLinks(
+    LinkID SERIAL NOT NULL PRIMARY KEY,
+    PlayerID VARCHAR(80) NOT NULL,
+    Platform VARCHAR(128) NOT NULL,
+    LinkedPlayerID VARCHAR(80) NOT NULL,
+    LinkedPlatform VARCHAR(128) NOT NULL,
+    UserID VARCHAR(36) NOT NULL,
+    Note TEXT,
+    CreatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE (PlayerID, Platform, LinkedPlayerID, LinkedPlatform),
+    FOREIGN KEY (PlayerID, Platform) REFERENCES Players (PlayerID, Platform) ON DELETE CASCADE,
+    FOREIGN KEY (LinkedPlayerID, LinkedPlatform) REFERENCES Players (PlayerID, Platform) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS playerlinks_linked_player_idx ON PlayerLinks (LinkedPlatform, LinkedPlayerID);
+
+-- Indexes used by alt account detection
+CREATE INDEX IF NOT EXISTS playernames_lower_name_idx ON PlayerNames (LOWER(Name));
+CREATE INDEX IF NOT EXISTS playersessions_server_started_idx ON PlayerSessions (ServerID, StartedAt);
@@ -0,0 +1,58 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+-- FederationSources holds the ban feeds of partner Refractor instances. PublicKey is the base64 encoded ed25519 key
+-- used to verify each feed's signature and Instance is the instance name each feed must carry. FeedKey is the encrypted
+-- key sent to the partner to access its feed. LastFeedAt is the generation time of the last imported feed, used to
+-- reject replayed feeds.
+CREATE TABLE IF NOT EXISTS FederationSources(
+    SourceID SERIAL NOT NULL PRIMARY KEY,
+    Name VARCHAR(64) NOT NULL,
+    FeedURL TEXT NOT NULL UNIQUE,
+    PublicKey VARCHAR(64) NOT NULL,
+    Instance VARCHAR(128) NOT NULL,
+    FeedKey BYTEA NOT NULL,
+    TrustLevel VARCHAR(16) NOT NULL DEFAULT 'ADVISORY',
+    Enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    LastSyncedAt TIMESTAMP,
+    LastSyncError TEXT,
+    LastFeedAt TIMESTAMP,
+    CreatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ModifiedAt TIMESTAMP
+);
+
+DROP TRIGGER IF EXISTS update_federationsources_modat ON FederationSources;
+CREATE TRIGGER update_federationsources_modat BEFORE UPDATE ON FederationSources
+    FOR EACH ROW EXECUTE PROCEDURE update_modified_at_column();
+
+-- FederatedBans holds the bans imported from each source. The bans of a source are replaced each time it is synced.
+-- Imported bans are kept apart from Infractions so they are never exported again or enforced unless trusted.
+CREATE TABLE IF NOT EXISTS FederatedBans(
+    SourceID INT NOT NULL,
+    RemoteID BIGINT NOT NULL,
+    Platform VARCHAR(128) NOT NULL,
+    PlayerID VARCHAR(80) NOT NULL,
+    Reason TEXT,
+    Duration INT NOT NULL,
+    RemoteCreatedAt TIMESTAMP NOT NULL,
+    ImportedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (SourceID, RemoteID),
+    FOREIGN KEY (SourceID) REFERENCES FederationSources (SourceID) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS federatedbans_player_idx ON FederatedBans (Platform, PlayerID);
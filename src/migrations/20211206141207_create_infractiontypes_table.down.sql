@@ -0,0 +1,31 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+DROP TABLE IF EXISTS InfractionTypes;
+
+-- Infractions of custom types cannot be represented by the enum
+DELETE FROM Infractions WHERE Type NOT IN ('WARNING', 'MUTE', 'KICK', 'BAN');
+
+DO $$ BEGIN
+    CREATE TYPE InfractionType AS ENUM ('WARNING', 'MUTE', 'KICK', 'BAN');
+EXCEPTION
+    WHEN duplicate_object THEN null;
+END $$;
+
+ALTER TABLE Infractions ALTER COLUMN Type TYPE InfractionType USING Type::InfractionType;
+
+-- Permission columns keep their larger size since values with custom infraction type flags set would not fit.
@@ -0,0 +1,47 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+-- Custom infraction types are stored by name on infractions, so the type column can no longer be a fixed enum.
+ALTER TABLE Infractions ALTER COLUMN Type TYPE VARCHAR(32) USING Type::VARCHAR;
+DROP TYPE IF EXISTS InfractionType;
+
+-- InfractionTypes holds the custom infraction types defined for each game. The permission flag to create infractions
+-- of a type is derived from its TypeID, so IDs must never be reused.
+CREATE TABLE IF NOT EXISTS InfractionTypes(
+    TypeID SERIAL NOT NULL PRIMARY KEY,
+    Game VARCHAR(32) NOT NULL,
+    Name VARCHAR(32) NOT NULL,
+    DisplayName VARCHAR(64) NOT NULL,
+    HasDuration BOOLEAN NOT NULL DEFAULT FALSE,
+    AllowPermanent BOOLEAN NOT NULL DEFAULT FALSE,
+    AllowedUpdateFields VARCHAR(16)[] NOT NULL DEFAULT '{}',
+    CreatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ModifiedAt TIMESTAMP,
+
+    UNIQUE (Game, Name)
+);
+
+DROP TRIGGER IF EXISTS update_infractiontypes_modat ON InfractionTypes;
+CREATE TRIGGER update_infractiontypes_modat BEFORE UPDATE ON InfractionTypes
+    FOR EACH ROW EXECUTE PROCEDURE update_modified_at_column();
+
+-- Custom infraction type permission flags are set above the built-in flags, so permission values need more room.
+ALTER TABLE Groups ALTER COLUMN Permissions TYPE VARCHAR(255);
+ALTER TABLE ServerGroups ALTER COLUMN AllowOverrides TYPE VARCHAR(255);
+ALTER TABLE ServerGroups ALTER COLUMN DenyOverrides TYPE VARCHAR(255);
+ALTER TABLE UserOverrides ALTER COLUMN AllowOverrides TYPE VARCHAR(255);
+ALTER TABLE UserOverrides ALTER COLUMN DenyOverrides TYPE VARCHAR(255);
@@ -0,0 +1,35 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+-- Rules is the catalogue of server rules which infractions can reference. Suggestions holds the default punishments
+-- for breaking the rule, keyed by offense number.
+CREATE TABLE IF NOT EXISTS Rules(
+    RuleID SERIAL NOT NULL PRIMARY KEY,
+    Title VARCHAR(128) NOT NULL,
+    Description TEXT NOT NULL DEFAULT '',
+    Suggestions JSONB NOT NULL DEFAULT '[]',
+    CreatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ModifiedAt TIMESTAMP
+);
+
+DROP TRIGGER IF EXISTS update_rules_modat ON Rules;
+CREATE TRIGGER update_rules_modat BEFORE UPDATE ON Rules
+    FOR EACH ROW EXECUTE PROCEDURE update_modified_at_column();
+
+ALTER TABLE Infractions ADD COLUMN IF NOT EXISTS RuleID INT NULL REFERENCES Rules(RuleID) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS infractions_ruleid_idx ON Infractions (RuleID) WHERE RuleID IS NOT NULL;
@@ -0,0 +1,22 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+ALTER TABLE Infractions ADD COLUMN IF NOT EXISTS DeletedAt TIMESTAMP NULL;
+ALTER TABLE Infractions ADD COLUMN IF NOT EXISTS DeletedBy VARCHAR(36) NULL;
+ALTER TABLE Infractions ADD COLUMN IF NOT EXISTS DeleteReason TEXT NULL;
+
+CREATE INDEX IF NOT EXISTS infractions_deleted_idx ON Infractions (DeletedAt) WHERE DeletedAt IS NOT NULL;
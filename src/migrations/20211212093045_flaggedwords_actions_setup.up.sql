@@ -0,0 +1,29 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+ALTER TABLE FlaggedWords ADD COLUMN IF NOT EXISTS Severity VARCHAR(16) NOT NULL DEFAULT 'low';
+ALTER TABLE FlaggedWords ADD COLUMN IF NOT EXISTS Action VARCHAR(16) NOT NULL DEFAULT 'flag';
+ALTER TABLE FlaggedWords ADD COLUMN IF NOT EXISTS ActionDuration INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS FlaggedWordExemptions (
+    WordID INT NOT NULL,
+    ServerID INT NOT NULL,
+
+    PRIMARY KEY (WordID, ServerID),
+    FOREIGN KEY (WordID) REFERENCES FlaggedWords (WordID) ON DELETE CASCADE,
+    FOREIGN KEY (ServerID) REFERENCES Servers (ServerID) ON DELETE CASCADE
+);
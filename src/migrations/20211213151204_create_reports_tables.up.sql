@@ -0,0 +1,63 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+DO $$ BEGIN
+    CREATE TYPE ReportStatus AS ENUM ('open', 'claimed', 'resolved');
+EXCEPTION
+    WHEN duplicate_object THEN null;
+END $$;
+
+-- Reports holds reports made by players in-game through the report command of their server's game.
+CREATE TABLE IF NOT EXISTS Reports(
+    ReportID SERIAL NOT NULL PRIMARY KEY,
+    ServerID INT NOT NULL,
+    Platform VARCHAR(128) NOT NULL,
+    ReporterID VARCHAR(80) NOT NULL,
+    TargetID VARCHAR(80) NOT NULL,
+    Reason TEXT NOT NULL,
+    Status ReportStatus NOT NULL DEFAULT 'open',
+    ClaimedBy VARCHAR(36),
+    ClaimedAt TIMESTAMP,
+    ResolvedBy VARCHAR(36),
+    ResolvedAt TIMESTAMP,
+    Resolution TEXT,
+    InfractionID INT,
+    CreatedAt TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ModifiedAt TIMESTAMP,
+
+    FOREIGN KEY (ServerID) REFERENCES Servers (ServerID) ON DELETE CASCADE,
+    FOREIGN KEY (ReporterID, Platform) REFERENCES Players (PlayerID, Platform),
+    FOREIGN KEY (TargetID, Platform) REFERENCES Players (PlayerID, Platform),
+    FOREIGN KEY (InfractionID) REFERENCES Infractions (InfractionID) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS reports_serverid_idx ON Reports (ServerID);
+CREATE INDEX IF NOT EXISTS reports_status_idx ON Reports (Status);
+
+DROP TRIGGER IF EXISTS update_reports_modat ON Reports;
+CREATE TRIGGER update_reports_modat BEFORE UPDATE ON Reports
+    FOR EACH ROW EXECUTE PROCEDURE update_modified_at_column();
+
+-- ReportChatMessages links a report to the chat which surrounded it on the server.
+CREATE TABLE IF NOT EXISTS ReportChatMessages(
+    ReportID INT NOT NULL,
+    MessageID INT NOT NULL,
+
+    PRIMARY KEY (ReportID, MessageID),
+    FOREIGN KEY (ReportID) REFERENCES Reports (ReportID) ON DELETE CASCADE,
+    FOREIGN KEY (MessageID) REFERENCES ChatMessages (MessageID) ON DELETE CASCADE
+);
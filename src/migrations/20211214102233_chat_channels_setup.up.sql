@@ -0,0 +1,21 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+ALTER TABLE ChatMessages ADD COLUMN IF NOT EXISTS Channel VARCHAR(32) NOT NULL DEFAULT 'all';
+CREATE INDEX IF NOT EXISTS chatmessages_channel_idx ON ChatMessages (Channel);
+
+ALTER TABLE FlaggedWords ADD COLUMN IF NOT EXISTS Channels VARCHAR(32)[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,27 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+-- Commands queued for an infraction record the player, infraction type and action they were prepared for. This lets
+-- in-game moderation actions which were caused by Refractor's own commands be recognised when the game reports them.
+ALTER TABLE QueuedCommands
+    ADD COLUMN IF NOT EXISTS Platform VARCHAR(128),
+    ADD COLUMN IF NOT EXISTS PlayerID VARCHAR(80),
+    ADD COLUMN IF NOT EXISTS InfractionType VARCHAR(32),
+    ADD COLUMN IF NOT EXISTS Action VARCHAR(16);
+
+CREATE INDEX IF NOT EXISTS queuedcommands_player_idx ON QueuedCommands (ServerID, Platform, PlayerID)
+    WHERE PlayerID IS NOT NULL;
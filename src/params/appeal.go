@@ -0,0 +1,62 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package params
+
+import (
+	"Refractor/domain"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"strings"
+)
+
+type CreateAppealParams struct {
+	Statement string `json:"statement" form:"statement"`
+}
+
+func (body CreateAppealParams) Validate() error {
+	body.Statement = strings.TrimSpace(body.Statement)
+
+	return ValidateStruct(&body,
+		validation.Field(&body.Statement, validation.Required, validation.Length(1, 4096)))
+}
+
+type AppealCommentParams struct {
+	Comment string `json:"comment" form:"comment"`
+}
+
+func (body AppealCommentParams) Validate() error {
+	body.Comment = strings.TrimSpace(body.Comment)
+
+	return ValidateStruct(&body,
+		validation.Field(&body.Comment, validation.Required, validation.Length(1, 2048)))
+}
+
+type SetAppealStatusParams struct {
+	Status  string `json:"status" form:"status"`
+	Comment string `json:"comment" form:"comment"`
+}
+
+func (body SetAppealStatusParams) Validate() error {
+	body.Status = strings.TrimSpace(body.Status)
+	body.Comment = strings.TrimSpace(body.Comment)
+
+	return ValidateStruct(&body,
+		validation.Field(&body.Status, validation.Required, validation.In(domain.AppealStatusAccepted,
+			domain.AppealStatusRejected)),
+		validation.Field(&body.Comment, validation.Length(0, 2048)),
+	)
+}
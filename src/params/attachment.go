@@ -33,3 +33,13 @@ func (body CreateAttachmentParams) Validate() error {
 		validation.Field(&body.Note, rules.AttachmentNoteRules...),
 	)
 }
+
+type UploadAttachmentParams struct {
+	Note string `json:"note" form:"note"`
+}
+
+func (body UploadAttachmentParams) Validate() error {
+	return ValidateStruct(&body,
+		validation.Field(&body.Note, rules.AttachmentNoteRules...),
+	)
+}
@@ -0,0 +1,120 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package params
+
+import (
+	"Refractor/domain"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"net/url"
+	"strings"
+)
+
+type CreateFederationSourceParams struct {
+	Name       string `json:"name" form:"name"`
+	FeedURL    string `json:"feed_url" form:"feed_url"`
+	PublicKey  string `json:"public_key" form:"public_key"`
+	Instance   string `json:"instance" form:"instance"`
+	FeedKey    string `json:"feed_key" form:"feed_key"`
+	TrustLevel string `json:"trust_level" form:"trust_level"`
+}
+
+func (body CreateFederationSourceParams) Validate() error {
+	body.Name = strings.TrimSpace(body.Name)
+	body.FeedURL = strings.TrimSpace(body.FeedURL)
+	body.PublicKey = strings.TrimSpace(body.PublicKey)
+	body.Instance = strings.TrimSpace(body.Instance)
+
+	return ValidateStruct(&body,
+		validation.Field(&body.Name, validation.Required, validation.Length(1, 64)),
+		validation.Field(&body.FeedURL, validation.Required, validation.By(feedURL)),
+		validation.Field(&body.PublicKey, validation.Required, validation.By(federationPublicKey)),
+		validation.Field(&body.Instance, validation.Required, validation.Length(1, 128)),
+		validation.Field(&body.FeedKey, validation.Required, validation.Length(16, 256)),
+		validation.Field(&body.TrustLevel, validation.Required, validation.In(domain.AllFederationTrustLevels...)))
+}
+
+type UpdateFederationSourceParams struct {
+	Name       *string `json:"name" form:"name"`
+	FeedURL    *string `json:"feed_url" form:"feed_url"`
+	PublicKey  *string `json:"public_key" form:"public_key"`
+	Instance   *string `json:"instance" form:"instance"`
+	FeedKey    *string `json:"feed_key" form:"feed_key"`
+	TrustLevel *string `json:"trust_level" form:"trust_level"`
+	Enabled    *bool   `json:"enabled" form:"enabled"`
+}
+
+func (body UpdateFederationSourceParams) Validate() error {
+	return ValidateStruct(&body,
+		validation.Field(&body.Name, validation.By(stringPointerNotEmpty), validation.Length(1, 64)),
+		validation.Field(&body.FeedURL, validation.By(stringPointerNotEmpty), validation.By(feedURL)),
+		validation.Field(&body.PublicKey, validation.By(stringPointerNotEmpty), validation.By(federationPublicKey)),
+		validation.Field(&body.Instance, validation.By(stringPointerNotEmpty), validation.Length(1, 128)),
+		validation.Field(&body.FeedKey, validation.By(stringPointerNotEmpty), validation.Length(16, 256)),
+		validation.Field(&body.TrustLevel, validation.In(domain.AllFederationTrustLevels...)))
+}
+
+// feedURL is a custom validation rule which checks that a string or string pointer is an absolute http or https URL.
+// Plain http is allowed so that feeds can be tested against a local second instance.
+func feedURL(val interface{}) error {
+	str, ok := stringValue(val)
+	if !ok {
+		return nil
+	}
+
+	u, err := url.Parse(str)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an http or https URL")
+	}
+
+	return nil
+}
+
+// federationPublicKey is a custom validation rule which checks that a string or string pointer is a base64 encoded
+// ed25519 public key.
+func federationPublicKey(val interface{}) error {
+	str, ok := stringValue(val)
+	if !ok {
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(str)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("must be a base64 encoded ed25519 public key")
+	}
+
+	return nil
+}
+
+// stringValue returns the value of a string or non-nil string pointer. False is returned for any other value.
+func stringValue(val interface{}) (string, bool) {
+	switch v := val.(type) {
+	case string:
+		return strings.TrimSpace(v), true
+	case *string:
+		if v == nil {
+			return "", false
+		}
+
+		return strings.TrimSpace(*v), true
+	}
+
+	return "", false
+}
@@ -18,23 +18,85 @@
 package params
 
 import (
+	"Refractor/domain"
+	"Refractor/params/validators"
+	"fmt"
 	validation "github.com/go-ozzo/ozzo-validation"
+	"math"
+	"strings"
 )
 
 type CreateFlaggedWordParams struct {
-	Word string `json:"word"`
+	Word           string   `json:"word"`
+	Mode           string   `json:"mode"`
+	MaxDistance    int      `json:"max_distance"`
+	Severity       string   `json:"severity"`
+	Action         string   `json:"action"`
+	ActionDuration int      `json:"action_duration"`
+	Channels       []string `json:"channels"`
 }
 
 func (body CreateFlaggedWordParams) Validate() error {
 	return ValidateStruct(&body,
-		validation.Field(&body.Word, validation.Required, validation.Length(1, 100)))
+		validation.Field(&body.Word, validation.Required, validation.Length(1, 100)),
+		validation.Field(&body.Mode, validation.By(validators.ValueInStrArray(domain.AllFlaggedWordModes))),
+		validation.Field(&body.MaxDistance, validation.Min(0), validation.Max(domain.MaxFlaggedWordDistance)),
+		validation.Field(&body.Severity, validation.By(validators.ValueInStrArray(domain.AllFlaggedWordSeverities))),
+		validation.Field(&body.Action, validation.By(validators.ValueInStrArray(domain.AllFlaggedWordActions))),
+		validation.Field(&body.ActionDuration, validation.Min(0), validation.Max(math.MaxInt32)),
+		validation.Field(&body.Channels, validation.By(flaggedWordChannels)))
 }
 
 type UpdateFlaggedWordParams struct {
-	Word *string `json:"word"`
+	Word           *string   `json:"word"`
+	Mode           *string   `json:"mode"`
+	MaxDistance    *int      `json:"max_distance"`
+	Severity       *string   `json:"severity"`
+	Action         *string   `json:"action"`
+	ActionDuration *int      `json:"action_duration"`
+	Channels       *[]string `json:"channels"`
 }
 
 func (body UpdateFlaggedWordParams) Validate() error {
 	return ValidateStruct(&body,
-		validation.Field(&body.Word, validation.Length(1, 100)))
+		validation.Field(&body.Word, validation.Length(1, 100)),
+		validation.Field(&body.Mode, validation.NilOrNotEmpty,
+			validation.By(validators.PtrValueInStrArray(domain.AllFlaggedWordModes))),
+		validation.Field(&body.MaxDistance, validation.Min(0), validation.Max(domain.MaxFlaggedWordDistance)),
+		validation.Field(&body.Severity, validation.NilOrNotEmpty,
+			validation.By(validators.PtrValueInStrArray(domain.AllFlaggedWordSeverities))),
+		validation.Field(&body.Action, validation.NilOrNotEmpty,
+			validation.By(validators.PtrValueInStrArray(domain.AllFlaggedWordActions))),
+		validation.Field(&body.ActionDuration, validation.Min(0), validation.Max(math.MaxInt32)),
+		validation.Field(&body.Channels, validation.By(flaggedWordChannels)))
+}
+
+func flaggedWordChannels(val interface{}) error {
+	var channels []string
+
+	switch v := val.(type) {
+	case []string:
+		channels = v
+	case *[]string:
+		if v == nil {
+			return nil
+		}
+		channels = *v
+	}
+
+	for _, channel := range channels {
+		allowed := false
+		for _, c := range domain.AllChatChannels {
+			if channel == c {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return fmt.Errorf("must only contain: %s", strings.Join(domain.AllChatChannels, ", "))
+		}
+	}
+
+	return nil
 }
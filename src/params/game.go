@@ -19,9 +19,12 @@ package params
 
 import (
 	"Refractor/domain"
+	"Refractor/pkg/cmdtemplate"
 	validation "github.com/go-ozzo/ozzo-validation"
 	"math"
 	"net/http"
+	"regexp"
+	"strings"
 )
 
 type SetGameCommandSettingsParams struct {
@@ -30,6 +33,7 @@ type SetGameCommandSettingsParams struct {
 	InfractionDelete *domain.InfractionCommands `json:"delete"`
 	InfractionRepeal *domain.InfractionCommands `json:"repeal"`
 	InfractionSync   *domain.InfractionCommands `json:"sync"`
+	InfractionExpire *domain.InfractionCommands `json:"expire"`
 }
 
 type cmdFieldErrBody struct {
@@ -103,6 +107,7 @@ func (body SetGameCommandSettingsParams) Validate() error {
 	// Ensure that warn and kick sync commands are nil since we don't support warn/kick syncing
 	body.InfractionSync.Warn = nil
 	body.InfractionSync.Kick = nil
+	body.InfractionSync.Custom = nil
 	// Validate sync manually since it's treated specially
 	if err := validateCmdArr(body.InfractionSync.Ban, "sync", "ban"); err != nil {
 		return err
@@ -111,6 +116,20 @@ func (body SetGameCommandSettingsParams) Validate() error {
 		return err
 	}
 
+	// Expire commands are optional. Only mutes and bans can expire, so warn and kick commands are not validated. They
+	// are dropped by the handler.
+	if body.InfractionExpire != nil {
+		if err := validateCmdArr(body.InfractionExpire.Ban, "expire", "ban"); err != nil {
+			return err
+		}
+		if err := validateCmdArr(body.InfractionExpire.Mute, "expire", "mute"); err != nil {
+			return err
+		}
+		if err := validateCustomCmds(body.InfractionExpire.Custom, "expire"); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -127,6 +146,23 @@ func validateActCmds(cmds *domain.InfractionCommands, act string) error {
 	if err := validateCmdArr(cmds.Ban, act, "ban"); err != nil {
 		return err
 	}
+	if err := validateCustomCmds(cmds.Custom, act); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func validateCustomCmds(custom map[string][]*domain.InfractionCommand, act string) error {
+	for name, arr := range custom {
+		if !customInfractionTypeNamePattern.MatchString(name) {
+			return buildManualError(act, "custom", "invalid infraction type name: "+name)
+		}
+
+		if err := validateCmdArr(arr, act, "custom."+name); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -139,21 +175,78 @@ func validateCmdArr(arr []*domain.InfractionCommand, act, infr string) error {
 				Message: "length must be between 1 and 256",
 			})
 		}
+
+		if err := cmdtemplate.Validate(cmd.Command); err != nil {
+			return buildManualError(act, infr, &cmdFieldErrBody{
+				Index:   idx,
+				Message: "invalid command template: " + err.Error(),
+			})
+		}
 	}
 
 	return nil
 }
 
 type SetGameGeneralSettingsParams struct {
-	EnableBanSync             bool `json:"enable_ban_sync"`
-	EnableMuteSync            bool `json:"enable_mute_sync"`
-	PlayerInfractionThreshold int  `json:"player_infraction_threshold"`
-	PlayerInfractionTimespan  int  `json:"player_infraction_timespan"`
+	EnableBanSync             bool   `json:"enable_ban_sync"`
+	EnableMuteSync            bool   `json:"enable_mute_sync"`
+	PlayerInfractionThreshold int    `json:"player_infraction_threshold"`
+	PlayerInfractionTimespan  int    `json:"player_infraction_timespan"`
+	ReportCommand             string `json:"report_command"`
 }
 
 func (body SetGameGeneralSettingsParams) Validate() error {
 	return ValidateStruct(&body,
 		validation.Field(&body.PlayerInfractionThreshold, validation.Required, validation.Min(0), validation.Max(math.MaxInt32)),
 		validation.Field(&body.PlayerInfractionTimespan, validation.Required, validation.Min(0), validation.Max(math.MaxInt32)),
+		validation.Field(&body.ReportCommand, validation.Length(0, 32),
+			validation.Match(regexp.MustCompile(`^\S*$`)).Error("must not contain whitespace")),
 	)
 }
+
+type SetGameEscalationSettingsParams struct {
+	Policies []*domain.EscalationPolicy `json:"policies"`
+}
+
+var infractionTypes = []interface{}{domain.InfractionTypeWarning, domain.InfractionTypeMute, domain.InfractionTypeKick,
+	domain.InfractionTypeBan}
+
+func (body SetGameEscalationSettingsParams) Validate() error {
+	if body.Policies == nil {
+		return buildManualError("policies", "", "this field is required")
+	}
+
+	if len(body.Policies) > 50 {
+		return buildManualError("policies", "", "a maximum of 50 policies can be set")
+	}
+
+	for idx, policy := range body.Policies {
+		if policy == nil {
+			return buildManualError("policies", "", &cmdFieldErrBody{Index: idx, Message: "policy is required"})
+		}
+
+		policy.ActionReason = strings.TrimSpace(policy.ActionReason)
+
+		err := validation.ValidateStruct(policy,
+			validation.Field(&policy.TriggerType, validation.Required, validation.In(infractionTypes...)),
+			validation.Field(&policy.TriggerCount, validation.Required, validation.Min(1), validation.Max(1000)),
+			validation.Field(&policy.Timespan, validation.Required, validation.Min(1), validation.Max(math.MaxInt32)),
+			validation.Field(&policy.ActionType, validation.Required, validation.In(infractionTypes...)),
+			validation.Field(&policy.ActionDuration, validation.Min(int64(-1)), validation.Max(int64(math.MaxInt32))),
+			validation.Field(&policy.ActionReason, validation.Length(0, 1024)),
+		)
+		if err != nil {
+			return buildManualError("policies", "", &cmdFieldErrBody{Index: idx, Message: err.Error()})
+		}
+
+		hasDuration := policy.ActionType == domain.InfractionTypeMute || policy.ActionType == domain.InfractionTypeBan
+		if hasDuration && policy.ActionDuration == 0 {
+			return buildManualError("policies", "", &cmdFieldErrBody{
+				Index:   idx,
+				Message: "action_duration: cannot be 0",
+			})
+		}
+	}
+
+	return nil
+}
@@ -35,7 +35,7 @@ type CreateGroupParams struct {
 
 const maxColor = 0xffffff
 
-var permissionsPattern = regexp.MustCompile("^[0-9]{1,20}$") // numbers only, max length 20
+var permissionsPattern = regexp.MustCompile("^[0-9]{1,255}$") // numbers only, max length 255
 
 func (body CreateGroupParams) Validate() error {
 	return ValidateStruct(&body,
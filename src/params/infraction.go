@@ -18,6 +18,7 @@
 package params
 
 import (
+	"Refractor/domain"
 	"Refractor/params/rules"
 	"fmt"
 	validation "github.com/go-ozzo/ozzo-validation"
@@ -47,10 +48,21 @@ var durationValidator = validation.By(func(val interface{}) error {
 	return nil
 })
 
+// infractionReasonRules returns the validation rules for the reason of a new infraction. The reason is optional if
+// the infraction references a rule since the rule's title is used as the reason.
+func infractionReasonRules(ruleID *int64) []validation.Rule {
+	if ruleID != nil {
+		return rules.InfractionReasonRules
+	}
+
+	return rules.InfractionReasonRules.Prepend(validation.Required)
+}
+
 type CreateWarningParams struct {
 	PlayerID       string                   `json:"player_id" form:"player_id"`
 	Platform       string                   `json:"platform" form:"platform"`
 	Reason         string                   `json:"reason" form:"reason"`
+	RuleID         *int64                   `json:"rule_id" form:"rule_id"`
 	Attachments    []CreateAttachmentParams `json:"attachments"`
 	LinkedMessages []int64                  `json:"linked_chat_messages"`
 }
@@ -74,7 +86,8 @@ func (body CreateWarningParams) Validate() error {
 	return ValidateStruct(&body,
 		validation.Field(&body.PlayerID, rules.PlayerIDRules.Prepend(validation.Required)...),
 		validation.Field(&body.Platform, rules.PlatformRules.Prepend(validation.Required)...),
-		validation.Field(&body.Reason, rules.InfractionReasonRules.Prepend(validation.Required)...),
+		validation.Field(&body.Reason, infractionReasonRules(body.RuleID)...),
+		validation.Field(&body.RuleID, validation.Min(1)),
 		validation.Field(&body.Attachments, attachmentArrValidator),
 	)
 }
@@ -83,6 +96,7 @@ type CreateMuteParams struct {
 	PlayerID       string                   `json:"player_id" form:"player_id"`
 	Platform       string                   `json:"platform" form:"platform"`
 	Reason         string                   `json:"reason" form:"reason"`
+	RuleID         *int64                   `json:"rule_id" form:"rule_id"`
 	Duration       *int                     `json:"duration" form:"duration"`
 	Attachments    []CreateAttachmentParams `json:"attachments"`
 	LinkedMessages []int64                  `json:"linked_chat_messages"`
@@ -96,7 +110,8 @@ func (body CreateMuteParams) Validate() error {
 	return ValidateStruct(&body,
 		validation.Field(&body.PlayerID, rules.PlayerIDRules.Prepend(validation.Required)...),
 		validation.Field(&body.Platform, rules.PlatformRules.Prepend(validation.Required)...),
-		validation.Field(&body.Reason, rules.InfractionReasonRules.Prepend(validation.Required)...),
+		validation.Field(&body.Reason, infractionReasonRules(body.RuleID)...),
+		validation.Field(&body.RuleID, validation.Min(1)),
 		validation.Field(&body.Duration, durationValidator),
 		validation.Field(&body.Attachments, attachmentArrValidator),
 	)
@@ -106,6 +121,7 @@ type CreateKickParams struct {
 	PlayerID       string                   `json:"player_id" form:"player_id"`
 	Platform       string                   `json:"platform" form:"platform"`
 	Reason         string                   `json:"reason" form:"reason"`
+	RuleID         *int64                   `json:"rule_id" form:"rule_id"`
 	Attachments    []CreateAttachmentParams `json:"attachments"`
 	LinkedMessages []int64                  `json:"linked_chat_messages"`
 }
@@ -118,7 +134,8 @@ func (body CreateKickParams) Validate() error {
 	return ValidateStruct(&body,
 		validation.Field(&body.PlayerID, rules.PlayerIDRules.Prepend(validation.Required)...),
 		validation.Field(&body.Platform, rules.PlatformRules.Prepend(validation.Required)...),
-		validation.Field(&body.Reason, rules.InfractionReasonRules.Prepend(validation.Required)...),
+		validation.Field(&body.Reason, infractionReasonRules(body.RuleID)...),
+		validation.Field(&body.RuleID, validation.Min(1)),
 		validation.Field(&body.Attachments, attachmentArrValidator),
 	)
 }
@@ -127,6 +144,7 @@ type CreateBanParams struct {
 	PlayerID       string                   `json:"player_id" form:"player_id"`
 	Platform       string                   `json:"platform" form:"platform"`
 	Reason         string                   `json:"reason" form:"reason"`
+	RuleID         *int64                   `json:"rule_id" form:"rule_id"`
 	Duration       *int                     `json:"duration" form:"duration"`
 	Attachments    []CreateAttachmentParams `json:"attachments"`
 	LinkedMessages []int64                  `json:"linked_chat_messages"`
@@ -140,29 +158,128 @@ func (body CreateBanParams) Validate() error {
 	return ValidateStruct(&body,
 		validation.Field(&body.PlayerID, rules.PlayerIDRules.Prepend(validation.Required)...),
 		validation.Field(&body.Platform, rules.PlatformRules.Prepend(validation.Required)...),
-		validation.Field(&body.Reason, rules.InfractionReasonRules.Prepend(validation.Required)...),
+		validation.Field(&body.Reason, infractionReasonRules(body.RuleID)...),
+		validation.Field(&body.RuleID, validation.Min(1)),
 		validation.Field(&body.Duration, durationValidator),
 		validation.Field(&body.Attachments, attachmentArrValidator),
 	)
 }
 
+// CreateCustomInfractionParams is used to create infractions of custom types. Whether a duration is required depends
+// on the type, so it is checked in the service.
+type CreateCustomInfractionParams struct {
+	PlayerID       string                   `json:"player_id" form:"player_id"`
+	Platform       string                   `json:"platform" form:"platform"`
+	Reason         string                   `json:"reason" form:"reason"`
+	RuleID         *int64                   `json:"rule_id" form:"rule_id"`
+	Duration       *int                     `json:"duration" form:"duration"`
+	Attachments    []CreateAttachmentParams `json:"attachments"`
+	LinkedMessages []int64                  `json:"linked_chat_messages"`
+}
+
+func (body CreateCustomInfractionParams) Validate() error {
+	body.PlayerID = strings.TrimSpace(body.PlayerID)
+	body.Platform = strings.TrimSpace(body.Platform)
+	body.Reason = strings.TrimSpace(body.Reason)
+
+	return ValidateStruct(&body,
+		validation.Field(&body.PlayerID, rules.PlayerIDRules.Prepend(validation.Required)...),
+		validation.Field(&body.Platform, rules.PlatformRules.Prepend(validation.Required)...),
+		validation.Field(&body.Reason, infractionReasonRules(body.RuleID)...),
+		validation.Field(&body.RuleID, validation.Min(1)),
+		validation.Field(&body.Duration, validation.By(func(val interface{}) error {
+			// duration is optional for custom types
+			if valPtr, _ := val.(*int); valPtr == nil {
+				return nil
+			}
+
+			return durationValidator.Validate(val)
+		})),
+		validation.Field(&body.Attachments, attachmentArrValidator),
+	)
+}
+
 type UpdateInfractionParams struct {
 	Reason   *string `json:"reason" form:"reason"`
 	Duration *int    `json:"duration" form:"duration"`
 	Repealed *bool   `json:"repealed" form:"repealed"`
+	RuleID   *int64  `json:"rule_id" form:"rule_id"` // RuleID 0 removes the infraction's rule
+	EditNote *string `json:"edit_note" form:"edit_note"`
 }
 
 func (body UpdateInfractionParams) Validate() error {
 	return ValidateStruct(&body,
 		validation.Field(&body.Reason, rules.InfractionReasonRules.Prepend(validation.By(stringPointerNotEmpty))...),
 		validation.Field(&body.Duration, rules.InfractionDurationRules...),
+		validation.Field(&body.RuleID, validation.Min(0)),
+		validation.Field(&body.EditNote, rules.InfractionEditNoteRules...),
 	)
 }
 
 type SetInfractionRepealedParams struct {
-	Repealed bool `json:"repealed" form:"repealed"`
+	Repealed bool   `json:"repealed" form:"repealed"`
+	EditNote string `json:"edit_note" form:"edit_note"`
 }
 
 func (body SetInfractionRepealedParams) Validate() error {
-	return nil
+	body.EditNote = strings.TrimSpace(body.EditNote)
+
+	return ValidateStruct(&body,
+		validation.Field(&body.EditNote, rules.InfractionEditNoteRules...),
+	)
+}
+
+type DeleteInfractionParams struct {
+	Reason string `json:"reason" form:"reason"`
+}
+
+func (body DeleteInfractionParams) Validate() error {
+	body.Reason = strings.TrimSpace(body.Reason)
+
+	return ValidateStruct(&body,
+		validation.Field(&body.Reason, rules.InfractionEditNoteRules...),
+	)
+}
+
+type PreviewInfractionCommandsParams struct {
+	Action     string `json:"action" form:"action"`
+	Type       string `json:"type" form:"type"`
+	PlayerID   string `json:"player_id" form:"player_id"`
+	Platform   string `json:"platform" form:"platform"`
+	PlayerName string `json:"player_name" form:"player_name"`
+	Reason     string `json:"reason" form:"reason"`
+	Duration   int    `json:"duration" form:"duration"`
+	RuleID     int64  `json:"rule_id" form:"rule_id"`
+}
+
+func (body PreviewInfractionCommandsParams) Validate() error {
+	body.PlayerID = strings.TrimSpace(body.PlayerID)
+	body.Platform = strings.TrimSpace(body.Platform)
+
+	return ValidateStruct(&body,
+		validation.Field(&body.Action, validation.Required, validation.In(domain.InfractionCommandCreate,
+			domain.InfractionCommandUpdate, domain.InfractionCommandDelete, domain.InfractionCommandRepeal,
+			domain.InfractionCommandSync, domain.InfractionCommandExpire)),
+		validation.Field(&body.Type, validation.Required, validation.By(infractionTypeName)),
+		validation.Field(&body.PlayerID, rules.PlayerIDRules.Prepend(validation.Required)...),
+		validation.Field(&body.Platform, rules.PlatformRules.Prepend(validation.Required)...),
+		validation.Field(&body.PlayerName, validation.Length(0, 128)),
+		validation.Field(&body.Reason, validation.Length(0, 1024)),
+		validation.Field(&body.Duration, rules.InfractionDurationRules...),
+		validation.Field(&body.RuleID, validation.Min(0)),
+	)
+}
+
+type ImportInfractionsParams struct {
+	ServerID   int64  `json:"server_id" form:"server_id"`
+	Format     string `json:"format" form:"format"`
+	MapIssuers bool   `json:"map_issuers" form:"map_issuers"`
+}
+
+func (body ImportInfractionsParams) Validate() error {
+	return ValidateStruct(&body,
+		validation.Field(&body.ServerID, validation.Required, validation.Min(1), validation.Max(math.MaxInt32)),
+		validation.Field(&body.Format, validation.In(domain.InfractionTransferFormatCSV,
+			domain.InfractionTransferFormatJSON)),
+	)
 }
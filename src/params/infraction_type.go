@@ -0,0 +1,131 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package params
+
+import (
+	"Refractor/domain"
+	"Refractor/params/validators"
+	"fmt"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"regexp"
+	"strings"
+)
+
+// customInfractionTypeNamePattern matches the names of custom infraction types, e.g. VOICE_MUTE.
+var customInfractionTypeNamePattern = regexp.MustCompile("^[A-Z][A-Z0-9_]{1,31}$")
+
+type CreateInfractionTypeParams struct {
+	Game                string   `json:"game" form:"game"`
+	Name                string   `json:"name" form:"name"`
+	DisplayName         string   `json:"display_name" form:"display_name"`
+	HasDuration         bool     `json:"has_duration" form:"has_duration"`
+	AllowPermanent      bool     `json:"allow_permanent" form:"allow_permanent"`
+	AllowedUpdateFields []string `json:"allowed_update_fields" form:"allowed_update_fields"`
+}
+
+func (body CreateInfractionTypeParams) Validate() error {
+	body.Name = strings.TrimSpace(body.Name)
+	body.DisplayName = strings.TrimSpace(body.DisplayName)
+
+	return ValidateStruct(&body,
+		validation.Field(&body.Game, validation.Required, validation.By(validators.ValueInStrArray(domain.AllGames))),
+		validation.Field(&body.Name, validation.Required, validation.Match(customInfractionTypeNamePattern),
+			validation.By(notBuiltInInfractionType)),
+		validation.Field(&body.DisplayName, validation.Required, validation.Length(1, 64)),
+		validation.Field(&body.AllowedUpdateFields, validation.By(infractionTypeUpdateFields)),
+	)
+}
+
+type UpdateInfractionTypeParams struct {
+	DisplayName         *string   `json:"display_name" form:"display_name"`
+	AllowPermanent      *bool     `json:"allow_permanent" form:"allow_permanent"`
+	AllowedUpdateFields *[]string `json:"allowed_update_fields" form:"allowed_update_fields"`
+}
+
+func (body UpdateInfractionTypeParams) Validate() error {
+	return ValidateStruct(&body,
+		validation.Field(&body.DisplayName, validation.By(stringPointerNotEmpty), validation.Length(1, 64)),
+		validation.Field(&body.AllowedUpdateFields, validation.By(infractionTypeUpdateFields)),
+	)
+}
+
+func notBuiltInInfractionType(val interface{}) error {
+	name, _ := val.(string)
+
+	if domain.IsBuiltInInfractionType(name) {
+		return fmt.Errorf("cannot be the name of a built-in infraction type")
+	}
+
+	return nil
+}
+
+// infractionTypeName is a custom validation rule which checks that a string or string pointer is the name of a built-in
+// infraction type or a valid custom infraction type name. Whether a custom type exists is checked in the service.
+func infractionTypeName(val interface{}) error {
+	var name string
+
+	switch v := val.(type) {
+	case string:
+		name = v
+	case *string:
+		if v == nil {
+			return nil
+		}
+		name = *v
+	default:
+		return nil
+	}
+
+	if name == "" || domain.IsBuiltInInfractionType(name) || customInfractionTypeNamePattern.MatchString(name) {
+		return nil
+	}
+
+	return fmt.Errorf("invalid infraction type")
+}
+
+// infractionTypeUpdateFields is a custom validation rule which checks that every field in a string slice or string slice
+// pointer can be allowed to be updated by a custom infraction type.
+func infractionTypeUpdateFields(val interface{}) error {
+	var fields []string
+
+	switch v := val.(type) {
+	case []string:
+		fields = v
+	case *[]string:
+		if v == nil {
+			return nil
+		}
+		fields = *v
+	}
+
+	for _, field := range fields {
+		allowed := false
+		for _, f := range domain.CustomInfractionTypeUpdateFields {
+			if field == f {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return fmt.Errorf("must only contain: %s", strings.Join(domain.CustomInfractionTypeUpdateFields, ", "))
+		}
+	}
+
+	return nil
+}
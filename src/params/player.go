@@ -0,0 +1,87 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package params
+
+import (
+	"Refractor/params/rules"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"strings"
+)
+
+type CreatePlayerNoteParams struct {
+	Note   string `json:"note" form:"note"`
+	Pinned bool   `json:"pinned" form:"pinned"`
+}
+
+func (body CreatePlayerNoteParams) Validate() error {
+	body.Note = strings.TrimSpace(body.Note)
+
+	return ValidateStruct(&body,
+		validation.Field(&body.Note, validation.Required, validation.Length(1, 4096)))
+}
+
+type UpdatePlayerNoteParams struct {
+	Note   *string `json:"note" form:"note"`
+	Pinned *bool   `json:"pinned" form:"pinned"`
+}
+
+func (body UpdatePlayerNoteParams) Validate() error {
+	return ValidateStruct(&body,
+		validation.Field(&body.Note, validation.By(stringPointerNotEmpty), validation.Length(1, 4096)))
+}
+
+type AddPlayerTagParams struct {
+	Tag string `json:"tag" form:"tag"`
+}
+
+func (body AddPlayerTagParams) Validate() error {
+	body.Tag = strings.TrimSpace(body.Tag)
+
+	return ValidateStruct(&body,
+		validation.Field(&body.Tag, validation.Required, validation.Length(1, 32)))
+}
+
+type WatchPlayerParams struct {
+	Reason   string `json:"reason" form:"reason"`
+	Duration int64  `json:"duration" form:"duration"` // Duration is in minutes. 0 means the watch does not expire.
+}
+
+func (body WatchPlayerParams) Validate() error {
+	body.Reason = strings.TrimSpace(body.Reason)
+
+	return ValidateStruct(&body,
+		validation.Field(&body.Reason, validation.Length(0, 1024)),
+		validation.Field(&body.Duration, validation.Min(0)))
+}
+
+type ConfirmPlayerLinkParams struct {
+	PlayerID string `json:"player_id" form:"player_id"`
+	Platform string `json:"platform" form:"platform"`
+	Note     string `json:"note" form:"note"`
+}
+
+func (body ConfirmPlayerLinkParams) Validate() error {
+	body.PlayerID = strings.TrimSpace(body.PlayerID)
+	body.Platform = strings.TrimSpace(body.Platform)
+	body.Note = strings.TrimSpace(body.Note)
+
+	return ValidateStruct(&body,
+		validation.Field(&body.PlayerID, rules.PlayerIDRules.Prepend(validation.Required)...),
+		validation.Field(&body.Platform, rules.PlatformRules.Prepend(validation.Required)...),
+		validation.Field(&body.Note, validation.Length(0, 1024)))
+}
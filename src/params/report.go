@@ -0,0 +1,92 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package params
+
+import (
+	"Refractor/domain"
+	"Refractor/params/rules"
+	"Refractor/params/validators"
+	"fmt"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"strings"
+)
+
+type SearchReportParams struct {
+	Status     *string `json:"status" form:"status"`
+	ServerID   *int64  `json:"server_id" form:"server_id"`
+	Platform   *string `json:"platform" form:"platform"`
+	TargetID   *string `json:"target_id" form:"target_id"`
+	ReporterID *string `json:"reporter_id" form:"reporter_id"`
+	*SearchParams
+}
+
+func (body SearchReportParams) Validate() error {
+	if body.SearchParams == nil {
+		return fmt.Errorf("no search params provided")
+	}
+	if err := body.SearchParams.Validate(); err != nil {
+		return err
+	}
+
+	return ValidateStruct(&body,
+		validation.Field(&body.Status, validation.By(validators.PtrValueInStrArray(domain.AllReportStatuses))),
+		validation.Field(&body.Platform, validation.By(validators.PtrValueInStrArray(domain.AllPlatforms))),
+		validation.Field(&body.TargetID, rules.PlayerIDRules...),
+		validation.Field(&body.ReporterID, rules.PlayerIDRules...),
+	)
+}
+
+type ResolveReportParams struct {
+	Resolution string `json:"resolution" form:"resolution"`
+}
+
+func (body ResolveReportParams) Validate() error {
+	body.Resolution = strings.TrimSpace(body.Resolution)
+
+	return ValidateStruct(&body,
+		validation.Field(&body.Resolution, validation.Length(0, 2048)))
+}
+
+// ConvertReportParams are the details of the infraction a report is converted into. The player, platform and server
+// of the infraction are taken from the report.
+type ConvertReportParams struct {
+	Type     string `json:"type" form:"type"`
+	Reason   string `json:"reason" form:"reason"`
+	RuleID   *int64 `json:"rule_id" form:"rule_id"`
+	Duration *int   `json:"duration" form:"duration"`
+}
+
+func (body ConvertReportParams) Validate() error {
+	body.Type = strings.TrimSpace(body.Type)
+	body.Reason = strings.TrimSpace(body.Reason)
+
+	return ValidateStruct(&body,
+		validation.Field(&body.Type, validation.Required, validation.Length(1, 32)),
+		validation.Field(&body.Reason, infractionReasonRules(body.RuleID)...),
+		validation.Field(&body.RuleID, validation.Min(1)),
+		validation.Field(&body.Duration, validation.By(func(val interface{}) error {
+			// duration is only required for mutes and bans, custom types are checked against their definition
+			if valPtr, _ := val.(*int); valPtr == nil && body.Type != domain.InfractionTypeMute &&
+				body.Type != domain.InfractionTypeBan {
+				return nil
+			}
+
+			return durationValidator.Validate(val)
+		})),
+	)
+}
@@ -0,0 +1,111 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package params
+
+import (
+	"Refractor/params/rules"
+	"fmt"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"strings"
+)
+
+type RuleSuggestionParams struct {
+	Offense  int    `json:"offense" form:"offense"`
+	Type     string `json:"type" form:"type"`
+	Duration *int   `json:"duration" form:"duration"`
+}
+
+var ruleSuggestionArrValidator = validation.Each(validation.By(func(value interface{}) error {
+	body, ok := value.(RuleSuggestionParams)
+	if !ok {
+		return fmt.Errorf("could not cast to RuleSuggestionParams")
+	}
+
+	return validation.ValidateStruct(&body,
+		validation.Field(&body.Offense, validation.Required, validation.Min(1), validation.Max(100)),
+		validation.Field(&body.Type, validation.Required, validation.By(infractionTypeName)),
+		validation.Field(&body.Duration, rules.InfractionDurationRules...))
+}))
+
+// uniqueRuleSuggestionOffenses is a custom validation rule which checks that no two suggestions in a slice or slice
+// pointer of suggestions are for the same offense.
+func uniqueRuleSuggestionOffenses(val interface{}) error {
+	var suggestions []RuleSuggestionParams
+
+	switch v := val.(type) {
+	case []RuleSuggestionParams:
+		suggestions = v
+	case *[]RuleSuggestionParams:
+		if v == nil {
+			return nil
+		}
+		suggestions = *v
+	default:
+		return nil
+	}
+
+	seen := map[int]bool{}
+	for _, s := range suggestions {
+		if seen[s.Offense] {
+			return fmt.Errorf("offense %d has more than one suggestion", s.Offense)
+		}
+
+		seen[s.Offense] = true
+	}
+
+	return nil
+}
+
+type CreateRuleParams struct {
+	Title       string                 `json:"title" form:"title"`
+	Description string                 `json:"description" form:"description"`
+	Suggestions []RuleSuggestionParams `json:"suggestions" form:"suggestions"`
+}
+
+func (body CreateRuleParams) Validate() error {
+	body.Title = strings.TrimSpace(body.Title)
+	body.Description = strings.TrimSpace(body.Description)
+
+	return ValidateStruct(&body,
+		validation.Field(&body.Title, validation.Required, validation.Length(1, 128)),
+		validation.Field(&body.Description, validation.Length(0, 4096)),
+		validation.Field(&body.Suggestions, validation.Length(0, 20), ruleSuggestionArrValidator,
+			validation.By(uniqueRuleSuggestionOffenses)),
+	)
+}
+
+type UpdateRuleParams struct {
+	Title       *string                 `json:"title" form:"title"`
+	Description *string                 `json:"description" form:"description"`
+	Suggestions *[]RuleSuggestionParams `json:"suggestions" form:"suggestions"`
+}
+
+func (body UpdateRuleParams) Validate() error {
+	return ValidateStruct(&body,
+		validation.Field(&body.Title, validation.By(stringPointerNotEmpty), validation.Length(1, 128)),
+		validation.Field(&body.Description, validation.Length(0, 4096)),
+		validation.Field(&body.Suggestions, validation.By(func(value interface{}) error {
+			suggestions, _ := value.(*[]RuleSuggestionParams)
+			if suggestions == nil {
+				return nil
+			}
+
+			return validation.Validate(*suggestions, validation.Length(0, 20), ruleSuggestionArrValidator)
+		}), validation.By(uniqueRuleSuggestionOffenses)),
+	)
+}
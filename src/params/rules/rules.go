@@ -39,6 +39,10 @@ var InfractionReasonRules = RuleGroup{
 	validation.Length(1, 1024),
 }
 
+var InfractionEditNoteRules = RuleGroup{
+	validation.Length(0, 512),
+}
+
 var InfractionDurationRules = RuleGroup{
 	validation.Min(-1),
 	validation.Max(math.MaxInt32),
@@ -47,7 +47,7 @@ type SearchPlayerParams struct {
 	*SearchParams
 }
 
-var validPlayerSearchTypes = []string{"name", "id"}
+var validPlayerSearchTypes = []string{"name", "id", "tag"}
 
 func (body SearchPlayerParams) Validate() error {
 	if body.SearchParams == nil {
@@ -87,11 +87,10 @@ type SearchInfractionParams struct {
 	Platform *string `json:"platform" form:"platform"`
 	ServerID *int64  `json:"server_id" form:"server_id"`
 	UserID   *string `json:"user_id" form:"user_id"`
+	RuleID   *int64  `json:"rule_id" form:"rule_id"`
 	*SearchParams
 }
 
-var validInfractionTypes = []string{domain.InfractionTypeWarning, domain.InfractionTypeMute, domain.InfractionTypeKick, domain.InfractionTypeBan}
-
 func (body SearchInfractionParams) Validate() error {
 	if body.SearchParams == nil {
 		return fmt.Errorf("no search params provided")
@@ -100,8 +99,12 @@ func (body SearchInfractionParams) Validate() error {
 		return err
 	}
 
+	return body.validateFilters()
+}
+
+func (body SearchInfractionParams) validateFilters() error {
 	return ValidateStruct(&body,
-		validation.Field(&body.Type, validation.By(validators.PtrValueInStrArray(validInfractionTypes))),
+		validation.Field(&body.Type, validation.By(infractionTypeName)),
 		validation.Field(&body.Game, validation.By(validators.PtrValueInStrArray(domain.AllGames))),
 		validation.Field(&body.PlayerID, rules.PlayerIDRules...),
 		validation.Field(&body.Platform, validation.By(validators.PtrValueInStrArray(domain.AllPlatforms)),
@@ -124,9 +127,28 @@ func (body SearchInfractionParams) Validate() error {
 				return nil
 			})),
 		validation.Field(&body.UserID, rules.UserIDRules...),
+		validation.Field(&body.RuleID, validation.Min(1)),
 	)
 }
 
+// ExportInfractionParams accepts the same filters as SearchInfractionParams. Limit and offset are ignored since every
+// matching infraction is exported.
+type ExportInfractionParams struct {
+	Format string `json:"format" form:"format"`
+	SearchInfractionParams
+}
+
+func (body ExportInfractionParams) Validate() error {
+	if err := ValidateStruct(&body,
+		validation.Field(&body.Format, validation.Required,
+			validation.By(validators.ValueInStrArray(domain.AllInfractionTransferFormats))),
+	); err != nil {
+		return err
+	}
+
+	return body.SearchInfractionParams.validateFilters()
+}
+
 type SearchMessagesParams struct {
 	PlayerID  *string `json:"player_id" form:"player_id"`
 	Platform  *string `json:"platform" form:"platform"`
@@ -135,6 +157,7 @@ type SearchMessagesParams struct {
 	StartDate *int64  `json:"start_date" form:"start_date"`
 	EndDate   *int64  `json:"end_date" form:"end_date"`
 	Query     *string `json:"query" form:"query"`
+	Channel   *string `json:"channel" form:"channel"`
 	*SearchParams
 }
 
@@ -198,5 +221,45 @@ func (body SearchMessagesParams) Validate() error {
 				return nil
 			})),
 		validation.Field(&body.Query, validation.Length(0, 128)),
+		validation.Field(&body.Channel, validation.By(validators.PtrValueInStrArray(domain.AllChatChannels))),
+	)
+}
+
+type SearchAppealParams struct {
+	Status       *string `json:"status" form:"status"`
+	InfractionID *int64  `json:"infraction_id" form:"infraction_id"`
+	PlayerID     *string `json:"player_id" form:"player_id"`
+	Platform     *string `json:"platform" form:"platform"`
+	ServerID     *int64  `json:"server_id" form:"server_id"`
+	*SearchParams
+}
+
+var validAppealStatuses = []string{domain.AppealStatusOpen, domain.AppealStatusAccepted, domain.AppealStatusRejected}
+
+func (body SearchAppealParams) Validate() error {
+	if body.SearchParams == nil {
+		return fmt.Errorf("no search params provided")
+	}
+	if err := body.SearchParams.Validate(); err != nil {
+		return err
+	}
+
+	return ValidateStruct(&body,
+		validation.Field(&body.Status, validation.By(validators.PtrValueInStrArray(validAppealStatuses))),
+		validation.Field(&body.PlayerID, rules.PlayerIDRules...),
+		validation.Field(&body.Platform, validation.By(validators.PtrValueInStrArray(domain.AllPlatforms)),
+			validation.By(func(value interface{}) error {
+				// if body.PlayerID is set then platform is required
+				if body.PlayerID == nil {
+					return nil
+				}
+
+				platformPtr, ok := value.(*string)
+				if !ok || platformPtr == nil || len(strings.TrimSpace(*platformPtr)) == 0 {
+					return errors.New("platform is required if player_id is set")
+				}
+
+				return nil
+			})),
 	)
 }
@@ -19,6 +19,10 @@ package api
 
 import (
 	"Refractor/domain"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
 )
 
 func ValidateRequestBody(body domain.Validable) (bool, error) {
@@ -29,3 +33,17 @@ func ValidateRequestBody(body domain.Validable) (bool, error) {
 
 	return true, nil
 }
+
+// GetPlayerParams returns the platform and player ID path parameters. An error is returned if the platform is invalid.
+func GetPlayerParams(c echo.Context) (string, string, error) {
+	platform := strings.ToLower(c.Param("platform"))
+	id := c.Param("id")
+
+	for _, p := range domain.AllPlatforms {
+		if p == platform {
+			return platform, id, nil
+		}
+	}
+
+	return "", "", domain.NewHTTPError(fmt.Errorf("invalid platform"), http.StatusBadRequest, "Invalid platform")
+}
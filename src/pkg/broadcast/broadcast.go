@@ -38,6 +38,10 @@ const (
 	TypeBan  = "BAN"
 )
 
+// FieldActionType is set on moderator action broadcasts (bans, kicks and mutes) so that subscribers can tell
+// which type of action was taken.
+const FieldActionType = "ActionType"
+
 func GetBroadcastType(broadcast string, patterns map[string]*regexp.Regexp) *Broadcast {
 	for bcastType, pattern := range patterns {
 		if pattern.MatchString(broadcast) {
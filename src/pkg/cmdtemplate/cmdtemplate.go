@@ -0,0 +1,262 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Package cmdtemplate renders the command strings configured in a game's command settings.
+//
+// Command templates use Go's text/template syntax. Values are exposed as fields on the template data (e.g
+// {{.PlayerID}}) and a set of helper functions are available for quoting, formatting durations, truncating text and
+// so on. The legacy placeholders ({{PLAYER_ID}}, {{REASON}}, etc) are still supported so that existing command settings
+// keep working.
+//
+// Template output is never parsed again, so user supplied values such as infraction reasons can't inject template
+// actions or other placeholders into a command. Control characters (including newlines) are stripped from all values
+// to stop them from splitting a single command into several.
+package cmdtemplate
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxOutputLength is the maximum length of a rendered command.
+const MaxOutputLength = 1024
+
+// errOutputTooLong is returned when a template writes more than MaxOutputLength bytes.
+var errOutputTooLong = fmt.Errorf("rendered command is longer than %d characters", MaxOutputLength)
+
+// limitedWriter is a buffer which fails once more than limit bytes are written to it. Template execution stops at the
+// first failed write, so templates which loop or recurse can't produce unbounded output.
+type limitedWriter struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.buf.Len()+len(p) > w.limit {
+		return 0, errOutputTooLong
+	}
+
+	return w.buf.Write(p)
+}
+
+// Data is the data made available to command templates.
+type Data struct {
+	PlayerID          string
+	Platform          string
+	PlayerName        string
+	Issuer            string
+	Reason            string
+	Rule              string // Rule is the title of the rule which was broken. It is empty if no rule was referenced.
+	Type              string
+	Duration          int64 // Duration is the infraction's duration in minutes
+	DurationRemaining int64 // DurationRemaining is the infraction's remaining duration in minutes
+}
+
+// Permanent returns true if the infraction is permanent.
+func (d *Data) Permanent() bool {
+	return d.Duration < 0
+}
+
+// sampleData is used to test execute templates during validation.
+var sampleData = &Data{
+	PlayerID:          "76561198000000000",
+	Platform:          "playfab",
+	PlayerName:        "Player",
+	Issuer:            "Moderator",
+	Reason:            "Reason",
+	Rule:              "Rule",
+	Type:              "BAN",
+	Duration:          60,
+	DurationRemaining: 30,
+}
+
+// Render renders the command template text using the provided data.
+func Render(text string, data *Data) (string, error) {
+	data = sanitize(data)
+
+	tmpl, err := parse(text, data)
+	if err != nil {
+		return "", err
+	}
+
+	// Surrounding whitespace is trimmed after execution, so it also counts towards the output limit
+	w := &limitedWriter{limit: MaxOutputLength}
+	if err := tmpl.Execute(w, data); err != nil {
+		return "", err
+	}
+
+	out := strings.TrimSpace(w.buf.String())
+
+	if strings.IndexFunc(out, unicode.IsControl) != -1 {
+		return "", fmt.Errorf("rendered command contains control characters")
+	}
+
+	return out, nil
+}
+
+// Validate checks that the command template text can be parsed and executed. A template which renders to an empty
+// command is also considered invalid.
+func Validate(text string) error {
+	out, err := Render(text, sampleData)
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		return fmt.Errorf("template renders an empty command")
+	}
+
+	return nil
+}
+
+func parse(text string, data *Data) (*template.Template, error) {
+	return template.New("command").Funcs(helperFuncs()).Funcs(legacyFuncs(data)).Parse(text)
+}
+
+// legacyFuncs returns functions which map the legacy placeholders to the provided data.
+func legacyFuncs(data *Data) template.FuncMap {
+	return template.FuncMap{
+		"PLAYER_ID":          func() string { return data.PlayerID },
+		"PLATFORM":           func() string { return data.Platform },
+		"PLAYER_NAME":        func() string { return data.PlayerName },
+		"ISSUER":             func() string { return data.Issuer },
+		"REASON":             func() string { return data.Reason },
+		"RULE":               func() string { return data.Rule },
+		"DURATION":           func() int64 { return data.Duration },
+		"DURATION_REMAINING": func() int64 { return data.DurationRemaining },
+	}
+}
+
+func helperFuncs() template.FuncMap {
+	return template.FuncMap{
+		"quote":    quote,
+		"squote":   singleQuote,
+		"escape":   escape,
+		"duration": humanDuration,
+		"truncate": truncate,
+		"default":  defaultValue,
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"trim":     strings.TrimSpace,
+		"replace":  replace,
+	}
+}
+
+func sanitize(data *Data) *Data {
+	clean := *data
+
+	clean.PlayerID = StripControl(clean.PlayerID)
+	clean.Platform = StripControl(clean.Platform)
+	clean.PlayerName = StripControl(clean.PlayerName)
+	clean.Issuer = StripControl(clean.Issuer)
+	clean.Reason = StripControl(clean.Reason)
+	clean.Rule = StripControl(clean.Rule)
+	clean.Type = StripControl(clean.Type)
+
+	return &clean
+}
+
+// StripControl replaces the control characters (including newlines) in s with spaces so that s can't split a command
+// into several.
+func StripControl(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+
+		return r
+	}, s)
+}
+
+// escape escapes backslashes and double quotes inside of s.
+func escape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
+
+// quote escapes s and wraps it in double quotes.
+func quote(s string) string {
+	return `"` + escape(s) + `"`
+}
+
+// singleQuote wraps s in single quotes. Single quotes and backslashes inside of s are escaped.
+func singleQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `'` + strings.ReplaceAll(s, `'`, `\'`) + `'`
+}
+
+// humanDuration formats a duration in minutes as a human readable string, e.g "1d 2h 30m". Negative durations are
+// considered permanent.
+func humanDuration(minutes int64) string {
+	if minutes < 0 {
+		return "permanent"
+	}
+
+	if minutes == 0 {
+		return "0m"
+	}
+
+	days := minutes / (60 * 24)
+	hours := minutes / 60 % 24
+	mins := minutes % 60
+
+	parts := make([]string, 0, 3)
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+
+	if mins > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", mins))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// truncate shortens s to at most length characters.
+func truncate(length int, s string) string {
+	if length < 0 {
+		length = 0
+	}
+
+	if utf8.RuneCountInString(s) <= length {
+		return s
+	}
+
+	return string([]rune(s)[:length])
+}
+
+// defaultValue returns def if s is empty or only contains whitespace.
+func defaultValue(def, s string) string {
+	if strings.TrimSpace(s) == "" {
+		return def
+	}
+
+	return s
+}
+
+// replace replaces all instances of old inside of s with new.
+func replace(old, new, s string) string {
+	return strings.ReplaceAll(s, old, new)
+}
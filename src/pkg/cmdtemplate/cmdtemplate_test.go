@@ -0,0 +1,159 @@
+/*
+ * This file is part of Refractor.
+ *
+ * Refractor is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+package cmdtemplate
+
+import (
+	"github.com/franela/goblin"
+	. "github.com/onsi/gomega"
+	"strings"
+	"testing"
+)
+
+func Test(t *testing.T) {
+	g := goblin.Goblin(t)
+
+	// Special hook for gomega
+	RegisterFailHandler(func(m string, _ ...int) { g.Fail(m) })
+
+	g.Describe("Render()", func() {
+		var data *Data
+
+		g.BeforeEach(func() {
+			data = &Data{
+				PlayerID:          "playerid",
+				Platform:          "platform",
+				PlayerName:        "Player Name",
+				Issuer:            "Moderator",
+				Reason:            "spamming \"chat\"",
+				Type:              "BAN",
+				Duration:          1530,
+				DurationRemaining: 90,
+			}
+		})
+
+		g.It("Should render legacy placeholders", func() {
+			out, err := Render("Ban {{PLAYER_ID}} {{DURATION}} {{REASON}}", data)
+
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Ban playerid 1530 spamming \"chat\""))
+		})
+
+		g.It("Should render the rule placeholder", func() {
+			data.Rule = "No cheating"
+
+			out, err := Render("Ban {{PLAYER_ID}} {{DURATION}} {{RULE | default \"No rule\"}}", data)
+
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Ban playerid 1530 No cheating"))
+		})
+
+		g.It("Should render fields and helpers", func() {
+			out, err := Render("Ban {{.PlayerID}} {{duration .Duration}} {{.Reason | quote}}", data)
+
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal(`Ban playerid 1d 1h 30m "spamming \"chat\""`))
+		})
+
+		g.It("Should support conditionals", func() {
+			tmpl := "Ban {{.PlayerID}}{{if .Permanent}} permanently{{else}} {{.Duration}}{{end}}"
+
+			out, err := Render(tmpl, data)
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Ban playerid 1530"))
+
+			data.Duration = -1
+			out, err = Render(tmpl, data)
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Ban playerid permanently"))
+		})
+
+		g.It("Should not expand placeholders inside of values", func() {
+			data.Reason = "{{DURATION}} {{.PlayerID}}"
+
+			out, err := Render("Kick {{REASON}}", data)
+
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Kick {{DURATION}} {{.PlayerID}}"))
+		})
+
+		g.It("Should strip control characters from values", func() {
+			data.Reason = "line one\nline two"
+
+			out, err := Render("Kick {{.PlayerID}} {{.Reason}}", data)
+
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Kick playerid line one line two"))
+		})
+
+		g.It("Should truncate values", func() {
+			out, err := Render("Kick {{.Reason | truncate 8}}", data)
+
+			Expect(err).To(BeNil())
+			Expect(out).To(Equal("Kick spamming"))
+		})
+
+		g.It("Should return an error for unknown functions", func() {
+			_, err := Render("Kick {{UNKNOWN}}", data)
+
+			Expect(err).ToNot(BeNil())
+		})
+
+		g.It("Should return an error if the command is too long", func() {
+			_, err := Render("Kick {{.PlayerID}} "+strings.Repeat("x", MaxOutputLength), data)
+
+			Expect(err).To(Equal(errOutputTooLong))
+		})
+
+		g.It("Should stop executing templates once the output is too long", func() {
+			// Without the output limit this recurses until text/template's depth limit is reached
+			tmpl := `{{define "loop"}}{{.PlayerID}}{{template "loop" .}}{{end}}{{template "loop" .}}`
+
+			_, err := Render(tmpl, data)
+
+			Expect(err).To(Equal(errOutputTooLong))
+		})
+	})
+
+	g.Describe("Validate()", func() {
+		g.It("Should accept valid templates", func() {
+			Expect(Validate("Ban {{PLAYER_ID}} {{.Duration}} {{.Reason | default \"No reason\" | quote}}")).To(BeNil())
+		})
+
+		g.It("Should reject templates which can't be parsed", func() {
+			Expect(Validate("Ban {{.PlayerID")).ToNot(BeNil())
+		})
+
+		g.It("Should reject templates referencing unknown fields", func() {
+			Expect(Validate("Ban {{.SteamID}}")).ToNot(BeNil())
+		})
+
+		g.It("Should reject templates which render an empty command", func() {
+			Expect(Validate("{{if false}}Ban{{end}}")).ToNot(BeNil())
+		})
+	})
+
+	g.Describe("humanDuration()", func() {
+		g.It("Should format durations", func() {
+			Expect(humanDuration(-1)).To(Equal("permanent"))
+			Expect(humanDuration(0)).To(Equal("0m"))
+			Expect(humanDuration(45)).To(Equal("45m"))
+			Expect(humanDuration(60)).To(Equal("1h"))
+			Expect(humanDuration(60*24*2 + 5)).To(Equal("2d 5m"))
+		})
+	})
+}
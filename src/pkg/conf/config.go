@@ -23,6 +23,7 @@ import (
 	"github.com/joho/godotenv"
 	"log"
 	"os"
+	"strconv"
 )
 
 // Config stores all configuration of Refractor.
@@ -39,6 +40,20 @@ type Config struct {
 	SmtpConnectionUri   string `mapstructure:"SMTP_CONNECTION_URI"`
 	SmtpFromAddress     string `mapstructure:"SMTP_FROM_ADDRESS"`
 	EncryptionKey       string `mapstructure:"ENCRYPTION_KEY"`
+	AttachmentStorage   string `mapstructure:"ATTACHMENT_STORAGE_PATH"`
+
+	// FederationSigningKey is the base64 encoded ed25519 seed used to sign the exported ban feed, for example the
+	// output of `openssl rand -base64 32`. Ban list sharing is disabled if it is not set.
+	FederationSigningKey string `mapstructure:"FEDERATION_SIGNING_KEY"`
+	FederationName       string `mapstructure:"FEDERATION_INSTANCE_NAME"`
+
+	// FederationFeedKey is the shared key partner instances must send to fetch the ban feed. Ban list sharing is
+	// disabled if it is not set.
+	FederationFeedKey string `mapstructure:"FEDERATION_FEED_KEY"`
+
+	// DeletedInfractionRetentionDays is how many days deleted infractions are kept in the trash before they are purged.
+	// Purging is disabled if it is set to 0. Defaults to 30.
+	DeletedInfractionRetentionDays int `mapstructure:"DELETED_INFRACTION_RETENTION_DAYS"`
 }
 
 // LoadConfig reads configuration from a file or environment variables.
@@ -73,6 +88,29 @@ func LoadConfig() (*Config, error) {
 		SmtpConnectionUri:   os.Getenv("SMTP_CONNECTION_URI"),
 		SmtpFromAddress:     os.Getenv("SMTP_FROM_ADDRESS"),
 		EncryptionKey:       os.Getenv("ENCRYPTION_KEY"),
+		AttachmentStorage:   os.Getenv("ATTACHMENT_STORAGE_PATH"),
+
+		FederationSigningKey: os.Getenv("FEDERATION_SIGNING_KEY"),
+		FederationName:       os.Getenv("FEDERATION_INSTANCE_NAME"),
+		FederationFeedKey:    os.Getenv("FEDERATION_FEED_KEY"),
+	}
+
+	if config.AttachmentStorage == "" {
+		config.AttachmentStorage = "./attachments"
+	}
+
+	if config.FederationName == "" {
+		config.FederationName = "Refractor"
+	}
+
+	config.DeletedInfractionRetentionDays = 30
+	if retention := os.Getenv("DELETED_INFRACTION_RETENTION_DAYS"); retention != "" {
+		days, err := strconv.Atoi(retention)
+		if err != nil || days < 0 {
+			return nil, fmt.Errorf("deleted infraction retention days must be a positive integer or 0")
+		}
+
+		config.DeletedInfractionRetentionDays = days
 	}
 
 	if len(config.EncryptionKey) != 32 {
@@ -19,8 +19,10 @@ package perms
 
 import (
 	"Refractor/pkg/bitperms"
+	"fmt"
 	"math/big"
 	"regexp"
+	"sync"
 )
 
 // perms is a package which provides supporting functionality for Refractor's binary Permission system.
@@ -45,6 +47,18 @@ const (
 	FlagReadLiveChat            = FlagName("FLAG_READ_LIVE_CHAT")
 	FlagSendLiveChat            = FlagName("FLAG_SEND_LIVE_CHAT")
 	FlagModerateFlaggedMessages = FlagName("FLAG_MODERATE_FLAGGED_MESSAGES")
+	FlagUseRCONConsole          = FlagName("FLAG_USE_RCON_CONSOLE")
+	FlagViewAppeals             = FlagName("FLAG_VIEW_APPEALS")
+	FlagCreateAppeals           = FlagName("FLAG_CREATE_APPEALS")
+	FlagDecideAppeals           = FlagName("FLAG_DECIDE_APPEALS")
+	FlagViewPlayerNotes         = FlagName("FLAG_VIEW_PLAYER_NOTES")
+	FlagEditPlayerNotes         = FlagName("FLAG_EDIT_PLAYER_NOTES")
+	FlagWatchPlayers            = FlagName("FLAG_WATCH_PLAYERS")
+	FlagLinkPlayers             = FlagName("FLAG_LINK_PLAYERS")
+	FlagManageFederation        = FlagName("FLAG_MANAGE_FEDERATION")
+	FlagImportExportInfractions = FlagName("FLAG_IMPORT_EXPORT_INFRACTIONS")
+	FlagManageRules             = FlagName("FLAG_MANAGE_RULES")
+	FlagHandleReports           = FlagName("FLAG_HANDLE_REPORTS")
 )
 
 type FlagName string
@@ -82,6 +96,16 @@ var permissions = map[FlagName]*Permission{}
 var permissionsArr []*Permission
 var defaultPermissions *bitperms.Permissions
 
+// permissionsMu guards permissions and permissionsArr since custom permissions can be registered at runtime.
+var permissionsMu sync.RWMutex
+
+// CustomFlagOffset is the bit of the first custom permission. Bits below it are reserved for the built-in permissions
+// so that adding new built-in permissions never shifts the flags of custom ones.
+const CustomFlagOffset = 128
+
+// MaxCustomFlags is the number of bits available for custom permissions.
+const MaxCustomFlags = 512
+
 func init() {
 	// Register Permission permissions
 	/////////////////////////////////////////////////////
@@ -210,6 +234,90 @@ func init() {
 						  the Flagged Messages page.`,
 			Scope: ScopeAny,
 		},
+		{
+			Name:        FlagUseRCONConsole,
+			DisplayName: "Use RCON console",
+			Description: `Allows users to run arbitrary RCON commands on a server through the console. Commands may be
+						  further restricted by the console rules of the user's groups. This permission can be
+						  overridden on servers.`,
+			Scope: ScopeAny,
+		},
+		{
+			Name:        FlagViewAppeals,
+			DisplayName: "View appeals",
+			Description: `Allows users to view and search infraction appeals and comment on them. This permission can be
+						  overridden on servers.`,
+			Scope: ScopeAny,
+		},
+		{
+			Name:        FlagCreateAppeals,
+			DisplayName: "Create appeals",
+			Description: `Allows users to record appeals against infractions on behalf of players. This permission can
+						  be overridden on servers.`,
+			Scope: ScopeAny,
+		},
+		{
+			Name:        FlagDecideAppeals,
+			DisplayName: "Decide appeals",
+			Description: `Allows users to accept or reject appeals. Accepting an appeal repeals the appealed infraction.
+						  This permission can be overridden on servers.`,
+			Scope: ScopeAny,
+		},
+		{
+			Name:        FlagViewPlayerNotes,
+			DisplayName: "View player notes",
+			Description: `Allows viewing of staff notes and tags on player records.`,
+			Scope:       ScopeApp,
+		},
+		{
+			Name:        FlagEditPlayerNotes,
+			DisplayName: "Edit player notes",
+			Description: `Allows users to add notes and tags to player records, and to edit or delete the notes they
+						  wrote. Administrators can edit or delete any note.`,
+			Scope: ScopeApp,
+		},
+		{
+			Name:        FlagWatchPlayers,
+			DisplayName: "Watch players",
+			Description: `Allows users to add players to or remove players from the watch list. Staff are alerted when
+						  a watched player joins a server or sends a chat message.`,
+			Scope: ScopeApp,
+		},
+		{
+			Name:        FlagLinkPlayers,
+			DisplayName: "Link players",
+			Description: `Allows users to confirm or remove links between player accounts which belong to the same
+						  person. Bans are synced across confirmed links.`,
+			Scope: ScopeApp,
+		},
+		{
+			Name:        FlagManageFederation,
+			DisplayName: "Manage ban list sharing",
+			Description: `Allows users to add, edit or remove the ban feeds of partner Refractor instances, and to set
+						  how much each one is trusted.`,
+			Scope: ScopeApp,
+		},
+		{
+			Name:        FlagImportExportInfractions,
+			DisplayName: "Import and export infractions",
+			Description: `Allows users to export infraction records as CSV or JSON files, and to import historical
+						  infractions from other moderation tools.`,
+			Scope: ScopeApp,
+		},
+		{
+			Name:        FlagManageRules,
+			DisplayName: "Manage rules",
+			Description: `Allows users to create, edit and delete rules in the rule catalogue, including the punishments
+						  suggested for breaking them.`,
+			Scope: ScopeApp,
+		},
+		{
+			Name:        FlagHandleReports,
+			DisplayName: "Handle reports",
+			Description: `Allows users to view, claim and resolve reports made by players in-game, and to turn them
+						  into infractions. This permission can be overridden on servers.`,
+			Scope: ScopeAny,
+		},
 		// ADD NEW FLAGS HERE. Do not touch any of the above permissions!
 	})
 
@@ -232,6 +340,10 @@ func init() {
 func registerPermissions(newPerms []Permission) {
 	var i uint = 0
 
+	if len(newPerms) > CustomFlagOffset {
+		panic("built-in permissions overlap the custom permission range")
+	}
+
 	for _, perm := range newPerms {
 		next := bitperms.GetFlag(i)
 		i++
@@ -250,20 +362,93 @@ func registerPermissions(newPerms []Permission) {
 	}
 }
 
-// GetFlag returns a Permission's integer value.
+// RegisterCustomPermission registers a permission which is not built into Refractor, such as the permission to create
+// infractions of a custom type. The permission's flag is set at CustomFlagOffset + index, so the same index must always
+// be used for the same permission. If a permission with the same name is already registered it is replaced.
+func RegisterCustomPermission(perm Permission, index uint) error {
+	if index >= MaxCustomFlags {
+		return fmt.Errorf("custom permission index %d is out of range", index)
+	}
+
+	bit := CustomFlagOffset + index
+
+	permissionsMu.Lock()
+	defer permissionsMu.Unlock()
+
+	for _, p := range permissionsArr {
+		if p.ID == int(bit)+1 && p.Name != perm.Name {
+			return fmt.Errorf("custom permission index %d is already used by %s", index, p.Name)
+		}
+	}
+
+	removePermission(perm.Name)
+
+	newPermission := &Permission{
+		ID:          int(bit) + 1,
+		Name:        perm.Name,
+		DisplayName: perm.DisplayName,
+		Description: perm.Description,
+		Flag:        bitperms.GetFlag(bit),
+		Scope:       perm.Scope,
+	}
+
+	permissions[perm.Name] = newPermission
+	permissionsArr = append(permissionsArr, newPermission)
+
+	return nil
+}
+
+// UnregisterCustomPermission removes a permission registered with RegisterCustomPermission.
+func UnregisterCustomPermission(name FlagName) {
+	permissionsMu.Lock()
+	defer permissionsMu.Unlock()
+
+	removePermission(name)
+}
+
+func removePermission(name FlagName) {
+	if _, ok := permissions[name]; !ok {
+		return
+	}
+
+	delete(permissions, name)
+
+	for i, p := range permissionsArr {
+		if p.Name == name {
+			permissionsArr = append(permissionsArr[:i:i], permissionsArr[i+1:]...)
+			break
+		}
+	}
+}
+
+// GetFlag returns a Permission's integer value. If no permission with the provided name is registered, nil is returned.
 func GetFlag(flag FlagName) *big.Int {
-	return permissions[flag].Flag
+	permissionsMu.RLock()
+	defer permissionsMu.RUnlock()
+
+	perm := permissions[flag]
+	if perm == nil {
+		return nil
+	}
+
+	return perm.Flag
 }
 
 func GetAll() []*Permission {
-	return permissionsArr
+	permissionsMu.RLock()
+	defer permissionsMu.RUnlock()
+
+	return append([]*Permission{}, permissionsArr...)
 }
 
 var whitespacePattern = regexp.MustCompile("\\s+")
 
 // GetDescription returns a Permission's human readable Description with newline and tab characters stripped off.
 func GetDescription(flag FlagName) string {
+	permissionsMu.RLock()
 	desc := permissions[flag].Description
+	permissionsMu.RUnlock()
+
 	desc = whitespacePattern.ReplaceAllString(desc, " ")
 
 	return desc
@@ -277,7 +462,7 @@ func GetDefaultPermissions() *bitperms.Permissions {
 // scope. For example, if the specified scope was ScopeServer and FlagAdministrator was set, FlagAdministrator would
 // be unset since it does not match ScopeServer.
 func FilterToScope(permissions *bitperms.Permissions, s Scope) *bitperms.Permissions {
-	for _, p := range permissionsArr {
+	for _, p := range GetAll() {
 		if !permissions.CheckFlag(p.Flag) {
 			// If permissions does not have this flag, continue to the next flag
 			continue
@@ -293,7 +478,7 @@ func FilterToScope(permissions *bitperms.Permissions, s Scope) *bitperms.Permiss
 }
 
 func Filter(permissions *bitperms.Permissions, filterFunc func(p *Permission) bool) *bitperms.Permissions {
-	for _, p := range permissionsArr {
+	for _, p := range GetAll() {
 		if !permissions.CheckFlag(p.Flag) {
 			continue
 		}
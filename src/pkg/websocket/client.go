@@ -27,28 +27,31 @@ import (
 )
 
 type ChatSendHandler func(body *SendChatBody)
+type ConsoleCommandHandler func(body *domain.ConsoleCommandBody)
 
 type Client struct {
-	ID              int64
-	UserID          string
-	Conn            net.Conn
-	Pool            *Pool
-	ChatSendHandler ChatSendHandler
-	logger          *zap.Logger
+	ID                    int64
+	UserID                string
+	Conn                  net.Conn
+	Pool                  *Pool
+	ChatSendHandler       ChatSendHandler
+	ConsoleCommandHandler ConsoleCommandHandler
+	logger                *zap.Logger
 }
 
 var nextClientID int64 = 0
 
-func NewClient(userID string, conn net.Conn, pool *Pool, csh ChatSendHandler, log *zap.Logger) *Client {
+func NewClient(userID string, conn net.Conn, pool *Pool, csh ChatSendHandler, cch ConsoleCommandHandler, log *zap.Logger) *Client {
 	nextClientID++
 
 	return &Client{
-		ID:              nextClientID,
-		UserID:          userID,
-		Conn:            conn,
-		Pool:            pool,
-		ChatSendHandler: csh,
-		logger:          log,
+		ID:                    nextClientID,
+		UserID:                userID,
+		Conn:                  conn,
+		Pool:                  pool,
+		ChatSendHandler:       csh,
+		ConsoleCommandHandler: cch,
+		logger:                log,
 	}
 }
 
@@ -163,6 +166,35 @@ func (c *Client) Read() {
 			c.ChatSendHandler(body)
 		}
 
+		if msg.Type == "console-command" {
+			data, err := json.Marshal(msg.Body)
+			if err != nil {
+				c.logger.Error(
+					"Could not marshal console command",
+					zap.Int64("Client ID", c.ID),
+					zap.String("User ID", c.UserID),
+					zap.Error(err),
+				)
+				continue
+			}
+
+			body := &domain.ConsoleCommandBody{}
+			if err := json.Unmarshal(data, body); err != nil {
+				c.logger.Error(
+					"Could not unmarshal console command body",
+					zap.Int64("Client ID", c.ID),
+					zap.String("User ID", c.UserID),
+					zap.Error(err),
+				)
+				continue
+			}
+
+			body.UserID = c.UserID
+
+			// Commands can take a while to respond so they're run in their own routine to avoid blocking reads
+			go c.ConsoleCommandHandler(body)
+		}
+
 		c.logger.Info(
 			"Received message from client",
 			zap.Int64("Client ID", c.ID),